@@ -175,6 +175,7 @@ export interface GrafanaConfig {
   helpEnabled: boolean;
   profileEnabled: boolean;
   ldapEnabled: boolean;
+  twoFactorEnabled: boolean;
   sigV4AuthEnabled: boolean;
   samlEnabled: boolean;
   autoAssignOrg: boolean;
@@ -52,6 +52,7 @@ export class GrafanaBootConfig implements GrafanaConfig {
   helpEnabled = false;
   profileEnabled = false;
   ldapEnabled = false;
+  twoFactorEnabled = false;
   jwtHeaderName = '';
   jwtUrlLogin = false;
   sigV4AuthEnabled = false;
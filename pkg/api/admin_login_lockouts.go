@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /admin/login-lockouts admin_users adminGetLoginLockouts
+//
+// Return the usernames and IP subnets which are temporarily blocked from logging in after too many failed login attempts.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users:read` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminGetLoginLockoutsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminGetLoginLockouts(c *models.ReqContext) response.Response {
+	query := models.SearchLoginLockoutsQuery{ActiveAt: time.Now()}
+	if err := hs.SQLStore.SearchLoginLockouts(c.Req.Context(), &query); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get login lockouts", err)
+	}
+
+	return response.JSON(http.StatusOK, query.Result)
+}
+
+// swagger:route DELETE /admin/login-lockouts/{lockout_id} admin_users adminDeleteLoginLockout
+//
+// Remove a lockout and the failed login attempts of its source, allowing the username or the IP subnet to log in again.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users:write` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) AdminDeleteLoginLockout(c *models.ReqContext) response.Response {
+	lockoutID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	if err := hs.SQLStore.DeleteLoginLockout(c.Req.Context(), &models.DeleteLoginLockoutCommand{Id: lockoutID}); err != nil {
+		if errors.Is(err, models.ErrLoginLockoutNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to delete login lockout", err)
+	}
+
+	return response.Success("Login lockout removed")
+}
+
+// swagger:route POST /admin/users/{user_id}/unlock admin_users adminUnlockUser
+//
+// Unlock a user which is temporarily blocked from logging in after too many failed login attempts.
+//
+// The failed login attempts of the user are removed as well. Lockouts of the IP subnets the attempts came from are not removed.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users:write` and scope `global.users:1` (userIDScope).
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) AdminUnlockUser(c *models.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	usr, err := hs.userService.GetByID(c.Req.Context(), &user.GetUserByIDQuery{ID: userID})
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return response.Error(http.StatusNotFound, user.ErrUserNotFound.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+
+	// failed attempts are recorded with the username as typed in, which is either the login or the email
+	cmd := models.ResetUserLoginAttemptsCommand{Usernames: []string{usr.Login}}
+	if usr.Email != "" && usr.Email != usr.Login {
+		cmd.Usernames = append(cmd.Usernames, usr.Email)
+	}
+	if err := hs.SQLStore.ResetUserLoginAttempts(c.Req.Context(), &cmd); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to unlock user", err)
+	}
+
+	return response.Success("User unlocked")
+}
+
+// swagger:parameters adminDeleteLoginLockout
+type AdminDeleteLoginLockoutParams struct {
+	// in:path
+	// required:true
+	LockoutID int64 `json:"lockout_id"`
+}
+
+// swagger:parameters adminUnlockUser
+type AdminUnlockUserParams struct {
+	// in:path
+	// required:true
+	UserID int64 `json:"user_id"`
+}
+
+// swagger:response adminGetLoginLockoutsResponse
+type AdminGetLoginLockoutsResponse struct {
+	// in:body
+	Body []*models.LoginLockout `json:"body"`
+}
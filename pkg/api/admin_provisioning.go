@@ -107,3 +107,26 @@ func (hs *HTTPServer) AdminProvisioningReloadAlerting(c *models.ReqContext) resp
 	}
 	return response.Success("Alerting config reloaded")
 }
+
+// swagger:route POST /admin/provisioning/access-control/reload admin_provisioning adminProvisioningReloadAccessControl
+//
+// Reload custom role provisioning configurations.
+//
+// Reloads the provisioning config files for custom roles again. It won’t return until the new provisioned roles are already stored in the database.
+// If you have role based access control enabled, you need to have a permission with action `provisioning:reload` and scope `provisioners:accesscontrol`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminProvisioningReloadAccessControl(c *models.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionAccessControl(c.Req.Context())
+	if err != nil {
+		return response.Error(500, "Failed to reload access control config", err)
+	}
+	return response.Success("Access control config reloaded")
+}
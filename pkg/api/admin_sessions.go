@@ -0,0 +1,207 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /admin/sessions admin_users adminSearchSessions
+//
+// Search the active sessions of all users.
+//
+// Sessions can be filtered by user, by client IP address or CIDR range and by user agent.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users.authtoken:read` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminSearchSessionsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminSearchSessions(c *models.ReqContext) response.Response {
+	perPage := c.QueryInt("perpage")
+	if perPage <= 0 {
+		perPage = 100
+	}
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+
+	query := models.SearchUserTokensQuery{
+		UserTokenFilter: models.UserTokenFilter{
+			UserId:    c.QueryInt64("userId"),
+			ClientIp:  c.Query("ip"),
+			UserAgent: c.Query("userAgent"),
+		},
+		Page:  page,
+		Limit: perPage,
+	}
+	result, err := hs.AuthTokenService.SearchUserTokens(c.Req.Context(), &query)
+	if errors.Is(err, models.ErrInvalidUserTokenFilter) {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search sessions", err)
+	}
+
+	sessions := make([]*dtos.AdminSession, 0, len(result.Tokens))
+	for _, hit := range result.Tokens {
+		isActive := c.UserToken != nil && c.UserToken.Id == hit.Id
+		sessions = append(sessions, &dtos.AdminSession{
+			UserToken: *newUserTokenDTO(&hit.UserToken, isActive),
+			UserId:    hit.UserId,
+			Login:     hit.Login,
+			Email:     hit.Email,
+			UserAgent: hit.UserAgent,
+		})
+	}
+
+	return response.JSON(http.StatusOK, dtos.AdminSearchSessionsResult{
+		TotalCount: result.TotalCount,
+		Sessions:   sessions,
+		Page:       page,
+		PerPage:    perPage,
+	})
+}
+
+// swagger:route DELETE /admin/sessions/{session_id} admin_users adminRevokeSession
+//
+// Revoke a session of any user, who is required to log in again upon next activity.
+//
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users.authtoken:write` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) AdminRevokeSession(c *models.ReqContext) response.Response {
+	sessionID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil || sessionID <= 0 {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	if c.UserToken != nil && c.UserToken.Id == sessionID {
+		return response.Error(http.StatusBadRequest, "Cannot revoke active user auth token", nil)
+	}
+
+	cmd := models.RevokeUserTokensCommand{UserTokenFilter: models.UserTokenFilter{Id: sessionID}}
+	count, err := hs.AuthTokenService.RevokeUserTokens(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to revoke session", err)
+	}
+	if count == 0 {
+		return response.Error(http.StatusNotFound, models.ErrUserTokenNotFound.Error(), nil)
+	}
+
+	return response.Success("Session revoked")
+}
+
+// swagger:route POST /admin/sessions/revoke admin_users adminRevokeSessions
+//
+// Revoke all the sessions matching a filter, such as the sessions from an IP address range or a user agent.
+//
+// The session of the signed in user is never revoked. At least one filter is required.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users.authtoken:write` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminRevokeSessionsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminRevokeSessions(c *models.ReqContext) response.Response {
+	cmd := models.RevokeUserTokensCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.IsEmpty() {
+		return response.Error(http.StatusBadRequest, "At least one of userId, clientIp and userAgent is required", nil)
+	}
+	if c.UserToken != nil {
+		cmd.ExcludedTokenId = c.UserToken.Id
+	}
+
+	count, err := hs.AuthTokenService.RevokeUserTokens(c.Req.Context(), &cmd)
+	if errors.Is(err, models.ErrInvalidUserTokenFilter) {
+		return response.Error(http.StatusBadRequest, err.Error(), nil)
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to revoke sessions", err)
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"message": "Sessions revoked",
+		"count":   count,
+	})
+}
+
+// swagger:parameters adminSearchSessions
+type AdminSearchSessionsParams struct {
+	// in:query
+	// required:false
+	UserID int64 `json:"userId"`
+	// Client IP address, or CIDR range such as 10.0.0.0/24
+	// in:query
+	// required:false
+	IP string `json:"ip"`
+	// Case-insensitive part of the user agent
+	// in:query
+	// required:false
+	UserAgent string `json:"userAgent"`
+	// in:query
+	// required:false
+	// default:1
+	Page int `json:"page"`
+	// in:query
+	// required:false
+	// default:100
+	PerPage int `json:"perpage"`
+}
+
+// swagger:parameters adminRevokeSession
+type AdminRevokeSessionParams struct {
+	// in:path
+	// required:true
+	SessionID int64 `json:"session_id"`
+}
+
+// swagger:parameters adminRevokeSessions
+type AdminRevokeSessionsParams struct {
+	// in:body
+	// required:true
+	Body models.UserTokenFilter `json:"body"`
+}
+
+// swagger:response adminSearchSessionsResponse
+type AdminSearchSessionsResponse struct {
+	// in:body
+	Body dtos.AdminSearchSessionsResult `json:"body"`
+}
+
+// swagger:response adminRevokeSessionsResponse
+type AdminRevokeSessionsResponse struct {
+	// in:body
+	Body struct {
+		Message string `json:"message"`
+		Count   int64  `json:"count"`
+	} `json:"body"`
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestAdminSessionsAPIEndpoint(t *testing.T) {
+	currentToken := &models.UserToken{Id: 5}
+
+	adminSessionsScenario(t, "When searching sessions", "GET", "/api/admin/sessions", "/api/admin/sessions", currentToken, nil,
+		func(hs *HTTPServer) func(c *models.ReqContext) response.Response { return hs.AdminSearchSessions },
+		func(sc *scenarioContext) {
+			var query *models.SearchUserTokensQuery
+			sc.userAuthTokenService.SearchUserTokensProvider = func(ctx context.Context, q *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error) {
+				query = q
+				return &models.SearchUserTokensResult{
+					TotalCount: 1,
+					Tokens: []*models.UserTokenSearchHit{
+						{
+							UserToken: models.UserToken{
+								Id:        5,
+								UserId:    2,
+								ClientIp:  "10.0.0.1",
+								UserAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/72.0.3626.119 Safari/537.36",
+								CreatedAt: time.Now().Unix(),
+							},
+							Login: "editor",
+							Email: "editor@example.com",
+						},
+					},
+				}, nil
+			}
+
+			sc.fakeReqWithParams("GET", sc.url, map[string]string{"userId": "2", "ip": "10.0.0.0/16", "userAgent": "chrome", "perpage": "10"}).exec()
+			require.Equal(t, http.StatusOK, sc.resp.Code)
+
+			require.NotNil(t, query)
+			assert.Equal(t, models.UserTokenFilter{UserId: 2, ClientIp: "10.0.0.0/16", UserAgent: "chrome"}, query.UserTokenFilter)
+			assert.Equal(t, 1, query.Page)
+			assert.Equal(t, 10, query.Limit)
+
+			result := sc.ToJSON()
+			assert.Equal(t, int64(1), result.Get("totalCount").MustInt64())
+			session := result.Get("sessions").GetIndex(0)
+			assert.Equal(t, int64(5), session.Get("id").MustInt64())
+			assert.True(t, session.Get("isActive").MustBool())
+			assert.Equal(t, int64(2), session.Get("userId").MustInt64())
+			assert.Equal(t, "editor", session.Get("login").MustString())
+			assert.Equal(t, "Chrome", session.Get("browser").MustString())
+		})
+
+	adminSessionsScenario(t, "When revoking a session", "DELETE", "/api/admin/sessions/3", "/api/admin/sessions/:id", currentToken, nil,
+		func(hs *HTTPServer) func(c *models.ReqContext) response.Response { return hs.AdminRevokeSession },
+		func(sc *scenarioContext) {
+			var cmd *models.RevokeUserTokensCommand
+			sc.userAuthTokenService.RevokeUserTokensProvider = func(ctx context.Context, c *models.RevokeUserTokensCommand) (int64, error) {
+				cmd = c
+				return 1, nil
+			}
+
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+			assert.Equal(t, http.StatusOK, sc.resp.Code)
+			require.NotNil(t, cmd)
+			assert.Equal(t, int64(3), cmd.Id)
+		})
+
+	adminSessionsScenario(t, "When revoking a missing session", "DELETE", "/api/admin/sessions/3", "/api/admin/sessions/:id", currentToken, nil,
+		func(hs *HTTPServer) func(c *models.ReqContext) response.Response { return hs.AdminRevokeSession },
+		func(sc *scenarioContext) {
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+			assert.Equal(t, http.StatusNotFound, sc.resp.Code)
+		})
+
+	adminSessionsScenario(t, "When revoking the current session", "DELETE", "/api/admin/sessions/5", "/api/admin/sessions/:id", currentToken, nil,
+		func(hs *HTTPServer) func(c *models.ReqContext) response.Response { return hs.AdminRevokeSession },
+		func(sc *scenarioContext) {
+			sc.userAuthTokenService.RevokeUserTokensProvider = func(ctx context.Context, c *models.RevokeUserTokensCommand) (int64, error) {
+				t.Fatal("the current session should not be revoked")
+				return 0, nil
+			}
+
+			sc.fakeReqWithParams("DELETE", sc.url, map[string]string{}).exec()
+			assert.Equal(t, http.StatusBadRequest, sc.resp.Code)
+		})
+
+	adminSessionsScenario(t, "When revoking sessions by filter", "POST", "/api/admin/sessions/revoke", "/api/admin/sessions/revoke", currentToken,
+		models.UserTokenFilter{ClientIp: "10.0.0.0/16"},
+		func(hs *HTTPServer) func(c *models.ReqContext) response.Response { return hs.AdminRevokeSessions },
+		func(sc *scenarioContext) {
+			var cmd *models.RevokeUserTokensCommand
+			sc.userAuthTokenService.RevokeUserTokensProvider = func(ctx context.Context, c *models.RevokeUserTokensCommand) (int64, error) {
+				cmd = c
+				return 2, nil
+			}
+
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			require.Equal(t, http.StatusOK, sc.resp.Code)
+			require.NotNil(t, cmd)
+			assert.Equal(t, "10.0.0.0/16", cmd.ClientIp)
+			assert.Equal(t, currentToken.Id, cmd.ExcludedTokenId)
+			assert.Equal(t, int64(2), sc.ToJSON().Get("count").MustInt64())
+		})
+
+	adminSessionsScenario(t, "When revoking sessions without a filter", "POST", "/api/admin/sessions/revoke", "/api/admin/sessions/revoke", currentToken,
+		models.UserTokenFilter{},
+		func(hs *HTTPServer) func(c *models.ReqContext) response.Response { return hs.AdminRevokeSessions },
+		func(sc *scenarioContext) {
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			assert.Equal(t, http.StatusBadRequest, sc.resp.Code)
+		})
+
+	adminSessionsScenario(t, "When revoking sessions with an invalid IP filter", "POST", "/api/admin/sessions/revoke", "/api/admin/sessions/revoke", currentToken,
+		models.UserTokenFilter{ClientIp: "10.0."},
+		func(hs *HTTPServer) func(c *models.ReqContext) response.Response { return hs.AdminRevokeSessions },
+		func(sc *scenarioContext) {
+			sc.userAuthTokenService.RevokeUserTokensProvider = func(ctx context.Context, c *models.RevokeUserTokensCommand) (int64, error) {
+				return 0, models.ErrInvalidUserTokenFilter
+			}
+
+			sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+			assert.Equal(t, http.StatusBadRequest, sc.resp.Code)
+		})
+}
+
+func adminSessionsScenario(t *testing.T, desc string, method string, url string, routePattern string, token *models.UserToken,
+	body interface{}, handler func(hs *HTTPServer) func(c *models.ReqContext) response.Response, fn scenarioFunc) {
+	t.Run(fmt.Sprintf("%s %s", desc, url), func(t *testing.T) {
+		fakeAuthTokenService := auth.NewFakeUserAuthTokenService()
+
+		hs := &HTTPServer{
+			AuthTokenService: fakeAuthTokenService,
+		}
+
+		sc := setupScenarioContext(t, url)
+		sc.userAuthTokenService = fakeAuthTokenService
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			if body != nil {
+				c.Req.Body = mockRequestBody(body)
+			}
+			sc.context = c
+			sc.context.UserId = 1
+			sc.context.UserToken = token
+			sc.context.IsGrafanaAdmin = true
+
+			return handler(hs)(c)
+		})
+
+		sc.m.Handle(method, routePattern, []web.Handler{sc.defaultHandler})
+
+		fn(sc)
+	})
+}
@@ -166,6 +166,34 @@ func TestAdminAPIEndpoint(t *testing.T) {
 			})
 	})
 
+	t.Run("When a server admin unlocks a user", func(t *testing.T) {
+		mockUserService := usertest.NewUserServiceFake()
+		mockUserService.ExpectedUser = &user.User{ID: 42, Login: "user", Email: "user@example.com"}
+
+		adminUnlockUserScenario(t, "Should reset the login attempts of the login and the email", "/api/admin/users/42/unlock",
+			"/api/admin/users/:id/unlock", func(sc *scenarioContext) {
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+				assert.Equal(t, 200, sc.resp.Code)
+				cmd := sc.sqlStore.(*mockstore.SQLStoreMock).LastResetLoginAttempts
+				require.NotNil(t, cmd)
+				assert.Equal(t, []string{"user", "user@example.com"}, cmd.Usernames)
+			}, mockUserService)
+	})
+
+	t.Run("When a server admin unlocks a non-existing user", func(t *testing.T) {
+		mockUserService := usertest.NewUserServiceFake()
+		mockUserService.ExpectedError = user.ErrUserNotFound
+
+		adminUnlockUserScenario(t, "Should return not found when calling POST on", "/api/admin/users/42/unlock",
+			"/api/admin/users/:id/unlock", func(sc *scenarioContext) {
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+				assert.Equal(t, 404, sc.resp.Code)
+				assert.Nil(t, sc.sqlStore.(*mockstore.SQLStoreMock).LastResetLoginAttempts)
+			}, mockUserService)
+	})
+
 	t.Run("When a server admin attempts to create a user", func(t *testing.T) {
 		t.Run("Without an organization", func(t *testing.T) {
 			createCmd := dtos.AdminCreateUserForm{
@@ -375,6 +403,28 @@ func adminDisableUserScenario(t *testing.T, desc string, action string, url stri
 	})
 }
 
+func adminUnlockUserScenario(t *testing.T, desc string, url string, routePattern string, fn scenarioFunc, userService *usertest.FakeUserService) {
+	t.Run(fmt.Sprintf("%s %s", desc, url), func(t *testing.T) {
+		hs := HTTPServer{
+			SQLStore:    mockstore.NewSQLStoreMock(),
+			userService: userService,
+		}
+
+		sc := setupScenarioContext(t, url)
+		sc.sqlStore = hs.SQLStore
+		sc.defaultHandler = routing.Wrap(func(c *models.ReqContext) response.Response {
+			sc.context = c
+			sc.context.UserId = testUserID
+
+			return hs.AdminUnlockUser(c)
+		})
+
+		sc.m.Post(routePattern, sc.defaultHandler)
+
+		fn(sc)
+	})
+}
+
 func adminDeleteUserScenario(t *testing.T, desc string, url string, routePattern string, fn scenarioFunc) {
 	hs := HTTPServer{
 		SQLStore:    mockstore.NewSQLStoreMock(),
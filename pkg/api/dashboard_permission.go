@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,7 +12,9 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -188,6 +191,11 @@ func (hs *HTTPServer) UpdateDashboardPermissions(c *models.ReqContext) response.
 		return response.Error(403, "Cannot remove own admin permission for a folder", nil)
 	}
 
+	if hs.Cfg.Audit.Enabled {
+		audit.SetResourceUID(c.Req.Context(), dash.Uid)
+		auditACLChanges(c.Req.Context(), g, items)
+	}
+
 	if !hs.AccessControl.IsDisabled() {
 		old, err := g.GetACL()
 		if err != nil {
@@ -210,6 +218,39 @@ func (hs *HTTPServer) UpdateDashboardPermissions(c *models.ReqContext) response.
 	return response.Success("Dashboard permissions updated")
 }
 
+// auditACLChanges attaches the permission changes to the audit event of the request
+func auditACLChanges(ctx context.Context, g guardian.DashboardGuardian, items []*models.DashboardACL) {
+	old, err := g.GetACL()
+	if err != nil {
+		return
+	}
+
+	before := map[string]string{}
+	for _, item := range old {
+		if item.Inherited {
+			continue
+		}
+		before[aclSubject(item.UserId, item.TeamId, item.Role)] = item.Permission.String()
+	}
+	after := map[string]string{}
+	for _, item := range items {
+		after[aclSubject(item.UserID, item.TeamID, item.Role)] = item.Permission.String()
+	}
+	audit.SetDiff(ctx, audit.DiffSummary(before, after))
+}
+
+func aclSubject(userID, teamID int64, role *org.RoleType) string {
+	switch {
+	case userID > 0:
+		return fmt.Sprintf("user:%d", userID)
+	case teamID > 0:
+		return fmt.Sprintf("team:%d", teamID)
+	case role != nil:
+		return "role:" + string(*role)
+	}
+	return ""
+}
+
 // updateDashboardAccessControl is used for api backward compatibility
 func (hs *HTTPServer) updateDashboardAccessControl(ctx context.Context, orgID int64, uid string, isFolder bool, items []*models.DashboardACL, old []*models.DashboardACLInfoDTO) error {
 	commands := []accesscontrol.SetResourcePermissionCommand{}
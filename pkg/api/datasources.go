@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/datasources/permissions"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -371,6 +372,7 @@ func (hs *HTTPServer) AddDataSource(c *models.ReqContext) response.Response {
 	}
 
 	ds := hs.convertModelToDtos(c.Req.Context(), cmd.Result)
+	audit.SetResourceUID(c.Req.Context(), ds.UID)
 	return response.JSON(http.StatusOK, util.DynMap{
 		"message":    "Datasource added",
 		"id":         cmd.Result.Id,
@@ -468,6 +470,11 @@ func (hs *HTTPServer) updateDataSourceByID(c *models.ReqContext, ds *datasources
 		return response.Error(403, "Cannot update read-only data source", nil)
 	}
 
+	var before dtos.DataSource
+	if hs.Cfg.Audit.Enabled {
+		before = hs.convertModelToDtos(c.Req.Context(), ds)
+	}
+
 	err := hs.DataSourcesService.UpdateDataSource(c.Req.Context(), &cmd)
 	if err != nil {
 		if errors.Is(err, datasources.ErrDataSourceUpdatingOldVersion) {
@@ -493,6 +500,10 @@ func (hs *HTTPServer) updateDataSourceByID(c *models.ReqContext, ds *datasources
 	}
 
 	datasourceDTO := hs.convertModelToDtos(c.Req.Context(), query.Result)
+	audit.SetResourceUID(c.Req.Context(), datasourceDTO.UID)
+	if hs.Cfg.Audit.Enabled {
+		audit.SetDiff(c.Req.Context(), audit.DiffSummary(before, datasourceDTO))
+	}
 
 	hs.Live.HandleDatasourceUpdate(c.OrgId, datasourceDTO.UID)
 
@@ -14,3 +14,19 @@ type UserToken struct {
 	CreatedAt              time.Time `json:"createdAt"`
 	SeenAt                 time.Time `json:"seenAt"`
 }
+
+// AdminSession is a session of any user, listed by server admins.
+type AdminSession struct {
+	UserToken
+	UserId    int64  `json:"userId"`
+	Login     string `json:"login"`
+	Email     string `json:"email"`
+	UserAgent string `json:"userAgent"`
+}
+
+type AdminSearchSessionsResult struct {
+	TotalCount int64           `json:"totalCount"`
+	Sessions   []*AdminSession `json:"sessions"`
+	Page       int             `json:"page"`
+	PerPage    int             `json:"perPage"`
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	"github.com/grafana/grafana/pkg/util"
@@ -135,6 +136,11 @@ func (hs *HTTPServer) UpdateFolderPermissions(c *models.ReqContext) response.Res
 		return response.Error(403, "Cannot remove own admin permission for a folder", nil)
 	}
 
+	if hs.Cfg.Audit.Enabled {
+		audit.SetResourceUID(c.Req.Context(), folder.Uid)
+		auditACLChanges(c.Req.Context(), g, items)
+	}
+
 	if !hs.AccessControl.IsDisabled() {
 		old, err := g.GetACL()
 		if err != nil {
@@ -233,6 +233,18 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext) response.Response {
 
 	usr = authQuery.User
 
+	if authModule == twoFactorAuthModule && hs.twoFactorEnabled() {
+		challengeResp, err := hs.twoFactorChallenge(c, usr)
+		if err != nil {
+			resp = response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+			return resp
+		}
+		if challengeResp != nil {
+			resp = challengeResp
+			return resp
+		}
+	}
+
 	err = hs.loginUserWithUser(usr, c)
 	if err != nil {
 		var createTokenErr *models.CreateTokenErr
@@ -244,21 +256,8 @@ func (hs *HTTPServer) LoginPost(c *models.ReqContext) response.Response {
 		return resp
 	}
 
-	result := map[string]interface{}{
-		"message": "Logged in",
-	}
-
-	if redirectTo := c.GetCookie("redirect_to"); len(redirectTo) > 0 {
-		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
-			result["redirectUrl"] = redirectTo
-		} else {
-			c.Logger.Info("Ignored invalid redirect_to cookie value.", "url", redirectTo)
-		}
-		cookies.DeleteCookie(c.Resp, "redirect_to", hs.CookieOptionsFromCfg)
-	}
-
 	metrics.MApiLoginPost.Inc()
-	resp = response.JSON(http.StatusOK, result)
+	resp = response.JSON(http.StatusOK, hs.loginRedirectResult(c))
 	return resp
 }
 
@@ -359,15 +358,18 @@ func (hs *HTTPServer) RedirectResponseWithError(ctx *models.ReqContext, err erro
 }
 
 func (hs *HTTPServer) samlEnabled() bool {
-	return hs.SettingsProvider.KeyValue("auth.saml", "enabled").MustBool(false) && hs.License.FeatureEnabled("saml")
+	return hs.SAMLService != nil && hs.SAMLService.IsEnabled()
 }
 
 func (hs *HTTPServer) samlName() string {
-	return hs.SettingsProvider.KeyValue("auth.saml", "name").MustString("SAML")
+	if hs.SAMLService == nil {
+		return "SAML"
+	}
+	return hs.SAMLService.Name()
 }
 
 func (hs *HTTPServer) samlSingleLogoutEnabled() bool {
-	return hs.samlEnabled() && hs.SettingsProvider.KeyValue("auth.saml", "single_logout").MustBool(false) && hs.samlEnabled()
+	return hs.samlEnabled() && hs.SAMLService.SingleLogoutEnabled()
 }
 
 func getLoginExternalError(err error) string {
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/middleware/cookies"
+	"github.com/grafana/grafana/pkg/models"
+	loginService "github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	samlLogger = log.New("saml")
+
+	errInvalidSAMLResponse = errors.New("invalid SAML response")
+)
+
+const SAMLRequestIDCookieName = "saml_request_id"
+
+func (hs *HTTPServer) registerSAMLRoutes() {
+	r := hs.RouteRegister
+	r.Get("/saml/metadata", hs.SAMLMetadata)
+	r.Post("/saml/acs", hs.SAMLACS)
+	r.Get("/saml/slo", hs.SAMLSingleLogout)
+	r.Post("/saml/slo", hs.SAMLSingleLogout)
+	r.Get("/login/saml", hs.SAMLLogin)
+	r.Get("/logout/saml", hs.SAMLLogout)
+
+	// the IdP posts to these endpoints from its own origin
+	hs.Csrf.AddSafeEndpoint(hs.samlEndpointPath("/saml/acs"))
+	hs.Csrf.AddSafeEndpoint(hs.samlEndpointPath("/saml/slo"))
+}
+
+func (hs *HTTPServer) samlEndpointPath(path string) string {
+	if hs.Cfg.ServeFromSubPath {
+		return hs.Cfg.AppSubURL + path
+	}
+	return path
+}
+
+// samlCookieOptions keeps the request ID cookie available to the cross-site
+// POST sent by the IdP to the assertion consumer service.
+func (hs *HTTPServer) samlCookieOptions() cookies.CookieOptions {
+	options := hs.CookieOptionsFromCfg()
+	if options.Secure {
+		options.SameSiteDisabled = false
+		options.SameSiteMode = http.SameSiteNoneMode
+	} else {
+		options.SameSiteDisabled = true
+	}
+	return options
+}
+
+// SAMLMetadata serves the service provider metadata to register Grafana in the IdP.
+func (hs *HTTPServer) SAMLMetadata(c *models.ReqContext) {
+	metadata, err := hs.SAMLService.Metadata(c.Req.Context())
+	if err != nil {
+		c.Handle(hs.Cfg, http.StatusInternalServerError, "Failed to build SAML metadata", err)
+		return
+	}
+	c.Resp.Header().Set("Content-Type", "application/samlmetadata+xml")
+	c.Resp.WriteHeader(http.StatusOK)
+	if _, err := c.Resp.Write(metadata); err != nil {
+		samlLogger.Error("Failed to write SAML metadata", "error", err)
+	}
+}
+
+// SAMLLogin starts a SP initiated login by redirecting to the IdP.
+func (hs *HTTPServer) SAMLLogin(c *models.ReqContext) {
+	loginInfo := models.LoginInfo{AuthModule: loginService.SAMLAuthModule}
+
+	redirectURL, requestID, err := hs.SAMLService.AuthnRequestURL(c.Req.Context())
+	if err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, err)
+		return
+	}
+
+	cookies.WriteCookie(c.Resp, SAMLRequestIDCookieName, requestID, hs.Cfg.OAuthCookieMaxAge, hs.samlCookieOptions)
+	c.Redirect(redirectURL)
+}
+
+// SAMLACS is the assertion consumer service receiving the IdP response of
+// both SP and IdP initiated logins.
+func (hs *HTTPServer) SAMLACS(c *models.ReqContext) {
+	loginInfo := models.LoginInfo{AuthModule: loginService.SAMLAuthModule}
+
+	requestID := c.GetCookie(SAMLRequestIDCookieName)
+	cookies.DeleteCookie(c.Resp, SAMLRequestIDCookieName, hs.samlCookieOptions)
+
+	assertion, err := hs.SAMLService.ParseResponse(c.Req, requestID)
+	if err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, errInvalidSAMLResponse, "error", err)
+		return
+	}
+
+	extUser, err := hs.SAMLService.ExternalUserInfo(assertion)
+	if err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, err)
+		return
+	}
+	loginInfo.ExternalUser = *extUser
+
+	loginInfo.User, err = hs.syncSAMLUser(c, extUser)
+	if err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, err)
+		return
+	}
+
+	if err := hs.loginUserWithUser(loginInfo.User, c); err != nil {
+		hs.handleOAuthLoginErrorWithRedirect(c, loginInfo, err)
+		return
+	}
+
+	loginInfo.HTTPStatus = http.StatusOK
+	hs.HooksService.RunLoginHook(&loginInfo, c)
+	metrics.MApiLoginSAML.Inc()
+
+	if redirectTo, err := url.QueryUnescape(c.GetCookie("redirect_to")); err == nil && len(redirectTo) > 0 {
+		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
+			cookies.DeleteCookie(c.Resp, "redirect_to", hs.CookieOptionsFromCfg)
+			c.Redirect(redirectTo)
+			return
+		}
+		c.Logger.Debug("Ignored invalid redirect_to cookie value", "redirect_to", redirectTo)
+	}
+
+	c.Redirect(setting.AppSubUrl + "/")
+}
+
+// syncSAMLUser creates or updates the Grafana user of the assertion, syncing
+// roles and, through the login service, team memberships.
+func (hs *HTTPServer) syncSAMLUser(c *models.ReqContext, extUser *models.ExternalUserInfo) (*user.User, error) {
+	cmd := &models.UpsertUserCommand{
+		ReqContext:    c,
+		ExternalUser:  extUser,
+		SignupAllowed: hs.SAMLService.IsSignupAllowed(),
+		UserLookupParams: models.UserLookupParams{
+			Email: &extUser.Email,
+			Login: &extUser.Login,
+		},
+	}
+
+	if err := hs.Login.UpsertUser(c.Req.Context(), cmd); err != nil {
+		return nil, err
+	}
+
+	// Do not expose disabled status,
+	// just show incorrect user credentials error (see #17947)
+	if cmd.Result.IsDisabled {
+		samlLogger.Warn("User is disabled", "user", cmd.Result.Login)
+		return nil, login.ErrInvalidCredentials
+	}
+
+	return cmd.Result, nil
+}
+
+// SAMLLogout ends the Grafana session and asks the IdP to end its session.
+func (hs *HTTPServer) SAMLLogout(c *models.ReqContext) {
+	authInfoQuery := models.GetAuthInfoQuery{UserId: c.UserId, AuthModule: loginService.SAMLAuthModule}
+	authInfoErr := hs.authInfoService.GetAuthInfo(c.Req.Context(), &authInfoQuery)
+
+	if c.UserToken != nil {
+		if err := hs.AuthTokenService.RevokeToken(c.Req.Context(), c.UserToken, false); err != nil && !errors.Is(err, models.ErrUserTokenNotFound) {
+			samlLogger.Error("Failed to revoke auth token", "error", err)
+		}
+	}
+	cookies.WriteSessionCookie(c, hs.Cfg, "", -1)
+
+	if authInfoErr != nil {
+		c.Redirect(hs.Cfg.AppSubURL + "/login")
+		return
+	}
+
+	redirectURL, err := hs.SAMLService.LogoutRequestURL(c.Req.Context(), authInfoQuery.Result.AuthId)
+	if err != nil {
+		samlLogger.Error("Failed to create SAML logout request", "error", err)
+		c.Redirect(hs.Cfg.AppSubURL + "/login")
+		return
+	}
+	c.Redirect(redirectURL)
+}
+
+// SAMLSingleLogout receives both the IdP response to a SP initiated logout
+// and IdP initiated logout requests.
+func (hs *HTTPServer) SAMLSingleLogout(c *models.ReqContext) {
+	if err := c.Req.ParseForm(); err != nil {
+		c.Handle(hs.Cfg, http.StatusBadRequest, "Invalid SAML logout message", err)
+		return
+	}
+
+	if c.Req.Form.Get("SAMLResponse") != "" {
+		if err := hs.SAMLService.ValidateLogoutResponse(c.Req); err != nil {
+			samlLogger.Warn("Invalid SAML logout response", "error", err)
+		}
+		if setting.SignoutRedirectUrl != "" {
+			c.Redirect(setting.SignoutRedirectUrl)
+			return
+		}
+		c.Redirect(hs.Cfg.AppSubURL + "/login")
+		return
+	}
+
+	logoutRequest, err := hs.SAMLService.ParseLogoutRequest(c.Req)
+	if err != nil {
+		c.Handle(hs.Cfg, http.StatusBadRequest, "Invalid SAML logout request", err)
+		return
+	}
+
+	authInfoQuery := models.GetAuthInfoQuery{AuthModule: loginService.SAMLAuthModule, AuthId: logoutRequest.NameID.Value}
+	if err := hs.authInfoService.GetAuthInfo(c.Req.Context(), &authInfoQuery); err == nil {
+		if err := hs.AuthTokenService.RevokeAllUserTokens(c.Req.Context(), authInfoQuery.Result.UserId); err != nil {
+			c.Handle(hs.Cfg, http.StatusInternalServerError, "Failed to revoke user sessions", err)
+			return
+		}
+	} else if !errors.Is(err, user.ErrUserNotFound) {
+		c.Handle(hs.Cfg, http.StatusInternalServerError, "Failed to find SAML user", err)
+		return
+	}
+	cookies.WriteSessionCookie(c, hs.Cfg, "", -1)
+
+	redirectURL, err := hs.SAMLService.LogoutResponseURL(c.Req.Context(), logoutRequest.ID, c.Req.Form.Get("RelayState"))
+	if err != nil {
+		c.Handle(hs.Cfg, http.StatusInternalServerError, "Failed to create SAML logout response", err)
+		return
+	}
+	c.Redirect(redirectURL)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/query"
@@ -65,6 +67,7 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext) response.Response {
 	}
 
 	reqDTO.HTTPRequest = c.Req
+	audit.SetResourceUID(c.Req.Context(), queryDatasourceUIDs(reqDTO))
 
 	resp, err := hs.queryDataService.QueryData(c.Req.Context(), c.SignedInUser, c.SkipCache, reqDTO, true)
 	if err != nil {
@@ -73,6 +76,21 @@ func (hs *HTTPServer) QueryMetricsV2(c *models.ReqContext) response.Response {
 	return hs.toJsonStreamingResponse(resp)
 }
 
+// queryDatasourceUIDs returns the comma separated UIDs of the data sources used by the queries
+func queryDatasourceUIDs(reqDTO dtos.MetricRequest) string {
+	uids := make([]string, 0, len(reqDTO.Queries))
+	seen := map[string]bool{}
+	for _, q := range reqDTO.Queries {
+		uid := q.Get("datasource").Get("uid").MustString()
+		if uid == "" || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		uids = append(uids, uid)
+	}
+	return strings.Join(uids, ",")
+}
+
 func (hs *HTTPServer) toJsonStreamingResponse(qdr *backend.QueryDataResponse) response.Response {
 	statusWhenError := http.StatusBadRequest
 	if hs.Features.IsEnabled(featuremgmt.FlagDatasourceQueryMultiStatus) {
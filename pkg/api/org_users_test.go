@@ -327,10 +327,13 @@ func TestGetOrgUsersAPIEndpoint_AccessControlMetadata(t *testing.T) {
 			enableAccessControl: true,
 			expectedCode:        http.StatusOK,
 			expectedMetadata: map[string]bool{
-				"org.users:write":  true,
-				"org.users:add":    true,
-				"org.users:read":   true,
-				"org.users:remove": true},
+				"org.users:write":    true,
+				"org.users:add":      true,
+				"org.users:read":     true,
+				"org.users:remove":   true,
+				"users.roles:read":   true,
+				"users.roles:add":    true,
+				"users.roles:remove": true},
 			user:      testServerAdminViewer,
 			targetOrg: testServerAdminViewer.OrgId,
 		},
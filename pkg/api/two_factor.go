@@ -0,0 +1,513 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/middleware/cookies"
+	"github.com/grafana/grafana/pkg/models"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	twoFactorCookieName = "grafana_two_factor"
+	// two-factor authentication only applies to users logging in with a password stored in the Grafana database
+	twoFactorAuthModule = "grafana"
+)
+
+func (hs *HTTPServer) registerTwoFactorRoutes() {
+	r := hs.RouteRegister
+	reqSignedInNoAnonymous := middleware.ReqSignedInNoAnonymous
+	authorize := ac.Middleware(hs.AccessControl)
+	quota := middleware.Quota(hs.QuotaService)
+
+	r.Post("/login/two-factor", quota("session"), routing.Wrap(hs.TwoFactorLoginPost))
+	r.Post("/login/two-factor/enroll", routing.Wrap(hs.TwoFactorLoginEnroll))
+
+	r.Group("/api/user/two-factor", func(userRoute routing.RouteRegister) {
+		userRoute.Get("/", routing.Wrap(hs.GetTwoFactorStatus))
+		userRoute.Post("/enroll", routing.Wrap(hs.BeginTwoFactorEnrollment))
+		userRoute.Post("/confirm", routing.Wrap(hs.ConfirmTwoFactorEnrollment))
+		userRoute.Post("/recovery-codes", routing.Wrap(hs.RegenerateTwoFactorRecoveryCodes))
+		userRoute.Post("/disable", routing.Wrap(hs.DisableTwoFactor))
+	}, reqSignedInNoAnonymous)
+
+	r.Get("/api/org/two-factor", authorize(middleware.ReqOrgAdmin, ac.EvalPermission(ActionOrgsPreferencesRead)), routing.Wrap(hs.GetOrgTwoFactorPolicy))
+	r.Put("/api/org/two-factor", authorize(middleware.ReqOrgAdmin, ac.EvalPermission(ActionOrgsPreferencesWrite)), routing.Wrap(hs.UpdateOrgTwoFactorPolicy))
+
+	userIDScope := ac.Scope("global.users", "id", ac.Parameter(":id"))
+	r.Delete("/api/admin/users/:id/two-factor", authorize(middleware.ReqGrafanaAdmin, ac.EvalPermission(ac.ActionUsersWrite, userIDScope)), routing.Wrap(hs.AdminResetTwoFactor))
+}
+
+func (hs *HTTPServer) twoFactorEnabled() bool {
+	return hs.TwoFactorService != nil && hs.TwoFactorService.IsEnabled()
+}
+
+// twoFactorChallenge starts the second step of the login when the user has enabled two-factor
+// authentication, or has to enroll because one of their organizations requires it. It returns
+// nil when the user can be logged in right away.
+func (hs *HTTPServer) twoFactorChallenge(c *models.ReqContext, usr *user.User) (*response.NormalResponse, error) {
+	status, err := hs.TwoFactorService.GetStatus(c.Req.Context(), usr.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Enabled && !status.Required {
+		return nil, nil
+	}
+
+	enrollment := !status.Enabled
+	token, err := hs.TwoFactorService.CreateChallenge(c.Req.Context(), usr.ID, enrollment)
+	if err != nil {
+		return nil, err
+	}
+	cookies.WriteCookie(c.Resp, twoFactorCookieName, token, int(twofactor.ChallengeTTL.Seconds()), hs.CookieOptionsFromCfg)
+
+	return response.JSON(http.StatusOK, map[string]interface{}{
+		"message":             "Two-factor authentication required",
+		"twoFactorRequired":   true,
+		"twoFactorEnrollment": enrollment,
+	}), nil
+}
+
+// loginRedirectResult returns the body of a successful login response, redirecting to the
+// page the user was on before being asked to log in.
+func (hs *HTTPServer) loginRedirectResult(c *models.ReqContext) map[string]interface{} {
+	result := map[string]interface{}{
+		"message": "Logged in",
+	}
+
+	if redirectTo := c.GetCookie("redirect_to"); len(redirectTo) > 0 {
+		if err := hs.ValidateRedirectTo(redirectTo); err == nil {
+			result["redirectUrl"] = redirectTo
+		} else {
+			c.Logger.Info("Ignored invalid redirect_to cookie value.", "url", redirectTo)
+		}
+		cookies.DeleteCookie(c.Resp, "redirect_to", hs.CookieOptionsFromCfg)
+	}
+	return result
+}
+
+// swagger:route POST /login/two-factor two_factor twoFactorLogin
+//
+// Complete a login with a two-factor authentication code.
+//
+// Completes a login started with `POST /login` which answered with `twoFactorRequired`. The code is either a code
+// of the authenticator app or one of the recovery codes. When the user has to enroll because an organization requires
+// two-factor authentication, the code confirms the enrollment started with `POST /login/two-factor/enroll` and the
+// response contains the recovery codes of the user.
+//
+// Responses:
+// 200: twoFactorLoginResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) TwoFactorLoginPost(c *models.ReqContext) response.Response {
+	cmd := TwoFactorCodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	ctx := c.Req.Context()
+	token := c.GetCookie(twoFactorCookieName)
+	challenge, err := hs.TwoFactorService.AttemptChallenge(ctx, token)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrTooManyAttempts) {
+			cookies.DeleteCookie(c.Resp, twoFactorCookieName, hs.CookieOptionsFromCfg)
+			return response.Error(http.StatusUnauthorized, "Too many invalid codes, please log in again", err)
+		}
+		if errors.Is(err, twofactor.ErrChallengeNotFound) {
+			return response.Error(http.StatusUnauthorized, "Login expired, please log in again", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor challenge", err)
+	}
+
+	usr, err := hs.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: challenge.UserID})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+
+	var recoveryCodes []string
+	if challenge.Enrollment {
+		recoveryCodes, err = hs.TwoFactorService.ConfirmEnrollment(ctx, usr.ID, cmd.Code)
+	} else {
+		err = hs.TwoFactorService.Verify(ctx, usr.ID, cmd.Code)
+	}
+	if err != nil {
+		if !errors.Is(err, twofactor.ErrInvalidCode) && !errors.Is(err, twofactor.ErrNoEnrollment) {
+			return response.Error(http.StatusInternalServerError, "Failed to verify two-factor authentication code", err)
+		}
+		return hs.failTwoFactorChallenge(c, token, challenge, usr)
+	}
+
+	if err := hs.TwoFactorService.DeleteChallenge(ctx, token); err != nil {
+		hs.log.Warn("Failed to delete two-factor challenge", "error", err)
+	}
+	cookies.DeleteCookie(c.Resp, twoFactorCookieName, hs.CookieOptionsFromCfg)
+
+	if err := hs.loginUserWithUser(usr, c); err != nil {
+		var createTokenErr *models.CreateTokenErr
+		if errors.As(err, &createTokenErr) {
+			return response.Error(createTokenErr.StatusCode, createTokenErr.ExternalErr, createTokenErr.InternalErr)
+		}
+		return response.Error(http.StatusInternalServerError, "Error while signing in user", err)
+	}
+
+	result := hs.loginRedirectResult(c)
+	if recoveryCodes != nil {
+		result["recoveryCodes"] = recoveryCodes
+	}
+	metrics.MApiLoginPost.Inc()
+	return response.JSON(http.StatusOK, result)
+}
+
+// failTwoFactorChallenge counts the invalid code as a failed login attempt of the user, so that the
+// brute force protection of the login form also applies to the codes. The attempt itself has already
+// been counted by AttemptChallenge.
+func (hs *HTTPServer) failTwoFactorChallenge(c *models.ReqContext, token string, challenge *twofactor.Challenge, usr *user.User) response.Response {
+	ctx := c.Req.Context()
+	if !hs.Cfg.DisableBruteForceLoginProtection {
+		if err := hs.SQLStore.CreateLoginAttempt(ctx, &models.CreateLoginAttemptCommand{
+			Username:  usr.Login,
+			IpAddress: c.Req.RemoteAddr,
+			IpSubnet:  login.ClientSubnet(hs.Cfg, c.Req.RemoteAddr),
+		}); err != nil {
+			hs.log.Error("Failed to save invalid login attempt", "error", err)
+		}
+	}
+
+	if challenge.Attempts >= twofactor.MaxChallengeAttempts {
+		if err := hs.TwoFactorService.DeleteChallenge(ctx, token); err != nil {
+			hs.log.Warn("Failed to delete two-factor challenge", "error", err)
+		}
+		cookies.DeleteCookie(c.Resp, twoFactorCookieName, hs.CookieOptionsFromCfg)
+		return response.Error(http.StatusUnauthorized, "Too many invalid codes, please log in again", twofactor.ErrTooManyAttempts)
+	}
+	return response.Error(http.StatusUnauthorized, "Invalid two-factor authentication code", nil)
+}
+
+// swagger:route POST /login/two-factor/enroll two_factor twoFactorLoginEnroll
+//
+// Start the two-factor authentication enrollment required to complete a login.
+//
+// Only available when the response of `POST /login` asked for a `twoFactorEnrollment`.
+//
+// Responses:
+// 200: twoFactorEnrollmentResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) TwoFactorLoginEnroll(c *models.ReqContext) response.Response {
+	ctx := c.Req.Context()
+	challenge, err := hs.TwoFactorService.GetChallenge(ctx, c.GetCookie(twoFactorCookieName))
+	if err != nil {
+		if errors.Is(err, twofactor.ErrChallengeNotFound) {
+			return response.Error(http.StatusUnauthorized, "Login expired, please log in again", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor challenge", err)
+	}
+	if !challenge.Enrollment {
+		return response.Error(http.StatusBadRequest, twofactor.ErrAlreadyEnabled.Error(), nil)
+	}
+
+	usr, err := hs.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: challenge.UserID})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+	return hs.beginTwoFactorEnrollment(c, usr.ID, usr.Login)
+}
+
+func (hs *HTTPServer) beginTwoFactorEnrollment(c *models.ReqContext, userID int64, account string) response.Response {
+	enrollment, err := hs.TwoFactorService.BeginEnrollment(c.Req.Context(), userID, account)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrAlreadyEnabled) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to start two-factor enrollment", err)
+	}
+	return response.JSON(http.StatusOK, enrollment)
+}
+
+// swagger:route GET /user/two-factor signed_in_user getTwoFactorStatus
+//
+// Get the two-factor authentication status of the signed in user.
+//
+// Responses:
+// 200: twoFactorStatusResponse
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) GetTwoFactorStatus(c *models.ReqContext) response.Response {
+	status, err := hs.TwoFactorService.GetStatus(c.Req.Context(), c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor status", err)
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+// swagger:route POST /user/two-factor/enroll signed_in_user beginTwoFactorEnrollment
+//
+// Start the two-factor authentication enrollment of the signed in user.
+//
+// Returns the secret to add to an authenticator app and its `otpauth://` provisioning URI, to display as a QR code.
+// Two-factor authentication is enabled once the enrollment is confirmed with `POST /user/two-factor/confirm`.
+//
+// Responses:
+// 200: twoFactorEnrollmentResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) BeginTwoFactorEnrollment(c *models.ReqContext) response.Response {
+	return hs.beginTwoFactorEnrollment(c, c.UserId, c.Login)
+}
+
+// swagger:route POST /user/two-factor/confirm signed_in_user confirmTwoFactorEnrollment
+//
+// Confirm the two-factor authentication enrollment of the signed in user.
+//
+// Enables two-factor authentication and returns the recovery codes of the user. Recovery codes are only returned once.
+//
+// Responses:
+// 200: twoFactorRecoveryCodesResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) ConfirmTwoFactorEnrollment(c *models.ReqContext) response.Response {
+	cmd := TwoFactorCodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	codes, err := hs.TwoFactorService.ConfirmEnrollment(c.Req.Context(), c.UserId, cmd.Code)
+	if err != nil {
+		if errors.Is(err, twofactor.ErrInvalidCode) || errors.Is(err, twofactor.ErrNoEnrollment) || errors.Is(err, twofactor.ErrAlreadyEnabled) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to confirm two-factor enrollment", err)
+	}
+	return response.JSON(http.StatusOK, TwoFactorRecoveryCodes{RecoveryCodes: codes})
+}
+
+// swagger:route POST /user/two-factor/recovery-codes signed_in_user regenerateTwoFactorRecoveryCodes
+//
+// Replace the recovery codes of the signed in user.
+//
+// Requires a code of the authenticator app. The previous recovery codes can no longer be used.
+//
+// Responses:
+// 200: twoFactorRecoveryCodesResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 500: internalServerError
+func (hs *HTTPServer) RegenerateTwoFactorRecoveryCodes(c *models.ReqContext) response.Response {
+	if errResp := hs.verifyTwoFactorCode(c); errResp != nil {
+		return errResp
+	}
+
+	codes, err := hs.TwoFactorService.RegenerateRecoveryCodes(c.Req.Context(), c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to generate recovery codes", err)
+	}
+	return response.JSON(http.StatusOK, TwoFactorRecoveryCodes{RecoveryCodes: codes})
+}
+
+// swagger:route POST /user/two-factor/disable signed_in_user disableTwoFactor
+//
+// Disable two-factor authentication for the signed in user.
+//
+// Requires a code of the authenticator app or a recovery code. Not allowed when an organization of the user requires
+// two-factor authentication.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) DisableTwoFactor(c *models.ReqContext) response.Response {
+	required, err := hs.TwoFactorService.IsRequired(c.Req.Context(), c.UserId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor status", err)
+	}
+	if required {
+		return response.Error(http.StatusForbidden, twofactor.ErrRequiredByOrg.Error(), nil)
+	}
+
+	if errResp := hs.verifyTwoFactorCode(c); errResp != nil {
+		return errResp
+	}
+
+	if err := hs.TwoFactorService.Disable(c.Req.Context(), c.UserId); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to disable two-factor authentication", err)
+	}
+	return response.Success("Two-factor authentication disabled")
+}
+
+// verifyTwoFactorCode checks the code of the request body for the signed in user.
+func (hs *HTTPServer) verifyTwoFactorCode(c *models.ReqContext) response.Response {
+	cmd := TwoFactorCodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := hs.TwoFactorService.Verify(c.Req.Context(), c.UserId, cmd.Code); err != nil {
+		if errors.Is(err, twofactor.ErrInvalidCode) || errors.Is(err, twofactor.ErrNotEnabled) {
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to verify two-factor authentication code", err)
+	}
+	return nil
+}
+
+// swagger:route DELETE /admin/users/{user_id}/two-factor admin_users adminResetTwoFactor
+//
+// Reset the two-factor authentication of a user.
+//
+// Removes the authenticator app and recovery codes of the user, for example when the user lost their device. The user
+// has to enroll again on their next login when an organization requires two-factor authentication.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) AdminResetTwoFactor(c *models.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	if _, err := hs.userService.GetByID(c.Req.Context(), &user.GetUserByIDQuery{ID: userID}); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return response.Error(http.StatusNotFound, user.ErrUserNotFound.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+
+	if err := hs.TwoFactorService.Disable(c.Req.Context(), userID); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to reset two-factor authentication", err)
+	}
+	return response.Success("Two-factor authentication reset")
+}
+
+// swagger:route GET /org/two-factor org getOrgTwoFactorPolicy
+//
+// Get the two-factor authentication policy of the current organization.
+//
+// Responses:
+// 200: orgTwoFactorPolicyResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) GetOrgTwoFactorPolicy(c *models.ReqContext) response.Response {
+	policy, err := hs.TwoFactorService.GetOrgPolicy(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get two-factor policy", err)
+	}
+	return response.JSON(http.StatusOK, policy)
+}
+
+// swagger:route PUT /org/two-factor org updateOrgTwoFactorPolicy
+//
+// Update the two-factor authentication policy of the current organization.
+//
+// When two-factor authentication is required, members logging in with a username and password have to enroll before
+// completing their next login.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) UpdateOrgTwoFactorPolicy(c *models.ReqContext) response.Response {
+	cmd := UpdateOrgTwoFactorPolicyCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := hs.TwoFactorService.SetOrgPolicy(c.Req.Context(), &twofactor.OrgPolicy{OrgID: c.OrgId, Required: cmd.Required}); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to update two-factor policy", err)
+	}
+	return response.Success("Two-factor policy updated")
+}
+
+type TwoFactorCodeCommand struct {
+	// Code of the authenticator app or recovery code
+	Code string `json:"code"`
+}
+
+type TwoFactorRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type UpdateOrgTwoFactorPolicyCommand struct {
+	Required bool `json:"required"`
+}
+
+// swagger:parameters twoFactorLogin confirmTwoFactorEnrollment regenerateTwoFactorRecoveryCodes disableTwoFactor
+type TwoFactorCodeParams struct {
+	// in:body
+	// required:true
+	Body TwoFactorCodeCommand `json:"body"`
+}
+
+// swagger:parameters adminResetTwoFactor
+type AdminResetTwoFactorParams struct {
+	// in:path
+	// required:true
+	UserID int64 `json:"user_id"`
+}
+
+// swagger:parameters updateOrgTwoFactorPolicy
+type UpdateOrgTwoFactorPolicyParams struct {
+	// in:body
+	// required:true
+	Body UpdateOrgTwoFactorPolicyCommand `json:"body"`
+}
+
+// swagger:response twoFactorLoginResponse
+type TwoFactorLoginResponse struct {
+	// in: body
+	Body struct {
+		Message       string   `json:"message"`
+		RedirectURL   string   `json:"redirectUrl,omitempty"`
+		RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	} `json:"body"`
+}
+
+// swagger:response twoFactorEnrollmentResponse
+type TwoFactorEnrollmentResponse struct {
+	// in: body
+	Body twofactor.Enrollment `json:"body"`
+}
+
+// swagger:response twoFactorStatusResponse
+type TwoFactorStatusResponse struct {
+	// in: body
+	Body twofactor.Status `json:"body"`
+}
+
+// swagger:response twoFactorRecoveryCodesResponse
+type TwoFactorRecoveryCodesResponse struct {
+	// in: body
+	Body TwoFactorRecoveryCodes `json:"body"`
+}
+
+// swagger:response orgTwoFactorPolicyResponse
+type OrgTwoFactorPolicyResponse struct {
+	// in: body
+	Body twofactor.OrgPolicy `json:"body"`
+}
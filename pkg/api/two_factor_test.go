@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/licensing"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/twofactor/twofactorimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationTwoFactorLogin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	sqlStore := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.TwoFactor = setting.TwoFactorSettings{Enabled: true, Issuer: "Grafana"}
+	twoFactorService := twofactorimpl.ProvideService(cfg, sqlStore, fakes.NewFakeSecretsService())
+
+	usr, err := sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	userService := usertest.NewUserServiceFake()
+	userService.ExpectedUser = usr
+	tokenService := auth.NewFakeUserAuthTokenService()
+	hs := &HTTPServer{
+		log:              log.NewNopLogger(),
+		Cfg:              cfg,
+		HooksService:     &hooks.HooksService{},
+		License:          &licensing.OSSLicensingService{},
+		AuthTokenService: tokenService,
+		SQLStore:         sqlStore,
+		TwoFactorService: twoFactorService,
+		userService:      userService,
+		authenticator:    &fakeAuthenticator{usr, twoFactorAuthModule, nil},
+	}
+
+	sc := setupScenarioContext(t, "/login")
+	sc.m.Post("/login", routing.Wrap(hs.LoginPost))
+	sc.m.Post("/login/two-factor", routing.Wrap(hs.TwoFactorLoginPost))
+
+	tokensCreated := 0
+	tokenService.CreateTokenProvider = func(ctx context.Context, user *user.User, clientIP net.IP, userAgent string) (*models.UserToken, error) {
+		tokensCreated++
+		return &models.UserToken{UserId: user.ID, UnhashedToken: "session"}, nil
+	}
+
+	post := func(url string, body string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		resp := httptest.NewRecorder()
+		sc.m.ServeHTTP(resp, req)
+		return resp
+	}
+
+	login := func(t *testing.T) (map[string]interface{}, *http.Cookie) {
+		resp := post("/login", `{"user":"alice","password":"password"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+
+		for _, c := range resp.Result().Cookies() {
+			if c.Name == twoFactorCookieName {
+				return body, c
+			}
+		}
+		return body, nil
+	}
+
+	t.Run("users without two-factor authentication are logged in right away", func(t *testing.T) {
+		body, challenge := login(t)
+		assert.Nil(t, challenge)
+		assert.Equal(t, "Logged in", body["message"])
+		assert.Equal(t, 1, tokensCreated)
+	})
+
+	enrollment, err := twoFactorService.BeginEnrollment(ctx, usr.ID, usr.Login)
+	require.NoError(t, err)
+	code, err := twofactor.GenerateCode(enrollment.Secret, twofactor.Counter(time.Now()))
+	require.NoError(t, err)
+	recoveryCodes, err := twoFactorService.ConfirmEnrollment(ctx, usr.ID, code)
+	require.NoError(t, err)
+
+	t.Run("a code is required before the session is created", func(t *testing.T) {
+		tokensCreated = 0
+		body, challenge := login(t)
+		require.NotNil(t, challenge)
+		assert.Equal(t, true, body["twoFactorRequired"])
+		assert.Equal(t, false, body["twoFactorEnrollment"])
+		assert.Equal(t, 0, tokensCreated)
+
+		resp := post("/login/two-factor", `{"code":"000000"}`, challenge)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Equal(t, 0, tokensCreated)
+
+		resp = post("/login/two-factor", `{"code":"`+recoveryCodes[0]+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code, "the code must be sent with the challenge cookie")
+
+		resp = post("/login/two-factor", `{"code":"`+recoveryCodes[0]+`"}`, challenge)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 1, tokensCreated)
+
+		resp = post("/login/two-factor", `{"code":"`+recoveryCodes[1]+`"}`, challenge)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code, "a challenge can only be used once")
+	})
+
+	t.Run("the challenge is dropped after too many invalid codes", func(t *testing.T) {
+		_, challenge := login(t)
+		require.NotNil(t, challenge)
+		for i := 0; i < twofactor.MaxChallengeAttempts; i++ {
+			resp := post("/login/two-factor", `{"code":"000000"}`, challenge)
+			assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		}
+		resp := post("/login/two-factor", `{"code":"`+recoveryCodes[1]+`"}`, challenge)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("users have to enroll when their organization requires it", func(t *testing.T) {
+		require.NoError(t, twoFactorService.Disable(ctx, usr.ID))
+		org, err := sqlStore.CreateOrgWithMember("required", usr.ID)
+		require.NoError(t, err)
+		require.NoError(t, twoFactorService.SetOrgPolicy(ctx, &twofactor.OrgPolicy{OrgID: org.Id, Required: true}))
+
+		body, challenge := login(t)
+		require.NotNil(t, challenge)
+		assert.Equal(t, true, body["twoFactorEnrollment"])
+	})
+}
@@ -93,54 +93,55 @@ func (hs *HTTPServer) getUserAuthTokensInternal(c *models.ReqContext, userID int
 
 	result := []*dtos.UserToken{}
 	for _, token := range tokens {
-		isActive := false
-		if c.UserToken != nil && c.UserToken.Id == token.Id {
-			isActive = true
-		}
-
-		parser := uaparser.NewFromSaved()
-		client := parser.Parse(token.UserAgent)
+		isActive := c.UserToken != nil && c.UserToken.Id == token.Id
+		result = append(result, newUserTokenDTO(token, isActive))
+	}
 
-		osVersion := ""
-		if client.Os.Major != "" {
-			osVersion = client.Os.Major
+	return response.JSON(http.StatusOK, result)
+}
 
-			if client.Os.Minor != "" {
-				osVersion = osVersion + "." + client.Os.Minor
-			}
-		}
+// newUserTokenDTO describes the device of the token from its user agent.
+func newUserTokenDTO(token *models.UserToken, isActive bool) *dtos.UserToken {
+	parser := uaparser.NewFromSaved()
+	client := parser.Parse(token.UserAgent)
 
-		browserVersion := ""
-		if client.UserAgent.Major != "" {
-			browserVersion = client.UserAgent.Major
+	osVersion := ""
+	if client.Os.Major != "" {
+		osVersion = client.Os.Major
 
-			if client.UserAgent.Minor != "" {
-				browserVersion = browserVersion + "." + client.UserAgent.Minor
-			}
+		if client.Os.Minor != "" {
+			osVersion = osVersion + "." + client.Os.Minor
 		}
+	}
 
-		createdAt := time.Unix(token.CreatedAt, 0)
-		seenAt := time.Unix(token.SeenAt, 0)
+	browserVersion := ""
+	if client.UserAgent.Major != "" {
+		browserVersion = client.UserAgent.Major
 
-		if token.SeenAt == 0 {
-			seenAt = createdAt
+		if client.UserAgent.Minor != "" {
+			browserVersion = browserVersion + "." + client.UserAgent.Minor
 		}
+	}
+
+	createdAt := time.Unix(token.CreatedAt, 0)
+	seenAt := time.Unix(token.SeenAt, 0)
 
-		result = append(result, &dtos.UserToken{
-			Id:                     token.Id,
-			IsActive:               isActive,
-			ClientIp:               token.ClientIp,
-			Device:                 client.Device.ToString(),
-			OperatingSystem:        client.Os.Family,
-			OperatingSystemVersion: osVersion,
-			Browser:                client.UserAgent.Family,
-			BrowserVersion:         browserVersion,
-			CreatedAt:              createdAt,
-			SeenAt:                 seenAt,
-		})
+	if token.SeenAt == 0 {
+		seenAt = createdAt
 	}
 
-	return response.JSON(http.StatusOK, result)
+	return &dtos.UserToken{
+		Id:                     token.Id,
+		IsActive:               isActive,
+		ClientIp:               token.ClientIp,
+		Device:                 client.Device.ToString(),
+		OperatingSystem:        client.Os.Family,
+		OperatingSystemVersion: osVersion,
+		Browser:                client.UserAgent.Family,
+		BrowserVersion:         browserVersion,
+		CreatedAt:              createdAt,
+		SeenAt:                 seenAt,
+	}
 }
 
 func (hs *HTTPServer) revokeUserAuthTokenInternal(c *models.ReqContext, userID int64, cmd models.RevokeAuthTokenCmd) response.Response {
@@ -160,6 +160,11 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:   "reset-two-factor",
+		Usage:  "reset-two-factor [login or email]",
+		Action: runRunnerCommand(resetTwoFactorCommand),
+	},
 	{
 		Name:  "data-migration",
 		Usage: "Runs a script that migrates or cleanups data in your database",
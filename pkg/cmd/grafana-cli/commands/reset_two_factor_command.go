@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/runner"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// resetTwoFactorCommand removes the two-factor authentication settings of a user, the admin user when
+// no login is given, so that a user who lost their authenticator app and recovery codes can log in again.
+func resetTwoFactorCommand(c utils.CommandLine, runner runner.Runner) error {
+	ctx := context.Background()
+
+	var usr *user.User
+	var err error
+	if loginOrEmail := c.Args().First(); loginOrEmail != "" {
+		usr, err = runner.UserService.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: loginOrEmail})
+	} else {
+		usr, err = runner.UserService.GetByID(ctx, &user.GetUserByIDQuery{ID: AdminUserId})
+	}
+	if err != nil {
+		return fmt.Errorf("could not read user from database. Error: %v", err)
+	}
+
+	if err := runner.TwoFactorService.Disable(ctx, usr.ID); err != nil {
+		return fmt.Errorf("failed to reset two-factor authentication: %w", err)
+	}
+
+	logger.Infof("\n")
+	logger.Infof("Two-factor authentication reset for user %s %s", usr.Login, color.GreenString("✔"))
+
+	return nil
+}
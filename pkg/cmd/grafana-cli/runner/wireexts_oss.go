@@ -79,6 +79,7 @@ var wireExtsSet = wire.NewSet(
 	acdb.ProvideService,
 	wire.Bind(new(resourcepermissions.Store), new(*acdb.AccessControlStore)),
 	wire.Bind(new(accesscontrol.PermissionsStore), new(*acdb.AccessControlStore)),
+	wire.Bind(new(accesscontrol.RoleStore), new(*acdb.AccessControlStore)),
 	ldap.ProvideGroupsService,
 	wire.Bind(new(ldap.Groups), new(*ldap.OSSGroups)),
 	permissions.ProvideDatasourcePermissionsService,
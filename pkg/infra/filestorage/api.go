@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -18,6 +19,8 @@ var (
 	ErrPathEndsWithDelimiter = errors.New("path can not end with delimiter")
 	ErrPathPartTooLong       = errors.New("path part is too long")
 	ErrEmptyPathPart         = errors.New("path can not have empty parts")
+	ErrInvalidRange          = errors.New("invalid read range")
+	ErrChecksumMismatch      = errors.New("file contents do not match the expected checksum")
 	Delimiter                = "/"
 	DirectoryMimeType        = "directory"
 	multipleDelimiters       = regexp.MustCompile(`/+`)
@@ -90,6 +93,22 @@ type FileMetadata struct {
 	Created    time.Time
 	Size       int64
 	Properties map[string]string
+
+	// Checksum is the hex-encoded MD5 hash of the file contents. Might be empty for files stored by external tools.
+	Checksum string
+}
+
+// ReadRange selects a part of the file contents
+type ReadRange struct {
+	Offset int64
+	// Length of the range; non-positive length means "until the end of the file"
+	Length int64
+}
+
+// FileStream is returned by `GetReader`. The caller is responsible for closing the `Reader`
+type FileStream struct {
+	FileMetadata
+	Reader io.ReadCloser
 }
 
 type Paging struct {
@@ -107,6 +126,25 @@ type UpsertFileCommand struct {
 	Contents []byte
 	// Properties of an existing file won't be modified if cmd.Properties is nil
 	Properties map[string]string
+
+	// Checksum is the expected hex-encoded MD5 hash of the contents. The upsert fails with ErrChecksumMismatch if the contents don't match it
+	Checksum string
+}
+
+func validateReadRange(readRange *ReadRange, size int64) (int64, int64, error) {
+	if readRange == nil {
+		return 0, size, nil
+	}
+
+	if readRange.Offset < 0 || readRange.Offset > size {
+		return 0, 0, ErrInvalidRange
+	}
+
+	length := size - readRange.Offset
+	if readRange.Length > 0 && readRange.Length < length {
+		length = readRange.Length
+	}
+	return readRange.Offset, length, nil
 }
 
 func toLower(list []string) []string {
@@ -172,6 +210,12 @@ type FileStorage interface {
 	Delete(ctx context.Context, path string) error
 	Upsert(ctx context.Context, command *UpsertFileCommand) error
 
+	// GetReader streams the file contents instead of loading them into memory. Returns the whole file if readRange is nil
+	GetReader(ctx context.Context, path string, readRange *ReadRange) (*FileStream, error)
+
+	// UpsertReader is a streaming version of Upsert. `command.Contents` is ignored, contents are consumed from the reader instead
+	UpsertReader(ctx context.Context, command *UpsertFileCommand, contents io.Reader) error
+
 	// List lists only files without content by default
 	List(ctx context.Context, folderPath string, paging *Paging, options *ListOptions) (*ListResponse, error)
 
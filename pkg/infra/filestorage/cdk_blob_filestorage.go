@@ -2,6 +2,8 @@ package filestorage
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -66,6 +68,55 @@ func (c cdkBlobStorage) Get(ctx context.Context, filePath string) (*File, error)
 			Modified:   attributes.ModTime,
 			Size:       attributes.Size,
 			MimeType:   detectContentType(originalPath, attributes.ContentType),
+			Checksum:   hex.EncodeToString(attributes.MD5),
+		},
+	}, nil
+}
+
+func (c cdkBlobStorage) GetReader(ctx context.Context, filePath string, readRange *ReadRange) (*FileStream, error) {
+	key := strings.ToLower(filePath)
+	attributes, err := c.bucket.Attributes(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	offset, length, err := validateReadRange(readRange, attributes.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := c.bucket.NewRangeReader(ctx, key, offset, length, nil)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	props := make(map[string]string)
+	originalPath := filePath
+	for k, v := range attributes.Metadata {
+		if k == originalPathAttributeKey {
+			originalPath = v
+			continue
+		}
+		props[k] = v
+	}
+
+	return &FileStream{
+		Reader: reader,
+		FileMetadata: FileMetadata{
+			Name:       getName(originalPath),
+			FullPath:   originalPath,
+			Created:    attributes.CreateTime,
+			Properties: props,
+			Modified:   attributes.ModTime,
+			Size:       attributes.Size,
+			MimeType:   detectContentType(originalPath, attributes.ContentType),
+			Checksum:   hex.EncodeToString(attributes.MD5),
 		},
 	}, nil
 }
@@ -85,6 +136,10 @@ func (c cdkBlobStorage) Delete(ctx context.Context, filePath string) error {
 }
 
 func (c cdkBlobStorage) Upsert(ctx context.Context, command *UpsertFileCommand) error {
+	if command.Contents != nil && command.Checksum != "" && createContentsHash(command.Contents) != command.Checksum {
+		return ErrChecksumMismatch
+	}
+
 	existing, err := c.Get(ctx, command.Path)
 	if err != nil {
 		return err
@@ -132,6 +187,58 @@ func (c cdkBlobStorage) Upsert(ctx context.Context, command *UpsertFileCommand)
 	})
 }
 
+func (c cdkBlobStorage) UpsertReader(ctx context.Context, command *UpsertFileCommand, contents io.Reader) error {
+	key := strings.ToLower(command.Path)
+	attributes, err := c.bucket.Attributes(ctx, key)
+	if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return err
+	}
+
+	metadata := make(map[string]string)
+	originalPath := command.Path
+	if err == nil && attributes.Metadata != nil {
+		if path, ok := attributes.Metadata[originalPathAttributeKey]; ok {
+			originalPath = path
+		}
+		if command.Properties == nil {
+			for k, v := range attributes.Metadata {
+				metadata[k] = v
+			}
+		}
+	}
+	for k, v := range command.Properties {
+		metadata[k] = v
+	}
+	metadata[originalPathAttributeKey] = originalPath
+
+	// canceling the context before closing the writer aborts the upload and leaves the existing file untouched
+	writeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer, err := c.bucket.NewWriter(writeCtx, key, &blob.WriterOptions{
+		Metadata:    metadata,
+		ContentType: command.MimeType,
+	})
+	if err != nil {
+		return err
+	}
+
+	hasher := md5.New()
+	if _, err := io.Copy(io.MultiWriter(writer, hasher), contents); err != nil {
+		cancel()
+		_ = writer.Close()
+		return err
+	}
+
+	if command.Checksum != "" && hex.EncodeToString(hasher.Sum(nil)) != command.Checksum {
+		cancel()
+		_ = writer.Close()
+		return ErrChecksumMismatch
+	}
+
+	return writer.Close()
+}
+
 func (c cdkBlobStorage) convertFolderPathToPrefix(path string) string {
 	if path != "" && !strings.HasSuffix(path, Delimiter) {
 		return path + Delimiter
@@ -424,6 +531,7 @@ func (c cdkBlobStorage) list(ctx context.Context, folderPath string, paging *Pag
 					Modified:   attributes.ModTime,
 					Size:       attributes.Size,
 					MimeType:   detectContentType(originalPath, attributes.ContentType),
+					Checksum:   hex.EncodeToString(attributes.MD5),
 				},
 			})
 		}
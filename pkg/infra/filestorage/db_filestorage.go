@@ -1,9 +1,13 @@
 package filestorage
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
 	"reflect"
 
 	// can ignore because we don't need a cryptographically secure hash function
@@ -31,10 +35,11 @@ type file struct {
 	Created              time.Time `xorm:"created"`
 	Size                 int64     `xorm:"size"`
 	MimeType             string    `xorm:"mime_type"`
+	ChunkCount           int       `xorm:"chunk_count"`
 }
 
 var (
-	fileColsNoContents = []string{"path", "path_hash", "parent_folder_path_hash", "etag", "cache_control", "content_disposition", "updated", "created", "size", "mime_type"}
+	fileColsNoContents = []string{"path", "path_hash", "parent_folder_path_hash", "etag", "cache_control", "content_disposition", "updated", "created", "size", "mime_type", "chunk_count"}
 	allFileCols        = append([]string{"contents"}, fileColsNoContents...)
 )
 
@@ -44,6 +49,25 @@ type fileMeta struct {
 	Value    string `xorm:"value"`
 }
 
+type fileChunk struct {
+	PathHash   string `xorm:"path_hash"`
+	ChunkIndex int    `xorm:"chunk_index"`
+	Contents   []byte `xorm:"contents"`
+}
+
+// dbFileChunkSize is the max size of contents stored inline in the `file` table.
+// Contents of larger files uploaded with `UpsertReader` are split into `file_chunk` rows of this size.
+// Changing it breaks range reads of existing chunked files.
+var dbFileChunkSize = 1024 * 1024
+
+// fileContents holds the new contents of a file in the `file` table; contents are stored in `file_chunk` if chunkCount > 0
+type fileContents struct {
+	inline     []byte
+	etag       string
+	size       int64
+	chunkCount int
+}
+
 type dbFileStorage struct {
 	db  *sqlstore.SQLStore
 	log log.Logger
@@ -114,6 +138,12 @@ func (s dbFileStorage) Get(ctx context.Context, filePath string) (*File, error)
 		}
 
 		contents := table.Contents
+		if table.ChunkCount > 0 {
+			contents, err = readChunks(sess, pathHash, 0, table.ChunkCount)
+			if err != nil {
+				return err
+			}
+		}
 		if contents == nil {
 			contents = make([]byte, 0)
 		}
@@ -128,6 +158,7 @@ func (s dbFileStorage) Get(ctx context.Context, filePath string) (*File, error)
 				Modified:   table.Updated,
 				Size:       table.Size,
 				MimeType:   table.MimeType,
+				Checksum:   table.ETag,
 			},
 		}
 		return err
@@ -136,6 +167,170 @@ func (s dbFileStorage) Get(ctx context.Context, filePath string) (*File, error)
 	return result, err
 }
 
+func readChunks(sess *sqlstore.DBSession, pathHash string, from int, to int) ([]byte, error) {
+	chunks := make([]*fileChunk, 0)
+	err := sess.Table("file_chunk").
+		Where("path_hash = ?", pathHash).
+		Where("chunk_index >= ? AND chunk_index < ?", from, to).
+		OrderBy("chunk_index").
+		Find(&chunks)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunks) != to-from {
+		return nil, fmt.Errorf("missing file chunks: expected %d, found %d", to-from, len(chunks))
+	}
+
+	var buf bytes.Buffer
+	for _, chunk := range chunks {
+		buf.Write(chunk.Contents)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s dbFileStorage) GetReader(ctx context.Context, filePath string, readRange *ReadRange) (*FileStream, error) {
+	var result *FileStream
+
+	pathHash, err := createPathHash(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		table := &file{}
+		exists, err := sess.Table("file").Where("path_hash = ?", pathHash).Cols(fileColsNoContents...).Get(table)
+		if err != nil || !exists {
+			return err
+		}
+
+		offset, length, err := validateReadRange(readRange, table.Size)
+		if err != nil {
+			return err
+		}
+
+		var meta = make([]*fileMeta, 0)
+		if err := sess.Table("file_meta").Where("path_hash = ?", pathHash).Find(&meta); err != nil {
+			return err
+		}
+
+		var metaProperties = make(map[string]string, len(meta))
+		for i := range meta {
+			metaProperties[meta[i].Key] = meta[i].Value
+		}
+
+		var reader io.ReadCloser
+		if table.ChunkCount == 0 {
+			inline := &file{}
+			if _, err := sess.Table("file").Where("path_hash = ?", pathHash).Cols("contents").Get(inline); err != nil {
+				return err
+			}
+
+			end := offset + length
+			if end > int64(len(inline.Contents)) {
+				end = int64(len(inline.Contents))
+			}
+			if offset > end {
+				offset = end
+			}
+			reader = io.NopCloser(bytes.NewReader(inline.Contents[offset:end]))
+		} else {
+			reader = &dbChunkReader{
+				ctx:        ctx,
+				db:         s.db,
+				pathHash:   pathHash,
+				chunkCount: table.ChunkCount,
+				chunkIndex: int(offset / int64(dbFileChunkSize)),
+				skip:       offset % int64(dbFileChunkSize),
+				remaining:  length,
+			}
+		}
+
+		result = &FileStream{
+			Reader: reader,
+			FileMetadata: FileMetadata{
+				Name:       getName(table.Path),
+				FullPath:   table.Path,
+				Created:    table.Created,
+				Properties: metaProperties,
+				Modified:   table.Updated,
+				Size:       table.Size,
+				MimeType:   table.MimeType,
+				Checksum:   table.ETag,
+			},
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// dbChunkReader lazily loads chunks of a file, keeping at most a single chunk in memory
+type dbChunkReader struct {
+	ctx        context.Context
+	db         *sqlstore.SQLStore
+	pathHash   string
+	chunkCount int
+	chunkIndex int
+	skip       int64
+	remaining  int64
+	current    []byte
+}
+
+func (r *dbChunkReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	if len(r.current) == 0 {
+		if r.chunkIndex >= r.chunkCount {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		chunk := &fileChunk{}
+		err := r.db.WithDbSession(r.ctx, func(sess *sqlstore.DBSession) error {
+			exists, err := sess.Table("file_chunk").
+				Where("path_hash = ?", r.pathHash).
+				Where("chunk_index = ?", r.chunkIndex).
+				Get(chunk)
+			if err == nil && !exists {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		r.chunkIndex++
+		r.current = chunk.Contents
+		if r.skip > 0 {
+			if r.skip > int64(len(r.current)) {
+				r.skip = int64(len(r.current))
+			}
+			r.current = r.current[r.skip:]
+			r.skip = 0
+		}
+		if int64(len(r.current)) > r.remaining {
+			r.current = r.current[:r.remaining]
+		}
+		if len(r.current) == 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+
+	n := copy(p, r.current)
+	r.current = r.current[n:]
+	r.remaining -= int64(n)
+	return n, nil
+}
+
+func (r *dbChunkReader) Close() error {
+	r.current = nil
+	r.remaining = 0
+	return nil
+}
+
 func (s dbFileStorage) Delete(ctx context.Context, filePath string) error {
 	pathHash, err := createPathHash(filePath)
 	if err != nil {
@@ -156,7 +351,12 @@ func (s dbFileStorage) Delete(ctx context.Context, filePath string) error {
 			return err
 		}
 
-		s.log.Info("Deleted file", "path", filePath, "deletedMetaCount", deletedMetaCount, "deletedFilesCount", deletedFilesCount)
+		deletedChunksCount, err := sess.Table("file_chunk").Where("path_hash = ?", pathHash).Delete(&fileChunk{})
+		if err != nil {
+			return err
+		}
+
+		s.log.Info("Deleted file", "path", filePath, "deletedMetaCount", deletedMetaCount, "deletedFilesCount", deletedFilesCount, "deletedChunksCount", deletedChunksCount)
 		return err
 	})
 
@@ -170,72 +370,166 @@ func (s dbFileStorage) Upsert(ctx context.Context, cmd *UpsertFileCommand) error
 		return err
 	}
 
+	var contents *fileContents
+	if cmd.Contents != nil {
+		contents = &fileContents{
+			inline: cmd.Contents,
+			etag:   createContentsHash(cmd.Contents),
+			size:   int64(len(cmd.Contents)),
+		}
+		if cmd.Checksum != "" && contents.etag != cmd.Checksum {
+			return ErrChecksumMismatch
+		}
+	}
+
+	err = s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return s.upsert(sess, now, cmd, pathHash, contents)
+	})
+
+	return err
+}
+
+func (s dbFileStorage) UpsertReader(ctx context.Context, cmd *UpsertFileCommand, reader io.Reader) error {
+	now := time.Now()
+	pathHash, err := createPathHash(cmd.Path)
+	if err != nil {
+		return err
+	}
+
 	err = s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
-		existing := &file{}
-		exists, err := sess.Table("file").Where("path_hash = ?", pathHash).Get(existing)
+		if _, err := sess.Table("file_chunk").Where("path_hash = ?", pathHash).Delete(&fileChunk{}); err != nil {
+			return err
+		}
+
+		hasher := md5.New()
+		contents, err := s.writeChunks(sess, pathHash, reader, hasher)
 		if err != nil {
 			return err
 		}
 
-		if exists {
-			existing.Updated = now
-			if cmd.Contents != nil {
-				contents := cmd.Contents
-				existing.Contents = contents
-				existing.MimeType = cmd.MimeType
-				existing.ETag = createContentsHash(contents)
-				existing.ContentDisposition = cmd.ContentDisposition
-				existing.CacheControl = cmd.CacheControl
-				existing.Size = int64(len(contents))
-			}
+		contents.etag = hex.EncodeToString(hasher.Sum(nil))
+		if cmd.Checksum != "" && contents.etag != cmd.Checksum {
+			return ErrChecksumMismatch
+		}
 
-			_, err = sess.Where("path_hash = ?", pathHash).Update(existing)
-			if err != nil {
-				return err
-			}
-		} else {
-			contentsToInsert := make([]byte, 0)
-			if cmd.Contents != nil {
-				contentsToInsert = cmd.Contents
-			}
+		return s.upsert(sess, now, cmd, pathHash, contents)
+	})
 
-			parentFolderPath := getParentFolderPath(cmd.Path)
-			parentFolderPathHash, err := createPathHash(parentFolderPath)
-			if err != nil {
-				return err
-			}
+	return err
+}
 
-			file := &file{
-				Path:                 cmd.Path,
-				PathHash:             pathHash,
-				ParentFolderPathHash: parentFolderPathHash,
-				Contents:             contentsToInsert,
-				ContentDisposition:   cmd.ContentDisposition,
-				CacheControl:         cmd.CacheControl,
-				ETag:                 createContentsHash(contentsToInsert),
-				MimeType:             cmd.MimeType,
-				Size:                 int64(len(contentsToInsert)),
-				Updated:              now,
-				Created:              now,
-			}
-			if _, err = sess.Insert(file); err != nil {
-				return err
+// writeChunks stores the contents in `file_chunk` rows unless they fit into a single chunk
+func (s dbFileStorage) writeChunks(sess *sqlstore.DBSession, pathHash string, reader io.Reader, hasher hash.Hash) (*fileContents, error) {
+	contents := &fileContents{}
+	for {
+		buf := make([]byte, dbFileChunkSize)
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+		buf = buf[:n]
+		isLastChunk := err != nil
+		contents.size += int64(n)
+		_, _ = hasher.Write(buf)
+
+		if isLastChunk && contents.chunkCount == 0 {
+			contents.inline = buf
+			return contents, nil
+		}
+
+		if n > 0 {
+			if _, err := sess.Insert(&fileChunk{
+				PathHash:   pathHash,
+				ChunkIndex: contents.chunkCount,
+				Contents:   buf,
+			}); err != nil {
+				return nil, err
 			}
+			contents.chunkCount++
 		}
 
-		if len(cmd.Properties) != 0 {
-			if err = upsertProperties(s.db.Dialect, sess, now, cmd, pathHash); err != nil {
-				if rollbackErr := sess.Rollback(); rollbackErr != nil {
-					s.log.Error("failed while rolling back upsert", "path", cmd.Path)
+		if isLastChunk {
+			contents.inline = make([]byte, 0)
+			return contents, nil
+		}
+	}
+}
+
+// upsert creates or updates the `file` row. The existing contents are kept if `contents` is nil
+func (s dbFileStorage) upsert(sess *sqlstore.DBSession, now time.Time, cmd *UpsertFileCommand, pathHash string, contents *fileContents) error {
+	existing := &file{}
+	exists, err := sess.Table("file").Where("path_hash = ?", pathHash).Get(existing)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		existing.Updated = now
+		if contents != nil {
+			if existing.ChunkCount > 0 && contents.chunkCount == 0 {
+				if _, err := sess.Table("file_chunk").Where("path_hash = ?", pathHash).Delete(&fileChunk{}); err != nil {
+					return err
 				}
-				return err
 			}
+
+			existing.Contents = contents.inline
+			existing.MimeType = cmd.MimeType
+			existing.ETag = contents.etag
+			existing.ContentDisposition = cmd.ContentDisposition
+			existing.CacheControl = cmd.CacheControl
+			existing.Size = contents.size
+			existing.ChunkCount = contents.chunkCount
 		}
 
-		return err
-	})
+		// `chunk_count` has to be updated even if it changed to 0
+		_, err = sess.Where("path_hash = ?", pathHash).MustCols("chunk_count").Update(existing)
+		if err != nil {
+			return err
+		}
+	} else {
+		if contents == nil {
+			inline := make([]byte, 0)
+			contents = &fileContents{
+				inline: inline,
+				etag:   createContentsHash(inline),
+			}
+		}
 
-	return err
+		parentFolderPath := getParentFolderPath(cmd.Path)
+		parentFolderPathHash, err := createPathHash(parentFolderPath)
+		if err != nil {
+			return err
+		}
+
+		file := &file{
+			Path:                 cmd.Path,
+			PathHash:             pathHash,
+			ParentFolderPathHash: parentFolderPathHash,
+			Contents:             contents.inline,
+			ContentDisposition:   cmd.ContentDisposition,
+			CacheControl:         cmd.CacheControl,
+			ETag:                 contents.etag,
+			MimeType:             cmd.MimeType,
+			Size:                 contents.size,
+			Updated:              now,
+			Created:              now,
+			ChunkCount:           contents.chunkCount,
+		}
+		if _, err = sess.Insert(file); err != nil {
+			return err
+		}
+	}
+
+	if len(cmd.Properties) != 0 {
+		if err = upsertProperties(s.db.Dialect, sess, now, cmd, pathHash); err != nil {
+			if rollbackErr := sess.Rollback(); rollbackErr != nil {
+				s.log.Error("failed while rolling back upsert", "path", cmd.Path)
+			}
+			return err
+		}
+	}
+
+	return nil
 }
 
 func upsertProperties(dialect migrator.Dialect, sess *sqlstore.DBSession, now time.Time, cmd *UpsertFileCommand, pathHash string) error {
@@ -395,6 +689,12 @@ func (s dbFileStorage) List(ctx context.Context, folderPath string, paging *Pagi
 			var contents []byte
 			if options.WithContents {
 				contents = foundFiles[i].Contents
+				if foundFiles[i].ChunkCount > 0 {
+					contents, err = readChunks(sess, foundFiles[i].PathHash, 0, foundFiles[i].ChunkCount)
+					if err != nil {
+						return err
+					}
+				}
 			} else {
 				contents = []byte{}
 			}
@@ -406,6 +706,7 @@ func (s dbFileStorage) List(ctx context.Context, folderPath string, paging *Pagi
 				Modified:   foundFiles[i].Updated,
 				Size:       foundFiles[i].Size,
 				MimeType:   foundFiles[i].MimeType,
+				Checksum:   foundFiles[i].ETag,
 			}})
 		}
 
@@ -574,7 +875,16 @@ func (s dbFileStorage) DeleteFolder(ctx context.Context, folderPath string, opti
 			return err
 		}
 
-		s.log.Info("Force deleted folder", "path", folderPath, "deletedFilesCount", deletedFilesCount, "deletedMetaCount", deletedMetaCount)
+		deletedChunksCount, err := sess.
+			Table("file_chunk").
+			In("path_hash", hashes...).
+			Delete(&fileChunk{})
+
+		if err != nil {
+			return err
+		}
+
+		s.log.Info("Force deleted folder", "path", folderPath, "deletedFilesCount", deletedFilesCount, "deletedMetaCount", deletedMetaCount, "deletedChunksCount", deletedChunksCount)
 		return nil
 	})
 
@@ -4,6 +4,7 @@ package filestorage
 
 import (
 	context "context"
+	io "io"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -78,6 +79,29 @@ func (_m *MockFileStorage) Get(ctx context.Context, path string) (*File, error)
 	return r0, r1
 }
 
+// GetReader provides a mock function with given fields: ctx, path, readRange
+func (_m *MockFileStorage) GetReader(ctx context.Context, path string, readRange *ReadRange) (*FileStream, error) {
+	ret := _m.Called(ctx, path, readRange)
+
+	var r0 *FileStream
+	if rf, ok := ret.Get(0).(func(context.Context, string, *ReadRange) *FileStream); ok {
+		r0 = rf(ctx, path, readRange)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*FileStream)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *ReadRange) error); ok {
+		r1 = rf(ctx, path, readRange)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // List provides a mock function with given fields: ctx, folderPath, paging, options
 func (_m *MockFileStorage) List(ctx context.Context, folderPath string, paging *Paging, options *ListOptions) (*ListResponse, error) {
 	ret := _m.Called(ctx, folderPath, paging, options)
@@ -115,6 +139,20 @@ func (_m *MockFileStorage) Upsert(ctx context.Context, command *UpsertFileComman
 	return r0
 }
 
+// UpsertReader provides a mock function with given fields: ctx, command, contents
+func (_m *MockFileStorage) UpsertReader(ctx context.Context, command *UpsertFileCommand, contents io.Reader) error {
+	ret := _m.Called(ctx, command, contents)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *UpsertFileCommand, io.Reader) error); ok {
+		r0 = rf(ctx, command, contents)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // close provides a mock function with given fields:
 func (_m *MockFileStorage) close() error {
 	ret := _m.Called()
@@ -1474,9 +1474,107 @@ func TestIntegrationFsStorage(t *testing.T) {
 		}
 	}
 
+	createStreamingCases := func() []fsTestCase {
+		contents := []byte("0123456789abcdefghij")
+		checksum := createContentsHash(contents)
+		return []fsTestCase{
+			{
+				name: "upserting and reading streams",
+				steps: []interface{}{
+					cmdUpsertReader{
+						cmd:      UpsertFileCommand{Path: "/folder/data.csv", Checksum: checksum},
+						contents: contents,
+					},
+					queryGet{
+						input:  queryGetInput{path: "/folder/data.csv"},
+						checks: checks(fContents(contents), fSize(20), fChecksum(checksum)),
+					},
+					queryGetReader{
+						input:  queryGetReaderInput{path: "/folder/data.csv"},
+						checks: checks(fContents(contents), fSize(20), fChecksum(checksum)),
+					},
+					queryGetReader{
+						input:  queryGetReaderInput{path: "/folder/data.csv", readRange: &ReadRange{Offset: 6, Length: 5}},
+						checks: checks(fContents([]byte("6789a")), fSize(20)),
+					},
+					queryGetReader{
+						input:  queryGetReaderInput{path: "/folder/data.csv", readRange: &ReadRange{Offset: 18}},
+						checks: checks(fContents([]byte("ij"))),
+					},
+					queryGetReader{
+						input: queryGetReaderInput{path: "/folder/data.csv", readRange: &ReadRange{Offset: 21}},
+						error: &cmdErrorOutput{instance: ErrInvalidRange},
+					},
+					queryGetReader{
+						input: queryGetReaderInput{path: "/folder/missing.csv"},
+					},
+				},
+			},
+			{
+				name: "overwriting and deleting streamed files",
+				steps: []interface{}{
+					cmdUpsertReader{
+						cmd:      UpsertFileCommand{Path: "/folder/data.csv"},
+						contents: contents,
+					},
+					cmdUpsert{
+						cmd: UpsertFileCommand{Path: "/folder/data.csv", Contents: []byte("small")},
+					},
+					queryGetReader{
+						input:  queryGetReaderInput{path: "/folder/data.csv"},
+						checks: checks(fContents([]byte("small")), fSize(5), fChecksum(createContentsHash([]byte("small")))),
+					},
+					cmdUpsertReader{
+						cmd:      UpsertFileCommand{Path: "/folder/data.csv"},
+						contents: contents,
+					},
+					queryListFiles{
+						input: queryListFilesInput{path: "/folder", options: &ListOptions{WithContents: true}},
+						list:  checks(listSize(1), listHasMore(false), listLastPath("/folder/data.csv")),
+						files: [][]interface{}{
+							checks(fContents(contents), fSize(20)),
+						},
+					},
+					cmdDelete{path: "/folder/data.csv"},
+					queryGet{
+						input: queryGetInput{path: "/folder/data.csv"},
+					},
+				},
+			},
+			{
+				name: "rejecting contents with invalid checksum",
+				steps: []interface{}{
+					cmdUpsertReader{
+						cmd:      UpsertFileCommand{Path: "/folder/data.csv", Checksum: createContentsHash([]byte("other"))},
+						contents: contents,
+						error:    &cmdErrorOutput{instance: ErrChecksumMismatch},
+					},
+					queryGet{
+						input: queryGetInput{path: "/folder/data.csv"},
+					},
+					cmdUpsert{
+						cmd:   UpsertFileCommand{Path: "/folder/data.csv", Contents: contents, Checksum: createContentsHash([]byte("other"))},
+						error: &cmdErrorOutput{instance: ErrChecksumMismatch},
+					},
+					queryGet{
+						input: queryGetInput{path: "/folder/data.csv"},
+					},
+				},
+			},
+		}
+	}
+
+	// use tiny chunks to store the streamed contents in multiple `file_chunk` rows
+	originalChunkSize := dbFileChunkSize
+	dbFileChunkSize = 8
+	defer func() {
+		dbFileChunkSize = originalChunkSize
+	}()
+
 	runTests(createListFoldersTests, t)
 	runTests(createListFilesTests, t)
 	runTests(createFileCRUDTests, t)
 	runTests(createFolderCrudCases, t)
 	runTests(createPathFiltersCases, t)
+	runTests(createStreamingCases, t)
 }
@@ -1,8 +1,10 @@
 package filestorage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -26,6 +28,12 @@ type cmdUpsert struct {
 	error *cmdErrorOutput
 }
 
+type cmdUpsertReader struct {
+	cmd      UpsertFileCommand
+	contents []byte
+	error    *cmdErrorOutput
+}
+
 type cmdCreateFolder struct {
 	path  string
 	error *cmdErrorOutput
@@ -41,6 +49,11 @@ type queryGetInput struct {
 	path string
 }
 
+type queryGetReaderInput struct {
+	path      string
+	readRange *ReadRange
+}
+
 type fileNameCheck struct {
 	v string
 }
@@ -61,6 +74,10 @@ type fileMimeTypeCheck struct {
 	v string
 }
 
+type fileChecksumCheck struct {
+	v string
+}
+
 type filePathCheck struct {
 	v string
 }
@@ -100,6 +117,10 @@ func fMimeType(mimeType string) interface{} {
 	return fileMimeTypeCheck{v: mimeType}
 }
 
+func fChecksum(checksum string) interface{} {
+	return fileChecksumCheck{v: checksum}
+}
+
 func listSize(size int) interface{} {
 	return listSizeCheck{v: size}
 }
@@ -121,6 +142,12 @@ type queryGet struct {
 	checks []interface{}
 }
 
+type queryGetReader struct {
+	input  queryGetReaderInput
+	checks []interface{}
+	error  *cmdErrorOutput
+}
+
 type queryListFilesInput struct {
 	path    string
 	paging  *Paging
@@ -169,6 +196,12 @@ func handleCommand(t *testing.T, ctx context.Context, cmd interface{}, cmdName s
 			require.NoError(t, err, "%s: should be able to upsert file %s", cmdName, c.cmd.Path)
 		}
 		expectedErr = c.error
+	case cmdUpsertReader:
+		err = fs.UpsertReader(ctx, &c.cmd, bytes.NewReader(c.contents))
+		if c.error == nil {
+			require.NoError(t, err, "%s: should be able to upsert file %s", cmdName, c.cmd.Path)
+		}
+		expectedErr = c.error
 	case cmdCreateFolder:
 		err = fs.CreateFolder(ctx, c.path)
 		if c.error == nil {
@@ -213,6 +246,8 @@ func runChecks(t *testing.T, stepName string, path string, output interface{}, c
 			require.Equal(t, c.v, file.MimeType, "%s-%s %s", stepName, checkName, path)
 		case filePathCheck:
 			require.Equal(t, c.v, file.FullPath, "%s-%s %s", stepName, checkName, path)
+		case fileChecksumCheck:
+			require.Equal(t, c.v, file.Checksum, "%s-%s %s", stepName, checkName, path)
 		default:
 			t.Fatalf("unrecognized file check %s", checkName)
 		}
@@ -280,6 +315,24 @@ func handleQuery(t *testing.T, ctx context.Context, query interface{}, queryName
 		} else {
 			require.Nil(t, file, "%s %s", queryName, inputPath)
 		}
+	case queryGetReader:
+		inputPath := q.input.path
+		stream, err := fs.GetReader(ctx, inputPath, q.input.readRange)
+		if q.error != nil {
+			require.ErrorIs(t, err, q.error.instance, "%s %s", queryName, inputPath)
+			return
+		}
+		require.NoError(t, err, "%s: should be able to get file reader %s", queryName, inputPath)
+
+		if q.checks != nil && len(q.checks) > 0 {
+			require.NotNil(t, stream, "%s %s", queryName, inputPath)
+			contents, err := io.ReadAll(stream.Reader)
+			require.NoError(t, err, "%s: should be able to read the stream %s", queryName, inputPath)
+			require.NoError(t, stream.Reader.Close())
+			runChecks(t, queryName, inputPath, &File{Contents: contents, FileMetadata: stream.FileMetadata}, q.checks)
+		} else {
+			require.Nil(t, stream, "%s %s", queryName, inputPath)
+		}
 	case queryListFiles:
 		inputPath := q.input.path
 		resp, err := fs.List(ctx, inputPath, q.input.paging, q.input.options)
@@ -345,8 +398,12 @@ func executeTestStep(t *testing.T, ctx context.Context, step interface{}, stepNu
 		handleQuery(t, ctx, s, name, fs)
 	case queryListFolders:
 		handleQuery(t, ctx, s, name, fs)
+	case queryGetReader:
+		handleQuery(t, ctx, s, name, fs)
 	case cmdUpsert:
 		handleCommand(t, ctx, s, name, fs)
+	case cmdUpsertReader:
+		handleCommand(t, ctx, s, name, fs)
 	case cmdDelete:
 		handleCommand(t, ctx, s, name, fs)
 	case cmdCreateFolder:
@@ -3,6 +3,7 @@ package filestorage
 import (
 	"context"
 	"fmt"
+	"io"
 	"mime"
 	"path/filepath"
 	"strings"
@@ -184,9 +185,61 @@ func (b wrapper) Upsert(ctx context.Context, file *UpsertFileCommand) error {
 		MimeType:   file.MimeType,
 		Contents:   file.Contents,
 		Properties: file.Properties,
+		Checksum:   file.Checksum,
 	})
 }
 
+func (b wrapper) GetReader(ctx context.Context, path string, readRange *ReadRange) (*FileStream, error) {
+	if err := b.validatePath(path); err != nil {
+		return nil, err
+	}
+
+	rootedPath := b.addRoot(path)
+	if !b.filter.IsAllowed(rootedPath) {
+		return nil, nil
+	}
+
+	if b.rootFolder == rootedPath {
+		return nil, nil
+	}
+
+	stream, err := b.wrapped.GetReader(ctx, rootedPath, readRange)
+	if stream != nil {
+		stream.FullPath = b.removeRoot(stream.FullPath)
+	}
+	return stream, err
+}
+
+func (b wrapper) UpsertReader(ctx context.Context, file *UpsertFileCommand, contents io.Reader) error {
+	if err := b.validatePath(file.Path); err != nil {
+		return err
+	}
+
+	rootedPath := b.addRoot(file.Path)
+	if !b.filter.IsAllowed(rootedPath) {
+		return nil
+	}
+
+	path := getParentFolderPath(file.Path)
+	b.log.Info("Creating folder before upserting file", "file", file.Path, "folder", path)
+	if err := b.CreateFolder(ctx, path); err != nil {
+		return err
+	}
+
+	if file.MimeType == "" {
+		file.MimeType = detectContentType(file.Path, "")
+	}
+
+	return b.wrapped.UpsertReader(ctx, &UpsertFileCommand{
+		Path:               rootedPath,
+		MimeType:           file.MimeType,
+		CacheControl:       file.CacheControl,
+		ContentDisposition: file.ContentDisposition,
+		Properties:         file.Properties,
+		Checksum:           file.Checksum,
+	}, contents)
+}
+
 func (b wrapper) pagingOptionsWithDefaults(paging *Paging) *Paging {
 	if paging == nil {
 		return &Paging{
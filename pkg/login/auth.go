@@ -3,11 +3,14 @@ package login
 import (
 	"context"
 	"errors"
+	"net"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/ldap"
 	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/user"
 )
@@ -27,6 +30,8 @@ var (
 
 var loginLogger = log.New("login")
 
+const accountLockedEmailTemplate = "account_locked"
+
 type Authenticator interface {
 	AuthenticateUser(context.Context, *models.LoginUserQuery) error
 }
@@ -35,20 +40,27 @@ type AuthenticatorService struct {
 	store        sqlstore.Store
 	loginService login.Service
 	userService  user.Service
+	emailSender  notifications.EmailSender
 }
 
-func ProvideService(store sqlstore.Store, loginService login.Service, userService user.Service) *AuthenticatorService {
+func ProvideService(store sqlstore.Store, loginService login.Service, userService user.Service,
+	emailSender notifications.EmailSender) *AuthenticatorService {
 	a := &AuthenticatorService{
 		store:        store,
 		loginService: loginService,
 		userService:  userService,
+		emailSender:  emailSender,
 	}
 	return a
 }
 
 // AuthenticateUser authenticates the user via username & password
 func (a *AuthenticatorService) AuthenticateUser(ctx context.Context, query *models.LoginUserQuery) error {
-	if err := validateLoginAttempts(ctx, query, a.store); err != nil {
+	lockout, err := validateLoginAttempts(ctx, query, a.store)
+	if lockout != nil {
+		a.notifyLockout(ctx, query, lockout)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -56,7 +68,7 @@ func (a *AuthenticatorService) AuthenticateUser(ctx context.Context, query *mode
 		return err
 	}
 
-	err := loginUsingGrafanaDB(ctx, query, a.userService)
+	err = loginUsingGrafanaDB(ctx, query, a.userService)
 	if err == nil || (!errors.Is(err, user.ErrUserNotFound) && !errors.Is(err, ErrInvalidCredentials) &&
 		!errors.Is(err, ErrUserDisabled)) {
 		query.AuthModule = "grafana"
@@ -86,6 +98,42 @@ func (a *AuthenticatorService) AuthenticateUser(ctx context.Context, query *mode
 	return err
 }
 
+// notifyLockout tells the user by email that their account is temporarily locked, so that they
+// learn about attacks on their password.
+func (a *AuthenticatorService) notifyLockout(ctx context.Context, query *models.LoginUserQuery, lockout *models.LoginLockout) {
+	if !query.Cfg.BruteForce.NotifyUser || a.emailSender == nil {
+		return
+	}
+
+	usr, err := a.userService.GetByLogin(ctx, &user.GetUserByLoginQuery{LoginOrEmail: query.Username})
+	if err != nil {
+		if !errors.Is(err, user.ErrUserNotFound) {
+			loginLogger.Error("Failed to get locked out user", "username", query.Username, "err", err)
+		}
+		return
+	}
+	if usr.Email == "" {
+		return
+	}
+
+	ipAddress, _, err := net.SplitHostPort(query.IpAddress)
+	if err != nil {
+		ipAddress = query.IpAddress
+	}
+
+	if err := a.emailSender.SendEmailCommandHandler(ctx, &models.SendEmailCommand{
+		To:       []string{usr.Email},
+		Template: accountLockedEmailTemplate,
+		Data: map[string]interface{}{
+			"Name":        usr.NameOrFallback(),
+			"LockedUntil": lockout.LockedUntil.UTC().Format(time.RFC1123),
+			"IpAddress":   ipAddress,
+		},
+	}); err != nil {
+		loginLogger.Error("Failed to send account locked email", "userId", usr.ID, "err", err)
+	}
+}
+
 func validatePasswordSet(password string) error {
 	if len(password) == 0 {
 		return ErrPasswordEmpty
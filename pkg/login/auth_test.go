@@ -4,14 +4,18 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/ldap"
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/login/logintest"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/mockstore"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -52,6 +56,33 @@ func TestAuthenticateUser(t *testing.T) {
 		assert.Empty(t, sc.loginUserQuery.AuthModule)
 	})
 
+	authScenario(t, "When a user gets locked out by too many login attempts", func(sc *authScenarioContext) {
+		lockedUntil := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		validateLoginAttempts = func(context.Context, *models.LoginUserQuery, sqlstore.Store) (*models.LoginLockout, error) {
+			sc.loginAttemptValidationWasCalled = true
+			return &models.LoginLockout{Kind: models.LoginLockoutUser, Source: "user", LockedUntil: lockedUntil}, ErrTooManyLoginAttempts
+		}
+
+		cfg := setting.NewCfg()
+		cfg.BruteForce.NotifyUser = true
+		sc.loginUserQuery.Cfg = cfg
+
+		userService := usertest.NewUserServiceFake()
+		userService.ExpectedUser = &user.User{ID: 1, Login: "user", Email: "user@grafana.com", Name: "User"}
+		emailSender := &notifications.NotificationServiceMock{}
+
+		a := AuthenticatorService{store: mockstore.NewSQLStoreMock(), loginService: &logintest.LoginServiceFake{},
+			userService: userService, emailSender: emailSender}
+		err := a.AuthenticateUser(context.Background(), sc.loginUserQuery)
+
+		require.EqualError(t, err, ErrTooManyLoginAttempts.Error())
+		assert.True(t, sc.loginAttemptValidationWasCalled)
+		assert.Equal(t, []string{"user@grafana.com"}, emailSender.Email.To)
+		assert.Equal(t, accountLockedEmailTemplate, emailSender.Email.Template)
+		assert.Equal(t, "192.168.1.1", emailSender.Email.Data["IpAddress"])
+		assert.Equal(t, lockedUntil.Format(time.RFC1123), emailSender.Email.Data["LockedUntil"])
+	})
+
 	authScenario(t, "When grafana user authenticate with valid credentials", func(sc *authScenarioContext) {
 		mockLoginAttemptValidation(nil, sc)
 		mockLoginUsingGrafanaDB(nil, sc)
@@ -198,9 +229,9 @@ func mockLoginUsingLDAP(enabled bool, err error, sc *authScenarioContext) {
 }
 
 func mockLoginAttemptValidation(err error, sc *authScenarioContext) {
-	validateLoginAttempts = func(context.Context, *models.LoginUserQuery, sqlstore.Store) error {
+	validateLoginAttempts = func(context.Context, *models.LoginUserQuery, sqlstore.Store) (*models.LoginLockout, error) {
 		sc.loginAttemptValidationWasCalled = true
-		return err
+		return nil, err
 	}
 }
 
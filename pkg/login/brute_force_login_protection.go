@@ -2,36 +2,186 @@ package login
 
 import (
 	"context"
+	"errors"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var (
 	maxInvalidLoginAttempts int64 = 5
 	loginAttemptsWindow           = time.Minute * 5
+	loginLockoutDuration          = time.Minute * 5
+	loginLockoutMaxDuration       = time.Hour
+	ipv4SubnetPrefixLength        = 32
+	ipv6SubnetPrefixLength        = 64
+
+	// loginLockoutDurationLimit caps the configured lockout durations, since anyone knowing a
+	// username can lock it out
+	loginLockoutDurationLimit = time.Hour * 24
 )
 
-var validateLoginAttempts = func(ctx context.Context, query *models.LoginUserQuery, store sqlstore.Store) error {
+var getTimeNow = time.Now
+
+// bruteForcePolicy returns the brute force settings of the configuration, using the defaults for the
+// unset values.
+func bruteForcePolicy(cfg *setting.Cfg) setting.BruteForceSettings {
+	policy := cfg.BruteForce
+	if policy.UserMaxAttempts <= 0 {
+		policy.UserMaxAttempts = maxInvalidLoginAttempts
+	}
+	if policy.UserWindow <= 0 {
+		policy.UserWindow = loginAttemptsWindow
+	}
+	if policy.IPWindow <= 0 {
+		policy.IPWindow = loginAttemptsWindow
+	}
+	if policy.IPv4PrefixLength <= 0 || policy.IPv4PrefixLength > 32 {
+		policy.IPv4PrefixLength = ipv4SubnetPrefixLength
+	}
+	if policy.IPv6PrefixLength <= 0 || policy.IPv6PrefixLength > 128 {
+		policy.IPv6PrefixLength = ipv6SubnetPrefixLength
+	}
+	if policy.LockoutDuration <= 0 {
+		policy.LockoutDuration = loginLockoutDuration
+	}
+	if policy.LockoutMaxDuration < policy.LockoutDuration {
+		policy.LockoutMaxDuration = loginLockoutMaxDuration
+	}
+	if policy.LockoutMaxDuration > loginLockoutDurationLimit {
+		policy.LockoutMaxDuration = loginLockoutDurationLimit
+	}
+	if policy.LockoutDuration > policy.LockoutMaxDuration {
+		policy.LockoutDuration = policy.LockoutMaxDuration
+	}
+	return policy
+}
+
+// ClientSubnet returns the subnet, in CIDR notation, by which the failed login attempts of a client
+// address are grouped. The address may contain a port. An empty string is returned when the address
+// isn't an IP address.
+func ClientSubnet(cfg *setting.Cfg, address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	if ip == nil {
+		return ""
+	}
+
+	policy := bruteForcePolicy(cfg)
+	bits, prefixLength := 128, policy.IPv6PrefixLength
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip, bits, prefixLength = ipv4, 32, policy.IPv4PrefixLength
+	}
+
+	mask := net.CIDRMask(prefixLength, bits)
+	subnet := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	return subnet.String()
+}
+
+// lockoutDuration doubles the lockout duration for each consecutive lockout of a source.
+func lockoutDuration(policy setting.BruteForceSettings, lockouts int64) time.Duration {
+	duration := policy.LockoutDuration
+	for i := int64(1); i < lockouts && duration < policy.LockoutMaxDuration; i++ {
+		duration *= 2
+	}
+	if duration > policy.LockoutMaxDuration {
+		duration = policy.LockoutMaxDuration
+	}
+	return duration
+}
+
+// validateLoginAttempts returns ErrTooManyLoginAttempts when the username or the subnet of the client
+// are locked out. A source which reached its limit of failed login attempts is locked out by the
+// next attempt, and a new lockout of the username is returned so that the user can be notified.
+var validateLoginAttempts = func(ctx context.Context, query *models.LoginUserQuery, store sqlstore.Store) (*models.LoginLockout, error) {
 	if query.Cfg.DisableBruteForceLoginProtection {
-		return nil
+		return nil, nil
+	}
+
+	policy := bruteForcePolicy(query.Cfg)
+	now := getTimeNow()
+
+	if subnet := ClientSubnet(query.Cfg, query.IpAddress); subnet != "" && policy.IPMaxAttempts > 0 {
+		countAttempts := func(since time.Time) (int64, error) {
+			countQuery := models.GetIPLoginAttemptCountQuery{IpSubnet: subnet, Since: since}
+			err := store.GetIPLoginAttemptCount(ctx, &countQuery)
+			return countQuery.Result, err
+		}
+
+		if _, err := checkLoginLockout(ctx, store, policy, now, models.LoginLockoutIP, subnet,
+			policy.IPMaxAttempts, policy.IPWindow, countAttempts); err != nil {
+			return nil, err
+		}
+	}
+
+	countAttempts := func(since time.Time) (int64, error) {
+		countQuery := models.GetUserLoginAttemptCountQuery{Username: query.Username, Since: since}
+		err := store.GetUserLoginAttemptCount(ctx, &countQuery)
+		return countQuery.Result, err
+	}
+
+	return checkLoginLockout(ctx, store, policy, now, models.LoginLockoutUser, query.Username,
+		policy.UserMaxAttempts, policy.UserWindow, countAttempts)
+}
+
+func checkLoginLockout(ctx context.Context, store sqlstore.Store, policy setting.BruteForceSettings, now time.Time,
+	kind models.LoginLockoutKind, source string, maxAttempts int64, window time.Duration,
+	countAttempts func(since time.Time) (int64, error)) (*models.LoginLockout, error) {
+	lockoutQuery := models.GetLoginLockoutQuery{Kind: kind, Source: source}
+	if err := store.GetLoginLockout(ctx, &lockoutQuery); err != nil && !errors.Is(err, models.ErrLoginLockoutNotFound) {
+		return nil, err
 	}
 
-	loginAttemptCountQuery := models.GetUserLoginAttemptCountQuery{
-		Username: query.Username,
-		Since:    time.Now().Add(-loginAttemptsWindow),
+	previous := lockoutQuery.Result
+	if previous != nil && previous.IsActive(now) {
+		return nil, ErrTooManyLoginAttempts
 	}
 
-	if err := store.GetUserLoginAttemptCount(ctx, &loginAttemptCountQuery); err != nil {
-		return err
+	// attempts which caused the previous lockout don't count again
+	since := now.Add(-window)
+	if previous != nil && previous.LockedUntil.After(since) {
+		since = previous.LockedUntil
+	}
+
+	attempts, err := countAttempts(since)
+	if err != nil {
+		return nil, err
+	}
+	if attempts < maxAttempts {
+		return nil, nil
 	}
 
-	if loginAttemptCountQuery.Result >= maxInvalidLoginAttempts {
-		return ErrTooManyLoginAttempts
+	// the lockout duration grows until the source stays out of trouble for the maximum duration
+	lockouts := int64(1)
+	if previous != nil && previous.LockedUntil.Add(policy.LockoutMaxDuration).After(now) {
+		lockouts = previous.Lockouts + 1
 	}
 
-	return nil
+	cmd := models.SaveLoginLockoutCommand{
+		Kind:        kind,
+		Source:      source,
+		Lockouts:    lockouts,
+		LockedUntil: now.Add(lockoutDuration(policy, lockouts)),
+	}
+	if err := store.SaveLoginLockout(ctx, &cmd); err != nil {
+		return nil, err
+	}
+
+	loginLogger.Warn("Login temporarily blocked after too many failed login attempts", "kind", kind,
+		"source", source, "attempts", attempts, "lockedUntil", cmd.LockedUntil)
+
+	if kind != models.LoginLockoutUser {
+		return nil, ErrTooManyLoginAttempts
+	}
+	return cmd.Result, ErrTooManyLoginAttempts
 }
 
 var saveInvalidLoginAttempt = func(ctx context.Context, query *models.LoginUserQuery, store sqlstore.Store) error {
@@ -42,6 +192,7 @@ var saveInvalidLoginAttempt = func(ctx context.Context, query *models.LoginUserQ
 	loginAttemptCommand := models.CreateLoginAttemptCommand{
 		Username:  query.Username,
 		IpAddress: query.IpAddress,
+		IpSubnet:  ClientSubnet(query.Cfg, query.IpAddress),
 	}
 
 	return store.CreateLoginAttempt(ctx, &loginAttemptCommand)
@@ -2,9 +2,12 @@ package login
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/mockstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/stretchr/testify/assert"
@@ -64,12 +67,155 @@ func TestValidateLoginAttempts(t *testing.T) {
 
 			query := &models.LoginUserQuery{Username: "user", Cfg: tc.cfg}
 
-			err := validateLoginAttempts(context.Background(), query, store)
+			_, err := validateLoginAttempts(context.Background(), query, store)
 			require.Equal(t, tc.expected, err)
 		})
 	}
 }
 
+func TestValidateLoginAttemptsLockout(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	origGetTimeNow := getTimeNow
+	getTimeNow = func() time.Time { return now }
+	t.Cleanup(func() { getTimeNow = origGetTimeNow })
+
+	query := &models.LoginUserQuery{Username: "user", IpAddress: "192.168.1.1:56433", Cfg: cfgWithBruteForceLoginProtectionEnabled(t)}
+
+	t.Run("should lock out the username when reaching max attempts", func(t *testing.T) {
+		store := mockstore.NewSQLStoreMock()
+		store.ExpectedLoginAttempts = maxInvalidLoginAttempts
+
+		lockout, err := validateLoginAttempts(context.Background(), query, store)
+		require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		require.NotNil(t, lockout)
+		assert.Equal(t, models.LoginLockoutUser, lockout.Kind)
+		assert.Equal(t, "user", lockout.Source)
+		assert.Equal(t, int64(1), lockout.Lockouts)
+		assert.Equal(t, now.Add(loginLockoutDuration), lockout.LockedUntil)
+	})
+
+	t.Run("should reject logins during a lockout without counting attempts", func(t *testing.T) {
+		store := mockstore.NewSQLStoreMock()
+		store.ExpectedLoginLockouts = []*models.LoginLockout{
+			{Kind: models.LoginLockoutUser, Source: "user", Lockouts: 1, LockedUntil: now.Add(time.Minute)},
+		}
+
+		lockout, err := validateLoginAttempts(context.Background(), query, store)
+		require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		require.Nil(t, lockout)
+	})
+
+	t.Run("should double the lockout duration for consecutive lockouts", func(t *testing.T) {
+		store := mockstore.NewSQLStoreMock()
+		store.ExpectedLoginAttempts = maxInvalidLoginAttempts
+		store.ExpectedLoginLockouts = []*models.LoginLockout{
+			{Kind: models.LoginLockoutUser, Source: "user", Lockouts: 2, LockedUntil: now.Add(-time.Minute)},
+		}
+
+		lockout, err := validateLoginAttempts(context.Background(), query, store)
+		require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		require.NotNil(t, lockout)
+		assert.Equal(t, int64(3), lockout.Lockouts)
+		assert.Equal(t, now.Add(4*loginLockoutDuration), lockout.LockedUntil)
+	})
+
+	t.Run("should start over after the maximum lockout duration", func(t *testing.T) {
+		store := mockstore.NewSQLStoreMock()
+		store.ExpectedLoginAttempts = maxInvalidLoginAttempts
+		store.ExpectedLoginLockouts = []*models.LoginLockout{
+			{Kind: models.LoginLockoutUser, Source: "user", Lockouts: 5, LockedUntil: now.Add(-loginLockoutMaxDuration - time.Minute)},
+		}
+
+		lockout, err := validateLoginAttempts(context.Background(), query, store)
+		require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		require.NotNil(t, lockout)
+		assert.Equal(t, int64(1), lockout.Lockouts)
+		assert.Equal(t, now.Add(loginLockoutDuration), lockout.LockedUntil)
+	})
+
+	t.Run("should cap the lockout duration", func(t *testing.T) {
+		cfg := cfgWithBruteForceLoginProtectionEnabled(t)
+		cfg.BruteForce.LockoutDuration = 48 * time.Hour
+		cfg.BruteForce.LockoutMaxDuration = 72 * time.Hour
+
+		store := mockstore.NewSQLStoreMock()
+		store.ExpectedLoginAttempts = maxInvalidLoginAttempts
+		store.ExpectedLoginLockouts = []*models.LoginLockout{
+			{Kind: models.LoginLockoutUser, Source: "user", Lockouts: 3, LockedUntil: now.Add(-time.Minute)},
+		}
+
+		lockout, err := validateLoginAttempts(context.Background(), &models.LoginUserQuery{
+			Username: "user", IpAddress: "192.168.1.1:56433", Cfg: cfg,
+		}, store)
+		require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		require.NotNil(t, lockout)
+		assert.Equal(t, now.Add(loginLockoutDurationLimit), lockout.LockedUntil)
+	})
+
+	t.Run("should lock out the subnet of the client when reaching max attempts", func(t *testing.T) {
+		cfg := cfgWithBruteForceLoginProtectionEnabled(t)
+		cfg.BruteForce.IPMaxAttempts = 20
+		cfg.BruteForce.IPv4PrefixLength = 24
+
+		store := mockstore.NewSQLStoreMock()
+		store.ExpectedIPLoginAttempts = 20
+
+		lockout, err := validateLoginAttempts(context.Background(), &models.LoginUserQuery{
+			Username: "other", IpAddress: "192.168.1.1:56433", Cfg: cfg,
+		}, store)
+		require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		require.Nil(t, lockout)
+
+		require.Len(t, store.ExpectedLoginLockouts, 1)
+		assert.Equal(t, models.LoginLockoutIP, store.ExpectedLoginLockouts[0].Kind)
+		assert.Equal(t, "192.168.1.0/24", store.ExpectedLoginLockouts[0].Source)
+
+		_, err = validateLoginAttempts(context.Background(), &models.LoginUserQuery{
+			Username: "another", IpAddress: "192.168.1.200:1234", Cfg: cfg,
+		}, store)
+		require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+	})
+}
+
+func TestIntegrationValidateLoginAttemptsAfterDeletingLockout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := sqlstore.InitTestDB(t)
+	ctx := context.Background()
+
+	cfg := cfgWithBruteForceLoginProtectionEnabled(t)
+	cfg.BruteForce.IPMaxAttempts = 3
+	cfg.BruteForce.IPv4PrefixLength = 24
+	query := &models.LoginUserQuery{Username: "user", IpAddress: "192.168.1.1:56433", Cfg: cfg}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, saveInvalidLoginAttempt(ctx, &models.LoginUserQuery{
+			Username: fmt.Sprintf("user-%d", i), IpAddress: "192.168.1.1:56433", Cfg: cfg,
+		}, store))
+	}
+	_, err := validateLoginAttempts(ctx, query, store)
+	require.ErrorIs(t, err, ErrTooManyLoginAttempts)
+
+	lockoutsQuery := models.SearchLoginLockoutsQuery{}
+	require.NoError(t, store.SearchLoginLockouts(ctx, &lockoutsQuery))
+	require.Len(t, lockoutsQuery.Result, 1)
+	require.NoError(t, store.DeleteLoginLockout(ctx, &models.DeleteLoginLockoutCommand{Id: lockoutsQuery.Result[0].Id}))
+
+	_, err = validateLoginAttempts(ctx, query, store)
+	require.NoError(t, err)
+}
+
+func TestClientSubnet(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.BruteForce.IPv4PrefixLength = 24
+
+	assert.Equal(t, "192.168.1.0/24", ClientSubnet(cfg, "192.168.1.12:56433"))
+	assert.Equal(t, "192.168.1.0/24", ClientSubnet(cfg, "192.168.1.12"))
+	assert.Equal(t, "2001:db8:85a3::/64", ClientSubnet(cfg, "[2001:db8:85a3::8a2e:370:7334]:56433"))
+	assert.Equal(t, "", ClientSubnet(cfg, "@"))
+}
+
 func TestSaveInvalidLoginAttempt(t *testing.T) {
 	t.Run("When brute force protection enabled", func(t *testing.T) {
 		store := mockstore.NewSQLStoreMock()
@@ -84,6 +230,7 @@ func TestSaveInvalidLoginAttempt(t *testing.T) {
 		require.NotNil(t, store.LastLoginAttemptCommand)
 		assert.Equal(t, "user", store.LastLoginAttemptCommand.Username)
 		assert.Equal(t, "192.168.1.1:56433", store.LastLoginAttemptCommand.IpAddress)
+		assert.Equal(t, "192.168.1.1/32", store.LastLoginAttemptCommand.IpSubnet)
 	})
 
 	t.Run("When brute force protection disabled", func(t *testing.T) {
@@ -0,0 +1,160 @@
+package saml
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	crewjamsaml "github.com/crewjam/saml"
+
+	"github.com/grafana/grafana/pkg/login"
+	"github.com/grafana/grafana/pkg/models"
+	loginService "github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+var ErrMissingNameID = errors.New("SAML assertion does not contain a NameID")
+
+var nameTemplateVariable = regexp.MustCompile(`\$__saml\{([^}]*)\}`)
+
+// nameTemplate is the parsed assertion_attribute_name option, either a plain
+// attribute name or a template referencing attributes as $__saml{attribute}.
+type nameTemplate struct {
+	template   string
+	attributes []string
+}
+
+func parseNameTemplate(template string) (*nameTemplate, error) {
+	t := &nameTemplate{template: template}
+	if !strings.Contains(template, "$__saml") {
+		return t, nil
+	}
+	for _, match := range nameTemplateVariable.FindAllStringSubmatch(template, -1) {
+		if strings.TrimSpace(match[1]) == "" {
+			return nil, fmt.Errorf("invalid assertion_attribute_name %q: empty attribute name", template)
+		}
+		t.attributes = append(t.attributes, match[1])
+	}
+	if len(t.attributes) == 0 || strings.Contains(nameTemplateVariable.ReplaceAllString(template, ""), "$__saml") {
+		return nil, fmt.Errorf("invalid assertion_attribute_name %q", template)
+	}
+	return t, nil
+}
+
+func (t *nameTemplate) execute(attrs attributes) (string, error) {
+	if len(t.attributes) == 0 {
+		return attrs.first(t.template), nil
+	}
+	var missing error
+	name := nameTemplateVariable.ReplaceAllStringFunc(t.template, func(variable string) string {
+		attribute := nameTemplateVariable.FindStringSubmatch(variable)[1]
+		if _, ok := attrs[attribute]; !ok && missing == nil {
+			missing = fmt.Errorf("attribute %q used in assertion_attribute_name is missing from the SAML assertion", attribute)
+		}
+		return attrs.first(attribute)
+	})
+	return strings.TrimSpace(name), missing
+}
+
+// attributes holds the assertion attribute values indexed by both name and friendly name.
+type attributes map[string][]string
+
+func newAttributes(assertion *crewjamsaml.Assertion) attributes {
+	attrs := attributes{}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			var values []string
+			for _, v := range attr.Values {
+				values = append(values, v.Value)
+			}
+			if attr.Name != "" {
+				attrs[attr.Name] = append(attrs[attr.Name], values...)
+			}
+			if attr.FriendlyName != "" && attr.FriendlyName != attr.Name {
+				attrs[attr.FriendlyName] = append(attrs[attr.FriendlyName], values...)
+			}
+		}
+	}
+	return attrs
+}
+
+func (a attributes) first(name string) string {
+	if name == "" || len(a[name]) == 0 {
+		return ""
+	}
+	return a[name][0]
+}
+
+// ExternalUserInfo maps a validated assertion to the user synced by login.UpsertUser.
+func (s *Service) ExternalUserInfo(assertion *crewjamsaml.Assertion) (*models.ExternalUserInfo, error) {
+	if assertion.Subject == nil || assertion.Subject.NameID == nil || assertion.Subject.NameID.Value == "" {
+		return nil, ErrMissingNameID
+	}
+
+	attrs := newAttributes(assertion)
+	extUser := &models.ExternalUserInfo{
+		AuthModule: loginService.SAMLAuthModule,
+		AuthId:     assertion.Subject.NameID.Value,
+		Email:      attrs.first(s.settings.AttributeEmail),
+		Login:      attrs.first(s.settings.AttributeLogin),
+		OrgRoles:   map[int64]org.RoleType{},
+	}
+	if s.settings.AttributeGroups != "" {
+		extUser.Groups = attrs[s.settings.AttributeGroups]
+	}
+
+	name, err := s.name.execute(attrs)
+	if err != nil {
+		return nil, err
+	}
+	extUser.Name = name
+
+	if extUser.Email == "" {
+		return nil, login.ErrNoEmail
+	}
+	if extUser.Login == "" {
+		extUser.Login = extUser.Email
+	}
+
+	if s.settings.AttributeRole != "" {
+		role, isGrafanaAdmin := s.mapRole(attrs[s.settings.AttributeRole])
+		extUser.OrgRoles[s.defaultOrgID()] = role
+		extUser.IsGrafanaAdmin = &isGrafanaAdmin
+	}
+
+	return extUser, nil
+}
+
+// mapRole returns the highest role matched by the assertion role values,
+// defaulting to Viewer when role sync is configured but nothing matches.
+func (s *Service) mapRole(values []string) (org.RoleType, bool) {
+	contains := func(allowed []string) bool {
+		for _, v := range values {
+			for _, a := range allowed {
+				if v == a {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch {
+	case contains(s.settings.RoleValuesGrafanaAdmin):
+		return org.RoleAdmin, true
+	case contains(s.settings.RoleValuesAdmin):
+		return org.RoleAdmin, false
+	case contains(s.settings.RoleValuesEditor):
+		return org.RoleEditor, false
+	}
+	return org.RoleViewer, false
+}
+
+// defaultOrgID is the organization synced roles are assigned to.
+func (s *Service) defaultOrgID() int64 {
+	if s.cfg.AutoAssignOrg && s.cfg.AutoAssignOrgId > 0 {
+		return int64(s.cfg.AutoAssignOrgId)
+	}
+	return 1
+}
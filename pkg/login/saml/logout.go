@@ -0,0 +1,209 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	crewjamsaml "github.com/crewjam/saml"
+	xrv "github.com/mattermost/xml-roundtrip-validator"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+var (
+	ErrLogoutRequestNotSigned = errors.New("SAML logout request is not signed")
+	ErrLogoutRequestTooLarge  = errors.New("SAML logout request is too large")
+)
+
+// maxInflatedRequestSize caps the size of the requests sent with the HTTP-Redirect binding.
+const maxInflatedRequestSize = 1 << 20
+
+var querySignatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	dsig.RSASHA1SignatureMethod:   x509.SHA1WithRSA,
+	dsig.RSASHA256SignatureMethod: x509.SHA256WithRSA,
+	dsig.RSASHA512SignatureMethod: x509.SHA512WithRSA,
+}
+
+// ParseLogoutRequest validates a IdP initiated logout request sent with
+// either the HTTP-Redirect or the HTTP-POST binding. The request must be
+// signed by one of the IdP signing certificates.
+func (s *Service) ParseLogoutRequest(r *http.Request) (*crewjamsaml.LogoutRequest, error) {
+	sp, err := s.serviceProvider(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	certs, err := idpSigningCertificates(sp.IDPMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	if r.Method == http.MethodGet {
+		// the signature covers the encoded request, so it is verified before inflating it
+		if err := validateQuerySignature(r.URL.RawQuery, certs); err != nil {
+			return nil, err
+		}
+		raw, err = inflate(r.URL.Query().Get("SAMLRequest"))
+		if err != nil {
+			return nil, err
+		}
+		if err := xrv.Validate(bytes.NewReader(raw)); err != nil {
+			return nil, fmt.Errorf("SAML request contains invalid XML: %w", err)
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		raw, err = base64.StdEncoding.DecodeString(r.PostForm.Get("SAMLRequest"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode SAML request: %w", err)
+		}
+		if err := xrv.Validate(bytes.NewReader(raw)); err != nil {
+			return nil, fmt.Errorf("SAML request contains invalid XML: %w", err)
+		}
+		// only the signed element is read, so that no unsigned content is trusted
+		raw, err = validateSignedElement(raw, certs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req := &crewjamsaml.LogoutRequest{}
+	if err := xml.Unmarshal(raw, req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal SAML logout request: %w", err)
+	}
+
+	switch {
+	case req.Issuer == nil || req.Issuer.Value != sp.IDPMetadata.EntityID:
+		return nil, fmt.Errorf("logout request issuer does not match the IdP entity ID %q", sp.IDPMetadata.EntityID)
+	case req.Destination != "" && req.Destination != sp.SloURL.String():
+		return nil, fmt.Errorf("logout request destination does not match %q", sp.SloURL.String())
+	case req.IssueInstant.Add(crewjamsaml.MaxIssueDelay).Before(time.Now()):
+		return nil, errors.New("logout request has expired")
+	case req.NameID == nil || req.NameID.Value == "":
+		return nil, ErrMissingNameID
+	}
+	return req, nil
+}
+
+func inflate(encoded string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode SAML request: %w", err)
+	}
+	raw, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), maxInflatedRequestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate SAML request: %w", err)
+	}
+	if len(raw) > maxInflatedRequestSize {
+		return nil, ErrLogoutRequestTooLarge
+	}
+	return raw, nil
+}
+
+// validateQuerySignature verifies the HTTP-Redirect binding signature which
+// covers the raw SAMLRequest, RelayState and SigAlg query parameters.
+func validateQuerySignature(rawQuery string, certs []*x509.Certificate) error {
+	rawValues := map[string]string{}
+	for _, part := range strings.Split(rawQuery, "&") {
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			rawValues[kv[0]] = kv[1]
+		}
+	}
+	if rawValues["Signature"] == "" || rawValues["SigAlg"] == "" {
+		return ErrLogoutRequestNotSigned
+	}
+
+	sigAlg, err := url.QueryUnescape(rawValues["SigAlg"])
+	if err != nil {
+		return err
+	}
+	algorithm, ok := querySignatureAlgorithms[sigAlg]
+	if !ok {
+		return fmt.Errorf("unsupported signature algorithm %q", sigAlg)
+	}
+	encodedSignature, err := url.QueryUnescape(rawValues["Signature"])
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	signed := "SAMLRequest=" + rawValues["SAMLRequest"]
+	if relayState, ok := rawValues["RelayState"]; ok {
+		signed += "&RelayState=" + relayState
+	}
+	signed += "&SigAlg=" + rawValues["SigAlg"]
+
+	for _, cert := range certs {
+		if cert.CheckSignature(algorithm, []byte(signed), signature) == nil {
+			return nil
+		}
+	}
+	return errors.New("invalid SAML logout request signature")
+}
+
+// validateSignedElement verifies the enveloped XML signature of the HTTP-POST binding
+// and returns the signed element.
+func validateSignedElement(raw []byte, certs []*x509.Certificate) ([]byte, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		return nil, err
+	}
+	if doc.Root() == nil || doc.Root().FindElement("./Signature") == nil {
+		return nil, ErrLogoutRequestNotSigned
+	}
+
+	validationContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs})
+	validationContext.IdAttribute = "ID"
+	validated, err := validationContext.Validate(doc.Root())
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML logout request signature: %w", err)
+	}
+
+	signed := etree.NewDocument()
+	signed.SetRoot(validated)
+	return signed.WriteToBytes()
+}
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// idpSigningCertificates returns the certificates the IdP signs messages with.
+func idpSigningCertificates(metadata *crewjamsaml.EntityDescriptor) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, descriptor := range metadata.IDPSSODescriptors {
+		for _, keyDescriptor := range descriptor.KeyDescriptors {
+			if keyDescriptor.Use != "" && keyDescriptor.Use != "signing" {
+				continue
+			}
+			for _, c := range keyDescriptor.KeyInfo.X509Data.X509Certificates {
+				data, err := base64.StdEncoding.DecodeString(whitespace.ReplaceAllString(c.Data, ""))
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode IdP certificate: %w", err)
+				}
+				cert, err := x509.ParseCertificate(data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse IdP certificate: %w", err)
+				}
+				certs = append(certs, cert)
+			}
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("IdP metadata does not contain a signing certificate")
+	}
+	return certs, nil
+}
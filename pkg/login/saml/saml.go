@@ -0,0 +1,439 @@
+package saml
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	crewjamsaml "github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	ErrNotEnabled             = errors.New("SAML authentication is not enabled")
+	ErrIDPInitiatedNotAllowed = errors.New("IdP initiated login is not allowed")
+	ErrInvalidRelayState      = errors.New("invalid SAML relay state")
+	ErrNoSingleLogoutService  = errors.New("IdP metadata does not define a single logout service")
+)
+
+var signatureAlgorithms = map[string]string{
+	"rsa-sha1":   dsig.RSASHA1SignatureMethod,
+	"rsa-sha256": dsig.RSASHA256SignatureMethod,
+	"rsa-sha512": dsig.RSASHA512SignatureMethod,
+}
+
+// Settings holds the [auth.saml] configuration.
+type Settings struct {
+	Enabled            bool
+	Name               string
+	SingleLogout       bool
+	AllowSignUp        bool
+	AllowIDPInitiated  bool
+	RelayState         string
+	SignatureAlgorithm string
+
+	Certificate     string
+	CertificatePath string
+	PrivateKey      string
+	PrivateKeyPath  string
+
+	IDPMetadata     string
+	IDPMetadataPath string
+	IDPMetadataURL  string
+
+	MaxIssueDelay         time.Duration
+	MetadataValidDuration time.Duration
+
+	AttributeName   string
+	AttributeLogin  string
+	AttributeEmail  string
+	AttributeGroups string
+	AttributeRole   string
+
+	RoleValuesEditor       []string
+	RoleValuesAdmin        []string
+	RoleValuesGrafanaAdmin []string
+}
+
+func readSettings(cfg *setting.Cfg) Settings {
+	sec := cfg.Raw.Section("auth.saml")
+	return Settings{
+		Enabled:                sec.Key("enabled").MustBool(false),
+		Name:                   sec.Key("name").MustString("SAML"),
+		SingleLogout:           sec.Key("single_logout").MustBool(false),
+		AllowSignUp:            sec.Key("allow_sign_up").MustBool(true),
+		AllowIDPInitiated:      sec.Key("allow_idp_initiated").MustBool(false),
+		RelayState:             sec.Key("relay_state").String(),
+		SignatureAlgorithm:     sec.Key("signature_algorithm").String(),
+		Certificate:            sec.Key("certificate").String(),
+		CertificatePath:        sec.Key("certificate_path").String(),
+		PrivateKey:             sec.Key("private_key").String(),
+		PrivateKeyPath:         sec.Key("private_key_path").String(),
+		IDPMetadata:            sec.Key("idp_metadata").String(),
+		IDPMetadataPath:        sec.Key("idp_metadata_path").String(),
+		IDPMetadataURL:         sec.Key("idp_metadata_url").String(),
+		MaxIssueDelay:          sec.Key("max_issue_delay").MustDuration(90 * time.Second),
+		MetadataValidDuration:  sec.Key("metadata_valid_duration").MustDuration(48 * time.Hour),
+		AttributeName:          sec.Key("assertion_attribute_name").MustString("displayName"),
+		AttributeLogin:         sec.Key("assertion_attribute_login").MustString("mail"),
+		AttributeEmail:         sec.Key("assertion_attribute_email").MustString("mail"),
+		AttributeGroups:        sec.Key("assertion_attribute_groups").String(),
+		AttributeRole:          sec.Key("assertion_attribute_role").String(),
+		RoleValuesEditor:       util.SplitString(sec.Key("role_values_editor").String()),
+		RoleValuesAdmin:        util.SplitString(sec.Key("role_values_admin").String()),
+		RoleValuesGrafanaAdmin: util.SplitString(sec.Key("role_values_grafana_admin").String()),
+	}
+}
+
+// Service is the SAML 2.0 service provider used for single sign-on.
+type Service struct {
+	cfg        *setting.Cfg
+	settings   Settings
+	httpClient *http.Client
+	log        log.Logger
+
+	certificate *x509.Certificate
+	key         *rsa.PrivateKey
+	name        *nameTemplate
+	initErr     error
+
+	mu sync.Mutex
+	sp *crewjamsaml.ServiceProvider
+}
+
+func ProvideService(cfg *setting.Cfg) *Service {
+	s := &Service{
+		cfg:        cfg,
+		settings:   readSettings(cfg),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		log:        log.New("saml"),
+	}
+	if !s.settings.Enabled {
+		return s
+	}
+
+	// the crewjam/saml library only exposes the issue delay as a package level setting
+	crewjamsaml.MaxIssueDelay = s.settings.MaxIssueDelay
+
+	// SAML logins are refused until the configuration is fixed, but a broken
+	// configuration should not prevent Grafana from starting
+	if err := s.init(); err != nil {
+		s.log.Error("Invalid SAML configuration, SAML login is disabled", "error", err)
+		s.initErr = err
+	}
+	return s
+}
+
+func (s *Service) init() error {
+	certPEM, err := readValueOrFile(s.settings.Certificate, s.settings.CertificatePath, "certificate")
+	if err != nil {
+		return err
+	}
+	keyPEM, err := readValueOrFile(s.settings.PrivateKey, s.settings.PrivateKeyPath, "private_key")
+	if err != nil {
+		return err
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate and private key: %w", err)
+	}
+	key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return errors.New("private key must be an RSA key")
+	}
+	s.key = key
+	s.certificate, err = x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	if s.settings.SignatureAlgorithm != "" {
+		if _, ok := signatureAlgorithms[s.settings.SignatureAlgorithm]; !ok {
+			return fmt.Errorf("unsupported signature algorithm %q", s.settings.SignatureAlgorithm)
+		}
+	}
+
+	configured := 0
+	for _, v := range []string{s.settings.IDPMetadata, s.settings.IDPMetadataPath, s.settings.IDPMetadataURL} {
+		if v != "" {
+			configured++
+		}
+	}
+	if configured != 1 {
+		return errors.New("exactly one of idp_metadata, idp_metadata_path or idp_metadata_url must be set")
+	}
+
+	s.name, err = parseNameTemplate(s.settings.AttributeName)
+	return err
+}
+
+// IsEnabled returns true when SAML authentication is enabled.
+func (s *Service) IsEnabled() bool {
+	return s.settings.Enabled
+}
+
+// Name returns the display name of the SAML login button.
+func (s *Service) Name() string {
+	return s.settings.Name
+}
+
+// SingleLogoutEnabled returns true when logging out of Grafana should end the IdP session.
+func (s *Service) SingleLogoutEnabled() bool {
+	return s.settings.Enabled && s.settings.SingleLogout
+}
+
+// IsSignupAllowed returns true when SAML logins may create new Grafana users.
+func (s *Service) IsSignupAllowed() bool {
+	return s.settings.AllowSignUp
+}
+
+// Metadata returns the XML metadata of the service provider.
+func (s *Service) Metadata(ctx context.Context) ([]byte, error) {
+	sp, err := s.serviceProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// AuthnRequestURL starts a SP initiated login, it returns the IdP redirect
+// URL and the ID of the request which the response must refer to.
+func (s *Service) AuthnRequestURL(ctx context.Context) (string, string, error) {
+	sp, err := s.serviceProvider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	req, err := sp.MakeAuthenticationRequest(sp.GetSSOBindingLocation(crewjamsaml.HTTPRedirectBinding), crewjamsaml.HTTPRedirectBinding, crewjamsaml.HTTPPostBinding)
+	if err != nil {
+		return "", "", err
+	}
+	redirectURL, err := req.Redirect("", sp)
+	if err != nil {
+		return "", "", err
+	}
+	return redirectURL.String(), req.ID, nil
+}
+
+// ParseResponse validates the SAML response posted to the assertion consumer
+// service. Responses are accepted when they answer requestID or, if
+// requestID is empty, when IdP initiated login is allowed.
+func (s *Service) ParseResponse(r *http.Request, requestID string) (*crewjamsaml.Assertion, error) {
+	sp, err := s.serviceProvider(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	if requestID == "" {
+		if !s.settings.AllowIDPInitiated {
+			return nil, ErrIDPInitiatedNotAllowed
+		}
+		if s.settings.RelayState != "" && r.PostForm.Get("RelayState") != s.settings.RelayState {
+			return nil, ErrInvalidRelayState
+		}
+		idpInitiated := *sp
+		idpInitiated.AllowIDPInitiated = true
+		sp = &idpInitiated
+	}
+
+	assertion, err := sp.ParseResponse(r, []string{requestID})
+	if err != nil {
+		var invalidErr *crewjamsaml.InvalidResponseError
+		if errors.As(err, &invalidErr) {
+			return nil, fmt.Errorf("invalid SAML response: %w", invalidErr.PrivateErr)
+		}
+		return nil, err
+	}
+	return assertion, nil
+}
+
+// LogoutRequestURL returns the IdP redirect URL ending the IdP session of nameID.
+func (s *Service) LogoutRequestURL(ctx context.Context, nameID string) (string, error) {
+	sp, err := s.serviceProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	if sp.GetSLOBindingLocation(crewjamsaml.HTTPRedirectBinding) == "" {
+		return "", ErrNoSingleLogoutService
+	}
+	redirectURL, err := sp.MakeRedirectLogoutRequest(nameID, "")
+	if err != nil {
+		return "", err
+	}
+	return redirectURL.String(), nil
+}
+
+// ValidateLogoutResponse validates the IdP response to a SP initiated logout.
+func (s *Service) ValidateLogoutResponse(r *http.Request) error {
+	sp, err := s.serviceProvider(r.Context())
+	if err != nil {
+		return err
+	}
+	return sp.ValidateLogoutResponseRequest(r)
+}
+
+// LogoutResponseURL returns the IdP redirect URL acknowledging a IdP initiated logout.
+func (s *Service) LogoutResponseURL(ctx context.Context, requestID, relayState string) (string, error) {
+	sp, err := s.serviceProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	if sp.GetSLOBindingLocation(crewjamsaml.HTTPRedirectBinding) == "" {
+		return "", ErrNoSingleLogoutService
+	}
+	redirectURL, err := sp.MakeRedirectLogoutResponse(requestID, relayState)
+	if err != nil {
+		return "", err
+	}
+	return redirectURL.String(), nil
+}
+
+// serviceProvider returns the service provider, loading the IdP metadata on first use
+// so that an unavailable IdP at startup does not require a restart.
+func (s *Service) serviceProvider(ctx context.Context) (*crewjamsaml.ServiceProvider, error) {
+	if !s.settings.Enabled {
+		return nil, ErrNotEnabled
+	}
+	if s.initErr != nil {
+		return nil, s.initErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sp != nil {
+		return s.sp, nil
+	}
+
+	idpMetadata, err := s.loadIDPMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load IdP metadata: %w", err)
+	}
+
+	rootURL, err := url.Parse(s.cfg.AppURL)
+	if err != nil {
+		return nil, err
+	}
+	sp := &crewjamsaml.ServiceProvider{
+		Key:                   s.key,
+		Certificate:           s.certificate,
+		HTTPClient:            s.httpClient,
+		MetadataURL:           *rootURL.ResolveReference(&url.URL{Path: "saml/metadata"}),
+		AcsURL:                *rootURL.ResolveReference(&url.URL{Path: "saml/acs"}),
+		SloURL:                *rootURL.ResolveReference(&url.URL{Path: "saml/slo"}),
+		IDPMetadata:           idpMetadata,
+		MetadataValidDuration: s.settings.MetadataValidDuration,
+		SignatureMethod:       signatureAlgorithms[s.settings.SignatureAlgorithm],
+		LogoutBindings:        []string{crewjamsaml.HTTPRedirectBinding, crewjamsaml.HTTPPostBinding},
+	}
+	s.sp = sp
+	return sp, nil
+}
+
+func (s *Service) loadIDPMetadata(ctx context.Context) (*crewjamsaml.EntityDescriptor, error) {
+	var data []byte
+	var err error
+	switch {
+	case s.settings.IDPMetadataURL != "":
+		data, err = s.fetchIDPMetadata(ctx, s.settings.IDPMetadataURL)
+	case s.settings.IDPMetadataPath != "":
+		// nolint:gosec
+		// We can ignore the gosec G304 warning since the path comes from the Grafana configuration file
+		data, err = os.ReadFile(filepath.Clean(s.settings.IDPMetadataPath))
+	default:
+		data, err = base64.StdEncoding.DecodeString(s.settings.IDPMetadata)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIDPMetadata(data)
+}
+
+func (s *Service) fetchIDPMetadata(ctx context.Context, metadataURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "err", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseIDPMetadata accepts both a single EntityDescriptor and an
+// EntitiesDescriptor containing the IdP entity.
+func parseIDPMetadata(data []byte) (*crewjamsaml.EntityDescriptor, error) {
+	entity := &crewjamsaml.EntityDescriptor{}
+	err := xml.Unmarshal(data, entity)
+	if err == nil {
+		if len(entity.IDPSSODescriptors) == 0 {
+			return nil, errors.New("no IDPSSODescriptor found")
+		}
+		return entity, nil
+	}
+
+	entities := &crewjamsaml.EntitiesDescriptor{}
+	if xml.Unmarshal(data, entities) != nil {
+		return nil, err
+	}
+	for i, e := range entities.EntityDescriptors {
+		if len(e.IDPSSODescriptors) > 0 {
+			return &entities.EntityDescriptors[i], nil
+		}
+	}
+	return nil, errors.New("no entity found with IDPSSODescriptor")
+}
+
+// readValueOrFile reads a PEM value configured either base64 encoded or as a file path.
+func readValueOrFile(value, path, name string) ([]byte, error) {
+	switch {
+	case value != "" && path != "":
+		return nil, fmt.Errorf("only one of %s and %s_path can be set", name, name)
+	case path != "":
+		// nolint:gosec
+		// We can ignore the gosec G304 warning since the path comes from the Grafana configuration file
+		return os.ReadFile(filepath.Clean(path))
+	case value != "":
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not base64 encoded: %w", name, err)
+		}
+		if block, _ := pem.Decode(data); block == nil {
+			return nil, fmt.Errorf("%s is not PEM encoded", name)
+		}
+		return bytes.TrimSpace(data), nil
+	}
+	return nil, fmt.Errorf("%s or %s_path must be set", name, name)
+}
@@ -0,0 +1,480 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	crewjamsaml "github.com/crewjam/saml"
+	"github.com/crewjam/saml/logger"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/login"
+	loginService "github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestService_SPInitiatedLogin(t *testing.T) {
+	idp, s := setupTestIDP(t, nil)
+
+	redirectURL, requestID, err := s.AuthnRequestURL(context.Background())
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(redirectURL, "https://idp.example.com/sso?"))
+
+	samlResponse, _ := idp.login(t, redirectURL)
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	require.NoError(t, err)
+	require.Contains(t, string(raw), "EncryptedAssertion")
+
+	t.Run("should accept the response to the request", func(t *testing.T) {
+		assertion, err := s.ParseResponse(acsRequest(samlResponse, ""), requestID)
+		require.NoError(t, err)
+
+		extUser, err := s.ExternalUserInfo(assertion)
+		require.NoError(t, err)
+		assert.Equal(t, loginService.SAMLAuthModule, extUser.AuthModule)
+		assert.Equal(t, "jdoe-name-id", extUser.AuthId)
+		assert.Equal(t, "jdoe", extUser.Login)
+		assert.Equal(t, "jdoe@example.com", extUser.Email)
+		assert.Equal(t, "John Doe", extUser.Name)
+		assert.Equal(t, []string{"engineering", "oncall"}, extUser.Groups)
+		assert.Equal(t, map[int64]org.RoleType{1: org.RoleEditor}, extUser.OrgRoles)
+		require.NotNil(t, extUser.IsGrafanaAdmin)
+		assert.False(t, *extUser.IsGrafanaAdmin)
+	})
+
+	t.Run("should reject a response to another request", func(t *testing.T) {
+		_, err := s.ParseResponse(acsRequest(samlResponse, ""), "id-other")
+		require.Error(t, err)
+	})
+
+	t.Run("should reject a tampered response", func(t *testing.T) {
+		tampered := bytes.Replace(raw, []byte("https://idp.example.com/metadata"), []byte("https://evil.example.com/metadata"), 1)
+		_, err := s.ParseResponse(acsRequest(base64.StdEncoding.EncodeToString(tampered), ""), requestID)
+		require.Error(t, err)
+	})
+
+	t.Run("should reject unsolicited responses when IdP initiated login is disabled", func(t *testing.T) {
+		_, err := s.ParseResponse(acsRequest(samlResponse, ""), "")
+		require.ErrorIs(t, err, ErrIDPInitiatedNotAllowed)
+	})
+}
+
+func TestService_IDPInitiatedLogin(t *testing.T) {
+	idp, s := setupTestIDP(t, map[string]string{
+		"allow_idp_initiated": "true",
+		"relay_state":         "grafana",
+	})
+
+	samlResponse, relayState := idp.idpInitiatedLogin(t, s, "grafana")
+	assert.Equal(t, "grafana", relayState)
+
+	assertion, err := s.ParseResponse(acsRequest(samlResponse, relayState), "")
+	require.NoError(t, err)
+	extUser, err := s.ExternalUserInfo(assertion)
+	require.NoError(t, err)
+	assert.Equal(t, "jdoe@example.com", extUser.Email)
+
+	_, err = s.ParseResponse(acsRequest(samlResponse, "other"), "")
+	require.ErrorIs(t, err, ErrInvalidRelayState)
+}
+
+func TestService_Metadata(t *testing.T) {
+	_, s := setupTestIDP(t, nil)
+
+	data, err := s.Metadata(context.Background())
+	require.NoError(t, err)
+
+	metadata := &crewjamsaml.EntityDescriptor{}
+	require.NoError(t, xml.Unmarshal(data, metadata))
+	assert.Equal(t, "http://localhost:3000/saml/metadata", metadata.EntityID)
+	require.Len(t, metadata.SPSSODescriptors, 1)
+	assert.Equal(t, "http://localhost:3000/saml/acs", metadata.SPSSODescriptors[0].AssertionConsumerServices[0].Location)
+	assert.Equal(t, "http://localhost:3000/saml/slo", metadata.SPSSODescriptors[0].SingleLogoutServices[0].Location)
+	// the encryption key is published so that the IdP encrypts assertions
+	require.Len(t, metadata.SPSSODescriptors[0].KeyDescriptors, 1)
+	assert.Equal(t, "encryption", metadata.SPSSODescriptors[0].KeyDescriptors[0].Use)
+}
+
+func TestService_SingleLogout(t *testing.T) {
+	idp, s := setupTestIDP(t, map[string]string{"single_logout": "true"})
+	require.True(t, s.SingleLogoutEnabled())
+
+	t.Run("should redirect SP initiated logouts to the IdP", func(t *testing.T) {
+		redirectURL, err := s.LogoutRequestURL(context.Background(), "jdoe-name-id")
+		require.NoError(t, err)
+		u, err := url.Parse(redirectURL)
+		require.NoError(t, err)
+		assert.Equal(t, "/slo", u.Path)
+
+		raw, err := inflate(u.Query().Get("SAMLRequest"))
+		require.NoError(t, err)
+		req := &crewjamsaml.LogoutRequest{}
+		require.NoError(t, xml.Unmarshal(raw, req))
+		assert.Equal(t, "jdoe-name-id", req.NameID.Value)
+	})
+
+	t.Run("should accept signed IdP initiated logout requests", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/saml/slo?"+idp.logoutRequestQuery(t, "jdoe-name-id", "state", true), nil)
+		req, err := s.ParseLogoutRequest(r)
+		require.NoError(t, err)
+		assert.Equal(t, "jdoe-name-id", req.NameID.Value)
+
+		redirectURL, err := s.LogoutResponseURL(context.Background(), req.ID, "state")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(redirectURL, "https://idp.example.com/slo?"))
+	})
+
+	t.Run("should accept signed logout requests using the POST binding", func(t *testing.T) {
+		form := url.Values{"SAMLRequest": {idp.signedLogoutRequest(t, "jdoe-name-id")}}
+		r := httptest.NewRequest(http.MethodPost, "/saml/slo", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req, err := s.ParseLogoutRequest(r)
+		require.NoError(t, err)
+		assert.Equal(t, "jdoe-name-id", req.NameID.Value)
+	})
+
+	t.Run("should reject unsigned logout requests", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/saml/slo?"+idp.logoutRequestQuery(t, "jdoe-name-id", "", false), nil)
+		_, err := s.ParseLogoutRequest(r)
+		require.ErrorIs(t, err, ErrLogoutRequestNotSigned)
+	})
+
+	t.Run("should reject logout requests with a forged signature", func(t *testing.T) {
+		query := idp.logoutRequestQuery(t, "jdoe-name-id", "", true)
+		query = strings.Replace(query, "SAMLRequest=", "SAMLRequest=A", 1)
+		_, err := s.ParseLogoutRequest(httptest.NewRequest(http.MethodGet, "/saml/slo?"+query, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("should not inflate unsigned logout requests", func(t *testing.T) {
+		_, err := s.ParseLogoutRequest(httptest.NewRequest(http.MethodGet, "/saml/slo?SAMLRequest=not-deflated", nil))
+		require.ErrorIs(t, err, ErrLogoutRequestNotSigned)
+	})
+
+	t.Run("should reject oversized logout requests", func(t *testing.T) {
+		query := idp.redirectQuery(t, bytes.Repeat([]byte(" "), maxInflatedRequestSize+1), "", true)
+		_, err := s.ParseLogoutRequest(httptest.NewRequest(http.MethodGet, "/saml/slo?"+query, nil))
+		require.ErrorIs(t, err, ErrLogoutRequestTooLarge)
+	})
+}
+
+func TestService_ExternalUserInfo(t *testing.T) {
+	assertion := func(attrs map[string][]string) *crewjamsaml.Assertion {
+		statement := crewjamsaml.AttributeStatement{}
+		for name, values := range attrs {
+			attr := crewjamsaml.Attribute{FriendlyName: name, Name: "urn:" + name}
+			for _, v := range values {
+				attr.Values = append(attr.Values, crewjamsaml.AttributeValue{Value: v})
+			}
+			statement.Attributes = append(statement.Attributes, attr)
+		}
+		return &crewjamsaml.Assertion{
+			Subject:             &crewjamsaml.Subject{NameID: &crewjamsaml.NameID{Value: "name-id"}},
+			AttributeStatements: []crewjamsaml.AttributeStatement{statement},
+		}
+	}
+
+	newService := func(t *testing.T, settings map[string]string) *Service {
+		cfg := setting.NewCfg()
+		sec := cfg.Raw.Section("auth.saml")
+		for k, v := range settings {
+			_, err := sec.NewKey(k, v)
+			require.NoError(t, err)
+		}
+		s := &Service{cfg: cfg, settings: readSettings(cfg)}
+		var err error
+		s.name, err = parseNameTemplate(s.settings.AttributeName)
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("should render the name template", func(t *testing.T) {
+		s := newService(t, map[string]string{"assertion_attribute_name": "$__saml{givenName} $__saml{sn}"})
+		extUser, err := s.ExternalUserInfo(assertion(map[string][]string{"mail": {"jdoe@example.com"}, "givenName": {"John"}, "sn": {"Doe"}}))
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", extUser.Name)
+		assert.Equal(t, "jdoe@example.com", extUser.Login)
+		assert.Empty(t, extUser.OrgRoles)
+		assert.Nil(t, extUser.IsGrafanaAdmin)
+
+		_, err = s.ExternalUserInfo(assertion(map[string][]string{"mail": {"jdoe@example.com"}, "givenName": {"John"}}))
+		require.Error(t, err)
+	})
+
+	t.Run("should reject invalid name templates", func(t *testing.T) {
+		_, err := parseNameTemplate("$__saml{} $__saml{sn}")
+		require.Error(t, err)
+		_, err = parseNameTemplate("$__saml{givenName")
+		require.Error(t, err)
+	})
+
+	t.Run("should require an email", func(t *testing.T) {
+		s := newService(t, nil)
+		_, err := s.ExternalUserInfo(assertion(map[string][]string{"displayName": {"John"}}))
+		require.ErrorIs(t, err, login.ErrNoEmail)
+	})
+
+	t.Run("should map roles", func(t *testing.T) {
+		s := newService(t, map[string]string{
+			"assertion_attribute_role":  "role",
+			"role_values_editor":        "editor, developer",
+			"role_values_admin":         "admin",
+			"role_values_grafana_admin": "superadmin",
+		})
+		s.cfg.AutoAssignOrg = true
+		s.cfg.AutoAssignOrgId = 2
+
+		testCases := []struct {
+			roles          []string
+			role           org.RoleType
+			isGrafanaAdmin bool
+		}{
+			{roles: []string{"developer"}, role: org.RoleEditor},
+			{roles: []string{"developer", "admin"}, role: org.RoleAdmin},
+			{roles: []string{"superadmin"}, role: org.RoleAdmin, isGrafanaAdmin: true},
+			{roles: []string{"unknown"}, role: org.RoleViewer},
+			{roles: nil, role: org.RoleViewer},
+		}
+		for _, tc := range testCases {
+			extUser, err := s.ExternalUserInfo(assertion(map[string][]string{"mail": {"jdoe@example.com"}, "role": tc.roles}))
+			require.NoError(t, err)
+			assert.Equal(t, map[int64]org.RoleType{2: tc.role}, extUser.OrgRoles, tc.roles)
+			assert.Equal(t, tc.isGrafanaAdmin, *extUser.IsGrafanaAdmin, tc.roles)
+		}
+	})
+}
+
+func TestProvideService_InvalidConfiguration(t *testing.T) {
+	cfg := setting.NewCfg()
+	sec := cfg.Raw.Section("auth.saml")
+	_, err := sec.NewKey("enabled", "true")
+	require.NoError(t, err)
+
+	s := ProvideService(cfg)
+	assert.True(t, s.IsEnabled())
+	_, _, err = s.AuthnRequestURL(context.Background())
+	require.Error(t, err)
+}
+
+// testIDP is a local identity provider standing in for a real IdP.
+type testIDP struct {
+	*crewjamsaml.IdentityProvider
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func setupTestIDP(t *testing.T, settings map[string]string) (*testIDP, *Service) {
+	t.Helper()
+
+	spKey, spCert := generateKeyPair(t, "grafana")
+	idpKey, idpCert := generateKeyPair(t, "idp")
+
+	idp := &testIDP{
+		IdentityProvider: &crewjamsaml.IdentityProvider{
+			Key:         idpKey,
+			Certificate: idpCert,
+			Logger:      logger.DefaultLogger,
+			MetadataURL: url.URL{Scheme: "https", Host: "idp.example.com", Path: "/metadata"},
+			SSOURL:      url.URL{Scheme: "https", Host: "idp.example.com", Path: "/sso"},
+			LogoutURL:   url.URL{Scheme: "https", Host: "idp.example.com", Path: "/slo"},
+			SessionProvider: sessionProviderFunc(func(w http.ResponseWriter, r *http.Request, req *crewjamsaml.IdpAuthnRequest) *crewjamsaml.Session {
+				return &crewjamsaml.Session{
+					ID:         "session-id",
+					CreateTime: time.Now(),
+					ExpireTime: time.Now().Add(time.Hour),
+					NameID:     "jdoe-name-id",
+					Groups:     []string{"engineering", "oncall"},
+					CustomAttributes: []crewjamsaml.Attribute{
+						{FriendlyName: "login", Name: "login", Values: []crewjamsaml.AttributeValue{{Value: "jdoe"}}},
+						{FriendlyName: "mail", Name: "mail", Values: []crewjamsaml.AttributeValue{{Value: "jdoe@example.com"}}},
+						{FriendlyName: "displayName", Name: "displayName", Values: []crewjamsaml.AttributeValue{{Value: "John Doe"}}},
+						{FriendlyName: "role", Name: "role", Values: []crewjamsaml.AttributeValue{{Value: "developer"}}},
+					},
+				}
+			}),
+		},
+		key:  idpKey,
+		cert: idpCert,
+	}
+	idpMetadata, err := xml.Marshal(idp.Metadata())
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.AppURL = "http://localhost:3000/"
+	sec := cfg.Raw.Section("auth.saml")
+	defaults := map[string]string{
+		"enabled":                    "true",
+		"certificate":                base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: spCert.Raw})),
+		"private_key":                base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(spKey)})),
+		"idp_metadata":               base64.StdEncoding.EncodeToString(idpMetadata),
+		"assertion_attribute_login":  "login",
+		"assertion_attribute_groups": "eduPersonAffiliation",
+		"assertion_attribute_role":   "role",
+		"role_values_editor":         "developer",
+	}
+	for k, v := range settings {
+		defaults[k] = v
+	}
+	for k, v := range defaults {
+		_, err := sec.NewKey(k, v)
+		require.NoError(t, err)
+	}
+
+	s := ProvideService(cfg)
+	require.NoError(t, s.initErr)
+
+	spMetadata, err := s.Metadata(context.Background())
+	require.NoError(t, err)
+	idp.ServiceProviderProvider = serviceProviderFunc(func(r *http.Request, serviceProviderID string) (*crewjamsaml.EntityDescriptor, error) {
+		metadata := &crewjamsaml.EntityDescriptor{}
+		return metadata, xml.Unmarshal(spMetadata, metadata)
+	})
+	return idp, s
+}
+
+var postFormValue = regexp.MustCompile(`name="(SAMLResponse|RelayState)" value="([^"]*)"`)
+
+// login follows the SP redirect to the IdP and returns the posted SAML response.
+func (idp *testIDP) login(t *testing.T, redirectURL string) (string, string) {
+	rec := httptest.NewRecorder()
+	idp.ServeSSO(rec, httptest.NewRequest(http.MethodGet, redirectURL, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return parsePostForm(t, rec.Body.String())
+}
+
+func (idp *testIDP) idpInitiatedLogin(t *testing.T, s *Service, relayState string) (string, string) {
+	sp, err := s.serviceProvider(context.Background())
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	idp.ServeIDPInitiated(rec, httptest.NewRequest(http.MethodGet, "https://idp.example.com/login", nil), sp.MetadataURL.String(), relayState)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return parsePostForm(t, rec.Body.String())
+}
+
+func parsePostForm(t *testing.T, body string) (string, string) {
+	values := map[string]string{}
+	for _, match := range postFormValue.FindAllStringSubmatch(body, -1) {
+		values[match[1]] = html.UnescapeString(match[2])
+	}
+	require.NotEmpty(t, values["SAMLResponse"])
+	return values["SAMLResponse"], values["RelayState"]
+}
+
+func (idp *testIDP) logoutRequest(nameID string) *crewjamsaml.LogoutRequest {
+	return &crewjamsaml.LogoutRequest{
+		ID:           fmt.Sprintf("id-%d", time.Now().UnixNano()),
+		Version:      "2.0",
+		IssueInstant: time.Now().UTC(),
+		Destination:  "http://localhost:3000/saml/slo",
+		Issuer:       &crewjamsaml.Issuer{Value: idp.MetadataURL.String()},
+		NameID:       &crewjamsaml.NameID{Value: nameID},
+	}
+}
+
+// logoutRequestQuery encodes a logout request with the HTTP-Redirect binding.
+func (idp *testIDP) logoutRequestQuery(t *testing.T, nameID, relayState string, signed bool) string {
+	doc := etree.NewDocument()
+	doc.SetRoot(idp.logoutRequest(nameID).Element())
+	raw, err := doc.WriteToBytes()
+	require.NoError(t, err)
+	return idp.redirectQuery(t, raw, relayState, signed)
+}
+
+// redirectQuery deflates and encodes a raw request with the HTTP-Redirect binding.
+func (idp *testIDP) redirectQuery(t *testing.T, raw []byte, relayState string, signed bool) string {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	require.NoError(t, err)
+	_, err = w.Write(raw)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if relayState != "" {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+	if !signed {
+		return query
+	}
+	query += "&SigAlg=" + url.QueryEscape(dsig.RSASHA256SignatureMethod)
+	hashed := sha256.Sum256([]byte(query))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, hashed[:])
+	require.NoError(t, err)
+	return query + "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+}
+
+// signedLogoutRequest encodes a logout request with the HTTP-POST binding.
+func (idp *testIDP) signedLogoutRequest(t *testing.T, nameID string) string {
+	signingContext := dsig.NewDefaultSigningContext(dsig.TLSCertKeyStore{PrivateKey: idp.key, Certificate: [][]byte{idp.cert.Raw}})
+	signed, err := signingContext.SignEnveloped(idp.logoutRequest(nameID).Element())
+	require.NoError(t, err)
+
+	doc := etree.NewDocument()
+	doc.SetRoot(signed)
+	raw, err := doc.WriteToBytes()
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func acsRequest(samlResponse, relayState string) *http.Request {
+	form := url.Values{"SAMLResponse": {samlResponse}}
+	if relayState != "" {
+		form.Set("RelayState", relayState)
+	}
+	r := httptest.NewRequest(http.MethodPost, "http://localhost:3000/saml/acs", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func generateKeyPair(t *testing.T, commonName string) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return key, cert
+}
+
+type sessionProviderFunc func(w http.ResponseWriter, r *http.Request, req *crewjamsaml.IdpAuthnRequest) *crewjamsaml.Session
+
+func (f sessionProviderFunc) GetSession(w http.ResponseWriter, r *http.Request, req *crewjamsaml.IdpAuthnRequest) *crewjamsaml.Session {
+	return f(w, r, req)
+}
+
+type serviceProviderFunc func(r *http.Request, serviceProviderID string) (*crewjamsaml.EntityDescriptor, error)
+
+func (f serviceProviderFunc) GetServiceProvider(r *http.Request, serviceProviderID string) (*crewjamsaml.EntityDescriptor, error) {
+	return f(r, serviceProviderID)
+}
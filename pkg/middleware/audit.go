@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// Audit records an audit event for the requests classified by audit.Classify once they are handled
+func Audit(service audit.Service) web.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mContext := web.FromContext(r.Context())
+			if mContext == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, details := audit.WithDetails(mContext.Req.Context())
+			mContext.Req = mContext.Req.WithContext(ctx)
+
+			rw := web.Rw(w, r)
+			next.ServeHTTP(rw, mContext.Req)
+
+			req := mContext.Req
+			route, ok := routeOperationName(req)
+			if !ok {
+				return
+			}
+			category, action, ok := audit.Classify(req.Method, route)
+			if !ok {
+				return
+			}
+
+			event := &audit.Event{
+				Category:    category,
+				Action:      action,
+				ResourceUID: details.ResourceUID(),
+				Method:      req.Method,
+				Path:        req.URL.Path,
+				StatusCode:  rw.Status(),
+				Diff:        details.Diff(),
+				Result:      audit.ResultSuccess,
+			}
+			if event.StatusCode >= 400 {
+				event.Result = audit.ResultFailure
+			}
+			if event.ResourceUID == "" {
+				event.ResourceUID = routeResource(route, web.Params(req))
+			}
+
+			reqContext := contexthandler.FromContext(req.Context())
+			if reqContext != nil {
+				setActor(event, reqContext)
+			}
+
+			service.Record(req.Context(), event)
+		})
+	}
+}
+
+func setActor(event *audit.Event, c *models.ReqContext) {
+	event.IP = c.RemoteAddr()
+	if c.SignedInUser == nil {
+		event.ActorType = audit.ActorAnonymous
+		return
+	}
+
+	event.OrgID = c.OrgId
+	event.ActorLogin = c.Login
+	switch {
+	case c.ApiKeyId > 0:
+		event.ActorType = audit.ActorAPIKey
+		event.ActorID = c.ApiKeyId
+	case c.IsServiceAccount:
+		event.ActorType = audit.ActorServiceAccount
+		event.ActorID = c.UserId
+	case c.UserId > 0:
+		event.ActorType = audit.ActorUser
+		event.ActorID = c.UserId
+	default:
+		event.ActorType = audit.ActorAnonymous
+	}
+}
+
+// routeResource returns the value of the last parameter of the route, which identifies the resource the
+// action is performed on, for example the user in /api/orgs/:orgId/users/:userId
+func routeResource(route string, params map[string]string) string {
+	segments := strings.Split(route, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if strings.HasPrefix(segments[i], ":") {
+			if v := params[segments[i]]; v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
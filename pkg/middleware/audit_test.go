@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/audit/audittest"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestAudit(t *testing.T) {
+	setup := func(signedInUser *user.SignedInUser) (*web.Mux, *audittest.FakeAuditService) {
+		service := audittest.NewFakeAuditService()
+		m := web.New()
+		m.Use(func(c *web.Context) {
+			reqContext := &models.ReqContext{Context: c, SignedInUser: signedInUser, Logger: log.New("test")}
+			c.Req = c.Req.WithContext(ctxkey.Set(c.Req.Context(), reqContext))
+		})
+		m.UseMiddleware(Audit(service))
+
+		m.Put("/api/datasources/uid/:uid", ProvideRouteOperationName("/api/datasources/uid/:uid"), func(c *web.Context) {
+			audit.SetDiff(c.Req.Context(), "url: changed")
+			c.Resp.WriteHeader(http.StatusOK)
+		})
+		m.Get("/api/datasources/uid/:uid", ProvideRouteOperationName("/api/datasources/uid/:uid"), func(c *web.Context) {
+			c.Resp.WriteHeader(http.StatusOK)
+		})
+		m.Delete("/api/orgs/:orgId/users/:userId", ProvideRouteOperationName("/api/orgs/:orgId/users/:userId"), func(c *web.Context) {
+			c.Resp.WriteHeader(http.StatusForbidden)
+		})
+		return m, service
+	}
+
+	t.Run("should record audited requests with the handler details", func(t *testing.T) {
+		m, service := setup(&user.SignedInUser{UserId: 2, OrgId: 1, Login: "editor"})
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/datasources/uid/prom", nil))
+
+		require.Len(t, service.Events, 1)
+		event := service.Events[0]
+		assert.Equal(t, audit.CategoryDatasources, event.Category)
+		assert.Equal(t, "datasources:update", event.Action)
+		assert.Equal(t, "prom", event.ResourceUID)
+		assert.Equal(t, "url: changed", event.Diff)
+		assert.Equal(t, audit.ActorUser, event.ActorType)
+		assert.Equal(t, int64(2), event.ActorID)
+		assert.Equal(t, "editor", event.ActorLogin)
+		assert.Equal(t, int64(1), event.OrgID)
+		assert.Equal(t, audit.ResultSuccess, event.Result)
+	})
+
+	t.Run("should not record reads", func(t *testing.T) {
+		m, service := setup(&user.SignedInUser{UserId: 2, OrgId: 1})
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/datasources/uid/prom", nil))
+		assert.Len(t, service.Events, 0)
+	})
+
+	t.Run("should record failures and api key actors", func(t *testing.T) {
+		m, service := setup(&user.SignedInUser{ApiKeyId: 5, OrgId: 1})
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/orgs/1/users/3", nil))
+
+		require.Len(t, service.Events, 1)
+		event := service.Events[0]
+		assert.Equal(t, "orgs.users:delete", event.Action)
+		assert.Equal(t, "3", event.ResourceUID)
+		assert.Equal(t, audit.ActorAPIKey, event.ActorType)
+		assert.Equal(t, int64(5), event.ActorID)
+		assert.Equal(t, audit.ResultFailure, event.Result)
+		assert.Equal(t, http.StatusForbidden, event.StatusCode)
+	})
+}
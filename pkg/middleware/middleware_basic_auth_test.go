@@ -8,7 +8,9 @@ import (
 	"github.com/grafana/grafana/pkg/services/apikey"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/login/logintest"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/user/usertest"
 	"github.com/grafana/grafana/pkg/setting"
@@ -55,6 +57,26 @@ func TestMiddlewareBasicAuth(t *testing.T) {
 		assert.Equal(t, id, sc.context.UserId)
 	}, configure)
 
+	middlewareScenario(t, "Should reject users with two-factor authentication", func(t *testing.T, sc *scenarioContext) {
+		sc.mockSQLStore.ExpectedSignedInUser = &user.SignedInUser{OrgId: 2, UserId: id}
+		sc.twoFactorService.ExpectedEnabled = true
+
+		for _, status := range []*twofactor.Status{{Enabled: true}, {Required: true}} {
+			sc.twoFactorService.ExpectedStatus = status
+
+			authHeader := util.GetBasicAuthHeader("myUser", "MyPass")
+			sc.fakeReq("GET", "/").withAuthorizationHeader(authHeader).exec()
+
+			assert.Equal(t, 401, sc.resp.Code)
+			assert.Equal(t, contexthandler.TwoFactorBasicAuth, sc.respJson["message"])
+		}
+
+		sc.twoFactorService.ExpectedStatus = &twofactor.Status{}
+		authHeader := util.GetBasicAuthHeader("myUser", "MyPass")
+		sc.fakeReq("GET", "/").withAuthorizationHeader(authHeader).exec()
+		assert.True(t, sc.context.IsSignedIn)
+	}, configure)
+
 	middlewareScenario(t, "Auth sequence", func(t *testing.T, sc *scenarioContext) {
 		const password = "MyPass"
 		const salt = "Salt"
@@ -64,7 +86,7 @@ func TestMiddlewareBasicAuth(t *testing.T) {
 
 		sc.mockSQLStore.ExpectedUser = &user.User{Password: encoded, ID: id, Salt: salt}
 		sc.mockSQLStore.ExpectedSignedInUser = &user.SignedInUser{UserId: id}
-		login.ProvideService(sc.mockSQLStore, &logintest.LoginServiceFake{}, usertest.NewUserServiceFake())
+		login.ProvideService(sc.mockSQLStore, &logintest.LoginServiceFake{}, usertest.NewUserServiceFake(), &notifications.NotificationServiceMock{})
 
 		authHeader := util.GetBasicAuthHeader("myUser", password)
 		sc.fakeReq("GET", "/").withAuthorizationHeader(authHeader).exec()
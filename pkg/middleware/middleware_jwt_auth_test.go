@@ -119,6 +119,68 @@ func TestMiddlewareJWTAuth(t *testing.T) {
 		assert.Equal(t, myEmail, sc.context.Email)
 	}, configure, configureEmailClaim, configureAutoSignUp)
 
+	middlewareScenario(t, "Valid token with groups claim and auto_sign_up enabled", func(t *testing.T, sc *scenarioContext) {
+		myEmail := "vladimir@example.com"
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"sub":        myEmail,
+				"foo-email":  myEmail,
+				"foo-groups": []interface{}{"editors", "viewers"},
+			}, nil
+		}
+		var skipTeamSync bool
+		sc.loginService.ExpectedUserFunc = func(cmd *models.UpsertUserCommand) *user.User {
+			skipTeamSync = cmd.SkipTeamSync
+			return &user.User{ID: id}
+		}
+		var groups []string
+		sc.loginService.SyncTeamsFunc = func(usr *user.User, externalUser *models.ExternalUserInfo) error {
+			assert.Equal(t, id, usr.ID)
+			groups = externalUser.Groups
+			return nil
+		}
+		sc.mockSQLStore.ExpectedSignedInUser = &user.SignedInUser{UserId: id, OrgId: orgID, Email: myEmail}
+
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.True(t, skipTeamSync)
+		assert.Equal(t, []string{"editors", "viewers"}, groups)
+	}, configure, configureEmailClaim, configureAutoSignUp, func(cfg *setting.Cfg) {
+		cfg.JWTAuthGroupsClaim = "foo-groups"
+	})
+
+	middlewareScenario(t, "Valid token with groups claim syncs teams only when the groups change", func(t *testing.T, sc *scenarioContext) {
+		myEmail := "vladimir@example.com"
+		tokenGroups := []interface{}{"editors", "viewers"}
+		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
+			return models.JWTClaims{
+				"sub":        myEmail,
+				"foo-email":  myEmail,
+				"foo-groups": tokenGroups,
+			}, nil
+		}
+		var synced [][]string
+		sc.loginService.SyncTeamsFunc = func(usr *user.User, externalUser *models.ExternalUserInfo) error {
+			synced = append(synced, externalUser.Groups)
+			return nil
+		}
+		sc.mockSQLStore.ExpectedSignedInUser = &user.SignedInUser{UserId: id, OrgId: orgID, Email: myEmail}
+
+		// teams are synced without auto_sign_up too
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, [][]string{{"editors", "viewers"}}, synced)
+
+		tokenGroups = []interface{}{"viewers"}
+		sc.fakeReq("GET", "/").withJWTAuthHeader(token).exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, [][]string{{"editors", "viewers"}, {"viewers"}}, synced)
+	}, configure, configureEmailClaim, func(cfg *setting.Cfg) {
+		cfg.JWTAuthGroupsClaim = "foo-groups"
+	})
+
 	middlewareScenario(t, "Valid token without a login claim", func(t *testing.T, sc *scenarioContext) {
 		var verifiedToken string
 		sc.jwtAuthService.VerifyProvider = func(ctx context.Context, token string) (models.JWTClaims, error) {
@@ -30,6 +30,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/sqlstore/mockstore"
+	"github.com/grafana/grafana/pkg/services/twofactor/twofactortest"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/user/usertest"
 	"github.com/grafana/grafana/pkg/setting"
@@ -315,6 +316,40 @@ func TestMiddlewareContext(t *testing.T) {
 		assert.Nil(t, sc.context.UserToken)
 	})
 
+	middlewareScenario(t, "Auth token in cookie expired for the organization", func(t *testing.T, sc *scenarioContext) {
+		const userID int64 = 12
+
+		sc.withTokenSessionCookie("token")
+		sc.mockSQLStore.ExpectedSignedInUser = &user.SignedInUser{OrgId: 2, UserId: userID}
+
+		sc.userAuthTokenService.LookupTokenProvider = func(ctx context.Context, unhashedToken string) (*models.UserToken, error) {
+			return &models.UserToken{
+				Id:        3,
+				UserId:    userID,
+				CreatedAt: time.Now().Add(-2 * time.Hour).Unix(),
+				RotatedAt: time.Now().Add(-2 * time.Hour).Unix(),
+			}, nil
+		}
+		var revoked *models.UserToken
+		sc.userAuthTokenService.RevokeTokenProvider = func(ctx context.Context, token *models.UserToken, soft bool) error {
+			revoked = token
+			return nil
+		}
+
+		sc.fakeReq("GET", "/").exec()
+
+		assert.False(t, sc.context.IsSignedIn)
+		assert.Nil(t, sc.context.UserToken)
+		var expiredErr *models.TokenExpiredError
+		assert.ErrorAs(t, sc.context.LookupTokenErr, &expiredErr)
+		require.NotNil(t, revoked)
+		assert.Equal(t, int64(3), revoked.Id)
+	}, func(cfg *setting.Cfg) {
+		cfg.Session.OrgLifetimes = map[int64]setting.SessionLifetime{
+			2: {MaxInactiveLifetime: time.Hour, MaxLifetime: 24 * time.Hour},
+		}
+	})
+
 	middlewareScenario(t, "When anonymous access is enabled", func(t *testing.T, sc *scenarioContext) {
 		sc.mockSQLStore.ExpectedOrg = &models.Org{Id: 1, Name: sc.cfg.AnonymousOrgName}
 		orga, err := sc.mockSQLStore.CreateOrgWithMember(sc.cfg.AnonymousOrgName, 1)
@@ -632,7 +667,8 @@ func middlewareScenario(t *testing.T, desc string, fn scenarioFunc, cbs ...func(
 		sc.mockSQLStore = mockstore.NewSQLStoreMock()
 		sc.loginService = &loginservice.LoginServiceMock{}
 		sc.apiKeyService = &apikeytest.Service{}
-		ctxHdlr := getContextHandler(t, cfg, sc.mockSQLStore, sc.loginService, sc.apiKeyService)
+		sc.twoFactorService = &twofactortest.FakeService{}
+		ctxHdlr := getContextHandler(t, cfg, sc.mockSQLStore, sc.loginService, sc.apiKeyService, sc.twoFactorService)
 		sc.sqlStore = ctxHdlr.SQLStore
 		sc.contextHandler = ctxHdlr
 		sc.m.Use(ctxHdlr.Middleware)
@@ -665,7 +701,7 @@ func middlewareScenario(t *testing.T, desc string, fn scenarioFunc, cbs ...func(
 	})
 }
 
-func getContextHandler(t *testing.T, cfg *setting.Cfg, mockSQLStore *mockstore.SQLStoreMock, loginService *loginservice.LoginServiceMock, apiKeyService *apikeytest.Service) *contexthandler.ContextHandler {
+func getContextHandler(t *testing.T, cfg *setting.Cfg, mockSQLStore *mockstore.SQLStoreMock, loginService *loginservice.LoginServiceMock, apiKeyService *apikeytest.Service, twoFactorService *twofactortest.FakeService) *contexthandler.ContextHandler {
 	t.Helper()
 
 	if cfg == nil {
@@ -681,8 +717,11 @@ func getContextHandler(t *testing.T, cfg *setting.Cfg, mockSQLStore *mockstore.S
 	authJWTSvc := models.NewFakeJWTService()
 	tracer := tracing.InitializeTracerForTest()
 	authProxy := authproxy.ProvideAuthProxy(cfg, remoteCacheSvc, loginService, mockSQLStore)
-	authenticator := &logintest.AuthenticatorFake{ExpectedUser: &user.User{}}
-	return contexthandler.ProvideService(cfg, userAuthTokenSvc, authJWTSvc, remoteCacheSvc, renderSvc, mockSQLStore, tracer, authProxy, loginService, apiKeyService, authenticator, usertest.NewUserServiceFake())
+	authenticator := &logintest.AuthenticatorFake{ExpectedUser: &user.User{}, ExpectedAuthModule: "grafana"}
+	if twoFactorService == nil {
+		twoFactorService = &twofactortest.FakeService{}
+	}
+	return contexthandler.ProvideService(cfg, userAuthTokenSvc, authJWTSvc, remoteCacheSvc, renderSvc, mockSQLStore, tracer, authProxy, loginService, apiKeyService, authenticator, usertest.NewUserServiceFake(), twoFactorService)
 }
 
 type fakeRenderService struct {
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/login/loginservice"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/mockstore"
+	"github.com/grafana/grafana/pkg/services/twofactor/twofactortest"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 	"github.com/stretchr/testify/require"
@@ -41,6 +42,7 @@ type scenarioContext struct {
 	contextHandler       *contexthandler.ContextHandler
 	loginService         *loginservice.LoginServiceMock
 	apiKeyService        *apikeytest.Service
+	twoFactorService     *twofactortest.FakeService
 
 	req *http.Request
 }
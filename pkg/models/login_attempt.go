@@ -1,22 +1,51 @@
 package models
 
 import (
+	"errors"
 	"time"
 )
 
+var ErrLoginLockoutNotFound = errors.New("login lockout not found")
+
 type LoginAttempt struct {
 	Id        int64
 	Username  string
 	IpAddress string
+	IpSubnet  string
 	Created   int64
 }
 
+// LoginLockoutKind is the kind of source a lockout applies to
+type LoginLockoutKind string
+
+const (
+	LoginLockoutUser LoginLockoutKind = "user"
+	LoginLockoutIP   LoginLockoutKind = "ip"
+)
+
+// LoginLockout blocks logins of a username or from an IP subnet until LockedUntil. Lockouts is the
+// number of consecutive lockouts of the source and drives the exponential backoff.
+type LoginLockout struct {
+	Id          int64            `json:"id"`
+	Kind        LoginLockoutKind `json:"kind"`
+	Source      string           `json:"source"`
+	Lockouts    int64            `json:"lockouts"`
+	LockedUntil time.Time        `json:"lockedUntil"`
+	Created     time.Time        `json:"created"`
+	Updated     time.Time        `json:"updated"`
+}
+
+func (l *LoginLockout) IsActive(now time.Time) bool {
+	return l.LockedUntil.After(now)
+}
+
 // ---------------------
 // COMMANDS
 
 type CreateLoginAttemptCommand struct {
 	Username  string
 	IpAddress string
+	IpSubnet  string
 
 	Result LoginAttempt
 }
@@ -26,6 +55,30 @@ type DeleteOldLoginAttemptsCommand struct {
 	DeletedRows int64
 }
 
+type SaveLoginLockoutCommand struct {
+	Kind        LoginLockoutKind
+	Source      string
+	Lockouts    int64
+	LockedUntil time.Time
+
+	Result *LoginLockout
+}
+
+type DeleteLoginLockoutCommand struct {
+	Id int64
+}
+
+// ResetUserLoginAttemptsCommand removes the failed login attempts and lockouts of the usernames,
+// which are typically the login and the email of a user.
+type ResetUserLoginAttemptsCommand struct {
+	Usernames []string
+}
+
+type DeleteExpiredLoginLockoutsCommand struct {
+	OlderThan   time.Time
+	DeletedRows int64
+}
+
 // ---------------------
 // QUERIES
 
@@ -34,3 +87,23 @@ type GetUserLoginAttemptCountQuery struct {
 	Since    time.Time
 	Result   int64
 }
+
+type GetIPLoginAttemptCountQuery struct {
+	IpSubnet string
+	Since    time.Time
+	Result   int64
+}
+
+type GetLoginLockoutQuery struct {
+	Kind   LoginLockoutKind
+	Source string
+
+	Result *LoginLockout
+}
+
+type SearchLoginLockoutsQuery struct {
+	// ActiveAt only returns lockouts which are still active at that time when set
+	ActiveAt time.Time
+
+	Result []*LoginLockout
+}
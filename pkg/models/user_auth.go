@@ -58,6 +58,8 @@ type UpsertUserCommand struct {
 	ExternalUser *ExternalUserInfo
 	UserLookupParams
 	SignupAllowed bool
+	// SkipTeamSync is set by callers which sync the teams of the user themselves.
+	SkipTeamSync bool
 
 	Result *user.User
 }
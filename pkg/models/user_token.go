@@ -11,7 +11,8 @@ import (
 
 // Typed errors
 var (
-	ErrUserTokenNotFound = errors.New("user token not found")
+	ErrUserTokenNotFound      = errors.New("user token not found")
+	ErrInvalidUserTokenFilter = errors.New("invalid user token filter")
 )
 
 // CreateTokenErr represents a token creation error; used in Enterprise
@@ -64,6 +65,44 @@ type RevokeAuthTokenCmd struct {
 	AuthTokenId int64 `json:"authTokenId"`
 }
 
+// UserTokenFilter filters the user tokens of all users, empty fields match all tokens.
+type UserTokenFilter struct {
+	Id     int64 `json:"-"`
+	UserId int64 `json:"userId"`
+	// ClientIp matches the client IP address, or the addresses of a CIDR range such as 10.0.0.0/24
+	ClientIp string `json:"clientIp"`
+	// UserAgent matches the user agents containing it, ignoring case
+	UserAgent string `json:"userAgent"`
+}
+
+func (f UserTokenFilter) IsEmpty() bool {
+	return f.Id == 0 && f.UserId == 0 && f.ClientIp == "" && f.UserAgent == ""
+}
+
+// SearchUserTokensQuery searches the active user tokens, the most recently created first.
+type SearchUserTokensQuery struct {
+	UserTokenFilter
+	Page  int
+	Limit int
+}
+
+type UserTokenSearchHit struct {
+	UserToken
+	Login string
+	Email string
+}
+
+type SearchUserTokensResult struct {
+	TotalCount int64
+	Tokens     []*UserTokenSearchHit
+}
+
+type RevokeUserTokensCommand struct {
+	UserTokenFilter
+	// ExcludedTokenId is not revoked, such as the token of the user revoking the tokens
+	ExcludedTokenId int64 `json:"-"`
+}
+
 // UserTokenService are used for generating and validating user tokens
 type UserTokenService interface {
 	CreateToken(ctx context.Context, user *user.User, clientIP net.IP, userAgent string) (*UserToken, error)
@@ -74,6 +113,8 @@ type UserTokenService interface {
 	GetUserToken(ctx context.Context, userId, userTokenId int64) (*UserToken, error)
 	GetUserTokens(ctx context.Context, userId int64) ([]*UserToken, error)
 	GetUserRevokedTokens(ctx context.Context, userId int64) ([]*UserToken, error)
+	SearchUserTokens(ctx context.Context, query *SearchUserTokensQuery) (*SearchUserTokensResult, error)
+	RevokeUserTokens(ctx context.Context, cmd *RevokeUserTokensCommand) (int64, error)
 }
 
 type ActiveTokenService interface {
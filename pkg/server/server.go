@@ -127,7 +127,7 @@ func (s *Server) init() error {
 		return err
 	}
 
-	login.ProvideService(s.HTTPServer.SQLStore, s.HTTPServer.Login, s.userService)
+	login.ProvideService(s.HTTPServer.SQLStore, s.HTTPServer.Login, s.userService, s.HTTPServer.NotificationService)
 	social.ProvideService(s.cfg)
 
 	if err := s.roleRegistry.RegisterFixedRoles(s.context); err != nil {
@@ -50,6 +50,23 @@ type PermissionsStore interface {
 	DeleteUserPermissions(ctx context.Context, userID int64) error
 }
 
+// RoleStore stores custom roles and their assignments to users, teams and service accounts.
+// Global roles are stored in the GlobalOrgID organization, roles of other organizations never include them.
+type RoleStore interface {
+	// ListRoles returns the custom roles of an organization with their permissions.
+	ListRoles(ctx context.Context, orgID int64) ([]*RoleDTO, error)
+	// GetRole returns a custom role with its permissions.
+	GetRole(ctx context.Context, orgID int64, uid string) (*RoleDTO, error)
+	CreateRole(ctx context.Context, orgID int64, cmd CreateRoleCommand) (*RoleDTO, error)
+	UpdateRole(ctx context.Context, orgID int64, uid string, cmd UpdateRoleCommand) (*RoleDTO, error)
+	// DeleteRole deletes a custom role, its permissions and its assignments.
+	DeleteRole(ctx context.Context, orgID int64, uid string) error
+	// ListAssignedRoles returns the custom roles assigned to the user, team or service account of the assignment.
+	ListAssignedRoles(ctx context.Context, assignment RoleAssignment) ([]*RoleDTO, error)
+	AssignRole(ctx context.Context, assignment RoleAssignment) error
+	UnassignRole(ctx context.Context, assignment RoleAssignment) error
+}
+
 type TeamPermissionsService interface {
 	GetPermissions(ctx context.Context, user *user.SignedInUser, resourceID string) ([]ResourcePermission, error)
 	SetUserPermission(ctx context.Context, orgID int64, user User, resourceID, permission string) (*ResourcePermission, error)
@@ -13,12 +13,18 @@ import (
 type AccessControlAPI struct {
 	RouteRegister routing.RouteRegister
 	AccessControl ac.AccessControl
+	RoleStore     ac.RoleStore
 }
 
 func (api *AccessControlAPI) RegisterAPIEndpoints() {
 	// Users
 	api.RouteRegister.Get("/api/access-control/user/permissions",
 		middleware.ReqSignedIn, routing.Wrap(api.getUsersPermissions))
+
+	// Custom roles
+	if api.RoleStore != nil {
+		api.registerRoleEndpoints()
+	}
 }
 
 // GET /api/access-control/user/permissions
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var (
+	scopeUsersID           = ac.Scope("users", "id", ac.Parameter(":userId"))
+	scopeServiceAccountsID = ac.Scope("users", "id", ac.Parameter(":serviceAccountId"))
+)
+
+func (api *AccessControlAPI) registerRoleEndpoints() {
+	auth := ac.Middleware(api.AccessControl)
+
+	api.RouteRegister.Group("/api/access-control", func(r routing.RouteRegister) {
+		// Custom roles
+		r.Get("/roles", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionRolesRead)), routing.Wrap(api.listRoles))
+		r.Post("/roles", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionRolesWrite)), routing.Wrap(api.createRole))
+		r.Get("/roles/:roleUID", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionRolesRead, ac.ScopeRolesUID)), routing.Wrap(api.getRole))
+		r.Put("/roles/:roleUID", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionRolesWrite, ac.ScopeRolesUID)), routing.Wrap(api.updateRole))
+		r.Delete("/roles/:roleUID", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionRolesDelete, ac.ScopeRolesUID)), routing.Wrap(api.deleteRole))
+
+		// Custom role assignments
+		r.Get("/users/:userId/roles", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionUsersRolesRead, scopeUsersID)), routing.Wrap(api.listAssignedRoles))
+		r.Post("/users/:userId/roles", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionUsersRolesAdd, scopeUsersID)), routing.Wrap(api.assignRole))
+		r.Delete("/users/:userId/roles/:roleUID", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionUsersRolesRemove, scopeUsersID)), routing.Wrap(api.unassignRole))
+		r.Get("/teams/:teamId/roles", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionTeamsRolesRead, ac.ScopeTeamsID)), routing.Wrap(api.listAssignedRoles))
+		r.Post("/teams/:teamId/roles", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionTeamsRolesAdd, ac.ScopeTeamsID)), routing.Wrap(api.assignRole))
+		r.Delete("/teams/:teamId/roles/:roleUID", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionTeamsRolesRemove, ac.ScopeTeamsID)), routing.Wrap(api.unassignRole))
+		// Service accounts are users, their roles are managed with the users actions
+		r.Get("/serviceaccounts/:serviceAccountId/roles", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionUsersRolesRead, scopeServiceAccountsID)), routing.Wrap(api.listAssignedRoles))
+		r.Post("/serviceaccounts/:serviceAccountId/roles", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionUsersRolesAdd, scopeServiceAccountsID)), routing.Wrap(api.assignRole))
+		r.Delete("/serviceaccounts/:serviceAccountId/roles/:roleUID", auth(middleware.ReqOrgAdmin, ac.EvalPermission(ac.ActionUsersRolesRemove, scopeServiceAccountsID)), routing.Wrap(api.unassignRole))
+	})
+}
+
+// GET /api/access-control/roles
+func (api *AccessControlAPI) listRoles(c *models.ReqContext) response.Response {
+	roles, err := api.RoleStore.ListRoles(c.Req.Context(), c.OrgId)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list roles", err)
+	}
+
+	if api.hasGlobalRolesAccess(c, ac.ActionRolesRead, ac.ScopeRolesAll) {
+		globalRoles, err := api.RoleStore.ListRoles(c.Req.Context(), ac.GlobalOrgID)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to list roles", err)
+		}
+		roles = append(roles, globalRoles...)
+		sort.SliceStable(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	}
+	return response.JSON(http.StatusOK, roles)
+}
+
+// GET /api/access-control/roles/:roleUID
+func (api *AccessControlAPI) getRole(c *models.ReqContext) response.Response {
+	role, err := api.resolveRole(c, web.Params(c.Req)[":roleUID"], ac.ActionRolesRead)
+	if err != nil {
+		return roleErrorResponse("Failed to get role", err)
+	}
+	return response.JSON(http.StatusOK, role)
+}
+
+// POST /api/access-control/roles
+func (api *AccessControlAPI) createRole(c *models.ReqContext) response.Response {
+	var cmd ac.CreateRoleCommand
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if resp := api.checkDelegation(c, cmd.Permissions); resp != nil {
+		return resp
+	}
+
+	role, err := api.RoleStore.CreateRole(c.Req.Context(), c.OrgId, cmd)
+	if err != nil {
+		return roleErrorResponse("Failed to create role", err)
+	}
+	return response.JSON(http.StatusCreated, role)
+}
+
+// PUT /api/access-control/roles/:roleUID
+func (api *AccessControlAPI) updateRole(c *models.ReqContext) response.Response {
+	var cmd ac.UpdateRoleCommand
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if resp := api.checkDelegation(c, cmd.Permissions); resp != nil {
+		return resp
+	}
+
+	uid := web.Params(c.Req)[":roleUID"]
+	existing, err := api.resolveRole(c, uid, ac.ActionRolesWrite)
+	if err != nil {
+		return roleErrorResponse("Failed to update role", err)
+	}
+
+	role, err := api.RoleStore.UpdateRole(c.Req.Context(), existing.OrgID, uid, cmd)
+	if err != nil {
+		return roleErrorResponse("Failed to update role", err)
+	}
+	return response.JSON(http.StatusOK, role)
+}
+
+// DELETE /api/access-control/roles/:roleUID
+func (api *AccessControlAPI) deleteRole(c *models.ReqContext) response.Response {
+	uid := web.Params(c.Req)[":roleUID"]
+	role, err := api.resolveRole(c, uid, ac.ActionRolesDelete)
+	if err != nil {
+		return roleErrorResponse("Failed to delete role", err)
+	}
+
+	if err := api.RoleStore.DeleteRole(c.Req.Context(), role.OrgID, uid); err != nil {
+		return roleErrorResponse("Failed to delete role", err)
+	}
+	return response.Success("Role deleted")
+}
+
+// GET /api/access-control/{users,teams,serviceaccounts}/:id/roles
+func (api *AccessControlAPI) listAssignedRoles(c *models.ReqContext) response.Response {
+	assignment, err := roleAssignment(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+
+	roles, err := api.RoleStore.ListAssignedRoles(c.Req.Context(), assignment)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list assigned roles", err)
+	}
+	return response.JSON(http.StatusOK, roles)
+}
+
+type assignRoleCommand struct {
+	RoleUID string `json:"roleUid"`
+}
+
+// POST /api/access-control/{users,teams,serviceaccounts}/:id/roles
+func (api *AccessControlAPI) assignRole(c *models.ReqContext) response.Response {
+	assignment, err := roleAssignment(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+
+	var cmd assignRoleCommand
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	assignment.RoleUID = cmd.RoleUID
+
+	role, err := api.resolveRole(c, cmd.RoleUID, ac.ActionRolesRead)
+	if err != nil {
+		return roleErrorResponse("Failed to assign role", err)
+	}
+	if resp := api.checkDelegation(c, role.Permissions); resp != nil {
+		return resp
+	}
+	assignment.GlobalRole = role.Global()
+
+	if err := api.RoleStore.AssignRole(c.Req.Context(), assignment); err != nil {
+		return roleErrorResponse("Failed to assign role", err)
+	}
+	return response.Success("Role assigned")
+}
+
+// DELETE /api/access-control/{users,teams,serviceaccounts}/:id/roles/:roleUID
+func (api *AccessControlAPI) unassignRole(c *models.ReqContext) response.Response {
+	assignment, err := roleAssignment(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	assignment.RoleUID = web.Params(c.Req)[":roleUID"]
+
+	role, err := api.resolveRole(c, assignment.RoleUID, ac.ActionRolesRead)
+	if err != nil {
+		return roleErrorResponse("Failed to remove role assignment", err)
+	}
+	assignment.GlobalRole = role.Global()
+
+	if err := api.RoleStore.UnassignRole(c.Req.Context(), assignment); err != nil {
+		return roleErrorResponse("Failed to remove role assignment", err)
+	}
+	return response.Success("Role assignment removed")
+}
+
+// resolveRole returns the custom role of the organization or, for callers with global access
+// for the action, the global role with the uid
+func (api *AccessControlAPI) resolveRole(c *models.ReqContext, uid string, action string) (*ac.RoleDTO, error) {
+	role, err := api.RoleStore.GetRole(c.Req.Context(), c.OrgId, uid)
+	if !errors.Is(err, ac.ErrRoleNotFound) {
+		return role, err
+	}
+	if !api.hasGlobalRolesAccess(c, action, ac.Scope("roles", "uid", uid)) {
+		return nil, err
+	}
+	return api.RoleStore.GetRole(c.Req.Context(), ac.GlobalOrgID, uid)
+}
+
+// hasGlobalRolesAccess checks if the caller may use global roles, which apply to every organization,
+// with its globally assigned permissions
+func (api *AccessControlAPI) hasGlobalRolesAccess(c *models.ReqContext, action string, scope string) bool {
+	return ac.HasGlobalAccess(api.AccessControl, c)(ac.ReqGrafanaAdmin, ac.EvalPermission(action, scope))
+}
+
+// checkDelegation prevents privilege escalation, users can only create and assign roles with
+// permissions they have themselves
+func (api *AccessControlAPI) checkDelegation(c *models.ReqContext, permissions []ac.Permission) response.Response {
+	for _, p := range permissions {
+		var scopes []string
+		if p.Scope != "" {
+			scopes = append(scopes, p.Scope)
+		}
+		hasAccess, err := api.AccessControl.Evaluate(c.Req.Context(), c.SignedInUser, ac.EvalPermission(p.Action, scopes...))
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to evaluate permissions", err)
+		}
+		if !hasAccess {
+			return response.Error(http.StatusForbidden, "Cannot grant a permission you don't have: "+p.Action+" "+p.Scope, nil)
+		}
+	}
+	return nil
+}
+
+func roleAssignment(c *models.ReqContext) (ac.RoleAssignment, error) {
+	params := web.Params(c.Req)
+	assignment := ac.RoleAssignment{OrgID: c.OrgId}
+
+	var err error
+	switch {
+	case params[":teamId"] != "":
+		assignment.TeamID, err = strconv.ParseInt(params[":teamId"], 10, 64)
+		if err != nil {
+			return assignment, errors.New("teamId is invalid")
+		}
+	case params[":serviceAccountId"] != "":
+		assignment.ServiceAccountID, err = strconv.ParseInt(params[":serviceAccountId"], 10, 64)
+		if err != nil {
+			return assignment, errors.New("serviceAccountId is invalid")
+		}
+	default:
+		assignment.UserID, err = strconv.ParseInt(params[":userId"], 10, 64)
+		if err != nil {
+			return assignment, errors.New("userId is invalid")
+		}
+	}
+	return assignment, nil
+}
+
+func roleErrorResponse(message string, err error) response.Response {
+	switch {
+	case errors.Is(err, ac.ErrRoleNotFound), errors.Is(err, ac.ErrAssigneeNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, ac.ErrRoleAlreadyExists), errors.Is(err, ac.ErrRoleVersionConflict):
+		return response.Error(http.StatusConflict, err.Error(), err)
+	case errors.Is(err, ac.ErrRoleNameMissing), errors.Is(err, ac.ErrRoleNameReserved),
+		errors.Is(err, ac.ErrInvalidPermission), errors.Is(err, ac.ErrInvalidScope):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestAccessControlAPI_GlobalRoles(t *testing.T) {
+	sql := sqlstore.InitTestDB(t)
+	store := database.ProvideService(sql)
+	ctx := context.Background()
+
+	usr, err := sql.CreateUser(ctx, user.CreateUserCommand{Login: "viewer", OrgID: 1})
+	require.NoError(t, err)
+
+	orgRole, err := store.CreateRole(ctx, 1, ac.CreateRoleCommand{Name: "org role"})
+	require.NoError(t, err)
+	globalRole, err := store.CreateRole(ctx, ac.GlobalOrgID, ac.CreateRoleCommand{Name: "global role"})
+	require.NoError(t, err)
+
+	t.Run("org admins without global access only resolve roles of their organization", func(t *testing.T) {
+		server := setupRolesTestServer(t, store, false)
+
+		var roles []ac.RoleDTO
+		res := request(t, server, http.MethodGet, "/api/access-control/roles", "")
+		require.Equal(t, http.StatusOK, res.Code)
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &roles))
+		require.Len(t, roles, 1)
+		assert.Equal(t, orgRole.UID, roles[0].UID)
+
+		rolePath := "/api/access-control/roles/" + globalRole.UID
+		assert.Equal(t, http.StatusNotFound, request(t, server, http.MethodGet, rolePath, "").Code)
+		assert.Equal(t, http.StatusNotFound, request(t, server, http.MethodPut, rolePath, `{"name":"renamed"}`).Code)
+		assert.Equal(t, http.StatusNotFound, request(t, server, http.MethodDelete, rolePath, "").Code)
+
+		assignPath := fmt.Sprintf("/api/access-control/users/%d/roles", usr.ID)
+		assert.Equal(t, http.StatusNotFound, request(t, server, http.MethodPost, assignPath, `{"roleUid":"`+globalRole.UID+`"}`).Code)
+		assert.Equal(t, http.StatusOK, request(t, server, http.MethodPost, assignPath, `{"roleUid":"`+orgRole.UID+`"}`).Code)
+
+		role, err := store.GetRole(ctx, ac.GlobalOrgID, globalRole.UID)
+		require.NoError(t, err)
+		assert.Equal(t, "global role", role.Name)
+	})
+
+	t.Run("callers with global access resolve global roles", func(t *testing.T) {
+		server := setupRolesTestServer(t, store, true)
+
+		var roles []ac.RoleDTO
+		res := request(t, server, http.MethodGet, "/api/access-control/roles", "")
+		require.Equal(t, http.StatusOK, res.Code)
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &roles))
+		require.Len(t, roles, 2)
+
+		assignPath := fmt.Sprintf("/api/access-control/users/%d/roles", usr.ID)
+		assert.Equal(t, http.StatusOK, request(t, server, http.MethodPost, assignPath, `{"roleUid":"`+globalRole.UID+`"}`).Code)
+		assigned, err := store.ListAssignedRoles(ctx, ac.RoleAssignment{OrgID: 1, UserID: usr.ID})
+		require.NoError(t, err)
+		assert.Len(t, assigned, 2)
+
+		rolePath := "/api/access-control/roles/" + globalRole.UID
+		assert.Equal(t, http.StatusOK, request(t, server, http.MethodPut, rolePath, `{"name":"renamed"}`).Code)
+		role, err := store.GetRole(ctx, ac.GlobalOrgID, globalRole.UID)
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", role.Name)
+
+		assert.Equal(t, http.StatusOK, request(t, server, http.MethodDelete, rolePath, "").Code)
+		_, err = store.GetRole(ctx, ac.GlobalOrgID, globalRole.UID)
+		assert.ErrorIs(t, err, ac.ErrRoleNotFound)
+	})
+}
+
+// setupRolesTestServer serves the role endpoints to an admin of organization 1, who has all
+// permissions in the organization and, with globalAccess, all global permissions
+func setupRolesTestServer(t *testing.T, store ac.RoleStore, globalAccess bool) *web.Mux {
+	t.Helper()
+
+	accessControl := mock.New()
+	accessControl.EvaluateFunc = func(ctx context.Context, u *user.SignedInUser, evaluator ac.Evaluator) (bool, error) {
+		return u.OrgId != ac.GlobalOrgID || globalAccess, nil
+	}
+
+	router := routing.NewRouteRegister()
+	api := &AccessControlAPI{RouteRegister: router, AccessControl: accessControl, RoleStore: store}
+	api.registerRoleEndpoints()
+
+	server := web.New()
+	server.Use(func(c *web.Context) {
+		reqCtx := &models.ReqContext{
+			Context:      c,
+			SignedInUser: &user.SignedInUser{UserId: 1000, OrgId: 1, OrgRole: org.RoleAdmin},
+			IsSignedIn:   true,
+			Logger:       log.New("test"),
+		}
+		c.Req = c.Req.WithContext(ctxkey.Set(c.Req.Context(), reqCtx))
+	})
+	router.Register(server)
+	return server
+}
+
+func request(t *testing.T, server *web.Mux, method string, url string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, req)
+	return res
+}
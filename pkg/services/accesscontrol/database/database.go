@@ -34,7 +34,7 @@ func (s *AccessControlStore) GetUserPermissions(ctx context.Context, query acces
 		` + filter
 
 		if query.Actions != nil {
-			q += " AND permission.action IN("
+			q += " AND (permission.action IN("
 			if len(query.Actions) > 0 {
 				q += "?" + strings.Repeat(",?", len(query.Actions)-1)
 			}
@@ -42,6 +42,12 @@ func (s *AccessControlStore) GetUserPermissions(ctx context.Context, query acces
 			for _, a := range query.Actions {
 				params = append(params, a)
 			}
+			if query.CustomRoles {
+				filter, filterParams := customRolesFilter("role")
+				q += " OR (" + filter + ")"
+				params = append(params, filterParams...)
+			}
+			q += ")"
 		}
 
 		if err := sess.SQL(q, params...).Find(&result); err != nil {
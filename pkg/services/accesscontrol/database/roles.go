@@ -0,0 +1,397 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// customRolesFilter excludes fixed, basic and managed roles
+func customRolesFilter(alias string) (string, []interface{}) {
+	q := alias + ".name NOT LIKE ? AND " + alias + ".name NOT LIKE ? AND " + alias + ".name NOT LIKE ?"
+	return q, []interface{}{
+		accesscontrol.FixedRolePrefix + "%",
+		accesscontrol.BasicRolePrefix + "%",
+		accesscontrol.ManagedRolePrefix + "%",
+	}
+}
+
+func (s *AccessControlStore) ListRoles(ctx context.Context, orgID int64) ([]*accesscontrol.RoleDTO, error) {
+	var result []*accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		filter, params := customRolesFilter("role")
+		q := "SELECT role.* FROM role WHERE role.org_id = ? AND " + filter + " ORDER BY role.name"
+		roles, err := findRoles(sess, q, append([]interface{}{orgID}, params...)...)
+		if err != nil {
+			return err
+		}
+		result = roles
+		return nil
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) GetRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	var result *accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		role, err := getCustomRole(sess, orgID, uid)
+		if err != nil {
+			return err
+		}
+		result = role
+		return loadPermissions(sess, []*accesscontrol.RoleDTO{role})
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) CreateRole(ctx context.Context, orgID int64, cmd accesscontrol.CreateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	if err := accesscontrol.ValidateCustomRole(cmd.Name, cmd.Permissions); err != nil {
+		return nil, err
+	}
+
+	var result *accesscontrol.RoleDTO
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		uid := cmd.UID
+		if uid == "" {
+			var err error
+			if uid, err = generateNewRoleUID(sess, orgID); err != nil {
+				return err
+			}
+		}
+
+		exists, err := sess.Where("uid = ? OR (org_id = ? AND name = ?)", uid, orgID, cmd.Name).Exist(&accesscontrol.Role{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return accesscontrol.ErrRoleAlreadyExists
+		}
+
+		version := cmd.Version
+		if version < 1 {
+			version = 1
+		}
+
+		now := time.Now()
+		role := accesscontrol.Role{
+			OrgID:       orgID,
+			UID:         uid,
+			Version:     version,
+			Name:        cmd.Name,
+			DisplayName: cmd.DisplayName,
+			Description: cmd.Description,
+			Group:       cmd.Group,
+			Hidden:      cmd.Hidden,
+			Created:     now,
+			Updated:     now,
+		}
+		if _, err := sess.Insert(&role); err != nil {
+			return err
+		}
+
+		permissions, err := insertRolePermissions(sess, role.ID, cmd.Permissions)
+		if err != nil {
+			return err
+		}
+
+		result = roleDTO(role, permissions)
+		return nil
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) UpdateRole(ctx context.Context, orgID int64, uid string, cmd accesscontrol.UpdateRoleCommand) (*accesscontrol.RoleDTO, error) {
+	if err := accesscontrol.ValidateCustomRole(cmd.Name, cmd.Permissions); err != nil {
+		return nil, err
+	}
+
+	var result *accesscontrol.RoleDTO
+	err := s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		existing, err := getCustomRole(sess, orgID, uid)
+		if err != nil {
+			return err
+		}
+
+		version := cmd.Version
+		if version == 0 {
+			version = existing.Version + 1
+		} else if version <= existing.Version {
+			return accesscontrol.ErrRoleVersionConflict
+		}
+
+		if cmd.Name != existing.Name {
+			exists, err := sess.Where("org_id = ? AND name = ?", existing.OrgID, cmd.Name).Exist(&accesscontrol.Role{})
+			if err != nil {
+				return err
+			}
+			if exists {
+				return accesscontrol.ErrRoleAlreadyExists
+			}
+		}
+
+		role := accesscontrol.Role{
+			ID:          existing.ID,
+			OrgID:       existing.OrgID,
+			UID:         existing.UID,
+			Version:     version,
+			Name:        cmd.Name,
+			DisplayName: cmd.DisplayName,
+			Description: cmd.Description,
+			Group:       cmd.Group,
+			Hidden:      cmd.Hidden,
+			Created:     existing.Created,
+			Updated:     time.Now(),
+		}
+		// updating an existing role with an older version from a concurrent request affects no rows
+		affected, err := sess.ID(role.ID).Where("version = ?", existing.Version).AllCols().Update(&role)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return accesscontrol.ErrRoleVersionConflict
+		}
+
+		if _, err := sess.Exec("DELETE FROM permission WHERE role_id = ?", role.ID); err != nil {
+			return err
+		}
+		permissions, err := insertRolePermissions(sess, role.ID, cmd.Permissions)
+		if err != nil {
+			return err
+		}
+
+		result = roleDTO(role, permissions)
+		return nil
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) DeleteRole(ctx context.Context, orgID int64, uid string) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		role, err := getCustomRole(sess, orgID, uid)
+		if err != nil {
+			return err
+		}
+
+		for _, q := range []string{
+			"DELETE FROM permission WHERE role_id = ?",
+			"DELETE FROM user_role WHERE role_id = ?",
+			"DELETE FROM team_role WHERE role_id = ?",
+			"DELETE FROM builtin_role WHERE role_id = ?",
+			"DELETE FROM role WHERE id = ?",
+		} {
+			if _, err := sess.Exec(q, role.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *AccessControlStore) ListAssignedRoles(ctx context.Context, assignment accesscontrol.RoleAssignment) ([]*accesscontrol.RoleDTO, error) {
+	var result []*accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		filter, params := customRolesFilter("role")
+
+		var q string
+		if assignment.TeamID != 0 {
+			q = "SELECT role.* FROM role INNER JOIN team_role AS a ON a.role_id = role.id WHERE a.org_id = ? AND a.team_id = ? AND "
+			params = append([]interface{}{assignment.OrgID, assignment.TeamID}, params...)
+		} else {
+			q = "SELECT role.* FROM role INNER JOIN user_role AS a ON a.role_id = role.id WHERE a.org_id = ? AND a.user_id = ? AND "
+			params = append([]interface{}{assignment.OrgID, assigneeUserID(assignment)}, params...)
+		}
+		roles, err := findRoles(sess, q+filter+" ORDER BY role.name", params...)
+		if err != nil {
+			return err
+		}
+		result = roles
+		return nil
+	})
+	return result, err
+}
+
+func (s *AccessControlStore) AssignRole(ctx context.Context, assignment accesscontrol.RoleAssignment) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		role, err := getCustomRole(sess, assignmentRoleOrgID(assignment), assignment.RoleUID)
+		if err != nil {
+			return err
+		}
+		if err := s.validateAssignee(sess, assignment); err != nil {
+			return err
+		}
+
+		// assigning a role twice is not an error
+		if assignment.TeamID != 0 {
+			exists, err := sess.Where("org_id = ? AND team_id = ? AND role_id = ?", assignment.OrgID, assignment.TeamID, role.ID).Exist(&accesscontrol.TeamRole{})
+			if err != nil || exists {
+				return err
+			}
+			_, err = sess.Insert(&accesscontrol.TeamRole{OrgID: assignment.OrgID, TeamID: assignment.TeamID, RoleID: role.ID, Created: time.Now()})
+			return err
+		}
+
+		userID := assigneeUserID(assignment)
+		exists, err := sess.Where("org_id = ? AND user_id = ? AND role_id = ?", assignment.OrgID, userID, role.ID).Exist(&accesscontrol.UserRole{})
+		if err != nil || exists {
+			return err
+		}
+		_, err = sess.Insert(&accesscontrol.UserRole{OrgID: assignment.OrgID, UserID: userID, RoleID: role.ID, Created: time.Now()})
+		return err
+	})
+}
+
+func (s *AccessControlStore) UnassignRole(ctx context.Context, assignment accesscontrol.RoleAssignment) error {
+	return s.sql.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		role, err := getCustomRole(sess, assignmentRoleOrgID(assignment), assignment.RoleUID)
+		if err != nil {
+			return err
+		}
+
+		if assignment.TeamID != 0 {
+			_, err = sess.Exec("DELETE FROM team_role WHERE org_id = ? AND team_id = ? AND role_id = ?", assignment.OrgID, assignment.TeamID, role.ID)
+			return err
+		}
+		_, err = sess.Exec("DELETE FROM user_role WHERE org_id = ? AND user_id = ? AND role_id = ?", assignment.OrgID, assigneeUserID(assignment), role.ID)
+		return err
+	})
+}
+
+// validateAssignee checks that the user, team or service account of the assignment belongs to the organization
+func (s *AccessControlStore) validateAssignee(sess *sqlstore.DBSession, assignment accesscontrol.RoleAssignment) error {
+	var q string
+	var params []interface{}
+	switch {
+	case assignment.TeamID != 0:
+		q = "SELECT 1 FROM team WHERE org_id = ? AND id = ?"
+		params = []interface{}{assignment.OrgID, assignment.TeamID}
+	case assignment.ServiceAccountID != 0:
+		q = "SELECT 1 FROM " + s.sql.Dialect.Quote("user") + " WHERE org_id = ? AND id = ? AND is_service_account = " + s.sql.Dialect.BooleanStr(true)
+		params = []interface{}{assignment.OrgID, assignment.ServiceAccountID}
+	default:
+		q = "SELECT 1 FROM org_user INNER JOIN " + s.sql.Dialect.Quote("user") + " AS u ON u.id = org_user.user_id" +
+			" WHERE org_user.org_id = ? AND org_user.user_id = ? AND u.is_service_account = " + s.sql.Dialect.BooleanStr(false)
+		params = []interface{}{assignment.OrgID, assignment.UserID}
+	}
+
+	res, err := sess.Query(append([]interface{}{q}, params...)...)
+	if err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		return accesscontrol.ErrAssigneeNotFound
+	}
+	return nil
+}
+
+// assigneeUserID returns the user id of a user or service account assignment, service accounts are users
+func assigneeUserID(assignment accesscontrol.RoleAssignment) int64 {
+	if assignment.ServiceAccountID != 0 {
+		return assignment.ServiceAccountID
+	}
+	return assignment.UserID
+}
+
+// assignmentRoleOrgID returns the organization of the assigned role, global roles are assigned within an organization
+func assignmentRoleOrgID(assignment accesscontrol.RoleAssignment) int64 {
+	if assignment.GlobalRole {
+		return globalOrgID
+	}
+	return assignment.OrgID
+}
+
+// getCustomRole returns the custom role of the organization, global roles are only
+// returned for the global organization
+func getCustomRole(sess *sqlstore.DBSession, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	var role accesscontrol.Role
+	has, err := sess.Where("uid = ? AND org_id = ?", uid, orgID).Get(&role)
+	if err != nil {
+		return nil, err
+	}
+	if !has || !accesscontrol.IsCustomRoleName(role.Name) {
+		return nil, accesscontrol.ErrRoleNotFound
+	}
+	return roleDTO(role, nil), nil
+}
+
+func insertRolePermissions(sess *sqlstore.DBSession, roleID int64, permissions []accesscontrol.Permission) ([]accesscontrol.Permission, error) {
+	now := time.Now()
+	seen := map[accesscontrol.Permission]bool{}
+	inserted := make([]accesscontrol.Permission, 0, len(permissions))
+	for _, p := range permissions {
+		key := accesscontrol.Permission{Action: p.Action, Scope: p.Scope}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		inserted = append(inserted, accesscontrol.Permission{RoleID: roleID, Action: p.Action, Scope: p.Scope, Created: now, Updated: now})
+	}
+	if len(inserted) == 0 {
+		return inserted, nil
+	}
+
+	if _, err := sess.Table(&accesscontrol.Permission{}).InsertMulti(inserted); err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+// findRoles returns the roles of the query with their permissions
+func findRoles(sess *sqlstore.DBSession, q string, params ...interface{}) ([]*accesscontrol.RoleDTO, error) {
+	var found []accesscontrol.Role
+	if err := sess.SQL(q, params...).Find(&found); err != nil {
+		return nil, err
+	}
+
+	roles := make([]*accesscontrol.RoleDTO, 0, len(found))
+	for _, role := range found {
+		roles = append(roles, roleDTO(role, nil))
+	}
+	if err := loadPermissions(sess, roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func loadPermissions(sess *sqlstore.DBSession, roles []*accesscontrol.RoleDTO) error {
+	if len(roles) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*accesscontrol.RoleDTO, len(roles))
+	args := make([]interface{}, 0, len(roles))
+	for _, role := range roles {
+		role.Permissions = []accesscontrol.Permission{}
+		byID[role.ID] = role
+		args = append(args, role.ID)
+	}
+
+	var permissions []accesscontrol.Permission
+	q := "SELECT * FROM permission WHERE role_id IN (?" + strings.Repeat(",?", len(args)-1) + ") ORDER BY action, scope"
+	if err := sess.SQL(q, args...).Find(&permissions); err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		byID[p.RoleID].Permissions = append(byID[p.RoleID].Permissions, p)
+	}
+	return nil
+}
+
+func roleDTO(role accesscontrol.Role, permissions []accesscontrol.Permission) *accesscontrol.RoleDTO {
+	return &accesscontrol.RoleDTO{
+		ID:          role.ID,
+		OrgID:       role.OrgID,
+		UID:         role.UID,
+		Version:     role.Version,
+		Name:        role.Name,
+		DisplayName: role.DisplayName,
+		Description: role.Description,
+		Group:       role.Group,
+		Hidden:      role.Hidden,
+		Permissions: permissions,
+		Created:     role.Created,
+		Updated:     role.Updated,
+	}
+}
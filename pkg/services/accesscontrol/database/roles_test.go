@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/resourcepermissions/types"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestAccessControlStore_CustomRoles(t *testing.T) {
+	store, _ := setupTestEnv(t)
+	ctx := context.Background()
+
+	role, err := store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{
+		Name:        "reports:reader",
+		DisplayName: "Reports reader",
+		Permissions: []accesscontrol.Permission{
+			{Action: "reports:read", Scope: "reports:*"},
+			{Action: "reports:read", Scope: "reports:*"},
+		},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, role.UID)
+	assert.Equal(t, int64(1), role.Version)
+	assert.Len(t, role.Permissions, 1)
+
+	t.Run("reserved names and duplicates are rejected", func(t *testing.T) {
+		_, err := store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{Name: "fixed:reports:reader"})
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleNameReserved)
+		_, err = store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{Name: "reports:reader"})
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleAlreadyExists)
+		_, err = store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{Name: "invalid", Permissions: []accesscontrol.Permission{{Action: "reports:read", Scope: "reports*"}}})
+		assert.ErrorIs(t, err, accesscontrol.ErrInvalidScope)
+	})
+
+	t.Run("roles are listed per organization without managed roles", func(t *testing.T) {
+		_, err := store.CreateRole(ctx, 2, accesscontrol.CreateRoleCommand{Name: "reports:reader"})
+		require.NoError(t, err)
+		_, err = store.SetUserResourcePermission(ctx, 1, accesscontrol.User{ID: 1}, types.SetResourcePermissionCommand{
+			Actions:    []string{"dashboards:write"},
+			Resource:   "dashboards",
+			ResourceID: "1",
+		}, nil)
+		require.NoError(t, err)
+
+		roles, err := store.ListRoles(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, role.UID, roles[0].UID)
+		assert.Equal(t, []string{"reports:read"}, []string{roles[0].Permissions[0].Action})
+
+		_, err = store.GetRole(ctx, 2, role.UID)
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+	})
+
+	t.Run("updates need a greater version", func(t *testing.T) {
+		cmd := accesscontrol.UpdateRoleCommand{
+			Name:        "reports:writer",
+			Version:     1,
+			Permissions: []accesscontrol.Permission{{Action: "reports:write", Scope: "reports:*"}},
+		}
+		_, err := store.UpdateRole(ctx, 1, role.UID, cmd)
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleVersionConflict)
+
+		cmd.Version = 3
+		updated, err := store.UpdateRole(ctx, 1, role.UID, cmd)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), updated.Version)
+
+		cmd.Version = 0
+		updated, err = store.UpdateRole(ctx, 1, role.UID, cmd)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), updated.Version)
+
+		stored, err := store.GetRole(ctx, 1, role.UID)
+		require.NoError(t, err)
+		assert.Equal(t, "reports:writer", stored.Name)
+		require.Len(t, stored.Permissions, 1)
+		assert.Equal(t, "reports:write", stored.Permissions[0].Action)
+	})
+
+	t.Run("deleted roles are not found", func(t *testing.T) {
+		deleted, err := store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{Name: "deleted"})
+		require.NoError(t, err)
+		require.NoError(t, store.DeleteRole(ctx, 1, deleted.UID))
+		_, err = store.GetRole(ctx, 1, deleted.UID)
+		assert.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+		assert.ErrorIs(t, store.DeleteRole(ctx, 1, deleted.UID), accesscontrol.ErrRoleNotFound)
+	})
+}
+
+func TestAccessControlStore_CustomRoleAssignments(t *testing.T) {
+	store, sql := setupTestEnv(t)
+	ctx := context.Background()
+
+	usr, team := createUserAndTeam(t, sql, 1)
+	sql.Cfg.AutoAssignOrg = true
+	sa, err := sql.CreateUser(ctx, user.CreateUserCommand{Login: "sa", OrgID: 1, IsServiceAccount: true})
+	require.NoError(t, err)
+
+	userRole, err := store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{
+		Name:        "user",
+		Permissions: []accesscontrol.Permission{{Action: "reports:read", Scope: "reports:*"}},
+	})
+	require.NoError(t, err)
+	teamRole, err := store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{
+		Name:        "team",
+		Permissions: []accesscontrol.Permission{{Action: "reports:write", Scope: "reports:*"}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.AssignRole(ctx, accesscontrol.RoleAssignment{OrgID: 1, UserID: usr.ID, RoleUID: userRole.UID}))
+	// assigning a role twice is a no-op
+	require.NoError(t, store.AssignRole(ctx, accesscontrol.RoleAssignment{OrgID: 1, UserID: usr.ID, RoleUID: userRole.UID}))
+	require.NoError(t, store.AssignRole(ctx, accesscontrol.RoleAssignment{OrgID: 1, TeamID: team.Id, RoleUID: teamRole.UID}))
+	require.NoError(t, store.AssignRole(ctx, accesscontrol.RoleAssignment{OrgID: 1, ServiceAccountID: sa.ID, RoleUID: teamRole.UID}))
+
+	t.Run("assignees must belong to the organization", func(t *testing.T) {
+		err := store.AssignRole(ctx, accesscontrol.RoleAssignment{OrgID: 1, UserID: sa.ID, RoleUID: userRole.UID})
+		assert.ErrorIs(t, err, accesscontrol.ErrAssigneeNotFound)
+		err = store.AssignRole(ctx, accesscontrol.RoleAssignment{OrgID: 1, ServiceAccountID: usr.ID, RoleUID: userRole.UID})
+		assert.ErrorIs(t, err, accesscontrol.ErrAssigneeNotFound)
+		err = store.AssignRole(ctx, accesscontrol.RoleAssignment{OrgID: 1, TeamID: 1000, RoleUID: userRole.UID})
+		assert.ErrorIs(t, err, accesscontrol.ErrAssigneeNotFound)
+	})
+
+	t.Run("assigned roles are listed", func(t *testing.T) {
+		roles, err := store.ListAssignedRoles(ctx, accesscontrol.RoleAssignment{OrgID: 1, UserID: usr.ID})
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, userRole.UID, roles[0].UID)
+
+		roles, err = store.ListAssignedRoles(ctx, accesscontrol.RoleAssignment{OrgID: 1, ServiceAccountID: sa.ID})
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, teamRole.UID, roles[0].UID)
+	})
+
+	t.Run("custom role permissions are resolved for users", func(t *testing.T) {
+		query := accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: usr.ID, Actions: []string{"dashboards:write"}}
+		permissions, err := store.GetUserPermissions(ctx, query)
+		require.NoError(t, err)
+		assert.Len(t, permissions, 0)
+
+		query.CustomRoles = true
+		permissions, err = store.GetUserPermissions(ctx, query)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"reports:read", "reports:write"}, actions(permissions))
+
+		permissions, err = store.GetUserPermissions(ctx, accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: sa.ID, Actions: []string{}, CustomRoles: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"reports:write"}, actions(permissions))
+	})
+
+	t.Run("removed assignments are not resolved", func(t *testing.T) {
+		require.NoError(t, store.UnassignRole(ctx, accesscontrol.RoleAssignment{OrgID: 1, TeamID: team.Id, RoleUID: teamRole.UID}))
+		require.NoError(t, store.DeleteRole(ctx, 1, userRole.UID))
+
+		permissions, err := store.GetUserPermissions(ctx, accesscontrol.GetUserPermissionsQuery{OrgID: 1, UserID: usr.ID, Actions: []string{}, CustomRoles: true})
+		require.NoError(t, err)
+		assert.Len(t, permissions, 0)
+	})
+}
+
+func actions(permissions []accesscontrol.Permission) []string {
+	result := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		result = append(result, p.Action)
+	}
+	return result
+}
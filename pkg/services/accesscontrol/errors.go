@@ -6,4 +6,11 @@ var (
 	ErrFixedRolePrefixMissing = errors.New("fixed role should be prefixed with '" + FixedRolePrefix + "'")
 	ErrInvalidBuiltinRole     = errors.New("built-in role is not valid")
 	ErrInvalidScope           = errors.New("invalid scope")
+	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleAlreadyExists      = errors.New("role with the same uid or name already exists")
+	ErrRoleNameReserved       = errors.New("custom role name can't start with '" + FixedRolePrefix + "', '" + BasicRolePrefix + "' or '" + ManagedRolePrefix + "'")
+	ErrRoleNameMissing        = errors.New("role name is missing")
+	ErrRoleVersionConflict    = errors.New("role version must be greater than the current version")
+	ErrInvalidPermission      = errors.New("permission action is missing")
+	ErrAssigneeNotFound       = errors.New("user, team or service account not found in organization")
 )
@@ -211,6 +211,43 @@ type GetUserPermissionsQuery struct {
 	UserID  int64 `json:"userId"`
 	Roles   []string
 	Actions []string
+	// CustomRoles includes all permissions of custom roles, regardless of the actions filter
+	CustomRoles bool
+}
+
+// CreateRoleCommand creates a custom role with its permissions in an organization.
+type CreateRoleCommand struct {
+	UID         string       `json:"uid"`
+	Name        string       `json:"name"`
+	DisplayName string       `json:"displayName"`
+	Description string       `json:"description"`
+	Group       string       `json:"group"`
+	Hidden      bool         `json:"hidden"`
+	Version     int64        `json:"version"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// UpdateRoleCommand replaces the definition of a custom role. The version must be greater
+// than the stored version, a zero version increments the stored version.
+type UpdateRoleCommand struct {
+	Name        string       `json:"name"`
+	DisplayName string       `json:"displayName"`
+	Description string       `json:"description"`
+	Group       string       `json:"group"`
+	Hidden      bool         `json:"hidden"`
+	Version     int64        `json:"version"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// RoleAssignment is the assignment of a custom role to either a user, a team or a service account.
+type RoleAssignment struct {
+	OrgID            int64
+	RoleUID          string
+	UserID           int64
+	TeamID           int64
+	ServiceAccountID int64
+	// GlobalRole is set when RoleUID is a global role, global roles can be assigned in every organization
+	GlobalRole bool
 }
 
 // ScopeParams holds the parameters used to fill in scope templates
@@ -389,12 +426,31 @@ const (
 	// Alerting provisioning actions
 	ActionAlertingProvisioningRead  = "alert.provisioning:read"
 	ActionAlertingProvisioningWrite = "alert.provisioning:write"
+
+	// Custom roles actions
+	ActionRolesRead   = "roles:read"
+	ActionRolesWrite  = "roles:write"
+	ActionRolesDelete = "roles:delete"
+
+	// Custom role assignments actions
+	ActionUsersRolesRead   = "users.roles:read"
+	ActionUsersRolesAdd    = "users.roles:add"
+	ActionUsersRolesRemove = "users.roles:remove"
+	ActionTeamsRolesRead   = "teams.roles:read"
+	ActionTeamsRolesAdd    = "teams.roles:add"
+	ActionTeamsRolesRemove = "teams.roles:remove"
+
+	// Custom roles scope
+	ScopeRolesAll = "roles:*"
 )
 
 var (
 	// Team scope
 	ScopeTeamsID = Scope("teams", "id", Parameter(":teamId"))
 
+	// Custom role scope
+	ScopeRolesUID = Scope("roles", "uid", Parameter(":roleUID"))
+
 	// Annotation scopes
 	ScopeAnnotationsRoot             = "annotations"
 	ScopeAnnotationsProvider         = NewScopeProvider(ScopeAnnotationsRoot)
@@ -16,7 +16,7 @@ import (
 
 func ProvideService(
 	features featuremgmt.FeatureToggles, cfg *setting.Cfg,
-	store accesscontrol.PermissionsStore, routeRegister routing.RouteRegister,
+	store accesscontrol.PermissionsStore, routeRegister routing.RouteRegister, roleStore accesscontrol.RoleStore,
 ) (*OSSAccessControlService, error) {
 	var errDeclareRoles error
 	s := ProvideOSSAccessControl(cfg, store)
@@ -24,6 +24,7 @@ func ProvideService(
 		api := api.AccessControlAPI{
 			RouteRegister: routeRegister,
 			AccessControl: s,
+			RoleStore:     roleStore,
 		}
 		api.RegisterAPIEndpoints()
 
@@ -102,7 +103,7 @@ func (ac *OSSAccessControlService) Evaluate(ctx context.Context, user *user.Sign
 	return resolvedEvaluator.Evaluate(user.Permissions[user.OrgId]), nil
 }
 
-// GetUserPermissions returns user permissions based on built-in roles
+// GetUserPermissions returns user permissions based on built-in roles and assigned custom roles
 func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user *user.SignedInUser, _ accesscontrol.Options) ([]accesscontrol.Permission, error) {
 	timer := prometheus.NewTimer(metrics.MAccessPermissionsSummary)
 	defer timer.ObserveDuration()
@@ -114,6 +115,8 @@ func (ac *OSSAccessControlService) GetUserPermissions(ctx context.Context, user
 		UserID:  user.UserId,
 		Roles:   accesscontrol.GetOrgRoles(ac.cfg, user),
 		Actions: append(TeamAdminActions, append(DashboardAdminActions, FolderAdminActions...)...),
+		// custom roles are assigned to users, teams and service accounts with the roles API
+		CustomRoles: true,
 	})
 	if err != nil {
 		return nil, err
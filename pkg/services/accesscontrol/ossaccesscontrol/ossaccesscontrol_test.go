@@ -142,11 +142,13 @@ func TestUsageMetrics(t *testing.T) {
 			if tt.enabled {
 				cfg.RBACEnabled = true
 			}
+			store := database.ProvideService(sqlstore.InitTestDB(t))
 			s, errInitAc := ProvideService(
 				featuremgmt.WithFeatures(),
 				cfg,
-				database.ProvideService(sqlstore.InitTestDB(t)),
+				store,
 				routing.NewRouteRegister(),
+				store,
 			)
 			require.NoError(t, errInitAc)
 			assert.Equal(t, tt.expectedValue, s.GetUsageStats(context.Background())["stats.oss.accesscontrol.enabled.count"])
@@ -496,3 +498,29 @@ func TestOSSAccessControlService_Evaluate(t *testing.T) {
 		})
 	}
 }
+
+func TestOSSAccessControlService_CustomRoles(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.RBACEnabled = true
+	sql := sqlstore.InitTestDB(t)
+	store := database.ProvideService(sql)
+	ac := ProvideOSSAccessControl(cfg, store)
+
+	usr, err := sql.CreateUser(context.Background(), user.CreateUserCommand{Login: "viewer"})
+	require.NoError(t, err)
+	role, err := store.CreateRole(context.Background(), usr.OrgID, accesscontrol.CreateRoleCommand{
+		Name:        "reports:reader",
+		Permissions: []accesscontrol.Permission{{Action: "reports:read", Scope: "reports:*"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.AssignRole(context.Background(), accesscontrol.RoleAssignment{OrgID: usr.OrgID, UserID: usr.ID, RoleUID: role.UID}))
+
+	signedInUser := &user.SignedInUser{UserId: usr.ID, OrgId: usr.OrgID, OrgRole: org.RoleViewer}
+	permissions, err := ac.GetUserPermissions(context.Background(), signedInUser, accesscontrol.Options{})
+	require.NoError(t, err)
+	assert.Contains(t, extractRawPermissionsHelper(permissions), accesscontrol.Permission{Action: "reports:read", Scope: "reports:*"})
+
+	hasAccess, err := ac.Evaluate(context.Background(), signedInUser, accesscontrol.EvalPermission("reports:read", "reports:uid:1"))
+	require.NoError(t, err)
+	assert.True(t, hasAccess)
+}
@@ -167,6 +167,60 @@ var (
 			},
 		}),
 	}
+
+	rolesReaderRole = RoleDTO{
+		Name:        "fixed:roles:reader",
+		DisplayName: "Role reader",
+		Description: "Read custom roles and the custom roles assigned to users, teams and service accounts.",
+		Group:       "Access control",
+		Permissions: []Permission{
+			{
+				Action: ActionRolesRead,
+				Scope:  ScopeRolesAll,
+			},
+			{
+				Action: ActionUsersRolesRead,
+				Scope:  ScopeUsersAll,
+			},
+			{
+				Action: ActionTeamsRolesRead,
+				Scope:  ScopeTeamsAll,
+			},
+		},
+	}
+
+	rolesWriterRole = RoleDTO{
+		Name:        "fixed:roles:writer",
+		DisplayName: "Role writer",
+		Description: "Create, update and delete custom roles, and assign them to users, teams and service accounts.",
+		Group:       "Access control",
+		Permissions: ConcatPermissions(rolesReaderRole.Permissions, []Permission{
+			{
+				Action: ActionRolesWrite,
+				Scope:  ScopeRolesAll,
+			},
+			{
+				Action: ActionRolesDelete,
+				Scope:  ScopeRolesAll,
+			},
+			{
+				Action: ActionUsersRolesAdd,
+				Scope:  ScopeUsersAll,
+			},
+			{
+				Action: ActionUsersRolesRemove,
+				Scope:  ScopeUsersAll,
+			},
+			{
+				Action: ActionTeamsRolesAdd,
+				Scope:  ScopeTeamsAll,
+			},
+			{
+				Action: ActionTeamsRolesRemove,
+				Scope:  ScopeTeamsAll,
+			},
+		}),
+	}
 )
 
 // Declare OSS roles to the accesscontrol service
@@ -203,9 +257,17 @@ func DeclareFixedRoles(ac AccessControl) error {
 		Role:   usersWriterRole,
 		Grants: []string{RoleGrafanaAdmin},
 	}
+	rolesReader := RoleRegistration{
+		Role:   rolesReaderRole,
+		Grants: []string{string(org.RoleAdmin), RoleGrafanaAdmin},
+	}
+	rolesWriter := RoleRegistration{
+		Role:   rolesWriterRole,
+		Grants: []string{string(org.RoleAdmin), RoleGrafanaAdmin},
+	}
 
 	return ac.DeclareFixedRoles(ldapReader, ldapWriter, orgUsersReader, orgUsersWriter,
-		settingsReader, statsReader, usersReader, usersWriter)
+		settingsReader, statsReader, usersReader, usersWriter, rolesReader, rolesWriter)
 }
 
 func ConcatPermissions(permissions ...[]Permission) []Permission {
@@ -229,6 +291,34 @@ func ValidateFixedRole(role RoleDTO) error {
 	return nil
 }
 
+// ValidateCustomRole errors when a custom role uses the name of fixed, basic or managed roles,
+// or when one of its permissions is invalid
+func ValidateCustomRole(name string, permissions []Permission) error {
+	if name == "" {
+		return ErrRoleNameMissing
+	}
+	for _, prefix := range []string{FixedRolePrefix, BasicRolePrefix, ManagedRolePrefix} {
+		if strings.HasPrefix(name, prefix) {
+			return ErrRoleNameReserved
+		}
+	}
+	for _, p := range permissions {
+		if p.Action == "" {
+			return ErrInvalidPermission
+		}
+		if p.Scope != "" && !ValidateScope(p.Scope) {
+			return fmt.Errorf("%w: '%s'", ErrInvalidScope, p.Scope)
+		}
+	}
+	return nil
+}
+
+// IsCustomRoleName returns true when the role is neither a fixed, basic or managed role
+func IsCustomRoleName(name string) bool {
+	return !strings.HasPrefix(name, FixedRolePrefix) && !strings.HasPrefix(name, BasicRolePrefix) &&
+		!strings.HasPrefix(name, ManagedRolePrefix)
+}
+
 // ValidateBuiltInRoles errors when a built-in role does not match expected pattern
 func ValidateBuiltInRoles(builtInRoles []string) error {
 	for _, br := range builtInRoles {
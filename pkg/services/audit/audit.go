@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Service interface {
+	// Record sends the event to all the configured sinks. Failures are logged and never returned,
+	// auditing must not break the audited action.
+	Record(ctx context.Context, event *Event)
+	Search(ctx context.Context, query *SearchQuery) (*SearchResult, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type detailsKey struct{}
+
+// Details can be filled in by request handlers to enrich the event recorded for the request
+type Details struct {
+	mu          sync.Mutex
+	resourceUID string
+	diff        string
+}
+
+func (d *Details) ResourceUID() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.resourceUID
+}
+
+func (d *Details) Diff() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.diff
+}
+
+// WithDetails returns a context holding empty details for the audit event of a request
+func WithDetails(ctx context.Context) (context.Context, *Details) {
+	details := &Details{}
+	return context.WithValue(ctx, detailsKey{}, details), details
+}
+
+// SetResourceUID overrides the resource UID of the audit event, it's a no-op when the request is not audited
+func SetResourceUID(ctx context.Context, uid string) {
+	if details, ok := ctx.Value(detailsKey{}).(*Details); ok {
+		details.mu.Lock()
+		details.resourceUID = uid
+		details.mu.Unlock()
+	}
+}
+
+// SetDiff attaches a summary of the changes to the audit event, it's a no-op when the request is not audited
+func SetDiff(ctx context.Context, diff string) {
+	if details, ok := ctx.Value(detailsKey{}).(*Details); ok {
+		details.mu.Lock()
+		details.diff = diff
+		details.mu.Unlock()
+	}
+}
+
+const maxDiffValueLength = 100
+
+var sensitiveFields = map[string]bool{
+	"password":          true,
+	"basicAuthPassword": true,
+	"secureJsonData":    true,
+	"key":               true,
+	"token":             true,
+}
+
+// DiffSummary compares the JSON representation of two values and returns one line per changed top level field.
+// Values of sensitive fields are never included in the summary.
+func DiffSummary(before, after interface{}) string {
+	b, err := toMap(before)
+	if err != nil {
+		return ""
+	}
+	a, err := toMap(after)
+	if err != nil {
+		return ""
+	}
+
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	lines := make([]string, 0)
+	for _, k := range sorted {
+		old, hadOld := b[k]
+		cur, hasCur := a[k]
+		if reflect.DeepEqual(old, cur) {
+			continue
+		}
+		if sensitiveFields[k] {
+			lines = append(lines, k+": changed")
+			continue
+		}
+		switch {
+		case !hadOld:
+			lines = append(lines, fmt.Sprintf("%s: added %s", k, diffValue(cur)))
+		case !hasCur:
+			lines = append(lines, fmt.Sprintf("%s: removed", k))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s -> %s", k, diffValue(old), diffValue(cur)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	if v == nil {
+		return m, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func diffValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "?"
+	}
+	if len(data) > maxDiffValueLength {
+		return string(data[:maxDiffValueLength]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		method   string
+		route    string
+		category Category
+		action   string
+		audited  bool
+	}{
+		{method: "POST", route: "/login", category: CategoryAuth, action: "auth:login", audited: true},
+		{method: "GET", route: "/login", audited: false},
+		{method: "GET", route: "/login/:name", category: CategoryAuth, action: "auth:login", audited: true},
+		{method: "GET", route: "/logout", category: CategoryAuth, action: "auth:logout", audited: true},
+		{method: "POST", route: "/api/auth/keys", category: CategoryAuth, action: "auth.keys:create", audited: true},
+		{method: "POST", route: "/api/serviceaccounts/:serviceAccountId/tokens", category: CategoryAuth, action: "serviceaccounts.tokens:create", audited: true},
+		{method: "POST", route: "/api/dashboards/uid/:uid/permissions", category: CategoryPermissions, action: "dashboards.permissions:update", audited: true},
+		{method: "GET", route: "/api/dashboards/uid/:uid/permissions", audited: false},
+		{method: "PUT", route: "/api/access-control/roles/:roleUID", category: CategoryPermissions, action: "access-control.roles:update", audited: true},
+		{method: "POST", route: "/api/ds/query", category: CategoryQueries, action: "datasources:query", audited: true},
+		{method: "GET", route: "/api/datasources/proxy/uid/:uid/*", category: CategoryQueries, action: "datasources:proxy", audited: true},
+		{method: "GET", route: "/api/datasources/uid/:uid/resources/*", category: CategoryQueries, action: "datasources:resources", audited: true},
+		{method: "PUT", route: "/api/datasources/uid/:uid", category: CategoryDatasources, action: "datasources:update", audited: true},
+		{method: "GET", route: "/api/datasources/uid/:uid", audited: false},
+		{method: "PATCH", route: "/api/orgs/:orgId/users/:userId", category: CategoryAdmin, action: "orgs.users:update", audited: true},
+		{method: "DELETE", route: "/api/admin/users/:id", category: CategoryAdmin, action: "admin.users:delete", audited: true},
+		{method: "POST", route: "/api/dashboards/db", audited: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.route, func(t *testing.T) {
+			category, action, ok := Classify(tc.method, tc.route)
+			assert.Equal(t, tc.audited, ok)
+			assert.Equal(t, tc.category, category)
+			assert.Equal(t, tc.action, action)
+		})
+	}
+}
+
+func TestDiffSummary(t *testing.T) {
+	type ds struct {
+		Name           string          `json:"name"`
+		URL            string          `json:"url"`
+		BasicAuth      bool            `json:"basicAuth,omitempty"`
+		SecureJSONData map[string]bool `json:"secureJsonData,omitempty"`
+	}
+
+	before := ds{Name: "prometheus", URL: "http://localhost:9090"}
+	after := ds{Name: "prometheus", URL: "http://prometheus:9090", BasicAuth: true, SecureJSONData: map[string]bool{"password": true}}
+
+	assert.Equal(t, "basicAuth: added true\nsecureJsonData: changed\nurl: \"http://localhost:9090\" -> \"http://prometheus:9090\"", DiffSummary(before, after))
+	assert.Equal(t, "", DiffSummary(before, before))
+	assert.Equal(t, "user:1: removed", DiffSummary(map[string]string{"user:1": "View"}, map[string]string{}))
+}
+
+func TestDetails(t *testing.T) {
+	// details are ignored for requests that are not audited
+	SetResourceUID(context.Background(), "uid")
+
+	ctx, details := WithDetails(context.Background())
+	SetResourceUID(ctx, "uid")
+	SetDiff(ctx, "name: a -> b")
+	assert.Equal(t, "uid", details.ResourceUID())
+	assert.Equal(t, "name: a -> b", details.Diff())
+}
@@ -0,0 +1,128 @@
+package auditimpl
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	s.routeRegister.Group("/api/admin/audit", func(entities routing.RouteRegister) {
+		entities.Get("/", middleware.ReqGrafanaAdmin, routing.Wrap(s.searchHandler))
+	})
+}
+
+// swagger:route GET /admin/audit admin searchAuditEvents
+//
+// Search audit events.
+//
+// Returns the audit events matching the search criteria, most recent first. Only available when the database audit sink is enabled.
+// Use the `limit` parameter to control the maximum number of events returned; the default limit is 100.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: searchAuditEventsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (s *Service) searchHandler(c *models.ReqContext) response.Response {
+	query := &audit.SearchQuery{
+		OrgID:       c.QueryInt64("orgId"),
+		ActorLogin:  c.Query("actorLogin"),
+		ActorType:   audit.ActorType(c.Query("actorType")),
+		Category:    audit.Category(c.Query("category")),
+		Action:      c.Query("action"),
+		ResourceUID: c.Query("resourceUid"),
+		Result:      audit.Result(c.Query("result")),
+		Page:        c.QueryInt("page"),
+		Limit:       c.QueryInt("limit"),
+	}
+
+	timeRange := legacydata.NewDataTimeRange(c.Query("from"), c.Query("to"))
+	if timeRange.From != "" {
+		from, err := timeRange.ParseFrom()
+		if err != nil {
+			return response.Error(http.StatusBadRequest, "Invalid from parameter", err)
+		}
+		query.From = from
+	}
+	if timeRange.To != "" {
+		to, err := timeRange.ParseTo()
+		if err != nil {
+			return response.Error(http.StatusBadRequest, "Invalid to parameter", err)
+		}
+		query.To = to
+	}
+
+	result, err := s.Search(c.Req.Context(), query)
+	if err != nil {
+		if errors.Is(err, audit.ErrSearchNotSupported) {
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to search audit events", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// swagger:parameters searchAuditEvents
+type SearchAuditEventsParams struct {
+	// Only return the events of this organization
+	// in:query
+	// required: false
+	OrgID int64 `json:"orgId"`
+	// Login of the user or service account that performed the action
+	// in:query
+	// required: false
+	ActorLogin string `json:"actorLogin"`
+	// in:query
+	// required: false
+	// Enum: user,service-account,api-key,anonymous
+	ActorType string `json:"actorType"`
+	// in:query
+	// required: false
+	// Enum: admin,permissions,auth,datasources,queries
+	Category string `json:"category"`
+	// Action, for example datasources:update
+	// in:query
+	// required: false
+	Action string `json:"action"`
+	// UID or ID of the resource the action was performed on
+	// in:query
+	// required: false
+	ResourceUID string `json:"resourceUid"`
+	// in:query
+	// required: false
+	// Enum: success,failure
+	Result string `json:"result"`
+	// Start of the search time range, in epoch milliseconds or relative time such as now-24h
+	// in:query
+	// required: false
+	From string `json:"from"`
+	// End of the search time range, in epoch milliseconds or relative time such as now
+	// in:query
+	// required: false
+	To string `json:"to"`
+	// Numbering starts at 1
+	// in:query
+	// required: false
+	Page int `json:"page"`
+	// in:query
+	// required: false
+	// default: 100
+	Limit int `json:"limit"`
+}
+
+// swagger:response searchAuditEventsResponse
+type SearchAuditEventsResponse struct {
+	// in: body
+	Body audit.SearchResult `json:"body"`
+}
@@ -0,0 +1,107 @@
+package auditimpl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/sqlstore/db"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// writeTimeout bounds the time spent writing an event, the request context may already be canceled
+// once the response is sent
+const writeTimeout = 5 * time.Second
+
+type Service struct {
+	cfg           *setting.Cfg
+	store         store
+	sinks         []sink
+	categories    map[audit.Category]bool
+	routeRegister routing.RouteRegister
+	log           log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, db db.DB, routeRegister routing.RouteRegister) (*Service, error) {
+	s := &Service{
+		cfg:           cfg,
+		store:         &sqlStore{db: db},
+		categories:    map[audit.Category]bool{},
+		routeRegister: routeRegister,
+		log:           log.New("audit"),
+	}
+
+	if !cfg.Audit.Enabled {
+		return s, nil
+	}
+
+	for _, c := range cfg.Audit.Categories {
+		s.categories[audit.Category(c)] = true
+	}
+
+	for _, name := range cfg.Audit.Sinks {
+		switch name {
+		case sinkDatabase:
+			s.sinks = append(s.sinks, &databaseSink{store: s.store})
+		case sinkFile:
+			fs, err := newFileSink(cfg)
+			if err != nil {
+				return nil, err
+			}
+			s.sinks = append(s.sinks, fs)
+		case sinkSyslog:
+			s.sinks = append(s.sinks, newSyslogSink(cfg))
+		default:
+			return nil, fmt.Errorf("unknown audit sink %q", name)
+		}
+	}
+
+	s.registerAPIEndpoints()
+	return s, nil
+}
+
+func (s *Service) Record(ctx context.Context, event *audit.Event) {
+	if !s.categories[event.Category] {
+		return
+	}
+	if event.Created.IsZero() {
+		event.Created = time.Now()
+	}
+
+	// the event is recorded even when the request has been canceled
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	for _, sk := range s.sinks {
+		if err := sk.Write(ctx, event); err != nil {
+			s.log.Error("Failed to write audit event", "sink", sk.Name(), "action", event.Action, "error", err)
+		}
+	}
+}
+
+func (s *Service) Search(ctx context.Context, query *audit.SearchQuery) (*audit.SearchResult, error) {
+	if !s.hasDatabaseSink() {
+		return nil, audit.ErrSearchNotSupported
+	}
+	return s.store.Search(ctx, query)
+}
+
+// DeleteExpired removes the events older than the configured retention from the database
+func (s *Service) DeleteExpired(ctx context.Context) (int64, error) {
+	if !s.hasDatabaseSink() || s.cfg.Audit.RetentionDays <= 0 {
+		return 0, nil
+	}
+	return s.store.DeleteOlderThan(ctx, time.Now().AddDate(0, 0, -s.cfg.Audit.RetentionDays))
+}
+
+func (s *Service) hasDatabaseSink() bool {
+	for _, sk := range s.sinks {
+		if sk.Name() == sinkDatabase {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package auditimpl
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationAuditService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ss := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.Audit = setting.AuditSettings{
+		Enabled:          true,
+		Sinks:            []string{sinkDatabase, sinkFile},
+		Categories:       []string{string(audit.CategoryAdmin), string(audit.CategoryDatasources)},
+		RetentionDays:    30,
+		FilePath:         filepath.Join(t.TempDir(), "audit.log"),
+		FileMaxSizeShift: 28,
+		FileMaxDays:      7,
+	}
+	s, err := ProvideService(cfg, ss, routing.NewRouteRegister())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	s.Record(ctx, &audit.Event{
+		OrgID:       1,
+		Created:     time.Now().AddDate(0, 0, -60),
+		ActorType:   audit.ActorUser,
+		ActorLogin:  "admin",
+		Category:    audit.CategoryAdmin,
+		Action:      "orgs.users:update",
+		ResourceUID: "2",
+		Result:      audit.ResultSuccess,
+	})
+	s.Record(ctx, &audit.Event{
+		OrgID:       1,
+		ActorType:   audit.ActorServiceAccount,
+		ActorLogin:  "sa-deploy",
+		Category:    audit.CategoryDatasources,
+		Action:      "datasources:update",
+		ResourceUID: "prometheus",
+		Result:      audit.ResultFailure,
+		Diff:        "url: changed",
+	})
+	// disabled categories are not recorded
+	s.Record(ctx, &audit.Event{OrgID: 1, Category: audit.CategoryQueries, Action: "datasources:query"})
+
+	t.Run("events are searched with filters", func(t *testing.T) {
+		result, err := s.Search(ctx, &audit.SearchQuery{OrgID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.TotalCount)
+		require.Len(t, result.Events, 2)
+		assert.Equal(t, "datasources:update", result.Events[0].Action)
+
+		result, err = s.Search(ctx, &audit.SearchQuery{ActorLogin: "sa-deploy", Result: audit.ResultFailure})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, "url: changed", result.Events[0].Diff)
+
+		result, err = s.Search(ctx, &audit.SearchQuery{From: time.Now().AddDate(0, 0, -1)})
+		require.NoError(t, err)
+		assert.Len(t, result.Events, 1)
+
+		result, err = s.Search(ctx, &audit.SearchQuery{OrgID: 1, Limit: 1, Page: 2})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, "orgs.users:update", result.Events[0].Action)
+	})
+
+	t.Run("events are written to the file as JSON lines", func(t *testing.T) {
+		data, err := os.ReadFile(cfg.Audit.FilePath)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 2)
+
+		var event audit.Event
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+		assert.Equal(t, "prometheus", event.ResourceUID)
+		assert.Equal(t, audit.ActorServiceAccount, event.ActorType)
+	})
+
+	t.Run("expired events are deleted", func(t *testing.T) {
+		deleted, err := s.DeleteExpired(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		result, err := s.Search(ctx, &audit.SearchQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.TotalCount)
+	})
+}
+
+func TestAuditService_Sinks(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.Audit = setting.AuditSettings{Enabled: true, Sinks: []string{"kafka"}}
+	_, err := ProvideService(cfg, nil, routing.NewRouteRegister())
+	require.Error(t, err)
+
+	cfg.Audit.Sinks = []string{sinkFile}
+	cfg.Audit.FilePath = filepath.Join(t.TempDir(), "audit.log")
+	s, err := ProvideService(cfg, nil, routing.NewRouteRegister())
+	require.NoError(t, err)
+	_, err = s.Search(context.Background(), &audit.SearchQuery{})
+	require.ErrorIs(t, err, audit.ErrSearchNotSupported)
+}
@@ -0,0 +1,123 @@
+package auditimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	sinkDatabase = "database"
+	sinkFile     = "file"
+	sinkSyslog   = "syslog"
+
+	maxColumnLength = 190
+)
+
+// sink receives every recorded audit event
+type sink interface {
+	Name() string
+	Write(ctx context.Context, event *audit.Event) error
+}
+
+type databaseSink struct {
+	store store
+}
+
+func (s *databaseSink) Name() string { return sinkDatabase }
+
+func (s *databaseSink) Write(ctx context.Context, event *audit.Event) error {
+	// the other sinks get the full values, only the stored copy is truncated to the column sizes
+	e := *event
+	e.ActorLogin = truncate(e.ActorLogin, maxColumnLength)
+	e.ResourceUID = truncate(e.ResourceUID, maxColumnLength)
+	e.Action = truncate(e.Action, maxColumnLength)
+	return s.store.Insert(ctx, &e)
+}
+
+func truncate(s string, length int) string {
+	if len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+// fileSink writes one JSON document per line, the file is rotated like the Grafana log files
+type fileSink struct {
+	writer io.Writer
+}
+
+func newFileSink(cfg *setting.Cfg) (*fileSink, error) {
+	path := cfg.Audit.FilePath
+	if path == "" {
+		path = filepath.Join(cfg.LogsPath, "audit.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	writer := log.NewFileWriter()
+	writer.Filename = path
+	writer.Maxsize = 1 << uint(cfg.Audit.FileMaxSizeShift)
+	writer.Daily = cfg.Audit.FileDailyRotate
+	writer.Maxdays = cfg.Audit.FileMaxDays
+	if err := writer.Init(); err != nil {
+		return nil, fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	return &fileSink{writer: writer}, nil
+}
+
+func (s *fileSink) Name() string { return sinkFile }
+
+func (s *fileSink) Write(_ context.Context, event *audit.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.writer.Write(append(line, '\n'))
+	return err
+}
+
+// syslogSink sends the events as JSON messages with the syslog handler of the Grafana logger
+type syslogSink struct {
+	logger gokitlog.Logger
+}
+
+func newSyslogSink(cfg *setting.Cfg) *syslogSink {
+	format := func(w io.Writer) gokitlog.Logger {
+		return gokitlog.NewJSONLogger(gokitlog.NewSyncWriter(w))
+	}
+	return &syslogSink{logger: log.NewSyslog(cfg.Raw.Section("audit.syslog"), format)}
+}
+
+func (s *syslogSink) Name() string { return sinkSyslog }
+
+func (s *syslogSink) Write(_ context.Context, event *audit.Event) error {
+	return level.Info(s.logger).Log(
+		"msg", "audit",
+		"created", event.Created,
+		"orgId", event.OrgID,
+		"actorType", event.ActorType,
+		"actorId", event.ActorID,
+		"actorLogin", event.ActorLogin,
+		"category", event.Category,
+		"action", event.Action,
+		"resourceUid", event.ResourceUID,
+		"method", event.Method,
+		"path", event.Path,
+		"ip", event.IP,
+		"result", event.Result,
+		"statusCode", event.StatusCode,
+		"diff", event.Diff,
+	)
+}
@@ -0,0 +1,109 @@
+package auditimpl
+
+import (
+	"context"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/audit"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/db"
+)
+
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+type store interface {
+	Insert(context.Context, *audit.Event) error
+	Search(context.Context, *audit.SearchQuery) (*audit.SearchResult, error)
+	DeleteOlderThan(context.Context, time.Time) (int64, error)
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) Insert(ctx context.Context, event *audit.Event) error {
+	return s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(event)
+		return err
+	})
+}
+
+func (s *sqlStore) Search(ctx context.Context, query *audit.SearchQuery) (*audit.SearchResult, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	page := query.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	result := &audit.SearchResult{
+		Events:  make([]*audit.Event, 0),
+		Page:    page,
+		PerPage: limit,
+	}
+	err := s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		filter := func() *xorm.Session {
+			q := sess.Table("audit_event")
+			if query.OrgID > 0 {
+				q = q.Where("org_id = ?", query.OrgID)
+			}
+			if !query.From.IsZero() {
+				q = q.Where("created >= ?", query.From)
+			}
+			if !query.To.IsZero() {
+				q = q.Where("created <= ?", query.To)
+			}
+			if query.ActorLogin != "" {
+				q = q.Where("actor_login = ?", query.ActorLogin)
+			}
+			if query.ActorType != "" {
+				q = q.Where("actor_type = ?", query.ActorType)
+			}
+			if query.Category != "" {
+				q = q.Where("category = ?", query.Category)
+			}
+			if query.Action != "" {
+				q = q.Where("action = ?", query.Action)
+			}
+			if query.ResourceUID != "" {
+				q = q.Where("resource_uid = ?", query.ResourceUID)
+			}
+			if query.Result != "" {
+				q = q.Where("result = ?", query.Result)
+			}
+			return q
+		}
+
+		count, err := filter().Count(&audit.Event{})
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		return filter().Desc("created", "id").Limit(limit, (page-1)*limit).Find(&result.Events)
+	})
+	return result, err
+}
+
+func (s *sqlStore) DeleteOlderThan(ctx context.Context, olderThan time.Time) (int64, error) {
+	var affected int64
+	err := s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM audit_event WHERE created < ?", olderThan)
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected, err
+}
@@ -0,0 +1,33 @@
+package audittest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/services/audit"
+)
+
+type FakeAuditService struct {
+	mu             sync.Mutex
+	Events         []*audit.Event
+	ExpectedResult *audit.SearchResult
+	ExpectedError  error
+}
+
+func NewFakeAuditService() *FakeAuditService {
+	return &FakeAuditService{}
+}
+
+func (f *FakeAuditService) Record(ctx context.Context, event *audit.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Events = append(f.Events, event)
+}
+
+func (f *FakeAuditService) Search(ctx context.Context, query *audit.SearchQuery) (*audit.SearchResult, error) {
+	return f.ExpectedResult, f.ExpectedError
+}
+
+func (f *FakeAuditService) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, f.ExpectedError
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrSearchNotSupported = errors.New("audit events can only be searched when the database sink is enabled")
+)
+
+type ActorType string
+
+const (
+	ActorUser           ActorType = "user"
+	ActorServiceAccount ActorType = "service-account"
+	ActorAPIKey         ActorType = "api-key"
+	ActorAnonymous      ActorType = "anonymous"
+)
+
+type Result string
+
+const (
+	ResultSuccess Result = "success"
+	ResultFailure Result = "failure"
+)
+
+// Category groups audited actions, categories can be enabled and disabled in the [audit] section
+type Category string
+
+const (
+	CategoryAdmin       Category = "admin"
+	CategoryPermissions Category = "permissions"
+	CategoryAuth        Category = "auth"
+	CategoryDatasources Category = "datasources"
+	CategoryQueries     Category = "queries"
+)
+
+// Event is a single audited action
+type Event struct {
+	ID          int64     `xorm:"pk autoincr 'id'" json:"id"`
+	OrgID       int64     `xorm:"org_id" json:"orgId"`
+	Created     time.Time `json:"created"`
+	ActorType   ActorType `json:"actorType"`
+	ActorID     int64     `xorm:"actor_id" json:"actorId"`
+	ActorLogin  string    `json:"actorLogin"`
+	Category    Category  `json:"category"`
+	Action      string    `json:"action"`
+	ResourceUID string    `xorm:"resource_uid" json:"resourceUid"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	IP          string    `xorm:"ip" json:"ip"`
+	Result      Result    `json:"result"`
+	StatusCode  int       `json:"statusCode"`
+	Diff        string    `json:"diff,omitempty"`
+}
+
+func (e Event) TableName() string { return "audit_event" }
+
+type SearchQuery struct {
+	OrgID       int64
+	From        time.Time
+	To          time.Time
+	ActorLogin  string
+	ActorType   ActorType
+	Category    Category
+	Action      string
+	ResourceUID string
+	Result      Result
+	Page        int
+	Limit       int
+}
+
+type SearchResult struct {
+	TotalCount int64    `json:"totalCount"`
+	Events     []*Event `json:"events"`
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"net/http"
+	"strings"
+)
+
+type rule struct {
+	match    func(route string) bool
+	category Category
+	// reads are audited as well as writes
+	reads bool
+	// action overrides the action derived from the route
+	action string
+}
+
+func prefix(p string) func(string) bool {
+	return func(route string) bool {
+		return route == p || strings.HasPrefix(route, p+"/")
+	}
+}
+
+// rules are evaluated in order, the first matching rule wins
+var rules = []rule{
+	// OAuth logins complete with a GET request
+	{match: prefix("/login/:name"), category: CategoryAuth, reads: true, action: "auth:login"},
+	{match: prefix("/login"), category: CategoryAuth, action: "auth:login"},
+	{match: prefix("/logout"), category: CategoryAuth, reads: true, action: "auth:logout"},
+	{match: prefix("/api/auth/keys"), category: CategoryAuth},
+	{match: prefix("/api/user/password"), category: CategoryAuth},
+	{match: prefix("/api/user/revoke-auth-token"), category: CategoryAuth},
+	{match: prefix("/api/admin/users/:id/logout"), category: CategoryAuth},
+	{match: prefix("/api/admin/users/:id/revoke-auth-token"), category: CategoryAuth},
+	{match: func(route string) bool {
+		return strings.HasPrefix(route, "/api/serviceaccounts/") && strings.Contains(route, "/tokens")
+	}, category: CategoryAuth},
+
+	{match: prefix("/api/access-control"), category: CategoryPermissions},
+	{match: func(route string) bool {
+		return strings.Contains(route, "/permissions")
+	}, category: CategoryPermissions},
+
+	{match: prefix("/api/ds/query"), category: CategoryQueries, action: "datasources:query"},
+	{match: prefix("/api/tsdb/query"), category: CategoryQueries, action: "datasources:query"},
+	{match: prefix("/api/datasources/proxy"), category: CategoryQueries, reads: true, action: "datasources:proxy"},
+	{match: func(route string) bool {
+		return strings.HasPrefix(route, "/api/datasources/") && strings.Contains(route, "/resources")
+	}, category: CategoryQueries, reads: true, action: "datasources:resources"},
+	{match: prefix("/api/datasources"), category: CategoryDatasources},
+
+	{match: prefix("/api/admin"), category: CategoryAdmin},
+	{match: prefix("/api/orgs"), category: CategoryAdmin},
+	{match: prefix("/api/org"), category: CategoryAdmin},
+	{match: prefix("/api/users"), category: CategoryAdmin},
+	{match: prefix("/api/teams"), category: CategoryAdmin},
+	{match: prefix("/api/serviceaccounts"), category: CategoryAdmin},
+}
+
+// Classify returns the category and the action of a request, the last return value is false when the request
+// must not be audited
+func Classify(method, route string) (Category, string, bool) {
+	for _, r := range rules {
+		if !r.match(route) {
+			continue
+		}
+		if !r.reads && isRead(method) {
+			return "", "", false
+		}
+		if r.action != "" {
+			return r.category, r.action, true
+		}
+		return r.category, routeAction(method, route), true
+	}
+	return "", "", false
+}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// routeAction builds an action such as `orgs.users:update` from the method and the route
+// pattern, route parameters and lookup segments are left out of the resource name
+func routeAction(method, route string) string {
+	segments := make([]string, 0)
+	for _, s := range strings.Split(strings.TrimPrefix(route, "/api/"), "/") {
+		if s == "" || s == "uid" || s == "id" || s == "name" || s == "*" || strings.HasPrefix(s, ":") {
+			continue
+		}
+		segments = append(segments, s)
+	}
+	return strings.Join(segments, ".") + ":" + verb(method, route)
+}
+
+func verb(method, route string) string {
+	switch method {
+	case http.MethodPost:
+		// permissions are set with POST
+		if strings.HasSuffix(route, "/permissions") {
+			return "update"
+		}
+		return "create"
+	case http.MethodPut, http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	}
+	return "read"
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"net"
 	"strings"
 	"time"
@@ -107,6 +109,12 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *user.User,
 		return nil, err
 	}
 
+	if s.Cfg.Session.MaxActiveSessions > 0 {
+		if err := s.revokeExceedingTokens(ctx, user.ID); err != nil {
+			s.log.Error("Failed to revoke user auth tokens exceeding the maximum active sessions", "userId", user.ID, "error", err)
+		}
+	}
+
 	userAuthToken.UnhashedToken = token
 
 	s.log.Debug("user auth token created", "tokenId", userAuthToken.Id, "userId", userAuthToken.UserId, "clientIP", userAuthToken.ClientIp, "userAgent", userAuthToken.UserAgent, "authToken", userAuthToken.AuthToken)
@@ -138,10 +146,12 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 		return nil, models.ErrUserTokenNotFound
 	}
 
+	// only tokens exceeding the maximum active sessions are soft revoked
 	if model.RevokedAt > 0 {
 		return nil, &models.TokenRevokedError{
-			UserID:  model.UserId,
-			TokenID: model.Id,
+			UserID:                model.UserId,
+			TokenID:               model.Id,
+			MaxConcurrentSessions: int64(s.Cfg.Session.MaxActiveSessions),
 		}
 	}
 
@@ -453,6 +463,192 @@ func (s *UserAuthTokenService) GetUserRevokedTokens(ctx context.Context, userId
 	return result, err
 }
 
+// SearchUserTokens returns the active tokens of all users matching the filter of the query.
+func (s *UserAuthTokenService) SearchUserTokens(ctx context.Context, query *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	page := query.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	result := &models.SearchUserTokensResult{Tokens: []*models.UserTokenSearchHit{}}
+	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		where, params, err := s.userTokensFilter(dbSession, query.UserTokenFilter)
+		if err != nil {
+			return err
+		}
+		where = append(where, "created_at > ? AND rotated_at > ? AND revoked_at = 0")
+		params = append(params, s.createdAfterParam(), s.rotatedAfterParam())
+		whereSQL := strings.Join(where, " AND ")
+
+		result.TotalCount, err = dbSession.Where(whereSQL, params...).Count(&userAuthToken{})
+		if err != nil {
+			return err
+		}
+
+		var tokens []*userAuthToken
+		err = dbSession.Where(whereSQL, params...).Desc("created_at", "id").Limit(limit, (page-1)*limit).Find(&tokens)
+		if err != nil || len(tokens) == 0 {
+			return err
+		}
+
+		userIDs := make([]int64, 0, len(tokens))
+		for _, token := range tokens {
+			userIDs = append(userIDs, token.UserId)
+		}
+		var users []*user.User
+		if err := dbSession.In("id", userIDs).Cols("id", "login", "email").Find(&users); err != nil {
+			return err
+		}
+		usersByID := make(map[int64]*user.User, len(users))
+		for _, u := range users {
+			usersByID[u.ID] = u
+		}
+
+		for _, token := range tokens {
+			hit := &models.UserTokenSearchHit{}
+			if err := token.toUserToken(&hit.UserToken); err != nil {
+				return err
+			}
+			if u, ok := usersByID[token.UserId]; ok {
+				hit.Login = u.Login
+				hit.Email = u.Email
+			}
+			result.Tokens = append(result.Tokens, hit)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// RevokeUserTokens deletes the tokens of all users matching the filter of the command and
+// returns how many were revoked. The filter cannot be empty.
+func (s *UserAuthTokenService) RevokeUserTokens(ctx context.Context, cmd *models.RevokeUserTokensCommand) (int64, error) {
+	if cmd.IsEmpty() {
+		return 0, errors.New("revoking user tokens requires a filter")
+	}
+
+	var affected int64
+	err := s.SQLStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		where, params, err := s.userTokensFilter(dbSession, cmd.UserTokenFilter)
+		if err != nil {
+			return err
+		}
+		if cmd.ExcludedTokenId != 0 {
+			where = append(where, "id <> ?")
+			params = append(params, cmd.ExcludedTokenId)
+		}
+
+		sql := "DELETE FROM user_auth_token WHERE " + strings.Join(where, " AND ")
+		res, err := dbSession.Exec(append([]interface{}{sql}, params...)...)
+		if err != nil {
+			return err
+		}
+
+		affected, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.log.Debug("user auth tokens revoked", "filter", cmd.UserTokenFilter, "count", affected)
+	return affected, nil
+}
+
+func (s *UserAuthTokenService) userTokensFilter(dbSession *sqlstore.DBSession, filter models.UserTokenFilter) ([]string, []interface{}, error) {
+	where := []string{}
+	params := []interface{}{}
+	if filter.Id != 0 {
+		where = append(where, "id = ?")
+		params = append(params, filter.Id)
+	}
+	if filter.UserId != 0 {
+		where = append(where, "user_id = ?")
+		params = append(params, filter.UserId)
+	}
+	if filter.UserAgent != "" {
+		where = append(where, "LOWER(user_agent) "+s.SQLStore.Dialect.LikeStr()+" ? ESCAPE '!'")
+		params = append(params, "%"+escapeLike(strings.ToLower(filter.UserAgent))+"%")
+	}
+	if filter.ClientIp == "" {
+		return where, params, nil
+	}
+
+	if !strings.Contains(filter.ClientIp, "/") {
+		ip := net.ParseIP(filter.ClientIp)
+		if ip == nil {
+			return nil, nil, fmt.Errorf("%w: %q is not an IP address", models.ErrInvalidUserTokenFilter, filter.ClientIp)
+		}
+		where = append(where, "client_ip = ?")
+		params = append(params, ip.String())
+		return where, params, nil
+	}
+
+	// IP ranges cannot be matched portably in SQL, so the tokens matching the other
+	// conditions are filtered here and selected by id.
+	_, ipNet, err := net.ParseCIDR(filter.ClientIp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %q is not a CIDR range", models.ErrInvalidUserTokenFilter, filter.ClientIp)
+	}
+	var tokens []*userAuthToken
+	if err := dbSession.Where(strings.Join(append([]string{"1 = 1"}, where...), " AND "), params...).
+		Cols("id", "client_ip").Find(&tokens); err != nil {
+		return nil, nil, err
+	}
+	ids := []interface{}{}
+	for _, token := range tokens {
+		if ip := net.ParseIP(token.ClientIp); ip != nil && ipNet.Contains(ip) {
+			ids = append(ids, token.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return append(where, "1 = 0"), params, nil
+	}
+	where = append(where, "id IN (?"+strings.Repeat(",?", len(ids)-1)+")")
+	return where, append(params, ids...), nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, using ! as the escape character
+// since backslashes are handled differently by each database.
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
+
+// revokeExceedingTokens soft revokes the oldest active tokens of the user exceeding the maximum
+// active sessions, so that their clients are told why they have been signed out.
+func (s *UserAuthTokenService) revokeExceedingTokens(ctx context.Context, userID int64) error {
+	maxSessions := s.Cfg.Session.MaxActiveSessions
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		var tokens []*userAuthToken
+		err := dbSession.Where("user_id = ? AND created_at > ? AND rotated_at > ? AND revoked_at = 0",
+			userID,
+			s.createdAfterParam(),
+			s.rotatedAfterParam()).
+			Desc("created_at", "id").
+			Find(&tokens)
+		if err != nil || len(tokens) <= maxSessions {
+			return err
+		}
+
+		ids := make([]int64, 0, len(tokens)-maxSessions)
+		for _, token := range tokens[maxSessions:] {
+			ids = append(ids, token.Id)
+		}
+		if _, err := dbSession.Table("user_auth_token").In("id", ids).
+			Update(map[string]interface{}{"revoked_at": getTime().Unix()}); err != nil {
+			return err
+		}
+
+		s.log.Debug("user auth tokens exceeding the maximum active sessions revoked", "userId", userID, "count", len(ids))
+		return nil
+	})
+}
+
 func (s *UserAuthTokenService) createdAfterParam() int64 {
 	return getTime().Add(-s.Cfg.LoginMaxLifetime).Unix()
 }
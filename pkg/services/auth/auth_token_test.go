@@ -529,6 +529,131 @@ func TestUserAuthToken(t *testing.T) {
 	})
 }
 
+func TestUserAuthTokenMaxActiveSessions(t *testing.T) {
+	ctx := createTestContext(t)
+	ctx.tokenService.Cfg.Session.MaxActiveSessions = 2
+	usr := &user.User{ID: int64(10)}
+
+	now := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	tokens := make([]*models.UserToken, 0, 3)
+	for i := 0; i < 3; i++ {
+		token, err := ctx.tokenService.CreateToken(context.Background(), usr, net.ParseIP("192.168.10.11"), "some user agent")
+		require.Nil(t, err)
+		tokens = append(tokens, token)
+	}
+
+	t.Run("the oldest token should be soft revoked", func(t *testing.T) {
+		_, err := ctx.tokenService.LookupToken(context.Background(), tokens[0].UnhashedToken)
+		var revokedErr *models.TokenRevokedError
+		require.ErrorAs(t, err, &revokedErr)
+		require.Equal(t, tokens[0].Id, revokedErr.TokenID)
+		require.Equal(t, int64(2), revokedErr.MaxConcurrentSessions)
+	})
+
+	t.Run("the newest tokens should stay active", func(t *testing.T) {
+		for _, token := range tokens[1:] {
+			_, err := ctx.tokenService.LookupToken(context.Background(), token.UnhashedToken)
+			require.Nil(t, err)
+		}
+	})
+
+	t.Run("tokens of other users should not be revoked", func(t *testing.T) {
+		other, err := ctx.tokenService.CreateToken(context.Background(), &user.User{ID: int64(11)}, net.ParseIP("192.168.10.11"), "some user agent")
+		require.Nil(t, err)
+
+		active, err := ctx.tokenService.GetUserTokens(context.Background(), usr.ID)
+		require.Nil(t, err)
+		require.Len(t, active, 2)
+
+		_, err = ctx.tokenService.LookupToken(context.Background(), other.UnhashedToken)
+		require.Nil(t, err)
+	})
+}
+
+func TestSearchAndRevokeUserTokens(t *testing.T) {
+	ctx := createTestContext(t)
+
+	now := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	createToken := func(userID int64, ip, userAgent string) *models.UserToken {
+		token, err := ctx.tokenService.CreateToken(context.Background(), &user.User{ID: userID}, net.ParseIP(ip), userAgent)
+		require.Nil(t, err)
+		return token
+	}
+	chrome := createToken(1, "192.168.10.11", "Mozilla/5.0 Chrome/72.0")
+	curl := createToken(1, "10.0.0.1", "curl/7.64.1")
+	firefox := createToken(2, "192.168.10.12", "Mozilla/5.0 Firefox/90.0")
+
+	search := func(filter models.UserTokenFilter) []int64 {
+		result, err := ctx.tokenService.SearchUserTokens(context.Background(), &models.SearchUserTokensQuery{UserTokenFilter: filter})
+		require.Nil(t, err)
+		ids := []int64{}
+		for _, hit := range result.Tokens {
+			ids = append(ids, hit.Id)
+		}
+		require.Equal(t, int64(len(ids)), result.TotalCount)
+		return ids
+	}
+
+	t.Run("should search the tokens of all users, the most recent first", func(t *testing.T) {
+		require.Equal(t, []int64{firefox.Id, curl.Id, chrome.Id}, search(models.UserTokenFilter{}))
+	})
+
+	t.Run("should filter the tokens", func(t *testing.T) {
+		require.Equal(t, []int64{curl.Id, chrome.Id}, search(models.UserTokenFilter{UserId: 1}))
+		require.Equal(t, []int64{firefox.Id}, search(models.UserTokenFilter{ClientIp: "192.168.10.12"}))
+		require.Equal(t, []int64{firefox.Id, chrome.Id}, search(models.UserTokenFilter{ClientIp: "192.168.10.0/24"}))
+		require.Equal(t, []int64{chrome.Id}, search(models.UserTokenFilter{UserId: 1, ClientIp: "192.168.0.0/16"}))
+		require.Equal(t, []int64{curl.Id}, search(models.UserTokenFilter{ClientIp: "10.0.0.0/8"}))
+		require.Equal(t, []int64{}, search(models.UserTokenFilter{ClientIp: "172.16.0.0/12"}))
+		require.Equal(t, []int64{firefox.Id, chrome.Id}, search(models.UserTokenFilter{UserAgent: "mozilla"}))
+		require.Equal(t, []int64{chrome.Id}, search(models.UserTokenFilter{UserId: 1, UserAgent: "chrome"}))
+	})
+
+	t.Run("should not treat filters as patterns", func(t *testing.T) {
+		require.Equal(t, []int64{}, search(models.UserTokenFilter{ClientIp: "192.168.10.1"}))
+		require.Equal(t, []int64{}, search(models.UserTokenFilter{UserAgent: "%"}))
+		require.Equal(t, []int64{}, search(models.UserTokenFilter{UserAgent: "mozilla_5"}))
+	})
+
+	t.Run("should reject invalid IP filters", func(t *testing.T) {
+		for _, clientIP := range []string{"192.168.10.", "192.168.10.0/33"} {
+			_, err := ctx.tokenService.SearchUserTokens(context.Background(), &models.SearchUserTokensQuery{
+				UserTokenFilter: models.UserTokenFilter{ClientIp: clientIP},
+			})
+			require.ErrorIs(t, err, models.ErrInvalidUserTokenFilter)
+		}
+	})
+
+	t.Run("should paginate the tokens", func(t *testing.T) {
+		result, err := ctx.tokenService.SearchUserTokens(context.Background(), &models.SearchUserTokensQuery{Page: 2, Limit: 2})
+		require.Nil(t, err)
+		require.Equal(t, int64(3), result.TotalCount)
+		require.Len(t, result.Tokens, 1)
+		require.Equal(t, chrome.Id, result.Tokens[0].Id)
+	})
+
+	t.Run("should not revoke tokens without a filter", func(t *testing.T) {
+		_, err := ctx.tokenService.RevokeUserTokens(context.Background(), &models.RevokeUserTokensCommand{})
+		require.Error(t, err)
+	})
+
+	t.Run("should revoke the tokens matching the filter except the excluded one", func(t *testing.T) {
+		count, err := ctx.tokenService.RevokeUserTokens(context.Background(), &models.RevokeUserTokensCommand{
+			UserTokenFilter: models.UserTokenFilter{ClientIp: "192.168.10.0/24"},
+			ExcludedTokenId: firefox.Id,
+		})
+		require.Nil(t, err)
+		require.Equal(t, int64(1), count)
+		require.Equal(t, []int64{firefox.Id, curl.Id}, search(models.UserTokenFilter{}))
+	})
+}
+
 func createTestContext(t *testing.T) *testContext {
 	t.Helper()
 	maxInactiveDurationVal, _ := time.ParseDuration("168h")
@@ -19,6 +19,8 @@ type FakeUserAuthTokenService struct {
 	GetUserTokensProvider        func(ctx context.Context, userId int64) ([]*models.UserToken, error)
 	GetUserRevokedTokensProvider func(ctx context.Context, userId int64) ([]*models.UserToken, error)
 	BatchRevokedTokenProvider    func(ctx context.Context, userIds []int64) error
+	SearchUserTokensProvider     func(ctx context.Context, query *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error)
+	RevokeUserTokensProvider     func(ctx context.Context, cmd *models.RevokeUserTokensCommand) (int64, error)
 }
 
 func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
@@ -56,6 +58,12 @@ func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
 		GetUserTokensProvider: func(ctx context.Context, userId int64) ([]*models.UserToken, error) {
 			return nil, nil
 		},
+		SearchUserTokensProvider: func(ctx context.Context, query *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error) {
+			return &models.SearchUserTokensResult{Tokens: []*models.UserTokenSearchHit{}}, nil
+		},
+		RevokeUserTokensProvider: func(ctx context.Context, cmd *models.RevokeUserTokensCommand) (int64, error) {
+			return 0, nil
+		},
 	}
 }
 
@@ -105,3 +113,11 @@ func (s *FakeUserAuthTokenService) GetUserRevokedTokens(ctx context.Context, use
 func (s *FakeUserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context, userIds []int64) error {
 	return s.BatchRevokedTokenProvider(ctx, userIds)
 }
+
+func (s *FakeUserAuthTokenService) SearchUserTokens(ctx context.Context, query *models.SearchUserTokensQuery) (*models.SearchUserTokensResult, error) {
+	return s.SearchUserTokensProvider(ctx, query)
+}
+
+func (s *FakeUserAuthTokenService) RevokeUserTokens(ctx context.Context, cmd *models.RevokeUserTokensCommand) (int64, error) {
+	return s.RevokeUserTokensProvider(ctx, cmd)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/audit"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 	"github.com/grafana/grafana/pkg/services/ngalert/image"
@@ -23,7 +24,8 @@ import (
 
 func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockService,
 	shortURLService shorturls.Service, sqlstore *sqlstore.SQLStore, queryHistoryService queryhistory.Service,
-	dashboardVersionService dashver.Service, dashSnapSvc dashboardsnapshots.Service, deleteExpiredImageService *image.DeleteExpiredService) *CleanUpService {
+	dashboardVersionService dashver.Service, dashSnapSvc dashboardsnapshots.Service, deleteExpiredImageService *image.DeleteExpiredService,
+	auditService audit.Service) *CleanUpService {
 	s := &CleanUpService{
 		Cfg:                       cfg,
 		ServerLockService:         serverLockService,
@@ -34,6 +36,7 @@ func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockSe
 		dashboardVersionService:   dashboardVersionService,
 		dashboardSnapshotService:  dashSnapSvc,
 		deleteExpiredImageService: deleteExpiredImageService,
+		auditService:              auditService,
 	}
 	return s
 }
@@ -48,6 +51,7 @@ type CleanUpService struct {
 	dashboardVersionService   dashver.Service
 	dashboardSnapshotService  dashboardsnapshots.Service
 	deleteExpiredImageService *image.DeleteExpiredService
+	auditService              audit.Service
 }
 
 func (srv *CleanUpService) Run(ctx context.Context) error {
@@ -68,6 +72,7 @@ func (srv *CleanUpService) Run(ctx context.Context) error {
 			srv.expireOldUserInvites(ctx)
 			srv.deleteStaleShortURLs(ctx)
 			srv.deleteStaleQueryHistory(ctx)
+			srv.deleteExpiredAuditEvents(ctx)
 			err := srv.ServerLockService.LockAndExecute(ctx, "delete old login attempts",
 				time.Minute*10, func(context.Context) {
 					srv.deleteOldLoginAttempts(ctx)
@@ -175,14 +180,33 @@ func (srv *CleanUpService) deleteOldLoginAttempts(ctx context.Context) {
 		return
 	}
 
+	// keep the attempts as long as they can count towards a lockout
+	window := time.Minute * 10
+	if srv.Cfg.BruteForce.UserWindow > window {
+		window = srv.Cfg.BruteForce.UserWindow
+	}
+	if srv.Cfg.BruteForce.IPWindow > window {
+		window = srv.Cfg.BruteForce.IPWindow
+	}
+
 	cmd := models.DeleteOldLoginAttemptsCommand{
-		OlderThan: time.Now().Add(time.Minute * -10),
+		OlderThan: time.Now().Add(-window),
 	}
 	if err := srv.store.DeleteOldLoginAttempts(ctx, &cmd); err != nil {
 		srv.log.Error("Problem deleting expired login attempts", "error", err.Error())
 	} else {
 		srv.log.Debug("Deleted expired login attempts", "rows affected", cmd.DeletedRows)
 	}
+
+	// expired lockouts are kept for the maximum lockout duration to grow the lockout of repeat offenders
+	lockoutCmd := models.DeleteExpiredLoginLockoutsCommand{
+		OlderThan: time.Now().Add(-srv.Cfg.BruteForce.LockoutMaxDuration),
+	}
+	if err := srv.store.DeleteExpiredLoginLockouts(ctx, &lockoutCmd); err != nil {
+		srv.log.Error("Problem deleting expired login lockouts", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted expired login lockouts", "rows affected", lockoutCmd.DeletedRows)
+	}
 }
 
 func (srv *CleanUpService) expireOldUserInvites(ctx context.Context) {
@@ -209,6 +233,15 @@ func (srv *CleanUpService) deleteStaleShortURLs(ctx context.Context) {
 	}
 }
 
+func (srv *CleanUpService) deleteExpiredAuditEvents(ctx context.Context) {
+	rowsCount, err := srv.auditService.DeleteExpired(ctx)
+	if err != nil {
+		srv.log.Error("Problem deleting expired audit events", "error", err.Error())
+	} else {
+		srv.log.Debug("Deleted expired audit events", "rows affected", rowsCount)
+	}
+}
+
 func (srv *CleanUpService) deleteStaleQueryHistory(ctx context.Context) {
 	// Delete query history from 14+ days ago with exception of starred queries
 	maxQueryHistoryLifetime := time.Hour * 24 * 14
@@ -1,8 +1,14 @@
 package contexthandler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/login"
 	"github.com/grafana/grafana/pkg/models"
@@ -12,6 +18,11 @@ import (
 const InvalidJWT = "Invalid JWT"
 const UserNotFound = "User not found"
 
+// jwtTeamSyncExpiry is how long the groups of a JWT user are remembered, teams
+// are synced again when the groups change or after the expiry, to pick up
+// changes of the team group mappings.
+const jwtTeamSyncExpiry = 5 * time.Minute
+
 func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64) bool {
 	if !h.Cfg.JWTAuthEnabled || h.Cfg.JWTAuthHeaderName == "" {
 		return false
@@ -60,6 +71,10 @@ func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64)
 		extUser.Name = name
 	}
 
+	if key := h.Cfg.JWTAuthGroupsClaim; key != "" {
+		extUser.Groups = jwtGroups(claims[key])
+	}
+
 	if query.Login == "" && query.Email == "" {
 		ctx.Logger.Debug("Failed to get an authentication claim from JWT")
 		ctx.JsonApiErr(http.StatusUnauthorized, InvalidJWT, err)
@@ -70,6 +85,7 @@ func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64)
 		upsert := &models.UpsertUserCommand{
 			ReqContext:    ctx,
 			SignupAllowed: h.Cfg.JWTAuthAutoSignUp,
+			SkipTeamSync:  true,
 			ExternalUser:  extUser,
 			UserLookupParams: models.UserLookupParams{
 				UserID: nil,
@@ -99,8 +115,53 @@ func (h *ContextHandler) initContextWithJWT(ctx *models.ReqContext, orgId int64)
 		return true
 	}
 
+	if h.Cfg.JWTAuthGroupsClaim != "" {
+		if err := h.syncJWTTeams(ctx, query.Result, extUser); err != nil {
+			ctx.Logger.Error("Failed to sync teams of JWT user", "error", err)
+		}
+	}
+
 	ctx.SignedInUser = query.Result
 	ctx.IsSignedIn = true
 
 	return true
 }
+
+// syncJWTTeams syncs the teams of the user with the groups of the token, unless
+// they already were for the same groups.
+func (h *ContextHandler) syncJWTTeams(ctx *models.ReqContext, signedInUser *user.SignedInUser, extUser *models.ExternalUserInfo) error {
+	groups := append([]string{}, extUser.Groups...)
+	sort.Strings(groups)
+	sum := sha256.Sum256([]byte(strings.Join(groups, "\n")))
+	groupsHash := hex.EncodeToString(sum[:])
+
+	key := fmt.Sprintf("jwt-team-sync-%d", signedInUser.UserId)
+	if cached, err := h.RemoteCache.Get(ctx.Req.Context(), key); err == nil && cached == groupsHash {
+		return nil
+	}
+
+	usr := &user.User{ID: signedInUser.UserId, Login: signedInUser.Login}
+	if err := h.loginService.SyncTeams(ctx.Req.Context(), usr, extUser); err != nil {
+		return err
+	}
+	return h.RemoteCache.Set(ctx.Req.Context(), key, groupsHash, jwtTeamSyncExpiry)
+}
+
+// jwtGroups reads a groups claim holding either a list of groups or a single group.
+func jwtGroups(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		if value != "" {
+			return []string{value}
+		}
+	case []interface{}:
+		groups := make([]string, 0, len(value))
+		for _, v := range value {
+			if group, ok := v.(string); ok && group != "" {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	}
+	return nil
+}
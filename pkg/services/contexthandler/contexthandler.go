@@ -26,6 +26,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/twofactor"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -36,6 +37,8 @@ const (
 	InvalidUsernamePassword = "invalid username or password"
 	/* #nosec */
 	InvalidAPIKey = "invalid API key"
+	// TwoFactorBasicAuth is returned when a user with two-factor authentication uses basic auth.
+	TwoFactorBasicAuth = "basic auth is not allowed for users with two-factor authentication, use a service account token instead"
 )
 
 const ServiceName = "ContextHandler"
@@ -44,6 +47,7 @@ func ProvideService(cfg *setting.Cfg, tokenService models.UserTokenService, jwtS
 	remoteCache *remotecache.RemoteCache, renderService rendering.Service, sqlStore sqlstore.Store,
 	tracer tracing.Tracer, authProxy *authproxy.AuthProxy, loginService login.Service,
 	apiKeyService apikey.Service, authenticator loginpkg.Authenticator, userService user.Service,
+	twoFactorService twofactor.Service,
 ) *ContextHandler {
 	return &ContextHandler{
 		Cfg:              cfg,
@@ -58,6 +62,7 @@ func ProvideService(cfg *setting.Cfg, tokenService models.UserTokenService, jwtS
 		loginService:     loginService,
 		apiKeyService:    apiKeyService,
 		userService:      userService,
+		twoFactorService: twoFactorService,
 	}
 }
 
@@ -75,6 +80,7 @@ type ContextHandler struct {
 	loginService     login.Service
 	apiKeyService    apikey.Service
 	userService      user.Service
+	twoFactorService twofactor.Service
 	// GetTime returns the current time.
 	// Stubbable by tests.
 	GetTime func() time.Time
@@ -344,9 +350,10 @@ func (h *ContextHandler) initContextWithBasicAuth(reqContext *models.ReqContext,
 	}
 
 	authQuery := models.LoginUserQuery{
-		Username: username,
-		Password: password,
-		Cfg:      h.Cfg,
+		Username:  username,
+		Password:  password,
+		IpAddress: reqContext.Req.RemoteAddr,
+		Cfg:       h.Cfg,
 	}
 	if err := h.authenticator.AuthenticateUser(reqContext.Req.Context(), &authQuery); err != nil {
 		reqContext.Logger.Debug(
@@ -364,6 +371,21 @@ func (h *ContextHandler) initContextWithBasicAuth(reqContext *models.ReqContext,
 
 	usr := authQuery.User
 
+	// The second factor can't be checked with basic auth, which would otherwise bypass it.
+	// Like on the login form, it only applies to users authenticated by the Grafana database.
+	if authQuery.AuthModule == "grafana" && h.twoFactorService != nil && h.twoFactorService.IsEnabled() {
+		status, err := h.twoFactorService.GetStatus(ctx, usr.ID)
+		if err != nil {
+			reqContext.Logger.Error("Failed to get two-factor authentication status", "id", usr.ID, "err", err)
+			reqContext.JsonApiErr(http.StatusInternalServerError, "Failed to authenticate user", err)
+			return true
+		}
+		if status.Enabled || status.Required {
+			reqContext.JsonApiErr(http.StatusUnauthorized, TwoFactorBasicAuth, nil)
+			return true
+		}
+	}
+
 	query := models.GetSignedInUserQuery{UserId: usr.ID, OrgId: orgID}
 	if err := h.SQLStore.GetSignedInUserWithCacheCtx(ctx, &query); err != nil {
 		reqContext.Logger.Error(
@@ -406,6 +428,17 @@ func (h *ContextHandler) initContextWithToken(reqContext *models.ReqContext, org
 		return false
 	}
 
+	if h.orgSessionExpired(query.Result.OrgId, token) {
+		reqContext.Logger.Debug("Session expired in organization", "userId", token.UserId, "orgId", query.Result.OrgId)
+		// the session is revoked rather than only rejected in this organization, so users
+		// cannot switch to organizations with longer session lifetimes
+		if err := h.AuthTokenService.RevokeToken(ctx, token, false); err != nil && !errors.Is(err, models.ErrUserTokenNotFound) {
+			reqContext.Logger.Error("Failed to revoke expired session", "userId", token.UserId, "error", err)
+		}
+		reqContext.LookupTokenErr = &models.TokenExpiredError{UserID: token.UserId, TokenID: token.Id}
+		return false
+	}
+
 	reqContext.SignedInUser = query.Result
 	reqContext.IsSignedIn = true
 	reqContext.UserToken = token
@@ -417,6 +450,22 @@ func (h *ContextHandler) initContextWithToken(reqContext *models.ReqContext, org
 	return true
 }
 
+// orgSessionExpired returns whether the session has expired according to the session
+// lifetimes of the organization the user acts in.
+func (h *ContextHandler) orgSessionExpired(orgID int64, token *models.UserToken) bool {
+	lifetime, ok := h.Cfg.Session.OrgLifetimes[orgID]
+	if !ok {
+		return false
+	}
+	getTime := h.GetTime
+	if getTime == nil {
+		getTime = time.Now
+	}
+	now := getTime()
+	return token.CreatedAt <= now.Add(-lifetime.MaxLifetime).Unix() ||
+		token.RotatedAt <= now.Add(-lifetime.MaxInactiveLifetime).Unix()
+}
+
 func (h *ContextHandler) rotateEndOfRequestFunc(reqContext *models.ReqContext, authTokenService models.UserTokenService,
 	token *models.UserToken) web.BeforeFunc {
 	return func(w web.ResponseWriter) {
@@ -0,0 +1,49 @@
+package ldapsync
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := accesscontrol.Middleware(s.accessControl)
+	s.routeRegister.Get("/api/admin/ldap-sync-status",
+		auth(middleware.ReqGrafanaAdmin, accesscontrol.EvalPermission(accesscontrol.ActionLDAPStatusRead)),
+		routing.Wrap(s.getSyncStatus))
+}
+
+// swagger:route GET /admin/ldap-sync-status admin_ldap getLDAPSyncStatus
+//
+// Returns the summary of the last active LDAP synchronization.
+//
+// If you have Fine-grained access control enabled, you need to have a permission with action `ldap.status:read`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: getLDAPSyncStatusResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+func (s *Service) getSyncStatus(c *models.ReqContext) response.Response {
+	summary, err := s.LastSummary(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get the LDAP synchronization status", err)
+	}
+	if summary == nil {
+		return response.Error(http.StatusNotFound, "LDAP synchronization has not run yet", nil)
+	}
+	return response.JSON(http.StatusOK, summary)
+}
+
+// swagger:response getLDAPSyncStatusResponse
+type GetLDAPSyncStatusResponse struct {
+	// in: body
+	Body SyncSummary `json:"body"`
+}
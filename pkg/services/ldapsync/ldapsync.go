@@ -0,0 +1,357 @@
+package ldapsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/multildap"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// minSyncInterval is the shortest allowed time between two scheduled syncs.
+	minSyncInterval = 10 * time.Minute
+	// usersBatchSize is the number of users looked up in a single LDAP search.
+	usersBatchSize = 100
+	// lastSummaryKey stores the summary of the last sync, shared by all instances.
+	lastSummaryKey = "last-summary"
+)
+
+var ErrLDAPNotConfigured = errors.New("LDAP is not configured")
+
+// getLDAPConfig and newLDAPServer are package variables so tests can replace them.
+var (
+	getLDAPConfig = multildap.GetConfig
+	newLDAPServer = ldap.New
+)
+
+// SyncSummary reports the outcome of a single LDAP synchronization.
+type SyncSummary struct {
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Users is the number of Grafana users authenticated with LDAP.
+	Users int `json:"users"`
+	// Updated is the number of users whose information, roles and teams were synced.
+	Updated int `json:"updated"`
+	// Disabled is the number of users disabled because they were removed from LDAP
+	// or no longer match any of the configured group mappings.
+	Disabled int `json:"disabled"`
+	// Failed is the number of users that could not be synced.
+	Failed int    `json:"failed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Service actively syncs the users that logged in with LDAP at least once,
+// instead of waiting for their next login.
+type Service struct {
+	cfg              *setting.Cfg
+	log              log.Logger
+	serverLock       *serverlock.ServerLockService
+	sqlStore         *sqlstore.SQLStore
+	loginService     login.Service
+	authTokenService models.UserTokenService
+	routeRegister    routing.RouteRegister
+	accessControl    accesscontrol.AccessControl
+	kv               *kvstore.NamespacedKVStore
+	schedule         cron.Schedule
+}
+
+func ProvideService(cfg *setting.Cfg, serverLock *serverlock.ServerLockService, sqlStore *sqlstore.SQLStore,
+	loginService login.Service, authTokenService models.UserTokenService, routeRegister routing.RouteRegister,
+	accessControl accesscontrol.AccessControl, kv kvstore.KVStore) *Service {
+	s := &Service{
+		cfg:              cfg,
+		log:              log.New("ldap.sync"),
+		serverLock:       serverLock,
+		sqlStore:         sqlStore,
+		loginService:     loginService,
+		authTokenService: authTokenService,
+		routeRegister:    routeRegister,
+		accessControl:    accessControl,
+		kv:               kvstore.WithNamespace(kv, 0, "ldap.sync"),
+	}
+
+	if !cfg.LDAPEnabled || !cfg.LDAPActiveSyncEnabled {
+		return s
+	}
+
+	schedule, err := parseSchedule(cfg.LDAPSyncCron)
+	if err != nil {
+		s.log.Error("Active LDAP synchronization is disabled", "error", err)
+		return s
+	}
+	s.schedule = schedule
+	s.registerAPIEndpoints()
+	return s
+}
+
+// parseSchedule parses the 5 fields cron expression or predefined schedule of
+// the sync_cron option and rejects schedules running more often than minSyncInterval.
+func parseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync_cron %q: %w", spec, err)
+	}
+
+	next := schedule.Next(time.Now())
+	for i := 0; i < 10; i++ {
+		following := schedule.Next(next)
+		if following.Sub(next) < minSyncInterval {
+			return nil, fmt.Errorf("invalid sync_cron %q: runs must be at least %s apart", spec, minSyncInterval)
+		}
+		next = following
+	}
+	return schedule, nil
+}
+
+func (s *Service) IsDisabled() bool {
+	return s.schedule == nil
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		timer := time.NewTimer(time.Until(s.schedule.Next(time.Now())))
+		select {
+		case <-timer.C:
+			err := s.serverLock.LockAndExecute(ctx, "ldap sync", minSyncInterval/2, func(ctx context.Context) {
+				_, _ = s.Sync(ctx)
+			})
+			if err != nil {
+				s.log.Error("Failed to lock and execute LDAP synchronization", "error", err)
+			}
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// LastSummary returns the summary of the last sync run by any instance, or nil.
+func (s *Service) LastSummary(ctx context.Context) (*SyncSummary, error) {
+	value, ok, err := s.kv.Get(ctx, lastSummaryKey)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var summary SyncSummary
+	if err := json.Unmarshal([]byte(value), &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func (s *Service) saveSummary(ctx context.Context, summary *SyncSummary) {
+	value, err := json.Marshal(summary)
+	if err == nil {
+		err = s.kv.Set(ctx, lastSummaryKey, string(value))
+	}
+	if err != nil {
+		s.log.Error("Failed to save the LDAP synchronization summary", "error", err)
+	}
+}
+
+// Sync updates the information, org roles and teams of every LDAP user found
+// in LDAP, and disables and logs out the users that are not.
+func (s *Service) Sync(ctx context.Context) (*SyncSummary, error) {
+	summary := &SyncSummary{Started: time.Now()}
+	err := s.sync(ctx, summary)
+	summary.Finished = time.Now()
+	if err != nil {
+		summary.Error = err.Error()
+		s.log.Error("LDAP synchronization failed", "error", err, "users", summary.Users,
+			"updated", summary.Updated, "disabled", summary.Disabled, "failed", summary.Failed)
+	} else {
+		s.log.Info("LDAP synchronization finished", "users", summary.Users, "updated", summary.Updated,
+			"disabled", summary.Disabled, "failed", summary.Failed, "duration", summary.Finished.Sub(summary.Started))
+	}
+
+	s.saveSummary(ctx, summary)
+
+	return summary, err
+}
+
+func (s *Service) sync(ctx context.Context, summary *SyncSummary) error {
+	ldapConfig, err := getLDAPConfig(s.cfg)
+	if err != nil {
+		return err
+	}
+	if ldapConfig == nil {
+		return ErrLDAPNotConfigured
+	}
+
+	users, err := s.ldapUsers(ctx)
+	if err != nil {
+		return err
+	}
+	summary.Users = len(users)
+
+	// Abort instead of disabling users when one of the servers cannot be searched,
+	// users of an unreachable server would otherwise look removed from LDAP.
+	servers, err := dialServers(ldapConfig.Servers)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}()
+
+	found, err := searchUsers(servers, users)
+	if err != nil {
+		return err
+	}
+
+	disabled := 0
+	for _, u := range users {
+		if s.shouldDisable(u, found[strings.ToLower(u.Login)]) {
+			disabled++
+		}
+	}
+	if limit := s.cfg.LDAPSyncMaxDisabledUsers; limit > 0 && disabled > limit {
+		return fmt.Errorf("refusing to disable %d users, which is more than sync_max_disabled_users (%d)", disabled, limit)
+	}
+
+	for _, u := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.syncUser(ctx, u, found[strings.ToLower(u.Login)], summary)
+	}
+	return nil
+}
+
+// dialServers connects and binds to every configured LDAP server, and fails if any of them is unavailable.
+func dialServers(configs []*ldap.ServerConfig) ([]ldap.IServer, error) {
+	if len(configs) == 0 {
+		return nil, multildap.ErrNoLDAPServers
+	}
+
+	servers := make([]ldap.IServer, 0, len(configs))
+	for _, config := range configs {
+		server := newLDAPServer(config)
+		err := server.Dial()
+		if err == nil {
+			servers = append(servers, server)
+			err = server.Bind()
+		}
+		if err != nil {
+			for _, server := range servers {
+				server.Close()
+			}
+			return nil, fmt.Errorf("failed to connect to LDAP server %s: %w", config.Host, err)
+		}
+	}
+	return servers, nil
+}
+
+// searchUsers looks up the users on every server, by lowercase login.
+func searchUsers(servers []ldap.IServer, users []*ldapUser) (map[string]*models.ExternalUserInfo, error) {
+	found := make(map[string]*models.ExternalUserInfo, len(users))
+	for start := 0; start < len(users); start += usersBatchSize {
+		end := start + usersBatchSize
+		if end > len(users) {
+			end = len(users)
+		}
+
+		logins := make([]string, 0, end-start)
+		for _, u := range users[start:end] {
+			logins = append(logins, u.Login)
+		}
+
+		for _, server := range servers {
+			extUsers, err := server.Users(logins)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search LDAP users: %w", err)
+			}
+			for _, extUser := range extUsers {
+				key := strings.ToLower(extUser.Login)
+				// the first configured server takes precedence, as it does on login
+				if _, ok := found[key]; !ok {
+					found[key] = extUser
+				}
+			}
+		}
+	}
+	return found, nil
+}
+
+// shouldDisable returns true when the user is enabled and was removed or disabled in LDAP.
+func (s *Service) shouldDisable(u *ldapUser, extUser *models.ExternalUserInfo) bool {
+	return (extUser == nil || extUser.IsDisabled) && !u.IsDisabled && u.Login != s.cfg.AdminUser
+}
+
+func (s *Service) syncUser(ctx context.Context, u *ldapUser, extUser *models.ExternalUserInfo, summary *SyncSummary) {
+	if extUser == nil || extUser.IsDisabled {
+		if u.IsDisabled {
+			return
+		}
+		if u.Login == s.cfg.AdminUser {
+			s.log.Warn("Refusing to disable grafana super admin removed from LDAP", "user", u.Login)
+			return
+		}
+
+		if err := s.loginService.DisableExternalUser(ctx, u.Login); err != nil {
+			s.log.Error("Failed to disable user removed from LDAP", "user", u.Login, "error", err)
+			summary.Failed++
+			return
+		}
+		if err := s.authTokenService.RevokeAllUserTokens(ctx, u.ID); err != nil {
+			s.log.Error("Failed to revoke sessions of user removed from LDAP", "user", u.Login, "error", err)
+			summary.Failed++
+			return
+		}
+		s.log.Debug("Disabled user removed from LDAP", "user", u.Login)
+		summary.Disabled++
+		return
+	}
+
+	cmd := &models.UpsertUserCommand{
+		ExternalUser:  extUser,
+		SignupAllowed: s.cfg.LDAPAllowSignup,
+		UserLookupParams: models.UserLookupParams{
+			UserID: &u.ID,
+		},
+	}
+	if err := s.loginService.UpsertUser(ctx, cmd); err != nil {
+		s.log.Error("Failed to sync LDAP user", "user", u.Login, "error", err)
+		summary.Failed++
+		return
+	}
+	summary.Updated++
+}
+
+type ldapUser struct {
+	ID         int64  `xorm:"id"`
+	Login      string `xorm:"login"`
+	IsDisabled bool   `xorm:"is_disabled"`
+}
+
+// ldapUsers returns the users that logged in with LDAP at least once.
+func (s *Service) ldapUsers(ctx context.Context) ([]*ldapUser, error) {
+	users := make([]*ldapUser, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("user").Alias("u").
+			Distinct("u.id", "u.login", "u.is_disabled").
+			Join("INNER", "user_auth", "user_auth.user_id = u.id").
+			Where("user_auth.auth_module = ?", login.LDAPAuthModule).
+			Asc("u.id").
+			Find(&users)
+	})
+	return users, err
+}
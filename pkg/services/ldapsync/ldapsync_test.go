@@ -0,0 +1,285 @@
+package ldapsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/ldap"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeLDAP struct {
+	ldap.IServer
+	users       []*models.ExternalUserInfo
+	dialErr     error
+	err         error
+	searchCalls int
+	closed      bool
+}
+
+func (f *fakeLDAP) Dial() error {
+	return f.dialErr
+}
+
+func (f *fakeLDAP) Bind() error {
+	return nil
+}
+
+func (f *fakeLDAP) Close() {
+	f.closed = true
+}
+
+func (f *fakeLDAP) Users(logins []string) ([]*models.ExternalUserInfo, error) {
+	f.searchCalls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	var result []*models.ExternalUserInfo
+	for _, u := range f.users {
+		for _, l := range logins {
+			if u.Login == l {
+				result = append(result, u)
+			}
+		}
+	}
+	return result, nil
+}
+
+type fakeLoginService struct {
+	login.Service
+	upserted []*models.UpsertUserCommand
+	disabled []string
+}
+
+func (f *fakeLoginService) UpsertUser(ctx context.Context, cmd *models.UpsertUserCommand) error {
+	f.upserted = append(f.upserted, cmd)
+	return nil
+}
+
+func (f *fakeLoginService) DisableExternalUser(ctx context.Context, username string) error {
+	f.disabled = append(f.disabled, username)
+	return nil
+}
+
+func setupTestService(t *testing.T, ldapServers ...*fakeLDAP) (*Service, *sqlstore.SQLStore, *fakeLoginService, *[]int64) {
+	t.Helper()
+
+	origGetConfig, origNewLDAPServer := getLDAPConfig, newLDAPServer
+	t.Cleanup(func() {
+		getLDAPConfig, newLDAPServer = origGetConfig, origNewLDAPServer
+	})
+	configs := make([]*ldap.ServerConfig, 0, len(ldapServers))
+	servers := make(map[string]*fakeLDAP, len(ldapServers))
+	for i, server := range ldapServers {
+		host := fmt.Sprintf("ldap%d.example.com", i)
+		configs = append(configs, &ldap.ServerConfig{Host: host})
+		servers[host] = server
+	}
+	getLDAPConfig = func(*setting.Cfg) (*ldap.Config, error) {
+		return &ldap.Config{Servers: configs}, nil
+	}
+	newLDAPServer = func(config *ldap.ServerConfig) ldap.IServer {
+		return servers[config.Host]
+	}
+
+	sqlStore := sqlstore.InitTestDB(t)
+	loginService := &fakeLoginService{}
+	revoked := []int64{}
+	tokenService := auth.NewFakeUserAuthTokenService()
+	tokenService.RevokeAllUserTokensProvider = func(ctx context.Context, userID int64) error {
+		revoked = append(revoked, userID)
+		return nil
+	}
+
+	cfg := setting.NewCfg()
+	cfg.AdminUser = "admin"
+	cfg.LDAPAllowSignup = true
+	cfg.LDAPSyncMaxDisabledUsers = 100
+
+	s := &Service{
+		cfg:              cfg,
+		log:              log.New("ldap.sync"),
+		sqlStore:         sqlStore,
+		loginService:     loginService,
+		authTokenService: tokenService,
+		kv:               kvstore.WithNamespace(kvstore.ProvideService(sqlStore), 0, "ldap.sync"),
+	}
+	return s, sqlStore, loginService, &revoked
+}
+
+func createUser(t *testing.T, sqlStore *sqlstore.SQLStore, login string, authModule string, disabled bool) *user.User {
+	t.Helper()
+
+	usr, err := sqlStore.CreateUser(context.Background(), user.CreateUserCommand{
+		Login:      login,
+		Email:      login + "@example.com",
+		IsDisabled: disabled,
+	})
+	require.NoError(t, err)
+
+	if authModule != "" {
+		err = sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			_, err := sess.Insert(&models.UserAuth{
+				UserId:     usr.ID,
+				AuthModule: authModule,
+				AuthId:     login,
+				Created:    time.Now(),
+			})
+			return err
+		})
+		require.NoError(t, err)
+	}
+	return usr
+}
+
+func TestService_Sync(t *testing.T) {
+	t.Run("updates users found in LDAP and disables the others", func(t *testing.T) {
+		server := &fakeLDAP{users: []*models.ExternalUserInfo{
+			{Login: "alice", Email: "alice@example.com", OrgRoles: map[int64]org.RoleType{1: org.RoleEditor}},
+			{Login: "bob", Email: "bob@example.com", IsDisabled: true},
+		}}
+		s, sqlStore, loginService, revoked := setupTestService(t, server)
+
+		alice := createUser(t, sqlStore, "alice", login.LDAPAuthModule, false)
+		bob := createUser(t, sqlStore, "bob", login.LDAPAuthModule, false)
+		carol := createUser(t, sqlStore, "carol", login.LDAPAuthModule, false)
+		createUser(t, sqlStore, "dave", login.LDAPAuthModule, true)
+		createUser(t, sqlStore, "admin", login.LDAPAuthModule, false)
+		createUser(t, sqlStore, "erin", "oauth_generic_oauth", false)
+
+		summary, err := s.Sync(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 5, summary.Users)
+		assert.Equal(t, 1, summary.Updated)
+		assert.Equal(t, 2, summary.Disabled)
+		assert.Equal(t, 0, summary.Failed)
+		assert.Empty(t, summary.Error)
+		assert.True(t, server.closed)
+
+		// the summary is shared with the other instances
+		last, err := s.LastSummary(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, summary.Updated, last.Updated)
+		assert.Equal(t, summary.Disabled, last.Disabled)
+		assert.True(t, summary.Finished.Equal(last.Finished))
+
+		require.Len(t, loginService.upserted, 1)
+		cmd := loginService.upserted[0]
+		assert.Equal(t, alice.ID, *cmd.UserLookupParams.UserID)
+		assert.Nil(t, cmd.UserLookupParams.Login)
+		assert.Equal(t, org.RoleEditor, cmd.ExternalUser.OrgRoles[1])
+
+		assert.Equal(t, []string{"bob", "carol"}, loginService.disabled)
+		assert.Equal(t, []int64{bob.ID, carol.ID}, *revoked)
+	})
+
+	t.Run("searches LDAP in batches", func(t *testing.T) {
+		server := &fakeLDAP{}
+		s, sqlStore, loginService, _ := setupTestService(t, server)
+		for i := 0; i < usersBatchSize+1; i++ {
+			createUser(t, sqlStore, fmt.Sprintf("user%d", i), login.LDAPAuthModule, true)
+		}
+
+		summary, err := s.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, usersBatchSize+1, summary.Users)
+		assert.Equal(t, 2, server.searchCalls)
+		assert.Empty(t, loginService.disabled)
+	})
+
+	t.Run("users found on any server are not disabled", func(t *testing.T) {
+		first := &fakeLDAP{users: []*models.ExternalUserInfo{{Login: "alice", Name: "first"}}}
+		second := &fakeLDAP{users: []*models.ExternalUserInfo{{Login: "alice", Name: "second"}, {Login: "bob"}}}
+		s, sqlStore, loginService, _ := setupTestService(t, first, second)
+		createUser(t, sqlStore, "alice", login.LDAPAuthModule, false)
+		createUser(t, sqlStore, "bob", login.LDAPAuthModule, false)
+
+		summary, err := s.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, summary.Updated)
+		assert.Empty(t, loginService.disabled)
+		// the first configured server takes precedence
+		assert.Equal(t, "first", loginService.upserted[0].ExternalUser.Name)
+	})
+
+	t.Run("does not disable users when one of the servers is unreachable", func(t *testing.T) {
+		first := &fakeLDAP{dialErr: errors.New("connection refused")}
+		second := &fakeLDAP{users: []*models.ExternalUserInfo{{Login: "bob"}}}
+		s, sqlStore, loginService, revoked := setupTestService(t, &fakeLDAP{}, first, second)
+		createUser(t, sqlStore, "alice", login.LDAPAuthModule, false)
+		createUser(t, sqlStore, "bob", login.LDAPAuthModule, false)
+
+		summary, err := s.Sync(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, summary.Error, "ldap1.example.com")
+		assert.Empty(t, loginService.upserted)
+		assert.Empty(t, loginService.disabled)
+		assert.Empty(t, *revoked)
+		assert.Equal(t, 0, second.searchCalls)
+	})
+
+	t.Run("does not disable more users than the limit", func(t *testing.T) {
+		server := &fakeLDAP{users: []*models.ExternalUserInfo{{Login: "alice"}}}
+		s, sqlStore, loginService, revoked := setupTestService(t, server)
+		s.cfg.LDAPSyncMaxDisabledUsers = 1
+		createUser(t, sqlStore, "alice", login.LDAPAuthModule, false)
+		createUser(t, sqlStore, "bob", login.LDAPAuthModule, false)
+		createUser(t, sqlStore, "carol", login.LDAPAuthModule, false)
+
+		summary, err := s.Sync(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, summary.Error, "refusing to disable 2 users")
+		assert.Empty(t, loginService.upserted)
+		assert.Empty(t, loginService.disabled)
+		assert.Empty(t, *revoked)
+
+		s.cfg.LDAPSyncMaxDisabledUsers = 2
+		_, err = s.Sync(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob", "carol"}, loginService.disabled)
+	})
+
+	t.Run("does not disable users when LDAP cannot be searched", func(t *testing.T) {
+		server := &fakeLDAP{err: errors.New("connection refused")}
+		s, sqlStore, loginService, revoked := setupTestService(t, server)
+		createUser(t, sqlStore, "alice", login.LDAPAuthModule, false)
+
+		summary, err := s.Sync(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, summary.Error, "connection refused")
+		assert.Empty(t, loginService.disabled)
+		assert.Empty(t, *revoked)
+	})
+}
+
+func TestParseSchedule(t *testing.T) {
+	_, err := parseSchedule("0 1 * * *")
+	require.NoError(t, err)
+
+	_, err = parseSchedule("@hourly")
+	require.NoError(t, err)
+
+	_, err = parseSchedule("*/10 * * * *")
+	require.NoError(t, err)
+
+	_, err = parseSchedule("*/9 * * * *")
+	require.Error(t, err)
+
+	_, err = parseSchedule("not a cron")
+	require.Error(t, err)
+}
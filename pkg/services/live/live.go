@@ -62,6 +62,14 @@ var (
 	loggerCF = log.New("live.centrifuge")
 )
 
+// pipelineChangedNotificationOp is sent to all nodes when pipeline
+// configuration of an organization changes.
+const pipelineChangedNotificationOp = "pipeline_changed"
+
+type pipelineChangedNotification struct {
+	OrgID int64 `json:"orgId"`
+}
+
 // CoreGrafanaScope list of core features
 type CoreGrafanaScope struct {
 	Features map[string]models.ChannelHandlerFactory
@@ -192,9 +200,16 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 				ChannelHandlerGetter: g,
 			}
 		} else {
-			storage := &pipeline.FileStorage{
-				DataPath:       cfg.DataPath,
+			storage := &pipeline.SQLStorage{
+				SQLStore:       sqlStore,
 				SecretsService: g.SecretsService,
+				OnChange: func(orgID int64) {
+					// Notify all nodes (including this one) to reload cached rules.
+					data, _ := json.Marshal(pipelineChangedNotification{OrgID: orgID})
+					if err := node.Notify(pipelineChangedNotificationOp, data, ""); err != nil {
+						logger.Error("Error notifying about pipeline change", "error", err, "orgId", orgID)
+					}
+				},
 			}
 			g.pipelineStorage = storage
 			builder = &pipeline.StorageRuleBuilder{
@@ -207,6 +222,31 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 			}
 		}
 		channelRuleGetter := pipeline.NewCacheSegmentedTree(builder)
+		node.OnNotification(func(e centrifuge.NotificationEvent) {
+			if e.Op != pipelineChangedNotificationOp {
+				return
+			}
+			var n pipelineChangedNotification
+			if err := json.Unmarshal(e.Data, &n); err != nil {
+				logger.Error("Error decoding pipeline change notification", "error", err)
+				return
+			}
+			if err := channelRuleGetter.Reload(n.OrgID); err != nil {
+				logger.Error("Error reloading channel rules", "error", err, "orgId", n.OrgID)
+			}
+		})
+
+		// Import after the notification handler is registered, so that the
+		// rules reload on change. A bad rules file must not prevent startup.
+		if storage, ok := g.pipelineStorage.(*pipeline.SQLStorage); ok {
+			err := storage.ImportFileStorage(context.Background(), &pipeline.FileStorage{
+				DataPath:       cfg.DataPath,
+				SecretsService: g.SecretsService,
+			})
+			if err != nil {
+				logger.Error("Error importing pipeline configuration files", "error", err)
+			}
+		}
 
 		// Pre-build/validate channel rules for all organizations on start.
 		// This can be unreasonable to have in production scenario with many
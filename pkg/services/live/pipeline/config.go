@@ -49,11 +49,36 @@ type KeepFieldsFrameProcessorConfig struct {
 	FieldNames []string `json:"fieldNames"`
 }
 
+// WindowAggregation describes how to reduce a field over a window.
+type WindowAggregation struct {
+	FieldName string `json:"fieldName"`
+	// Function is one of mean, min, max, last, count.
+	Function string `json:"function"`
+	// As is a name of resulting field, <fieldName>_<function> by default.
+	As string `json:"as,omitempty"`
+}
+
+type WindowAggregateFrameProcessorConfig struct {
+	WindowMilliseconds int64               `json:"windowMilliseconds"`
+	Aggregations       []WindowAggregation `json:"aggregations"`
+	// GroupBy contains names of labels (or string fields) to aggregate
+	// each label value combination separately.
+	GroupBy []string `json:"groupBy,omitempty"`
+}
+
+type RateLimitFrameProcessorConfig struct {
+	// MaxFrames is a maximum number of frames passed per interval, other frames are dropped.
+	MaxFrames            int   `json:"maxFrames"`
+	IntervalMilliseconds int64 `json:"intervalMilliseconds"`
+}
+
 type FrameProcessorConfig struct {
-	Type                      string                          `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
-	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
-	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
-	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
+	Type                           string                               `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
+	DropFieldsProcessorConfig      *DropFieldsFrameProcessorConfig      `json:"dropFields,omitempty"`
+	KeepFieldsProcessorConfig      *KeepFieldsFrameProcessorConfig      `json:"keepFields,omitempty"`
+	MultipleProcessorConfig        *MultipleFrameProcessorConfig        `json:"multiple,omitempty"`
+	WindowAggregateProcessorConfig *WindowAggregateFrameProcessorConfig `json:"windowAggregate,omitempty"`
+	RateLimitProcessorConfig       *RateLimitFrameProcessorConfig       `json:"rateLimit,omitempty"`
 }
 
 type MultipleFrameProcessorConfig struct {
@@ -78,6 +103,52 @@ type LokiOutputConfig struct {
 	UID string `json:"uid"`
 }
 
+// KafkaOutputConfig publishes frames to a Kafka topic. Messages are keyed by channel.
+type KafkaOutputConfig struct {
+	Brokers  []string      `json:"brokers"`
+	Topic    string        `json:"topic"`
+	Encoding FrameEncoding `json:"encoding,omitempty"`
+}
+
+// NATSOutputConfig publishes frames to a NATS subject.
+type NATSOutputConfig struct {
+	URL      string        `json:"url"`
+	Subject  string        `json:"subject"`
+	Encoding FrameEncoding `json:"encoding,omitempty"`
+}
+
+// MQTTOutputConfig publishes frames to an MQTT topic.
+type MQTTOutputConfig struct {
+	Broker   string        `json:"broker"`
+	Topic    string        `json:"topic"`
+	QoS      byte          `json:"qos,omitempty"`
+	Encoding FrameEncoding `json:"encoding,omitempty"`
+}
+
+// KafkaSubscriberConfig consumes a Kafka topic while a channel has subscribers.
+// Messages are converted to frames with Converter.
+type KafkaSubscriberConfig struct {
+	Brokers   []string         `json:"brokers"`
+	Topic     string           `json:"topic"`
+	GroupID   string           `json:"groupId,omitempty"`
+	Converter *ConverterConfig `json:"converter"`
+}
+
+// NATSSubscriberConfig consumes a NATS subject while a channel has subscribers.
+type NATSSubscriberConfig struct {
+	URL       string           `json:"url"`
+	Subject   string           `json:"subject"`
+	Converter *ConverterConfig `json:"converter"`
+}
+
+// MQTTSubscriberConfig consumes an MQTT topic while a channel has subscribers.
+type MQTTSubscriberConfig struct {
+	Broker    string           `json:"broker"`
+	Topic     string           `json:"topic"`
+	QoS       byte             `json:"qos,omitempty"`
+	Converter *ConverterConfig `json:"converter"`
+}
+
 type MultipleSubscriberConfig struct {
 	Subscribers []SubscriberConfig `json:"subscribers"`
 }
@@ -85,6 +156,9 @@ type MultipleSubscriberConfig struct {
 type SubscriberConfig struct {
 	Type                     string                    `json:"type" ts_type:"Omit<keyof SubscriberConfig, 'type'>"`
 	MultipleSubscriberConfig *MultipleSubscriberConfig `json:"multiple,omitempty"`
+	KafkaSubscriberConfig    *KafkaSubscriberConfig    `json:"kafka,omitempty"`
+	NATSSubscriberConfig     *NATSSubscriberConfig     `json:"nats,omitempty"`
+	MQTTSubscriberConfig     *MQTTSubscriberConfig     `json:"mqtt,omitempty"`
 }
 
 // RedirectDataOutputConfig ...
@@ -108,6 +182,9 @@ type FrameOutputterConfig struct {
 	RemoteWriteOutputConfig *RemoteWriteOutputConfig   `json:"remoteWrite,omitempty"`
 	LokiOutputConfig        *LokiOutputConfig          `json:"loki,omitempty"`
 	ChangeLogOutputConfig   *ChangeLogOutputConfig     `json:"changeLog,omitempty"`
+	KafkaOutputConfig       *KafkaOutputConfig         `json:"kafka,omitempty"`
+	NATSOutputConfig        *NATSOutputConfig          `json:"nats,omitempty"`
+	MQTTOutputConfig        *MQTTOutputConfig          `json:"mqtt,omitempty"`
 }
 
 type MultipleFrameConditionCheckerConfig struct {
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/segmentio/kafka-go"
+)
+
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// KafkaFrameOutput publishes frames to a Kafka topic. Messages are keyed by
+// channel so frames of one channel keep their order inside a partition.
+type KafkaFrameOutput struct {
+	clientKey string
+	writer    kafkaWriter
+	encoding  FrameEncoding
+}
+
+const FrameOutputTypeKafka = "kafka"
+
+func NewKafkaFrameOutput(config KafkaOutputConfig) (*KafkaFrameOutput, error) {
+	key := "kafka-writer/" + strings.Join(config.Brokers, ",") + "/" + config.Topic
+	client, err := messageBusClients.acquire(key, func() (io.Closer, error) {
+		return &kafka.Writer{
+			Addr:     kafka.TCP(config.Brokers...),
+			Topic:    config.Topic,
+			Balancer: &kafka.Hash{},
+			Async:    true,
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					logger.Error("Error writing to Kafka", "error", err, "topic", config.Topic, "numMessages", len(messages))
+				}
+			},
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &KafkaFrameOutput{
+		clientKey: key,
+		writer:    client.(*kafka.Writer),
+		encoding:  config.Encoding,
+	}, nil
+}
+
+func (out *KafkaFrameOutput) Type() string {
+	return FrameOutputTypeKafka
+}
+
+// Close releases the shared Kafka writer.
+func (out *KafkaFrameOutput) Close() error {
+	messageBusClients.release(out.clientKey)
+	return nil
+}
+
+func (out *KafkaFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	payload, err := encodeFrame(frame, out.encoding)
+	if err != nil {
+		return nil, err
+	}
+	return nil, out.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(vars.Channel),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte(out.encoding.contentType())},
+		},
+	})
+}
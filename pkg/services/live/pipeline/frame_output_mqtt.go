@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const mqttTimeout = 5 * time.Second
+
+var errMQTTTimeout = errors.New("mqtt operation timed out")
+
+// MQTTFrameOutput publishes frames to an MQTT topic.
+type MQTTFrameOutput struct {
+	clientKey string
+	client    mqtt.Client
+	topic     string
+	qos       byte
+	encoding  FrameEncoding
+}
+
+const FrameOutputTypeMQTT = "mqtt"
+
+// mqttClient adapts mqtt.Client to io.Closer to keep it in messageBusClients.
+type mqttClient struct {
+	mqtt.Client
+}
+
+func (c mqttClient) Close() error {
+	c.Client.Disconnect(250)
+	return nil
+}
+
+func waitMQTTToken(token mqtt.Token) error {
+	if !token.WaitTimeout(mqttTimeout) {
+		return errMQTTTimeout
+	}
+	return token.Error()
+}
+
+func newMQTTClient(broker string) mqtt.Client {
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID("grafana-live-" + util.GenerateShortUID()).
+		SetConnectTimeout(mqttTimeout).
+		SetAutoReconnect(true).
+		SetConnectRetry(true)
+	client := mqtt.NewClient(opts)
+	// Do not wait for connection so an unavailable broker does not fail
+	// rule building, the client keeps retrying in background.
+	client.Connect()
+	return client
+}
+
+func NewMQTTFrameOutput(config MQTTOutputConfig) (*MQTTFrameOutput, error) {
+	key := "mqtt/" + config.Broker
+	client, err := messageBusClients.acquire(key, func() (io.Closer, error) {
+		return mqttClient{newMQTTClient(config.Broker)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MQTTFrameOutput{
+		clientKey: key,
+		client:    client.(mqttClient).Client,
+		topic:     config.Topic,
+		qos:       config.QoS,
+		encoding:  config.Encoding,
+	}, nil
+}
+
+func (out *MQTTFrameOutput) Type() string {
+	return FrameOutputTypeMQTT
+}
+
+// Close releases the shared MQTT client.
+func (out *MQTTFrameOutput) Close() error {
+	messageBusClients.release(out.clientKey)
+	return nil
+}
+
+func (out *MQTTFrameOutput) OutputFrame(_ context.Context, _ Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	payload, err := encodeFrame(frame, out.encoding)
+	if err != nil {
+		return nil, err
+	}
+	token := out.client.Publish(out.topic, out.qos, false, payload)
+	if out.qos == 0 {
+		// Fire and forget, nothing to wait for.
+		return nil, nil
+	}
+	return nil, waitMQTTToken(token)
+}
@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/nats-io/nats.go"
+)
+
+type natsPublisher interface {
+	PublishMsg(msg *nats.Msg) error
+}
+
+// NATSFrameOutput publishes frames to a NATS subject.
+type NATSFrameOutput struct {
+	clientKey string
+	conn      natsPublisher
+	subject   string
+	encoding  FrameEncoding
+}
+
+const FrameOutputTypeNATS = "nats"
+
+// natsClient adapts nats.Conn to io.Closer to keep it in messageBusClients.
+type natsClient struct {
+	*nats.Conn
+}
+
+func (c natsClient) Close() error {
+	c.Conn.Close()
+	return nil
+}
+
+func natsClientKey(url string) string {
+	return "nats/" + url
+}
+
+// acquireNATSConn returns a shared connection, which must be released with
+// messageBusClients.release(natsClientKey(url)).
+func acquireNATSConn(url string) (*nats.Conn, error) {
+	client, err := messageBusClients.acquire(natsClientKey(url), func() (io.Closer, error) {
+		// Do not fail rule building while the server is unavailable, keep reconnecting instead.
+		conn, err := nats.Connect(url, nats.Name("grafana-live"), nats.MaxReconnects(-1), nats.RetryOnFailedConnect(true))
+		if err != nil {
+			return nil, err
+		}
+		return natsClient{conn}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(natsClient).Conn, nil
+}
+
+func NewNATSFrameOutput(config NATSOutputConfig) (*NATSFrameOutput, error) {
+	conn, err := acquireNATSConn(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	return &NATSFrameOutput{
+		clientKey: natsClientKey(config.URL),
+		conn:      conn,
+		subject:   config.Subject,
+		encoding:  config.Encoding,
+	}, nil
+}
+
+func (out *NATSFrameOutput) Type() string {
+	return FrameOutputTypeNATS
+}
+
+// Close releases the shared NATS connection.
+func (out *NATSFrameOutput) Close() error {
+	messageBusClients.release(out.clientKey)
+	return nil
+}
+
+func (out *NATSFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	payload, err := encodeFrame(frame, out.encoding)
+	if err != nil {
+		return nil, err
+	}
+	msg := nats.NewMsg(out.subject)
+	msg.Data = payload
+	msg.Header.Set("Content-Type", out.encoding.contentType())
+	msg.Header.Set("Grafana-Live-Channel", vars.Channel)
+	return nil, out.conn.PublishMsg(msg)
+}
@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// RateLimitFrameProcessor passes at most MaxFrames frames of a channel per
+// interval, other frames are dropped.
+type RateLimitFrameProcessor struct {
+	config   RateLimitFrameProcessorConfig
+	interval time.Duration
+}
+
+const FrameProcessorTypeRateLimit = "rateLimit"
+
+func NewRateLimitFrameProcessor(config RateLimitFrameProcessorConfig) (*RateLimitFrameProcessor, error) {
+	if config.MaxFrames <= 0 || config.IntervalMilliseconds <= 0 {
+		return nil, errors.New("maxFrames and intervalMilliseconds must be positive")
+	}
+	return &RateLimitFrameProcessor{
+		config:   config,
+		interval: time.Duration(config.IntervalMilliseconds) * time.Millisecond,
+	}, nil
+}
+
+func (p *RateLimitFrameProcessor) Type() string {
+	return FrameProcessorTypeRateLimit
+}
+
+func (p *RateLimitFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	key := fmt.Sprintf("%d/%s/%d/%d", vars.OrgID, vars.Channel, p.config.MaxFrames, p.config.IntervalMilliseconds)
+	if !rateLimits.allow(key, p.config.MaxFrames, p.interval, time.Now()) {
+		return nil, nil
+	}
+	return frame, nil
+}
+
+// rateLimits keeps limiter state outside of processors since channel rules
+// (and so processors) are periodically rebuilt.
+var rateLimits = newRateLimitRegistry()
+
+// rateLimitSweepInterval defines how often the states of expired windows are
+// dropped, channels with high cardinality would grow the registry otherwise.
+const rateLimitSweepInterval = time.Minute
+
+type rateLimitRegistry struct {
+	mu        sync.Mutex
+	limits    map[string]*rateLimitState
+	lastSweep time.Time
+}
+
+type rateLimitState struct {
+	windowStart time.Time
+	interval    time.Duration
+	count       int
+}
+
+func newRateLimitRegistry() *rateLimitRegistry {
+	return &rateLimitRegistry{limits: map[string]*rateLimitState{}}
+}
+
+func (r *rateLimitRegistry) allow(key string, maxFrames int, interval time.Duration, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Sub(r.lastSweep) >= rateLimitSweepInterval {
+		r.sweep(now)
+	}
+	state, ok := r.limits[key]
+	if !ok {
+		state = &rateLimitState{interval: interval}
+		r.limits[key] = state
+	}
+	if now.Sub(state.windowStart) >= interval {
+		state.windowStart = now
+		state.count = 0
+	}
+	if state.count >= maxFrames {
+		return false
+	}
+	state.count++
+	return true
+}
+
+// sweep drops the states of expired windows, which are the same as missing ones.
+func (r *rateLimitRegistry) sweep(now time.Time) {
+	r.lastSweep = now
+	for key, state := range r.limits {
+		if now.Sub(state.windowStart) >= state.interval {
+			delete(r.limits, key)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitRegistry_Allow(t *testing.T) {
+	r := newRateLimitRegistry()
+	now := time.Now()
+
+	require.True(t, r.allow("a", 2, time.Second, now))
+	require.True(t, r.allow("a", 2, time.Second, now))
+	require.False(t, r.allow("a", 2, time.Second, now.Add(500*time.Millisecond)))
+	require.True(t, r.allow("b", 2, time.Second, now.Add(500*time.Millisecond)))
+
+	// the window is reset after the interval
+	require.True(t, r.allow("a", 2, time.Second, now.Add(time.Second)))
+}
+
+func TestRateLimitRegistry_Sweep(t *testing.T) {
+	r := newRateLimitRegistry()
+	now := time.Now()
+
+	require.True(t, r.allow("short", 1, time.Second, now))
+	require.True(t, r.allow("long", 1, time.Hour, now))
+	require.Len(t, r.limits, 2)
+
+	// expired windows are dropped, active ones are kept
+	require.True(t, r.allow("other", 1, time.Second, now.Add(rateLimitSweepInterval)))
+	require.Len(t, r.limits, 2)
+	require.Contains(t, r.limits, "long")
+	require.Contains(t, r.limits, "other")
+	require.False(t, r.allow("long", 1, time.Hour, now.Add(rateLimitSweepInterval)))
+}
@@ -0,0 +1,285 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	WindowFunctionMean  = "mean"
+	WindowFunctionMin   = "min"
+	WindowFunctionMax   = "max"
+	WindowFunctionLast  = "last"
+	WindowFunctionCount = "count"
+)
+
+// WindowAggregateFrameProcessor downsamples frames of a channel. Incoming frames
+// are accumulated during a window and dropped, when the window ends frames with
+// aggregated values (one frame per group) are emitted to the rest of the rule.
+type WindowAggregateFrameProcessor struct {
+	config    WindowAggregateFrameProcessorConfig
+	configKey string
+}
+
+const FrameProcessorTypeWindowAggregate = "windowAggregate"
+
+func NewWindowAggregateFrameProcessor(config WindowAggregateFrameProcessorConfig) (*WindowAggregateFrameProcessor, error) {
+	if config.WindowMilliseconds <= 0 {
+		return nil, errors.New("windowMilliseconds must be positive")
+	}
+	if len(config.Aggregations) == 0 {
+		return nil, errors.New("no aggregations configured")
+	}
+	for _, a := range config.Aggregations {
+		switch a.Function {
+		case WindowFunctionMean, WindowFunctionMin, WindowFunctionMax, WindowFunctionLast, WindowFunctionCount:
+		default:
+			return nil, fmt.Errorf("unknown aggregation function: %s", a.Function)
+		}
+	}
+	configKey, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return &WindowAggregateFrameProcessor{config: config, configKey: string(configKey)}, nil
+}
+
+func (p *WindowAggregateFrameProcessor) Type() string {
+	return FrameProcessorTypeWindowAggregate
+}
+
+func (p *WindowAggregateFrameProcessor) ProcessFrame(ctx context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	emitter, ok := FrameEmitterFromContext(ctx)
+	if !ok {
+		return nil, errors.New("window aggregation requires frame emitter")
+	}
+	key := fmt.Sprintf("%d/%s/%s", vars.OrgID, vars.Channel, p.configKey)
+	for {
+		w := windows.getOrCreate(key, p.config)
+		if w.add(frame, emitter) {
+			break
+		}
+		// Window was flushed concurrently, a new one will be created.
+	}
+	return nil, nil
+}
+
+// windows keeps window state outside of processors since channel rules
+// (and so processors) are periodically rebuilt.
+var windows = &windowRegistry{windows: map[string]*window{}}
+
+type windowRegistry struct {
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+func (r *windowRegistry) getOrCreate(key string, config WindowAggregateFrameProcessorConfig) *window {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, ok := r.windows[key]
+	if !ok {
+		w = &window{
+			config: config,
+			groups: map[string]*windowGroup{},
+		}
+		time.AfterFunc(time.Duration(config.WindowMilliseconds)*time.Millisecond, func() {
+			r.remove(key)
+			w.flush()
+		})
+		r.windows[key] = w
+	}
+	return w
+}
+
+func (r *windowRegistry) remove(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.windows, key)
+}
+
+type window struct {
+	mu        sync.Mutex
+	config    WindowAggregateFrameProcessorConfig
+	flushed   bool
+	emitter   FrameEmitter
+	frameName string
+	groups    map[string]*windowGroup
+	// groupOrder keeps groups in order of appearance to emit frames in a stable order.
+	groupOrder []string
+	// groupColumns contains group names found as frame string fields. Other
+	// group values come from field labels.
+	groupColumns map[string]struct{}
+}
+
+type windowGroup struct {
+	values       []string
+	accumulators []windowAccumulator
+}
+
+type windowAccumulator struct {
+	sum   float64
+	min   float64
+	max   float64
+	last  float64
+	count int64
+}
+
+func (a *windowAccumulator) add(v float64) {
+	if a.count == 0 || v < a.min {
+		a.min = v
+	}
+	if a.count == 0 || v > a.max {
+		a.max = v
+	}
+	a.sum += v
+	a.last = v
+	a.count++
+}
+
+func (a *windowAccumulator) value(function string) *float64 {
+	var v float64
+	switch function {
+	case WindowFunctionCount:
+		v = float64(a.count)
+		return &v
+	}
+	if a.count == 0 {
+		return nil
+	}
+	switch function {
+	case WindowFunctionMean:
+		v = a.sum / float64(a.count)
+	case WindowFunctionMin:
+		v = a.min
+	case WindowFunctionMax:
+		v = a.max
+	case WindowFunctionLast:
+		v = a.last
+	}
+	return &v
+}
+
+// add accumulates frame values, returns false if window was already flushed.
+func (w *window) add(frame *data.Frame, emitter FrameEmitter) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.flushed {
+		return false
+	}
+	w.emitter = emitter
+	w.frameName = frame.Name
+
+	columns := map[string]*data.Field{}
+	for _, name := range w.config.GroupBy {
+		for _, f := range frame.Fields {
+			if f.Name == name && (f.Type() == data.FieldTypeString || f.Type() == data.FieldTypeNullableString) {
+				columns[name] = f
+				if w.groupColumns == nil {
+					w.groupColumns = map[string]struct{}{}
+				}
+				w.groupColumns[name] = struct{}{}
+				break
+			}
+		}
+	}
+
+	for i, aggregation := range w.config.Aggregations {
+		for _, field := range frame.Fields {
+			if field.Name != aggregation.FieldName {
+				continue
+			}
+			for row := 0; row < field.Len(); row++ {
+				v, err := field.FloatAt(row)
+				if err != nil {
+					// Not a numeric field.
+					break
+				}
+				if math.IsNaN(v) {
+					continue
+				}
+				group := w.getGroup(columns, field.Labels, row)
+				group.accumulators[i].add(v)
+			}
+		}
+	}
+	return true
+}
+
+func (w *window) getGroup(columns map[string]*data.Field, labels data.Labels, row int) *windowGroup {
+	values := make([]string, len(w.config.GroupBy))
+	for i, name := range w.config.GroupBy {
+		if column, ok := columns[name]; ok {
+			if v, ok := column.ConcreteAt(row); ok {
+				values[i], _ = v.(string)
+			}
+			continue
+		}
+		values[i] = labels[name]
+	}
+	key := strings.Join(values, "\x00")
+	group, ok := w.groups[key]
+	if !ok {
+		group = &windowGroup{
+			values:       values,
+			accumulators: make([]windowAccumulator, len(w.config.Aggregations)),
+		}
+		w.groups[key] = group
+		w.groupOrder = append(w.groupOrder, key)
+	}
+	return group
+}
+
+func (w *window) flush() {
+	w.mu.Lock()
+	w.flushed = true
+	frames := w.frames(time.Now())
+	emitter := w.emitter
+	w.mu.Unlock()
+
+	for _, frame := range frames {
+		if err := emitter(context.Background(), frame); err != nil {
+			logger.Error("Error emitting aggregated frame", "error", err)
+		}
+	}
+}
+
+func (w *window) frames(windowEnd time.Time) []*data.Frame {
+	frames := make([]*data.Frame, 0, len(w.groupOrder))
+	for _, key := range w.groupOrder {
+		group := w.groups[key]
+		fields := []*data.Field{data.NewField("time", nil, []time.Time{windowEnd})}
+
+		var labels data.Labels
+		for i, name := range w.config.GroupBy {
+			if _, ok := w.groupColumns[name]; ok {
+				fields = append(fields, data.NewField(name, nil, []string{group.values[i]}))
+				continue
+			}
+			if group.values[i] == "" {
+				continue
+			}
+			if labels == nil {
+				labels = data.Labels{}
+			}
+			labels[name] = group.values[i]
+		}
+
+		for i, aggregation := range w.config.Aggregations {
+			name := aggregation.As
+			if name == "" {
+				name = aggregation.FieldName + "_" + aggregation.Function
+			}
+			fields = append(fields, data.NewField(name, labels, []*float64{group.accumulators[i].value(aggregation.Function)}))
+		}
+		frames = append(frames, data.NewFrame(w.frameName, fields...))
+	}
+	return frames
+}
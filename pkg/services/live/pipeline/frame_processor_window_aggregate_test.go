@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowAggregateFrameProcessor(t *testing.T) {
+	p, err := NewWindowAggregateFrameProcessor(WindowAggregateFrameProcessorConfig{
+		WindowMilliseconds: 50,
+		Aggregations: []WindowAggregation{
+			{FieldName: "value", Function: WindowFunctionMean},
+			{FieldName: "value", Function: WindowFunctionMin},
+			{FieldName: "value", Function: WindowFunctionMax},
+			{FieldName: "value", Function: WindowFunctionLast},
+			{FieldName: "value", Function: WindowFunctionCount, As: "points"},
+		},
+		GroupBy: []string{"host"},
+	})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var emitted []*data.Frame
+	done := make(chan struct{})
+	ctx := withFrameEmitter(context.Background(), func(_ context.Context, frame *data.Frame) error {
+		mu.Lock()
+		defer mu.Unlock()
+		emitted = append(emitted, frame)
+		if len(emitted) == 2 {
+			close(done)
+		}
+		return nil
+	})
+
+	vars := Vars{OrgID: 1, Channel: "stream/test/window"}
+	frames := []*data.Frame{
+		data.NewFrame("cpu",
+			data.NewField("time", nil, []time.Time{time.Now(), time.Now()}),
+			data.NewField("host", nil, []string{"a", "b"}),
+			data.NewField("value", nil, []float64{1, 10}),
+		),
+		data.NewFrame("cpu",
+			data.NewField("time", nil, []time.Time{time.Now()}),
+			data.NewField("host", nil, []string{"a"}),
+			data.NewField("value", nil, []float64{3}),
+		),
+	}
+	for _, frame := range frames {
+		result, err := p.ProcessFrame(ctx, vars, frame)
+		require.NoError(t, err)
+		require.Nil(t, result)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("aggregated frames were not emitted")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, emitted, 2)
+
+	a := emitted[0]
+	require.Equal(t, "cpu", a.Name)
+	require.Equal(t, "host", a.Fields[1].Name)
+	require.Equal(t, "a", a.Fields[1].At(0))
+	values := map[string]float64{}
+	for _, f := range a.Fields[2:] {
+		v, ok := f.ConcreteAt(0)
+		require.True(t, ok)
+		values[f.Name] = v.(float64)
+	}
+	require.Equal(t, map[string]float64{
+		"value_mean": 2,
+		"value_min":  1,
+		"value_max":  3,
+		"value_last": 3,
+		"points":     2,
+	}, values)
+
+	b := emitted[1]
+	require.Equal(t, "b", b.Fields[1].At(0))
+	v, ok := b.Fields[2].ConcreteAt(0)
+	require.True(t, ok)
+	require.Equal(t, 10.0, v)
+}
+
+func TestWindowAggregateFrameProcessor_GroupByLabels(t *testing.T) {
+	p, err := NewWindowAggregateFrameProcessor(WindowAggregateFrameProcessorConfig{
+		WindowMilliseconds: 50,
+		Aggregations:       []WindowAggregation{{FieldName: "value", Function: WindowFunctionMax}},
+		GroupBy:            []string{"host"},
+	})
+	require.NoError(t, err)
+
+	emitted := make(chan *data.Frame, 2)
+	ctx := withFrameEmitter(context.Background(), func(_ context.Context, frame *data.Frame) error {
+		emitted <- frame
+		return nil
+	})
+
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("value", data.Labels{"host": "a"}, []float64{1}),
+		data.NewField("value", data.Labels{"host": "b"}, []float64{2}),
+	)
+	_, err = p.ProcessFrame(ctx, Vars{OrgID: 1, Channel: "stream/test/labels"}, frame)
+	require.NoError(t, err)
+
+	for _, host := range []string{"a", "b"} {
+		select {
+		case f := <-emitted:
+			require.Len(t, f.Fields, 2)
+			require.Equal(t, data.Labels{"host": host}, f.Fields[1].Labels)
+		case <-time.After(5 * time.Second):
+			t.Fatal("aggregated frame was not emitted")
+		}
+	}
+}
+
+func TestWindowAggregateFrameProcessor_InvalidConfig(t *testing.T) {
+	_, err := NewWindowAggregateFrameProcessor(WindowAggregateFrameProcessorConfig{
+		WindowMilliseconds: 1000,
+		Aggregations:       []WindowAggregation{{FieldName: "value", Function: "median"}},
+	})
+	require.Error(t, err)
+
+	_, err = NewWindowAggregateFrameProcessor(WindowAggregateFrameProcessorConfig{
+		Aggregations: []WindowAggregation{{FieldName: "value", Function: WindowFunctionMean}},
+	})
+	require.Error(t, err)
+}
+
+func TestRateLimitFrameProcessor(t *testing.T) {
+	p, err := NewRateLimitFrameProcessor(RateLimitFrameProcessorConfig{MaxFrames: 2, IntervalMilliseconds: 60000})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	vars := Vars{OrgID: 1, Channel: "stream/test/rate_limit"}
+
+	for i := 0; i < 2; i++ {
+		result, err := p.ProcessFrame(context.Background(), vars, frame)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+	}
+	result, err := p.ProcessFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	// Other channels have separate limits.
+	result, err = p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/other"}, frame)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	require.True(t, rateLimits.allow("window", 1, time.Second, time.Unix(0, 0)))
+	require.False(t, rateLimits.allow("window", 1, time.Second, time.Unix(0, 0).Add(500*time.Millisecond)))
+	require.True(t, rateLimits.allow("window", 1, time.Second, time.Unix(1, 0)))
+}
@@ -0,0 +1,252 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// FrameEncoding defines how frames are serialized when published to a message bus.
+type FrameEncoding string
+
+const (
+	FrameEncodingJSON  FrameEncoding = "json"
+	FrameEncodingArrow FrameEncoding = "arrow"
+)
+
+func (e FrameEncoding) contentType() string {
+	if e == FrameEncodingArrow {
+		return "application/vnd.apache.arrow.file"
+	}
+	return "application/json"
+}
+
+func (e FrameEncoding) valid() bool {
+	return e == "" || e == FrameEncodingJSON || e == FrameEncodingArrow
+}
+
+func encodeFrame(frame *data.Frame, encoding FrameEncoding) ([]byte, error) {
+	switch encoding {
+	case "", FrameEncodingJSON:
+		return data.FrameToJSON(frame, data.IncludeAll)
+	case FrameEncodingArrow:
+		return frame.MarshalArrow()
+	default:
+		return nil, fmt.Errorf("unknown frame encoding: %s", encoding)
+	}
+}
+
+// messageBusClients keeps connections to message buses. Rules are rebuilt
+// periodically, so outputs and subscribers share connections instead of
+// establishing new ones on every rebuild. Connections are reference counted
+// and closed once no output or consumer uses them anymore.
+var messageBusClients = &clientCache{clients: map[string]*sharedClient{}}
+
+type sharedClient struct {
+	client io.Closer
+	refs   int
+}
+
+type clientCache struct {
+	mu      sync.Mutex
+	clients map[string]*sharedClient
+}
+
+// acquire returns the client for the key, creating it when it does not exist.
+// Every acquire must be followed by a release.
+func (c *clientCache) acquire(key string, create func() (io.Closer, error)) (io.Closer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if shared, ok := c.clients[key]; ok {
+		shared.refs++
+		return shared.client, nil
+	}
+	client, err := create()
+	if err != nil {
+		return nil, err
+	}
+	c.clients[key] = &sharedClient{client: client, refs: 1}
+	return client, nil
+}
+
+// release drops a reference to the client and closes it when it was the last one.
+func (c *clientCache) release(key string) {
+	c.mu.Lock()
+	shared, ok := c.clients[key]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	shared.refs--
+	if shared.refs > 0 {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.clients, key)
+	c.mu.Unlock()
+
+	if err := shared.client.Close(); err != nil {
+		logger.Error("Error closing message bus client", "error", err, "key", key)
+	}
+}
+
+// messageConsumer reads messages from a message bus. Consumers implementing
+// io.Closer are closed once they stop consuming.
+type messageConsumer interface {
+	// Consume blocks until ctx is done calling handle for every received message.
+	Consume(ctx context.Context, handle func(payload []byte)) error
+}
+
+// subscriberCheckInterval defines how often consumers check whether a channel
+// still has subscribers on this node.
+var subscriberCheckInterval = 10 * time.Second
+
+var runningConsumers = &consumerRegistry{consumers: map[string]struct{}{}}
+
+type consumerRegistry struct {
+	mu        sync.Mutex
+	consumers map[string]struct{}
+}
+
+func (r *consumerRegistry) add(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.consumers[key]; ok {
+		return false
+	}
+	r.consumers[key] = struct{}{}
+	return true
+}
+
+func (r *consumerRegistry) remove(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.consumers, key)
+}
+
+// messageBusSubscriber is a managed stream subscriber which additionally runs
+// a message bus consumer while the channel has subscribers on this node.
+// Messages are converted to frames and pushed into the managed stream.
+type messageBusSubscriber struct {
+	subscriberType string
+	// source uniquely identifies the consumed topic, used to run a single
+	// consumer per channel.
+	source        string
+	node          *centrifuge.Node
+	managedStream *managedstream.Runner
+	converter     Converter
+	newConsumer   func() (messageConsumer, error)
+}
+
+func (s *messageBusSubscriber) Type() string {
+	return s.subscriberType
+}
+
+func (s *messageBusSubscriber) Subscribe(ctx context.Context, vars Vars, _ []byte) (models.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	u, ok := livecontext.GetContextSignedUser(ctx)
+	if !ok {
+		return models.SubscribeReply{}, backend.SubscribeStreamStatusPermissionDenied, nil
+	}
+	stream, err := s.managedStream.GetOrCreateStream(vars.OrgID, vars.Scope, vars.Namespace)
+	if err != nil {
+		logger.Error("Error getting managed stream", "error", err)
+		return models.SubscribeReply{}, 0, err
+	}
+	if err := s.startConsumer(vars); err != nil {
+		logger.Error("Error starting message bus consumer", "type", s.subscriberType, "error", err, "channel", vars.Channel)
+		return models.SubscribeReply{}, 0, err
+	}
+	return stream.OnSubscribe(ctx, u, models.SubscribeEvent{
+		Channel: vars.Channel,
+		Path:    vars.Path,
+	})
+}
+
+func (s *messageBusSubscriber) startConsumer(vars Vars) error {
+	key := fmt.Sprintf("%s/%s/%d/%s", s.subscriberType, s.source, vars.OrgID, vars.Channel)
+	if !runningConsumers.add(key) {
+		return nil
+	}
+	consumer, err := s.newConsumer()
+	if err != nil {
+		runningConsumers.remove(key)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer runningConsumers.remove(key)
+		defer cancel()
+		if closer, ok := consumer.(io.Closer); ok {
+			defer func() { _ = closer.Close() }()
+		}
+		err := consumer.Consume(ctx, func(payload []byte) {
+			if err := s.handleMessage(ctx, vars, payload); err != nil {
+				logger.Error("Error handling message bus message", "type", s.subscriberType, "error", err, "channel", vars.Channel)
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Message bus consumer stopped", "type", s.subscriberType, "error", err, "channel", vars.Channel)
+		}
+	}()
+	go s.stopWithoutSubscribers(ctx, cancel, vars)
+	return nil
+}
+
+func (s *messageBusSubscriber) stopWithoutSubscribers(ctx context.Context, cancel func(), vars Vars) {
+	ticker := time.NewTicker(subscriberCheckInterval)
+	defer ticker.Stop()
+	channel := orgchannel.PrependOrgID(vars.OrgID, vars.Channel)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.node.Hub().NumSubscribers(channel) == 0 {
+				logger.Debug("Stop message bus consumer, no subscribers", "type", s.subscriberType, "channel", vars.Channel)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *messageBusSubscriber) handleMessage(ctx context.Context, vars Vars, payload []byte) error {
+	channelFrames, err := s.converter.Convert(ctx, vars, payload)
+	if err != nil {
+		return fmt.Errorf("error converting message: %w", err)
+	}
+	for _, cf := range channelFrames {
+		frameVars := vars
+		if cf.Channel != "" {
+			ch, err := live.ParseChannel(cf.Channel)
+			if err != nil {
+				return err
+			}
+			frameVars.Channel = cf.Channel
+			frameVars.Scope = ch.Scope
+			frameVars.Namespace = ch.Namespace
+			frameVars.Path = ch.Path
+		}
+		stream, err := s.managedStream.GetOrCreateStream(frameVars.OrgID, frameVars.Scope, frameVars.Namespace)
+		if err != nil {
+			return err
+		}
+		if err := stream.Push(ctx, frameVars.Path, cf.Frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
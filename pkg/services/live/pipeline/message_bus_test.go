@@ -0,0 +1,362 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+)
+
+func TestEncodeFrame(t *testing.T) {
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+		data.NewField("value", nil, []float64{1.5}),
+	)
+
+	t.Run("json", func(t *testing.T) {
+		for _, encoding := range []FrameEncoding{"", FrameEncodingJSON} {
+			payload, err := encodeFrame(frame, encoding)
+			require.NoError(t, err)
+			var decoded data.Frame
+			require.NoError(t, json.Unmarshal(payload, &decoded))
+			require.Equal(t, "test", decoded.Name)
+			require.Equal(t, 1.5, decoded.Fields[1].At(0))
+		}
+	})
+
+	t.Run("arrow", func(t *testing.T) {
+		payload, err := encodeFrame(frame, FrameEncodingArrow)
+		require.NoError(t, err)
+		decoded, err := data.UnmarshalArrowFrame(payload)
+		require.NoError(t, err)
+		require.Equal(t, "test", decoded.Name)
+		require.Equal(t, 1.5, decoded.Fields[1].At(0))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := encodeFrame(frame, "xml")
+		require.Error(t, err)
+		require.False(t, FrameEncoding("xml").valid())
+	})
+}
+
+type testPublication struct {
+	orgID   int64
+	channel string
+	data    []byte
+}
+
+func TestMessageBusSubscriber_HandleMessage(t *testing.T) {
+	var publications []testPublication
+	runner := managedstream.NewRunner(func(orgID int64, channel string, data []byte) error {
+		publications = append(publications, testPublication{orgID: orgID, channel: channel, data: data})
+		return nil
+	}, nil, managedstream.NewMemoryFrameCache())
+
+	s := &messageBusSubscriber{
+		subscriberType: SubscriberTypeNATS,
+		managedStream:  runner,
+		converter:      NewAutoJsonConverter(AutoJsonConverterConfig{}),
+	}
+	vars := Vars{OrgID: 1, Channel: "stream/nats/cpu", Scope: "stream", Namespace: "nats", Path: "cpu"}
+
+	err := s.handleMessage(context.Background(), vars, []byte(`{"value": 1}`))
+	require.NoError(t, err)
+	require.Len(t, publications, 1)
+	require.Equal(t, int64(1), publications[0].orgID)
+	require.Equal(t, "stream/nats/cpu", publications[0].channel)
+
+	err = s.handleMessage(context.Background(), vars, []byte(`not json`))
+	require.Error(t, err)
+	require.Len(t, publications, 1)
+}
+
+type testCloser struct {
+	closed int
+}
+
+func (c *testCloser) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestClientCache(t *testing.T) {
+	cache := &clientCache{clients: map[string]*sharedClient{}}
+	created := 0
+	create := func() (io.Closer, error) {
+		created++
+		return &testCloser{}, nil
+	}
+
+	first, err := cache.acquire("key", create)
+	require.NoError(t, err)
+	second, err := cache.acquire("key", create)
+	require.NoError(t, err)
+	require.Same(t, first, second)
+	require.Equal(t, 1, created)
+
+	cache.release("key")
+	require.Equal(t, 0, first.(*testCloser).closed)
+
+	cache.release("key")
+	require.Equal(t, 1, first.(*testCloser).closed)
+	require.Empty(t, cache.clients)
+
+	// released clients are created again
+	third, err := cache.acquire("key", create)
+	require.NoError(t, err)
+	require.NotSame(t, first, third)
+	require.Equal(t, 2, created)
+
+	_, err = cache.acquire("other", func() (io.Closer, error) {
+		return nil, errors.New("boom")
+	})
+	require.Error(t, err)
+	require.NotContains(t, cache.clients, "other")
+}
+
+type testConsumer struct {
+	payload  []byte
+	consumed chan struct{}
+	closed   chan struct{}
+}
+
+func (c *testConsumer) Consume(_ context.Context, handle func(payload []byte)) error {
+	handle(c.payload)
+	close(c.consumed)
+	return nil
+}
+
+func (c *testConsumer) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestMessageBusSubscriber_StartConsumer(t *testing.T) {
+	var mu sync.Mutex
+	var publications []testPublication
+	runner := managedstream.NewRunner(func(orgID int64, channel string, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		publications = append(publications, testPublication{orgID: orgID, channel: channel, data: data})
+		return nil
+	}, nil, managedstream.NewMemoryFrameCache())
+
+	consumer := &testConsumer{payload: []byte(`{"value": 1}`), consumed: make(chan struct{}), closed: make(chan struct{})}
+	s := &messageBusSubscriber{
+		subscriberType: SubscriberTypeNATS,
+		source:         "nats://localhost:4222/test",
+		managedStream:  runner,
+		converter:      NewAutoJsonConverter(AutoJsonConverterConfig{}),
+		newConsumer: func() (messageConsumer, error) {
+			return consumer, nil
+		},
+	}
+	vars := Vars{OrgID: 1, Channel: "stream/nats/cpu", Scope: "stream", Namespace: "nats", Path: "cpu"}
+
+	require.NoError(t, s.startConsumer(vars))
+
+	select {
+	case <-consumer.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer was not closed")
+	}
+	<-consumer.consumed
+
+	mu.Lock()
+	require.Len(t, publications, 1)
+	require.Equal(t, "stream/nats/cpu", publications[0].channel)
+	mu.Unlock()
+
+	// the consumer is started again once the previous one stopped
+	require.Eventually(t, func() bool {
+		runningConsumers.mu.Lock()
+		defer runningConsumers.mu.Unlock()
+		return len(runningConsumers.consumers) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMessageBusSubscriber_StartConsumerOnce(t *testing.T) {
+	s := &messageBusSubscriber{
+		subscriberType: SubscriberTypeKafka,
+		source:         "localhost:9092/test",
+		newConsumer: func() (messageConsumer, error) {
+			return nil, errors.New("consumer should not be created")
+		},
+	}
+	vars := Vars{OrgID: 1, Channel: "stream/kafka/cpu"}
+
+	key := "kafka/localhost:9092/test/1/stream/kafka/cpu"
+	require.True(t, runningConsumers.add(key))
+	defer runningConsumers.remove(key)
+
+	require.NoError(t, s.startConsumer(vars))
+}
+
+type testKafkaWriter struct {
+	messages []kafka.Message
+}
+
+func (w *testKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+type testNATSPublisher struct {
+	messages []*nats.Msg
+}
+
+func (p *testNATSPublisher) PublishMsg(msg *nats.Msg) error {
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+type testMQTTToken struct {
+	mqtt.Token
+	err error
+}
+
+func (t testMQTTToken) WaitTimeout(time.Duration) bool { return true }
+func (t testMQTTToken) Error() error                   { return t.err }
+
+type testMQTTMessage struct {
+	mqtt.Message
+	payload []byte
+}
+
+func (m testMQTTMessage) Payload() []byte { return m.payload }
+
+type testMQTTClient struct {
+	mqtt.Client
+	mu           sync.Mutex
+	published    map[string][]byte
+	handlers     map[string]mqtt.MessageHandler
+	unsubscribed []string
+	disconnected bool
+}
+
+func newTestMQTTClient() *testMQTTClient {
+	return &testMQTTClient{published: map[string][]byte{}, handlers: map[string]mqtt.MessageHandler{}}
+}
+
+func (c *testMQTTClient) Publish(topic string, _ byte, _ bool, payload interface{}) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published[topic] = payload.([]byte)
+	return testMQTTToken{}
+}
+
+func (c *testMQTTClient) Subscribe(topic string, _ byte, callback mqtt.MessageHandler) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[topic] = callback
+	return testMQTTToken{}
+}
+
+func (c *testMQTTClient) Unsubscribe(topics ...string) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unsubscribed = append(c.unsubscribed, topics...)
+	return testMQTTToken{}
+}
+
+func (c *testMQTTClient) Disconnect(uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disconnected = true
+}
+
+func testFrame() *data.Frame {
+	return data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+		data.NewField("value", nil, []float64{1.5}),
+	)
+}
+
+func TestKafkaFrameOutput(t *testing.T) {
+	writer := &testKafkaWriter{}
+	out := &KafkaFrameOutput{writer: writer, encoding: FrameEncodingArrow}
+
+	_, err := out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, testFrame())
+	require.NoError(t, err)
+	require.Len(t, writer.messages, 1)
+
+	msg := writer.messages[0]
+	require.Equal(t, "stream/test/cpu", string(msg.Key))
+	require.Equal(t, []kafka.Header{{Key: "content-type", Value: []byte("application/vnd.apache.arrow.file")}}, msg.Headers)
+	frame, err := data.UnmarshalArrowFrame(msg.Value)
+	require.NoError(t, err)
+	require.Equal(t, "test", frame.Name)
+}
+
+func TestNATSFrameOutput(t *testing.T) {
+	publisher := &testNATSPublisher{}
+	out := &NATSFrameOutput{conn: publisher, subject: "cpu"}
+
+	_, err := out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, testFrame())
+	require.NoError(t, err)
+	require.Len(t, publisher.messages, 1)
+
+	msg := publisher.messages[0]
+	require.Equal(t, "cpu", msg.Subject)
+	require.Equal(t, "application/json", msg.Header.Get("Content-Type"))
+	require.Equal(t, "stream/test/cpu", msg.Header.Get("Grafana-Live-Channel"))
+	var frame data.Frame
+	require.NoError(t, json.Unmarshal(msg.Data, &frame))
+	require.Equal(t, "test", frame.Name)
+}
+
+func TestMQTTFrameOutput(t *testing.T) {
+	client := newTestMQTTClient()
+	out := &MQTTFrameOutput{client: client, topic: "cpu", qos: 1}
+
+	_, err := out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, testFrame())
+	require.NoError(t, err)
+
+	var frame data.Frame
+	require.NoError(t, json.Unmarshal(client.published["cpu"], &frame))
+	require.Equal(t, "test", frame.Name)
+}
+
+func TestMQTTConsumer(t *testing.T) {
+	client := newTestMQTTClient()
+	consumer := &mqttConsumer{client: client, topic: "cpu"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan []byte, 1)
+	done := make(chan error)
+	go func() {
+		done <- consumer.Consume(ctx, func(payload []byte) {
+			received <- payload
+		})
+	}()
+
+	require.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return client.handlers["cpu"] != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	client.mu.Lock()
+	handler := client.handlers["cpu"]
+	client.mu.Unlock()
+	handler(client, testMQTTMessage{payload: []byte("1")})
+	require.Equal(t, []byte("1"), <-received)
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Equal(t, []string{"cpu"}, client.unsubscribed)
+
+	require.NoError(t, consumer.Close())
+	require.True(t, client.disconnected)
+}
@@ -105,6 +105,23 @@ type FrameOutputter interface {
 	OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error)
 }
 
+// FrameEmitter allows a FrameProcessor to emit frames asynchronously, for example
+// on timer. Emitted frame is processed by the rest of the channel rule processors
+// and outputs.
+type FrameEmitter func(ctx context.Context, frame *data.Frame) error
+
+type frameEmitterKey struct{}
+
+func withFrameEmitter(ctx context.Context, emitter FrameEmitter) context.Context {
+	return context.WithValue(ctx, frameEmitterKey{}, emitter)
+}
+
+// FrameEmitterFromContext returns FrameEmitter for the FrameProcessor being executed.
+func FrameEmitterFromContext(ctx context.Context) (FrameEmitter, bool) {
+	emitter, ok := ctx.Value(frameEmitterKey{}).(FrameEmitter)
+	return emitter, ok
+}
+
 // Subscriber can handle channel subscribe events.
 type Subscriber interface {
 	Type() string
@@ -405,16 +422,29 @@ func (p *Pipeline) processFrame(ctx context.Context, orgID int64, channelID stri
 		Path:      ch.Path,
 	}
 
-	if len(rule.FrameProcessors) > 0 {
-		for _, proc := range rule.FrameProcessors {
-			frame, err = p.execProcessor(ctx, proc, vars, frame)
+	return p.processRuleFrame(ctx, rule, vars, frame, 0)
+}
+
+// processRuleFrame runs channel rule frame processors starting from the processor
+// with index fromProcessor and then outputs the resulting frame.
+func (p *Pipeline) processRuleFrame(ctx context.Context, rule *LiveChannelRule, vars Vars, frame *data.Frame, fromProcessor int) ([]*ChannelFrame, error) {
+	var err error
+	for i := fromProcessor; i < len(rule.FrameProcessors); i++ {
+		next := i + 1
+		procCtx := withFrameEmitter(ctx, func(ctx context.Context, frame *data.Frame) error {
+			frames, err := p.processRuleFrame(ctx, rule, vars, frame, next)
 			if err != nil {
-				logger.Error("Error processing frame", "error", err)
-				return nil, err
-			}
-			if frame == nil {
-				return nil, nil
+				return err
 			}
+			return p.processChannelFrames(ctx, vars.OrgID, vars.Channel, frames, map[string]struct{}{vars.Channel: {}})
+		})
+		frame, err = p.execProcessor(procCtx, rule.FrameProcessors[i], vars, frame)
+		if err != nil {
+			logger.Error("Error processing frame", "error", err)
+			return nil, err
+		}
+		if frame == nil {
+			return nil, nil
 		}
 	}
 
@@ -143,3 +143,37 @@ func TestPipeline_Recursion(t *testing.T) {
 	_, err = p.ProcessInput(context.Background(), 1, "stream/test/xxx", []byte(`{}`))
 	require.ErrorIs(t, err, errChannelRecursion)
 }
+
+// testEmittingProcessor drops incoming frames and emits a replacement frame.
+type testEmittingProcessor struct{}
+
+func (t *testEmittingProcessor) Type() string {
+	return "testEmitting"
+}
+
+func (t *testEmittingProcessor) ProcessFrame(ctx context.Context, _ Vars, _ *data.Frame) (*data.Frame, error) {
+	emit, ok := FrameEmitterFromContext(ctx)
+	if !ok {
+		return nil, errors.New("no emitter")
+	}
+	return nil, emit(context.Background(), data.NewFrame("emitted"))
+}
+
+func TestPipeline_FrameEmitter(t *testing.T) {
+	outputter := &testOutputter{}
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/xxx": {
+				Converter:       &testConverter{"", data.NewFrame("test")},
+				FrameProcessors: []FrameProcessor{&testEmittingProcessor{}, &testProcessor{}},
+				FrameOutputters: []FrameOutputter{outputter},
+			},
+		},
+	})
+	require.NoError(t, err)
+	ok, err := p.ProcessInput(context.Background(), 1, "stream/test/xxx", []byte(`{}`))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, outputter.frame)
+	require.Equal(t, "emitted", outputter.frame.Name)
+}
@@ -15,6 +15,30 @@ var SubscribersRegistry = []EntityInfo{
 		Type:        SubscriberTypeManagedStream,
 		Description: "apply managed stream subscribe logic",
 	},
+	{
+		Type:        SubscriberTypeKafka,
+		Description: "consume Kafka topic messages into channel while it has subscribers",
+		Example: KafkaSubscriberConfig{
+			Brokers:   []string{"localhost:9092"},
+			Converter: &ConverterConfig{Type: ConverterTypeJsonAuto},
+		},
+	},
+	{
+		Type:        SubscriberTypeNATS,
+		Description: "consume NATS subject messages into channel while it has subscribers",
+		Example: NATSSubscriberConfig{
+			URL:       "nats://localhost:4222",
+			Converter: &ConverterConfig{Type: ConverterTypeJsonAuto},
+		},
+	},
+	{
+		Type:        SubscriberTypeMQTT,
+		Description: "consume MQTT topic messages into channel while it has subscribers",
+		Example: MQTTSubscriberConfig{
+			Broker:    "tcp://localhost:1883",
+			Converter: &ConverterConfig{Type: ConverterTypeInfluxAuto, AutoInfluxConverterConfig: &AutoInfluxConverterConfig{FrameFormat: "labels_column"}},
+		},
+	},
 }
 
 var FrameOutputsRegistry = []EntityInfo{
@@ -48,6 +72,21 @@ var FrameOutputsRegistry = []EntityInfo{
 		Type:        FrameOutputTypeLoki,
 		Description: "output frame as JSON to Loki",
 	},
+	{
+		Type:        FrameOutputTypeKafka,
+		Description: "publish frame encoded as JSON or Arrow to Kafka topic",
+		Example:     KafkaOutputConfig{Brokers: []string{"localhost:9092"}, Encoding: FrameEncodingJSON},
+	},
+	{
+		Type:        FrameOutputTypeNATS,
+		Description: "publish frame encoded as JSON or Arrow to NATS subject",
+		Example:     NATSOutputConfig{URL: "nats://localhost:4222", Encoding: FrameEncodingJSON},
+	},
+	{
+		Type:        FrameOutputTypeMQTT,
+		Description: "publish frame encoded as JSON or Arrow to MQTT topic",
+		Example:     MQTTOutputConfig{Broker: "tcp://localhost:1883", Encoding: FrameEncodingJSON},
+	},
 }
 
 var ConvertersRegistry = []EntityInfo{
@@ -83,6 +122,19 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeWindowAggregate,
+		Description: "downsample frames over a window, optionally grouped by labels",
+		Example: WindowAggregateFrameProcessorConfig{
+			WindowMilliseconds: 10000,
+			Aggregations:       []WindowAggregation{{FieldName: "value", Function: WindowFunctionMean}},
+		},
+	},
+	{
+		Type:        FrameProcessorTypeRateLimit,
+		Description: "drop frames exceeding the maximum number of frames per interval",
+		Example:     RateLimitFrameProcessorConfig{MaxFrames: 1, IntervalMilliseconds: 1000},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
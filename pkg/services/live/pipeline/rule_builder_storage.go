@@ -3,6 +3,8 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"io"
+	"sync"
 
 	"github.com/centrifugal/centrifuge"
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
@@ -16,6 +18,11 @@ type StorageRuleBuilder struct {
 	Storage              Storage
 	ChannelHandlerGetter ChannelHandlerGetter
 	SecretsService       secrets.Service
+
+	clientsMu sync.Mutex
+	// orgClients are the outputs of the last rules built for an organization
+	// which hold message bus clients. They are closed when the rules are rebuilt.
+	orgClients map[int64][]io.Closer
 }
 
 func (f *StorageRuleBuilder) extractSubscriber(config *SubscriberConfig) (Subscriber, error) {
@@ -42,11 +49,48 @@ func (f *StorageRuleBuilder) extractSubscriber(config *SubscriberConfig) (Subscr
 			subscribers = append(subscribers, sub)
 		}
 		return NewMultipleSubscriber(subscribers...), nil
+	case SubscriberTypeKafka:
+		c := config.KafkaSubscriberConfig
+		if c == nil || len(c.Brokers) == 0 || c.Topic == "" {
+			return nil, missingConfiguration
+		}
+		converter, err := f.extractSubscriberConverter(c.Converter, missingConfiguration)
+		if err != nil {
+			return nil, err
+		}
+		return NewKafkaSubscriber(f.Node, f.ManagedStream, converter, *c), nil
+	case SubscriberTypeNATS:
+		c := config.NATSSubscriberConfig
+		if c == nil || c.URL == "" || c.Subject == "" {
+			return nil, missingConfiguration
+		}
+		converter, err := f.extractSubscriberConverter(c.Converter, missingConfiguration)
+		if err != nil {
+			return nil, err
+		}
+		return NewNATSSubscriber(f.Node, f.ManagedStream, converter, *c), nil
+	case SubscriberTypeMQTT:
+		c := config.MQTTSubscriberConfig
+		if c == nil || c.Broker == "" || c.Topic == "" {
+			return nil, missingConfiguration
+		}
+		converter, err := f.extractSubscriberConverter(c.Converter, missingConfiguration)
+		if err != nil {
+			return nil, err
+		}
+		return NewMQTTSubscriber(f.Node, f.ManagedStream, converter, *c), nil
 	default:
 		return nil, fmt.Errorf("unknown subscriber type: %s", config.Type)
 	}
 }
 
+func (f *StorageRuleBuilder) extractSubscriberConverter(config *ConverterConfig, missingConfiguration error) (Converter, error) {
+	if config == nil {
+		return nil, missingConfiguration
+	}
+	return f.extractConverter(config)
+}
+
 func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converter, error) {
 	if config == nil {
 		return nil, nil
@@ -108,6 +152,16 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			processors = append(processors, proc)
 		}
 		return NewMultipleFrameProcessor(processors...), nil
+	case FrameProcessorTypeWindowAggregate:
+		if config.WindowAggregateProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewWindowAggregateFrameProcessor(*config.WindowAggregateProcessorConfig)
+	case FrameProcessorTypeRateLimit:
+		if config.RateLimitProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewRateLimitFrameProcessor(*config.RateLimitProcessorConfig)
 	default:
 		return nil, fmt.Errorf("unknown processor type: %s", config.Type)
 	}
@@ -168,7 +222,9 @@ func (f *StorageRuleBuilder) constructBasicAuth(writeConfig WriteConfig) (*Basic
 	}, nil
 }
 
-func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig, writeConfigs []WriteConfig) (FrameOutputter, error) {
+// extractFrameOutputter builds a frame outputter, outputters holding message bus
+// clients are added to clients to be closed when the rules are rebuilt.
+func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig, writeConfigs []WriteConfig, clients *[]io.Closer) (FrameOutputter, error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -186,7 +242,7 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 		var outputters []FrameOutputter
 		for _, outConf := range config.MultipleOutputterConfig.Outputters {
 			out := outConf
-			outputter, err := f.extractFrameOutputter(&out, writeConfigs)
+			outputter, err := f.extractFrameOutputter(&out, writeConfigs, clients)
 			if err != nil {
 				return nil, err
 			}
@@ -205,7 +261,7 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 		if err != nil {
 			return nil, err
 		}
-		outputter, err := f.extractFrameOutputter(config.ConditionalOutputConfig.Outputter, writeConfigs)
+		outputter, err := f.extractFrameOutputter(config.ConditionalOutputConfig.Outputter, writeConfigs, clients)
 		if err != nil {
 			return nil, err
 		}
@@ -253,6 +309,48 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 			return nil, missingConfiguration
 		}
 		return NewChangeLogFrameOutput(f.FrameStorage, *config.ChangeLogOutputConfig), nil
+	case FrameOutputTypeKafka:
+		c := config.KafkaOutputConfig
+		if c == nil || len(c.Brokers) == 0 || c.Topic == "" {
+			return nil, missingConfiguration
+		}
+		if !c.Encoding.valid() {
+			return nil, fmt.Errorf("unknown frame encoding: %s", c.Encoding)
+		}
+		out, err := NewKafkaFrameOutput(*c)
+		if err != nil {
+			return nil, err
+		}
+		*clients = append(*clients, out)
+		return out, nil
+	case FrameOutputTypeNATS:
+		c := config.NATSOutputConfig
+		if c == nil || c.URL == "" || c.Subject == "" {
+			return nil, missingConfiguration
+		}
+		if !c.Encoding.valid() {
+			return nil, fmt.Errorf("unknown frame encoding: %s", c.Encoding)
+		}
+		out, err := NewNATSFrameOutput(*c)
+		if err != nil {
+			return nil, err
+		}
+		*clients = append(*clients, out)
+		return out, nil
+	case FrameOutputTypeMQTT:
+		c := config.MQTTOutputConfig
+		if c == nil || c.Broker == "" || c.Topic == "" {
+			return nil, missingConfiguration
+		}
+		if !c.Encoding.valid() {
+			return nil, fmt.Errorf("unknown frame encoding: %s", c.Encoding)
+		}
+		out, err := NewMQTTFrameOutput(*c)
+		if err != nil {
+			return nil, err
+		}
+		*clients = append(*clients, out)
+		return out, nil
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", config.Type)
 	}
@@ -304,6 +402,36 @@ func (f *StorageRuleBuilder) getWriteConfig(uid string, writeConfigs []WriteConf
 }
 
 func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*LiveChannelRule, error) {
+	var clients []io.Closer
+	rules, err := f.buildRules(ctx, orgID, &clients)
+	if err != nil {
+		closeAll(clients)
+		return nil, err
+	}
+
+	f.clientsMu.Lock()
+	if f.orgClients == nil {
+		f.orgClients = map[int64][]io.Closer{}
+	}
+	previous := f.orgClients[orgID]
+	f.orgClients[orgID] = clients
+	f.clientsMu.Unlock()
+
+	// Shared clients are reference counted, the ones still used by the new
+	// rules stay open.
+	closeAll(previous)
+	return rules, nil
+}
+
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			logger.Error("Error closing outputter", "error", err)
+		}
+	}
+}
+
+func (f *StorageRuleBuilder) buildRules(ctx context.Context, orgID int64, clients *[]io.Closer) ([]*LiveChannelRule, error) {
 	channelRules, err := f.Storage.ListChannelRules(ctx, orgID)
 	if err != nil {
 		return nil, err
@@ -359,7 +487,7 @@ func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*Li
 
 		var outputters []FrameOutputter
 		for _, outConfig := range ruleConfig.Settings.FrameOutputters {
-			out, err := f.extractFrameOutputter(outConfig, writeConfigs)
+			out, err := f.extractFrameOutputter(outConfig, writeConfigs, clients)
 			if err != nil {
 				return nil, fmt.Errorf("error building frame outputter for %s: %w", rule.Pattern, err)
 			}
@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRuleStorage struct {
+	Storage
+	rules []ChannelRule
+}
+
+func (s *testRuleStorage) ListChannelRules(_ context.Context, _ int64) ([]ChannelRule, error) {
+	return s.rules, nil
+}
+
+func (s *testRuleStorage) ListWriteConfigs(_ context.Context, _ int64) ([]WriteConfig, error) {
+	return nil, nil
+}
+
+func kafkaOutputRule(pattern string, topic string) ChannelRule {
+	return ChannelRule{
+		Pattern: pattern,
+		Settings: ChannelRuleSettings{
+			FrameOutputters: []*FrameOutputterConfig{{
+				Type: FrameOutputTypeMultiple,
+				MultipleOutputterConfig: &MultipleOutputterConfig{
+					Outputters: []FrameOutputterConfig{{
+						Type:              FrameOutputTypeKafka,
+						KafkaOutputConfig: &KafkaOutputConfig{Brokers: []string{"localhost:9092"}, Topic: topic},
+					}},
+				},
+			}},
+		},
+	}
+}
+
+func TestStorageRuleBuilder_MessageBusClients(t *testing.T) {
+	storage := &testRuleStorage{}
+	builder := &StorageRuleBuilder{Storage: storage}
+	key := "kafka-writer/localhost:9092/builder-test"
+	refs := func() int {
+		messageBusClients.mu.Lock()
+		defer messageBusClients.mu.Unlock()
+		if shared, ok := messageBusClients.clients[key]; ok {
+			return shared.refs
+		}
+		return 0
+	}
+
+	storage.rules = []ChannelRule{
+		kafkaOutputRule("stream/test/a", "builder-test"),
+		kafkaOutputRule("stream/test/b", "builder-test"),
+	}
+	_, err := builder.BuildRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, refs())
+
+	// rebuilding keeps the client of the remaining rule open
+	storage.rules = storage.rules[:1]
+	_, err = builder.BuildRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, refs())
+
+	// the client is closed once its last rule is deleted
+	storage.rules = nil
+	_, err = builder.BuildRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, 0, refs())
+
+	// clients of a failed build are released
+	storage.rules = []ChannelRule{
+		kafkaOutputRule("stream/test/a", "builder-test"),
+		{Pattern: "stream/test/b", Settings: ChannelRuleSettings{
+			FrameOutputters: []*FrameOutputterConfig{{Type: FrameOutputTypeNATS}},
+		}},
+	}
+	_, err = builder.BuildRules(context.Background(), 1)
+	require.Error(t, err)
+	require.Equal(t, 0, refs())
+}
+
+func TestStorageRuleBuilder_ExtractMessageBusFrameOutputter(t *testing.T) {
+	builder := &StorageRuleBuilder{}
+
+	invalid := []*FrameOutputterConfig{
+		{Type: FrameOutputTypeKafka},
+		{Type: FrameOutputTypeKafka, KafkaOutputConfig: &KafkaOutputConfig{Topic: "cpu"}},
+		{Type: FrameOutputTypeKafka, KafkaOutputConfig: &KafkaOutputConfig{Brokers: []string{"localhost:9092"}, Topic: "cpu", Encoding: "xml"}},
+		{Type: FrameOutputTypeNATS},
+		{Type: FrameOutputTypeNATS, NATSOutputConfig: &NATSOutputConfig{URL: "nats://localhost:4222"}},
+		{Type: FrameOutputTypeMQTT},
+		{Type: FrameOutputTypeMQTT, MQTTOutputConfig: &MQTTOutputConfig{Topic: "cpu"}},
+	}
+	for _, config := range invalid {
+		_, err := builder.extractFrameOutputter(config, nil, &[]io.Closer{})
+		require.Error(t, err, config.Type)
+	}
+
+	var clients []io.Closer
+	out, err := builder.extractFrameOutputter(&FrameOutputterConfig{
+		Type:              FrameOutputTypeKafka,
+		KafkaOutputConfig: &KafkaOutputConfig{Brokers: []string{"localhost:9092"}, Topic: "extract-test"},
+	}, nil, &clients)
+	require.NoError(t, err)
+	require.Equal(t, FrameOutputTypeKafka, out.Type())
+	require.Len(t, clients, 1)
+	closeAll(clients)
+}
+
+func TestStorageRuleBuilder_ExtractMessageBusSubscriber(t *testing.T) {
+	builder := &StorageRuleBuilder{}
+	converter := &ConverterConfig{Type: ConverterTypeJsonAuto}
+
+	invalid := []*SubscriberConfig{
+		{Type: SubscriberTypeKafka},
+		{Type: SubscriberTypeKafka, KafkaSubscriberConfig: &KafkaSubscriberConfig{Brokers: []string{"localhost:9092"}, Topic: "cpu"}},
+		{Type: SubscriberTypeNATS, NATSSubscriberConfig: &NATSSubscriberConfig{URL: "nats://localhost:4222", Converter: converter}},
+		{Type: SubscriberTypeMQTT, MQTTSubscriberConfig: &MQTTSubscriberConfig{Topic: "cpu", Converter: converter}},
+	}
+	for _, config := range invalid {
+		_, err := builder.extractSubscriber(config)
+		require.Error(t, err, config.Type)
+	}
+
+	valid := []*SubscriberConfig{
+		{Type: SubscriberTypeKafka, KafkaSubscriberConfig: &KafkaSubscriberConfig{Brokers: []string{"localhost:9092"}, Topic: "cpu", Converter: converter}},
+		{Type: SubscriberTypeNATS, NATSSubscriberConfig: &NATSSubscriberConfig{URL: "nats://localhost:4222", Subject: "cpu", Converter: converter}},
+		{Type: SubscriberTypeMQTT, MQTTSubscriberConfig: &MQTTSubscriberConfig{Broker: "tcp://localhost:1883", Topic: "cpu", Converter: converter}},
+	}
+	for _, config := range valid {
+		sub, err := builder.extractSubscriber(config)
+		require.NoError(t, err, config.Type)
+		require.Equal(t, config.Type, sub.Type())
+	}
+}
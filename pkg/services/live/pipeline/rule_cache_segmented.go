@@ -59,6 +59,19 @@ func (s *CacheSegmentedTree) fillOrg(orgID int64) error {
 	return nil
 }
 
+// Reload rebuilds rules of an organization if they are already cached. Called when
+// rules were changed on this or on another Grafana node.
+func (s *CacheSegmentedTree) Reload(orgID int64) error {
+	s.radixMu.RLock()
+	_, ok := s.radix[orgID]
+	s.radixMu.RUnlock()
+	if !ok {
+		// Will be built on first access.
+		return nil
+	}
+	return s.fillOrg(orgID)
+}
+
 func (s *CacheSegmentedTree) Get(orgID int64, channel string) (*LiveChannelRule, bool, error) {
 	s.radixMu.RLock()
 	_, ok := s.radix[orgID]
@@ -0,0 +1,475 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	ErrChannelRuleNotFound = errors.New("rule not found")
+	ErrWriteConfigNotFound = errors.New("write config not found")
+)
+
+// SQLStorage keeps channel rules and write configs in the Grafana database, so
+// all nodes of HA setup share the same pipeline configuration. OnChange is called
+// after every successful modification and can be used to notify other nodes.
+type SQLStorage struct {
+	SQLStore       *sqlstore.SQLStore
+	SecretsService secrets.Service
+	OnChange       func(orgID int64)
+}
+
+type liveChannelRule struct {
+	Id       int64
+	OrgId    int64
+	Pattern  string
+	Settings string
+	Created  time.Time
+	Updated  time.Time
+}
+
+func (liveChannelRule) TableName() string {
+	return "live_channel_rule"
+}
+
+type liveWriteConfig struct {
+	Id             int64
+	OrgId          int64
+	Uid            string
+	Settings       string
+	SecureSettings string
+	Created        time.Time
+	Updated        time.Time
+}
+
+func (liveWriteConfig) TableName() string {
+	return "live_write_config"
+}
+
+func (r liveChannelRule) toChannelRule() (ChannelRule, error) {
+	rule := ChannelRule{
+		OrgId:   r.OrgId,
+		Pattern: r.Pattern,
+	}
+	if err := json.Unmarshal([]byte(r.Settings), &rule.Settings); err != nil {
+		return ChannelRule{}, fmt.Errorf("can't unmarshal settings of channel rule %s: %w", r.Pattern, err)
+	}
+	return rule, nil
+}
+
+func (c liveWriteConfig) toWriteConfig() (WriteConfig, error) {
+	writeConfig := WriteConfig{
+		OrgId: c.OrgId,
+		UID:   c.Uid,
+	}
+	if err := json.Unmarshal([]byte(c.Settings), &writeConfig.Settings); err != nil {
+		return WriteConfig{}, fmt.Errorf("can't unmarshal settings of write config %s: %w", c.Uid, err)
+	}
+	if c.SecureSettings != "" {
+		if err := json.Unmarshal([]byte(c.SecureSettings), &writeConfig.SecureSettings); err != nil {
+			return WriteConfig{}, fmt.Errorf("can't unmarshal secure settings of write config %s: %w", c.Uid, err)
+		}
+	}
+	return writeConfig, nil
+}
+
+func newLiveChannelRule(rule ChannelRule) (*liveChannelRule, error) {
+	settings, err := json.Marshal(rule.Settings)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &liveChannelRule{
+		OrgId:    rule.OrgId,
+		Pattern:  rule.Pattern,
+		Settings: string(settings),
+		Created:  now,
+		Updated:  now,
+	}, nil
+}
+
+func newLiveWriteConfig(writeConfig WriteConfig) (*liveWriteConfig, error) {
+	settings, err := json.Marshal(writeConfig.Settings)
+	if err != nil {
+		return nil, err
+	}
+	var secureSettings []byte
+	if len(writeConfig.SecureSettings) > 0 {
+		secureSettings, err = json.Marshal(writeConfig.SecureSettings)
+		if err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	return &liveWriteConfig{
+		OrgId:          writeConfig.OrgId,
+		Uid:            writeConfig.UID,
+		Settings:       string(settings),
+		SecureSettings: string(secureSettings),
+		Created:        now,
+		Updated:        now,
+	}, nil
+}
+
+func (s *SQLStorage) notifyChange(orgID int64) {
+	if s.OnChange != nil {
+		s.OnChange(orgID)
+	}
+}
+
+func (s *SQLStorage) ListWriteConfigs(ctx context.Context, orgID int64) ([]WriteConfig, error) {
+	var rows []liveWriteConfig
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Where("org_id = ?", orgID).Asc("uid").Find(&rows)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't read write configs: %w", err)
+	}
+	writeConfigs := make([]WriteConfig, 0, len(rows))
+	for _, row := range rows {
+		writeConfig, err := row.toWriteConfig()
+		if err != nil {
+			return nil, err
+		}
+		writeConfigs = append(writeConfigs, writeConfig)
+	}
+	return writeConfigs, nil
+}
+
+func (s *SQLStorage) GetWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigGetCmd) (WriteConfig, bool, error) {
+	var row liveWriteConfig
+	var exists bool
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		exists, err = sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).Get(&row)
+		return err
+	})
+	if err != nil {
+		return WriteConfig{}, false, fmt.Errorf("can't read write config: %w", err)
+	}
+	if !exists {
+		return WriteConfig{}, false, nil
+	}
+	writeConfig, err := row.toWriteConfig()
+	if err != nil {
+		return WriteConfig{}, false, err
+	}
+	return writeConfig, true, nil
+}
+
+func (s *SQLStorage) encryptWriteConfig(ctx context.Context, orgID int64, uid string, settings WriteSettings, secureSettings map[string]string) (WriteConfig, error) {
+	encrypted, err := s.SecretsService.EncryptJsonData(ctx, secureSettings, secrets.WithoutScope())
+	if err != nil {
+		return WriteConfig{}, fmt.Errorf("error encrypting data: %w", err)
+	}
+	writeConfig := WriteConfig{
+		OrgId:          orgID,
+		UID:            uid,
+		Settings:       settings,
+		SecureSettings: encrypted,
+	}
+	ok, reason := writeConfig.Valid()
+	if !ok {
+		return WriteConfig{}, fmt.Errorf("invalid write config: %s", reason)
+	}
+	return writeConfig, nil
+}
+
+func (s *SQLStorage) CreateWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigCreateCmd) (WriteConfig, error) {
+	if cmd.UID == "" {
+		cmd.UID = util.GenerateShortUID()
+	}
+	writeConfig, err := s.encryptWriteConfig(ctx, orgID, cmd.UID, cmd.Settings, cmd.SecureSettings)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	row, err := newLiveWriteConfig(writeConfig)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+
+	err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND uid = ?", orgID, writeConfig.UID).Exist(&liveWriteConfig{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("backend already exists in org: %s", writeConfig.UID)
+		}
+		_, err = sess.Insert(row)
+		return err
+	})
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	s.notifyChange(orgID)
+	return writeConfig, nil
+}
+
+func (s *SQLStorage) UpdateWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigUpdateCmd) (WriteConfig, error) {
+	writeConfig, err := s.encryptWriteConfig(ctx, orgID, cmd.UID, cmd.Settings, cmd.SecureSettings)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	row, err := newLiveWriteConfig(writeConfig)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+
+	err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var existing liveWriteConfig
+		exists, err := sess.Where("org_id = ? AND uid = ?", orgID, writeConfig.UID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			_, err = sess.Insert(row)
+			return err
+		}
+		row.Id = existing.Id
+		row.Created = existing.Created
+		_, err = sess.ID(existing.Id).MustCols("secure_settings").Update(row)
+		return err
+	})
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	s.notifyChange(orgID)
+	return writeConfig, nil
+}
+
+func (s *SQLStorage) DeleteWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigDeleteCmd) error {
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND uid = ?", orgID, cmd.UID).Delete(&liveWriteConfig{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrWriteConfigNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.notifyChange(orgID)
+	return nil
+}
+
+func (s *SQLStorage) ListChannelRules(ctx context.Context, orgID int64) ([]ChannelRule, error) {
+	var rules []ChannelRule
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		rules, err = listChannelRules(sess, orgID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't read channel rules: %w", err)
+	}
+	return rules, nil
+}
+
+func listChannelRules(sess *sqlstore.DBSession, orgID int64) ([]ChannelRule, error) {
+	var rows []liveChannelRule
+	if err := sess.Where("org_id = ?", orgID).Asc("pattern").Find(&rows); err != nil {
+		return nil, err
+	}
+	rules := make([]ChannelRule, 0, len(rows))
+	for _, row := range rows {
+		rule, err := row.toChannelRule()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// saveChannelRule inserts or updates the rule after checking that it does not
+// conflict with other rules of the organization.
+func saveChannelRule(sess *sqlstore.DBSession, rule ChannelRule, allowUpdate bool) error {
+	rules, err := listChannelRules(sess, rule.OrgId)
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, existingRule := range rules {
+		if existingRule.Pattern == rule.Pattern {
+			index = i
+			break
+		}
+	}
+	if index > -1 && !allowUpdate {
+		return fmt.Errorf("pattern already exists in org: %s", rule.Pattern)
+	}
+	if index > -1 {
+		rules[index] = rule
+	} else {
+		rules = append(rules, rule)
+	}
+	ok, reason := checkRulesValid(rule.OrgId, rules)
+	if !ok {
+		return errors.New(reason)
+	}
+
+	row, err := newLiveChannelRule(rule)
+	if err != nil {
+		return err
+	}
+	if index == -1 {
+		_, err = sess.Insert(row)
+		return err
+	}
+	_, err = sess.Where("org_id = ? AND pattern = ?", rule.OrgId, rule.Pattern).Cols("settings", "updated").Update(row)
+	return err
+}
+
+func (s *SQLStorage) CreateChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleCreateCmd) (ChannelRule, error) {
+	rule := ChannelRule{
+		OrgId:    orgID,
+		Pattern:  cmd.Pattern,
+		Settings: cmd.Settings,
+	}
+	ok, reason := rule.Valid()
+	if !ok {
+		return rule, fmt.Errorf("invalid channel rule: %s", reason)
+	}
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return saveChannelRule(sess, rule, false)
+	})
+	if err != nil {
+		return rule, err
+	}
+	s.notifyChange(orgID)
+	return rule, nil
+}
+
+func (s *SQLStorage) UpdateChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleUpdateCmd) (ChannelRule, error) {
+	rule := ChannelRule{
+		OrgId:    orgID,
+		Pattern:  cmd.Pattern,
+		Settings: cmd.Settings,
+	}
+	ok, reason := rule.Valid()
+	if !ok {
+		return rule, fmt.Errorf("invalid channel rule: %s", reason)
+	}
+	err := s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return saveChannelRule(sess, rule, true)
+	})
+	if err != nil {
+		return rule, err
+	}
+	s.notifyChange(orgID)
+	return rule, nil
+}
+
+func (s *SQLStorage) DeleteChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleDeleteCmd) error {
+	err := s.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("org_id = ? AND pattern = ?", orgID, cmd.Pattern).Delete(&liveChannelRule{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrChannelRuleNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.notifyChange(orgID)
+	return nil
+}
+
+// ImportFileStorage copies channel rules and write configs from the files used by
+// FileStorage into the database. Entries which already exist in the database are
+// left untouched. Imported files are renamed so the import runs only once.
+// Secure settings are copied as is since both storages encrypt them the same way.
+func (s *SQLStorage) ImportFileStorage(ctx context.Context, f *FileStorage) error {
+	changedOrgs := map[int64]struct{}{}
+
+	channelRules, err := f.readRules()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			for _, rule := range channelRules.Rules {
+				if rule.OrgId == 0 {
+					rule.OrgId = 1
+				}
+				exists, err := sess.Where("org_id = ? AND pattern = ?", rule.OrgId, rule.Pattern).Exist(&liveChannelRule{})
+				if err != nil {
+					return err
+				}
+				if exists {
+					continue
+				}
+				if err := saveChannelRule(sess, rule, false); err != nil {
+					return fmt.Errorf("can't import channel rule %s: %w", rule.Pattern, err)
+				}
+				changedOrgs[rule.OrgId] = struct{}{}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(f.ruleFilePath(), f.ruleFilePath()+".imported"); err != nil {
+			return err
+		}
+	}
+
+	writeConfigs, err := f.readWriteConfigs()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+			for _, writeConfig := range writeConfigs.Configs {
+				if writeConfig.OrgId == 0 {
+					writeConfig.OrgId = 1
+				}
+				exists, err := sess.Where("org_id = ? AND uid = ?", writeConfig.OrgId, writeConfig.UID).Exist(&liveWriteConfig{})
+				if err != nil {
+					return err
+				}
+				if exists {
+					continue
+				}
+				row, err := newLiveWriteConfig(writeConfig)
+				if err != nil {
+					return err
+				}
+				if _, err := sess.Insert(row); err != nil {
+					return fmt.Errorf("can't import write config %s: %w", writeConfig.UID, err)
+				}
+				changedOrgs[writeConfig.OrgId] = struct{}{}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(f.writeConfigsFilePath(), f.writeConfigsFilePath()+".imported"); err != nil {
+			return err
+		}
+	}
+
+	for orgID := range changedOrgs {
+		s.notifyChange(orgID)
+	}
+	return nil
+}
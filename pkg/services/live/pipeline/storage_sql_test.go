@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func setupSQLStorage(t *testing.T) (*SQLStorage, *[]int64) {
+	t.Helper()
+	var changes []int64
+	return &SQLStorage{
+		SQLStore:       sqlstore.InitTestDB(t),
+		SecretsService: secretsManager.SetupTestService(t, fakes.NewFakeSecretsStore()),
+		OnChange: func(orgID int64) {
+			changes = append(changes, orgID)
+		},
+	}, &changes
+}
+
+func TestSQLStorage_ChannelRules(t *testing.T) {
+	s, changes := setupSQLStorage(t)
+	ctx := context.Background()
+
+	rule, err := s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{
+		Pattern:  "stream/telegraf/:metric",
+		Settings: ChannelRuleSettings{Converter: &ConverterConfig{Type: ConverterTypeInfluxAuto}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rule.OrgId)
+
+	_, err = s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/telegraf/:metric"})
+	require.Error(t, err)
+
+	// Conflicting parameter names are rejected by the tree.
+	_, err = s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/telegraf/:other"})
+	require.Error(t, err)
+
+	_, err = s.UpdateChannelRule(ctx, 1, ChannelRuleUpdateCmd{
+		Pattern:  "stream/telegraf/:metric",
+		Settings: ChannelRuleSettings{Converter: &ConverterConfig{Type: ConverterTypeJsonAuto}},
+	})
+	require.NoError(t, err)
+
+	rules, err := s.ListChannelRules(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, ConverterTypeJsonAuto, rules[0].Settings.Converter.Type)
+
+	rules, err = s.ListChannelRules(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, rules, 0)
+
+	require.NoError(t, s.DeleteChannelRule(ctx, 1, ChannelRuleDeleteCmd{Pattern: "stream/telegraf/:metric"}))
+	require.ErrorIs(t, s.DeleteChannelRule(ctx, 1, ChannelRuleDeleteCmd{Pattern: "stream/telegraf/:metric"}), ErrChannelRuleNotFound)
+	require.Equal(t, []int64{1, 1, 1}, *changes)
+}
+
+func TestSQLStorage_WriteConfigs(t *testing.T) {
+	s, changes := setupSQLStorage(t)
+	ctx := context.Background()
+
+	writeConfig, err := s.CreateWriteConfig(ctx, 1, WriteConfigCreateCmd{
+		Settings:       WriteSettings{Endpoint: "http://localhost:9090"},
+		SecureSettings: map[string]string{"basicAuthPassword": "secret"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, writeConfig.UID)
+
+	stored, ok, err := s.GetWriteConfig(ctx, 1, WriteConfigGetCmd{UID: writeConfig.UID})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "http://localhost:9090", stored.Settings.Endpoint)
+	require.NotEqual(t, []byte("secret"), stored.SecureSettings["basicAuthPassword"])
+	decrypted, err := s.SecretsService.DecryptJsonData(ctx, stored.SecureSettings)
+	require.NoError(t, err)
+	require.Equal(t, "secret", decrypted["basicAuthPassword"])
+
+	_, ok, err = s.GetWriteConfig(ctx, 2, WriteConfigGetCmd{UID: writeConfig.UID})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = s.UpdateWriteConfig(ctx, 1, WriteConfigUpdateCmd{
+		UID:      writeConfig.UID,
+		Settings: WriteSettings{Endpoint: "http://localhost:9091"},
+	})
+	require.NoError(t, err)
+	writeConfigs, err := s.ListWriteConfigs(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, writeConfigs, 1)
+	require.Equal(t, "http://localhost:9091", writeConfigs[0].Settings.Endpoint)
+	require.Empty(t, writeConfigs[0].SecureSettings)
+
+	require.NoError(t, s.DeleteWriteConfig(ctx, 1, WriteConfigDeleteCmd{UID: writeConfig.UID}))
+	require.ErrorIs(t, s.DeleteWriteConfig(ctx, 1, WriteConfigDeleteCmd{UID: writeConfig.UID}), ErrWriteConfigNotFound)
+	require.Equal(t, []int64{1, 1, 1}, *changes)
+}
+
+func TestSQLStorage_ImportFileStorage(t *testing.T) {
+	s, changes := setupSQLStorage(t)
+	ctx := context.Background()
+
+	dataPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataPath, "pipeline"), 0750))
+	f := &FileStorage{DataPath: dataPath, SecretsService: s.SecretsService}
+
+	secureSettings, err := s.SecretsService.EncryptJsonData(ctx, map[string]string{"basicAuthPassword": "secret"}, secrets.WithoutScope())
+	require.NoError(t, err)
+	rulesData, err := json.Marshal(ChannelRules{Rules: []ChannelRule{
+		{Pattern: "stream/telegraf/:metric"},
+		{Pattern: "stream/influx/:metric"},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(f.ruleFilePath(), rulesData, 0600))
+	writeConfigsData, err := json.Marshal(WriteConfigs{Configs: []WriteConfig{
+		{UID: "remote", Settings: WriteSettings{Endpoint: "http://localhost:9090"}, SecureSettings: secureSettings},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(f.writeConfigsFilePath(), writeConfigsData, 0600))
+
+	require.NoError(t, s.ImportFileStorage(ctx, f))
+	require.Equal(t, []int64{1}, *changes)
+
+	rules, err := s.ListChannelRules(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	writeConfig, ok, err := s.GetWriteConfig(ctx, 1, WriteConfigGetCmd{UID: "remote"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	decrypted, err := s.SecretsService.DecryptJsonData(ctx, writeConfig.SecureSettings)
+	require.NoError(t, err)
+	require.Equal(t, "secret", decrypted["basicAuthPassword"])
+
+	require.NoFileExists(t, f.ruleFilePath())
+	require.FileExists(t, f.ruleFilePath()+".imported")
+
+	// Import is a no-op when there is nothing to import.
+	require.NoError(t, s.ImportFileStorage(ctx, f))
+}
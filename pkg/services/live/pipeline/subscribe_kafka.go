@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+)
+
+const SubscriberTypeKafka = "kafka"
+
+// NewKafkaSubscriber creates a subscriber which consumes a Kafka topic while a
+// channel has subscribers and pushes converted frames into the channel.
+func NewKafkaSubscriber(node *centrifuge.Node, managedStream *managedstream.Runner, converter Converter, config KafkaSubscriberConfig) Subscriber {
+	return &messageBusSubscriber{
+		subscriberType: SubscriberTypeKafka,
+		source:         strings.Join(config.Brokers, ",") + "/" + config.Topic,
+		node:           node,
+		managedStream:  managedStream,
+		converter:      converter,
+		newConsumer: func() (messageConsumer, error) {
+			return &kafkaConsumer{config: config}, nil
+		},
+	}
+}
+
+type kafkaConsumer struct {
+	config KafkaSubscriberConfig
+}
+
+func (c *kafkaConsumer) Consume(ctx context.Context, handle func(payload []byte)) error {
+	readerConfig := kafka.ReaderConfig{
+		Brokers: c.config.Brokers,
+		Topic:   c.config.Topic,
+		GroupID: c.config.GroupID,
+	}
+	reader := kafka.NewReader(readerConfig)
+	defer func() { _ = reader.Close() }()
+	if readerConfig.GroupID == "" {
+		// Without consumer group the first partition is read starting from new messages.
+		if err := reader.SetOffset(kafka.LastOffset); err != nil {
+			return err
+		}
+	}
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return err
+		}
+		handle(msg.Value)
+	}
+}
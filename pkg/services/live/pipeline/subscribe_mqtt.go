@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/centrifugal/centrifuge"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+)
+
+const SubscriberTypeMQTT = "mqtt"
+
+// NewMQTTSubscriber creates a subscriber which consumes an MQTT topic while a
+// channel has subscribers and pushes converted frames into the channel.
+func NewMQTTSubscriber(node *centrifuge.Node, managedStream *managedstream.Runner, converter Converter, config MQTTSubscriberConfig) Subscriber {
+	return &messageBusSubscriber{
+		subscriberType: SubscriberTypeMQTT,
+		source:         config.Broker + "/" + config.Topic,
+		node:           node,
+		managedStream:  managedStream,
+		converter:      converter,
+		newConsumer: func() (messageConsumer, error) {
+			// MQTT clients keep a single handler per topic, so every consumer gets its own
+			// client: unsubscribing must not stop delivering to other channels.
+			return &mqttConsumer{client: newMQTTClient(config.Broker), topic: config.Topic, qos: config.QoS}, nil
+		},
+	}
+}
+
+type mqttConsumer struct {
+	client mqtt.Client
+	topic  string
+	qos    byte
+}
+
+func (c *mqttConsumer) Consume(ctx context.Context, handle func(payload []byte)) error {
+	err := waitMQTTToken(c.client.Subscribe(c.topic, c.qos, func(_ mqtt.Client, msg mqtt.Message) {
+		handle(msg.Payload())
+	}))
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return waitMQTTToken(c.client.Unsubscribe(c.topic))
+}
+
+// Close disconnects the client of the consumer.
+func (c *mqttConsumer) Close() error {
+	c.client.Disconnect(250)
+	return nil
+}
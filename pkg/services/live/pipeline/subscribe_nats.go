@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/nats-io/nats.go"
+
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+)
+
+const SubscriberTypeNATS = "nats"
+
+// NewNATSSubscriber creates a subscriber which consumes a NATS subject while a
+// channel has subscribers and pushes converted frames into the channel.
+func NewNATSSubscriber(node *centrifuge.Node, managedStream *managedstream.Runner, converter Converter, config NATSSubscriberConfig) Subscriber {
+	return &messageBusSubscriber{
+		subscriberType: SubscriberTypeNATS,
+		source:         config.URL + "/" + config.Subject,
+		node:           node,
+		managedStream:  managedStream,
+		converter:      converter,
+		newConsumer: func() (messageConsumer, error) {
+			conn, err := acquireNATSConn(config.URL)
+			if err != nil {
+				return nil, err
+			}
+			return &natsConsumer{url: config.URL, conn: conn, subject: config.Subject}, nil
+		},
+	}
+}
+
+type natsConsumer struct {
+	url     string
+	conn    *nats.Conn
+	subject string
+}
+
+// Close releases the shared NATS connection.
+func (c *natsConsumer) Close() error {
+	messageBusClients.release(natsClientKey(c.url))
+	return nil
+}
+
+func (c *natsConsumer) Consume(ctx context.Context, handle func(payload []byte)) error {
+	sub, err := c.conn.Subscribe(c.subject, func(msg *nats.Msg) {
+		handle(msg.Data)
+	})
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return sub.Unsubscribe()
+}
@@ -15,11 +15,12 @@ var (
 	ErrSignupNotAllowed   = errors.New("system administrator has disabled signup")
 )
 
-type TeamSyncFunc func(user *user.User, externalUser *models.ExternalUserInfo) error
+type TeamSyncFunc func(ctx context.Context, user *user.User, externalUser *models.ExternalUserInfo) error
 
 type Service interface {
 	CreateUser(cmd user.CreateUserCommand) (*user.User, error)
 	UpsertUser(ctx context.Context, cmd *models.UpsertUserCommand) error
 	DisableExternalUser(ctx context.Context, username string) error
 	SetTeamSyncFunc(TeamSyncFunc)
+	SyncTeams(ctx context.Context, user *user.User, externalUser *models.ExternalUserInfo) error
 }
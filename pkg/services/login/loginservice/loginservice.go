@@ -145,8 +145,8 @@ func (ls *Implementation) UpsertUser(ctx context.Context, cmd *models.UpsertUser
 		}
 	}
 
-	if ls.TeamSync != nil {
-		if errTeamSync := ls.TeamSync(cmd.Result, extUser); errTeamSync != nil {
+	if !cmd.SkipTeamSync {
+		if errTeamSync := ls.SyncTeams(ctx, cmd.Result, extUser); errTeamSync != nil {
 			return errTeamSync
 		}
 	}
@@ -199,6 +199,14 @@ func (ls *Implementation) SetTeamSyncFunc(teamSyncFunc login.TeamSyncFunc) {
 	ls.TeamSync = teamSyncFunc
 }
 
+// SyncTeams runs the team sync function, if any, for an external user.
+func (ls *Implementation) SyncTeams(ctx context.Context, usr *user.User, extUser *models.ExternalUserInfo) error {
+	if ls.TeamSync == nil {
+		return nil
+	}
+	return ls.TeamSync(ctx, usr, extUser)
+}
+
 func (ls *Implementation) createUser(extUser *models.ExternalUserInfo) (*user.User, error) {
 	cmd := user.CreateUserCommand{
 		Login:        extUser.Login,
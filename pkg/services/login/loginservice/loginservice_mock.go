@@ -18,6 +18,7 @@ type LoginServiceMock struct {
 	GeneratedUserId     int64
 	ExpectedUser        *user.User
 	ExpectedUserFunc    func(cmd *models.UpsertUserCommand) *user.User
+	SyncTeamsFunc       func(usr *user.User, externalUser *models.ExternalUserInfo) error
 	ExpectedError       error
 }
 
@@ -47,6 +48,13 @@ func (s LoginServiceMock) UpsertUser(ctx context.Context, cmd *models.UpsertUser
 	return s.ExpectedError
 }
 
+func (s LoginServiceMock) SyncTeams(ctx context.Context, usr *user.User, externalUser *models.ExternalUserInfo) error {
+	if s.SyncTeamsFunc != nil {
+		return s.SyncTeamsFunc(usr, externalUser)
+	}
+	return nil
+}
+
 func (s LoginServiceMock) DisableExternalUser(ctx context.Context, username string) error {
 	return nil
 }
@@ -92,7 +92,7 @@ func Test_teamSync(t *testing.T) {
 		assert.Nil(t, actualExternalUser)
 
 		t.Run("login.TeamSync should be called when not nil", func(t *testing.T) {
-			teamSyncFunc := func(user *user.User, externalUser *models.ExternalUserInfo) error {
+			teamSyncFunc := func(ctx context.Context, user *user.User, externalUser *models.ExternalUserInfo) error {
 				actualUser = user
 				actualExternalUser = externalUser
 				return nil
@@ -105,13 +105,26 @@ func Test_teamSync(t *testing.T) {
 		})
 
 		t.Run("login.TeamSync should propagate its errors to the caller", func(t *testing.T) {
-			teamSyncFunc := func(user *user.User, externalUser *models.ExternalUserInfo) error {
+			teamSyncFunc := func(ctx context.Context, user *user.User, externalUser *models.ExternalUserInfo) error {
 				return errors.New("teamsync test error")
 			}
 			login.TeamSync = teamSyncFunc
 			err := login.UpsertUser(context.Background(), upserCmd)
 			require.Error(t, err)
 		})
+
+		t.Run("login.TeamSync should not be called when skipped", func(t *testing.T) {
+			called := false
+			login.TeamSync = func(ctx context.Context, user *user.User, externalUser *models.ExternalUserInfo) error {
+				called = true
+				return nil
+			}
+			cmd := *upserCmd
+			cmd.SkipTeamSync = true
+			err := login.UpsertUser(context.Background(), &cmd)
+			require.NoError(t, err)
+			assert.False(t, called)
+		})
 	})
 }
 
@@ -20,6 +20,9 @@ func (l *LoginServiceFake) DisableExternalUser(ctx context.Context, username str
 	return nil
 }
 func (l *LoginServiceFake) SetTeamSyncFunc(login.TeamSyncFunc) {}
+func (l *LoginServiceFake) SyncTeams(ctx context.Context, usr *user.User, externalUser *models.ExternalUserInfo) error {
+	return nil
+}
 
 type AuthInfoServiceFake struct {
 	LatestUserID         int64
@@ -56,11 +59,13 @@ func (a *AuthInfoServiceFake) GetExternalUserInfoByLogin(ctx context.Context, qu
 }
 
 type AuthenticatorFake struct {
-	ExpectedUser  *user.User
-	ExpectedError error
+	ExpectedUser       *user.User
+	ExpectedAuthModule string
+	ExpectedError      error
 }
 
 func (a *AuthenticatorFake) AuthenticateUser(c context.Context, query *models.LoginUserQuery) error {
 	query.User = a.ExpectedUser
+	query.AuthModule = a.ExpectedAuthModule
 	return a.ExpectedError
 }
@@ -0,0 +1,92 @@
+package accesscontrol
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"gopkg.in/yaml.v2"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*rolesAsConfig, error) {
+	var configs []*rolesAsConfig
+	cr.log.Debug("Looking for access control provisioning files", "path", path)
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Failed to read access control provisioning files from directory", "path", path, "error", err)
+		return configs, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing access control provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseRolesConfig(path, file.Name())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file.Name(), err)
+			}
+
+			if cfg != nil {
+				configs = append(configs, cfg)
+			}
+		}
+	}
+
+	if err := validateRoles(configs); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+func (cr *configReader) parseRolesConfig(path string, name string) (*rolesAsConfig, error) {
+	filename, err := filepath.Abs(filepath.Join(path, name))
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *rolesAsConfigV1
+	if err := yaml.Unmarshal(yamlFile, &cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToRolesFromConfig(), nil
+}
+
+// validateRoles checks the role definitions and sets the default organization
+func validateRoles(configs []*rolesAsConfig) error {
+	for _, cfg := range configs {
+		for _, role := range cfg.Roles {
+			if err := accesscontrol.ValidateCustomRole(role.Name, role.Permissions); err != nil {
+				return fmt.Errorf("role %q: %w", role.Name, err)
+			}
+			if role.OrgID < 1 {
+				role.OrgID = 1
+			}
+		}
+
+		for _, role := range cfg.DeleteRoles {
+			if role.UID == "" && role.Name == "" {
+				return fmt.Errorf("role to delete is missing a uid or a name")
+			}
+			if role.OrgID < 1 {
+				role.OrgID = 1
+			}
+		}
+	}
+	return nil
+}
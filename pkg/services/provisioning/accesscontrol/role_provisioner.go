@@ -0,0 +1,135 @@
+package accesscontrol
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+// Provision scans a directory for provisioning config files
+// and provisions the custom roles in those files.
+func Provision(ctx context.Context, configDirectory string, store accesscontrol.RoleStore, orgStore utils.OrgStore) error {
+	logger := log.New("provisioning.accesscontrol")
+	rp := RoleProvisioner{
+		log:         logger,
+		cfgProvider: &configReader{log: logger},
+		store:       store,
+		orgStore:    orgStore,
+	}
+	return rp.applyChanges(ctx, configDirectory)
+}
+
+// RoleProvisioner is responsible for provisioning custom roles based on
+// configuration read by the `configReader`
+type RoleProvisioner struct {
+	log         log.Logger
+	cfgProvider *configReader
+	store       accesscontrol.RoleStore
+	orgStore    utils.OrgStore
+}
+
+func (rp *RoleProvisioner) apply(ctx context.Context, cfg *rolesAsConfig) error {
+	for _, role := range cfg.DeleteRoles {
+		existing, err := rp.findRole(ctx, role.OrgID, role.UID, role.Name)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			continue
+		}
+
+		rp.log.Info("Deleting role from configuration", "name", existing.Name, "uid", existing.UID, "orgId", role.OrgID)
+		if err := rp.store.DeleteRole(ctx, role.OrgID, existing.UID); err != nil && !errors.Is(err, accesscontrol.ErrRoleNotFound) {
+			return err
+		}
+	}
+
+	for _, role := range cfg.Roles {
+		if err := utils.CheckOrgExists(ctx, rp.orgStore, role.OrgID); err != nil {
+			return err
+		}
+
+		existing, err := rp.findRole(ctx, role.OrgID, role.UID, role.Name)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			rp.log.Info("Inserting role from configuration", "name", role.Name, "uid", role.UID, "orgId", role.OrgID)
+			if _, err := rp.store.CreateRole(ctx, role.OrgID, accesscontrol.CreateRoleCommand{
+				UID:         role.UID,
+				Name:        role.Name,
+				DisplayName: role.DisplayName,
+				Description: role.Description,
+				Group:       role.Group,
+				Hidden:      role.Hidden,
+				Version:     role.Version,
+				Permissions: role.Permissions,
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// the role is only updated when the version of the definition is increased
+		if role.Version <= existing.Version {
+			rp.log.Debug("Skipping role with unchanged version", "name", role.Name, "version", role.Version, "orgId", role.OrgID)
+			continue
+		}
+
+		rp.log.Info("Updating role from configuration", "name", role.Name, "uid", existing.UID, "version", role.Version, "orgId", role.OrgID)
+		if _, err := rp.store.UpdateRole(ctx, role.OrgID, existing.UID, accesscontrol.UpdateRoleCommand{
+			Name:        role.Name,
+			DisplayName: role.DisplayName,
+			Description: role.Description,
+			Group:       role.Group,
+			Hidden:      role.Hidden,
+			Version:     role.Version,
+			Permissions: role.Permissions,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findRole returns the role with the uid or, without uid, the role with the name
+func (rp *RoleProvisioner) findRole(ctx context.Context, orgID int64, uid string, name string) (*accesscontrol.RoleDTO, error) {
+	if uid != "" {
+		role, err := rp.store.GetRole(ctx, orgID, uid)
+		if errors.Is(err, accesscontrol.ErrRoleNotFound) {
+			return nil, nil
+		}
+		return role, err
+	}
+
+	roles, err := rp.store.ListRoles(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		if role.Name == name && role.OrgID == orgID {
+			return role, nil
+		}
+	}
+	return nil, nil
+}
+
+func (rp *RoleProvisioner) applyChanges(ctx context.Context, configPath string) error {
+	configs, err := rp.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := rp.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
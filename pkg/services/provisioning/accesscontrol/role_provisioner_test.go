@@ -0,0 +1,93 @@
+package accesscontrol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+const (
+	correctRoles = "testdata/correct-roles"
+	invalidRole  = "testdata/invalid-role"
+	brokenYaml   = "testdata/broken-yaml"
+	emptyFolder  = "testdata/empty-folder"
+)
+
+func setupTestEnv(t *testing.T) (*database.AccessControlStore, *sqlstore.SQLStore) {
+	t.Helper()
+	sql := sqlstore.InitTestDB(t)
+	for _, name := range []string{"Main Org.", "Second Org."} {
+		_, err := sql.CreateOrgWithMember(name, 0)
+		require.NoError(t, err)
+	}
+	return database.ProvideService(sql), sql
+}
+
+func TestRoleProvisioning(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("roles are created in their organization", func(t *testing.T) {
+		store, sql := setupTestEnv(t)
+		legacy, err := store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{Name: "legacy:reader"})
+		require.NoError(t, err)
+
+		require.NoError(t, Provision(ctx, correctRoles, store, sql))
+
+		role, err := store.GetRole(ctx, 1, "reports_reader")
+		require.NoError(t, err)
+		require.Equal(t, "Reports reader", role.DisplayName)
+		require.Equal(t, "Reports", role.Group)
+		require.Equal(t, int64(1), role.Version)
+		require.Len(t, role.Permissions, 1)
+
+		roles, err := store.ListRoles(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.Equal(t, "dashboards:auditor", roles[0].Name)
+		require.Len(t, roles[0].Permissions, 2)
+
+		_, err = store.GetRole(ctx, 1, legacy.UID)
+		require.ErrorIs(t, err, accesscontrol.ErrRoleNotFound)
+	})
+
+	t.Run("roles are only updated with a greater version", func(t *testing.T) {
+		store, sql := setupTestEnv(t)
+		_, err := store.CreateRole(ctx, 1, accesscontrol.CreateRoleCommand{UID: "reports_reader", Name: "reports:reader", Version: 1})
+		require.NoError(t, err)
+		_, err = store.CreateRole(ctx, 2, accesscontrol.CreateRoleCommand{Name: "dashboards:auditor", Version: 1})
+		require.NoError(t, err)
+
+		require.NoError(t, Provision(ctx, correctRoles, store, sql))
+
+		role, err := store.GetRole(ctx, 1, "reports_reader")
+		require.NoError(t, err)
+		require.Empty(t, role.Permissions)
+
+		roles, err := store.ListRoles(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.Equal(t, int64(2), roles[0].Version)
+		require.Len(t, roles[0].Permissions, 2)
+
+		// provisioning is idempotent
+		require.NoError(t, Provision(ctx, correctRoles, store, sql))
+	})
+
+	t.Run("invalid roles return an error", func(t *testing.T) {
+		store, sql := setupTestEnv(t)
+		err := Provision(ctx, invalidRole, store, sql)
+		require.ErrorIs(t, err, accesscontrol.ErrRoleNameReserved)
+
+		require.Error(t, Provision(ctx, brokenYaml, store, sql))
+	})
+
+	t.Run("missing folder is skipped", func(t *testing.T) {
+		store, sql := setupTestEnv(t)
+		require.NoError(t, Provision(ctx, emptyFolder, store, sql))
+	})
+}
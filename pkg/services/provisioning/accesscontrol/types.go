@@ -0,0 +1,102 @@
+package accesscontrol
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// rolesAsConfig is a normalized data object for custom roles config data. Any config version should be mappable
+// to this type.
+type rolesAsConfig struct {
+	Roles       []*roleFromConfig
+	DeleteRoles []*deleteRoleFromConfig
+}
+
+type roleFromConfig struct {
+	OrgID       int64
+	UID         string
+	Name        string
+	DisplayName string
+	Description string
+	Group       string
+	Hidden      bool
+	Version     int64
+	Permissions []accesscontrol.Permission
+}
+
+type deleteRoleFromConfig struct {
+	OrgID int64
+	UID   string
+	Name  string
+}
+
+type permissionFromConfigV1 struct {
+	Action values.StringValue `json:"action" yaml:"action"`
+	Scope  values.StringValue `json:"scope" yaml:"scope"`
+}
+
+type roleFromConfigV1 struct {
+	OrgID       values.Int64Value         `json:"orgId" yaml:"orgId"`
+	UID         values.StringValue        `json:"uid" yaml:"uid"`
+	Name        values.StringValue        `json:"name" yaml:"name"`
+	DisplayName values.StringValue        `json:"displayName" yaml:"displayName"`
+	Description values.StringValue        `json:"description" yaml:"description"`
+	Group       values.StringValue        `json:"group" yaml:"group"`
+	Hidden      values.BoolValue          `json:"hidden" yaml:"hidden"`
+	Version     values.Int64Value         `json:"version" yaml:"version"`
+	Permissions []*permissionFromConfigV1 `json:"permissions" yaml:"permissions"`
+}
+
+type deleteRoleFromConfigV1 struct {
+	OrgID values.Int64Value  `json:"orgId" yaml:"orgId"`
+	UID   values.StringValue `json:"uid" yaml:"uid"`
+	Name  values.StringValue `json:"name" yaml:"name"`
+}
+
+// rolesAsConfigV1 is a mapping for version 1 configs. This is mapped to its normalised version.
+type rolesAsConfigV1 struct {
+	APIVersion  values.Int64Value         `json:"apiVersion" yaml:"apiVersion"`
+	Roles       []*roleFromConfigV1       `json:"roles" yaml:"roles"`
+	DeleteRoles []*deleteRoleFromConfigV1 `json:"deleteRoles" yaml:"deleteRoles"`
+}
+
+// mapToRolesFromConfig maps config syntax to a normalized rolesAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *rolesAsConfigV1) mapToRolesFromConfig() *rolesAsConfig {
+	r := &rolesAsConfig{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, role := range cfg.Roles {
+		permissions := make([]accesscontrol.Permission, 0, len(role.Permissions))
+		for _, p := range role.Permissions {
+			permissions = append(permissions, accesscontrol.Permission{
+				Action: p.Action.Value(),
+				Scope:  p.Scope.Value(),
+			})
+		}
+
+		r.Roles = append(r.Roles, &roleFromConfig{
+			OrgID:       role.OrgID.Value(),
+			UID:         role.UID.Value(),
+			Name:        role.Name.Value(),
+			DisplayName: role.DisplayName.Value(),
+			Description: role.Description.Value(),
+			Group:       role.Group.Value(),
+			Hidden:      role.Hidden.Value(),
+			Version:     role.Version.Value(),
+			Permissions: permissions,
+		})
+	}
+
+	for _, role := range cfg.DeleteRoles {
+		r.DeleteRoles = append(r.DeleteRoles, &deleteRoleFromConfig{
+			OrgID: role.OrgID.Value(),
+			UID:   role.UID.Value(),
+			Name:  role.Name.Value(),
+		})
+	}
+
+	return r
+}
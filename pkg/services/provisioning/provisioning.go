@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/pluginsettings"
+	prov_accesscontrol "github.com/grafana/grafana/pkg/services/provisioning/accesscontrol"
 	prov_alerting "github.com/grafana/grafana/pkg/services/provisioning/alerting"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
@@ -49,6 +50,7 @@ func ProvideService(
 	searchService searchV2.SearchService,
 	quotaService quota.Service,
 	secrectService secrets.Service,
+	roleStore accesscontrol.RoleStore,
 ) (*ProvisioningServiceImpl, error) {
 	s := &ProvisioningServiceImpl{
 		Cfg:                          cfg,
@@ -62,6 +64,7 @@ func ProvideService(
 		provisionDatasources:         datasources.Provision,
 		provisionPlugins:             plugins.Provision,
 		provisionAlerting:            prov_alerting.Provision,
+		provisionAccessControl:       prov_accesscontrol.Provision,
 		dashboardProvisioningService: dashboardProvisioningService,
 		dashboardService:             dashboardService,
 		datasourceService:            datasourceService,
@@ -71,6 +74,7 @@ func ProvideService(
 		searchService:                searchService,
 		quotaService:                 quotaService,
 		secretService:                secrectService,
+		roleStore:                    roleStore,
 		log:                          log.New("provisioning"),
 	}
 	return s, nil
@@ -84,6 +88,7 @@ type ProvisioningService interface {
 	ProvisionNotifications(ctx context.Context) error
 	ProvisionDashboards(ctx context.Context) error
 	ProvisionAlerting(ctx context.Context) error
+	ProvisionAccessControl(ctx context.Context) error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 }
@@ -131,6 +136,7 @@ type ProvisioningServiceImpl struct {
 	provisionDatasources         func(context.Context, string, datasources.Store, datasources.CorrelationsStore, utils.OrgStore) error
 	provisionPlugins             func(context.Context, string, plugins.Store, plugifaces.Store, pluginsettings.Service) error
 	provisionAlerting            func(context.Context, prov_alerting.ProvisionerConfig) error
+	provisionAccessControl       func(context.Context, string, accesscontrol.RoleStore, utils.OrgStore) error
 	mutex                        sync.Mutex
 	dashboardProvisioningService dashboardservice.DashboardProvisioningService
 	dashboardService             dashboardservice.DashboardService
@@ -141,10 +147,16 @@ type ProvisioningServiceImpl struct {
 	searchService                searchV2.SearchService
 	quotaService                 quota.Service
 	secretService                secrets.Service
+	roleStore                    accesscontrol.RoleStore
 }
 
 func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) error {
-	err := ps.ProvisionDatasources(ctx)
+	err := ps.ProvisionAccessControl(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ps.ProvisionDatasources(ctx)
 	if err != nil {
 		return err
 	}
@@ -289,6 +301,21 @@ func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error
 	return ps.provisionAlerting(ctx, cfg)
 }
 
+func (ps *ProvisioningServiceImpl) ProvisionAccessControl(ctx context.Context) error {
+	// Custom roles are only used with role based access control
+	if ps.provisionAccessControl == nil || ps.ac == nil || ps.ac.IsDisabled() {
+		return nil
+	}
+
+	accessControlPath := filepath.Join(ps.Cfg.ProvisioningPath, "access-control")
+	if err := ps.provisionAccessControl(ctx, accessControlPath, ps.roleStore, ps.SQLStore); err != nil {
+		err = fmt.Errorf("%v: %w", "Access control provisioning error", err)
+		ps.log.Error("Failed to provision access control", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (ps *ProvisioningServiceImpl) GetDashboardProvisionerResolvedPath(name string) string {
 	return ps.dashboardProvisioner.GetProvisionerResolvedPath(name)
 }
@@ -62,7 +62,7 @@ func setupTestServer(
 	}
 
 	var err error
-	ac, err := ossaccesscontrol.ProvideService(features, cfg, database.ProvideService(db), rr)
+	ac, err := ossaccesscontrol.ProvideService(features, cfg, database.ProvideService(db), rr, database.ProvideService(db))
 	require.NoError(t, err)
 
 	// build mux
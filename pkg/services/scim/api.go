@@ -0,0 +1,352 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := accesscontrol.Middleware(s.accessControl)
+	reqUsers := auth(middleware.ReqOrgAdmin, accesscontrol.EvalAll(
+		accesscontrol.EvalPermission(accesscontrol.ActionOrgUsersRead),
+		accesscontrol.EvalPermission(accesscontrol.ActionOrgUsersAdd),
+		accesscontrol.EvalPermission(accesscontrol.ActionOrgUsersRemove),
+	))
+	reqGroups := auth(middleware.ReqOrgAdmin, accesscontrol.EvalAll(
+		accesscontrol.EvalPermission(accesscontrol.ActionTeamsCreate),
+		accesscontrol.EvalPermission(accesscontrol.ActionTeamsRead, accesscontrol.ScopeTeamsAll),
+		accesscontrol.EvalPermission(accesscontrol.ActionTeamsWrite, accesscontrol.ScopeTeamsAll),
+		accesscontrol.EvalPermission(accesscontrol.ActionTeamsDelete, accesscontrol.ScopeTeamsAll),
+		accesscontrol.EvalPermission(accesscontrol.ActionTeamsPermissionsWrite, accesscontrol.ScopeTeamsAll),
+	))
+
+	s.routeRegister.Group("/api/scim/v2", func(scimRoute routing.RouteRegister) {
+		scimRoute.Get("/ServiceProviderConfig", routing.Wrap(s.getServiceProviderConfigHandler))
+
+		scimRoute.Get("/Users", reqUsers, routing.Wrap(s.listUsersHandler))
+		scimRoute.Post("/Users", reqUsers, routing.Wrap(s.createUserHandler))
+		scimRoute.Get("/Users/:id", reqUsers, routing.Wrap(s.getUserHandler))
+		scimRoute.Put("/Users/:id", reqUsers, routing.Wrap(s.replaceUserHandler))
+		scimRoute.Patch("/Users/:id", reqUsers, routing.Wrap(s.patchUserHandler))
+		scimRoute.Delete("/Users/:id", reqUsers, routing.Wrap(s.deleteUserHandler))
+
+		scimRoute.Get("/Groups", reqGroups, routing.Wrap(s.listGroupsHandler))
+		scimRoute.Post("/Groups", reqGroups, routing.Wrap(s.createGroupHandler))
+		scimRoute.Get("/Groups/:id", reqGroups, routing.Wrap(s.getGroupHandler))
+		scimRoute.Put("/Groups/:id", reqGroups, routing.Wrap(s.replaceGroupHandler))
+		scimRoute.Patch("/Groups/:id", reqGroups, routing.Wrap(s.patchGroupHandler))
+		scimRoute.Delete("/Groups/:id", reqGroups, routing.Wrap(s.deleteGroupHandler))
+	}, reqServiceAccount)
+}
+
+// reqServiceAccount only lets requests authenticated with a service account token through, so
+// that the identity provider acts on the organization of the service account.
+func reqServiceAccount(c *models.ReqContext) {
+	if !c.IsSignedIn || c.SignedInUser == nil || !c.IsServiceAccount {
+		scimResponse(http.StatusUnauthorized, newError(http.StatusUnauthorized, "", "a service account token is required")).WriteTo(c)
+	}
+}
+
+func scimResponse(status int, body interface{}) *response.NormalResponse {
+	return response.JSON(status, body).SetHeader("Content-Type", "application/scim+json")
+}
+
+func (s *Service) errorResponse(err error) response.Response {
+	var scimErr *Error
+	if errors.As(err, &scimErr) {
+		return scimResponse(scimErr.Status, scimErr)
+	}
+	s.log.Error("SCIM request failed", "error", err)
+	return scimResponse(http.StatusInternalServerError, newError(http.StatusInternalServerError, "", "internal server error"))
+}
+
+func decodeBody(c *models.ReqContext, v interface{}) error {
+	if err := json.NewDecoder(c.Req.Body).Decode(v); err != nil {
+		return newError(http.StatusBadRequest, "invalidSyntax", "invalid request body: %s", err)
+	}
+	return nil
+}
+
+// idParam returns the ID of the resource of the request, or notFound when it is not a Grafana ID.
+func idParam(c *models.ReqContext, notFound *Error) (int64, error) {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return 0, notFound
+	}
+	return id, nil
+}
+
+// listQuery is the filter and the page of a list request, see RFC 7644 section 3.4.2.
+type listQuery struct {
+	filter     Filter
+	startIndex int
+	count      int
+}
+
+func (s *Service) parseListQuery(c *models.ReqContext) (*listQuery, error) {
+	filter, err := ParseFilter(c.Query("filter"))
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "invalidFilter", "%s", err)
+	}
+
+	maxResults := s.cfg.SCIM.MaxResults
+	if maxResults <= 0 {
+		maxResults = 1000
+	}
+	q := &listQuery{filter: filter, startIndex: 1, count: defaultCount}
+	if v := c.Query("startIndex"); v != "" {
+		if q.startIndex, err = strconv.Atoi(v); err != nil {
+			return nil, newError(http.StatusBadRequest, "invalidValue", "invalid startIndex %s", v)
+		}
+	}
+	if v := c.Query("count"); v != "" {
+		if q.count, err = strconv.Atoi(v); err != nil {
+			return nil, newError(http.StatusBadRequest, "invalidValue", "invalid count %s", v)
+		}
+	}
+	if q.startIndex < 1 {
+		q.startIndex = 1
+	}
+	if q.count < 0 {
+		q.count = 0
+	}
+	if q.count > maxResults {
+		q.count = maxResults
+	}
+	return q, nil
+}
+
+// page returns the list response of the page of the resources.
+func (q *listQuery) page(resources []interface{}) *ListResponse {
+	res := &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   q.startIndex,
+		Resources:    []interface{}{},
+	}
+	if start := q.startIndex - 1; start < len(resources) {
+		end := start + q.count
+		if end > len(resources) {
+			end = len(resources)
+		}
+		res.Resources = resources[start:end]
+	}
+	res.ItemsPerPage = len(res.Resources)
+	return res
+}
+
+// excludeMembers returns whether the members of groups are excluded from the response, which
+// identity providers request to avoid listing the members of large groups.
+func excludeMembers(c *models.ReqContext) bool {
+	for _, attr := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if normalizePath(strings.TrimSpace(attr)) == "members" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) getServiceProviderConfigHandler(c *models.ReqContext) response.Response {
+	supported := func(supported bool) map[string]interface{} {
+		return map[string]interface{}{"supported": supported}
+	}
+	return scimResponse(http.StatusOK, map[string]interface{}{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": s.cfg.SCIM.MaxResults},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "Service account token",
+			"description": "Authentication with a Grafana service account token",
+		}},
+	})
+}
+
+func (s *Service) listUsersHandler(c *models.ReqContext) response.Response {
+	q, err := s.parseListQuery(c)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	users, err := s.listUsers(c.Req.Context(), c.OrgId, q.filter)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	resources := make([]interface{}, 0, len(users))
+	for _, u := range users {
+		resources = append(resources, u)
+	}
+	return scimResponse(http.StatusOK, q.page(resources))
+}
+
+func (s *Service) getUserHandler(c *models.ReqContext) response.Response {
+	userID, err := idParam(c, errUserNotFound)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	u, err := s.getUser(c.Req.Context(), c.OrgId, userID)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, u)
+}
+
+func (s *Service) createUserHandler(c *models.ReqContext) response.Response {
+	var u User
+	if err := decodeBody(c, &u); err != nil {
+		return s.errorResponse(err)
+	}
+	created, err := s.createUser(c.Req.Context(), c.OrgId, &u)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusCreated, created).SetHeader("Location", created.Meta.Location)
+}
+
+func (s *Service) replaceUserHandler(c *models.ReqContext) response.Response {
+	userID, err := idParam(c, errUserNotFound)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	var u User
+	if err := decodeBody(c, &u); err != nil {
+		return s.errorResponse(err)
+	}
+	updated, err := s.replaceUser(c.Req.Context(), c.OrgId, userID, &u)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, updated)
+}
+
+func (s *Service) patchUserHandler(c *models.ReqContext) response.Response {
+	userID, err := idParam(c, errUserNotFound)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	var patch PatchRequest
+	if err := decodeBody(c, &patch); err != nil {
+		return s.errorResponse(err)
+	}
+	updated, err := s.patchUser(c.Req.Context(), c.OrgId, userID, patch.Operations)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, updated)
+}
+
+func (s *Service) deleteUserHandler(c *models.ReqContext) response.Response {
+	userID, err := idParam(c, errUserNotFound)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	if err := s.deactivateUser(c.Req.Context(), c.OrgId, userID); err != nil {
+		return s.errorResponse(err)
+	}
+	return response.Empty(http.StatusNoContent)
+}
+
+func (s *Service) listGroupsHandler(c *models.ReqContext) response.Response {
+	q, err := s.parseListQuery(c)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	groups, err := s.listGroups(c.Req.Context(), c.OrgId, q.filter)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+
+	exclude := excludeMembers(c)
+	resources := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		if exclude {
+			g.Members = nil
+		}
+		resources = append(resources, g)
+	}
+	return scimResponse(http.StatusOK, q.page(resources))
+}
+
+func (s *Service) getGroupHandler(c *models.ReqContext) response.Response {
+	teamID, err := idParam(c, errGroupNotFound)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	g, err := s.getGroup(c.Req.Context(), c.OrgId, teamID)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	if excludeMembers(c) {
+		g.Members = nil
+	}
+	return scimResponse(http.StatusOK, g)
+}
+
+func (s *Service) createGroupHandler(c *models.ReqContext) response.Response {
+	var g Group
+	if err := decodeBody(c, &g); err != nil {
+		return s.errorResponse(err)
+	}
+	created, err := s.createGroup(c.Req.Context(), c.OrgId, &g)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusCreated, created).SetHeader("Location", created.Meta.Location)
+}
+
+func (s *Service) replaceGroupHandler(c *models.ReqContext) response.Response {
+	teamID, err := idParam(c, errGroupNotFound)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	var g Group
+	if err := decodeBody(c, &g); err != nil {
+		return s.errorResponse(err)
+	}
+	updated, err := s.replaceGroup(c.Req.Context(), c.OrgId, teamID, &g)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, updated)
+}
+
+func (s *Service) patchGroupHandler(c *models.ReqContext) response.Response {
+	teamID, err := idParam(c, errGroupNotFound)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	var patch PatchRequest
+	if err := decodeBody(c, &patch); err != nil {
+		return s.errorResponse(err)
+	}
+	updated, err := s.patchGroup(c.Req.Context(), c.OrgId, teamID, patch.Operations)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	return scimResponse(http.StatusOK, updated)
+}
+
+func (s *Service) deleteGroupHandler(c *models.ReqContext) response.Response {
+	teamID, err := idParam(c, errGroupNotFound)
+	if err != nil {
+		return s.errorResponse(err)
+	}
+	if err := s.deleteGroup(c.Req.Context(), c.OrgId, teamID); err != nil {
+		return s.errorResponse(err)
+	}
+	return response.Empty(http.StatusNoContent)
+}
@@ -0,0 +1,305 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// attributes are the values of a resource by lowercase attribute path, such as
+// "username" or "emails.value", that filters are evaluated against.
+type attributes map[string][]string
+
+// Filter is a parsed SCIM filter expression, see RFC 7644 section 3.4.2.2.
+type Filter interface {
+	Match(attrs attributes) bool
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right Filter
+}
+
+func (f *logicalFilter) Match(attrs attributes) bool {
+	if f.and {
+		return f.left.Match(attrs) && f.right.Match(attrs)
+	}
+	return f.left.Match(attrs) || f.right.Match(attrs)
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+func (f *notFilter) Match(attrs attributes) bool {
+	return !f.filter.Match(attrs)
+}
+
+type compareFilter struct {
+	path  string
+	op    string
+	value string
+}
+
+func (f *compareFilter) Match(attrs attributes) bool {
+	values := attrs[f.path]
+	if f.op == "pr" {
+		return len(values) > 0
+	}
+	if f.op == "ne" {
+		for _, v := range values {
+			if strings.EqualFold(v, f.value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	expected := strings.ToLower(f.value)
+	for _, v := range values {
+		v = strings.ToLower(v)
+		var matched bool
+		switch f.op {
+		case "eq":
+			matched = v == expected
+		case "co":
+			matched = strings.Contains(v, expected)
+		case "sw":
+			matched = strings.HasPrefix(v, expected)
+		case "ew":
+			matched = strings.HasSuffix(v, expected)
+		case "gt":
+			matched = v > expected
+		case "ge":
+			matched = v >= expected
+		case "lt":
+			matched = v < expected
+		case "le":
+			matched = v <= expected
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAll is the filter of requests without filter parameter.
+type matchAll struct{}
+
+func (matchAll) Match(attributes) bool {
+	return true
+}
+
+var filterOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true, "pr": true,
+}
+
+// ParseFilter parses a SCIM filter. Value filters of multi-valued attributes, such as
+// emails[type eq "work"], apply their expression to the sub-attributes of the attribute.
+func ParseFilter(filter string) (Filter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return matchAll{}, nil
+	}
+
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr("")
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *filterParser) parseOr(prefix string) (Filter, error) {
+	left, err := p.parseAnd(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd(prefix)
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd(prefix string) (Filter, error) {
+	left, err := p.parseFactor(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseFactor(prefix)
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseFactor(prefix string) (Filter, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of filter")
+	case strings.EqualFold(token, "not"):
+		if p.next() != "(" {
+			return nil, fmt.Errorf("expected ( after not")
+		}
+		f, err := p.parseGroup(prefix, ")")
+		if err != nil {
+			return nil, err
+		}
+		return &notFilter{filter: f}, nil
+	case token == "(":
+		return p.parseGroup(prefix, ")")
+	}
+
+	path := prefix + normalizePath(token)
+	if p.peek() == "[" {
+		p.next()
+		f, err := p.parseGroup(path+".", "]")
+		if err != nil {
+			return nil, err
+		}
+		// emails[type eq "work"].value eq "..." compares a sub-attribute of the matched values
+		if next := p.peek(); strings.HasPrefix(next, ".") {
+			p.next()
+			compare, err := p.parseComparison(path + normalizePath(next))
+			if err != nil {
+				return nil, err
+			}
+			return &logicalFilter{and: true, left: f, right: compare}, nil
+		}
+		return f, nil
+	}
+
+	return p.parseComparison(path)
+}
+
+func (p *filterParser) parseComparison(path string) (Filter, error) {
+	op := strings.ToLower(p.next())
+	if !filterOperators[op] {
+		return nil, fmt.Errorf("unsupported filter operator %q", op)
+	}
+	if op == "pr" {
+		return &compareFilter{path: path, op: op}, nil
+	}
+
+	value, err := parseFilterValue(p.next())
+	if err != nil {
+		return nil, err
+	}
+	return &compareFilter{path: path, op: op, value: value}, nil
+}
+
+func (p *filterParser) parseGroup(prefix, closing string) (Filter, error) {
+	f, err := p.parseOr(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if p.next() != closing {
+		return nil, fmt.Errorf("expected %s in filter", closing)
+	}
+	return f, nil
+}
+
+func parseFilterValue(token string) (string, error) {
+	switch {
+	case token == "":
+		return "", fmt.Errorf("missing filter value")
+	case strings.HasPrefix(token, `"`):
+		var value string
+		if err := json.Unmarshal([]byte(token), &value); err != nil {
+			return "", fmt.Errorf("invalid filter value %s", token)
+		}
+		return value, nil
+	case token == "true", token == "false", token == "null":
+		return token, nil
+	}
+	for _, r := range token {
+		if !unicode.IsDigit(r) && r != '.' && r != '-' {
+			return "", fmt.Errorf("invalid filter value %s", token)
+		}
+	}
+	return token, nil
+}
+
+// normalizePath lowercases an attribute path and removes the schema of core attributes.
+func normalizePath(path string) string {
+	path = strings.ToLower(path)
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		if prefix := strings.ToLower(schema) + ":"; strings.HasPrefix(path, prefix) {
+			return strings.TrimPrefix(path, prefix)
+		}
+	}
+	return path
+}
+
+// tokenize splits a filter into attribute paths, operators, values and brackets.
+func tokenize(filter string) ([]string, error) {
+	var tokens []string
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '[' || r == ']':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			tokens = append(tokens, string(runes[i:j+1]))
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()[]\"", runes[j]); j++ {
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens, nil
+}
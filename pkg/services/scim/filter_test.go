@@ -0,0 +1,76 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	alice := attributes{
+		"id":           {"2"},
+		"username":     {"alice"},
+		"displayname":  {"Alice Smith"},
+		"emails":       {"alice@example.com"},
+		"emails.value": {"alice@example.com"},
+		"emails.type":  {"work"},
+		"active":       {"true"},
+	}
+
+	tests := []struct {
+		filter  string
+		matched bool
+	}{
+		{filter: "", matched: true},
+		{filter: `userName eq "alice"`, matched: true},
+		{filter: `userName eq "ALICE"`, matched: true},
+		{filter: `USERNAME Eq "alice"`, matched: true},
+		{filter: `userName eq "bob"`, matched: false},
+		{filter: `userName ne "bob"`, matched: true},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice"`, matched: true},
+		{filter: `displayName co "smith"`, matched: true},
+		{filter: `displayName sw "Alice"`, matched: true},
+		{filter: `displayName ew "Alice"`, matched: false},
+		{filter: `emails pr`, matched: true},
+		{filter: `name.givenName pr`, matched: false},
+		{filter: `active eq true`, matched: true},
+		{filter: `id gt "1" and id le "2"`, matched: true},
+		{filter: `userName eq "bob" or emails.value ew "@example.com"`, matched: true},
+		{filter: `userName eq "bob" or userName eq "carol" and active eq true`, matched: false},
+		{filter: `(userName eq "bob" or userName eq "alice") and active eq true`, matched: true},
+		{filter: `not (userName eq "alice")`, matched: false},
+		{filter: `emails[type eq "work" and value co "@example.com"]`, matched: true},
+		{filter: `emails[type eq "home"]`, matched: false},
+		{filter: `emails[type eq "work"].value eq "alice@example.com"`, matched: true},
+		{filter: `displayName eq "Alice \"Al\" Smith"`, matched: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := ParseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.matched, f.Match(alice))
+		})
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, filter := range []string{
+		`userName`,
+		`userName eq`,
+		`userName is "alice"`,
+		`userName eq alice`,
+		`userName eq "alice`,
+		`(userName eq "alice"`,
+		`emails[type eq "work"`,
+		`not userName eq "alice"`,
+		`userName eq "alice" and`,
+		`userName eq "alice" userName eq "bob"`,
+	} {
+		t.Run(filter, func(t *testing.T) {
+			_, err := ParseFilter(filter)
+			require.Error(t, err)
+		})
+	}
+}
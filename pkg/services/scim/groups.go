@@ -0,0 +1,317 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+func (g *Group) attributes() attributes {
+	attrs := attributes{
+		"id":          {g.ID},
+		"displayname": {g.DisplayName},
+	}
+	for _, member := range g.Members {
+		attrs["members"] = append(attrs["members"], member.Value)
+		attrs["members.value"] = append(attrs["members.value"], member.Value)
+		attrs["members.display"] = append(attrs["members.display"], member.Display)
+	}
+	return attrs
+}
+
+func (s *Service) groupLocation(teamID int64) string {
+	return fmt.Sprintf("%sapi/scim/v2/Groups/%d", s.cfg.AppURL, teamID)
+}
+
+func (s *Service) toGroup(t *team, memberships []*membership) *Group {
+	res := &Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          strconv.FormatInt(t.ID, 10),
+		DisplayName: t.Name,
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      t.Created,
+			LastModified: t.Updated,
+			Location:     s.groupLocation(t.ID),
+		},
+	}
+	for _, m := range memberships {
+		res.Members = append(res.Members, MultiValue{
+			Value:   strconv.FormatInt(m.UserID, 10),
+			Display: m.Login,
+			Ref:     s.userLocation(m.UserID),
+		})
+	}
+	return res
+}
+
+func (s *Service) listGroups(ctx context.Context, orgID int64, filter Filter) ([]*Group, error) {
+	teams, err := s.getTeams(ctx, orgID, 0)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.getMemberships(ctx, orgID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	teamMemberships := make(map[int64][]*membership)
+	for _, m := range memberships {
+		teamMemberships[m.TeamID] = append(teamMemberships[m.TeamID], m)
+	}
+
+	res := make([]*Group, 0, len(teams))
+	for _, t := range teams {
+		if group := s.toGroup(t, teamMemberships[t.ID]); filter.Match(group.attributes()) {
+			res = append(res, group)
+		}
+	}
+	return res, nil
+}
+
+func (s *Service) getGroup(ctx context.Context, orgID, teamID int64) (*Group, error) {
+	t, err := s.getTeam(ctx, orgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.getMemberships(ctx, orgID, teamID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.toGroup(t, memberships), nil
+}
+
+func (s *Service) createGroup(ctx context.Context, orgID int64, g *Group) (*Group, error) {
+	name := strings.TrimSpace(g.DisplayName)
+	if name == "" {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	members, err := memberIDs(g.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.sqlStore.CreateTeam(name, "", orgID)
+	if err != nil {
+		if errors.Is(err, models.ErrTeamNameTaken) {
+			return nil, newError(http.StatusConflict, "uniqueness", "group %s already exists", name)
+		}
+		return nil, err
+	}
+
+	if err := s.setMembers(ctx, orgID, t.Id, members); err != nil {
+		return nil, err
+	}
+	return s.getGroup(ctx, orgID, t.Id)
+}
+
+func (s *Service) replaceGroup(ctx context.Context, orgID, teamID int64, g *Group) (*Group, error) {
+	t, err := s.getTeam(ctx, orgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := memberIDs(g.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.renameTeam(ctx, orgID, t, g.DisplayName); err != nil {
+		return nil, err
+	}
+	if err := s.setMembers(ctx, orgID, teamID, members); err != nil {
+		return nil, err
+	}
+	return s.getGroup(ctx, orgID, teamID)
+}
+
+// patchGroup supports replacing the displayName, and adding, removing and replacing members.
+// Members are removed by value, or by filter such as members[value eq "2"].
+func (s *Service) patchGroup(ctx context.Context, orgID, teamID int64, operations []PatchOperation) (*Group, error) {
+	t, err := s.getTeam(ctx, orgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.getMemberships(ctx, orgID, teamID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	name := t.Name
+	members := make(map[int64]bool, len(memberships))
+	for _, m := range memberships {
+		members[m.UserID] = true
+	}
+
+	for _, op := range operations {
+		if err := applyPatch(op, func(opName, path string, value json.RawMessage) error {
+			return patchGroupAttribute(&name, members, opName, path, value)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.renameTeam(ctx, orgID, t, name); err != nil {
+		return nil, err
+	}
+	if err := s.setMembers(ctx, orgID, teamID, members); err != nil {
+		return nil, err
+	}
+	return s.getGroup(ctx, orgID, teamID)
+}
+
+func (s *Service) deleteGroup(ctx context.Context, orgID, teamID int64) error {
+	if _, err := s.getTeam(ctx, orgID, teamID); err != nil {
+		return err
+	}
+	return s.sqlStore.DeleteTeam(ctx, &models.DeleteTeamCommand{OrgId: orgID, Id: teamID})
+}
+
+func (s *Service) renameTeam(ctx context.Context, orgID int64, t *team, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return newError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	if name == t.Name {
+		return nil
+	}
+
+	err := s.sqlStore.UpdateTeam(ctx, &models.UpdateTeamCommand{Id: t.ID, Name: name, OrgId: orgID})
+	if errors.Is(err, models.ErrTeamNameTaken) {
+		return newError(http.StatusConflict, "uniqueness", "group %s already exists", name)
+	}
+	return err
+}
+
+// setMembers adds and removes team members so that the members of the team are the users.
+func (s *Service) setMembers(ctx context.Context, orgID, teamID int64, userIDs map[int64]bool) error {
+	memberships, err := s.getMemberships(ctx, orgID, teamID, 0)
+	if err != nil {
+		return err
+	}
+	current := make(map[int64]bool, len(memberships))
+	for _, m := range memberships {
+		current[m.UserID] = true
+	}
+
+	for _, userID := range sortedIDs(userIDs) {
+		if current[userID] {
+			continue
+		}
+		if _, err := s.getOrgUser(ctx, orgID, userID); err != nil {
+			if errors.Is(err, errUserNotFound) {
+				return newError(http.StatusBadRequest, "invalidValue", "user %d is not a member of the organization", userID)
+			}
+			return err
+		}
+		if err := s.setTeamPermission(ctx, orgID, teamID, userID, "Member"); err != nil {
+			return err
+		}
+	}
+
+	for _, userID := range sortedIDs(current) {
+		if userIDs[userID] {
+			continue
+		}
+		if err := s.setTeamPermission(ctx, orgID, teamID, userID, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTeamPermission adds the user to the team with the permission, or removes the user from
+// the team when the permission is empty. Memberships are not external, so that team sync does
+// not remove the users it did not add.
+func (s *Service) setTeamPermission(ctx context.Context, orgID, teamID, userID int64, permission string) error {
+	_, err := s.teamPermissionsService.SetUserPermission(ctx, orgID, accesscontrol.User{ID: userID},
+		strconv.FormatInt(teamID, 10), permission)
+	return err
+}
+
+// patchGroupAttribute applies an operation to the name or the members of a group. Attributes
+// Grafana does not store, such as externalId, are ignored.
+func patchGroupAttribute(name *string, members map[int64]bool, op, path string, value json.RawMessage) error {
+	p := normalizePath(path)
+	switch {
+	case p == "displayname":
+		if op == "remove" {
+			return newError(http.StatusBadRequest, "mutability", "displayName cannot be removed")
+		}
+		return unmarshalValue(value, name)
+	case p == "members":
+		var values []MultiValue
+		if len(value) > 0 {
+			if err := unmarshalValue(value, &values); err != nil {
+				return err
+			}
+		}
+		ids, err := memberIDs(values)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case op == "replace":
+			for userID := range members {
+				delete(members, userID)
+			}
+			fallthrough
+		case op == "add":
+			for userID := range ids {
+				members[userID] = true
+			}
+		case len(values) == 0:
+			// removing members without value removes all of them
+			for userID := range members {
+				delete(members, userID)
+			}
+		default:
+			for userID := range ids {
+				delete(members, userID)
+			}
+		}
+	case strings.HasPrefix(p, "members["):
+		if op != "remove" {
+			return newError(http.StatusBadRequest, "invalidPath", "unsupported path %s", path)
+		}
+		filter, err := ParseFilter(path)
+		if err != nil {
+			return newError(http.StatusBadRequest, "invalidPath", "invalid path %s: %s", path, err)
+		}
+		for userID := range members {
+			if filter.Match(attributes{"members.value": {strconv.FormatInt(userID, 10)}}) {
+				delete(members, userID)
+			}
+		}
+	}
+	return nil
+}
+
+func memberIDs(values []MultiValue) (map[int64]bool, error) {
+	ids := make(map[int64]bool, len(values))
+	for _, v := range values {
+		userID, err := strconv.ParseInt(v.Value, 10, 64)
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, "invalidValue", "invalid member %q", v.Value)
+		}
+		ids[userID] = true
+	}
+	return ids, nil
+}
+
+func sortedIDs(ids map[int64]bool) []int64 {
+	res := make([]int64, 0, len(ids))
+	for id := range ids {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
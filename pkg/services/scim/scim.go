@@ -0,0 +1,190 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	// defaultCount is the page size of list requests without count parameter
+	defaultCount = 100
+)
+
+// Error is a SCIM error response, see RFC 7644 section 3.12.
+type Error struct {
+	Status int
+	// Type is the scimType detail error keyword, such as invalidFilter or uniqueness
+	Type   string
+	Detail string
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Schemas []string `json:"schemas"`
+		Status  string   `json:"status"`
+		Type    string   `json:"scimType,omitempty"`
+		Detail  string   `json:"detail"`
+	}{[]string{SchemaError}, fmt.Sprint(e.Status), e.Type, e.Detail})
+}
+
+func newError(status int, scimType, format string, args ...interface{}) *Error {
+	return &Error{Status: status, Type: scimType, Detail: fmt.Sprintf(format, args...)}
+}
+
+var (
+	errUserNotFound  = newError(http.StatusNotFound, "", "user not found")
+	errGroupNotFound = newError(http.StatusNotFound, "", "group not found")
+	errServerAdmin   = newError(http.StatusForbidden, "mutability", "Grafana server administrators cannot be modified through SCIM")
+	errSharedUser    = newError(http.StatusConflict, "mutability", "the user is a member of other organizations and was not created through SCIM of this organization, it cannot be modified")
+)
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// MultiValue is a value of a multi-valued attribute, such as the emails of a user or the members of a group.
+type MultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// User is a Grafana user member of the organization of the service account. The userName is
+// the login of the user, the displayName and name.formatted are both the name of the user.
+type User struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	UserName    string       `json:"userName"`
+	Name        *Name        `json:"name,omitempty"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []MultiValue `json:"emails,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Groups      []MultiValue `json:"groups,omitempty"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+// Group is a team of the organization of the service account.
+type Group struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []MultiValue `json:"members,omitempty"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Service implements the SCIM 2.0 /Users and /Groups endpoints, so that identity providers
+// provision the users and teams of the organization of a service account. Deprovisioned users
+// are disabled and logged out rather than deleted, so that their resources are kept.
+type Service struct {
+	cfg                    *setting.Cfg
+	log                    log.Logger
+	sqlStore               *sqlstore.SQLStore
+	userService            user.Service
+	authTokenService       models.UserTokenService
+	teamPermissionsService accesscontrol.TeamPermissionsService
+	accessControl          accesscontrol.AccessControl
+	routeRegister          routing.RouteRegister
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, userService user.Service,
+	authTokenService models.UserTokenService, teamPermissionsService accesscontrol.TeamPermissionsService,
+	accessControl accesscontrol.AccessControl, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		cfg:                    cfg,
+		log:                    log.New("scim"),
+		sqlStore:               sqlStore,
+		userService:            userService,
+		authTokenService:       authTokenService,
+		teamPermissionsService: teamPermissionsService,
+		accessControl:          accessControl,
+		routeRegister:          routeRegister,
+	}
+	if cfg.SCIM.Enabled {
+		s.registerAPIEndpoints()
+	}
+	return s
+}
+
+// applyPatch calls apply for the attribute of the operation, or for each attribute of the
+// value of operations without path, with the lowercase operation name.
+func applyPatch(op PatchOperation, apply func(opName, path string, value json.RawMessage) error) error {
+	opName := strings.ToLower(op.Op)
+	if opName != "add" && opName != "replace" && opName != "remove" {
+		return newError(http.StatusBadRequest, "invalidSyntax", "unsupported patch operation %q", op.Op)
+	}
+	if op.Path != "" {
+		return apply(opName, op.Path, op.Value)
+	}
+	if opName == "remove" {
+		return newError(http.StatusBadRequest, "noTarget", "remove operations require a path")
+	}
+
+	var values map[string]json.RawMessage
+	if err := unmarshalValue(op.Value, &values); err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := apply(opName, path, values[path]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
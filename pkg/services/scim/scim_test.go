@@ -0,0 +1,380 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeTeamPermissionsService updates team memberships the way the team
+// permissions service hook does.
+type fakeTeamPermissionsService struct {
+	accesscontrol.TeamPermissionsService
+	sqlStore *sqlstore.SQLStore
+}
+
+func (f *fakeTeamPermissionsService) SetUserPermission(ctx context.Context, orgID int64, usr accesscontrol.User, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
+	teamID, err := strconv.ParseInt(resourceID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if permission == "" {
+		return nil, f.sqlStore.RemoveTeamMember(ctx, &models.RemoveTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: usr.ID})
+	}
+	return nil, f.sqlStore.AddTeamMember(usr.ID, orgID, teamID, usr.IsExternal, 0)
+}
+
+type fakeUserService struct {
+	user.Service
+	sqlStore *sqlstore.SQLStore
+}
+
+func (f *fakeUserService) Create(ctx context.Context, cmd *user.CreateUserCommand) (*user.User, error) {
+	return f.sqlStore.CreateUser(ctx, *cmd)
+}
+
+func (f *fakeUserService) Update(ctx context.Context, cmd *user.UpdateUserCommand) error {
+	return f.sqlStore.UpdateUser(ctx, &models.UpdateUserCommand{UserId: cmd.UserID, Login: cmd.Login, Email: cmd.Email, Name: cmd.Name})
+}
+
+func setupTestService(t *testing.T) (*Service, *[]int64) {
+	t.Helper()
+	sqlStore := sqlstore.InitTestDB(t)
+
+	revoked := []int64{}
+	tokenService := auth.NewFakeUserAuthTokenService()
+	tokenService.RevokeAllUserTokensProvider = func(ctx context.Context, userID int64) error {
+		revoked = append(revoked, userID)
+		return nil
+	}
+
+	return &Service{
+		cfg:                    setting.NewCfg(),
+		log:                    log.New("scim"),
+		sqlStore:               sqlStore,
+		userService:            &fakeUserService{sqlStore: sqlStore},
+		authTokenService:       tokenService,
+		teamPermissionsService: &fakeTeamPermissionsService{sqlStore: sqlStore},
+	}, &revoked
+}
+
+func requireSCIMError(t *testing.T, err error, status int, scimType string) {
+	t.Helper()
+	var scimErr *Error
+	require.ErrorAs(t, err, &scimErr)
+	assert.Equal(t, status, scimErr.Status)
+	assert.Equal(t, scimType, scimErr.Type)
+}
+
+func patch(t *testing.T, op, path string, value interface{}) PatchOperation {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	require.NoError(t, err)
+	return PatchOperation{Op: op, Path: path, Value: raw}
+}
+
+func TestService_Users(t *testing.T) {
+	ctx := context.Background()
+	s, revoked := setupTestService(t)
+
+	owner, err := s.sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "owner"})
+	require.NoError(t, err)
+	orgID := owner.OrgID
+
+	alice, err := s.createUser(ctx, orgID, &User{
+		UserName: "alice",
+		Name:     &Name{GivenName: "Alice", FamilyName: "Smith"},
+		Emails:   []MultiValue{{Value: "alice@home.example.com"}, {Value: "alice@example.com", Primary: true}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", alice.UserName)
+	assert.Equal(t, "Alice Smith", alice.DisplayName)
+	assert.Equal(t, []MultiValue{{Value: "alice@example.com", Type: "work", Primary: true}}, alice.Emails)
+	assert.True(t, *alice.Active)
+	aliceID := mustParseID(t, alice.ID)
+
+	query := models.GetOrgUsersQuery{OrgId: orgID, UserID: aliceID, DontEnforceAccessControl: true}
+	require.NoError(t, s.sqlStore.GetOrgUsers(ctx, &query))
+	require.Len(t, query.Result, 1)
+	assert.Equal(t, "Viewer", query.Result[0].Role)
+
+	t.Run("users already in the organization cannot be created", func(t *testing.T) {
+		_, err := s.createUser(ctx, orgID, &User{UserName: "ALICE@example.com"})
+		requireSCIMError(t, err, http.StatusConflict, "uniqueness")
+	})
+
+	t.Run("existing users of other organizations are added to the organization", func(t *testing.T) {
+		bob, err := s.sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "bob", Email: "bob@example.com", SkipOrgSetup: true})
+		require.NoError(t, err)
+
+		res, err := s.createUser(ctx, orgID, &User{UserName: "bob", DisplayName: "Bob"})
+		require.NoError(t, err)
+		assert.Equal(t, strconv.FormatInt(bob.ID, 10), res.ID)
+		assert.Equal(t, "Bob", res.DisplayName)
+	})
+
+	t.Run("users are listed with filter", func(t *testing.T) {
+		users, err := s.listUsers(ctx, orgID, matchAll{})
+		require.NoError(t, err)
+		require.Len(t, users, 3)
+
+		f, err := ParseFilter(`userName eq "alice" or emails.value eq "bob@example.com"`)
+		require.NoError(t, err)
+		users, err = s.listUsers(ctx, orgID, f)
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, "alice", users[0].UserName)
+		assert.Equal(t, "bob", users[1].UserName)
+	})
+
+	t.Run("users are updated with patch operations", func(t *testing.T) {
+		res, err := s.patchUser(ctx, orgID, aliceID, []PatchOperation{
+			patch(t, "Replace", "displayName", "Alice Jones"),
+			patch(t, "replace", `emails[type eq "work"].value`, "alice.jones@example.com"),
+			patch(t, "add", "", map[string]interface{}{"userName": "alice.jones", "externalId": "42"}),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "alice.jones", res.UserName)
+		assert.Equal(t, "Alice Jones", res.DisplayName)
+		assert.Equal(t, "alice.jones@example.com", res.Emails[0].Value)
+		assert.True(t, *res.Active)
+		assert.Empty(t, *revoked)
+	})
+
+	t.Run("logins and emails of other users cannot be taken", func(t *testing.T) {
+		_, err := s.patchUser(ctx, orgID, aliceID, []PatchOperation{patch(t, "replace", "userName", "bob")})
+		requireSCIMError(t, err, http.StatusConflict, "uniqueness")
+
+		_, err = s.replaceUser(ctx, orgID, aliceID, &User{UserName: "alice.jones", Emails: []MultiValue{{Value: "bob@example.com"}}})
+		requireSCIMError(t, err, http.StatusConflict, "uniqueness")
+	})
+
+	t.Run("deactivated users are disabled and logged out", func(t *testing.T) {
+		res, err := s.patchUser(ctx, orgID, aliceID, []PatchOperation{patch(t, "Replace", "active", "False")})
+		require.NoError(t, err)
+		assert.False(t, *res.Active)
+		assert.Equal(t, []int64{aliceID}, *revoked)
+
+		res, err = s.replaceUser(ctx, orgID, aliceID, &User{UserName: "alice.jones", Active: boolPtr(true)})
+		require.NoError(t, err)
+		assert.True(t, *res.Active)
+
+		require.NoError(t, s.deactivateUser(ctx, orgID, aliceID))
+		res, err = s.getUser(ctx, orgID, aliceID)
+		require.NoError(t, err)
+		assert.False(t, *res.Active)
+		assert.Equal(t, []int64{aliceID, aliceID}, *revoked)
+	})
+
+	t.Run("server administrators cannot be modified", func(t *testing.T) {
+		admin, err := s.sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "admin", IsAdmin: true, SkipOrgSetup: true})
+		require.NoError(t, err)
+		_, err = s.createUser(ctx, orgID, &User{UserName: "admin"})
+		require.NoError(t, err)
+
+		_, err = s.patchUser(ctx, orgID, admin.ID, []PatchOperation{patch(t, "replace", "active", false)})
+		require.ErrorIs(t, err, errServerAdmin)
+		require.ErrorIs(t, s.deactivateUser(ctx, orgID, admin.ID), errServerAdmin)
+	})
+
+	t.Run("users of other organizations are not found", func(t *testing.T) {
+		_, err := s.getUser(ctx, orgID+1, aliceID)
+		require.ErrorIs(t, err, errUserNotFound)
+		require.ErrorIs(t, s.deactivateUser(ctx, orgID+1, aliceID), errUserNotFound)
+	})
+}
+
+func TestService_UsersOfOtherOrganizations(t *testing.T) {
+	ctx := context.Background()
+	s, revoked := setupTestService(t)
+
+	owner, err := s.sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "owner"})
+	require.NoError(t, err)
+	orgID := owner.OrgID
+	otherOwner, err := s.sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "other-owner"})
+	require.NoError(t, err)
+	otherOrgID := otherOwner.OrgID
+	require.NotEqual(t, orgID, otherOrgID)
+
+	// carol is a member of the other organization, and was not created through SCIM
+	carol, err := s.sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "carol", Email: "carol@example.com", Name: "Carol", OrgID: otherOrgID})
+	require.NoError(t, err)
+
+	t.Run("existing users are not changed when added to the organization", func(t *testing.T) {
+		_, err := s.createUser(ctx, orgID, &User{UserName: "carol", DisplayName: "Mallory", Emails: []MultiValue{{Value: "mallory@example.com"}}})
+		requireSCIMError(t, err, http.StatusConflict, "mutability")
+		_, err = s.getUser(ctx, orgID, carol.ID)
+		require.ErrorIs(t, err, errUserNotFound)
+
+		res, err := s.createUser(ctx, orgID, &User{UserName: "carol", DisplayName: "Carol", Emails: []MultiValue{{Value: "carol@example.com"}}})
+		require.NoError(t, err)
+		assert.Equal(t, strconv.FormatInt(carol.ID, 10), res.ID)
+	})
+
+	t.Run("users of other organizations cannot be modified or deactivated", func(t *testing.T) {
+		_, err := s.replaceUser(ctx, orgID, carol.ID, &User{UserName: "mallory", DisplayName: "Carol"})
+		requireSCIMError(t, err, http.StatusConflict, "mutability")
+
+		_, err = s.patchUser(ctx, orgID, carol.ID, []PatchOperation{patch(t, "replace", "active", false)})
+		requireSCIMError(t, err, http.StatusConflict, "mutability")
+
+		err = s.deactivateUser(ctx, orgID, carol.ID)
+		requireSCIMError(t, err, http.StatusConflict, "mutability")
+
+		res, err := s.getUser(ctx, orgID, carol.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "carol", res.UserName)
+		assert.True(t, *res.Active)
+		assert.Empty(t, *revoked)
+
+		// requests without changes succeed
+		_, err = s.replaceUser(ctx, orgID, carol.ID, &User{UserName: "carol", DisplayName: "Carol", Emails: []MultiValue{{Value: "carol@example.com"}}})
+		require.NoError(t, err)
+	})
+
+	t.Run("users created through SCIM can be modified after joining other organizations", func(t *testing.T) {
+		dave, err := s.createUser(ctx, orgID, &User{UserName: "dave"})
+		require.NoError(t, err)
+		daveID := mustParseID(t, dave.ID)
+		require.NoError(t, s.sqlStore.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: otherOrgID, UserId: daveID, Role: "Viewer"}))
+
+		res, err := s.patchUser(ctx, orgID, daveID, []PatchOperation{patch(t, "replace", "displayName", "Dave")})
+		require.NoError(t, err)
+		assert.Equal(t, "Dave", res.DisplayName)
+		require.NoError(t, s.deactivateUser(ctx, orgID, daveID))
+
+		// but not through SCIM of the other organization
+		_, err = s.patchUser(ctx, otherOrgID, daveID, []PatchOperation{patch(t, "replace", "active", true)})
+		requireSCIMError(t, err, http.StatusConflict, "mutability")
+	})
+}
+
+func TestService_Groups(t *testing.T) {
+	ctx := context.Background()
+	s, _ := setupTestService(t)
+
+	owner, err := s.sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "owner"})
+	require.NoError(t, err)
+	orgID := owner.OrgID
+
+	alice, err := s.createUser(ctx, orgID, &User{UserName: "alice"})
+	require.NoError(t, err)
+	bob, err := s.createUser(ctx, orgID, &User{UserName: "bob"})
+	require.NoError(t, err)
+
+	engineering, err := s.createGroup(ctx, orgID, &Group{DisplayName: "Engineering", Members: []MultiValue{{Value: alice.ID}}})
+	require.NoError(t, err)
+	require.Len(t, engineering.Members, 1)
+	assert.Equal(t, MultiValue{Value: alice.ID, Display: "alice", Ref: alice.Meta.Location}, engineering.Members[0])
+	teamID := mustParseID(t, engineering.ID)
+
+	res, err := s.getUser(ctx, orgID, mustParseID(t, alice.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []MultiValue{{Value: engineering.ID, Display: "Engineering", Ref: engineering.Meta.Location}}, res.Groups)
+
+	t.Run("groups with the name of another group cannot be created", func(t *testing.T) {
+		_, err := s.createGroup(ctx, orgID, &Group{DisplayName: "Engineering"})
+		requireSCIMError(t, err, http.StatusConflict, "uniqueness")
+	})
+
+	t.Run("members are added and removed with patch operations", func(t *testing.T) {
+		g, err := s.patchGroup(ctx, orgID, teamID, []PatchOperation{
+			patch(t, "Add", "members", []MultiValue{{Value: bob.ID}}),
+			patch(t, "Remove", `members[value eq "`+alice.ID+`"]`, nil),
+		})
+		require.NoError(t, err)
+		require.Len(t, g.Members, 1)
+		assert.Equal(t, bob.ID, g.Members[0].Value)
+
+		g, err = s.patchGroup(ctx, orgID, teamID, []PatchOperation{
+			patch(t, "replace", "members", []MultiValue{{Value: alice.ID}, {Value: bob.ID}}),
+			patch(t, "remove", "members", []MultiValue{{Value: bob.ID}}),
+		})
+		require.NoError(t, err)
+		require.Len(t, g.Members, 1)
+		assert.Equal(t, alice.ID, g.Members[0].Value)
+	})
+
+	t.Run("groups are renamed", func(t *testing.T) {
+		g, err := s.patchGroup(ctx, orgID, teamID, []PatchOperation{
+			patch(t, "replace", "", map[string]interface{}{"id": engineering.ID, "displayName": "R&D"}),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "R&D", g.DisplayName)
+
+		_, err = s.createGroup(ctx, orgID, &Group{DisplayName: "Sales"})
+		require.NoError(t, err)
+		_, err = s.replaceGroup(ctx, orgID, teamID, &Group{DisplayName: "Sales"})
+		requireSCIMError(t, err, http.StatusConflict, "uniqueness")
+	})
+
+	t.Run("members must be users of the organization", func(t *testing.T) {
+		other, err := s.sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "other", SkipOrgSetup: true})
+		require.NoError(t, err)
+
+		_, err = s.patchGroup(ctx, orgID, teamID, []PatchOperation{
+			patch(t, "add", "members", []MultiValue{{Value: strconv.FormatInt(other.ID, 10)}}),
+		})
+		requireSCIMError(t, err, http.StatusBadRequest, "invalidValue")
+	})
+
+	t.Run("groups are listed with filter", func(t *testing.T) {
+		f, err := ParseFilter(`displayName eq "r&d"`)
+		require.NoError(t, err)
+		groups, err := s.listGroups(ctx, orgID, f)
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		assert.Equal(t, engineering.ID, groups[0].ID)
+	})
+
+	t.Run("deleted groups are not found", func(t *testing.T) {
+		require.NoError(t, s.deleteGroup(ctx, orgID, teamID))
+		_, err := s.getGroup(ctx, orgID, teamID)
+		require.ErrorIs(t, err, errGroupNotFound)
+	})
+}
+
+func TestListQuery_Page(t *testing.T) {
+	resources := []interface{}{"a", "b", "c"}
+
+	q := &listQuery{startIndex: 2, count: 1}
+	assert.Equal(t, &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: 3,
+		StartIndex:   2,
+		ItemsPerPage: 1,
+		Resources:    []interface{}{"b"},
+	}, q.page(resources))
+
+	q = &listQuery{startIndex: 3, count: 10}
+	assert.Equal(t, []interface{}{"c"}, q.page(resources).Resources)
+
+	q = &listQuery{startIndex: 4, count: 10}
+	res := q.page(resources)
+	assert.Equal(t, []interface{}{}, res.Resources)
+	assert.Equal(t, 3, res.TotalResults)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func mustParseID(t *testing.T, id string) int64 {
+	t.Helper()
+	res, err := strconv.ParseInt(id, 10, 64)
+	require.NoError(t, err)
+	return res
+}
@@ -0,0 +1,173 @@
+package scim
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// orgUser is a user member of an organization.
+type orgUser struct {
+	ID         int64 `xorm:"id"`
+	Login      string
+	Email      string
+	Name       string
+	IsAdmin    bool
+	IsDisabled bool
+	Created    time.Time
+	Updated    time.Time
+}
+
+// scimUser records a user created through SCIM in an organization.
+type scimUser struct {
+	ID      int64 `xorm:"pk autoincr 'id'"`
+	OrgID   int64 `xorm:"org_id"`
+	UserID  int64 `xorm:"user_id"`
+	Created time.Time
+}
+
+func (scimUser) TableName() string {
+	return "scim_user"
+}
+
+type team struct {
+	ID      int64 `xorm:"id"`
+	Name    string
+	Created time.Time
+	Updated time.Time
+}
+
+type membership struct {
+	TeamID   int64  `xorm:"team_id"`
+	TeamName string `xorm:"team_name"`
+	UserID   int64  `xorm:"user_id"`
+	Login    string `xorm:"login"`
+}
+
+// getOrgUsers returns the users of the organization, or only the user with the ID when not 0.
+func (s *Service) getOrgUsers(ctx context.Context, orgID, userID int64) ([]*orgUser, error) {
+	users := make([]*orgUser, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		userTable := s.sqlStore.Dialect.Quote("user")
+		rawSQL := "SELECT u.id, u.login, u.email, u.name, u.is_admin, u.is_disabled, u.created, u.updated FROM " + userTable + " u" +
+			" INNER JOIN org_user ON org_user.user_id = u.id" +
+			" WHERE org_user.org_id = ? AND u.is_service_account = " + s.sqlStore.Dialect.BooleanStr(false)
+		args := []interface{}{orgID}
+		if userID != 0 {
+			rawSQL += " AND u.id = ?"
+			args = append(args, userID)
+		}
+		return sess.SQL(rawSQL+" ORDER BY u.id", args...).Find(&users)
+	})
+	return users, err
+}
+
+func (s *Service) getOrgUser(ctx context.Context, orgID, userID int64) (*orgUser, error) {
+	users, err := s.getOrgUsers(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, errUserNotFound
+	}
+	return users[0], nil
+}
+
+// getTeams returns the teams of the organization, or only the team with the ID when not 0.
+func (s *Service) getTeams(ctx context.Context, orgID, teamID int64) ([]*team, error) {
+	teams := make([]*team, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := "SELECT id, name, created, updated FROM team WHERE org_id = ?"
+		args := []interface{}{orgID}
+		if teamID != 0 {
+			rawSQL += " AND id = ?"
+			args = append(args, teamID)
+		}
+		return sess.SQL(rawSQL+" ORDER BY id", args...).Find(&teams)
+	})
+	return teams, err
+}
+
+func (s *Service) getTeam(ctx context.Context, orgID, teamID int64) (*team, error) {
+	teams, err := s.getTeams(ctx, orgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return nil, errGroupNotFound
+	}
+	return teams[0], nil
+}
+
+// getMemberships returns the team memberships of the organization, filtered by team and
+// user when their IDs are not 0.
+func (s *Service) getMemberships(ctx context.Context, orgID, teamID, userID int64) ([]*membership, error) {
+	memberships := make([]*membership, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := "SELECT team_member.team_id, team.name AS team_name, team_member.user_id, u.login FROM team_member" +
+			" INNER JOIN team ON team.id = team_member.team_id" +
+			" INNER JOIN " + s.sqlStore.Dialect.Quote("user") + " u ON u.id = team_member.user_id" +
+			" WHERE team_member.org_id = ?"
+		args := []interface{}{orgID}
+		if teamID != 0 {
+			rawSQL += " AND team_member.team_id = ?"
+			args = append(args, teamID)
+		}
+		if userID != 0 {
+			rawSQL += " AND team_member.user_id = ?"
+			args = append(args, userID)
+		}
+		return sess.SQL(rawSQL+" ORDER BY team_member.team_id, team_member.user_id", args...).Find(&memberships)
+	})
+	return memberships, err
+}
+
+// findUser returns a user, member of any organization, whose login or email is the login or the
+// email, other than the user with the excluded ID. It returns nil when no such user exists.
+func (s *Service) findUser(ctx context.Context, login, email string, excludedID int64) (*orgUser, error) {
+	values := []interface{}{strings.ToLower(login)}
+	if email != "" {
+		values = append(values, strings.ToLower(email))
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")
+
+	users := make([]*orgUser, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		rawSQL := "SELECT id, login, email, name, is_admin, is_disabled, created, updated FROM " + s.sqlStore.Dialect.Quote("user") +
+			" WHERE is_service_account = " + s.sqlStore.Dialect.BooleanStr(false) +
+			" AND (LOWER(login) IN (" + placeholders + ") OR LOWER(email) IN (" + placeholders + ")) AND id <> ?"
+		args := append(append(append([]interface{}{}, values...), values...), excludedID)
+		return sess.SQL(rawSQL+" ORDER BY id", args...).Find(&users)
+	})
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+	return users[0], nil
+}
+
+func (s *Service) addSCIMUser(ctx context.Context, orgID, userID int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Insert(&scimUser{OrgID: orgID, UserID: userID, Created: time.Now()})
+		return err
+	})
+}
+
+// isManagedUser reports whether the attributes shared by all the organizations of the user, such as
+// the login or the disabled flag, can be changed through SCIM of the organization: the user was
+// created through it, or isn't a member of any other organization.
+func (s *Service) isManagedUser(ctx context.Context, orgID, userID int64) (bool, error) {
+	var managed bool
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		created, err := sess.SQL("SELECT 1 FROM scim_user WHERE org_id = ? AND user_id = ?", orgID, userID).Exist()
+		if err != nil || created {
+			managed = created
+			return err
+		}
+		otherOrgs, err := sess.SQL("SELECT 1 FROM org_user WHERE user_id = ? AND org_id <> ?", userID, orgID).Exist()
+		managed = !otherOrgs
+		return err
+	})
+	return managed, err
+}
@@ -0,0 +1,459 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// String returns the formatted name, or the given name followed by the family name.
+func (n *Name) String() string {
+	if n == nil {
+		return ""
+	}
+	if n.Formatted != "" {
+		return n.Formatted
+	}
+	return strings.TrimSpace(n.GivenName + " " + n.FamilyName)
+}
+
+func (u *User) attributes() attributes {
+	attrs := attributes{
+		"id":       {u.ID},
+		"username": {u.UserName},
+	}
+	if u.DisplayName != "" {
+		attrs["displayname"] = []string{u.DisplayName}
+	}
+	if name := u.Name.String(); name != "" {
+		attrs["name.formatted"] = []string{name}
+	}
+	for _, email := range u.Emails {
+		attrs["emails"] = append(attrs["emails"], email.Value)
+		attrs["emails.value"] = append(attrs["emails.value"], email.Value)
+		attrs["emails.type"] = append(attrs["emails.type"], email.Type)
+	}
+	if u.Active != nil {
+		attrs["active"] = []string{strconv.FormatBool(*u.Active)}
+	}
+	for _, group := range u.Groups {
+		attrs["groups"] = append(attrs["groups"], group.Value)
+		attrs["groups.value"] = append(attrs["groups.value"], group.Value)
+		attrs["groups.display"] = append(attrs["groups.display"], group.Display)
+	}
+	return attrs
+}
+
+func (s *Service) userLocation(userID int64) string {
+	return fmt.Sprintf("%sapi/scim/v2/Users/%d", s.cfg.AppURL, userID)
+}
+
+func (s *Service) toUser(u *orgUser, memberships []*membership) *User {
+	active := !u.IsDisabled
+	res := &User{
+		Schemas:     []string{SchemaUser},
+		ID:          strconv.FormatInt(u.ID, 10),
+		UserName:    u.Login,
+		DisplayName: u.Name,
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      u.Created,
+			LastModified: u.Updated,
+			Location:     s.userLocation(u.ID),
+		},
+	}
+	if u.Name != "" {
+		res.Name = &Name{Formatted: u.Name}
+	}
+	if u.Email != "" {
+		res.Emails = []MultiValue{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	for _, m := range memberships {
+		res.Groups = append(res.Groups, MultiValue{
+			Value:   strconv.FormatInt(m.TeamID, 10),
+			Display: m.TeamName,
+			Ref:     s.groupLocation(m.TeamID),
+		})
+	}
+	return res
+}
+
+func (s *Service) listUsers(ctx context.Context, orgID int64, filter Filter) ([]*User, error) {
+	users, err := s.getOrgUsers(ctx, orgID, 0)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.getMemberships(ctx, orgID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	userMemberships := make(map[int64][]*membership)
+	for _, m := range memberships {
+		userMemberships[m.UserID] = append(userMemberships[m.UserID], m)
+	}
+
+	res := make([]*User, 0, len(users))
+	for _, u := range users {
+		if scimUser := s.toUser(u, userMemberships[u.ID]); filter.Match(scimUser.attributes()) {
+			res = append(res, scimUser)
+		}
+	}
+	return res, nil
+}
+
+func (s *Service) getUser(ctx context.Context, orgID, userID int64) (*User, error) {
+	u, err := s.getOrgUser(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.getMemberships(ctx, orgID, 0, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toUser(u, memberships), nil
+}
+
+// userFields are the fields of a Grafana user set from a SCIM user.
+type userFields struct {
+	login  string
+	email  string
+	name   string
+	active bool
+}
+
+func (s *Service) userFields(u *User) (userFields, error) {
+	f := userFields{
+		login:  strings.TrimSpace(u.UserName),
+		name:   u.DisplayName,
+		active: u.Active == nil || *u.Active,
+	}
+	if f.login == "" {
+		return f, newError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	if f.name == "" {
+		f.name = u.Name.String()
+	}
+
+	// Grafana users have a single email, the primary email of the SCIM user
+	for _, email := range u.Emails {
+		if email.Primary {
+			f.email = email.Value
+			break
+		}
+	}
+	if f.email == "" && len(u.Emails) > 0 {
+		f.email = u.Emails[0].Value
+	}
+	if f.email == "" && strings.Contains(f.login, "@") {
+		f.email = f.login
+	}
+
+	if s.cfg.CaseInsensitiveLogin {
+		f.login = strings.ToLower(f.login)
+		f.email = strings.ToLower(f.email)
+	}
+	return f, nil
+}
+
+// createUser creates a Grafana user member of the organization. Users already existing in other
+// organizations, for instance because they logged in with another authentication method, are
+// added to the organization rather than created, as long as their attributes are left unchanged.
+func (s *Service) createUser(ctx context.Context, orgID int64, u *User) (*User, error) {
+	f, err := s.userFields(u)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.findUser(ctx, f.login, f.email, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		usr, err := s.userService.Create(ctx, &user.CreateUserCommand{
+			Login:        f.login,
+			Email:        f.email,
+			Name:         f.name,
+			IsDisabled:   !f.active,
+			SkipOrgSetup: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.addOrgUser(ctx, orgID, usr.ID); err != nil {
+			return nil, err
+		}
+		if err := s.addSCIMUser(ctx, orgID, usr.ID); err != nil {
+			return nil, err
+		}
+		return s.getUser(ctx, orgID, usr.ID)
+	}
+
+	if _, err := s.getOrgUser(ctx, orgID, existing.ID); err == nil {
+		return nil, newError(http.StatusConflict, "uniqueness", "user %s already exists", f.login)
+	} else if !errors.Is(err, errUserNotFound) {
+		return nil, err
+	}
+
+	if userChanged(existing, f) {
+		if err := s.checkManagedUser(ctx, orgID, existing); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.addOrgUser(ctx, orgID, existing.ID); err != nil {
+		return nil, err
+	}
+	if err := s.updateUser(ctx, orgID, existing, f); err != nil {
+		return nil, err
+	}
+	return s.getUser(ctx, orgID, existing.ID)
+}
+
+func (s *Service) addOrgUser(ctx context.Context, orgID, userID int64) error {
+	role := org.RoleType(s.cfg.AutoAssignOrgRole)
+	if !role.IsValid() {
+		role = org.RoleViewer
+	}
+	err := s.sqlStore.AddOrgUser(ctx, &models.AddOrgUserCommand{OrgId: orgID, UserId: userID, Role: role})
+	if err != nil && !errors.Is(err, models.ErrOrgUserAlreadyAdded) {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) replaceUser(ctx context.Context, orgID, userID int64, u *User) (*User, error) {
+	existing, err := s.getOrgUser(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.userFields(u)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.updateUser(ctx, orgID, existing, f); err != nil {
+		return nil, err
+	}
+	return s.getUser(ctx, orgID, userID)
+}
+
+// patchUser applies the operations to the SCIM representation of the user, then updates
+// the user from the result.
+func (s *Service) patchUser(ctx context.Context, orgID, userID int64, operations []PatchOperation) (*User, error) {
+	existing, err := s.getOrgUser(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	u := s.toUser(existing, nil)
+	for _, op := range operations {
+		if err := applyPatch(op, func(opName, path string, value json.RawMessage) error {
+			return patchUserAttribute(u, opName, path, value)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := s.userFields(u)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.updateUser(ctx, orgID, existing, f); err != nil {
+		return nil, err
+	}
+	return s.getUser(ctx, orgID, userID)
+}
+
+// deactivateUser disables the user and revokes their sessions. Users are not deleted, so
+// that the dashboards and other resources they own are kept.
+func (s *Service) deactivateUser(ctx context.Context, orgID, userID int64) error {
+	existing, err := s.getOrgUser(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkManagedUser(ctx, orgID, existing); err != nil {
+		return err
+	}
+	return s.setActive(ctx, userID, false)
+}
+
+// checkManagedUser fails unless the attributes of the user shared by all the organizations
+// can be changed through SCIM of the organization.
+func (s *Service) checkManagedUser(ctx context.Context, orgID int64, existing *orgUser) error {
+	if existing.IsAdmin {
+		return errServerAdmin
+	}
+	managed, err := s.isManagedUser(ctx, orgID, existing.ID)
+	if err != nil {
+		return err
+	}
+	if !managed {
+		return errSharedUser
+	}
+	return nil
+}
+
+func userChanged(existing *orgUser, f userFields) bool {
+	return existing.Login != f.login ||
+		(f.email != "" && existing.Email != f.email) ||
+		(f.name != "" && existing.Name != f.name) ||
+		existing.IsDisabled == f.active
+}
+
+func (s *Service) updateUser(ctx context.Context, orgID int64, existing *orgUser, f userFields) error {
+	if !userChanged(existing, f) {
+		return nil
+	}
+	if err := s.checkManagedUser(ctx, orgID, existing); err != nil {
+		return err
+	}
+
+	cmd := user.UpdateUserCommand{UserID: existing.ID}
+	if existing.Login != f.login {
+		cmd.Login = f.login
+	}
+	if f.email != "" && existing.Email != f.email {
+		cmd.Email = f.email
+	}
+	if f.name != "" && existing.Name != f.name {
+		cmd.Name = f.name
+	}
+
+	if cmd.Login != "" || cmd.Email != "" {
+		// the user service does not check that the login and the email are unique
+		conflict, err := s.findUser(ctx, f.login, f.email, existing.ID)
+		if err != nil {
+			return err
+		}
+		if conflict != nil {
+			return newError(http.StatusConflict, "uniqueness", "another user already has the login or the email of user %s", f.login)
+		}
+	}
+
+	if cmd.Login != "" || cmd.Email != "" || cmd.Name != "" {
+		if err := s.userService.Update(ctx, &cmd); err != nil {
+			return err
+		}
+	}
+
+	if existing.IsDisabled == f.active {
+		return s.setActive(ctx, existing.ID, f.active)
+	}
+	return nil
+}
+
+func (s *Service) setActive(ctx context.Context, userID int64, active bool) error {
+	if err := s.sqlStore.DisableUser(ctx, &models.DisableUserCommand{UserId: userID, IsDisabled: !active}); err != nil {
+		return err
+	}
+	if active {
+		return nil
+	}
+
+	// deprovisioned users are logged out immediately rather than when their session expires
+	s.log.Info("Deactivating user", "userId", userID)
+	return s.authTokenService.RevokeAllUserTokens(ctx, userID)
+}
+
+// patchUserAttribute applies an add, replace or remove operation to an attribute of the user.
+// Attributes Grafana does not store, such as externalId or title, are ignored.
+func patchUserAttribute(u *User, op, path string, value json.RawMessage) error {
+	remove := op == "remove"
+	switch p := normalizePath(path); {
+	case p == "username":
+		if remove {
+			return newError(http.StatusBadRequest, "mutability", "userName cannot be removed")
+		}
+		return unmarshalValue(value, &u.UserName)
+	case p == "displayname":
+		if remove {
+			u.DisplayName = ""
+			return nil
+		}
+		return unmarshalValue(value, &u.DisplayName)
+	case p == "name":
+		u.Name = &Name{}
+		if !remove {
+			if err := unmarshalValue(value, u.Name); err != nil {
+				return err
+			}
+		}
+		u.DisplayName = u.Name.String()
+	case p == "name.formatted", p == "name.givenname", p == "name.familyname":
+		if u.Name == nil {
+			u.Name = &Name{}
+		}
+		var v string
+		if !remove {
+			if err := unmarshalValue(value, &v); err != nil {
+				return err
+			}
+		}
+		switch p {
+		case "name.formatted":
+			u.Name.Formatted = v
+		case "name.givenname":
+			u.Name.GivenName, u.Name.Formatted = v, ""
+		case "name.familyname":
+			u.Name.FamilyName, u.Name.Formatted = v, ""
+		}
+		u.DisplayName = u.Name.String()
+	case p == "emails":
+		// Grafana users have a single email, so added emails replace it
+		u.Emails = nil
+		if !remove {
+			return unmarshalValue(value, &u.Emails)
+		}
+	case strings.HasPrefix(p, "emails[") && strings.HasSuffix(p, "].value"):
+		u.Emails = nil
+		if !remove {
+			var email string
+			if err := unmarshalValue(value, &email); err != nil {
+				return err
+			}
+			u.Emails = []MultiValue{{Value: email, Primary: true}}
+		}
+	case p == "active":
+		if remove {
+			return newError(http.StatusBadRequest, "mutability", "active cannot be removed")
+		}
+		active, err := boolValue(value)
+		if err != nil {
+			return err
+		}
+		u.Active = &active
+	}
+	return nil
+}
+
+func unmarshalValue(value json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		return newError(http.StatusBadRequest, "invalidValue", "invalid value %s", string(value))
+	}
+	return nil
+}
+
+// boolValue parses a boolean value, also accepting strings such as "True" that some
+// identity providers send.
+func boolValue(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		if b, err := strconv.ParseBool(strings.ToLower(str)); err == nil {
+			return b, nil
+		}
+	}
+	return false, newError(http.StatusBadRequest, "invalidValue", "invalid boolean value %s", string(value))
+}
@@ -257,6 +257,117 @@ func TestServiceAccountsAPI_DeleteToken(t *testing.T) {
 	}
 }
 
+func TestServiceAccountsAPI_RotateToken(t *testing.T) {
+	store := sqlstore.InitTestDB(t)
+	apiKeyService := apikeyimpl.ProvideService(store, store.Cfg)
+	kvStore := kvstore.ProvideService(store)
+	svcMock := &tests.ServiceAccountMock{}
+	saStore := database.ProvideServiceAccountsStore(store, apiKeyService, kvStore)
+	sa := tests.SetupUserServiceAccount(t, store, tests.TestUser{Login: "sa", IsServiceAccount: true})
+
+	type testRotateSAToken struct {
+		desc         string
+		keyName      string
+		tokenID      int64
+		body         map[string]interface{}
+		expectedCode int
+		acmock       *accesscontrolmock.Mock
+	}
+
+	writePermissions := func(scope string) *accesscontrolmock.Mock {
+		return tests.SetupMockAccesscontrol(
+			t,
+			func(c context.Context, siu *user.SignedInUser, _ accesscontrol.Options) ([]accesscontrol.Permission, error) {
+				return []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: scope}}, nil
+			},
+			false,
+		)
+	}
+
+	testCases := []testRotateSAToken{
+		{
+			desc:         "should be ok to rotate serviceaccount token with scope id permissions",
+			keyName:      "Test1",
+			body:         map[string]interface{}{"gracePeriodSeconds": 60},
+			acmock:       writePermissions("serviceaccounts:id:1"),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should be ok to rotate serviceaccount token with the default grace period",
+			keyName:      "Test2",
+			body:         map[string]interface{}{},
+			acmock:       writePermissions(serviceaccounts.ScopeAll),
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should be forbidden to rotate serviceaccount token if wrong scoped",
+			keyName:      "Test3",
+			body:         map[string]interface{}{},
+			acmock:       writePermissions("serviceaccounts:id:10"),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should be bad request to rotate serviceaccount token with a negative grace period",
+			keyName:      "Test4",
+			body:         map[string]interface{}{"gracePeriodSeconds": -1},
+			acmock:       writePermissions("serviceaccounts:id:1"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "should be not found to rotate a missing serviceaccount token",
+			keyName:      "Test5",
+			tokenID:      1000,
+			body:         map[string]interface{}{},
+			acmock:       writePermissions("serviceaccounts:id:1"),
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	var requestResponse = func(server *web.Mux, httpMethod, requestpath string, requestBody io.Reader) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(httpMethod, requestpath, requestBody)
+		require.NoError(t, err)
+		req.Header.Add("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			token := createTokenforSA(t, saStore, tc.keyName, sa.OrgID, sa.ID, 1)
+			tokenID := token.Id
+			if tc.tokenID != 0 {
+				tokenID = tc.tokenID
+			}
+
+			endpoint := fmt.Sprintf(serviceaccountIDTokensDetailPath+"/rotate", sa.ID, tokenID)
+			bodyString, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			server, _ := setupTestServer(t, svcMock, routing.NewRouteRegister(), tc.acmock, store, saStore)
+			actual := requestResponse(server, http.MethodPost, endpoint, strings.NewReader(string(bodyString)))
+
+			actualCode := actual.Code
+			actualBody := map[string]interface{}{}
+
+			_ = json.Unmarshal(actual.Body.Bytes(), &actualBody)
+			require.Equal(t, tc.expectedCode, actualCode, endpoint, actualBody)
+
+			if actualCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, tc.keyName, actualBody["name"])
+			assert.NotEqual(t, float64(token.Id), actualBody["id"])
+			assert.True(t, strings.HasPrefix(actualBody["key"].(string), "glsa"))
+
+			query := apikey.GetByNameQuery{KeyName: fmt.Sprintf("%s-rotated-%d", tc.keyName, token.Id), OrgId: sa.OrgID}
+			err = apiKeyService.GetApiKeyByName(context.Background(), &query)
+			require.NoError(t, err)
+			assert.Equal(t, token.Key, query.Result.Key)
+		})
+	}
+}
+
 type saStoreMockTokens struct {
 	serviceaccounts.Store
 	saAPIKeys []*apikey.APIKey
@@ -21,6 +21,13 @@ var (
 	// MStatTotalServiceAccountTokens is a metric gauge for total number of service account tokens
 	MStatTotalServiceAccountTokens prometheus.Gauge
 
+	// MStatExpiredServiceAccountTokens is a metric gauge for number of expired service account tokens
+	MStatExpiredServiceAccountTokens prometheus.Gauge
+
+	// MStatUnusedServiceAccountTokens is a metric gauge for number of valid service account tokens
+	// not used for the token_unused_period setting
+	MStatUnusedServiceAccountTokens prometheus.Gauge
+
 	once        sync.Once
 	Initialised bool = false
 )
@@ -39,9 +46,23 @@ func InitMetrics() {
 			Namespace: ExporterName,
 		})
 
+		MStatExpiredServiceAccountTokens = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:      "stat_total_service_account_tokens_expired",
+			Help:      "total amount of expired service account tokens",
+			Namespace: ExporterName,
+		})
+
+		MStatUnusedServiceAccountTokens = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:      "stat_total_service_account_tokens_unused",
+			Help:      "total amount of valid service account tokens not used recently",
+			Namespace: ExporterName,
+		})
+
 		prometheus.MustRegister(
 			MStatTotalServiceAccounts,
 			MStatTotalServiceAccountTokens,
+			MStatExpiredServiceAccountTokens,
+			MStatUnusedServiceAccountTokens,
 		)
 	})
 }
@@ -68,6 +89,13 @@ func (s *ServiceAccountsStoreImpl) RunMetricsCollection(ctx context.Context) err
 func (s *ServiceAccountsStoreImpl) GetUsageMetrics(ctx context.Context) (map[string]interface{}, error) {
 	stats := map[string]interface{}{}
 
+	now := time.Now()
+	// without an unused period, no token is counted as unused
+	unusedBefore := time.Time{}
+	if s.sqlStore.Cfg != nil && s.sqlStore.Cfg.ServiceAccounts.TokenUnusedPeriod > 0 {
+		unusedBefore = now.Add(-s.sqlStore.Cfg.ServiceAccounts.TokenUnusedPeriod)
+	}
+
 	sb := &sqlstore.SQLBuilder{}
 	dialect := s.sqlStore.Dialect
 	sb.Write("SELECT ")
@@ -75,6 +103,11 @@ func (s *ServiceAccountsStoreImpl) GetUsageMetrics(ctx context.Context) (map[str
 		` WHERE is_service_account = ` + dialect.BooleanStr(true) + `) AS serviceaccounts,`)
 	sb.Write(`(SELECT COUNT(*) FROM ` + dialect.Quote("api_key") +
 		` WHERE service_account_id IS NOT NULL ) AS serviceaccount_tokens,`)
+	sb.Write(`(SELECT COUNT(*) FROM `+dialect.Quote("api_key")+
+		` WHERE service_account_id IS NOT NULL AND expires <= ?) AS serviceaccount_tokens_expired,`, now.Unix())
+	sb.Write(`(SELECT COUNT(*) FROM `+dialect.Quote("api_key")+
+		` WHERE service_account_id IS NOT NULL AND (expires IS NULL OR expires > ?)`+
+		` AND COALESCE(last_used_at, created) < ?) AS serviceaccount_tokens_unused,`, now.Unix(), unusedBefore)
 	// Add count to how many service accounts are in teams
 	sb.Write(`(SELECT COUNT(*) FROM team_member
 	JOIN ` + dialect.Quote("user") + ` on team_member.user_id=` + dialect.Quote("user") + `.id
@@ -83,6 +116,8 @@ func (s *ServiceAccountsStoreImpl) GetUsageMetrics(ctx context.Context) (map[str
 	type saStats struct {
 		ServiceAccounts int64 `xorm:"serviceaccounts"`
 		Tokens          int64 `xorm:"serviceaccount_tokens"`
+		ExpiredTokens   int64 `xorm:"serviceaccount_tokens_expired"`
+		UnusedTokens    int64 `xorm:"serviceaccount_tokens_unused"`
 		InTeams         int64 `xorm:"serviceaccounts_in_teams"`
 	}
 
@@ -97,9 +132,13 @@ func (s *ServiceAccountsStoreImpl) GetUsageMetrics(ctx context.Context) (map[str
 	stats["stats.serviceaccounts.count"] = sqlStats.ServiceAccounts
 	stats["stats.serviceaccounts.tokens.count"] = sqlStats.Tokens
 	stats["stats.serviceaccounts.in_teams.count"] = sqlStats.InTeams
+	stats["stats.serviceaccounts.tokens.expired.count"] = sqlStats.ExpiredTokens
+	stats["stats.serviceaccounts.tokens.unused.count"] = sqlStats.UnusedTokens
 
 	MStatTotalServiceAccountTokens.Set(float64(sqlStats.Tokens))
 	MStatTotalServiceAccounts.Set(float64(sqlStats.ServiceAccounts))
+	MStatExpiredServiceAccountTokens.Set(float64(sqlStats.ExpiredTokens))
+	MStatUnusedServiceAccountTokens.Set(float64(sqlStats.UnusedTokens))
 
 	return stats, nil
 }
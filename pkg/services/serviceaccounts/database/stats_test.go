@@ -3,11 +3,14 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/apikeygen"
 	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,6 +20,7 @@ func TestStore_UsageStats(t *testing.T) {
 	db, store := setupTestDatabase(t)
 	sa := tests.SetupUserServiceAccount(t, db, saToCreate)
 	InitMetrics()
+	db.Cfg.ServiceAccounts.TokenUnusedPeriod = time.Hour
 
 	keyName := t.Name()
 	key, err := apikeygen.New(sa.OrgID, keyName)
@@ -33,10 +37,25 @@ func TestStore_UsageStats(t *testing.T) {
 	err = store.AddServiceAccountToken(context.Background(), sa.ID, &cmd)
 	require.NoError(t, err)
 
+	expired := time.Now().Add(-time.Minute).Unix()
+	err = db.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		created := time.Now().Add(-2 * time.Hour)
+		_, err := sess.Insert(
+			&apikey.APIKey{OrgId: sa.OrgID, Name: "expired", Key: "expired", Role: org.RoleViewer,
+				Created: created, Updated: created, Expires: &expired, ServiceAccountId: &sa.ID},
+			&apikey.APIKey{OrgId: sa.OrgID, Name: "unused", Key: "unused", Role: org.RoleViewer,
+				Created: created, Updated: created, ServiceAccountId: &sa.ID},
+		)
+		return err
+	})
+	require.NoError(t, err)
+
 	stats, err := store.GetUsageMetrics(context.Background())
 	require.NoError(t, err)
 
 	assert.Equal(t, int64(1), stats["stats.serviceaccounts.count"].(int64))
-	assert.Equal(t, int64(1), stats["stats.serviceaccounts.tokens.count"].(int64))
+	assert.Equal(t, int64(3), stats["stats.serviceaccounts.tokens.count"].(int64))
+	assert.Equal(t, int64(1), stats["stats.serviceaccounts.tokens.expired.count"].(int64))
+	assert.Equal(t, int64(1), stats["stats.serviceaccounts.tokens.unused.count"].(int64))
 	assert.Equal(t, int64(0), stats["stats.serviceaccounts.in_teams.count"].(int64))
 }
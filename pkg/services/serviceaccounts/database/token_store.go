@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/grafana/grafana/pkg/services/apikey"
@@ -68,6 +69,96 @@ func (s *ServiceAccountsStoreImpl) AddServiceAccountToken(ctx context.Context, s
 	})
 }
 
+// RotateServiceAccountToken replaces a token by a new one with the same name. The rotated token is
+// renamed and stays valid for the grace period, so that its clients can switch to the new token.
+func (s *ServiceAccountsStoreImpl) RotateServiceAccountToken(ctx context.Context, serviceAccountId, tokenId int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) error {
+	if cmd.GracePeriodSeconds < 0 || cmd.SecondsToLive < 0 {
+		return ErrInvalidTokenExpiration
+	}
+
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var rotated apikey.APIKey
+		exists, err := sess.Where("id=? AND org_id=? AND service_account_id=?", tokenId, cmd.OrgId, serviceAccountId).Get(&rotated)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrServiceAccountTokenNotFound
+		}
+
+		now := time.Now()
+		// an expired token must not be revived by its replacement
+		if rotated.Expires != nil && *rotated.Expires <= now.Unix() {
+			return fmt.Errorf("%w: the token has expired", ErrInvalidTokenExpiration)
+		}
+
+		var expires *int64 = nil
+		if cmd.SecondsToLive > 0 {
+			v := now.Add(time.Second * time.Duration(cmd.SecondsToLive)).Unix()
+			expires = &v
+		} else if rotated.Expires != nil {
+			v := now.Unix() + *rotated.Expires - rotated.Created.Unix()
+			expires = &v
+		}
+
+		graceEnd := now.Add(time.Second * time.Duration(cmd.GracePeriodSeconds)).Unix()
+		if rotated.Expires == nil || *rotated.Expires > graceEnd {
+			rotated.Expires = &graceEnd
+		}
+		name := rotated.Name
+		rotated.Name = fmt.Sprintf("%s-rotated-%d", name, rotated.Id)
+		rotated.Updated = now
+		if _, err := sess.ID(rotated.Id).Cols("name", "expires", "updated").Update(&rotated); err != nil {
+			return err
+		}
+
+		token := apikey.APIKey{
+			OrgId:            cmd.OrgId,
+			Name:             name,
+			Role:             org.RoleViewer,
+			Key:              cmd.Key,
+			Created:          now,
+			Updated:          now,
+			Expires:          expires,
+			LastUsedAt:       nil,
+			ServiceAccountId: &serviceAccountId,
+		}
+
+		if _, err := sess.Insert(&token); err != nil {
+			return err
+		}
+		cmd.Result = &token
+		return nil
+	})
+}
+
+// GetExpiringTokens returns the tokens of all organizations expiring after from and at the latest at to.
+func (s *ServiceAccountsStoreImpl) GetExpiringTokens(ctx context.Context, from, to time.Time) ([]*serviceaccounts.ServiceAccountToken, error) {
+	return s.findTokens(ctx, "api_key.expires > ? AND api_key.expires <= ?", from.Unix(), to.Unix())
+}
+
+// GetUnusedTokens returns the valid tokens of all organizations last used, or created when never used,
+// after from and at the latest at to.
+func (s *ServiceAccountsStoreImpl) GetUnusedTokens(ctx context.Context, from, to time.Time) ([]*serviceaccounts.ServiceAccountToken, error) {
+	return s.findTokens(ctx,
+		"COALESCE(api_key.last_used_at, api_key.created) > ? AND COALESCE(api_key.last_used_at, api_key.created) <= ? AND (api_key.expires IS NULL OR api_key.expires > ?)",
+		from, to, time.Now().Unix())
+}
+
+func (s *ServiceAccountsStoreImpl) findTokens(ctx context.Context, where string, args ...interface{}) ([]*serviceaccounts.ServiceAccountToken, error) {
+	result := make([]*serviceaccounts.ServiceAccountToken, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *sqlstore.DBSession) error {
+		quotedUser := s.sqlStore.Dialect.Quote("user")
+		return dbSession.Table("api_key").
+			Select("api_key.*, "+quotedUser+".name AS service_account_name").
+			Join("inner", quotedUser, quotedUser+".id = api_key.service_account_id").
+			Where(where, args...).
+			Asc("api_key.org_id", "api_key.name").
+			Find(&result)
+	})
+	return result, err
+}
+
 func (s *ServiceAccountsStoreImpl) DeleteServiceAccountToken(ctx context.Context, orgId, serviceAccountId, tokenId int64) error {
 	rawSQL := "DELETE FROM api_key WHERE id=? and org_id=? and service_account_id=?"
 
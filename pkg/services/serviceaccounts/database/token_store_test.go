@@ -2,12 +2,16 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/components/apikeygen"
 	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/stretchr/testify/require"
 )
 
@@ -133,3 +137,169 @@ func TestStore_DeleteServiceAccountToken(t *testing.T) {
 		}
 	}
 }
+
+func TestStore_RotateServiceAccountToken(t *testing.T) {
+	saToCreate := tests.TestUser{Login: "servicetestwithTeam@admin", IsServiceAccount: true}
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, saToCreate)
+
+	addToken := func(t *testing.T, name string, secondsToLive int64) *apikey.APIKey {
+		key, err := apikeygen.New(sa.OrgID, name)
+		require.NoError(t, err)
+		cmd := serviceaccounts.AddServiceAccountTokenCommand{
+			Name:          name,
+			OrgId:         sa.OrgID,
+			Key:           key.HashedKey,
+			SecondsToLive: secondsToLive,
+		}
+		require.NoError(t, store.AddServiceAccountToken(context.Background(), sa.ID, &cmd))
+		return cmd.Result
+	}
+
+	rotate := func(t *testing.T, token *apikey.APIKey, cmd serviceaccounts.RotateServiceAccountTokenCommand) error {
+		key, err := apikeygen.New(sa.OrgID, token.Name)
+		require.NoError(t, err)
+		cmd.OrgId = sa.OrgID
+		cmd.Key = key.HashedKey
+		err = store.RotateServiceAccountToken(context.Background(), sa.ID, token.Id, &cmd)
+		if err == nil {
+			require.Equal(t, key.HashedKey, cmd.Result.Key)
+		}
+		return err
+	}
+
+	getToken := func(t *testing.T, name string) *apikey.APIKey {
+		tokens, err := store.ListTokens(context.Background(), sa.OrgID, sa.ID)
+		require.NoError(t, err)
+		for _, token := range tokens {
+			if token.Name == name {
+				return token
+			}
+		}
+		t.Fatalf("token %s not found", name)
+		return nil
+	}
+
+	t.Run("should keep the rotated token valid for the grace period", func(t *testing.T) {
+		token := addToken(t, "no-expiry", 0)
+		before := time.Now().Unix()
+
+		require.NoError(t, rotate(t, token, serviceaccounts.RotateServiceAccountTokenCommand{GracePeriodSeconds: 3600}))
+
+		rotated := getToken(t, fmt.Sprintf("no-expiry-rotated-%d", token.Id))
+		require.NotNil(t, rotated.Expires)
+		require.InDelta(t, before+3600, *rotated.Expires, 5)
+
+		replacement := getToken(t, "no-expiry")
+		require.NotEqual(t, token.Id, replacement.Id)
+		require.Nil(t, replacement.Expires)
+	})
+
+	t.Run("should give the new token the lifetime of the rotated token", func(t *testing.T) {
+		token := addToken(t, "expiring", 60)
+
+		require.NoError(t, rotate(t, token, serviceaccounts.RotateServiceAccountTokenCommand{GracePeriodSeconds: 3600}))
+
+		// the grace period does not extend the rotated token
+		rotated := getToken(t, fmt.Sprintf("expiring-rotated-%d", token.Id))
+		require.Equal(t, *token.Expires, *rotated.Expires)
+
+		replacement := getToken(t, "expiring")
+		require.NotNil(t, replacement.Expires)
+		require.InDelta(t, time.Now().Unix()+60, *replacement.Expires, 5)
+	})
+
+	t.Run("should set the lifetime of the new token", func(t *testing.T) {
+		token := addToken(t, "lifetime", 60)
+
+		require.NoError(t, rotate(t, token, serviceaccounts.RotateServiceAccountTokenCommand{SecondsToLive: 7200}))
+
+		replacement := getToken(t, "lifetime")
+		require.InDelta(t, time.Now().Unix()+7200, *replacement.Expires, 5)
+	})
+
+	t.Run("should fail for an invalid grace period", func(t *testing.T) {
+		token := addToken(t, "invalid", 0)
+
+		err := rotate(t, token, serviceaccounts.RotateServiceAccountTokenCommand{GracePeriodSeconds: -1})
+		require.ErrorIs(t, err, ErrInvalidTokenExpiration)
+	})
+
+	t.Run("should fail for an expired token", func(t *testing.T) {
+		token := addToken(t, "expired", 60)
+		err := db.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			_, err := sess.Exec("UPDATE api_key SET expires = ? WHERE id = ?", time.Now().Add(-time.Minute).Unix(), token.Id)
+			return err
+		})
+		require.NoError(t, err)
+
+		err = rotate(t, token, serviceaccounts.RotateServiceAccountTokenCommand{GracePeriodSeconds: 3600})
+		require.ErrorIs(t, err, ErrInvalidTokenExpiration)
+		require.Equal(t, token.Id, getToken(t, "expired").Id)
+	})
+
+	t.Run("should fail for a token of another service account", func(t *testing.T) {
+		token := addToken(t, "other", 0)
+		token.Id = token.Id + 1000
+
+		err := rotate(t, token, serviceaccounts.RotateServiceAccountTokenCommand{})
+		require.ErrorIs(t, err, ErrServiceAccountTokenNotFound)
+	})
+}
+
+func TestStore_GetExpiringAndUnusedTokens(t *testing.T) {
+	saToCreate := tests.TestUser{Name: "ci-robot", Login: "sa-ci-robot", IsServiceAccount: true}
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, saToCreate)
+
+	now := time.Now()
+	addToken := func(name string, expires *int64, created time.Time, lastUsed *time.Time) {
+		err := db.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			_, err := sess.Insert(&apikey.APIKey{
+				OrgId:            sa.OrgID,
+				Name:             name,
+				Role:             org.RoleViewer,
+				Key:              name,
+				Created:          created,
+				Updated:          created,
+				Expires:          expires,
+				LastUsedAt:       lastUsed,
+				ServiceAccountId: &sa.ID,
+			})
+			return err
+		})
+		require.NoError(t, err)
+	}
+	unix := func(d time.Duration) *int64 {
+		v := now.Add(d).Unix()
+		return &v
+	}
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+
+	addToken("expiring", unix(2*time.Hour), now.Add(-time.Hour), nil)
+	addToken("expiring-later", unix(48*time.Hour), now.Add(-time.Hour), nil)
+	addToken("expired", unix(-time.Hour), now.Add(-100*time.Hour), nil)
+	addToken("unused", nil, now.Add(-100*time.Hour), at(-30*time.Hour))
+	addToken("never-used", nil, now.Add(-30*time.Hour), nil)
+	addToken("used", nil, now.Add(-100*time.Hour), at(-time.Hour))
+
+	names := func(tokens []*serviceaccounts.ServiceAccountToken) []string {
+		result := []string{}
+		for _, token := range tokens {
+			require.Equal(t, saToCreate.Name, token.ServiceAccountName)
+			result = append(result, token.Name)
+		}
+		return result
+	}
+
+	expiring, err := store.GetExpiringTokens(context.Background(), now, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"expiring"}, names(expiring))
+
+	unused, err := store.GetUnusedTokens(context.Background(), now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"never-used", "unused"}, names(unused))
+}
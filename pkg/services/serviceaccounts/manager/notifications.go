@@ -0,0 +1,173 @@
+package manager
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+const (
+	tokenNotificationsEmailTemplate = "service_account_tokens"
+	// tokenNotificationsInterval is how often org admins are told about expiring and unused tokens.
+	tokenNotificationsInterval = 24 * time.Hour
+	// tokenNotificationsLastRunKey stores when tokens were last checked, so that each token is only notified once.
+	tokenNotificationsLastRunKey = "tokenNotificationsLastRun"
+)
+
+type orgTokenNotifications struct {
+	expiring []*serviceaccounts.ServiceAccountToken
+	unused   []*serviceaccounts.ServiceAccountToken
+}
+
+func (sa *ServiceAccountsService) tokenNotificationsEnabled() bool {
+	settings := sa.cfg.ServiceAccounts
+	return sa.cfg.Smtp.Enabled && (settings.TokenExpiryNoticePeriod > 0 || settings.TokenUnusedPeriod > 0)
+}
+
+func (sa *ServiceAccountsService) runTokenNotifications(ctx context.Context) error {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		// the lock makes sure a single instance checks the tokens once per interval
+		err := sa.serverLock.LockAndExecute(ctx, "service account token notifications", tokenNotificationsInterval, func(ctx context.Context) {
+			if err := sa.notifyTokens(ctx, time.Now()); err != nil {
+				sa.log.Error("Failed to notify about service account tokens", "error", err)
+			}
+		})
+		if err != nil {
+			sa.log.Error("Failed to lock and execute service account token notifications", "error", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// notifyTokens emails the org admins about the tokens which entered the expiry notice period,
+// or have not been used for the unused period, since the previous check.
+func (sa *ServiceAccountsService) notifyTokens(ctx context.Context, now time.Time) error {
+	// on the first check, every token already in the notice or unused period is notified
+	var lastRun time.Time
+	value, exists, err := sa.kvStore.Get(ctx, 0, "serviceaccounts", tokenNotificationsLastRunKey)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+			lastRun = time.Unix(unix, 0)
+		}
+	}
+
+	byOrg := map[int64]*orgTokenNotifications{}
+	notificationsOf := func(orgID int64) *orgTokenNotifications {
+		if _, ok := byOrg[orgID]; !ok {
+			byOrg[orgID] = &orgTokenNotifications{}
+		}
+		return byOrg[orgID]
+	}
+
+	settings := sa.cfg.ServiceAccounts
+	if settings.TokenExpiryNoticePeriod > 0 {
+		from := lastRun.Add(settings.TokenExpiryNoticePeriod)
+		if from.Before(now) {
+			from = now
+		}
+		tokens, err := sa.store.GetExpiringTokens(ctx, from, now.Add(settings.TokenExpiryNoticePeriod))
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			n := notificationsOf(token.OrgId)
+			n.expiring = append(n.expiring, token)
+		}
+	}
+	if settings.TokenUnusedPeriod > 0 {
+		from := time.Time{}
+		if !lastRun.IsZero() {
+			from = lastRun.Add(-settings.TokenUnusedPeriod)
+		}
+		tokens, err := sa.store.GetUnusedTokens(ctx, from, now.Add(-settings.TokenUnusedPeriod))
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			n := notificationsOf(token.OrgId)
+			n.unused = append(n.unused, token)
+		}
+	}
+
+	for orgID, n := range byOrg {
+		if err := sa.sendTokenNotifications(ctx, orgID, n); err != nil {
+			sa.log.Error("Failed to send service account token notifications", "orgId", orgID, "error", err)
+		}
+	}
+
+	return sa.kvStore.Set(ctx, 0, "serviceaccounts", tokenNotificationsLastRunKey, strconv.FormatInt(now.Unix(), 10))
+}
+
+func (sa *ServiceAccountsService) sendTokenNotifications(ctx context.Context, orgID int64, n *orgTokenNotifications) error {
+	usersQuery := models.GetOrgUsersQuery{
+		OrgId:                    orgID,
+		User:                     &user.SignedInUser{OrgId: orgID, OrgRole: org.RoleAdmin},
+		DontEnforceAccessControl: true,
+	}
+	if err := sa.sqlStore.GetOrgUsers(ctx, &usersQuery); err != nil {
+		return err
+	}
+	emails := []string{}
+	for _, orgUser := range usersQuery.Result {
+		if orgUser.Role == string(org.RoleAdmin) && orgUser.Email != "" {
+			emails = append(emails, orgUser.Email)
+		}
+	}
+	if len(emails) == 0 {
+		sa.log.Debug("No org admin to notify about service account tokens", "orgId", orgID)
+		return nil
+	}
+
+	orgQuery := models.GetOrgByIdQuery{Id: orgID}
+	if err := sa.sqlStore.GetOrgById(ctx, &orgQuery); err != nil {
+		return err
+	}
+
+	expiring := make([]map[string]string, 0, len(n.expiring))
+	for _, token := range n.expiring {
+		expiring = append(expiring, map[string]string{
+			"ServiceAccount": token.ServiceAccountName,
+			"Token":          token.Name,
+			"Expires":        time.Unix(*token.Expires, 0).UTC().Format(time.RFC1123),
+		})
+	}
+	unused := make([]map[string]string, 0, len(n.unused))
+	for _, token := range n.unused {
+		lastUsed := "never"
+		if token.LastUsedAt != nil {
+			lastUsed = token.LastUsedAt.UTC().Format(time.RFC1123)
+		}
+		unused = append(unused, map[string]string{
+			"ServiceAccount": token.ServiceAccountName,
+			"Token":          token.Name,
+			"LastUsed":       lastUsed,
+		})
+	}
+
+	return sa.emailSender.SendEmailCommandHandler(ctx, &models.SendEmailCommand{
+		To:       emails,
+		Template: tokenNotificationsEmailTemplate,
+		Data: map[string]interface{}{
+			"OrgName":    orgQuery.Result.Name,
+			"Expiring":   expiring,
+			"Unused":     unused,
+			"UnusedDays": int(sa.cfg.ServiceAccounts.TokenUnusedPeriod.Hours() / 24),
+		},
+	})
+}
@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/apikey/apikeyimpl"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/database"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestServiceAccountsService_NotifyTokens(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	// put the org admin and the service account in the same org
+	sqlStore.Cfg.AutoAssignOrg = true
+	sqlStore.Cfg.AutoAssignOrgId = 1
+	kvStore := kvstore.ProvideService(sqlStore)
+	saStore := database.ProvideServiceAccountsStore(sqlStore, apikeyimpl.ProvideService(sqlStore, sqlStore.Cfg), kvStore)
+
+	_, err := sqlStore.CreateUser(context.Background(), user.CreateUserCommand{
+		Login:          "admin",
+		Email:          "admin@example.com",
+		DefaultOrgRole: string(org.RoleAdmin),
+	})
+	require.NoError(t, err)
+	sa := tests.SetupUserServiceAccount(t, sqlStore, tests.TestUser{Name: "ci-robot", Login: "sa-ci-robot", IsServiceAccount: true})
+
+	now := time.Now()
+	addToken := func(name string, expires *int64, created time.Time) {
+		err := sqlStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+			_, err := sess.Insert(&apikey.APIKey{
+				OrgId:            sa.OrgID,
+				Name:             name,
+				Role:             org.RoleViewer,
+				Key:              name,
+				Created:          created,
+				Updated:          created,
+				Expires:          expires,
+				ServiceAccountId: &sa.ID,
+			})
+			return err
+		})
+		require.NoError(t, err)
+	}
+	expires := now.Add(48 * time.Hour).Unix()
+	addToken("expiring", &expires, now.Add(-time.Hour))
+	addToken("unused", nil, now.Add(-90*24*time.Hour-time.Hour))
+	addToken("used", nil, now.Add(-time.Hour))
+
+	cfg := sqlStore.Cfg
+	cfg.ServiceAccounts.TokenExpiryNoticePeriod = 7 * 24 * time.Hour
+	cfg.ServiceAccounts.TokenUnusedPeriod = 90 * 24 * time.Hour
+
+	emailSender := &notifications.NotificationServiceMock{}
+	svc := ServiceAccountsService{
+		cfg:         cfg,
+		store:       saStore,
+		sqlStore:    sqlStore,
+		kvStore:     kvStore,
+		emailSender: emailSender,
+		log:         log.New("serviceaccounts.manager.test"),
+	}
+
+	t.Run("should email the org admins about expiring and unused tokens", func(t *testing.T) {
+		require.NoError(t, svc.notifyTokens(context.Background(), now))
+
+		assert.Equal(t, []string{"admin@example.com"}, emailSender.Email.To)
+		assert.Equal(t, tokenNotificationsEmailTemplate, emailSender.Email.Template)
+		assert.Equal(t, 90, emailSender.Email.Data["UnusedDays"])
+
+		expiring := emailSender.Email.Data["Expiring"].([]map[string]string)
+		require.Len(t, expiring, 1)
+		assert.Equal(t, "ci-robot", expiring[0]["ServiceAccount"])
+		assert.Equal(t, "expiring", expiring[0]["Token"])
+
+		unused := emailSender.Email.Data["Unused"].([]map[string]string)
+		require.Len(t, unused, 1)
+		assert.Equal(t, "unused", unused[0]["Token"])
+		assert.Equal(t, "never", unused[0]["LastUsed"])
+	})
+
+	t.Run("should not email about the same tokens twice", func(t *testing.T) {
+		emailSender.Email = models.SendEmailCommand{}
+
+		require.NoError(t, svc.notifyTokens(context.Background(), now.Add(24*time.Hour)))
+
+		assert.Empty(t, emailSender.Email.To)
+	})
+}
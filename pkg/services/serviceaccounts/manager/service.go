@@ -3,19 +3,30 @@ package manager
 import (
 	"context"
 
+	"golang.org/x/sync/errgroup"
+
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/api"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/database"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
 type ServiceAccountsService struct {
-	store serviceaccounts.Store
-	log   log.Logger
+	cfg         *setting.Cfg
+	store       serviceaccounts.Store
+	sqlStore    sqlstore.Store
+	kvStore     kvstore.KVStore
+	serverLock  *serverlock.ServerLockService
+	emailSender notifications.EmailSender
+	log         log.Logger
 }
 
 func ProvideServiceAccountsService(
@@ -25,11 +36,20 @@ func ProvideServiceAccountsService(
 	usageStats usagestats.Service,
 	serviceAccountsStore serviceaccounts.Store,
 	permissionService accesscontrol.ServiceAccountPermissionsService,
+	sqlStore sqlstore.Store,
+	kvStore kvstore.KVStore,
+	serverLock *serverlock.ServerLockService,
+	emailSender notifications.EmailSender,
 ) (*ServiceAccountsService, error) {
 	database.InitMetrics()
 	s := &ServiceAccountsService{
-		store: serviceAccountsStore,
-		log:   log.New("serviceaccounts"),
+		cfg:         cfg,
+		store:       serviceAccountsStore,
+		sqlStore:    sqlStore,
+		kvStore:     kvStore,
+		serverLock:  serverLock,
+		emailSender: emailSender,
+		log:         log.New("serviceaccounts"),
 	}
 
 	if err := RegisterRoles(ac); err != nil {
@@ -45,8 +65,17 @@ func ProvideServiceAccountsService(
 }
 
 func (sa *ServiceAccountsService) Run(ctx context.Context) error {
+	g, ctx := errgroup.WithContext(ctx)
+
 	sa.log.Debug("Started Service Account Metrics collection service")
-	return sa.store.RunMetricsCollection(ctx)
+	g.Go(func() error { return sa.store.RunMetricsCollection(ctx) })
+
+	if sa.tokenNotificationsEnabled() {
+		sa.log.Debug("Started Service Account token notifications service")
+		g.Go(func() error { return sa.runTokenNotifications(ctx) })
+	}
+
+	return g.Wait()
 }
 
 func (sa *ServiceAccountsService) CreateServiceAccount(ctx context.Context, orgID int64, saForm *serviceaccounts.CreateServiceAccountForm) (*serviceaccounts.ServiceAccountDTO, error) {
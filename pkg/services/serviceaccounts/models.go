@@ -72,6 +72,23 @@ type AddServiceAccountTokenCommand struct {
 	Result        *apikey.APIKey `json:"-"`
 }
 
+// swagger:model
+type RotateServiceAccountTokenCommand struct {
+	// Seconds the rotated token stays valid for, the token_rotation_grace_period setting by default
+	GracePeriodSeconds int64 `json:"gracePeriodSeconds"`
+	// Seconds to live of the new token, the lifetime of the rotated token by default
+	SecondsToLive int64          `json:"secondsToLive"`
+	OrgId         int64          `json:"-"`
+	Key           string         `json:"-"`
+	Result        *apikey.APIKey `json:"-"`
+}
+
+// ServiceAccountToken is a token along with the name of its service account.
+type ServiceAccountToken struct {
+	apikey.APIKey      `xorm:"extends"`
+	ServiceAccountName string `xorm:"service_account_name"`
+}
+
 // swagger: model
 type SearchServiceAccountsResult struct {
 	// It can be used for pagination of the user list
@@ -2,6 +2,7 @@ package serviceaccounts
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/apikey"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -31,6 +32,9 @@ type Store interface {
 	ListTokens(ctx context.Context, orgID int64, serviceAccount int64) ([]*apikey.APIKey, error)
 	DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64) error
 	AddServiceAccountToken(ctx context.Context, serviceAccountID int64, cmd *AddServiceAccountTokenCommand) error
+	RotateServiceAccountToken(ctx context.Context, serviceAccountID, tokenID int64, cmd *RotateServiceAccountTokenCommand) error
+	GetExpiringTokens(ctx context.Context, from, to time.Time) ([]*ServiceAccountToken, error)
+	GetUnusedTokens(ctx context.Context, from, to time.Time) ([]*ServiceAccountToken, error)
 	GetUsageMetrics(ctx context.Context) (map[string]interface{}, error)
 	RunMetricsCollection(ctx context.Context) error
 }
@@ -15,6 +15,7 @@ func (ss *SQLStore) CreateLoginAttempt(ctx context.Context, cmd *models.CreateLo
 		loginAttempt := models.LoginAttempt{
 			Username:  cmd.Username,
 			IpAddress: cmd.IpAddress,
+			IpSubnet:  cmd.IpSubnet,
 			Created:   getTimeNow().Unix(),
 		}
 
@@ -75,6 +76,131 @@ func (ss *SQLStore) GetUserLoginAttemptCount(ctx context.Context, query *models.
 	})
 }
 
+func (ss *SQLStore) GetIPLoginAttemptCount(ctx context.Context, query *models.GetIPLoginAttemptCountQuery) error {
+	return ss.WithDbSession(ctx, func(dbSession *DBSession) error {
+		total, err := dbSession.
+			Where("ip_subnet = ?", query.IpSubnet).
+			And("created >= ?", query.Since.Unix()).
+			Count(new(models.LoginAttempt))
+
+		if err != nil {
+			return err
+		}
+
+		query.Result = total
+		return nil
+	})
+}
+
+func (ss *SQLStore) ResetUserLoginAttempts(ctx context.Context, cmd *models.ResetUserLoginAttemptsCommand) error {
+	if len(cmd.Usernames) == 0 {
+		return nil
+	}
+
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		if _, err := sess.In("username", cmd.Usernames).Delete(new(models.LoginAttempt)); err != nil {
+			return err
+		}
+
+		_, err := sess.Where("kind = ?", models.LoginLockoutUser).In("source", cmd.Usernames).Delete(new(models.LoginLockout))
+		return err
+	})
+}
+
+func (ss *SQLStore) GetLoginLockout(ctx context.Context, query *models.GetLoginLockoutQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		lockout := models.LoginLockout{}
+		has, err := sess.Where("kind = ? AND source = ?", query.Kind, query.Source).Get(&lockout)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrLoginLockoutNotFound
+		}
+
+		query.Result = &lockout
+		return nil
+	})
+}
+
+func (ss *SQLStore) SaveLoginLockout(ctx context.Context, cmd *models.SaveLoginLockoutCommand) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		now := getTimeNow()
+		lockout := models.LoginLockout{}
+		has, err := sess.Where("kind = ? AND source = ?", cmd.Kind, cmd.Source).Get(&lockout)
+		if err != nil {
+			return err
+		}
+
+		lockout.Lockouts = cmd.Lockouts
+		lockout.LockedUntil = cmd.LockedUntil
+		lockout.Updated = now
+
+		if has {
+			if _, err := sess.ID(lockout.Id).Cols("lockouts", "locked_until", "updated").Update(&lockout); err != nil {
+				return err
+			}
+		} else {
+			lockout.Kind = cmd.Kind
+			lockout.Source = cmd.Source
+			lockout.Created = now
+			if _, err := sess.Insert(&lockout); err != nil {
+				return err
+			}
+		}
+
+		cmd.Result = &lockout
+		return nil
+	})
+}
+
+func (ss *SQLStore) SearchLoginLockouts(ctx context.Context, query *models.SearchLoginLockoutsQuery) error {
+	return ss.WithDbSession(ctx, func(sess *DBSession) error {
+		query.Result = make([]*models.LoginLockout, 0)
+		if !query.ActiveAt.IsZero() {
+			sess.Where("locked_until > ?", query.ActiveAt)
+		}
+		return sess.Desc("locked_until").Find(&query.Result)
+	})
+}
+
+// DeleteLoginLockout deletes a lockout with the login attempts of its source, which would
+// otherwise lock the source out again on its next login.
+func (ss *SQLStore) DeleteLoginLockout(ctx context.Context, cmd *models.DeleteLoginLockoutCommand) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		lockout := models.LoginLockout{}
+		has, err := sess.ID(cmd.Id).Get(&lockout)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrLoginLockoutNotFound
+		}
+
+		sourceColumn := "username"
+		if lockout.Kind == models.LoginLockoutIP {
+			sourceColumn = "ip_subnet"
+		}
+		if _, err := sess.Where(sourceColumn+" = ?", lockout.Source).Delete(new(models.LoginAttempt)); err != nil {
+			return err
+		}
+
+		_, err = sess.ID(lockout.Id).Delete(new(models.LoginLockout))
+		return err
+	})
+}
+
+func (ss *SQLStore) DeleteExpiredLoginLockouts(ctx context.Context, cmd *models.DeleteExpiredLoginLockoutsCommand) error {
+	return ss.WithTransactionalDbSession(ctx, func(sess *DBSession) error {
+		affected, err := sess.Where("locked_until < ?", cmd.OlderThan).Delete(new(models.LoginLockout))
+		if err != nil {
+			return err
+		}
+		cmd.DeletedRows = affected
+		return nil
+	})
+}
+
 func toInt64(i interface{}) int64 {
 	switch i := i.(type) {
 	case []byte:
@@ -133,3 +133,93 @@ func TestIntegrationLoginAttempts(t *testing.T) {
 		require.Equal(t, int64(3), cmd.DeletedRows)
 	})
 }
+
+func TestIntegrationLoginLockouts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := InitTestDB(t)
+	ctx := context.Background()
+	now := mockTime(time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC))
+	t.Cleanup(func() { getTimeNow = time.Now })
+
+	for _, username := range []string{"user", "user@example.com", "other"} {
+		err := sqlStore.CreateLoginAttempt(ctx, &models.CreateLoginAttemptCommand{
+			Username:  username,
+			IpAddress: "192.168.0.1:1234",
+			IpSubnet:  "192.168.0.0/24",
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("Should count login attempts by IP subnet", func(t *testing.T) {
+		query := models.GetIPLoginAttemptCountQuery{IpSubnet: "192.168.0.0/24", Since: now.Add(-time.Minute)}
+		require.NoError(t, sqlStore.GetIPLoginAttemptCount(ctx, &query))
+		require.Equal(t, int64(3), query.Result)
+	})
+
+	t.Run("Should create and update lockouts", func(t *testing.T) {
+		query := models.GetLoginLockoutQuery{Kind: models.LoginLockoutUser, Source: "user"}
+		require.ErrorIs(t, sqlStore.GetLoginLockout(ctx, &query), models.ErrLoginLockoutNotFound)
+
+		cmd := models.SaveLoginLockoutCommand{Kind: models.LoginLockoutUser, Source: "user", Lockouts: 1, LockedUntil: now.Add(time.Minute)}
+		require.NoError(t, sqlStore.SaveLoginLockout(ctx, &cmd))
+		cmd = models.SaveLoginLockoutCommand{Kind: models.LoginLockoutUser, Source: "user", Lockouts: 2, LockedUntil: now.Add(time.Hour)}
+		require.NoError(t, sqlStore.SaveLoginLockout(ctx, &cmd))
+
+		require.NoError(t, sqlStore.GetLoginLockout(ctx, &query))
+		require.Equal(t, cmd.Result.Id, query.Result.Id)
+		require.Equal(t, int64(2), query.Result.Lockouts)
+		require.True(t, query.Result.IsActive(now))
+	})
+
+	t.Run("Should only search active lockouts", func(t *testing.T) {
+		cmd := models.SaveLoginLockoutCommand{Kind: models.LoginLockoutIP, Source: "192.168.0.0/24", Lockouts: 1, LockedUntil: now.Add(-time.Minute)}
+		require.NoError(t, sqlStore.SaveLoginLockout(ctx, &cmd))
+
+		query := models.SearchLoginLockoutsQuery{ActiveAt: now}
+		require.NoError(t, sqlStore.SearchLoginLockouts(ctx, &query))
+		require.Len(t, query.Result, 1)
+		require.Equal(t, "user", query.Result[0].Source)
+
+		query = models.SearchLoginLockoutsQuery{}
+		require.NoError(t, sqlStore.SearchLoginLockouts(ctx, &query))
+		require.Len(t, query.Result, 2)
+	})
+
+	t.Run("Should delete expired lockouts", func(t *testing.T) {
+		cmd := models.DeleteExpiredLoginLockoutsCommand{OlderThan: now}
+		require.NoError(t, sqlStore.DeleteExpiredLoginLockouts(ctx, &cmd))
+		require.Equal(t, int64(1), cmd.DeletedRows)
+	})
+
+	t.Run("Should reset the login attempts and lockouts of a user", func(t *testing.T) {
+		err := sqlStore.ResetUserLoginAttempts(ctx, &models.ResetUserLoginAttemptsCommand{Usernames: []string{"user", "user@example.com"}})
+		require.NoError(t, err)
+
+		query := models.GetLoginLockoutQuery{Kind: models.LoginLockoutUser, Source: "user"}
+		require.ErrorIs(t, sqlStore.GetLoginLockout(ctx, &query), models.ErrLoginLockoutNotFound)
+
+		countQuery := models.GetIPLoginAttemptCountQuery{IpSubnet: "192.168.0.0/24", Since: now.Add(-time.Minute)}
+		require.NoError(t, sqlStore.GetIPLoginAttemptCount(ctx, &countQuery))
+		require.Equal(t, int64(1), countQuery.Result)
+	})
+
+	t.Run("Should delete a lockout with the login attempts of its source", func(t *testing.T) {
+		cmd := models.SaveLoginLockoutCommand{Kind: models.LoginLockoutIP, Source: "192.168.0.0/24", Lockouts: 1, LockedUntil: now.Add(time.Hour)}
+		require.NoError(t, sqlStore.SaveLoginLockout(ctx, &cmd))
+
+		require.NoError(t, sqlStore.DeleteLoginLockout(ctx, &models.DeleteLoginLockoutCommand{Id: cmd.Result.Id}))
+
+		query := models.GetLoginLockoutQuery{Kind: models.LoginLockoutIP, Source: "192.168.0.0/24"}
+		require.ErrorIs(t, sqlStore.GetLoginLockout(ctx, &query), models.ErrLoginLockoutNotFound)
+		countQuery := models.GetIPLoginAttemptCountQuery{IpSubnet: "192.168.0.0/24", Since: now.Add(-time.Minute)}
+		require.NoError(t, sqlStore.GetIPLoginAttemptCount(ctx, &countQuery))
+		require.Equal(t, int64(0), countQuery.Result)
+	})
+
+	t.Run("Should return not found when deleting a missing lockout", func(t *testing.T) {
+		err := sqlStore.DeleteLoginLockout(ctx, &models.DeleteLoginLockoutCommand{Id: 1000})
+		require.ErrorIs(t, err, models.ErrLoginLockoutNotFound)
+	})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addAuditEventMigrations(mg *Migrator) {
+	auditEventV1 := Table{
+		Name: "audit_event",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "actor_type", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "actor_id", Type: DB_BigInt, Nullable: false},
+			{Name: "actor_login", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "category", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "resource_uid", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "method", Type: DB_NVarchar, Length: 10, Nullable: false},
+			{Name: "path", Type: DB_Text, Nullable: false},
+			{Name: "ip", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "result", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "status_code", Type: DB_Int, Nullable: false},
+			{Name: "diff", Type: DB_Text, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "created"}},
+			{Cols: []string{"created"}},
+		},
+	}
+
+	mg.AddMigration("create audit_event table v1", NewAddTableMigration(auditEventV1))
+	mg.AddMigration("add index audit_event.org_id-created", NewAddIndexMigration(auditEventV1, auditEventV1.Indices[0]))
+	mg.AddMigration("add index audit_event.created", NewAddIndexMigration(auditEventV1, auditEventV1.Indices[1]))
+}
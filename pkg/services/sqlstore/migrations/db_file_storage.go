@@ -64,4 +64,25 @@ func addDbFileStorageMigration(mg *migrator.Migrator) {
 		// MySQL `utf8mb4_unicode_ci` collation is set in `mysql_dialect.go`
 		// SQLite uses a `BINARY` collation by default
 		Postgres("ALTER TABLE file ALTER COLUMN path TYPE VARCHAR(1024) COLLATE \"C\";")) // Collate C - sorting done based on character code byte values
+
+	// large files are split into chunks to avoid buffering them in memory and hitting max blob size limits.
+	// `chunk_count` of 0 means that the contents are stored inline in the `contents` column
+	mg.AddMigration("add chunk_count column to file table", migrator.NewAddColumnMigration(filesTable, &migrator.Column{
+		Name: "chunk_count", Type: migrator.DB_Int, Nullable: false, Default: "0",
+	}))
+
+	fileChunkTable := migrator.Table{
+		Name: "file_chunk",
+		Columns: []*migrator.Column{
+			{Name: "path_hash", Type: migrator.DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "chunk_index", Type: migrator.DB_Int, Nullable: false},
+			{Name: "contents", Type: migrator.DB_Blob, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"path_hash", "chunk_index"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create file_chunk table", migrator.NewAddTableMigration(fileChunkTable))
+	mg.AddMigration("file_chunk table idx: path_hash chunk_index", migrator.NewAddIndexMigration(fileChunkTable, fileChunkTable.Indices[0]))
 }
@@ -39,4 +39,31 @@ func addLoginAttemptMigrations(mg *Migrator) {
 		"username":   "username",
 		"ip_address": "ip_address",
 	})
+
+	mg.AddMigration("add column ip_subnet to login_attempt", NewAddColumnMigration(loginAttemptV2, &Column{
+		Name: "ip_subnet", Type: DB_NVarchar, Length: 50, Nullable: true,
+	}))
+	mg.AddMigration("add index login_attempt.ip_subnet", NewAddIndexMigration(loginAttemptV2, &Index{
+		Cols: []string{"ip_subnet"},
+	}))
+
+	loginLockoutV1 := Table{
+		Name: "login_lockout",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "kind", Type: DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "source", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "lockouts", Type: DB_BigInt, Nullable: false},
+			{Name: "locked_until", Type: DB_DateTime, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"kind", "source"}, Type: UniqueIndex},
+			{Cols: []string{"locked_until"}},
+		},
+	}
+
+	mg.AddMigration("create login_lockout table", NewAddTableMigration(loginLockoutV1))
+	addTableIndicesMigrations(mg, "v1", loginLockoutV1)
 }
@@ -97,6 +97,14 @@ func (*OSSMigrations) AddMigration(mg *Migrator) {
 
 	ualert.UpdateRuleGroupIndexMigration(mg)
 	accesscontrol.AddManagedFolderAlertActionsRepeatMigration(mg)
+
+	addLivePipelineMigrations(mg)
+
+	addAuditEventMigrations(mg)
+
+	addTwoFactorMigrations(mg)
+
+	addSCIMMigrations(mg)
 }
 
 func addMigrationLogMigrations(mg *Migrator) {
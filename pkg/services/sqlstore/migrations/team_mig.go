@@ -60,4 +60,26 @@ func addTeamMigrations(mg *Migrator) {
 	mg.AddMigration("Add column permission to team_member table", NewAddColumnMigration(teamMemberV1, &Column{
 		Name: "permission", Type: DB_SmallInt, Nullable: true,
 	}))
+
+	teamGroupV1 := Table{
+		Name: "team_group",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt},
+			{Name: "team_id", Type: DB_BigInt},
+			{Name: "group_id", Type: DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id"}},
+			{Cols: []string{"org_id", "team_id", "group_id"}, Type: UniqueIndex},
+			{Cols: []string{"group_id"}},
+		},
+	}
+
+	mg.AddMigration("create team group table", NewAddTableMigration(teamGroupV1))
+	mg.AddMigration("add index team_group.org_id", NewAddIndexMigration(teamGroupV1, teamGroupV1.Indices[0]))
+	mg.AddMigration("add unique index team_group_org_id_team_id_group_id", NewAddIndexMigration(teamGroupV1, teamGroupV1.Indices[1]))
+	mg.AddMigration("add index team_group.group_id", NewAddIndexMigration(teamGroupV1, teamGroupV1.Indices[2]))
 }
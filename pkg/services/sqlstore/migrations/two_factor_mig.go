@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+func addTwoFactorMigrations(mg *Migrator) {
+	userTwoFactorV1 := Table{
+		Name: "user_two_factor",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "secret", Type: DB_Text, Nullable: false},
+			{Name: "salt", Type: DB_NVarchar, Length: 50, Nullable: false},
+			{Name: "recovery_codes", Type: DB_Text, Nullable: true},
+			{Name: "enabled", Type: DB_Bool, Nullable: false},
+			{Name: "last_used_counter", Type: DB_BigInt, Nullable: false},
+			{Name: "created", Type: DB_DateTime, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"user_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create user_two_factor table v1", NewAddTableMigration(userTwoFactorV1))
+	mg.AddMigration("add unique index user_two_factor.user_id", NewAddIndexMigration(userTwoFactorV1, userTwoFactorV1.Indices[0]))
+
+	orgTwoFactorPolicyV1 := Table{
+		Name: "org_two_factor_policy",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "required", Type: DB_Bool, Nullable: false},
+			{Name: "updated", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create org_two_factor_policy table v1", NewAddTableMigration(orgTwoFactorPolicyV1))
+	mg.AddMigration("add unique index org_two_factor_policy.org_id", NewAddIndexMigration(orgTwoFactorPolicyV1, orgTwoFactorPolicyV1.Indices[0]))
+
+	userTwoFactorChallengeV1 := Table{
+		Name: "user_two_factor_challenge",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "token", Type: DB_NVarchar, Length: 100, Nullable: false},
+			{Name: "user_id", Type: DB_BigInt, Nullable: false},
+			{Name: "enrollment", Type: DB_Bool, Nullable: false},
+			{Name: "attempts", Type: DB_Int, Nullable: false},
+			{Name: "expires", Type: DB_BigInt, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"token"}, Type: UniqueIndex},
+			{Cols: []string{"expires"}},
+		},
+	}
+
+	mg.AddMigration("create user_two_factor_challenge table v1", NewAddTableMigration(userTwoFactorChallengeV1))
+	addTableIndicesMigrations(mg, "v1", userTwoFactorChallengeV1)
+}
@@ -17,6 +17,7 @@ type OrgListResponse []struct {
 type SQLStoreMock struct {
 	LastGetAlertsQuery      *models.GetAlertsQuery
 	LastLoginAttemptCommand *models.CreateLoginAttemptCommand
+	LastResetLoginAttempts  *models.ResetUserLoginAttemptsCommand
 	LatestUserId            int64
 
 	ExpectedUser                   *user.User
@@ -38,6 +39,8 @@ type SQLStoreMock struct {
 	ExpectedSignedInUser           *user.SignedInUser
 	ExpectedUserStars              map[int64]bool
 	ExpectedLoginAttempts          int64
+	ExpectedIPLoginAttempts        int64
+	ExpectedLoginLockouts          []*models.LoginLockout
 
 	ExpectedError            error
 	ExpectedSetUsingOrgError error
@@ -129,6 +132,51 @@ func (m *SQLStoreMock) DeleteOldLoginAttempts(ctx context.Context, cmd *models.D
 	return m.ExpectedError
 }
 
+func (m *SQLStoreMock) GetIPLoginAttemptCount(ctx context.Context, query *models.GetIPLoginAttemptCountQuery) error {
+	query.Result = m.ExpectedIPLoginAttempts
+	return m.ExpectedError
+}
+
+func (m *SQLStoreMock) ResetUserLoginAttempts(ctx context.Context, cmd *models.ResetUserLoginAttemptsCommand) error {
+	m.LastResetLoginAttempts = cmd
+	return m.ExpectedError
+}
+
+func (m *SQLStoreMock) GetLoginLockout(ctx context.Context, query *models.GetLoginLockoutQuery) error {
+	for _, lockout := range m.ExpectedLoginLockouts {
+		if lockout.Kind == query.Kind && lockout.Source == query.Source {
+			query.Result = lockout
+			return m.ExpectedError
+		}
+	}
+	return models.ErrLoginLockoutNotFound
+}
+
+func (m *SQLStoreMock) SaveLoginLockout(ctx context.Context, cmd *models.SaveLoginLockoutCommand) error {
+	query := models.GetLoginLockoutQuery{Kind: cmd.Kind, Source: cmd.Source}
+	if err := m.GetLoginLockout(ctx, &query); err != nil {
+		query.Result = &models.LoginLockout{Kind: cmd.Kind, Source: cmd.Source}
+		m.ExpectedLoginLockouts = append(m.ExpectedLoginLockouts, query.Result)
+	}
+	cmd.Result = query.Result
+	cmd.Result.Lockouts = cmd.Lockouts
+	cmd.Result.LockedUntil = cmd.LockedUntil
+	return m.ExpectedError
+}
+
+func (m *SQLStoreMock) SearchLoginLockouts(ctx context.Context, query *models.SearchLoginLockoutsQuery) error {
+	query.Result = m.ExpectedLoginLockouts
+	return m.ExpectedError
+}
+
+func (m *SQLStoreMock) DeleteLoginLockout(ctx context.Context, cmd *models.DeleteLoginLockoutCommand) error {
+	return m.ExpectedError
+}
+
+func (m *SQLStoreMock) DeleteExpiredLoginLockouts(ctx context.Context, cmd *models.DeleteExpiredLoginLockoutsCommand) error {
+	return m.ExpectedError
+}
+
 func (m *SQLStoreMock) CreateUser(ctx context.Context, cmd user.CreateUserCommand) (*user.User, error) {
 	return nil, m.ExpectedError
 }
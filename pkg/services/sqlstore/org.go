@@ -234,8 +234,10 @@ func (ss *SQLStore) DeleteOrg(ctx context.Context, cmd *models.DeleteOrgCommand)
 			"DELETE FROM api_key WHERE org_id = ?",
 			"DELETE FROM data_source WHERE org_id = ?",
 			"DELETE FROM org_user WHERE org_id = ?",
+			"DELETE FROM scim_user WHERE org_id = ?",
 			"DELETE FROM org WHERE id = ?",
 			"DELETE FROM temp_user WHERE org_id = ?",
+			"DELETE FROM org_two_factor_policy WHERE org_id = ?",
 			"DELETE FROM ngalert_configuration WHERE org_id = ?",
 			"DELETE FROM alert_configuration WHERE org_id = ?",
 			"DELETE FROM alert_instance WHERE rule_org_id = ?",
@@ -261,6 +261,7 @@ func (ss *SQLStore) RemoveOrgUser(ctx context.Context, cmd *models.RemoveOrgUser
 			"DELETE FROM dashboard_acl WHERE org_id=? and user_id = ?",
 			"DELETE FROM team_member WHERE org_id=? and user_id = ?",
 			"DELETE FROM query_history_star WHERE org_id=? and user_id = ?",
+			"DELETE FROM scim_user WHERE org_id=? and user_id = ?",
 		}
 
 		for _, sql := range deletes {
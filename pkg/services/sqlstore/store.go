@@ -29,6 +29,13 @@ type Store interface {
 	CreateLoginAttempt(ctx context.Context, cmd *models.CreateLoginAttemptCommand) error
 	GetUserLoginAttemptCount(ctx context.Context, query *models.GetUserLoginAttemptCountQuery) error
 	DeleteOldLoginAttempts(ctx context.Context, cmd *models.DeleteOldLoginAttemptsCommand) error
+	GetIPLoginAttemptCount(ctx context.Context, query *models.GetIPLoginAttemptCountQuery) error
+	ResetUserLoginAttempts(ctx context.Context, cmd *models.ResetUserLoginAttemptsCommand) error
+	GetLoginLockout(ctx context.Context, query *models.GetLoginLockoutQuery) error
+	SaveLoginLockout(ctx context.Context, cmd *models.SaveLoginLockoutCommand) error
+	SearchLoginLockouts(ctx context.Context, query *models.SearchLoginLockoutsQuery) error
+	DeleteLoginLockout(ctx context.Context, cmd *models.DeleteLoginLockoutCommand) error
+	DeleteExpiredLoginLockouts(ctx context.Context, cmd *models.DeleteExpiredLoginLockoutsCommand) error
 	CreateUser(ctx context.Context, cmd user.CreateUserCommand) (*user.User, error)
 	SetUsingOrg(ctx context.Context, cmd *models.SetUsingOrgCommand) error
 	GetUserProfile(ctx context.Context, query *models.GetUserProfileQuery) error
@@ -505,6 +505,7 @@ func (ss *SQLStore) GetSignedInUser(ctx context.Context, query *models.GetSigned
 		u.login               as login,
 		u.name                as name,
 		u.is_disabled         as is_disabled,
+		u.is_service_account  as is_service_account,
 		u.help_flags1         as help_flags1,
 		u.last_seen_at        as last_seen_at,
 		(SELECT COUNT(*) FROM org_user where org_user.user_id = u.id) as org_count,
@@ -814,6 +815,8 @@ func UserDeletions() []string {
 		"DELETE FROM team_member WHERE user_id = ?",
 		"DELETE FROM user_auth WHERE user_id = ?",
 		"DELETE FROM user_auth_token WHERE user_id = ?",
+		"DELETE FROM user_two_factor WHERE user_id = ?",
+		"DELETE FROM scim_user WHERE user_id = ?",
 		"DELETE FROM quota WHERE user_id = ?",
 	}
 	return deletes
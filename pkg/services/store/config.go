@@ -17,6 +17,9 @@ type GlobalStorageConfig struct {
 	// Defined in grafana.ini
 	AllowUnsanitizedSvgUpload bool `json:"allowUnsanitizedSvgUpload"`
 
+	// Defined in grafana.ini
+	MaxUploadSize int64 `json:"-"`
+
 	// Add dev environment
 	AddDevEnv bool `json:"addDevEnv"`
 
@@ -78,6 +81,7 @@ func LoadStorageConfig(cfg *setting.Cfg, features featuremgmt.FeatureToggles) (*
 	if cfg.Storage.AllowUnsanitizedSvgUpload {
 		g.AllowUnsanitizedSvgUpload = true
 	}
+	g.MaxUploadSize = cfg.Storage.MaxUploadSizeMB * 1024 * 1024
 
 	// Save a template version in config
 	if changed && setting.Env != setting.Prod {
@@ -123,13 +127,18 @@ type StorageGitConfig struct {
 	Branch string `json:"branch"`
 	Root   string `json:"root"` // subfolder within the remote
 
-	// Pull interval?
 	// Requires pull request?
-	RequirePullRequest bool   `json:"requirePullRequest"`
-	PullInterval       string `json:"pullInterval"`
+	RequirePullRequest bool `json:"requirePullRequest"`
+	// Interval of the two-way sync (pull and push), ie. "5m". Sync is manual if empty
+	PullInterval string `json:"pullInterval"`
 
 	// SECURE JSON :grimicing:
-	AccessToken string `json:"accessToken,omitempty"` // Simplest auth method for github
+	AccessToken string `json:"accessToken,omitempty"` // Simplest auth method for github, also used as the password for HTTPS remotes
+	Username    string `json:"username,omitempty"`    // HTTPS remotes username
+
+	// SSH remotes, ie. git@github.com:grafana/example.git. The ssh agent is used if the key file is empty
+	SSHKeyFile       string `json:"sshKeyFile,omitempty"`
+	SSHKeyPassphrase string `json:"sshKeyPassphrase,omitempty"` // name of the environment variable with the passphrase, ie. "$GIT_SSH_PASSPHRASE"
 }
 
 type StorageSQLConfig struct {
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/filestorage"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/util"
@@ -55,6 +59,11 @@ func (s *standardStorageService) RegisterHTTPRoutes(storageRoute routing.RouteRe
 	storageRoute.Post("/createFolder", reqGrafanaAdmin, routing.Wrap(s.doCreateFolder))
 	storageRoute.Post("/deleteFolder", reqGrafanaAdmin, routing.Wrap(s.doDeleteFolder))
 	storageRoute.Get("/config", reqGrafanaAdmin, routing.Wrap(s.getConfig))
+
+	// Two-way sync of git storages
+	storageRoute.Get("/sync/*", reqGrafanaAdmin, routing.Wrap(s.getSyncStatus))
+	storageRoute.Post("/sync/*", reqGrafanaAdmin, routing.Wrap(s.doSync))
+	storageRoute.Post("/resolve/*", reqGrafanaAdmin, routing.Wrap(s.doResolveConflict))
 }
 
 func (s *standardStorageService) doWrite(c *models.ReqContext) response.Response {
@@ -81,20 +90,29 @@ func (s *standardStorageService) doUpload(c *models.ReqContext) response.Respons
 	}
 	rsp := &rspInfo{Message: "uploaded"}
 
-	c.Req.Body = http.MaxBytesReader(c.Resp, c.Req.Body, MAX_UPLOAD_SIZE)
-	if err := c.Req.ParseMultipartForm(MAX_UPLOAD_SIZE); err != nil {
-		rsp.Message = fmt.Sprintf("Please limit file uploaded under %s", util.ByteCountSI(MAX_UPLOAD_SIZE))
+	maxUploadSize := s.maxUploadSize()
+	c.Req.Body = http.MaxBytesReader(c.Resp, c.Req.Body, maxUploadSize)
+	// files bigger than the in-memory limit are stored in temporary files and streamed from there
+	if err := c.Req.ParseMultipartForm(multipartMemoryLimit); err != nil {
+		rsp.Message = fmt.Sprintf("Please limit file uploaded under %s", util.ByteCountSI(maxUploadSize))
 		rsp.Error = true
 		return response.JSON(400, rsp)
 	}
+	defer func() {
+		if err := c.Req.MultipartForm.RemoveAll(); err != nil {
+			grafanaStorageLogger.Warn("failed to remove temporary upload files", "error", err)
+		}
+	}()
 	message := getMultipartFormValue(c.Req, "message")
 	overwriteExistingFile := getMultipartFormValue(c.Req, "overwriteExistingFile") != "false" // must explicitly overwrite
 	folder := getMultipartFormValue(c.Req, "folder")
 
 	for k, fileHeaders := range c.Req.MultipartForm.File {
 		path := getMultipartFormValue(c.Req, k+".path") // match the path with a file
+		checksum := getMultipartFormValue(c.Req, k+".checksum")
 		if len(fileHeaders) > 1 {
 			path = ""
+			checksum = ""
 		}
 		if path == "" && folder == "" {
 			rsp.Message = "please specify the upload folder or full path"
@@ -103,35 +121,19 @@ func (s *standardStorageService) doUpload(c *models.ReqContext) response.Respons
 		}
 
 		for _, fileHeader := range fileHeaders {
-			// restrict file size based on file size
-			// open each file to copy contents
-			file, err := fileHeader.Open()
-			if err != nil {
-				return response.Error(500, "Internal Server Error", err)
-			}
-			err = file.Close()
-			if err != nil {
-				return response.Error(500, "Internal Server Error", err)
-			}
-			data, err := io.ReadAll(file)
-			if err != nil {
-				return response.Error(500, "Internal Server Error", err)
-			}
-
 			if path == "" {
 				path = folder + "/" + fileHeader.Filename
 			}
 
 			entityType := EntityTypeJSON
-			mimeType := http.DetectContentType(data)
-			if strings.HasPrefix(mimeType, "image") || strings.HasSuffix(path, ".svg") {
+			if isImageUpload(fileHeader.Header.Get("Content-Type"), path) {
 				entityType = EntityTypeImage
 			}
 
-			err = s.Upload(c.Req.Context(), c.SignedInUser, &UploadRequest{
-				Contents:              data,
+			err := s.uploadMultipartFile(c, fileHeader, &UploadRequest{
 				EntityType:            entityType,
 				Path:                  path,
+				Checksum:              checksum,
 				OverwriteExistingFile: overwriteExistingFile,
 				Properties: map[string]string{
 					"message": message, // the commit/changelog entry
@@ -142,7 +144,7 @@ func (s *standardStorageService) doUpload(c *models.ReqContext) response.Respons
 				return response.Error(UploadErrorToStatusCode(err), err.Error(), err)
 			}
 			rsp.Count++
-			rsp.Bytes += len(data)
+			rsp.Bytes += int(fileHeader.Size)
 			rsp.Path = path
 		}
 	}
@@ -150,6 +152,31 @@ func (s *standardStorageService) doUpload(c *models.ReqContext) response.Respons
 	return response.JSON(200, rsp)
 }
 
+// multipartMemoryLimit is the max size of uploaded files kept in memory while parsing multipart forms
+const multipartMemoryLimit = 1024 * 1024
+
+func isImageUpload(contentType string, path string) bool {
+	if strings.HasPrefix(contentType, "image") || strings.HasSuffix(path, ".svg") {
+		return true
+	}
+	return allowedImageExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+func (s *standardStorageService) uploadMultipartFile(c *models.ReqContext, fileHeader *multipart.FileHeader, req *UploadRequest) error {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			grafanaStorageLogger.Warn("failed to close uploaded file", "path", req.Path, "error", err)
+		}
+	}()
+
+	req.ContentsReader = file
+	return s.Upload(c.Req.Context(), c.SignedInUser, req)
+}
+
 func getMultipartFormValue(req *http.Request, key string) string {
 	v, ok := req.MultipartForm.Value[key]
 	if !ok || len(v) != 1 {
@@ -161,20 +188,86 @@ func getMultipartFormValue(req *http.Request, key string) string {
 func (s *standardStorageService) read(c *models.ReqContext) response.Response {
 	// full path is api/storage/read/upload/example.jpg, but we only want the part after read
 	scope, path := getPathAndScope(c)
-	file, err := s.Read(c.Req.Context(), c.SignedInUser, scope+"/"+path)
+	readRange := parseRangeHeader(c.Req.Header.Get("Range"))
+	stream, err := s.ReadStream(c.Req.Context(), c.SignedInUser, scope+"/"+path, readRange)
 	if err != nil {
+		if errors.Is(err, filestorage.ErrInvalidRange) {
+			return response.Error(http.StatusRequestedRangeNotSatisfiable, "invalid range", err)
+		}
 		return response.Error(400, "cannot call read", err)
 	}
 
-	if file == nil || file.Contents == nil {
+	if stream == nil || stream.Reader == nil {
 		return response.Error(404, "file does not exist", err)
 	}
+	defer func() {
+		if err := stream.Reader.Close(); err != nil {
+			grafanaStorageLogger.Warn("failed to close file reader", "path", path, "error", err)
+		}
+	}()
 
+	header := c.Resp.Header()
+	header.Set("Content-Type", stream.MimeType)
 	// set the correct content type for svg
 	if strings.HasSuffix(path, ".svg") {
-		c.Resp.Header().Set("Content-Type", "image/svg+xml")
+		header.Set("Content-Type", "image/svg+xml")
+	}
+	header.Set("Accept-Ranges", "bytes")
+	if stream.Checksum != "" {
+		header.Set("ETag", `"`+stream.Checksum+`"`)
+	}
+
+	if readRange != nil && readRange.Offset >= stream.Size {
+		header.Set("Content-Range", fmt.Sprintf("bytes */%d", stream.Size))
+		return response.Error(http.StatusRequestedRangeNotSatisfiable, "invalid range", nil)
 	}
-	return response.Respond(200, file.Contents)
+
+	status := http.StatusOK
+	length := stream.Size
+	if readRange != nil {
+		status = http.StatusPartialContent
+		length = stream.Size - readRange.Offset
+		if readRange.Length > 0 && readRange.Length < length {
+			length = readRange.Length
+		}
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", readRange.Offset, readRange.Offset+length-1, stream.Size))
+	}
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+
+	c.Resp.WriteHeader(status)
+	if _, err := io.Copy(c.Resp, stream.Reader); err != nil {
+		grafanaStorageLogger.Error("failed while streaming file", "path", path, "error", err)
+	}
+	return nil
+}
+
+// parseRangeHeader supports single `bytes=start-` and `bytes=start-end` ranges.
+// Other range forms are ignored and the whole file is returned, as allowed by RFC 7233
+func parseRangeHeader(rangeHeader string) *filestorage.ReadRange {
+	spec := strings.TrimPrefix(rangeHeader, "bytes=")
+	if spec == rangeHeader || strings.Contains(spec, ",") {
+		return nil
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(spec), "-", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil
+	}
+
+	start, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || start < 0 {
+		return nil
+	}
+
+	if parts[1] == "" {
+		return &filestorage.ReadRange{Offset: start}
+	}
+
+	end, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || end < start {
+		return nil
+	}
+	return &filestorage.ReadRange{Offset: start, Length: end - start + 1}
 }
 
 func (s *standardStorageService) getOptions(c *models.ReqContext) response.Response {
@@ -282,3 +375,46 @@ func (s *standardStorageService) getConfig(c *models.ReqContext) response.Respon
 	}
 	return response.JSON(200, roots)
 }
+
+func (s *standardStorageService) getSyncStatus(c *models.ReqContext) response.Response {
+	scope, _ := getPathAndScope(c)
+	root, err := s.getSyncableStorage(c.OrgId, scope)
+	if err != nil {
+		return response.Error(UploadErrorToStatusCode(err), err.Error(), err)
+	}
+	return response.JSON(200, root.SyncStatus())
+}
+
+func (s *standardStorageService) doSync(c *models.ReqContext) response.Response {
+	scope, _ := getPathAndScope(c)
+	root, err := s.getSyncableStorage(c.OrgId, scope)
+	if err != nil {
+		return response.Error(UploadErrorToStatusCode(err), err.Error(), err)
+	}
+
+	if err := root.Sync(); err != nil {
+		if errors.Is(err, ErrGitSyncConflict) {
+			return response.JSON(409, root.SyncStatus())
+		}
+		return response.Error(500, "sync failed: "+err.Error(), err)
+	}
+	return response.JSON(200, root.SyncStatus())
+}
+
+func (s *standardStorageService) doResolveConflict(c *models.ReqContext) response.Response {
+	scope, _ := getPathAndScope(c)
+	root, err := s.getSyncableStorage(c.OrgId, scope)
+	if err != nil {
+		return response.Error(UploadErrorToStatusCode(err), err.Error(), err)
+	}
+
+	cmd := &ResolveConflictCmd{}
+	if err := web.Bind(c.Req, cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := root.ResolveConflict(cmd.Path, cmd.Resolution); err != nil {
+		return response.Error(400, "failed to resolve the conflict: "+err.Error(), err)
+	}
+	return response.JSON(200, root.SyncStatus())
+}
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRangeHeader(t *testing.T) {
+	var tests = []struct {
+		header   string
+		expected *filestorage.ReadRange
+	}{
+		{header: "", expected: nil},
+		{header: "bytes=0-", expected: &filestorage.ReadRange{Offset: 0}},
+		{header: "bytes=10-19", expected: &filestorage.ReadRange{Offset: 10, Length: 10}},
+		{header: "bytes=5-5", expected: &filestorage.ReadRange{Offset: 5, Length: 1}},
+		{header: "bytes=-500", expected: nil},
+		{header: "bytes=0-1,5-6", expected: nil},
+		{header: "bytes=20-10", expected: nil},
+		{header: "items=0-10", expected: nil},
+		{header: "bytes=abc-", expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			require.Equal(t, tt.expected, parseRangeHeader(tt.header))
+		})
+	}
+}
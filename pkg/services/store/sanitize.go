@@ -56,5 +56,6 @@ func (s *standardStorageService) sanitizeUploadRequest(ctx context.Context, user
 		CacheControl:       req.CacheControl,
 		ContentDisposition: req.ContentDisposition,
 		Properties:         req.Properties,
+		Checksum:           req.Checksum,
 	}, nil
 }
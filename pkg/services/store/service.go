@@ -1,14 +1,18 @@
 package store
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
+	"golang.org/x/sync/errgroup"
+
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/filestorage"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -45,7 +49,8 @@ var (
 	SystemBrandingAdmin  = &user.SignedInUser{OrgId: ac.GlobalOrgID}
 )
 
-const MAX_UPLOAD_SIZE = 1 * 1024 * 1024 // 3MB
+// MAX_UPLOAD_SIZE is the default upload size limit, configurable with `[storage] max_upload_size_mb`
+const MAX_UPLOAD_SIZE = 1 * 1024 * 1024 // 1MB
 
 type DeleteFolderCmd struct {
 	Path  string `json:"path"`
@@ -56,6 +61,11 @@ type CreateFolderCmd struct {
 	Path string `json:"path"`
 }
 
+type ResolveConflictCmd struct {
+	Path       string            `json:"path"` // path within the git repository, as listed in the conflicts
+	Resolution GitSyncResolution `json:"resolution"`
+}
+
 type StorageService interface {
 	registry.BackgroundService
 
@@ -68,6 +78,9 @@ type StorageService interface {
 	// Read raw file contents out of the store
 	Read(ctx context.Context, user *user.SignedInUser, path string) (*filestorage.File, error)
 
+	// ReadStream streams raw file contents out of the store. The caller must close the returned reader
+	ReadStream(ctx context.Context, user *user.SignedInUser, path string, readRange *filestorage.ReadRange) (*filestorage.FileStream, error)
+
 	Upload(ctx context.Context, user *user.SignedInUser, req *UploadRequest) error
 
 	Delete(ctx context.Context, user *user.SignedInUser, path string) error
@@ -265,7 +278,20 @@ func newStandardStorageService(
 
 func (s *standardStorageService) Run(ctx context.Context) error {
 	grafanaStorageLogger.Info("storage starting")
-	return nil
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, root := range s.tree.rootsByOrgId[ac.GlobalOrgID] {
+		if gitRoot, ok := root.(*rootStorageGit); ok {
+			g.Go(func() error {
+				return gitRoot.runSync(ctx)
+			})
+		}
+	}
+	err := g.Wait()
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
 }
 
 func getOrgId(user *user.SignedInUser) int64 {
@@ -289,8 +315,26 @@ func (s *standardStorageService) Read(ctx context.Context, user *user.SignedInUs
 	return s.tree.GetFile(ctx, getOrgId(user), path)
 }
 
+func (s *standardStorageService) ReadStream(ctx context.Context, user *user.SignedInUser, path string, readRange *filestorage.ReadRange) (*filestorage.FileStream, error) {
+	guardian := s.authService.newGuardian(ctx, user, getFirstSegment(path))
+	if !guardian.canView(path) {
+		return nil, ErrAccessDenied
+	}
+	return s.tree.GetFileStream(ctx, getOrgId(user), path, readRange)
+}
+
+func (s *standardStorageService) maxUploadSize() int64 {
+	if s.cfg == nil || s.cfg.MaxUploadSize <= 0 {
+		return MAX_UPLOAD_SIZE
+	}
+	return s.cfg.MaxUploadSize
+}
+
 type UploadRequest struct {
-	Contents           []byte
+	Contents []byte
+	// ContentsReader is used if Contents is nil. Raster images are streamed to the store, other files are read into memory for validation
+	ContentsReader     io.Reader
+	Checksum           string
 	Path               string
 	CacheControl       string
 	ContentDisposition string
@@ -319,13 +363,36 @@ func (s *standardStorageService) Upload(ctx context.Context, user *user.SignedIn
 		return ErrUnsupportedStorage
 	}
 
-	validationResult := s.validateUploadRequest(ctx, user, req, storagePath)
+	var stream io.Reader
+	validatedReq := req
+	if req.Contents == nil && req.ContentsReader != nil {
+		if !isStreamableUpload(req) {
+			contents, err := io.ReadAll(req.ContentsReader)
+			if err != nil {
+				grafanaStorageLogger.Error("failed while reading the upload request", "path", req.Path, "error", err)
+				return ErrUploadInternalError
+			}
+			validatedReq = copyWithContents(req, contents)
+		} else {
+			// content type detection needs only the first 512 bytes, the rest is streamed to the store as is
+			buffered := bufio.NewReaderSize(req.ContentsReader, 512)
+			header, err := buffered.Peek(512)
+			if err != nil && !errors.Is(err, io.EOF) {
+				grafanaStorageLogger.Error("failed while reading the upload request", "path", req.Path, "error", err)
+				return ErrUploadInternalError
+			}
+			validatedReq = copyWithContents(req, header)
+			stream = buffered
+		}
+	}
+
+	validationResult := s.validateUploadRequest(ctx, user, validatedReq, storagePath)
 	if !validationResult.ok {
 		grafanaStorageLogger.Warn("file upload validation failed", "path", req.Path, "reason", validationResult.reason)
 		return ErrValidationFailed
 	}
 
-	upsertCommand, err := s.sanitizeUploadRequest(ctx, user, req, storagePath)
+	upsertCommand, err := s.sanitizeUploadRequest(ctx, user, validatedReq, storagePath)
 	if err != nil {
 		grafanaStorageLogger.Error("failed while sanitizing the upload request", "path", req.Path, "error", err)
 		return ErrUploadInternalError
@@ -345,14 +412,36 @@ func (s *standardStorageService) Upload(ctx context.Context, user *user.SignedIn
 		}
 	}
 
-	if err := root.Store().Upsert(ctx, upsertCommand); err != nil {
+	if stream != nil {
+		upsertCommand.Contents = nil
+		err = root.Store().UpsertReader(ctx, upsertCommand, stream)
+	} else {
+		err = root.Store().Upsert(ctx, upsertCommand)
+	}
+
+	if err != nil {
 		grafanaStorageLogger.Error("failed while uploading the file", "err", err, "path", req.Path)
+		if errors.Is(err, filestorage.ErrChecksumMismatch) {
+			return ErrValidationFailed
+		}
 		return ErrUploadInternalError
 	}
 
 	return nil
 }
 
+// isStreamableUpload returns true if the upload request can be validated without reading all of its contents
+func isStreamableUpload(req *UploadRequest) bool {
+	return req.EntityType == EntityTypeImage && filepath.Ext(req.Path) != ".svg"
+}
+
+func copyWithContents(req *UploadRequest, contents []byte) *UploadRequest {
+	c := *req
+	c.Contents = contents
+	c.ContentsReader = nil
+	return &c
+}
+
 func (s *standardStorageService) checkFileQuota(ctx context.Context, path string) error {
 	// assumes we are only uploading to the SQL database - TODO: refactor once we introduce object stores
 	quotaReached, err := s.quotaService.CheckQuotaReached(ctx, "file", nil)
@@ -471,6 +560,27 @@ func (s *standardStorageService) write(ctx context.Context, user *user.SignedInU
 	return root.Write(ctx, req)
 }
 
+// syncableStorage is implemented by storages with a two-way sync, currently only git
+type syncableStorage interface {
+	storageRuntime
+
+	SyncStatus() GitSyncStatus
+	ResolveConflict(path string, resolution GitSyncResolution) error
+}
+
+func (s *standardStorageService) getSyncableStorage(orgId int64, scope string) (syncableStorage, error) {
+	root, _ := s.tree.getRoot(orgId, scope)
+	if root == nil {
+		return nil, ErrStorageNotFound
+	}
+
+	syncable, ok := root.(syncableStorage)
+	if !ok {
+		return nil, ErrUnsupportedStorage
+	}
+	return syncable, nil
+}
+
 type workflowInfo struct {
 	Type        WriteValueWorkflow `json:"value"` // value matches selectable value
 	Label       string             `json:"label"`
@@ -496,11 +606,13 @@ func (s *standardStorageService) getWorkflowOptions(ctx context.Context, user *u
 	meta := root.Meta()
 	if meta.Config.Type == rootStorageTypeGit && meta.Config.Git != nil {
 		cfg := meta.Config.Git
-		options.Workflows = append(options.Workflows, workflowInfo{
-			Type:        WriteValueWorkflow_PR,
-			Label:       "Create pull request",
-			Description: "Create a new upstream pull request",
-		})
+		if gitRoot, ok := root.(*rootStorageGit); !ok || gitRoot.github != nil {
+			options.Workflows = append(options.Workflows, workflowInfo{
+				Type:        WriteValueWorkflow_PR,
+				Label:       "Create pull request",
+				Description: "Create a new upstream pull request",
+			})
+		}
 		if !cfg.RequirePullRequest {
 			options.Workflows = append(options.Workflows, workflowInfo{
 				Type:        WriteValueWorkflow_Push,
@@ -3,6 +3,7 @@ package store
 import (
 	"bytes"
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -254,3 +255,46 @@ func TestShouldNotUploadJpgDisguisedAsSvg(t *testing.T) {
 	})
 	require.ErrorIs(t, err, ErrValidationFailed)
 }
+
+func TestShouldStreamImageUploads(t *testing.T) {
+	service, mockStorage, storageName := setupUploadStore(t, nil)
+
+	fileName := "/myFile.jpg"
+	mockStorage.On("Get", mock.Anything, fileName).Return(nil, nil)
+	mockStorage.On("UpsertReader", mock.Anything, &filestorage.UpsertFileCommand{
+		Path:     fileName,
+		MimeType: "image/jpeg",
+	}, mock.Anything).Return(func(ctx context.Context, cmd *filestorage.UpsertFileCommand, contents io.Reader) error {
+		uploaded, err := io.ReadAll(contents)
+		require.NoError(t, err)
+		require.Equal(t, jpgBytes, uploaded)
+		return nil
+	})
+
+	err := service.Upload(context.Background(), dummyUser, &UploadRequest{
+		EntityType:     EntityTypeImage,
+		ContentsReader: bytes.NewReader(jpgBytes),
+		Path:           storageName + fileName,
+	})
+	require.NoError(t, err)
+	mockStorage.AssertCalled(t, "UpsertReader", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestShouldNotStreamSvgUploads(t *testing.T) {
+	service, mockStorage, storageName := setupUploadStore(t, nil)
+
+	fileName := "/myFile.svg"
+	mockStorage.On("Get", mock.Anything, fileName).Return(nil, nil)
+	mockStorage.On("Upsert", mock.Anything, &filestorage.UpsertFileCommand{
+		Path:     fileName,
+		MimeType: "image/svg+xml",
+		Contents: svgBytes,
+	}).Return(nil)
+
+	err := service.Upload(context.Background(), dummyUser, &UploadRequest{
+		EntityType:     EntityTypeImage,
+		ContentsReader: bytes.NewReader(svgBytes),
+		Path:           storageName + fileName,
+	})
+	require.NoError(t, err)
+}
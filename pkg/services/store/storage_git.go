@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -28,6 +30,10 @@ type rootStorageGit struct {
 	repo     *git.Repository
 	root     string // repostitory root
 
+	workdir      string // local clone
+	syncer       *gitSyncer
+	pullInterval time.Duration
+
 	github *githubHelper
 	meta   RootStorageMeta
 	store  filestorage.FileStorage
@@ -90,15 +96,39 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 		})
 	}
 
+	token := cfg.AccessToken
+	if strings.HasPrefix(token, "$") {
+		token = os.Getenv(token[1:])
+		if token == "" {
+			meta.Notice = append(meta.Notice, data.Notice{
+				Severity: data.NoticeSeverityError,
+				Text:     "Unable to find token environment variable: " + cfg.AccessToken,
+			})
+		}
+	}
+
+	auth, err := getGitAuth(cfg, token)
+	if err != nil {
+		meta.Notice = append(meta.Notice, data.Notice{
+			Severity: data.NoticeSeverityError,
+			Text:     "invalid git auth configuration: " + err.Error(),
+		})
+	}
+
 	if meta.Notice == nil {
 		repo, err := git.PlainOpen(localWorkCache)
 		if errors.Is(err, git.ErrRepositoryNotExists) {
-			repo, err = git.PlainClone(localWorkCache, false, &git.CloneOptions{
+			cloneOptions := &git.CloneOptions{
 				URL:      cfg.Remote,
+				Auth:     auth,
 				Progress: os.Stdout,
 				//Depth:    1,
 				//SingleBranch: true,
-			})
+			}
+			if cfg.Branch != "" {
+				cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(cfg.Branch)
+			}
+			repo, err = git.PlainClone(localWorkCache, false, cloneOptions)
 		}
 
 		if err != nil {
@@ -129,19 +159,10 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 
 				meta.Ready = true // exists!
 				s.root = p
+				s.workdir = localWorkCache
 
-				token := cfg.AccessToken
-				if strings.HasPrefix(token, "$") {
-					token = os.Getenv(token[1:])
-					if token == "" {
-						meta.Notice = append(meta.Notice, data.Notice{
-							Severity: data.NoticeSeverityError,
-							Text:     "Unable to find token environment variable: " + cfg.AccessToken,
-						})
-					}
-				}
-
-				if token != "" {
+				// the github helper is needed only for pull requests, plain git remotes are synced with `gitSyncer`
+				if token != "" && strings.Contains(cfg.Remote, "github.com") {
 					s.github, err = newGithubHelper(context.Background(), cfg.Remote, token)
 					if err != nil {
 						meta.Notice = append(meta.Notice, data.Notice{
@@ -165,9 +186,23 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 			}
 		}
 		s.repo = repo
+		if s.repo != nil {
+			branch := cfg.Branch
+			if head, err := s.repo.Head(); err == nil && branch == "" && head.Name().IsBranch() {
+				branch = head.Name().Short()
+			}
+			if branch == "" {
+				meta.Notice = append(meta.Notice, data.Notice{
+					Severity: data.NoticeSeverityError,
+					Text:     "Unable to find the branch to sync, set it in the configuration",
+				})
+			} else {
+				s.syncer = newGitSyncer(s.repo, localWorkCache, branch, auth)
+			}
+		}
 
-		// Try pulling after init
-		if s.repo != nil && !scfg.Disabled {
+		// Try syncing after init
+		if s.syncer != nil && !scfg.Disabled {
 			err = s.Sync()
 			if err != nil {
 				meta.Notice = append(meta.Notice, data.Notice{
@@ -175,23 +210,13 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 					Text:     "unable to pull: " + err.Error(),
 				})
 			} else if cfg.PullInterval != "" {
-				t, err := time.ParseDuration(cfg.PullInterval)
+				// the periodic sync is started by the storage service, see `runSync`
+				s.pullInterval, err = time.ParseDuration(cfg.PullInterval)
 				if err != nil {
 					meta.Notice = append(meta.Notice, data.Notice{
 						Severity: data.NoticeSeverityError,
 						Text:     "Invalid pull interval " + cfg.PullInterval,
 					})
-				} else {
-					ticker := time.NewTicker(t)
-					go func() {
-						for range ticker.C {
-							grafanaStorageLogger.Info("try git pull", "branch", s.settings.Remote)
-							err = s.Sync()
-							if err != nil {
-								grafanaStorageLogger.Info("error pulling", "error", err)
-							}
-						}
-					}()
 				}
 			}
 		}
@@ -201,6 +226,28 @@ func newGitStorage(meta RootStorageMeta, scfg RootStorageConfig, localWorkCache
 	return s
 }
 
+// runSync syncs the repository every pull interval until the context is done
+func (s *rootStorageGit) runSync(ctx context.Context) error {
+	if s.syncer == nil || s.pullInterval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(s.pullInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			grafanaStorageLogger.Info("try git sync", "branch", s.settings.Remote)
+			if err := s.Sync(); err != nil {
+				grafanaStorageLogger.Info("error syncing", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 func (s *rootStorageGit) Meta() RootStorageMeta {
 	return s.meta
 }
@@ -209,25 +256,26 @@ func (s *rootStorageGit) Store() filestorage.FileStorage {
 	return s.store
 }
 
-func (s *rootStorageGit) Pull() error {
-	w, err := s.repo.Worktree()
-	if err != nil {
-		return err
+func (s *rootStorageGit) SyncStatus() GitSyncStatus {
+	if s.syncer == nil {
+		return GitSyncStatus{Conflicts: make([]GitSyncConflict, 0)}
 	}
+	return s.syncer.getStatus()
+}
 
-	err = w.Pull(&git.PullOptions{
-		// Depth: 1,
-		//SingleBranch: true,
-	})
-	if err != nil {
-		return err
+func (s *rootStorageGit) ResolveConflict(path string, resolution GitSyncResolution) error {
+	if s.syncer == nil {
+		return ErrUnsupportedStorage
 	}
-	return nil
+	return s.syncer.resolve(path, resolution)
 }
 
 func (s *rootStorageGit) Write(ctx context.Context, cmd *WriteValueRequest) (*WriteValueResponse, error) {
 	if s.github == nil {
-		return nil, fmt.Errorf("github client not initialized")
+		if cmd.Workflow == WriteValueWorkflow_PR {
+			return nil, fmt.Errorf("pull requests require a github remote with an access token")
+		}
+		return s.commitAndSync(cmd)
 	}
 	// Write to the correct subfolder
 	if s.settings.Root != "" {
@@ -282,57 +330,45 @@ func (s *rootStorageGit) Write(ctx context.Context, cmd *WriteValueRequest) (*Wr
 	}
 
 	// Push to remote branch (save)
-	if cmd.Workflow == WriteValueWorkflow_Push || true {
-		res := &WriteValueResponse{
-			Branch: s.settings.Branch,
-		}
-		ref, _, err := s.github.getRef(ctx, s.settings.Branch)
-		if err != nil {
-			res.Code = 500
-			res.Message = "unable to create branch"
-			return res, nil
-		}
-		err = s.github.pushCommit(ctx, ref, cmd)
-		if err != nil {
-			res.Code = 500
-			res.Message = "error creating commit"
-			return res, nil
-		}
-		ref, _, _ = s.github.getRef(ctx, s.settings.Branch)
-		if ref != nil {
-			res.Hash = *ref.Object.SHA
-			res.URL = ref.GetURL()
-		}
-
-		err = s.Pull()
-		if err != nil {
-			res.Message = "error pulling: " + err.Error()
-		}
-
-		res.Code = 200
+	res := &WriteValueResponse{
+		Branch: s.settings.Branch,
+	}
+	ref, _, err := s.github.getRef(ctx, s.settings.Branch)
+	if err != nil {
+		res.Code = 500
+		res.Message = "unable to create branch"
 		return res, nil
 	}
-
-	rel := cmd.Path
-	if s.meta.Config.Git.Root != "" {
-		rel = filepath.Join(s.meta.Config.Git.Root, cmd.Path)
+	err = s.github.pushCommit(ctx, ref, cmd)
+	if err != nil {
+		res.Code = 500
+		res.Message = "error creating commit"
+		return res, nil
+	}
+	ref, _, _ = s.github.getRef(ctx, s.settings.Branch)
+	if ref != nil {
+		res.Hash = *ref.Object.SHA
+		res.URL = ref.GetURL()
 	}
 
-	fpath := filepath.Join(s.root, rel)
-	err := os.WriteFile(fpath, cmd.Body, 0644)
+	err = s.Sync()
 	if err != nil {
-		return nil, err
+		res.Message = "error pulling: " + err.Error()
 	}
 
-	w, err := s.repo.Worktree()
-	if err != nil {
-		return nil, err
+	res.Code = 200
+	return res, nil
+}
+
+// commitAndSync commits the change to the local clone and pushes it to the remote
+func (s *rootStorageGit) commitAndSync(cmd *WriteValueRequest) (*WriteValueResponse, error) {
+	if s.repo == nil || s.syncer == nil {
+		return nil, fmt.Errorf("git repository not initialized")
 	}
 
-	// The file we just wrote
-	_, err = w.Add(rel)
-	if err != nil {
-		return nil, err
+	rel := strings.TrimPrefix(cmd.Path, filestorage.Delimiter)
+	if s.settings.Root != "" {
+		rel = path.Join(s.settings.Root, rel)
 	}
 
 	msg := cmd.Message
@@ -344,37 +380,37 @@ func (s *rootStorageGit) Write(ctx context.Context, cmd *WriteValueRequest) (*Wr
 		usr = &user.SignedInUser{}
 	}
 
-	hash, err := w.Commit(msg, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  firstRealString(usr.Name, usr.Login, usr.Email, "?"),
-			Email: firstRealString(usr.Email, usr.Login, usr.Name, "?"),
-			When:  time.Now(),
-		},
+	commit, err := s.syncer.commitFile(rel, cmd.Body, msg, &object.Signature{
+		Name:  firstRealString(usr.Name, usr.Login, usr.Email, "?"),
+		Email: firstRealString(usr.Email, usr.Login, usr.Name, "?"),
+		When:  time.Now(),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	grafanaStorageLogger.Info("made commit", "hash", hash)
-	// err = s.repo.Push(&git.PushOptions{
-	// 	InsecureSkipTLS: true,
-	// })
-
-	return &WriteValueResponse{
-		Hash:    hash.String(),
+	grafanaStorageLogger.Info("made commit", "hash", commit.hash)
+	res := &WriteValueResponse{
+		Code:    200,
+		Hash:    commit.hash.String(),
+		Branch:  s.syncer.branch,
 		Message: "made commit",
-	}, nil
+	}
+
+	if commit.syncErr != nil {
+		// the commit stays in the local clone and will be pushed once the sync succeeds
+		res.Pending = true
+		res.Message = "commit not pushed: " + commit.syncErr.Error()
+	}
+	return res, nil
 }
 
 func (s *rootStorageGit) Sync() error {
-	grafanaStorageLogger.Info("GIT PULL", "remote", s.settings.Remote)
-	err := s.Pull()
-	if err != nil {
-		if err.Error() == "already up-to-date" {
-			return nil
-		}
+	if s.syncer == nil {
+		return fmt.Errorf("git repository not initialized")
 	}
-	return err
+	grafanaStorageLogger.Info("GIT SYNC", "remote", s.settings.Remote)
+	return s.syncer.sync()
 }
 
 func firstRealString(vals ...string) string {
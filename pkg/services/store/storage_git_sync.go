@@ -0,0 +1,449 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+var ErrGitSyncConflict = errors.New("git sync conflict")
+var ErrGitSyncUnknownResolution = errors.New("unknown conflict resolution")
+
+type GitSyncResolution = string
+
+var (
+	GitSyncResolution_Local  GitSyncResolution = "local"  // keep the local version and push it
+	GitSyncResolution_Remote GitSyncResolution = "remote" // drop the local version and pull the remote one
+)
+
+// GitSyncConflict describes a file changed both locally and in the remote repository since the last sync.
+// Hashes are git blob hashes; an empty hash means that the file did not exist or was deleted
+type GitSyncConflict struct {
+	Path       string    `json:"path"`
+	BaseHash   string    `json:"baseHash,omitempty"`
+	LocalHash  string    `json:"localHash,omitempty"`
+	RemoteHash string    `json:"remoteHash,omitempty"`
+	Detected   time.Time `json:"detected"`
+}
+
+type GitSyncStatus struct {
+	LastSync  time.Time         `json:"lastSync,omitempty"`
+	LastError string            `json:"lastError,omitempty"`
+	Conflicts []GitSyncConflict `json:"conflicts"`
+}
+
+// gitSyncer keeps the local clone and the remote branch in sync in both directions.
+// Files changed on a single side are merged at the file level; files changed on both sides
+// are reported as conflicts until they are resolved with `resolve`
+type gitSyncer struct {
+	repo    *git.Repository
+	workdir string
+	branch  string
+	auth    transport.AuthMethod
+
+	mu          sync.Mutex
+	status      GitSyncStatus
+	resolutions map[string]GitSyncResolution
+}
+
+func newGitSyncer(repo *git.Repository, workdir string, branch string, auth transport.AuthMethod) *gitSyncer {
+	return &gitSyncer{
+		repo:        repo,
+		workdir:     workdir,
+		branch:      branch,
+		auth:        auth,
+		resolutions: make(map[string]GitSyncResolution),
+		status: GitSyncStatus{
+			Conflicts: make([]GitSyncConflict, 0),
+		},
+	}
+}
+
+// getGitAuth picks the auth method based on the remote URL. Local remotes don't need auth
+func getGitAuth(cfg *StorageGitConfig, token string) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(cfg.Remote)
+	if err != nil {
+		return nil, err
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		if cfg.SSHKeyFile == "" {
+			return nil, nil // use the ssh agent
+		}
+		user := endpoint.User
+		if user == "" {
+			user = "git"
+		}
+		return gitssh.NewPublicKeysFromFile(user, cfg.SSHKeyFile, os.Getenv(strings.TrimPrefix(cfg.SSHKeyPassphrase, "$")))
+	case "http", "https":
+		if token == "" {
+			return nil, nil
+		}
+		username := cfg.Username
+		if username == "" {
+			username = "grafana" // ignored by GitHub and GitLab when using access tokens
+		}
+		return &githttp.BasicAuth{Username: username, Password: token}, nil
+	}
+	return nil, nil
+}
+
+func (g *gitSyncer) getStatus() GitSyncStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	conflicts := make([]GitSyncConflict, len(g.status.Conflicts))
+	copy(conflicts, g.status.Conflicts)
+	return GitSyncStatus{
+		LastSync:  g.status.LastSync,
+		LastError: g.status.LastError,
+		Conflicts: conflicts,
+	}
+}
+
+func (g *gitSyncer) resolve(path string, resolution GitSyncResolution) error {
+	if resolution != GitSyncResolution_Local && resolution != GitSyncResolution_Remote {
+		return ErrGitSyncUnknownResolution
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, c := range g.status.Conflicts {
+		if c.Path == path {
+			g.resolutions[path] = resolution
+			return nil
+		}
+	}
+	return fmt.Errorf("no conflict found for %s", path)
+}
+
+func (g *gitSyncer) sync() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.doSyncAndRecord()
+}
+
+func (g *gitSyncer) doSyncAndRecord() error {
+	err := g.doSync()
+	g.status.LastSync = time.Now()
+	g.status.LastError = ""
+	if err != nil {
+		g.status.LastError = err.Error()
+	}
+	return err
+}
+
+// gitCommitResult is the commit of a file and the error of the sync following it, if any.
+// The commit stays in the local clone when the sync fails
+type gitCommitResult struct {
+	hash    plumbing.Hash
+	syncErr error
+}
+
+// commitFile writes, commits and pushes a file of the worktree while holding the lock, so that
+// concurrent writes and periodic syncs never reset the worktree in the middle of a commit
+func (g *gitSyncer) commitFile(rel string, body []byte, msg string, author *object.Signature) (*gitCommitResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.doCheckoutBranch(); err != nil {
+		return nil, err
+	}
+
+	fpath := filepath.Join(g.workdir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(fpath), 0750); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(fpath, body, 0600); err != nil {
+		return nil, err
+	}
+
+	w, err := g.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Add(rel); err != nil {
+		return nil, err
+	}
+
+	hash, err := w.Commit(msg, &git.CommitOptions{Author: author})
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitCommitResult{hash: hash, syncErr: g.doSyncAndRecord()}, nil
+}
+
+// doCheckoutBranch makes sure HEAD is on the synced branch, so that commits are never made
+// on a detached HEAD or another branch and then pushed to the wrong ref
+func (g *gitSyncer) doCheckoutBranch() error {
+	branchRef := plumbing.NewBranchReferenceName(g.branch)
+	head, err := g.repo.Head()
+	if err == nil && head.Name() == branchRef {
+		return nil
+	}
+
+	w, err := g.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	opts := &git.CheckoutOptions{Branch: branchRef, Keep: true}
+	if _, err := g.repo.Reference(branchRef, true); errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// the local branch does not exist yet, start it from the remote one
+		remoteRef, err := g.repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, g.branch), true)
+		if err != nil {
+			return fmt.Errorf("branch %s not found: %w", g.branch, err)
+		}
+		opts.Create = true
+		opts.Hash = remoteRef.Hash()
+	} else if err != nil {
+		return err
+	}
+
+	return w.Checkout(opts)
+}
+
+func (g *gitSyncer) doSync() error {
+	err := g.repo.Fetch(&git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		Auth:       g.auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+
+	if err := g.doCheckoutBranch(); err != nil {
+		return err
+	}
+
+	localRef, err := g.repo.Head()
+	if err != nil {
+		return err
+	}
+	remoteRef, err := g.repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, g.branch), true)
+	if err != nil {
+		return err
+	}
+
+	if localRef.Hash() == remoteRef.Hash() {
+		g.status.Conflicts = make([]GitSyncConflict, 0)
+		return nil
+	}
+
+	localCommit, err := g.repo.CommitObject(localRef.Hash())
+	if err != nil {
+		return err
+	}
+	remoteCommit, err := g.repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return err
+	}
+
+	bases, err := localCommit.MergeBase(remoteCommit)
+	if err != nil {
+		return err
+	}
+
+	baseFiles := make(map[string]plumbing.Hash)
+	if len(bases) > 0 {
+		if baseFiles, err = commitFileHashes(bases[0]); err != nil {
+			return err
+		}
+	}
+	localFiles, err := commitFileHashes(localCommit)
+	if err != nil {
+		return err
+	}
+	remoteFiles, err := commitFileHashes(remoteCommit)
+	if err != nil {
+		return err
+	}
+
+	localChanges := changedFiles(baseFiles, localFiles)
+	remoteChanges := changedFiles(baseFiles, remoteFiles)
+
+	conflicts := make([]GitSyncConflict, 0)
+	replay := make([]string, 0, len(localChanges))
+	for path := range localChanges {
+		if !remoteChanges[path] {
+			replay = append(replay, path)
+			continue
+		}
+
+		if localFiles[path] == remoteFiles[path] {
+			continue // same change on both sides
+		}
+
+		switch g.resolutions[path] {
+		case GitSyncResolution_Local:
+			replay = append(replay, path)
+		case GitSyncResolution_Remote:
+			// the remote version wins, nothing to replay
+		default:
+			conflicts = append(conflicts, GitSyncConflict{
+				Path:       path,
+				BaseHash:   hashString(baseFiles, path),
+				LocalHash:  hashString(localFiles, path),
+				RemoteHash: hashString(remoteFiles, path),
+				Detected:   time.Now(),
+			})
+		}
+	}
+	sort.Strings(replay)
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Path < conflicts[j].Path
+	})
+
+	g.status.Conflicts = conflicts
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %d file(s) changed both locally and in the remote", ErrGitSyncConflict, len(conflicts))
+	}
+
+	if len(remoteChanges) == 0 {
+		// only local commits, the remote can be fast-forwarded
+		return g.push()
+	}
+
+	// start from the remote version and replay the local changes on top of it
+	contents := make(map[string][]byte, len(replay))
+	for _, path := range replay {
+		if _, ok := localFiles[path]; !ok {
+			continue // deleted locally
+		}
+		file, err := localCommit.File(path)
+		if err != nil {
+			return err
+		}
+		body, err := readGitFile(file)
+		if err != nil {
+			return err
+		}
+		contents[path] = body
+	}
+
+	w, err := g.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if err := w.Reset(&git.ResetOptions{Commit: remoteRef.Hash(), Mode: git.HardReset}); err != nil {
+		return err
+	}
+
+	g.resolutions = make(map[string]GitSyncResolution)
+	if len(replay) == 0 {
+		return nil
+	}
+
+	for _, path := range replay {
+		fpath := filepath.Join(g.workdir, filepath.FromSlash(path))
+		body, ok := contents[path]
+		if !ok {
+			if _, err := w.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(fpath), 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fpath, body, 0600); err != nil {
+			return err
+		}
+		if _, err := w.Add(path); err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Commit(fmt.Sprintf("sync %d file(s) changed in grafana", len(replay)), &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "grafana",
+			Email: "grafana@localhost",
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return g.push()
+}
+
+func (g *gitSyncer) push() error {
+	refSpec := config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", g.branch, g.branch))
+	err := g.repo.Push(&git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       g.auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+func commitFileHashes(commit *object.Commit) (map[string]plumbing.Hash, error) {
+	files := make(map[string]plumbing.Hash)
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files[f.Name] = f.Hash
+		return nil
+	})
+	return files, err
+}
+
+// changedFiles returns paths added, modified or deleted since the base
+func changedFiles(base map[string]plumbing.Hash, current map[string]plumbing.Hash) map[string]bool {
+	changes := make(map[string]bool)
+	for path, hash := range current {
+		if baseHash, ok := base[path]; !ok || baseHash != hash {
+			changes[path] = true
+		}
+	}
+	for path := range base {
+		if _, ok := current[path]; !ok {
+			changes[path] = true
+		}
+	}
+	return changes
+}
+
+func hashString(files map[string]plumbing.Hash, path string) string {
+	if hash, ok := files[path]; ok {
+		return hash.String()
+	}
+	return ""
+}
+
+func readGitFile(file *object.File) ([]byte, error) {
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	return io.ReadAll(reader)
+}
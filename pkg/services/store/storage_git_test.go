@@ -0,0 +1,250 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type gitTestRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+}
+
+func (r *gitTestRepo) commit(files map[string]string) {
+	r.t.Helper()
+
+	w, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+
+	for path, body := range files {
+		fpath := filepath.Join(r.dir, filepath.FromSlash(path))
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(fpath), 0750))
+		require.NoError(r.t, os.WriteFile(fpath, []byte(body), 0600))
+		_, err = w.Add(path)
+		require.NoError(r.t, err)
+	}
+
+	_, err = w.Commit("upstream change", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@localhost", When: time.Now()},
+	})
+	require.NoError(r.t, err)
+}
+
+func (r *gitTestRepo) push() {
+	r.t.Helper()
+	err := r.repo.Push(&git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{"refs/heads/master:refs/heads/master"},
+	})
+	require.NoError(r.t, err)
+}
+
+func (r *gitTestRepo) pull() {
+	r.t.Helper()
+	w, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	err = w.Pull(&git.PullOptions{RemoteName: git.DefaultRemoteName})
+	if err != nil {
+		require.ErrorIs(r.t, err, git.NoErrAlreadyUpToDate)
+	}
+}
+
+func (r *gitTestRepo) read(path string) string {
+	r.t.Helper()
+	body, err := os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(path)))
+	require.NoError(r.t, err)
+	return string(body)
+}
+
+// setupGitSyncTest creates a bare repository acting as the remote, an upstream clone simulating
+// other users of the remote and a git storage syncing with it
+func setupGitSyncTest(t *testing.T) (*rootStorageGit, *gitTestRepo) {
+	t.Helper()
+
+	remote := t.TempDir()
+	_, err := git.PlainInit(remote, true)
+	require.NoError(t, err)
+
+	upstreamDir := t.TempDir()
+	upstreamRepo, err := git.PlainInit(upstreamDir, false)
+	require.NoError(t, err)
+	_, err = upstreamRepo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{remote}})
+	require.NoError(t, err)
+
+	upstream := &gitTestRepo{t: t, dir: upstreamDir, repo: upstreamRepo}
+	upstream.commit(map[string]string{
+		"dashboards/a.json": `{"title": "A"}`,
+		"dashboards/b.json": `{"title": "B"}`,
+	})
+	upstream.push()
+
+	s := newGitStorage(RootStorageMeta{}, RootStorageConfig{
+		Prefix: "git",
+		Git: &StorageGitConfig{
+			Remote: remote,
+			Branch: "master",
+			Root:   "dashboards",
+		},
+	}, filepath.Join(t.TempDir(), "cache"))
+	require.Empty(t, s.meta.Notice)
+	require.True(t, s.meta.Ready)
+
+	return s, upstream
+}
+
+func writeGitDashboard(t *testing.T, s *rootStorageGit, path string, body string) *WriteValueResponse {
+	t.Helper()
+	rsp, err := s.Write(context.Background(), &WriteValueRequest{
+		Path:     path,
+		Body:     []byte(body),
+		Workflow: WriteValueWorkflow_Push,
+	})
+	require.NoError(t, err)
+	return rsp
+}
+
+func TestGitStorageSync(t *testing.T) {
+	t.Run("should push local changes and pull remote changes to different files", func(t *testing.T) {
+		s, upstream := setupGitSyncTest(t)
+
+		upstream.commit(map[string]string{"dashboards/b.json": `{"title": "B remote"}`})
+		upstream.push()
+
+		rsp := writeGitDashboard(t, s, "/a.json", `{"title": "A local"}`)
+		require.False(t, rsp.Pending, rsp.Message)
+
+		file, err := s.Store().Get(context.Background(), "/b.json")
+		require.NoError(t, err)
+		require.Equal(t, `{"title": "B remote"}`, string(file.Contents))
+
+		upstream.pull()
+		require.Equal(t, `{"title": "A local"}`, upstream.read("dashboards/a.json"))
+		require.Equal(t, `{"title": "B remote"}`, upstream.read("dashboards/b.json"))
+		require.Empty(t, s.SyncStatus().Conflicts)
+	})
+
+	t.Run("should detect and resolve conflicts", func(t *testing.T) {
+		s, upstream := setupGitSyncTest(t)
+
+		upstream.commit(map[string]string{"dashboards/a.json": `{"title": "A remote"}`})
+		upstream.push()
+
+		rsp := writeGitDashboard(t, s, "/a.json", `{"title": "A local"}`)
+		require.True(t, rsp.Pending)
+
+		status := s.SyncStatus()
+		require.Len(t, status.Conflicts, 1)
+		require.Equal(t, "dashboards/a.json", status.Conflicts[0].Path)
+		require.NotEqual(t, status.Conflicts[0].LocalHash, status.Conflicts[0].RemoteHash)
+		require.NotEmpty(t, status.LastError)
+
+		require.ErrorIs(t, s.ResolveConflict("dashboards/a.json", "unknown"), ErrGitSyncUnknownResolution)
+		require.Error(t, s.ResolveConflict("dashboards/b.json", GitSyncResolution_Remote))
+
+		require.NoError(t, s.ResolveConflict("dashboards/a.json", GitSyncResolution_Remote))
+		require.NoError(t, s.Sync())
+
+		file, err := s.Store().Get(context.Background(), "/a.json")
+		require.NoError(t, err)
+		require.Equal(t, `{"title": "A remote"}`, string(file.Contents))
+		require.Empty(t, s.SyncStatus().Conflicts)
+	})
+
+	t.Run("should push the local version when the conflict is resolved with local", func(t *testing.T) {
+		s, upstream := setupGitSyncTest(t)
+
+		upstream.commit(map[string]string{"dashboards/a.json": `{"title": "A remote"}`})
+		upstream.push()
+
+		rsp := writeGitDashboard(t, s, "/a.json", `{"title": "A local"}`)
+		require.True(t, rsp.Pending)
+
+		require.NoError(t, s.ResolveConflict("dashboards/a.json", GitSyncResolution_Local))
+		require.NoError(t, s.Sync())
+
+		upstream.pull()
+		require.Equal(t, `{"title": "A local"}`, upstream.read("dashboards/a.json"))
+	})
+
+	t.Run("should commit on the configured branch when HEAD is detached", func(t *testing.T) {
+		s, upstream := setupGitSyncTest(t)
+
+		head, err := s.repo.Head()
+		require.NoError(t, err)
+		w, err := s.repo.Worktree()
+		require.NoError(t, err)
+		require.NoError(t, w.Checkout(&git.CheckoutOptions{Hash: head.Hash()}))
+
+		rsp := writeGitDashboard(t, s, "/a.json", `{"title": "A local"}`)
+		require.False(t, rsp.Pending, rsp.Message)
+
+		head, err = s.repo.Head()
+		require.NoError(t, err)
+		require.Equal(t, plumbing.NewBranchReferenceName("master"), head.Name())
+
+		upstream.pull()
+		require.Equal(t, `{"title": "A local"}`, upstream.read("dashboards/a.json"))
+	})
+
+	t.Run("should not lose concurrent writes", func(t *testing.T) {
+		s, upstream := setupGitSyncTest(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(2)
+			go func(i int) {
+				defer wg.Done()
+				_, err := s.Write(context.Background(), &WriteValueRequest{
+					Path:     fmt.Sprintf("/concurrent-%d.json", i),
+					Body:     []byte(fmt.Sprintf(`{"title": "%d"}`, i)),
+					Workflow: WriteValueWorkflow_Push,
+				})
+				assert.NoError(t, err)
+			}(i)
+			go func() {
+				defer wg.Done()
+				_ = s.Sync()
+			}()
+		}
+		wg.Wait()
+
+		require.NoError(t, s.Sync())
+		require.Empty(t, s.SyncStatus().Conflicts)
+		upstream.pull()
+		for i := 0; i < 5; i++ {
+			require.Equal(t, fmt.Sprintf(`{"title": "%d"}`, i), upstream.read(fmt.Sprintf("dashboards/concurrent-%d.json", i)))
+		}
+	})
+
+	t.Run("should stop syncing when the context is done", func(t *testing.T) {
+		s, _ := setupGitSyncTest(t)
+		s.pullInterval = time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- s.runSync(ctx)
+		}()
+		cancel()
+
+		select {
+		case err := <-done:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("sync did not stop")
+		}
+	})
+}
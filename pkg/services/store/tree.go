@@ -89,6 +89,21 @@ func (t *nestedTree) GetFile(ctx context.Context, orgId int64, path string) (*fi
 	return store.Get(ctx, path)
 }
 
+func (t *nestedTree) GetFileStream(ctx context.Context, orgId int64, path string, readRange *filestorage.ReadRange) (*filestorage.FileStream, error) {
+	if path == "" {
+		return nil, nil // not found
+	}
+	root, path := t.getRoot(orgId, path)
+	if root == nil {
+		return nil, nil // not found (or not ready)
+	}
+	store := root.Store()
+	if store == nil {
+		return nil, fmt.Errorf("store not ready")
+	}
+	return store.GetReader(ctx, path, readRange)
+}
+
 func (t *nestedTree) getStorages(orgId int64) []storageRuntime {
 	globalStorages := make([]storageRuntime, 0)
 	globalStorages = append(globalStorages, t.rootsByOrgId[ac.GlobalOrgID]...)
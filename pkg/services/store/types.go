@@ -39,6 +39,7 @@ type WriteValueResponse struct {
 
 type storageTree interface {
 	GetFile(ctx context.Context, orgId int64, path string) (*filestorage.File, error)
+	GetFileStream(ctx context.Context, orgId int64, path string, readRange *filestorage.ReadRange) (*filestorage.FileStream, error)
 	ListFolder(ctx context.Context, orgId int64, path string, accessFilter filestorage.PathFilter) (*StorageListFrame, error)
 }
 
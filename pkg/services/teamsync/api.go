@@ -0,0 +1,241 @@
+package teamsync
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *Service) registerAPIEndpoints() {
+	auth := accesscontrol.Middleware(s.accessControl)
+	reqCanAccessTeams := middleware.AdminOrEditorAndFeatureEnabled(s.cfg.EditorsCanAdmin)
+
+	s.routeRegister.Group("/api/teams/:teamId/groups", func(groupsRoute routing.RouteRegister) {
+		groupsRoute.Get("/", auth(reqCanAccessTeams, accesscontrol.EvalPermission(accesscontrol.ActionTeamsPermissionsRead, accesscontrol.ScopeTeamsID)), routing.Wrap(s.getTeamGroupsHandler))
+		groupsRoute.Post("/", auth(reqCanAccessTeams, accesscontrol.EvalPermission(accesscontrol.ActionTeamsPermissionsWrite, accesscontrol.ScopeTeamsID)), routing.Wrap(s.addTeamGroupHandler))
+		groupsRoute.Delete("/", auth(reqCanAccessTeams, accesscontrol.EvalPermission(accesscontrol.ActionTeamsPermissionsWrite, accesscontrol.ScopeTeamsID)), routing.Wrap(s.removeTeamGroupHandler))
+		groupsRoute.Delete("/:groupId", auth(reqCanAccessTeams, accesscontrol.EvalPermission(accesscontrol.ActionTeamsPermissionsWrite, accesscontrol.ScopeTeamsID)), routing.Wrap(s.removeTeamGroupHandler))
+	})
+
+	userIDScope := accesscontrol.Scope("global.users", "id", accesscontrol.Parameter(":id"))
+	s.routeRegister.Post("/api/admin/users/:id/teams/sync/dry-run",
+		auth(middleware.ReqGrafanaAdmin, accesscontrol.EvalPermission(accesscontrol.ActionUsersRead, userIDScope)),
+		routing.Wrap(s.dryRunHandler))
+}
+
+// canAdminTeam checks team admin rights when access control is disabled,
+// otherwise the permission check has been done at middleware layer.
+func (s *Service) canAdminTeam(c *models.ReqContext) (int64, response.Response) {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return 0, response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+	if s.accessControl.IsDisabled() {
+		if err := s.teamGuardian.CanAdmin(c.Req.Context(), c.OrgId, teamID, c.SignedInUser); err != nil {
+			return 0, response.Error(http.StatusForbidden, "Not allowed to manage team groups", err)
+		}
+	}
+	return teamID, nil
+}
+
+// swagger:route GET /teams/{team_id}/groups sync_team_groups getTeamGroups
+//
+// Get the external groups mapped to a team.
+//
+// Responses:
+// 200: getTeamGroupsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (s *Service) getTeamGroupsHandler(c *models.ReqContext) response.Response {
+	teamID, errResp := s.canAdminTeam(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	groups, err := s.GetTeamGroups(c.Req.Context(), c.OrgId, teamID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get team groups", err)
+	}
+	return response.JSON(http.StatusOK, groups)
+}
+
+// swagger:route POST /teams/{team_id}/groups sync_team_groups addTeamGroup
+//
+// Map an external group to a team.
+//
+// Users logging in with OAuth, SAML, JWT, auth proxy or LDAP are added to the team when they are members of the group.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (s *Service) addTeamGroupHandler(c *models.ReqContext) response.Response {
+	teamID, errResp := s.canAdminTeam(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	cmd := AddTeamGroupCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := s.AddTeamGroup(c.Req.Context(), c.OrgId, teamID, cmd.GroupID); err != nil {
+		switch {
+		case errors.Is(err, ErrEmptyGroupID), errors.Is(err, ErrTeamGroupAlreadyAdded):
+			return response.Error(http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, models.ErrTeamNotFound):
+			return response.Error(http.StatusNotFound, "Team not found", nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to add group to team", err)
+	}
+	return response.Success("Group added to Team")
+}
+
+// swagger:route DELETE /teams/{team_id}/groups sync_team_groups removeTeamGroup
+//
+// Remove an external group mapping from a team.
+//
+// The group is given by the `groupId` query parameter, or as a URL encoded path segment: `DELETE /teams/{team_id}/groups/{group_id}`.
+//
+// Users added to the team through the group are removed from the team the next time they log in.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (s *Service) removeTeamGroupHandler(c *models.ReqContext) response.Response {
+	teamID, errResp := s.canAdminTeam(c)
+	if errResp != nil {
+		return errResp
+	}
+
+	groupID := web.Params(c.Req)[":groupId"]
+	if groupID == "" {
+		groupID = c.Query("groupId")
+	}
+	if groupID == "" {
+		return response.Error(http.StatusBadRequest, ErrEmptyGroupID.Error(), nil)
+	}
+
+	if err := s.RemoveTeamGroup(c.Req.Context(), c.OrgId, teamID, groupID); err != nil {
+		if errors.Is(err, ErrTeamGroupNotFound) {
+			return response.Error(http.StatusNotFound, err.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to remove group from team", err)
+	}
+	return response.Success("Team Group removed")
+}
+
+// swagger:route POST /admin/users/{user_id}/teams/sync/dry-run admin_users dryRunTeamSync
+//
+// Show the team memberships a team sync would add and remove for a user with the given groups, without applying them.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: dryRunTeamSyncResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (s *Service) dryRunHandler(c *models.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	cmd := DryRunCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := s.sqlStore.GetUserById(c.Req.Context(), &models.GetUserByIdQuery{Id: userID}); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return response.Error(http.StatusNotFound, user.ErrUserNotFound.Error(), nil)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+
+	plan, err := s.Plan(c.Req.Context(), userID, cmd.Groups)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to compute team sync changes", err)
+	}
+	return response.JSON(http.StatusOK, plan)
+}
+
+type AddTeamGroupCommand struct {
+	GroupID string `json:"groupId"`
+}
+
+type DryRunCommand struct {
+	// Groups of the user, as returned by the identity provider.
+	Groups []string `json:"groups"`
+}
+
+// swagger:parameters addTeamGroup
+type AddTeamGroupParams struct {
+	// in:body
+	// required:true
+	Body AddTeamGroupCommand `json:"body"`
+	// in:path
+	// required:true
+	TeamID string `json:"team_id"`
+}
+
+// swagger:parameters removeTeamGroup
+type RemoveTeamGroupParams struct {
+	// in:query
+	// required:true
+	GroupID string `json:"groupId"`
+	// in:path
+	// required:true
+	TeamID string `json:"team_id"`
+}
+
+// swagger:parameters getTeamGroups
+type GetTeamGroupsParams struct {
+	// in:path
+	// required:true
+	TeamID string `json:"team_id"`
+}
+
+// swagger:parameters dryRunTeamSync
+type DryRunTeamSyncParams struct {
+	// in:body
+	// required:true
+	Body DryRunCommand `json:"body"`
+	// in:path
+	// required:true
+	UserID int64 `json:"user_id"`
+}
+
+// swagger:response getTeamGroupsResponse
+type GetTeamGroupsResponse struct {
+	// in: body
+	Body []*TeamGroup `json:"body"`
+}
+
+// swagger:response dryRunTeamSyncResponse
+type DryRunTeamSyncResponse struct {
+	// in: body
+	Body SyncPlan `json:"body"`
+}
@@ -0,0 +1,129 @@
+package teamsync
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+type teamGroupRow struct {
+	TeamGroup `xorm:"extends"`
+	Created   time.Time `xorm:"created"`
+	Updated   time.Time `xorm:"updated"`
+}
+
+func (teamGroupRow) TableName() string {
+	return "team_group"
+}
+
+// AddTeamGroup maps a group to a team of the organization.
+func (s *Service) AddTeamGroup(ctx context.Context, orgID, teamID int64, groupID string) error {
+	groupID = strings.TrimSpace(groupID)
+	if groupID == "" {
+		return ErrEmptyGroupID
+	}
+
+	if err := s.sqlStore.GetTeamById(ctx, &models.GetTeamByIdQuery{OrgId: orgID, Id: teamID}); err != nil {
+		return err
+	}
+
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		exists, err := sess.Where("org_id = ? AND team_id = ? AND group_id = ?", orgID, teamID, groupID).Exist(&teamGroupRow{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrTeamGroupAlreadyAdded
+		}
+
+		now := time.Now()
+		_, err = sess.Insert(&teamGroupRow{
+			TeamGroup: TeamGroup{OrgID: orgID, TeamID: teamID, GroupID: groupID},
+			Created:   now,
+			Updated:   now,
+		})
+		return err
+	})
+}
+
+// RemoveTeamGroup removes a group mapping from a team. Memberships created
+// through the group are removed the next time their users log in.
+func (s *Service) RemoveTeamGroup(ctx context.Context, orgID, teamID int64, groupID string) error {
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM team_group WHERE org_id = ? AND team_id = ? AND group_id = ?", orgID, teamID, groupID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrTeamGroupNotFound
+		}
+		return nil
+	})
+}
+
+// GetTeamGroups returns the groups mapped to a team.
+func (s *Service) GetTeamGroups(ctx context.Context, orgID, teamID int64) ([]*TeamGroup, error) {
+	groups := make([]*TeamGroup, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.Table("team_group").
+			Where("org_id = ? AND team_id = ?", orgID, teamID).
+			Asc("group_id").
+			Find(&groups)
+	})
+	return groups, err
+}
+
+type teamGroupMapping struct {
+	OrgID    int64  `xorm:"org_id"`
+	TeamID   int64  `xorm:"team_id"`
+	TeamName string `xorm:"team_name"`
+	GroupID  string `xorm:"group_id"`
+}
+
+// userOrgTeamGroups returns the group mappings of the teams in the organizations the user belongs to.
+func (s *Service) userOrgTeamGroups(ctx context.Context, userID int64) ([]*teamGroupMapping, error) {
+	mappings := make([]*teamGroupMapping, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(`SELECT team_group.org_id, team_group.team_id, team.name AS team_name, team_group.group_id
+			FROM team_group
+			INNER JOIN team ON team.id = team_group.team_id
+			INNER JOIN org_user ON org_user.org_id = team_group.org_id AND org_user.user_id = ?`, userID).
+			Find(&mappings)
+	})
+	return mappings, err
+}
+
+type teamMembership struct {
+	OrgID    int64  `xorm:"org_id"`
+	TeamID   int64  `xorm:"team_id"`
+	TeamName string `xorm:"team_name"`
+	External bool   `xorm:"external"`
+}
+
+// userTeamMemberships returns the team memberships of the user indexed by team ID.
+func (s *Service) userTeamMemberships(ctx context.Context, userID int64) (map[int64]*teamMembership, error) {
+	rows := make([]*teamMembership, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		return sess.SQL(`SELECT team_member.org_id, team_member.team_id, team.name AS team_name, team_member.external
+			FROM team_member
+			INNER JOIN team ON team.id = team_member.team_id
+			WHERE team_member.user_id = ?`, userID).
+			Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := make(map[int64]*teamMembership, len(rows))
+	for _, m := range rows {
+		memberships[m.TeamID] = m
+	}
+	return memberships, nil
+}
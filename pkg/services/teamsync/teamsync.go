@@ -0,0 +1,178 @@
+package teamsync
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/teamguardian"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	ErrTeamGroupAlreadyAdded = errors.New("group is already added to this team")
+	ErrTeamGroupNotFound     = errors.New("group is not mapped to this team")
+	ErrEmptyGroupID          = errors.New("group ID cannot be empty")
+)
+
+// TeamGroup maps an external group, as found in ExternalUserInfo.Groups, to a team.
+type TeamGroup struct {
+	ID      int64  `json:"-" xorm:"pk autoincr 'id'"`
+	OrgID   int64  `json:"orgId" xorm:"org_id"`
+	TeamID  int64  `json:"teamId" xorm:"team_id"`
+	GroupID string `json:"groupId" xorm:"group_id"`
+}
+
+// TeamChange is a team membership added, removed or kept by a team sync.
+type TeamChange struct {
+	OrgID    int64  `json:"orgId"`
+	TeamID   int64  `json:"teamId"`
+	TeamName string `json:"teamName"`
+	// Groups are the team group mappings matched by the user groups.
+	Groups []string `json:"groups"`
+}
+
+// SyncPlan is the set of team membership changes a team sync makes for a user.
+type SyncPlan struct {
+	Added     []*TeamChange `json:"added"`
+	Removed   []*TeamChange `json:"removed"`
+	Unchanged []*TeamChange `json:"unchanged"`
+}
+
+// Service adds and removes the team memberships of users logging in with an
+// external identity provider based on the groups mapped to each team.
+// Memberships created by team sync are flagged as external and are the only
+// ones it removes, so manually added members are left untouched.
+type Service struct {
+	cfg                    *setting.Cfg
+	log                    log.Logger
+	sqlStore               *sqlstore.SQLStore
+	teamPermissionsService accesscontrol.TeamPermissionsService
+	teamGuardian           teamguardian.TeamGuardian
+	accessControl          accesscontrol.AccessControl
+	routeRegister          routing.RouteRegister
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore *sqlstore.SQLStore, loginService login.Service,
+	teamPermissionsService accesscontrol.TeamPermissionsService, teamGuardian teamguardian.TeamGuardian,
+	accessControl accesscontrol.AccessControl, routeRegister routing.RouteRegister) *Service {
+	s := &Service{
+		cfg:                    cfg,
+		log:                    log.New("teamsync"),
+		sqlStore:               sqlStore,
+		teamPermissionsService: teamPermissionsService,
+		teamGuardian:           teamGuardian,
+		accessControl:          accessControl,
+		routeRegister:          routeRegister,
+	}
+	loginService.SetTeamSyncFunc(s.SyncTeams)
+	s.registerAPIEndpoints()
+	return s
+}
+
+// SyncTeams is the login.TeamSyncFunc run every time an external user is upserted.
+func (s *Service) SyncTeams(ctx context.Context, usr *user.User, externalUser *models.ExternalUserInfo) error {
+	plan, err := s.Plan(ctx, usr.ID, externalUser.Groups)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range plan.Added {
+		if err := s.setMembership(ctx, usr.ID, change, "Member"); err != nil {
+			return err
+		}
+		s.log.Debug("Added user to team", "user", usr.Login, "teamId", change.TeamID, "groups", change.Groups)
+	}
+	for _, change := range plan.Removed {
+		if err := s.setMembership(ctx, usr.ID, change, ""); err != nil {
+			return err
+		}
+		s.log.Debug("Removed user from team", "user", usr.Login, "teamId", change.TeamID)
+	}
+	return nil
+}
+
+func (s *Service) setMembership(ctx context.Context, userID int64, change *TeamChange, permission string) error {
+	_, err := s.teamPermissionsService.SetUserPermission(ctx, change.OrgID,
+		accesscontrol.User{ID: userID, IsExternal: true}, strconv.FormatInt(change.TeamID, 10), permission)
+	return err
+}
+
+// Plan computes the team memberships to add and remove for a user with the
+// given groups, without applying them. Only teams of the organizations the
+// user belongs to are considered.
+func (s *Service) Plan(ctx context.Context, userID int64, groups []string) (*SyncPlan, error) {
+	mappings, err := s.userOrgTeamGroups(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.userTeamMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// teams mapped to at least one of the user groups
+	matched := map[int64]*TeamChange{}
+	for _, m := range mappings {
+		for _, group := range groups {
+			if !groupMatches(m.GroupID, group) {
+				continue
+			}
+			change, ok := matched[m.TeamID]
+			if !ok {
+				change = &TeamChange{OrgID: m.OrgID, TeamID: m.TeamID, TeamName: m.TeamName, Groups: []string{}}
+				matched[m.TeamID] = change
+			}
+			change.Groups = append(change.Groups, m.GroupID)
+			break
+		}
+	}
+
+	plan := &SyncPlan{Added: []*TeamChange{}, Removed: []*TeamChange{}, Unchanged: []*TeamChange{}}
+	for teamID, change := range matched {
+		if _, ok := memberships[teamID]; ok {
+			plan.Unchanged = append(plan.Unchanged, change)
+		} else {
+			plan.Added = append(plan.Added, change)
+		}
+	}
+	for teamID, membership := range memberships {
+		if _, ok := matched[teamID]; ok || !membership.External {
+			continue
+		}
+		plan.Removed = append(plan.Removed, &TeamChange{
+			OrgID: membership.OrgID, TeamID: teamID, TeamName: membership.TeamName, Groups: []string{},
+		})
+	}
+
+	for _, changes := range [][]*TeamChange{plan.Added, plan.Removed, plan.Unchanged} {
+		changes := changes
+		sort.Slice(changes, func(i, j int) bool { return changes[i].TeamID < changes[j].TeamID })
+	}
+	return plan, nil
+}
+
+// groupMatches reports whether a user group matches a team group mapping.
+// Matching is case insensitive, as LDAP distinguished names are, and a
+// mapping with a wildcard common name such as cn=*,ou=groups,dc=grafana,dc=org
+// matches any group of that organizational unit.
+func groupMatches(mapping, group string) bool {
+	if strings.EqualFold(mapping, group) {
+		return true
+	}
+	if !strings.HasPrefix(strings.ToLower(mapping), "cn=*,") {
+		return false
+	}
+	parts := strings.SplitN(group, ",", 2)
+	return len(parts) == 2 && strings.HasPrefix(strings.ToLower(parts[0]), "cn=") &&
+		strings.EqualFold(strings.TrimSpace(parts[1]), strings.TrimSpace(mapping[len("cn=*,"):]))
+}
@@ -0,0 +1,156 @@
+package teamsync
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// fakeTeamPermissionsService updates team memberships the way the team
+// permissions service hook does.
+type fakeTeamPermissionsService struct {
+	accesscontrol.TeamPermissionsService
+	sqlStore *sqlstore.SQLStore
+}
+
+func (f *fakeTeamPermissionsService) SetUserPermission(ctx context.Context, orgID int64, usr accesscontrol.User, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
+	teamID, err := strconv.ParseInt(resourceID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if permission == "" {
+		return nil, f.sqlStore.RemoveTeamMember(ctx, &models.RemoveTeamMemberCommand{OrgId: orgID, TeamId: teamID, UserId: usr.ID})
+	}
+	return nil, f.sqlStore.AddTeamMember(usr.ID, orgID, teamID, usr.IsExternal, 0)
+}
+
+func setupTestService(t *testing.T) (*Service, *sqlstore.SQLStore) {
+	t.Helper()
+	sqlStore := sqlstore.InitTestDB(t)
+	return &Service{
+		cfg:                    setting.NewCfg(),
+		log:                    log.New("teamsync"),
+		sqlStore:               sqlStore,
+		teamPermissionsService: &fakeTeamPermissionsService{sqlStore: sqlStore},
+	}, sqlStore
+}
+
+func createTeam(t *testing.T, s *Service, name string, orgID int64, groups ...string) models.Team {
+	t.Helper()
+	team, err := s.sqlStore.CreateTeam(name, "", orgID)
+	require.NoError(t, err)
+	for _, group := range groups {
+		require.NoError(t, s.AddTeamGroup(context.Background(), orgID, team.Id, group))
+	}
+	return team
+}
+
+func teamIDs(changes []*TeamChange) []int64 {
+	ids := []int64{}
+	for _, c := range changes {
+		ids = append(ids, c.TeamID)
+	}
+	return ids
+}
+
+func TestService_SyncTeams(t *testing.T) {
+	ctx := context.Background()
+	s, sqlStore := setupTestService(t)
+
+	usr, err := sqlStore.CreateUser(ctx, user.CreateUserCommand{Login: "alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	orgID := usr.OrgID
+
+	admins := createTeam(t, s, "admins", orgID, "cn=admins,ou=groups,dc=grafana,dc=org")
+	editors := createTeam(t, s, "editors", orgID, "editors", "cn=*,ou=editors,dc=grafana,dc=org")
+	viewers := createTeam(t, s, "viewers", orgID, "viewers")
+	manual := createTeam(t, s, "manual", orgID, "manual")
+	require.NoError(t, sqlStore.AddTeamMember(usr.ID, orgID, manual.Id, false, 0))
+
+	// teams of organizations the user does not belong to are ignored
+	other, err := sqlStore.CreateOrgWithMember("other", 0)
+	require.NoError(t, err)
+	createTeam(t, s, "other admins", other.Id, "cn=admins,ou=groups,dc=grafana,dc=org")
+
+	t.Run("adds the user to the teams mapped to its groups", func(t *testing.T) {
+		extUser := &models.ExternalUserInfo{Groups: []string{"CN=Admins,OU=Groups,DC=grafana,DC=org", "cn=frontend,ou=editors,dc=grafana,dc=org", "viewers"}}
+		require.NoError(t, s.SyncTeams(context.Background(), usr, extUser))
+
+		teams, err := sqlStore.GetUserTeamMemberships(ctx, orgID, usr.ID, true)
+		require.NoError(t, err)
+		var synced []int64
+		for _, m := range teams {
+			synced = append(synced, m.TeamId)
+		}
+		assert.ElementsMatch(t, []int64{admins.Id, editors.Id, viewers.Id}, synced)
+	})
+
+	t.Run("dry run shows the changes without applying them", func(t *testing.T) {
+		plan, err := s.Plan(ctx, usr.ID, []string{"editors", "manual"})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{}, teamIDs(plan.Added))
+		assert.Equal(t, []int64{admins.Id, viewers.Id}, teamIDs(plan.Removed))
+		assert.Equal(t, []int64{editors.Id, manual.Id}, teamIDs(plan.Unchanged))
+		assert.Equal(t, []string{"editors"}, plan.Unchanged[0].Groups)
+
+		teams, err := sqlStore.GetUserTeamMemberships(ctx, orgID, usr.ID, true)
+		require.NoError(t, err)
+		assert.Len(t, teams, 3)
+	})
+
+	t.Run("removes synced memberships only", func(t *testing.T) {
+		require.NoError(t, s.SyncTeams(context.Background(), usr, &models.ExternalUserInfo{}))
+
+		external, err := sqlStore.GetUserTeamMemberships(ctx, orgID, usr.ID, true)
+		require.NoError(t, err)
+		assert.Empty(t, external)
+
+		isMember, err := sqlStore.IsTeamMember(orgID, manual.Id, usr.ID)
+		require.NoError(t, err)
+		assert.True(t, isMember)
+	})
+}
+
+func TestService_TeamGroups(t *testing.T) {
+	ctx := context.Background()
+	s, _ := setupTestService(t)
+	team := createTeam(t, s, "team", 1, "group-a")
+
+	require.ErrorIs(t, s.AddTeamGroup(ctx, 1, team.Id, "group-a"), ErrTeamGroupAlreadyAdded)
+	require.ErrorIs(t, s.AddTeamGroup(ctx, 1, team.Id, " "), ErrEmptyGroupID)
+	require.ErrorIs(t, s.AddTeamGroup(ctx, 1, team.Id+1, "group-b"), models.ErrTeamNotFound)
+	require.NoError(t, s.AddTeamGroup(ctx, 1, team.Id, "group-b"))
+
+	groups, err := s.GetTeamGroups(ctx, 1, team.Id)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "group-a", groups[0].GroupID)
+	assert.Equal(t, team.Id, groups[0].TeamID)
+
+	require.NoError(t, s.RemoveTeamGroup(ctx, 1, team.Id, "group-a"))
+	require.ErrorIs(t, s.RemoveTeamGroup(ctx, 1, team.Id, "group-a"), ErrTeamGroupNotFound)
+
+	require.NoError(t, s.sqlStore.DeleteTeam(ctx, &models.DeleteTeamCommand{OrgId: 1, Id: team.Id}))
+	groups, err = s.GetTeamGroups(ctx, 1, team.Id)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestGroupMatches(t *testing.T) {
+	assert.True(t, groupMatches("admins", "Admins"))
+	assert.False(t, groupMatches("admins", "admins2"))
+	assert.True(t, groupMatches("cn=*,ou=groups,dc=grafana,dc=org", "cn=users,ou=groups,dc=grafana,dc=org"))
+	assert.True(t, groupMatches("cn=*,ou=groups,dc=grafana,dc=org", "CN=users, OU=groups,DC=grafana,DC=org"))
+	assert.False(t, groupMatches("cn=*,ou=groups,dc=grafana,dc=org", "cn=users,ou=other,dc=grafana,dc=org"))
+	assert.False(t, groupMatches("cn=*,ou=groups,dc=grafana,dc=org", "ou=groups,dc=grafana,dc=org"))
+}
@@ -0,0 +1,74 @@
+package twofactor
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to 6 digits
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for ts, expected := range tests {
+		code, err := GenerateCode(rfcSecret, Counter(time.Unix(ts, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, "time %d", ts)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	counter := Counter(now)
+
+	previous, err := GenerateCode(rfcSecret, counter-1)
+	require.NoError(t, err)
+	tooOld, err := GenerateCode(rfcSecret, counter-2)
+	require.NoError(t, err)
+
+	matched, ok := ValidateCode(rfcSecret, "005924", now, 0)
+	require.True(t, ok)
+	assert.Equal(t, counter, matched)
+
+	_, ok = ValidateCode(rfcSecret, "005 924", now, 0)
+	assert.True(t, ok)
+
+	_, ok = ValidateCode(rfcSecret, previous, now, 0)
+	assert.True(t, ok, "codes of the previous period are accepted")
+
+	_, ok = ValidateCode(rfcSecret, tooOld, now, 0)
+	assert.False(t, ok)
+
+	_, ok = ValidateCode(rfcSecret, "005924", now, counter)
+	assert.False(t, ok, "a code cannot be used twice")
+
+	_, ok = ValidateCode(rfcSecret, "12345", now, 0)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	u, err := url.Parse(ProvisioningURI(secret, "Grafana", "admin@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Grafana:admin@example.com", u.Path)
+	assert.Equal(t, secret, u.Query().Get("secret"))
+	assert.Equal(t, "Grafana", u.Query().Get("issuer"))
+}
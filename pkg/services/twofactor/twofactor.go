@@ -0,0 +1,93 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotEnabled        = errors.New("two-factor authentication is not enabled for the user")
+	ErrAlreadyEnabled    = errors.New("two-factor authentication is already enabled for the user")
+	ErrNoEnrollment      = errors.New("no pending two-factor enrollment")
+	ErrInvalidCode       = errors.New("invalid two-factor authentication code")
+	ErrRequiredByOrg     = errors.New("two-factor authentication is required by an organization of the user")
+	ErrFeatureDisabled   = errors.New("two-factor authentication is disabled")
+	ErrTooManyAttempts   = errors.New("too many invalid two-factor authentication codes")
+	ErrChallengeNotFound = errors.New("two-factor challenge not found or expired")
+)
+
+const (
+	// RecoveryCodesCount is the number of recovery codes issued on enrollment.
+	RecoveryCodesCount = 10
+	// ChallengeTTL is the time a user has to enter a code after entering their password.
+	ChallengeTTL = 5 * time.Minute
+	// MaxChallengeAttempts is the number of invalid codes after which the user has to enter their password again.
+	MaxChallengeAttempts = 5
+)
+
+type Service interface {
+	// IsEnabled reports whether two-factor authentication is enabled in the configuration.
+	IsEnabled() bool
+	GetStatus(ctx context.Context, userID int64) (*Status, error)
+	// IsRequired reports whether one of the organizations of the user requires two-factor authentication.
+	IsRequired(ctx context.Context, userID int64) (bool, error)
+
+	// BeginEnrollment generates a new secret for the user. Two-factor authentication is only enabled
+	// once the enrollment is confirmed with a code generated from that secret.
+	BeginEnrollment(ctx context.Context, userID int64, account string) (*Enrollment, error)
+	// ConfirmEnrollment enables two-factor authentication and returns the recovery codes of the user.
+	// Recovery codes are only stored hashed and cannot be retrieved later.
+	ConfirmEnrollment(ctx context.Context, userID int64, code string) ([]string, error)
+	// Verify checks a TOTP code, or a recovery code which is then consumed.
+	Verify(ctx context.Context, userID int64, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error)
+	// Disable removes the two-factor authentication settings of the user, it's also used for admin resets.
+	Disable(ctx context.Context, userID int64) error
+
+	GetOrgPolicy(ctx context.Context, orgID int64) (*OrgPolicy, error)
+	SetOrgPolicy(ctx context.Context, policy *OrgPolicy) error
+
+	// CreateChallenge starts the second step of a login once the password of the user has been
+	// checked and returns the token identifying it.
+	CreateChallenge(ctx context.Context, userID int64, enrollment bool) (string, error)
+	GetChallenge(ctx context.Context, token string) (*Challenge, error)
+	// AttemptChallenge atomically counts an attempt to enter a code before it's checked, so that
+	// concurrent requests cannot exceed MaxChallengeAttempts. The challenge is deleted and
+	// ErrTooManyAttempts returned once all the attempts have been used.
+	AttemptChallenge(ctx context.Context, token string) (*Challenge, error)
+	DeleteChallenge(ctx context.Context, token string) error
+}
+
+// Status is the two-factor authentication state of a user
+type Status struct {
+	Enabled bool `json:"enabled"`
+	// Required is true when an organization of the user requires two-factor authentication
+	Required               bool       `json:"required"`
+	RecoveryCodesRemaining int        `json:"recoveryCodesRemaining"`
+	EnabledAt              *time.Time `json:"enabledAt,omitempty"`
+}
+
+// Enrollment holds the secret to add to an authenticator app, either by scanning
+// a QR code of the provisioning URI or by typing the secret.
+type Enrollment struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// provisioning URI
+	URI string `json:"uri"`
+}
+
+type OrgPolicy struct {
+	OrgID    int64 `json:"orgId"`
+	Required bool  `json:"required"`
+}
+
+// Challenge is a login waiting for a two-factor authentication code
+type Challenge struct {
+	UserID int64
+	// Enrollment is true when an organization requires two-factor authentication but the user
+	// hasn't enrolled yet, the login completes once the enrollment is confirmed.
+	Enrollment bool
+	// Attempts is the number of codes entered, including the one being checked.
+	Attempts int
+	Expires  time.Time
+}
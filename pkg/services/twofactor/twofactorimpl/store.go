@@ -0,0 +1,289 @@
+package twofactorimpl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/db"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+)
+
+var errNotFound = errors.New("two-factor settings not found")
+
+// userTwoFactor is the two-factor authentication settings of a user. The secret is encrypted
+// with the secrets service and the recovery codes are hashed with a per user salt.
+type userTwoFactor struct {
+	ID              int64     `xorm:"pk autoincr 'id'"`
+	UserID          int64     `xorm:"user_id"`
+	Secret          string    `xorm:"secret"`
+	Salt            string    `xorm:"salt"`
+	RecoveryCodes   []string  `xorm:"recovery_codes"`
+	Enabled         bool      `xorm:"enabled"`
+	LastUsedCounter int64     `xorm:"last_used_counter"`
+	Created         time.Time `xorm:"created"`
+	Updated         time.Time `xorm:"updated"`
+}
+
+func (userTwoFactor) TableName() string {
+	return "user_two_factor"
+}
+
+type orgTwoFactorPolicy struct {
+	ID       int64     `xorm:"pk autoincr 'id'"`
+	OrgID    int64     `xorm:"org_id"`
+	Required bool      `xorm:"required"`
+	Updated  time.Time `xorm:"updated"`
+}
+
+func (orgTwoFactorPolicy) TableName() string {
+	return "org_two_factor_policy"
+}
+
+// userTwoFactorChallenge is a login waiting for a code, the token is stored hashed.
+type userTwoFactorChallenge struct {
+	ID         int64  `xorm:"pk autoincr 'id'"`
+	Token      string `xorm:"token"`
+	UserID     int64  `xorm:"user_id"`
+	Enrollment bool   `xorm:"enrollment"`
+	Attempts   int    `xorm:"attempts"`
+	Expires    int64  `xorm:"expires"`
+}
+
+func (userTwoFactorChallenge) TableName() string {
+	return "user_two_factor_challenge"
+}
+
+type store interface {
+	Get(ctx context.Context, userID int64) (*userTwoFactor, error)
+	// Save inserts or replaces the settings of the user.
+	Save(ctx context.Context, settings *userTwoFactor) error
+	// Enable enables two-factor authentication of a pending enrollment.
+	Enable(ctx context.Context, userID int64, counter int64, recoveryCodes []string) error
+	// UseCounter records the time step of a used code, it fails when a code of that time step
+	// or a later one has already been used.
+	UseCounter(ctx context.Context, userID int64, counter int64) error
+	// ReplaceRecoveryCodes replaces the recovery codes, it fails when they have been changed since
+	// they were read so that a recovery code cannot be used twice.
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, old, recoveryCodes []string) error
+	Delete(ctx context.Context, userID int64) error
+
+	// IsRequired reports whether an organization of the user requires two-factor authentication.
+	IsRequired(ctx context.Context, userID int64) (bool, error)
+	GetOrgPolicy(ctx context.Context, orgID int64) (*twofactor.OrgPolicy, error)
+	SetOrgPolicy(ctx context.Context, policy *twofactor.OrgPolicy) error
+
+	// CreateChallenge inserts a challenge and deletes the expired ones.
+	CreateChallenge(ctx context.Context, challenge *userTwoFactorChallenge) error
+	GetChallenge(ctx context.Context, token string, now int64) (*userTwoFactorChallenge, error)
+	// AttemptChallenge atomically counts an attempt of a challenge and returns it, it fails with
+	// ErrTooManyAttempts when all the attempts have been used.
+	AttemptChallenge(ctx context.Context, token string, now int64) (*userTwoFactorChallenge, error)
+	DeleteChallenge(ctx context.Context, token string) error
+}
+
+type sqlStore struct {
+	db db.DB
+}
+
+func (s *sqlStore) Get(ctx context.Context, userID int64) (*userTwoFactor, error) {
+	settings := &userTwoFactor{}
+	err := s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("user_id = ?", userID).Get(settings)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return errNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *sqlStore) Save(ctx context.Context, settings *userTwoFactor) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Exec("DELETE FROM user_two_factor WHERE user_id = ?", settings.UserID); err != nil {
+			return err
+		}
+		now := time.Now()
+		settings.ID = 0
+		settings.Created = now
+		settings.Updated = now
+		_, err := sess.Insert(settings)
+		return err
+	})
+}
+
+func (s *sqlStore) Enable(ctx context.Context, userID int64, counter int64, recoveryCodes []string) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("user_id = ? AND enabled = ? AND last_used_counter < ?", userID, false, counter).
+			Cols("enabled", "last_used_counter", "recovery_codes", "updated").
+			Update(&userTwoFactor{Enabled: true, LastUsedCounter: counter, RecoveryCodes: recoveryCodes, Updated: time.Now()})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return twofactor.ErrNoEnrollment
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) UseCounter(ctx context.Context, userID int64, counter int64) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		affected, err := sess.Where("user_id = ? AND last_used_counter < ?", userID, counter).
+			Cols("last_used_counter", "updated").
+			Update(&userTwoFactor{LastUsedCounter: counter, Updated: time.Now()})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return twofactor.ErrInvalidCode
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) ReplaceRecoveryCodes(ctx context.Context, userID int64, old, recoveryCodes []string) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		current := &userTwoFactor{}
+		has, err := sess.Where("user_id = ?", userID).ForUpdate().Get(current)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return errNotFound
+		}
+		if !equalCodes(current.RecoveryCodes, old) {
+			return twofactor.ErrInvalidCode
+		}
+		_, err = sess.Where("user_id = ?", userID).
+			Cols("recovery_codes", "updated").
+			Update(&userTwoFactor{RecoveryCodes: recoveryCodes, Updated: time.Now()})
+		return err
+	})
+}
+
+func (s *sqlStore) Delete(ctx context.Context, userID int64) error {
+	return s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("DELETE FROM user_two_factor WHERE user_id = ?", userID)
+		return err
+	})
+}
+
+func (s *sqlStore) IsRequired(ctx context.Context, userID int64) (bool, error) {
+	var required bool
+	err := s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		var err error
+		required, err = sess.SQL(`SELECT 1 FROM org_two_factor_policy
+			INNER JOIN org_user ON org_user.org_id = org_two_factor_policy.org_id
+			WHERE org_user.user_id = ? AND org_two_factor_policy.required = ?`, userID, true).Exist()
+		return err
+	})
+	return required, err
+}
+
+func (s *sqlStore) GetOrgPolicy(ctx context.Context, orgID int64) (*twofactor.OrgPolicy, error) {
+	policy := &orgTwoFactorPolicy{}
+	err := s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Where("org_id = ?", orgID).Get(policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &twofactor.OrgPolicy{OrgID: orgID, Required: policy.Required}, nil
+}
+
+func (s *sqlStore) SetOrgPolicy(ctx context.Context, policy *twofactor.OrgPolicy) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Exec("DELETE FROM org_two_factor_policy WHERE org_id = ?", policy.OrgID); err != nil {
+			return err
+		}
+		_, err := sess.Insert(&orgTwoFactorPolicy{OrgID: policy.OrgID, Required: policy.Required, Updated: time.Now()})
+		return err
+	})
+}
+
+func (s *sqlStore) CreateChallenge(ctx context.Context, challenge *userTwoFactorChallenge) error {
+	return s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		if _, err := sess.Exec("DELETE FROM user_two_factor_challenge WHERE expires <= ?", time.Now().Unix()); err != nil {
+			return err
+		}
+		_, err := sess.Insert(challenge)
+		return err
+	})
+}
+
+func (s *sqlStore) GetChallenge(ctx context.Context, token string, now int64) (*userTwoFactorChallenge, error) {
+	challenge := &userTwoFactorChallenge{}
+	err := s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		has, err := sess.Where("token = ? AND expires > ? AND attempts < ?", token, now, twofactor.MaxChallengeAttempts).Get(challenge)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return twofactor.ErrChallengeNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (s *sqlStore) AttemptChallenge(ctx context.Context, token string, now int64) (*userTwoFactorChallenge, error) {
+	challenge := &userTwoFactorChallenge{}
+	err := s.db.WithTransactionalDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("UPDATE user_two_factor_challenge SET attempts = attempts + 1 WHERE token = ? AND expires > ? AND attempts < ?",
+			token, now, twofactor.MaxChallengeAttempts)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		has, err := sess.Where("token = ? AND expires > ?", token, now).Get(challenge)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return twofactor.ErrChallengeNotFound
+		}
+		if affected == 0 {
+			return twofactor.ErrTooManyAttempts
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (s *sqlStore) DeleteChallenge(ctx context.Context, token string) error {
+	return s.db.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		_, err := sess.Exec("DELETE FROM user_two_factor_challenge WHERE token = ?", token)
+		return err
+	})
+}
+
+func equalCodes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
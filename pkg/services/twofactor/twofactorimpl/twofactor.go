@@ -0,0 +1,331 @@
+package twofactorimpl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/sqlstore/db"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// recovery codes are formatted as two groups of 5 characters, easier to type than a single group
+	recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+	recoveryCodeLength   = 10
+)
+
+type Service struct {
+	cfg            *setting.Cfg
+	store          store
+	secretsService secrets.Service
+	log            log.Logger
+	now            func() time.Time
+}
+
+func ProvideService(cfg *setting.Cfg, db db.DB, secretsService secrets.Service) *Service {
+	return &Service{
+		cfg:            cfg,
+		store:          &sqlStore{db: db},
+		secretsService: secretsService,
+		log:            log.New("twofactor"),
+		now:            time.Now,
+	}
+}
+
+func (s *Service) IsEnabled() bool {
+	return s.cfg.TwoFactor.Enabled
+}
+
+func (s *Service) GetStatus(ctx context.Context, userID int64) (*twofactor.Status, error) {
+	required, err := s.store.IsRequired(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &twofactor.Status{Required: required}
+	settings, err := s.store.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return status, nil
+		}
+		return nil, err
+	}
+
+	if settings.Enabled {
+		status.Enabled = true
+		status.RecoveryCodesRemaining = len(settings.RecoveryCodes)
+		status.EnabledAt = &settings.Updated
+	}
+	return status, nil
+}
+
+func (s *Service) IsRequired(ctx context.Context, userID int64) (bool, error) {
+	return s.store.IsRequired(ctx, userID)
+}
+
+func (s *Service) BeginEnrollment(ctx context.Context, userID int64, account string) (*twofactor.Enrollment, error) {
+	if !s.IsEnabled() {
+		return nil, twofactor.ErrFeatureDisabled
+	}
+
+	current, err := s.store.Get(ctx, userID)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, err
+	}
+	if current != nil && current.Enabled {
+		return nil, twofactor.ErrAlreadyEnabled
+	}
+
+	secret, err := twofactor.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.secretsService.Encrypt(ctx, []byte(secret), secrets.WithoutScope())
+	if err != nil {
+		return nil, err
+	}
+	salt, err := util.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Save(ctx, &userTwoFactor{
+		UserID: userID,
+		Secret: base64.StdEncoding.EncodeToString(encrypted),
+		Salt:   salt,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &twofactor.Enrollment{
+		Secret: secret,
+		URI:    twofactor.ProvisioningURI(secret, s.cfg.TwoFactor.Issuer, account),
+	}, nil
+}
+
+func (s *Service) ConfirmEnrollment(ctx context.Context, userID int64, code string) ([]string, error) {
+	settings, err := s.store.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return nil, twofactor.ErrNoEnrollment
+		}
+		return nil, err
+	}
+	if settings.Enabled {
+		return nil, twofactor.ErrAlreadyEnabled
+	}
+
+	counter, err := s.validateTOTP(ctx, settings, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashed, err := generateRecoveryCodes(settings.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Enable(ctx, userID, counter, hashed); err != nil {
+		return nil, err
+	}
+	s.log.Info("Two-factor authentication enabled", "userId", userID)
+	return codes, nil
+}
+
+func (s *Service) Verify(ctx context.Context, userID int64, code string) error {
+	settings, err := s.store.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return twofactor.ErrNotEnabled
+		}
+		return err
+	}
+	if !settings.Enabled {
+		return twofactor.ErrNotEnabled
+	}
+
+	if len(strings.TrimSpace(code)) == twofactor.Digits {
+		counter, err := s.validateTOTP(ctx, settings, code)
+		if err != nil {
+			return err
+		}
+		return s.store.UseCounter(ctx, userID, counter)
+	}
+	return s.useRecoveryCode(ctx, settings, code)
+}
+
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	settings, err := s.store.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			return nil, twofactor.ErrNotEnabled
+		}
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, twofactor.ErrNotEnabled
+	}
+
+	codes, hashed, err := generateRecoveryCodes(settings.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.ReplaceRecoveryCodes(ctx, userID, settings.RecoveryCodes, hashed); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) Disable(ctx context.Context, userID int64) error {
+	if err := s.store.Delete(ctx, userID); err != nil {
+		return err
+	}
+	s.log.Info("Two-factor authentication disabled", "userId", userID)
+	return nil
+}
+
+func (s *Service) GetOrgPolicy(ctx context.Context, orgID int64) (*twofactor.OrgPolicy, error) {
+	return s.store.GetOrgPolicy(ctx, orgID)
+}
+
+func (s *Service) SetOrgPolicy(ctx context.Context, policy *twofactor.OrgPolicy) error {
+	return s.store.SetOrgPolicy(ctx, policy)
+}
+
+func (s *Service) CreateChallenge(ctx context.Context, userID int64, enrollment bool) (string, error) {
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.CreateChallenge(ctx, &userTwoFactorChallenge{
+		Token:      hashChallengeToken(token),
+		UserID:     userID,
+		Enrollment: enrollment,
+		Expires:    s.now().Add(twofactor.ChallengeTTL).Unix(),
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *Service) GetChallenge(ctx context.Context, token string) (*twofactor.Challenge, error) {
+	if token == "" {
+		return nil, twofactor.ErrChallengeNotFound
+	}
+	challenge, err := s.store.GetChallenge(ctx, hashChallengeToken(token), s.now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	return toChallenge(challenge), nil
+}
+
+func (s *Service) AttemptChallenge(ctx context.Context, token string) (*twofactor.Challenge, error) {
+	if token == "" {
+		return nil, twofactor.ErrChallengeNotFound
+	}
+	challenge, err := s.store.AttemptChallenge(ctx, hashChallengeToken(token), s.now().Unix())
+	if err != nil {
+		if errors.Is(err, twofactor.ErrTooManyAttempts) {
+			if err := s.DeleteChallenge(ctx, token); err != nil {
+				s.log.Warn("Failed to delete two-factor challenge", "error", err)
+			}
+		}
+		return nil, err
+	}
+	return toChallenge(challenge), nil
+}
+
+func (s *Service) DeleteChallenge(ctx context.Context, token string) error {
+	return s.store.DeleteChallenge(ctx, hashChallengeToken(token))
+}
+
+// hashChallengeToken hashes challenge tokens, which are only stored hashed like session tokens.
+func hashChallengeToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func toChallenge(challenge *userTwoFactorChallenge) *twofactor.Challenge {
+	return &twofactor.Challenge{
+		UserID:     challenge.UserID,
+		Enrollment: challenge.Enrollment,
+		Attempts:   challenge.Attempts,
+		Expires:    time.Unix(challenge.Expires, 0),
+	}
+}
+
+func (s *Service) validateTOTP(ctx context.Context, settings *userTwoFactor, code string) (int64, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(settings.Secret)
+	if err != nil {
+		return 0, err
+	}
+	secret, err := s.secretsService.Decrypt(ctx, encrypted)
+	if err != nil {
+		return 0, err
+	}
+
+	counter, ok := twofactor.ValidateCode(string(secret), code, s.now(), settings.LastUsedCounter)
+	if !ok {
+		return 0, twofactor.ErrInvalidCode
+	}
+	return counter, nil
+}
+
+func (s *Service) useRecoveryCode(ctx context.Context, settings *userTwoFactor, code string) error {
+	hashed, err := hashRecoveryCode(code, settings.Salt)
+	if err != nil {
+		return err
+	}
+
+	remaining := make([]string, 0, len(settings.RecoveryCodes))
+	found := false
+	for _, c := range settings.RecoveryCodes {
+		if !found && c == hashed {
+			found = true
+			continue
+		}
+		remaining = append(remaining, c)
+	}
+	if !found {
+		return twofactor.ErrInvalidCode
+	}
+
+	if err := s.store.ReplaceRecoveryCodes(ctx, settings.UserID, settings.RecoveryCodes, remaining); err != nil {
+		return err
+	}
+	s.log.Info("Recovery code used", "userId", settings.UserID, "remaining", len(remaining))
+	return nil
+}
+
+// generateRecoveryCodes returns new recovery codes and their hashes.
+func generateRecoveryCodes(salt string) ([]string, []string, error) {
+	codes := make([]string, 0, twofactor.RecoveryCodesCount)
+	hashed := make([]string, 0, twofactor.RecoveryCodesCount)
+	for i := 0; i < twofactor.RecoveryCodesCount; i++ {
+		code, err := util.GetRandomString(recoveryCodeLength, []byte(recoveryCodeAlphabet)...)
+		if err != nil {
+			return nil, nil, err
+		}
+		code = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		h, err := hashRecoveryCode(code, salt)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashed = append(hashed, h)
+	}
+	return codes, hashed, nil
+}
+
+func hashRecoveryCode(code, salt string) (string, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return util.EncodePassword(normalized, salt)
+}
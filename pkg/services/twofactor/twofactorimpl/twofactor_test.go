@@ -0,0 +1,181 @@
+package twofactorimpl
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/twofactor"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func setupTestService(t *testing.T) (*Service, *sqlstore.SQLStore) {
+	t.Helper()
+	ss := sqlstore.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.TwoFactor = setting.TwoFactorSettings{Enabled: true, Issuer: "Grafana"}
+	return ProvideService(cfg, ss, fakes.NewFakeSecretsService()), ss
+}
+
+func codeAt(t *testing.T, secret string, now time.Time) string {
+	t.Helper()
+	code, err := twofactor.GenerateCode(secret, twofactor.Counter(now))
+	require.NoError(t, err)
+	return code
+}
+
+func TestIntegrationTwoFactorService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	s, ss := setupTestService(t)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	usr, err := ss.CreateUser(ctx, user.CreateUserCommand{Login: "alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	enrollment, err := s.BeginEnrollment(ctx, usr.ID, usr.Login)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Grafana:alice?")
+
+	status, err := s.GetStatus(ctx, usr.ID)
+	require.NoError(t, err)
+	assert.False(t, status.Enabled, "two-factor authentication is enabled only once the enrollment is confirmed")
+	require.ErrorIs(t, s.Verify(ctx, usr.ID, codeAt(t, enrollment.Secret, now)), twofactor.ErrNotEnabled)
+
+	_, err = s.ConfirmEnrollment(ctx, usr.ID, "000000")
+	require.ErrorIs(t, err, twofactor.ErrInvalidCode)
+	codes, err := s.ConfirmEnrollment(ctx, usr.ID, codeAt(t, enrollment.Secret, now))
+	require.NoError(t, err)
+	require.Len(t, codes, twofactor.RecoveryCodesCount)
+
+	_, err = s.BeginEnrollment(ctx, usr.ID, usr.Login)
+	require.ErrorIs(t, err, twofactor.ErrAlreadyEnabled)
+
+	t.Run("codes cannot be used twice", func(t *testing.T) {
+		require.ErrorIs(t, s.Verify(ctx, usr.ID, codeAt(t, enrollment.Secret, now)), twofactor.ErrInvalidCode)
+
+		now = now.Add(twofactor.Period)
+		require.NoError(t, s.Verify(ctx, usr.ID, codeAt(t, enrollment.Secret, now)))
+		require.ErrorIs(t, s.Verify(ctx, usr.ID, codeAt(t, enrollment.Secret, now)), twofactor.ErrInvalidCode)
+	})
+
+	t.Run("recovery codes are stored hashed and consumed on use", func(t *testing.T) {
+		settings, err := s.store.Get(ctx, usr.ID)
+		require.NoError(t, err)
+		require.Len(t, settings.RecoveryCodes, twofactor.RecoveryCodesCount)
+		assert.NotContains(t, settings.RecoveryCodes, codes[0])
+
+		require.NoError(t, s.Verify(ctx, usr.ID, codes[0]))
+		require.ErrorIs(t, s.Verify(ctx, usr.ID, codes[0]), twofactor.ErrInvalidCode)
+		require.NoError(t, s.Verify(ctx, usr.ID, " "+codes[1][:5]+codes[1][6:]+" "))
+
+		status, err := s.GetStatus(ctx, usr.ID)
+		require.NoError(t, err)
+		assert.True(t, status.Enabled)
+		assert.Equal(t, twofactor.RecoveryCodesCount-2, status.RecoveryCodesRemaining)
+
+		regenerated, err := s.RegenerateRecoveryCodes(ctx, usr.ID)
+		require.NoError(t, err)
+		require.ErrorIs(t, s.Verify(ctx, usr.ID, codes[2]), twofactor.ErrInvalidCode)
+		require.NoError(t, s.Verify(ctx, usr.ID, regenerated[0]))
+	})
+
+	t.Run("organizations can require two-factor authentication", func(t *testing.T) {
+		required, err := s.IsRequired(ctx, usr.ID)
+		require.NoError(t, err)
+		assert.False(t, required)
+
+		require.NoError(t, s.SetOrgPolicy(ctx, &twofactor.OrgPolicy{OrgID: usr.OrgID, Required: true}))
+		required, err = s.IsRequired(ctx, usr.ID)
+		require.NoError(t, err)
+		assert.True(t, required)
+
+		policy, err := s.GetOrgPolicy(ctx, usr.OrgID+1)
+		require.NoError(t, err)
+		assert.False(t, policy.Required)
+	})
+
+	t.Run("disable removes the user settings", func(t *testing.T) {
+		require.NoError(t, s.Disable(ctx, usr.ID))
+		status, err := s.GetStatus(ctx, usr.ID)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled)
+		assert.True(t, status.Required)
+	})
+}
+
+func TestIntegrationChallenge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	s, _ := setupTestService(t)
+
+	token, err := s.CreateChallenge(ctx, 1, true)
+	require.NoError(t, err)
+
+	_, err = s.GetChallenge(ctx, "unknown")
+	require.ErrorIs(t, err, twofactor.ErrChallengeNotFound)
+	_, err = s.AttemptChallenge(ctx, "unknown")
+	require.ErrorIs(t, err, twofactor.ErrChallengeNotFound)
+
+	challenge, err := s.GetChallenge(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), challenge.UserID)
+	assert.True(t, challenge.Enrollment)
+	assert.Equal(t, 0, challenge.Attempts)
+
+	for i := 1; i <= twofactor.MaxChallengeAttempts; i++ {
+		challenge, err = s.AttemptChallenge(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, i, challenge.Attempts)
+	}
+	_, err = s.AttemptChallenge(ctx, token)
+	require.ErrorIs(t, err, twofactor.ErrTooManyAttempts)
+	_, err = s.GetChallenge(ctx, token)
+	require.ErrorIs(t, err, twofactor.ErrChallengeNotFound)
+
+	t.Run("expired challenges are not found", func(t *testing.T) {
+		token, err := s.CreateChallenge(ctx, 1, false)
+		require.NoError(t, err)
+
+		s.now = func() time.Time { return time.Now().Add(twofactor.ChallengeTTL + time.Second) }
+		t.Cleanup(func() { s.now = time.Now })
+		_, err = s.AttemptChallenge(ctx, token)
+		require.ErrorIs(t, err, twofactor.ErrChallengeNotFound)
+	})
+
+	t.Run("concurrent attempts are all counted", func(t *testing.T) {
+		token, err := s.CreateChallenge(ctx, 1, false)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+		for i := 0; i < 3*twofactor.MaxChallengeAttempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := s.AttemptChallenge(ctx, token); err == nil {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, twofactor.MaxChallengeAttempts, allowed)
+	})
+}
@@ -0,0 +1,30 @@
+package twofactortest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/twofactor"
+)
+
+// FakeService implements the status methods of twofactor.Service, other methods panic.
+type FakeService struct {
+	twofactor.Service
+	ExpectedEnabled bool
+	ExpectedStatus  *twofactor.Status
+	ExpectedError   error
+}
+
+func (s *FakeService) IsEnabled() bool {
+	return s.ExpectedEnabled
+}
+
+func (s *FakeService) GetStatus(ctx context.Context, userID int64) (*twofactor.Status, error) {
+	if s.ExpectedStatus == nil {
+		return &twofactor.Status{}, s.ExpectedError
+	}
+	return s.ExpectedStatus, s.ExpectedError
+}
+
+func (s *FakeService) IsRequired(ctx context.Context, userID int64) (bool, error) {
+	return s.ExpectedStatus != nil && s.ExpectedStatus.Required, s.ExpectedError
+}
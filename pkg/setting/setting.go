@@ -319,6 +319,7 @@ type Cfg struct {
 	JWTAuthURLLogin      bool
 	JWTAuthEmailClaim    string
 	JWTAuthUsernameClaim string
+	JWTAuthGroupsClaim   string
 	JWTAuthExpectClaims  string
 	JWTAuthJWKSetURL     string
 	JWTAuthCacheTTL      time.Duration
@@ -397,8 +398,12 @@ type Cfg struct {
 	FeedbackLinksEnabled                bool
 
 	// LDAP
-	LDAPEnabled     bool
-	LDAPAllowSignup bool
+	LDAPEnabled           bool
+	LDAPAllowSignup       bool
+	LDAPSyncCron          string
+	LDAPActiveSyncEnabled bool
+	// LDAPSyncMaxDisabledUsers aborts an active sync that would disable more users, 0 means no limit
+	LDAPSyncMaxDisabledUsers int
 
 	Quota QuotaSettings
 
@@ -442,6 +447,20 @@ type Cfg struct {
 	// Query history
 	QueryHistoryEnabled bool
 
+	Audit AuditSettings
+
+	TwoFactor TwoFactorSettings
+
+	BruteForce BruteForceSettings
+
+	SCIM SCIMSettings
+
+	SQLite SQLiteSettings
+
+	Session SessionSettings
+
+	ServiceAccounts ServiceAccountsSettings
+
 	DashboardPreviews DashboardPreviewsSettings
 
 	Storage StorageSettings
@@ -934,6 +953,9 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	if err := readAuthSettings(iniFile, cfg); err != nil {
 		return err
 	}
+	if err := readSessionSettings(iniFile, cfg); err != nil {
+		return err
+	}
 	readAccessControlSettings(iniFile, cfg)
 	if err := cfg.readRenderingSettings(iniFile); err != nil {
 		return err
@@ -1018,6 +1040,14 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 
 	cfg.DashboardPreviews = readDashboardPreviewsSettings(iniFile)
 	cfg.Storage = readStorageSettings(iniFile)
+	cfg.Audit = readAuditSettings(iniFile)
+	cfg.TwoFactor = readTwoFactorSettings(iniFile)
+	cfg.BruteForce = readBruteForceSettings(iniFile)
+	cfg.SCIM = readSCIMSettings(iniFile)
+	cfg.SQLite = readSQLiteSettings(iniFile)
+	if cfg.ServiceAccounts, err = readServiceAccountsSettings(iniFile); err != nil {
+		return err
+	}
 
 	if VerifyEmailEnabled && !cfg.Smtp.Enabled {
 		cfg.Logger.Warn("require_email_validation is enabled but smtp is disabled")
@@ -1084,10 +1114,13 @@ type RemoteCacheOptions struct {
 func (cfg *Cfg) readLDAPConfig() {
 	ldapSec := cfg.Raw.Section("auth.ldap")
 	LDAPConfigFile = ldapSec.Key("config_file").String()
-	LDAPSyncCron = ldapSec.Key("sync_cron").String()
+	LDAPSyncCron = valueAsString(ldapSec, "sync_cron", "0 1 * * *")
+	cfg.LDAPSyncCron = LDAPSyncCron
 	LDAPEnabled = ldapSec.Key("enabled").MustBool(false)
 	cfg.LDAPEnabled = LDAPEnabled
-	LDAPActiveSyncEnabled = ldapSec.Key("active_sync_enabled").MustBool(false)
+	LDAPActiveSyncEnabled = ldapSec.Key("active_sync_enabled").MustBool(true)
+	cfg.LDAPActiveSyncEnabled = LDAPActiveSyncEnabled
+	cfg.LDAPSyncMaxDisabledUsers = ldapSec.Key("sync_max_disabled_users").MustInt(100)
 	LDAPAllowSignup = ldapSec.Key("allow_sign_up").MustBool(true)
 	cfg.LDAPAllowSignup = LDAPAllowSignup
 }
@@ -1309,6 +1342,7 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	cfg.JWTAuthURLLogin = authJWT.Key("url_login").MustBool(false)
 	cfg.JWTAuthEmailClaim = valueAsString(authJWT, "email_claim", "")
 	cfg.JWTAuthUsernameClaim = valueAsString(authJWT, "username_claim", "")
+	cfg.JWTAuthGroupsClaim = valueAsString(authJWT, "groups_claim", "")
 	cfg.JWTAuthExpectClaims = valueAsString(authJWT, "expect_claims", "{}")
 	cfg.JWTAuthJWKSetURL = valueAsString(authJWT, "jwk_set_url", "")
 	cfg.JWTAuthCacheTTL = authJWT.Key("cache_ttl").MustDuration(time.Minute * 60)
@@ -0,0 +1,35 @@
+package setting
+
+import (
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type AuditSettings struct {
+	Enabled       bool
+	Sinks         []string
+	Categories    []string
+	RetentionDays int
+
+	FilePath         string
+	FileMaxSizeShift int
+	FileDailyRotate  bool
+	FileMaxDays      int64
+}
+
+func readAuditSettings(iniFile *ini.File) AuditSettings {
+	s := AuditSettings{}
+	auditSection := iniFile.Section("audit")
+	s.Enabled = auditSection.Key("enabled").MustBool(false)
+	s.Sinks = util.SplitString(auditSection.Key("sinks").MustString("database"))
+	s.Categories = util.SplitString(auditSection.Key("categories").MustString("admin,permissions,auth,datasources,queries"))
+	s.RetentionDays = auditSection.Key("retention_days").MustInt(90)
+
+	fileSection := iniFile.Section("audit.file")
+	s.FilePath = fileSection.Key("path").MustString("")
+	s.FileMaxSizeShift = fileSection.Key("max_size_shift").MustInt(28)
+	s.FileDailyRotate = fileSection.Key("daily_rotate").MustBool(true)
+	s.FileMaxDays = fileSection.Key("max_days").MustInt64(7)
+	return s
+}
@@ -0,0 +1,39 @@
+package setting
+
+import (
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+type BruteForceSettings struct {
+	// UserMaxAttempts failed logins of a username within UserWindow lock the username
+	UserMaxAttempts int64
+	UserWindow      time.Duration
+	// IPMaxAttempts failed logins from an IP subnet within IPWindow lock the subnet, 0 disables it
+	IPMaxAttempts int64
+	IPWindow      time.Duration
+	// IPv4PrefixLength and IPv6PrefixLength group client addresses into subnets
+	IPv4PrefixLength int
+	IPv6PrefixLength int
+	// LockoutDuration is doubled for each consecutive lockout, up to LockoutMaxDuration which
+	// can't exceed 24 hours
+	LockoutDuration    time.Duration
+	LockoutMaxDuration time.Duration
+	NotifyUser         bool
+}
+
+func readBruteForceSettings(iniFile *ini.File) BruteForceSettings {
+	s := BruteForceSettings{}
+	section := iniFile.Section("security.brute_force")
+	s.UserMaxAttempts = section.Key("user_max_attempts").MustInt64(5)
+	s.UserWindow = section.Key("user_window").MustDuration(5 * time.Minute)
+	s.IPMaxAttempts = section.Key("ip_max_attempts").MustInt64(0)
+	s.IPWindow = section.Key("ip_window").MustDuration(5 * time.Minute)
+	s.IPv4PrefixLength = section.Key("ipv4_prefix_length").MustInt(32)
+	s.IPv6PrefixLength = section.Key("ipv6_prefix_length").MustInt(64)
+	s.LockoutDuration = section.Key("lockout_duration").MustDuration(5 * time.Minute)
+	s.LockoutMaxDuration = section.Key("lockout_max_duration").MustDuration(time.Hour)
+	s.NotifyUser = section.Key("notify_user").MustBool(true)
+	return s
+}
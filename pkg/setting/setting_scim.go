@@ -0,0 +1,19 @@
+package setting
+
+import (
+	"gopkg.in/ini.v1"
+)
+
+type SCIMSettings struct {
+	Enabled bool
+	// MaxResults is the maximum number of resources returned by a list request
+	MaxResults int
+}
+
+func readSCIMSettings(iniFile *ini.File) SCIMSettings {
+	s := SCIMSettings{}
+	section := iniFile.Section("auth.scim")
+	s.Enabled = section.Key("enabled").MustBool(false)
+	s.MaxResults = section.Key("max_results").MustInt(1000)
+	return s
+}
@@ -0,0 +1,46 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"gopkg.in/ini.v1"
+)
+
+type ServiceAccountsSettings struct {
+	// TokenRotationGracePeriod is how long a rotated token stays valid by default
+	TokenRotationGracePeriod time.Duration
+	// TokenExpiryNoticePeriod is how long before their expiration org admins are told about expiring tokens, 0 disables it
+	TokenExpiryNoticePeriod time.Duration
+	// TokenUnusedPeriod is how long a token has not been used before it's considered unused, 0 disables it
+	TokenUnusedPeriod time.Duration
+}
+
+func readServiceAccountsSettings(iniFile *ini.File) (ServiceAccountsSettings, error) {
+	s := ServiceAccountsSettings{}
+	section := iniFile.Section("service_accounts")
+
+	durations := []struct {
+		key string
+		def string
+		val *time.Duration
+	}{
+		{"token_rotation_grace_period", "24h", &s.TokenRotationGracePeriod},
+		{"token_expiry_notice_period", "7d", &s.TokenExpiryNoticePeriod},
+		{"token_unused_period", "90d", &s.TokenUnusedPeriod},
+	}
+	for _, d := range durations {
+		val := valueAsString(section, d.key, d.def)
+		if val == "" || val == "0" {
+			continue
+		}
+		parsed, err := gtime.ParseDuration(val)
+		if err != nil {
+			return s, fmt.Errorf("invalid %s in section [service_accounts]: %w", d.key, err)
+		}
+		*d.val = parsed
+	}
+
+	return s, nil
+}
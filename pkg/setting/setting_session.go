@@ -0,0 +1,80 @@
+package setting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"gopkg.in/ini.v1"
+)
+
+const orgSessionSectionPrefix = "auth.session.org."
+
+type SessionSettings struct {
+	// MaxActiveSessions is the maximum number of active sessions of a user, 0 for unlimited.
+	// The oldest sessions are revoked when a user logs in once more.
+	MaxActiveSessions int
+	// OrgLifetimes override the session lifetimes of the users acting in an organization, by organization ID
+	OrgLifetimes map[int64]SessionLifetime
+}
+
+// SessionLifetime is how long a session lasts, which can only be stricter than the
+// login_maximum_inactive_lifetime_duration and login_maximum_lifetime_duration settings.
+type SessionLifetime struct {
+	MaxInactiveLifetime time.Duration
+	MaxLifetime         time.Duration
+}
+
+// readSessionSettings reads the session settings, after the auth settings they depend on.
+func readSessionSettings(iniFile *ini.File, cfg *Cfg) error {
+	s := SessionSettings{OrgLifetimes: map[int64]SessionLifetime{}}
+	s.MaxActiveSessions = iniFile.Section("auth").Key("login_maximum_active_sessions").MustInt(0)
+	if s.MaxActiveSessions < 0 {
+		s.MaxActiveSessions = 0
+	}
+
+	rotationInterval := time.Duration(cfg.TokenRotationIntervalMinutes) * time.Minute
+	for _, section := range iniFile.Sections() {
+		if !strings.HasPrefix(section.Name(), orgSessionSectionPrefix) {
+			continue
+		}
+
+		orgID, err := strconv.ParseInt(strings.TrimPrefix(section.Name(), orgSessionSectionPrefix), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid organization ID in section [%s]: %w", section.Name(), err)
+		}
+
+		lifetime := SessionLifetime{
+			MaxInactiveLifetime: cfg.LoginMaxInactiveLifetime,
+			MaxLifetime:         cfg.LoginMaxLifetime,
+		}
+		if val := valueAsString(section, "login_maximum_inactive_lifetime_duration", ""); val != "" {
+			d, err := gtime.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid login_maximum_inactive_lifetime_duration in section [%s]: %w", section.Name(), err)
+			}
+			// sessions are only marked as active when their token is rotated
+			if d <= rotationInterval {
+				return fmt.Errorf("login_maximum_inactive_lifetime_duration in section [%s] must be longer than token_rotation_interval_minutes", section.Name())
+			}
+			if d < lifetime.MaxInactiveLifetime {
+				lifetime.MaxInactiveLifetime = d
+			}
+		}
+		if val := valueAsString(section, "login_maximum_lifetime_duration", ""); val != "" {
+			d, err := gtime.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid login_maximum_lifetime_duration in section [%s]: %w", section.Name(), err)
+			}
+			if d < lifetime.MaxLifetime {
+				lifetime.MaxLifetime = d
+			}
+		}
+		s.OrgLifetimes[orgID] = lifetime
+	}
+
+	cfg.Session = s
+	return nil
+}
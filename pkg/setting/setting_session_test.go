@@ -3,10 +3,12 @@ package setting
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log/logtest"
 
 	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
 )
 
 func TestSessionSettings(t *testing.T) {
@@ -29,3 +31,66 @@ func TestSessionSettings(t *testing.T) {
 		require.Greater(t, len(logger.WarnLogs.Message), 0)
 	})
 }
+
+func TestReadSessionSettings(t *testing.T) {
+	newCfg := func() *Cfg {
+		cfg := NewCfg()
+		cfg.LoginMaxInactiveLifetime = 7 * 24 * time.Hour
+		cfg.LoginMaxLifetime = 30 * 24 * time.Hour
+		cfg.TokenRotationIntervalMinutes = 10
+		return cfg
+	}
+
+	t.Run("Should read the maximum active sessions and the organization lifetimes", func(t *testing.T) {
+		iniFile, err := ini.Load([]byte(`
+[auth]
+login_maximum_active_sessions = 3
+
+[auth.session.org.2]
+login_maximum_inactive_lifetime_duration = 1h
+login_maximum_lifetime_duration = 60d
+
+[auth.session.org.3]
+login_maximum_lifetime_duration = 1d
+`))
+		require.NoError(t, err)
+
+		cfg := newCfg()
+		require.NoError(t, readSessionSettings(iniFile, cfg))
+
+		require.Equal(t, 3, cfg.Session.MaxActiveSessions)
+		require.Equal(t, map[int64]SessionLifetime{
+			// longer lifetimes than the global ones are ignored
+			2: {MaxInactiveLifetime: time.Hour, MaxLifetime: 30 * 24 * time.Hour},
+			3: {MaxInactiveLifetime: 7 * 24 * time.Hour, MaxLifetime: 24 * time.Hour},
+		}, cfg.Session.OrgLifetimes)
+	})
+
+	t.Run("Should default to unlimited sessions", func(t *testing.T) {
+		cfg := newCfg()
+		require.NoError(t, readSessionSettings(ini.Empty(), cfg))
+
+		require.Equal(t, 0, cfg.Session.MaxActiveSessions)
+		require.Empty(t, cfg.Session.OrgLifetimes)
+	})
+
+	t.Run("Should fail when the inactive lifetime is not longer than the token rotation interval", func(t *testing.T) {
+		iniFile, err := ini.Load([]byte(`
+[auth.session.org.2]
+login_maximum_inactive_lifetime_duration = 10m
+`))
+		require.NoError(t, err)
+
+		require.Error(t, readSessionSettings(iniFile, newCfg()))
+	})
+
+	t.Run("Should fail on an invalid organization ID", func(t *testing.T) {
+		iniFile, err := ini.Load([]byte(`
+[auth.session.org.main]
+login_maximum_lifetime_duration = 1d
+`))
+		require.NoError(t, err)
+
+		require.Error(t, readSessionSettings(iniFile, newCfg()))
+	})
+}
@@ -0,0 +1,24 @@
+package setting
+
+import (
+	"path/filepath"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type SQLiteSettings struct {
+	// AllowedPaths are the directories which may contain database files of
+	// SQLite data sources. The data source is disabled when it is empty.
+	AllowedPaths []string
+}
+
+func readSQLiteSettings(iniFile *ini.File) SQLiteSettings {
+	s := SQLiteSettings{}
+	section := iniFile.Section("sqlite")
+	for _, path := range util.SplitString(section.Key("allowed_paths").String()) {
+		s.AllowedPaths = append(s.AllowedPaths, filepath.Clean(path))
+	}
+	return s
+}
@@ -6,11 +6,13 @@ import (
 
 type StorageSettings struct {
 	AllowUnsanitizedSvgUpload bool
+	MaxUploadSizeMB           int64
 }
 
 func readStorageSettings(iniFile *ini.File) StorageSettings {
 	s := StorageSettings{}
 	storageSection := iniFile.Section("storage")
 	s.AllowUnsanitizedSvgUpload = storageSection.Key("allow_unsanitized_svg_upload").MustBool(false)
+	s.MaxUploadSizeMB = storageSection.Key("max_upload_size_mb").MustInt64(1)
 	return s
 }
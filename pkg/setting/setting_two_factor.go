@@ -0,0 +1,19 @@
+package setting
+
+import (
+	"gopkg.in/ini.v1"
+)
+
+type TwoFactorSettings struct {
+	Enabled bool
+	// Issuer is the account issuer shown by authenticator apps
+	Issuer string
+}
+
+func readTwoFactorSettings(iniFile *ini.File) TwoFactorSettings {
+	s := TwoFactorSettings{}
+	section := iniFile.Section("auth.two_factor")
+	s.Enabled = section.Key("enabled").MustBool(true)
+	s.Issuer = valueAsString(section, "issuer", "Grafana")
+	return s
+}
@@ -0,0 +1,167 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"xorm.io/core"
+)
+
+const defaultPort = "9000"
+
+var logger = log.New("tsdb.clickhouse")
+
+func init() {
+	// The data source engine only executes raw queries, so xorm can use the
+	// MySQL dialect for the ClickHouse driver.
+	core.RegisterDriver("clickhouse", &clickhouseDriver{})
+}
+
+type clickhouseDriver struct{}
+
+func (d *clickhouseDriver) Parse(driverName, dataSourceName string) (*core.Uri, error) {
+	u, err := url.Parse(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return &core.Uri{DbType: core.MYSQL, DbName: u.Query().Get("database")}, nil
+}
+
+type Service struct {
+	im instancemgmt.InstanceManager
+}
+
+func ProvideService(cfg *setting.Cfg, httpClientProvider httpclient.Provider) *Service {
+	return &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(cfg, httpClientProvider)),
+	}
+}
+
+func newInstanceSettings(cfg *setting.Cfg, httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		jsonData := sqleng.JsonData{
+			MaxOpenConns:    0,
+			MaxIdleConns:    2,
+			ConnMaxLifetime: 14400,
+		}
+
+		err := json.Unmarshal(settings.JSONData, &jsonData)
+		if err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+		dsInfo := sqleng.DataSourceInfo{
+			JsonData:                jsonData,
+			URL:                     settings.URL,
+			User:                    settings.User,
+			Database:                settings.Database,
+			ID:                      settings.ID,
+			Updated:                 settings.Updated,
+			UID:                     settings.UID,
+			DecryptedSecureJSONData: settings.DecryptedSecureJSONData,
+		}
+
+		tlsConfigName := ""
+		opts, err := settings.HTTPClientOptions()
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig, err := httpClientProvider.GetTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if tlsConfig.RootCAs != nil || len(tlsConfig.Certificates) > 0 || tlsConfig.InsecureSkipVerify {
+			tlsConfigName = fmt.Sprintf("ds%d", settings.ID)
+			if err := clickhouse.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		cnnstr := generateConnectionString(dsInfo, tlsConfigName)
+
+		if cfg.Env == setting.Dev {
+			logger.Debug("getEngine", "connection", cnnstr)
+		}
+
+		config := sqleng.DataPluginConfiguration{
+			DriverName:       "clickhouse",
+			ConnectionString: cnnstr,
+			DSInfo:           dsInfo,
+			TimeColumnNames:  []string{"time", "time_sec"},
+			MetricColumnTypes: []string{"String", "LowCardinality(String)", "Nullable(String)",
+				"LowCardinality(Nullable(String))"},
+			RowLimit: cfg.DataProxyRowLimit,
+		}
+
+		rowTransformer := clickhouseQueryResultTransformer{}
+
+		return sqleng.NewQueryDataHandler(config, &rowTransformer, newClickHouseMacroEngine(), logger)
+	}
+}
+
+// generateConnectionString builds the DSN of the native protocol driver, the
+// URL of the data source is the host and port of the ClickHouse server.
+func generateConnectionString(dsInfo sqleng.DataSourceInfo, tlsConfigName string) string {
+	host := strings.TrimPrefix(dsInfo.URL, "tcp://")
+	if !strings.Contains(host, ":") {
+		host += ":" + defaultPort
+	}
+
+	params := url.Values{}
+	if dsInfo.User != "" {
+		params.Set("username", dsInfo.User)
+	}
+	if password := dsInfo.DecryptedSecureJSONData["password"]; password != "" {
+		params.Set("password", password)
+	}
+	if dsInfo.Database != "" {
+		params.Set("database", dsInfo.Database)
+	}
+	if tlsConfigName != "" {
+		params.Set("tls_config", tlsConfigName)
+	}
+
+	return (&url.URL{Scheme: "tcp", Host: host, RawQuery: params.Encode()}).String()
+}
+
+func (s *Service) getDataSourceHandler(pluginCtx backend.PluginContext) (*sqleng.DataSourceHandler, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+	instance := i.(*sqleng.DataSourceHandler)
+	return instance, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsHandler, err := s.getDataSourceHandler(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	return dsHandler.QueryData(ctx, req)
+}
+
+type clickhouseQueryResultTransformer struct{}
+
+func (t *clickhouseQueryResultTransformer) TransformQueryError(err error) error {
+	return err
+}
+
+// The ClickHouse driver scans columns to native Go types, only types which
+// can't be used in data frames are converted to strings by default.
+func (t *clickhouseQueryResultTransformer) GetConverterList() []sqlutil.StringConverter {
+	return nil
+}
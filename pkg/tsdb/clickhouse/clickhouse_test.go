@@ -0,0 +1,72 @@
+package clickhouse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func TestGenerateConnectionString(t *testing.T) {
+	t.Run("uses the default port", func(t *testing.T) {
+		cnnstr := generateConnectionString(sqleng.DataSourceInfo{URL: "localhost"}, "")
+		require.Equal(t, "tcp://localhost:9000", cnnstr)
+	})
+
+	t.Run("with credentials, database and TLS", func(t *testing.T) {
+		cnnstr := generateConnectionString(sqleng.DataSourceInfo{
+			URL:                     "tcp://clickhouse:9440",
+			User:                    "grafana",
+			Database:                "metrics",
+			DecryptedSecureJSONData: map[string]string{"password": "p@ss&word"},
+		}, "ds1")
+		require.Equal(t, "tcp://clickhouse:9440?database=metrics&password=p%40ss%26word&tls_config=ds1&username=grafana", cnnstr)
+	})
+
+	t.Run("xorm engine can be created for the driver", func(t *testing.T) {
+		engine, err := xorm.NewEngine("clickhouse", "tcp://localhost:9000?database=metrics")
+		require.NoError(t, err)
+		require.NoError(t, engine.Close())
+	})
+}
+
+func TestMacroEngine(t *testing.T) {
+	engine := newClickHouseMacroEngine()
+	query := &backend.DataQuery{JSON: []byte(`{}`)}
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := backend.TimeRange{From: from, To: to}
+
+	tests := []struct {
+		sql      string
+		expected string
+	}{
+		{"select $__time(time_column)", "select toUnixTimestamp(time_column) AS time_sec"},
+		{"WHERE $__timeFilter(time_column)", "WHERE time_column BETWEEN toDateTime(1523556000) AND toDateTime(1523556300)"},
+		{"select $__timeFrom(), $__timeTo()", "select toDateTime(1523556000), toDateTime(1523556300)"},
+		{"GROUP BY $__timeGroup(time_column, '5m')", "GROUP BY intDiv(toUInt32(time_column), 300) * 300"},
+		{"select $__timeGroupAlias(time_column, '5m')", "select intDiv(toUInt32(time_column), 300) * 300 AS \"time\""},
+		{"WHERE $__unixEpochFilter(time)", "WHERE time >= 1523556000 AND time <= 1523556300"},
+		{"select $__unixEpochGroupAlias(time, '1h')", "select intDiv(time, 3600) * 3600 AS \"time\""},
+	}
+	for _, tt := range tests {
+		sql, err := engine.Interpolate(query, timeRange, tt.sql)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, sql)
+	}
+
+	t.Run("__timeGroup with fill sets up resampling", func(t *testing.T) {
+		query := &backend.DataQuery{JSON: []byte(`{}`)}
+		_, err := engine.Interpolate(query, timeRange, "select $__timeGroup(time_column, '1m', previous)")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"fill": true, "fillInterval": 60, "fillMode": "previous"}`, string(query.JSON))
+	})
+
+	t.Run("unknown macro", func(t *testing.T) {
+		_, err := engine.Interpolate(query, timeRange, "select $__unknown(time)")
+		require.Error(t, err)
+	})
+}
@@ -34,6 +34,14 @@ type DatasourceInfo struct {
 	MaxConcurrentShardRequests int64
 	IncludeFrozen              bool
 	XPack                      bool
+	ConfiguredFields           ConfiguredFields
+}
+
+// ConfiguredFields contains the datasource fields used to build log lines
+type ConfiguredFields struct {
+	TimeField       string
+	LogMessageField string
+	LogLevelField   string
 }
 
 const loggerName = "tsdb.elasticsearch.client"
@@ -50,8 +58,10 @@ var newDatasourceHttpClient = func(httpClientProvider httpclient.Provider, ds *D
 type Client interface {
 	GetVersion() *semver.Version
 	GetTimeField() string
+	GetConfiguredFields() ConfiguredFields
 	GetMinInterval(queryInterval string) (time.Duration, error)
 	ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearchResponse, error)
+	ExecuteSQL(r *SQLRequest) (*SQLResponse, error)
 	MultiSearch() *MultiSearchRequestBuilder
 	EnableDebug()
 }
@@ -100,6 +110,10 @@ func (c *baseClientImpl) GetTimeField() string {
 	return c.timeField
 }
 
+func (c *baseClientImpl) GetConfiguredFields() ConfiguredFields {
+	return c.ds.ConfiguredFields
+}
+
 func (c *baseClientImpl) GetMinInterval(queryInterval string) (time.Duration, error) {
 	timeInterval := c.ds.TimeInterval
 	return intervalv2.GetIntervalFrom(queryInterval, timeInterval, 0, 5*time.Second)
@@ -116,7 +130,7 @@ func (c *baseClientImpl) executeBatchRequest(uriPath, uriQuery string, requests
 	if err != nil {
 		return nil, err
 	}
-	return c.executeRequest(http.MethodPost, uriPath, uriQuery, bytes)
+	return c.executeRequest(http.MethodPost, uriPath, uriQuery, bytes, "application/x-ndjson")
 }
 
 func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte, error) {
@@ -149,7 +163,7 @@ func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte,
 	return payload.Bytes(), nil
 }
 
-func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body []byte) (*response, error) {
+func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body []byte, contentType string) (*response, error) {
 	u, err := url.Parse(c.ds.URL)
 	if err != nil {
 		return nil, err
@@ -178,7 +192,7 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 		}
 	}
 
-	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Type", contentType)
 
 	httpClient, err := newDatasourceHttpClient(c.httpClientProvider, c.ds)
 	if err != nil {
@@ -267,6 +281,46 @@ func (c *baseClientImpl) ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearch
 	return &msr, nil
 }
 
+// ExecuteSQL posts a SQL or PPL query to the OpenSearch SQL plugin
+func (c *baseClientImpl) ExecuteSQL(r *SQLRequest) (*SQLResponse, error) {
+	var uriPath string
+	switch r.Language {
+	case SQLLanguageSQL:
+		uriPath = "_plugins/_sql"
+	case SQLLanguagePPL:
+		uriPath = "_plugins/_ppl"
+	default:
+		return nil, fmt.Errorf("unsupported query language: %s", r.Language)
+	}
+
+	clientLog.Debug("Executing SQL query", "language", r.Language)
+
+	body, err := json.Marshal(map[string]interface{}{"query": r.Query})
+	if err != nil {
+		return nil, err
+	}
+	clientRes, err := c.executeRequest(http.MethodPost, uriPath, "format=jdbc", body, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	res := clientRes.httpResponse
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			clientLog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	clientLog.Debug("Received SQL response", "code", res.StatusCode, "status", res.Status, "content-length", res.ContentLength)
+
+	var sr SQLResponse
+	if err := json.NewDecoder(res.Body).Decode(&sr); err != nil {
+		return nil, fmt.Errorf("failed to decode SQL response, status %s: %w", res.Status, err)
+	}
+	sr.Status = res.StatusCode
+
+	return &sr, nil
+}
+
 func (c *baseClientImpl) createMultiSearchRequests(searchRequests []*SearchRequest) []*multiRequest {
 	multiRequests := []*multiRequest{}
 
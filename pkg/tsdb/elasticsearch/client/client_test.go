@@ -330,6 +330,49 @@ func createMultisearchForTest(t *testing.T, c Client) (*MultiSearchRequest, erro
 	return msb.Build()
 }
 
+func TestClient_ExecuteSQL(t *testing.T) {
+	version, err := semver.NewVersion("7.10.0")
+	require.NoError(t, err)
+	httpClientScenario(t, "Given a fake http client and a PPL query", &DatasourceInfo{
+		Database:  "logs",
+		ESVersion: version,
+		TimeField: "@timestamp",
+	}, func(sc *scenarioContext) {
+		sc.responseBody = `{
+			"schema": [{ "name": "count()", "type": "integer" }],
+			"datarows": [[10]],
+			"total": 1,
+			"size": 1,
+			"status": 200
+		}`
+
+		res, err := sc.client.ExecuteSQL(&SQLRequest{Language: SQLLanguagePPL, Query: "source=logs | stats count()"})
+		require.NoError(t, err)
+
+		require.NotNil(t, sc.request)
+		assert.Equal(t, http.MethodPost, sc.request.Method)
+		assert.Equal(t, "/_plugins/_ppl", sc.request.URL.Path)
+		assert.Equal(t, "format=jdbc", sc.request.URL.RawQuery)
+		assert.Equal(t, "application/json", sc.request.Header.Get("Content-Type"))
+		jBody, err := simplejson.NewJson(sc.requestBody.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, "source=logs | stats count()", jBody.Get("query").MustString())
+
+		require.Len(t, res.Schema, 1)
+		assert.Equal(t, "integer", res.Schema[0].Type)
+		assert.Equal(t, [][]interface{}{{float64(10)}}, res.DataRows)
+	})
+
+	httpClientScenario(t, "Given a fake http client and an unsupported language", &DatasourceInfo{
+		ESVersion: version,
+		TimeField: "@timestamp",
+	}, func(sc *scenarioContext) {
+		_, err := sc.client.ExecuteSQL(&SQLRequest{Language: "kql", Query: "*"})
+		require.Error(t, err)
+		assert.Nil(t, sc.request)
+	})
+}
+
 type scenarioContext struct {
 	client         Client
 	request        *http.Request
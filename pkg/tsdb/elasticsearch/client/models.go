@@ -86,6 +86,37 @@ type MultiSearchResponse struct {
 	DebugInfo *SearchDebugInfo  `json:"-"`
 }
 
+// SQLLanguage represents a query language of the OpenSearch SQL plugin
+type SQLLanguage string
+
+const (
+	SQLLanguageSQL SQLLanguage = "sql"
+	SQLLanguagePPL SQLLanguage = "ppl"
+)
+
+// SQLRequest represents a SQL or PPL query request
+type SQLRequest struct {
+	Language SQLLanguage
+	Query    string
+}
+
+// SQLColumn represents a column of the schema of a SQL response
+type SQLColumn struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias"`
+	Type  string `json:"type"`
+}
+
+// SQLResponse represents a SQL or PPL response in jdbc format
+type SQLResponse struct {
+	Status   int                    `json:"status"`
+	Schema   []SQLColumn            `json:"schema"`
+	DataRows [][]interface{}        `json:"datarows"`
+	Total    int64                  `json:"total"`
+	Size     int64                  `json:"size"`
+	Error    map[string]interface{} `json:"error"`
+}
+
 // Query represents a query
 type Query struct {
 	Bool *BoolQuery `json:"bool"`
@@ -144,6 +175,20 @@ type RangeFilter struct {
 // DateFormatEpochMS represents a date format of epoch milliseconds (epoch_millis)
 const DateFormatEpochMS = "epoch_millis"
 
+// SortOrder represents the order of a sort in a search request
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "asc"
+	SortOrderDesc SortOrder = "desc"
+)
+
+// Tags wrapping query matches in highlighted fields, the same tags are used by the frontend
+const (
+	HighlightPreTag  = "@HIGHLIGHT@"
+	HighlightPostTag = "@/HIGHLIGHT@"
+)
+
 // MarshalJSON returns the JSON encoding of the query string filter.
 func (f *RangeFilter) MarshalJSON() ([]byte, error) {
 	root := map[string]map[string]map[string]interface{}{
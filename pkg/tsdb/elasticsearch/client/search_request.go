@@ -1,6 +1,7 @@
 package es
 
 import (
+	"math"
 	"strings"
 
 	"github.com/Masterminds/semver"
@@ -72,8 +73,13 @@ func (b *SearchRequestBuilder) Size(size int) *SearchRequestBuilder {
 
 // SortDesc adds a sort to the search request
 func (b *SearchRequestBuilder) SortDesc(field, unmappedType string) *SearchRequestBuilder {
+	return b.Sort(SortOrderDesc, field, unmappedType)
+}
+
+// Sort adds a sort with the given order to the search request
+func (b *SearchRequestBuilder) Sort(order SortOrder, field, unmappedType string) *SearchRequestBuilder {
 	props := map[string]string{
-		"order": "desc",
+		"order": string(order),
 	}
 
 	if unmappedType != "" {
@@ -85,6 +91,21 @@ func (b *SearchRequestBuilder) SortDesc(field, unmappedType string) *SearchReque
 	return b
 }
 
+// AddHighlight adds highlighting of query matches in all fields to the search request
+func (b *SearchRequestBuilder) AddHighlight() *SearchRequestBuilder {
+	b.customProps["highlight"] = map[string]interface{}{
+		"fields": map[string]interface{}{
+			"*": map[string]interface{}{},
+		},
+		"pre_tags":  []string{HighlightPreTag},
+		"post_tags": []string{HighlightPostTag},
+		// Highlight the whole field value instead of fragments
+		"fragment_size": math.MaxInt32,
+	}
+
+	return b
+}
+
 // AddDocValueField adds a doc value field to the search request
 func (b *SearchRequestBuilder) AddDocValueField(field string) *SearchRequestBuilder {
 	// fields field not supported on version >= 5
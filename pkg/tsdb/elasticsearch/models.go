@@ -11,6 +11,7 @@ type Query struct {
 	BucketAggs    []*BucketAgg `json:"bucketAggs"`
 	Metrics       []*MetricAgg `json:"metrics"`
 	Alias         string       `json:"alias"`
+	QueryType     string       `json:"queryType"`
 	Interval      string
 	IntervalMs    int64
 	RefID         string
@@ -54,6 +55,8 @@ var metricAggType = map[string]string{
 	"serial_diff":    "Serial Difference",
 	"bucket_script":  "Bucket Script",
 	"raw_document":   "Raw Document",
+	"raw_data":       "Raw Data",
+	"logs":           "Logs",
 	"rate":           "Rate",
 }
 
@@ -91,6 +94,14 @@ var pipelineAggWithMultipleBucketPathsType = map[string]string{
 	"bucket_script": "bucket_script",
 }
 
+// isDocumentQuery returns true for logs and raw data queries, which fetch documents instead of aggregations
+func isDocumentQuery(q *Query) bool {
+	if len(q.Metrics) == 0 {
+		return false
+	}
+	return q.Metrics[0].Type == logsType || q.Metrics[0].Type == rawDataType
+}
+
 func isPipelineAgg(metricType string) bool {
 	if _, ok := pipelineAggType[metricType]; ok {
 		return true
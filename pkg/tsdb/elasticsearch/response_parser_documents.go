@@ -0,0 +1,315 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+const (
+	sourceField   = "_source"
+	highlightKey  = "highlight"
+	logLevelField = "level"
+)
+
+var hitMetaFields = []string{"_id", "_type", "_index"}
+
+var highlightRegexp = regexp.MustCompile(regexp.QuoteMeta(es.HighlightPreTag) + `(.*?)` + regexp.QuoteMeta(es.HighlightPostTag))
+
+// Log levels in order of precedence, the names match the ones used by the frontend
+var logLevels = []struct {
+	level  string
+	regexp *regexp.Regexp
+}{
+	{level: "critical", regexp: regexp.MustCompile(`(?i)\b(crit|critical|fatal|emerg|alert)\b`)},
+	{level: "error", regexp: regexp.MustCompile(`(?i)\b(err|eror|error)\b`)},
+	{level: "warning", regexp: regexp.MustCompile(`(?i)\b(warn|warning)\b`)},
+	{level: "info", regexp: regexp.MustCompile(`(?i)\b(info|information|informational|notice)\b`)},
+	{level: "debug", regexp: regexp.MustCompile(`(?i)\b(dbug|debug)\b`)},
+	{level: "trace", regexp: regexp.MustCompile(`(?i)\btrace\b`)},
+}
+
+// processDocuments converts the hits of logs and raw data queries to a data frame
+// with a column per (flattened) document field
+func (rp *responseParser) processDocuments(res *es.SearchResponse, target *Query, debugInfo *simplejson.Json) backend.DataResponse {
+	var hits []map[string]interface{}
+	if res.Hits != nil {
+		hits = res.Hits.Hits
+	}
+	isLogs := target.Metrics[0].Type == logsType
+	timeField := rp.ConfiguredFields.TimeField
+	messageField := rp.ConfiguredFields.LogMessageField
+
+	docs, propNames := flattenHits(hits, timeField)
+
+	fields := make([]*data.Field, 0, len(propNames)+3)
+	added := map[string]bool{}
+	addField := func(name string, field *data.Field) {
+		added[name] = true
+		fields = append(fields, field)
+	}
+
+	timeValues := make([]*time.Time, len(docs))
+	for i, doc := range docs {
+		timeValues[i] = parseDocumentTime(doc[timeField])
+	}
+	timeDataField := data.NewField(timeField, nil, timeValues)
+	timeDataField.Config = &data.FieldConfig{Filterable: boolPtr(true)}
+	addField(timeField, timeDataField)
+
+	if isLogs {
+		lineField := messageField
+		if lineField == "" {
+			lineField = sourceField
+		}
+		lines := make([]*string, len(docs))
+		for i, doc := range docs {
+			line := documentValueToString(doc[lineField])
+			lines[i] = &line
+		}
+		addField(lineField, data.NewField(lineField, nil, lines))
+
+		levels := make([]*string, len(docs))
+		for i, doc := range docs {
+			var level string
+			if rp.ConfiguredFields.LogLevelField != "" {
+				level = documentValueToString(doc[rp.ConfiguredFields.LogLevelField])
+			} else {
+				level = detectLogLevel(*lines[i])
+			}
+			levels[i] = &level
+		}
+		addField(logLevelField, data.NewField(logLevelField, nil, levels))
+	}
+
+	for _, name := range propNames {
+		// Raw data shows every source field as a column, the source itself is only useful for logs
+		if added[name] || (!isLogs && name == sourceField) {
+			continue
+		}
+		values := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			values[i] = doc[name]
+		}
+		field := newDocumentField(name, values)
+		field.Config = &data.FieldConfig{Filterable: boolPtr(true)}
+		addField(name, field)
+	}
+
+	frame := data.NewFrame("", fields...)
+	frame.RefID = target.RefID
+	frame.Meta = &data.FrameMeta{
+		Custom: debugInfo,
+	}
+	if isLogs {
+		frame.Meta.PreferredVisualization = data.VisTypeLogs
+		// The frame meta has no search words yet, they are passed next to the debug info
+		custom := map[string]interface{}{}
+		if debugInfo != nil {
+			for key, value := range debugInfo.MustMap() {
+				custom[key] = value
+			}
+		}
+		custom["searchWords"] = searchWords(hits)
+		frame.Meta.Custom = custom
+	}
+
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// flattenHits flattens the nested document sources to one level deep maps
+// with dotted keys and returns them with a sorted list of all keys
+func flattenHits(hits []map[string]interface{}, timeField string) ([]map[string]interface{}, []string) {
+	docs := make([]map[string]interface{}, 0, len(hits))
+	propNames := map[string]struct{}{}
+
+	for _, hit := range hits {
+		doc := map[string]interface{}{}
+		source, _ := hit[sourceField].(map[string]interface{})
+		flatten("", source, doc)
+		for _, name := range hitMetaFields {
+			if value, ok := hit[name]; ok {
+				doc[name] = value
+			}
+		}
+		if source != nil {
+			doc[sourceField] = source
+		}
+		// The time field may only be available as a doc value field
+		if _, ok := doc[timeField]; !ok {
+			if fields, ok := hit["fields"].(map[string]interface{}); ok {
+				if values, ok := fields[timeField].([]interface{}); ok && len(values) > 0 {
+					doc[timeField] = values[0]
+				}
+			}
+		}
+
+		for name := range doc {
+			propNames[name] = struct{}{}
+		}
+		docs = append(docs, doc)
+	}
+
+	names := make([]string, 0, len(propNames))
+	for name := range propNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return docs, names
+}
+
+func flatten(prefix string, source map[string]interface{}, target map[string]interface{}) {
+	for key, value := range source {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(name, nested, target)
+			continue
+		}
+		target[name] = value
+	}
+}
+
+// newDocumentField guesses the field type from the values, fields with mixed
+// or complex values are converted to strings
+func newDocumentField(name string, values []interface{}) *data.Field {
+	allNumbers, allBools := true, true
+	for _, value := range values {
+		switch value.(type) {
+		case nil:
+		case float64:
+			allBools = false
+		case bool:
+			allNumbers = false
+		default:
+			allNumbers, allBools = false, false
+		}
+	}
+
+	switch {
+	case allNumbers:
+		numbers := make([]*float64, len(values))
+		for i, value := range values {
+			if v, ok := value.(float64); ok {
+				numbers[i] = &v
+			}
+		}
+		return data.NewField(name, nil, numbers)
+	case allBools:
+		bools := make([]*bool, len(values))
+		for i, value := range values {
+			if v, ok := value.(bool); ok {
+				bools[i] = &v
+			}
+		}
+		return data.NewField(name, nil, bools)
+	default:
+		strs := make([]*string, len(values))
+		for i, value := range values {
+			if value != nil {
+				v := documentValueToString(value)
+				strs[i] = &v
+			}
+		}
+		return data.NewField(name, nil, strs)
+	}
+}
+
+func documentValueToString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	}
+}
+
+// parseDocumentTime parses formatted dates and epoch milliseconds
+func parseDocumentTime(value interface{}) *time.Time {
+	var t time.Time
+	switch v := value.(type) {
+	case float64:
+		t = time.Unix(0, int64(v*float64(time.Millisecond))).UTC()
+	case string:
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			t = time.Unix(0, ms*int64(time.Millisecond)).UTC()
+			break
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil
+		}
+		t = parsed
+	default:
+		return nil
+	}
+	return &t
+}
+
+func detectLogLevel(line string) string {
+	for _, l := range logLevels {
+		if l.regexp.MatchString(line) {
+			return l.level
+		}
+	}
+	return "unknown"
+}
+
+// searchWords collects the highlighted phrases of all hits
+func searchWords(hits []map[string]interface{}) []string {
+	words := []string{}
+	seen := map[string]bool{}
+	for _, hit := range hits {
+		highlight, ok := hit[highlightKey].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, fragments := range highlight {
+			fragments, ok := fragments.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, fragment := range fragments {
+				fragment, ok := fragment.(string)
+				if !ok {
+					continue
+				}
+				for _, match := range highlightRegexp.FindAllStringSubmatch(fragment, -1) {
+					word := strings.TrimSpace(match[1])
+					if word != "" && !seen[word] {
+						seen[word] = true
+						words = append(words, word)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(words)
+	return words
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
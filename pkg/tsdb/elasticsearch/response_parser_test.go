@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -1092,5 +1093,108 @@ func newResponseParserForTest(tsdbQueries map[string]string, responseBody string
 		return nil, err
 	}
 
-	return newResponseParser(response.Responses, queries, nil), nil
+	return newResponseParser(response.Responses, queries, nil, es.ConfiguredFields{TimeField: "@timestamp"}), nil
+}
+
+func TestProcessDocuments(t *testing.T) {
+	response := `{
+		"responses": [
+			{
+				"hits": {
+					"hits": [
+						{
+							"_id": "1",
+							"_index": "logs",
+							"_source": {
+								"@timestamp": "2019-06-24T09:51:19.765Z",
+								"message": "level=error msg=failed",
+								"host": { "name": "server1" },
+								"bytes": 100
+							},
+							"highlight": { "message": ["level=@HIGHLIGHT@error@/HIGHLIGHT@ msg=failed"] }
+						},
+						{
+							"_id": "2",
+							"_index": "logs",
+							"_source": {
+								"message": "started",
+								"host": { "name": "server2" },
+								"bytes": "n/a"
+							},
+							"fields": { "@timestamp": ["1561369879000"] }
+						}
+					]
+				}
+			}
+		]
+	}`
+
+	t.Run("Logs query", func(t *testing.T) {
+		targets := map[string]string{
+			"A": `{
+				"timeField": "@timestamp",
+				"metrics": [{ "type": "logs", "id": "1" }]
+			}`,
+		}
+		rp, err := newResponseParserForTest(targets, response)
+		require.NoError(t, err)
+		rp.ConfiguredFields.LogMessageField = "message"
+		result, err := rp.getTimeSeries()
+		require.NoError(t, err)
+
+		frames := result.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, "@timestamp", frame.Fields[0].Name)
+		require.Equal(t, time.Date(2019, 6, 24, 9, 51, 19, 765000000, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+		require.Equal(t, time.Unix(1561369879, 0).UTC(), *frame.Fields[0].At(1).(*time.Time))
+		require.Equal(t, "message", frame.Fields[1].Name)
+		require.Equal(t, "level", frame.Fields[2].Name)
+		require.Equal(t, "error", *frame.Fields[2].At(0).(*string))
+		require.Equal(t, "unknown", *frame.Fields[2].At(1).(*string))
+
+		names := make([]string, 0, len(frame.Fields))
+		for _, f := range frame.Fields {
+			names = append(names, f.Name)
+		}
+		require.Equal(t, []string{"@timestamp", "message", "level", "_id", "_index", "_source", "bytes", "host.name"}, names)
+
+		// Mixed values are converted to strings
+		bytes, _ := frame.FieldByName("bytes")
+		require.Equal(t, "100", *bytes.At(0).(*string))
+
+		require.Equal(t, data.VisTypeLogs, string(frame.Meta.PreferredVisualization))
+		custom := frame.Meta.Custom.(map[string]interface{})
+		require.Equal(t, []string{"error"}, custom["searchWords"])
+	})
+
+	t.Run("Raw data query", func(t *testing.T) {
+		targets := map[string]string{
+			"A": `{
+				"timeField": "@timestamp",
+				"metrics": [{ "type": "raw_data", "id": "1" }]
+			}`,
+		}
+		rp, err := newResponseParserForTest(targets, response)
+		require.NoError(t, err)
+		result, err := rp.getTimeSeries()
+		require.NoError(t, err)
+
+		frame := result.Responses["A"].Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		_, idx := frame.FieldByName("_source")
+		require.Equal(t, -1, idx)
+		_, idx = frame.FieldByName("level")
+		require.Equal(t, -1, idx)
+		hostName, _ := frame.FieldByName("host.name")
+		require.Equal(t, "server2", *hostName.At(1).(*string))
+	})
+}
+
+func TestDetectLogLevel(t *testing.T) {
+	require.Equal(t, "error", detectLogLevel("level=ERROR msg=failed"))
+	require.Equal(t, "warning", detectLogLevel("[warn] disk almost full"))
+	require.Equal(t, "critical", detectLogLevel("FATAL error occurred"))
+	require.Equal(t, "unknown", detectLogLevel("errors happen"))
 }
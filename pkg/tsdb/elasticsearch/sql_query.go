@@ -0,0 +1,236 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+const (
+	sqlQueryType = "sql"
+	pplQueryType = "ppl"
+)
+
+// Time format of timestamps in SQL and PPL queries and responses
+const sqlTimeFormat = "2006-01-02 15:04:05.999999999"
+
+const rsIdentifier = `([_a-zA-Z0-9]+)`
+const sExpr = `\$` + rsIdentifier + `\(([^\)]*)\)`
+
+var sqlMacroRegexp = regexp.MustCompile(sExpr)
+
+func isSQLQuery(q *Query) bool {
+	return q.QueryType == sqlQueryType || q.QueryType == pplQueryType
+}
+
+func (e *timeSeriesQuery) executeSQLQuery(q *Query, dataQuery backend.DataQuery) backend.DataResponse {
+	if strings.TrimSpace(q.RawQuery) == "" {
+		return backend.DataResponse{Error: errors.New("query is empty")}
+	}
+
+	language := es.SQLLanguageSQL
+	if q.QueryType == pplQueryType {
+		language = es.SQLLanguagePPL
+	}
+
+	query, err := sqleng.Interpolate(dataQuery, dataQuery.TimeRange, "", q.RawQuery)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	query, err = newSQLMacroEngine(language).Interpolate(&dataQuery, dataQuery.TimeRange, query)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+
+	res, err := e.client.ExecuteSQL(&es.SQLRequest{Language: language, Query: query})
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	if res.Error != nil {
+		return backend.DataResponse{Error: errors.New(getErrorFromSQLResponse(res))}
+	}
+
+	frame, err := sqlResponseToFrame(res)
+	if err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	frame.RefID = q.RefID
+	frame.Meta = &data.FrameMeta{
+		ExecutedQueryString: query,
+	}
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// sqlMacroEngine provides the time macros of SQL datasources for SQL and PPL queries
+type sqlMacroEngine struct {
+	*sqleng.SQLMacroEngineBase
+	language es.SQLLanguage
+}
+
+func newSQLMacroEngine(language es.SQLLanguage) *sqlMacroEngine {
+	return &sqlMacroEngine{SQLMacroEngineBase: sqleng.NewSQLMacroEngineBase(), language: language}
+}
+
+func (m *sqlMacroEngine) Interpolate(query *backend.DataQuery, timeRange backend.TimeRange, sql string) (string, error) {
+	var macroError error
+
+	sql = m.ReplaceAllStringSubmatchFunc(sqlMacroRegexp, sql, func(groups []string) string {
+		args := strings.Split(groups[2], ",")
+		for i, arg := range args {
+			args[i] = strings.Trim(arg, " ")
+		}
+		res, err := m.evaluateMacro(timeRange, groups[1], args)
+		if err != nil && macroError == nil {
+			macroError = err
+			return "macro_error()"
+		}
+		return res
+	})
+
+	if macroError != nil {
+		return "", macroError
+	}
+
+	return sql, nil
+}
+
+func (m *sqlMacroEngine) evaluateMacro(timeRange backend.TimeRange, name string, args []string) (string, error) {
+	and := "AND"
+	if m.language == es.SQLLanguagePPL {
+		and = "and"
+	}
+
+	switch name {
+	case "__timeFilter":
+		if len(args) == 0 || args[0] == "" {
+			return "", fmt.Errorf("missing time column argument for macro %v", name)
+		}
+		return fmt.Sprintf("%s >= '%s' %s %s <= '%s'", args[0], formatSQLTime(timeRange.From), and, args[0], formatSQLTime(timeRange.To)), nil
+	case "__timeFrom":
+		return fmt.Sprintf("'%s'", formatSQLTime(timeRange.From)), nil
+	case "__timeTo":
+		return fmt.Sprintf("'%s'", formatSQLTime(timeRange.To)), nil
+	default:
+		return "", fmt.Errorf("unknown macro %v", name)
+	}
+}
+
+func formatSQLTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeFormat)
+}
+
+// sqlResponseToFrame converts the schema and data rows of a jdbc formatted response to a data frame
+func sqlResponseToFrame(res *es.SQLResponse) (*data.Frame, error) {
+	fields := make([]*data.Field, len(res.Schema))
+	for i, column := range res.Schema {
+		name := column.Alias
+		if name == "" {
+			name = column.Name
+		}
+		fields[i] = data.NewFieldFromFieldType(sqlColumnFieldType(column.Type), len(res.DataRows))
+		fields[i].Name = name
+	}
+
+	for rowIdx, row := range res.DataRows {
+		if len(row) != len(fields) {
+			return nil, fmt.Errorf("unexpected number of values in row %d: %d, expected %d", rowIdx, len(row), len(fields))
+		}
+		for i, value := range row {
+			v, err := convertSQLValue(fields[i].Type(), value)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", fields[i].Name, err)
+			}
+			fields[i].Set(rowIdx, v)
+		}
+	}
+
+	return data.NewFrame("", fields...), nil
+}
+
+func sqlColumnFieldType(columnType string) data.FieldType {
+	switch strings.ToLower(columnType) {
+	case "byte", "short", "integer", "long":
+		return data.FieldTypeNullableInt64
+	case "float", "half_float", "scaled_float", "double":
+		return data.FieldTypeNullableFloat64
+	case "boolean":
+		return data.FieldTypeNullableBool
+	case "date", "timestamp", "datetime":
+		return data.FieldTypeNullableTime
+	default:
+		return data.FieldTypeNullableString
+	}
+}
+
+func convertSQLValue(fieldType data.FieldType, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch fieldType {
+	case data.FieldTypeNullableInt64:
+		v, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v for integer column", value)
+		}
+		i := int64(v)
+		return &i, nil
+	case data.FieldTypeNullableFloat64:
+		v, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v for float column", value)
+		}
+		return &v, nil
+	case data.FieldTypeNullableBool:
+		v, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v for boolean column", value)
+		}
+		return &v, nil
+	case data.FieldTypeNullableTime:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v for time column", value)
+		}
+		t, err := parseSQLTime(s)
+		if err != nil {
+			return nil, err
+		}
+		return &t, nil
+	default:
+		s := documentValueToString(value)
+		return &s, nil
+	}
+}
+
+func parseSQLTime(value string) (time.Time, error) {
+	for _, layout := range []string{sqlTimeFormat, "2006-01-02", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unexpected time value %q", value)
+}
+
+func getErrorFromSQLResponse(res *es.SQLResponse) string {
+	errJSON := simplejson.NewFromAny(res.Error)
+	if reason := errJSON.Get("reason").MustString(); reason != "" {
+		if details := errJSON.Get("details").MustString(); details != "" {
+			return reason + ": " + details
+		}
+		return reason
+	}
+	if b, err := json.Marshal(res.Error); err == nil {
+		return string(b)
+	}
+	return "Unknown SQL error response"
+}
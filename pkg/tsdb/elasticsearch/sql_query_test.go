@@ -0,0 +1,128 @@
+package elasticsearch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteSQLQuery(t *testing.T) {
+	from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
+	to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
+
+	t.Run("SQL query is interpolated and converted to a frame", func(t *testing.T) {
+		c := newFakeClient("7.10.0")
+		c.sqlResponse = &es.SQLResponse{
+			Schema: []es.SQLColumn{
+				{Name: "@timestamp", Type: "timestamp"},
+				{Name: "host", Type: "keyword"},
+				{Name: "count", Alias: "c", Type: "long"},
+				{Name: "avg", Type: "double"},
+				{Name: "ok", Type: "boolean"},
+			},
+			DataRows: [][]interface{}{
+				{"2018-05-15 17:51:00.123", "server1", float64(10), 1.5, true},
+				{"2018-05-15 17:52:00", nil, float64(20), nil, false},
+			},
+		}
+
+		res, err := executeTsdbQuery(c, `{
+			"timeField": "@timestamp",
+			"queryType": "sql",
+			"query": "SELECT * FROM logs WHERE $__timeFilter(`+"`@timestamp`"+`)"
+		}`, from, to, 15*time.Second)
+		require.NoError(t, err)
+
+		require.Len(t, c.sqlRequests, 1)
+		require.Equal(t, es.SQLLanguageSQL, c.sqlRequests[0].Language)
+		require.Equal(t, "SELECT * FROM logs WHERE `@timestamp` >= '2018-05-15 17:50:00' AND `@timestamp` <= '2018-05-15 17:55:00'", c.sqlRequests[0].Query)
+		require.Len(t, c.multisearchRequests, 0)
+
+		queryRes := res.Responses[""]
+		require.NoError(t, queryRes.Error)
+		require.Len(t, queryRes.Frames, 1)
+		frame := queryRes.Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		require.Equal(t, time.Date(2018, 5, 15, 17, 51, 0, 123000000, time.UTC), *frame.Fields[0].At(0).(*time.Time))
+		require.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		require.Nil(t, frame.Fields[1].At(1))
+		require.Equal(t, "c", frame.Fields[2].Name)
+		require.Equal(t, int64(20), *frame.Fields[2].At(1).(*int64))
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[3].Type())
+		require.Equal(t, false, *frame.Fields[4].At(1).(*bool))
+	})
+
+	t.Run("PPL query uses PPL time filter", func(t *testing.T) {
+		c := newFakeClient("7.10.0")
+		c.sqlResponse = &es.SQLResponse{}
+
+		_, err := executeTsdbQuery(c, `{
+			"timeField": "@timestamp",
+			"queryType": "ppl",
+			"query": "source=logs | where $__timeFilter(timestamp) | stats count()"
+		}`, from, to, 15*time.Second)
+		require.NoError(t, err)
+
+		require.Len(t, c.sqlRequests, 1)
+		require.Equal(t, es.SQLLanguagePPL, c.sqlRequests[0].Language)
+		require.Equal(t, "source=logs | where timestamp >= '2018-05-15 17:50:00' and timestamp <= '2018-05-15 17:55:00' | stats count()", c.sqlRequests[0].Query)
+	})
+
+	t.Run("Errors are returned per query", func(t *testing.T) {
+		c := newFakeClient("7.10.0")
+		c.sqlResponse = &es.SQLResponse{
+			Status: 400,
+			Error:  map[string]interface{}{"reason": "Invalid SQL query", "details": "unknown field"},
+		}
+
+		res, err := executeTsdbQuery(c, `{
+			"timeField": "@timestamp",
+			"queryType": "sql",
+			"query": "SELECT unknown FROM logs"
+		}`, from, to, 15*time.Second)
+		require.NoError(t, err)
+		require.EqualError(t, res.Responses[""].Error, "Invalid SQL query: unknown field")
+
+		c.sqlError = errors.New("connection refused")
+		res, err = executeTsdbQuery(c, `{
+			"timeField": "@timestamp",
+			"queryType": "sql",
+			"query": "SELECT 1"
+		}`, from, to, 15*time.Second)
+		require.NoError(t, err)
+		require.Error(t, res.Responses[""].Error)
+	})
+
+	t.Run("Unknown macro", func(t *testing.T) {
+		c := newFakeClient("7.10.0")
+		res, err := executeTsdbQuery(c, `{
+			"timeField": "@timestamp",
+			"queryType": "sql",
+			"query": "SELECT $__unknown(a) FROM logs"
+		}`, from, to, 15*time.Second)
+		require.NoError(t, err)
+		require.Error(t, res.Responses[""].Error)
+		require.Len(t, c.sqlRequests, 0)
+	})
+}
+
+func TestSQLMacroEngine(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC),
+		To:   time.Date(2018, 5, 15, 17, 55, 0, 500000000, time.UTC),
+	}
+	engine := newSQLMacroEngine(es.SQLLanguageSQL)
+
+	sql, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "SELECT $__timeFrom(), $__timeTo()")
+	require.NoError(t, err)
+	require.Equal(t, "SELECT '2018-05-15 17:50:00', '2018-05-15 17:55:00.5'", sql)
+
+	_, err = engine.Interpolate(&backend.DataQuery{}, timeRange, "SELECT * FROM logs WHERE $__timeFilter()")
+	require.Error(t, err)
+}
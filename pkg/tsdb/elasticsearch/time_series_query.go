@@ -35,14 +35,28 @@ func (e *timeSeriesQuery) execute() (*backend.QueryDataResponse, error) {
 		return &backend.QueryDataResponse{}, err
 	}
 
+	result := backend.QueryDataResponse{
+		Responses: backend.Responses{},
+	}
+
+	// SQL and PPL queries are executed separately, they can't be part of a multi search request
+	searchQueries := make([]*Query, 0, len(queries))
+	for i, q := range queries {
+		if isSQLQuery(q) {
+			result.Responses[q.RefID] = e.executeSQLQuery(q, e.dataQueries[i])
+			continue
+		}
+		searchQueries = append(searchQueries, q)
+	}
+	if len(searchQueries) == 0 {
+		return &result, nil
+	}
+
 	ms := e.client.MultiSearch()
 
 	from := e.dataQueries[0].TimeRange.From.UnixNano() / int64(time.Millisecond)
 	to := e.dataQueries[0].TimeRange.To.UnixNano() / int64(time.Millisecond)
-	result := backend.QueryDataResponse{
-		Responses: backend.Responses{},
-	}
-	for _, q := range queries {
+	for _, q := range searchQueries {
 		if err := e.processQuery(q, ms, from, to, result); err != nil {
 			return &backend.QueryDataResponse{}, err
 		}
@@ -58,8 +72,15 @@ func (e *timeSeriesQuery) execute() (*backend.QueryDataResponse, error) {
 		return &backend.QueryDataResponse{}, err
 	}
 
-	rp := newResponseParser(res.Responses, queries, res.DebugInfo)
-	return rp.getTimeSeries()
+	rp := newResponseParser(res.Responses, searchQueries, res.DebugInfo, e.client.GetConfiguredFields())
+	searchResult, err := rp.getTimeSeries()
+	if err != nil {
+		return &backend.QueryDataResponse{}, err
+	}
+	for refID, queryRes := range searchResult.Responses {
+		result.Responses[refID] = queryRes
+	}
+	return &result, nil
 }
 
 func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilder, from, to int64,
@@ -79,6 +100,11 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 		filters.AddQueryStringFilter(q.RawQuery, true)
 	}
 
+	if isDocumentQuery(q) {
+		processDocumentQuery(q, b, e.client.GetTimeField())
+		return nil
+	}
+
 	if len(q.BucketAggs) == 0 {
 		if len(q.Metrics) == 0 || q.Metrics[0].Type != "raw_document" {
 			result.Responses[q.RefID] = backend.DataResponse{
@@ -182,6 +208,50 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 	return nil
 }
 
+const defaultDocumentSize = 500
+
+// processDocumentQuery builds a search request fetching documents for logs and raw data queries
+func processDocumentQuery(q *Query, b *es.SearchRequestBuilder, timeField string) {
+	metric := q.Metrics[0]
+	defaultSize := defaultDocumentSize
+	sizeSetting := "size"
+	highlight := false
+	if metric.Type == logsType {
+		sizeSetting = "limit"
+		highlight = true
+	}
+
+	size := intSetting(metric.Settings, sizeSetting, defaultSize)
+	if size <= 0 {
+		size = defaultSize
+	}
+	b.Size(size)
+
+	order := es.SortOrderDesc
+	if metric.Settings.Get("sortDirection").MustString() == string(es.SortOrderAsc) {
+		order = es.SortOrderAsc
+	}
+	b.Sort(order, timeField, "boolean")
+	b.AddDocValueField(timeField)
+
+	if metric.Settings.Get("highlight").MustBool(highlight) {
+		b.AddHighlight()
+	}
+}
+
+// intSetting returns an integer setting which the frontend may store as a string
+func intSetting(settings *simplejson.Json, key string, defaultValue int) int {
+	if value, err := settings.Get(key).Int(); err == nil {
+		return value
+	}
+	if stringValue, err := settings.Get(key).String(); err == nil {
+		if value, err := strconv.Atoi(stringValue); err == nil {
+			return value
+		}
+	}
+	return defaultValue
+}
+
 func setFloatPath(settings *simplejson.Json, path ...string) {
 	if stringValue, err := settings.GetPath(path...).String(); err == nil {
 		if value, err := strconv.ParseFloat(stringValue, 64); err == nil {
@@ -402,6 +472,7 @@ func (p *timeSeriesQueryParser) parse(tsdbQuery []backend.DataQuery) ([]*Query,
 		}
 		alias := model.Get("alias").MustString("")
 		interval := model.Get("interval").MustString("")
+		queryType := model.Get("queryType").MustString("")
 
 		queries = append(queries, &Query{
 			TimeField:     timeField,
@@ -409,6 +480,7 @@ func (p *timeSeriesQueryParser) parse(tsdbQuery []backend.DataQuery) ([]*Query,
 			BucketAggs:    bucketAggs,
 			Metrics:       metrics,
 			Alias:         alias,
+			QueryType:     queryType,
 			Interval:      interval,
 			RefID:         q.RefID,
 			MaxDataPoints: q.MaxDataPoints,
@@ -377,6 +377,40 @@ func TestExecuteTimeSeriesQuery(t *testing.T) {
 			require.Equal(t, sr.Size, 1337)
 		})
 
+		t.Run("With logs metric", func(t *testing.T) {
+			c := newFakeClient("7.10.0")
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"query": "error",
+				"bucketAggs": [{ "type": "date_histogram", "field": "@timestamp", "id": "2" }],
+				"metrics": [{ "id": "1", "type": "logs", "settings": { "limit": "100" } }]
+			}`, from, to, 15*time.Second)
+			require.NoError(t, err)
+			sr := c.multisearchRequests[0].Requests[0]
+
+			require.Equal(t, 100, sr.Size)
+			require.Len(t, sr.Aggs, 0)
+			require.Equal(t, map[string]string{"order": "desc", "unmapped_type": "boolean"}, sr.Sort["@timestamp"])
+			require.Equal(t, []string{"@timestamp"}, sr.CustomProps["docvalue_fields"])
+			highlight := sr.CustomProps["highlight"].(map[string]interface{})
+			require.Equal(t, []string{es.HighlightPreTag}, highlight["pre_tags"])
+		})
+
+		t.Run("With raw data metric", func(t *testing.T) {
+			c := newFakeClient("7.10.0")
+			_, err := executeTsdbQuery(c, `{
+				"timeField": "@timestamp",
+				"bucketAggs": [],
+				"metrics": [{ "id": "1", "type": "raw_data", "settings": { "size": 20, "sortDirection": "asc" } }]
+			}`, from, to, 15*time.Second)
+			require.NoError(t, err)
+			sr := c.multisearchRequests[0].Requests[0]
+
+			require.Equal(t, 20, sr.Size)
+			require.Equal(t, map[string]string{"order": "asc", "unmapped_type": "boolean"}, sr.Sort["@timestamp"])
+			require.NotContains(t, sr.CustomProps, "highlight")
+		})
+
 		t.Run("With date histogram agg", func(t *testing.T) {
 			c := newFakeClient("5.0.0")
 			_, err := executeTsdbQuery(c, `{
@@ -1162,6 +1196,9 @@ type fakeClient struct {
 	multiSearchError    error
 	builder             *es.MultiSearchRequestBuilder
 	multisearchRequests []*es.MultiSearchRequest
+	sqlResponse         *es.SQLResponse
+	sqlError            error
+	sqlRequests         []*es.SQLRequest
 }
 
 func newFakeClient(versionString string) *fakeClient {
@@ -1184,6 +1221,10 @@ func (c *fakeClient) GetTimeField() string {
 	return c.timeField
 }
 
+func (c *fakeClient) GetConfiguredFields() es.ConfiguredFields {
+	return es.ConfiguredFields{TimeField: c.timeField}
+}
+
 func (c *fakeClient) GetMinInterval(queryInterval string) (time.Duration, error) {
 	return 15 * time.Second, nil
 }
@@ -1193,6 +1234,11 @@ func (c *fakeClient) ExecuteMultisearch(r *es.MultiSearchRequest) (*es.MultiSear
 	return c.multiSearchResponse, c.multiSearchError
 }
 
+func (c *fakeClient) ExecuteSQL(r *es.SQLRequest) (*es.SQLResponse, error) {
+	c.sqlRequests = append(c.sqlRequests, r)
+	return c.sqlResponse, c.sqlError
+}
+
 func (c *fakeClient) MultiSearch() *es.MultiSearchRequestBuilder {
 	c.builder = es.NewMultiSearchRequestBuilder(c.version)
 	return c.builder
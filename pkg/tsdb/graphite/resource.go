@@ -0,0 +1,241 @@
+package graphite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+var _ backend.CallResourceHandler = (*Service)(nil)
+
+// Graphite 1.1.7 returns invalid JSON for functions with an infinite default value,
+// see https://github.com/graphite-project/graphite-web/issues/2609
+var infinityDefaultRegexp = regexp.MustCompile(`"default": ?Infinity`)
+
+// metricFindValue is the normalized result of the metric find and expand resources.
+type metricFindValue struct {
+	Text       string `json:"text"`
+	Expandable bool   `json:"expandable"`
+}
+
+type graphiteResource struct {
+	method    string
+	params    []string
+	normalize func(body []byte) (interface{}, error)
+}
+
+// resources which can be requested through CallResource, with the parameters which are passed to Graphite.
+var graphiteResources = map[string]graphiteResource{
+	"metrics/find": {
+		method:    http.MethodPost,
+		params:    []string{"query", "from", "until"},
+		normalize: normalizeMetricFind,
+	},
+	"metrics/expand": {
+		method:    http.MethodGet,
+		params:    []string{"query", "from", "until"},
+		normalize: normalizeMetricExpand,
+	},
+	"tags/autoComplete/tags": {
+		method:    http.MethodGet,
+		params:    []string{"expr", "tagPrefix", "limit"},
+		normalize: normalizeStringList,
+	},
+	"tags/autoComplete/values": {
+		method:    http.MethodGet,
+		params:    []string{"expr", "tag", "valuePrefix", "limit"},
+		normalize: normalizeStringList,
+	},
+	"functions": {
+		method: http.MethodGet,
+	},
+}
+
+// CallResource provides metric browsing, tag autocompletion and function definitions.
+// Parameters are passed as query string, or as form for POST requests.
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	return s.callResource(ctx, req, sender, dsInfo)
+}
+
+func (s *Service) callResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo) error {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		return fmt.Errorf("invalid resource method: %s", req.Method)
+	}
+
+	reqURL, err := url.Parse(req.URL)
+	if err != nil {
+		return fmt.Errorf("invalid resource URL: %s", req.URL)
+	}
+	resourcePath := strings.Trim(reqURL.Path, "/")
+	resource, ok := graphiteResources[resourcePath]
+	if !ok {
+		return fmt.Errorf("invalid resource URL: %s", req.URL)
+	}
+
+	params := reqURL.Query()
+	if req.Method == http.MethodPost && len(req.Body) > 0 {
+		form, err := url.ParseQuery(string(req.Body))
+		if err != nil {
+			return fmt.Errorf("invalid resource request body: %w", err)
+		}
+		for key, values := range form {
+			params[key] = append(params[key], values...)
+		}
+	}
+
+	graphiteParams := url.Values{}
+	for _, name := range resource.params {
+		if values, ok := params[name]; ok {
+			graphiteParams[name] = values
+		}
+	}
+
+	graphiteReq, err := s.createResourceRequest(ctx, dsInfo, resourcePath, resource.method, graphiteParams)
+	if err != nil {
+		return err
+	}
+	for name, value := range getAuthHeadersForCallResource(req.Headers) {
+		graphiteReq.Header.Set(name, value)
+	}
+
+	res, err := dsInfo.HTTPClient.Do(graphiteReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode/100 != 2 {
+		s.logger.Info("Resource request failed", "resource", resourcePath, "status", res.Status, "body", string(body))
+		return sender.Send(&backend.CallResourceResponse{
+			Status: res.StatusCode,
+			Body:   body,
+		})
+	}
+
+	if resource.normalize == nil {
+		body = infinityDefaultRegexp.ReplaceAll(body, []byte(`"default": 1e9999`))
+	} else {
+		normalized, err := resource.normalize(body)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s response: %w", resourcePath, err)
+		}
+		if body, err = json.Marshal(normalized); err != nil {
+			return err
+		}
+	}
+
+	return sender.Send(&backend.CallResourceResponse{
+		Status: http.StatusOK,
+		Headers: map[string][]string{
+			"content-type": {"application/json"},
+		},
+		Body: body,
+	})
+}
+
+func (s *Service) createResourceRequest(ctx context.Context, dsInfo *datasourceInfo, resourcePath string, method string, params url.Values) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, resourcePath)
+
+	var body io.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(params.Encode())
+	} else {
+		u.RawQuery = params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		s.logger.Info("Failed to create request", "error", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return req, nil
+}
+
+func getAuthHeadersForCallResource(headers map[string][]string) map[string]string {
+	authHeaders := make(map[string]string)
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if values := headers[name]; len(values) > 0 && values[0] != "" {
+			authHeaders[name] = values[0]
+		}
+	}
+	return authHeaders
+}
+
+func normalizeMetricFind(body []byte) (interface{}, error) {
+	var metrics []struct {
+		Text       string      `json:"text"`
+		Expandable interface{} `json:"expandable"`
+	}
+	if err := json.Unmarshal(body, &metrics); err != nil {
+		return nil, err
+	}
+
+	values := make([]metricFindValue, 0, len(metrics))
+	for _, metric := range metrics {
+		// Older Graphite versions return expandable as a number.
+		expandable := false
+		switch v := metric.Expandable.(type) {
+		case bool:
+			expandable = v
+		case float64:
+			expandable = v != 0
+		}
+		values = append(values, metricFindValue{Text: metric.Text, Expandable: expandable})
+	}
+	return values, nil
+}
+
+func normalizeMetricExpand(body []byte) (interface{}, error) {
+	var expanded struct {
+		Results []string `json:"results"`
+	}
+	if err := json.Unmarshal(body, &expanded); err != nil {
+		return nil, err
+	}
+
+	values := make([]metricFindValue, 0, len(expanded.Results))
+	for _, metric := range expanded.Results {
+		values = append(values, metricFindValue{Text: metric})
+	}
+	return values, nil
+}
+
+func normalizeStringList(body []byte) (interface{}, error) {
+	values := []string{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = []string{}
+	}
+	return values, nil
+}
@@ -0,0 +1,98 @@
+package graphite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSender struct {
+	response *backend.CallResourceResponse
+}
+
+func (s *fakeSender) Send(res *backend.CallResourceResponse) error {
+	s.response = res
+	return nil
+}
+
+func TestCallResource(t *testing.T) {
+	var lastRequest *http.Request
+	var lastBody string
+	responses := map[string]string{
+		"/metrics/find":             `[{"text": "cpu", "expandable": 1, "leaf": 0}, {"text": "load", "expandable": false}]`,
+		"/metrics/expand":           `{"results": ["prod.cpu", "prod.load"]}`,
+		"/tags/autoComplete/tags":   `["host", "region"]`,
+		"/tags/autoComplete/values": `null`,
+		"/functions":                `{"sum": {"params": [{"name": "limit", "default": Infinity}]}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lastRequest, lastBody = r, string(body)
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	s := &Service{logger: log.New("test")}
+	dsInfo := &datasourceInfo{HTTPClient: server.Client(), URL: server.URL}
+
+	call := func(t *testing.T, method, url, body string) *backend.CallResourceResponse {
+		t.Helper()
+		sender := &fakeSender{}
+		err := s.callResource(context.Background(), &backend.CallResourceRequest{
+			Method:  method,
+			URL:     url,
+			Body:    []byte(body),
+			Headers: map[string][]string{"Authorization": {"Bearer token"}},
+		}, sender, dsInfo)
+		require.NoError(t, err)
+		return sender.response
+	}
+
+	t.Run("metrics find is posted as form", func(t *testing.T) {
+		res := call(t, http.MethodPost, "metrics/find", "query=prod.*&from=-1h&ignored=1")
+		require.Equal(t, http.StatusOK, res.Status)
+		require.JSONEq(t, `[{"text": "cpu", "expandable": true}, {"text": "load", "expandable": false}]`, string(res.Body))
+		require.Equal(t, http.MethodPost, lastRequest.Method)
+		require.Equal(t, "from=-1h&query=prod.%2A", lastBody)
+		require.Equal(t, "Bearer token", lastRequest.Header.Get("Authorization"))
+	})
+
+	t.Run("metrics expand", func(t *testing.T) {
+		res := call(t, http.MethodGet, "metrics/expand?query=prod.*", "")
+		require.JSONEq(t, `[{"text": "prod.cpu", "expandable": false}, {"text": "prod.load", "expandable": false}]`, string(res.Body))
+		require.Equal(t, "prod.*", lastRequest.URL.Query().Get("query"))
+	})
+
+	t.Run("tag autocompletion", func(t *testing.T) {
+		res := call(t, http.MethodGet, "tags/autoComplete/tags?expr=a%3Db&expr=c%3Dd&tagPrefix=h", "")
+		require.JSONEq(t, `["host", "region"]`, string(res.Body))
+		require.Equal(t, []string{"a=b", "c=d"}, lastRequest.URL.Query()["expr"])
+		require.Equal(t, "h", lastRequest.URL.Query().Get("tagPrefix"))
+
+		res = call(t, http.MethodGet, "tags/autoComplete/values?tag=host", "")
+		require.JSONEq(t, `[]`, string(res.Body))
+	})
+
+	t.Run("functions with infinite defaults", func(t *testing.T) {
+		res := call(t, http.MethodGet, "functions", "")
+		require.Equal(t, `{"sum": {"params": [{"name": "limit", "default": 1e9999}]}}`, string(res.Body))
+	})
+
+	t.Run("unknown resources are rejected", func(t *testing.T) {
+		err := s.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodGet, URL: "render"}, &fakeSender{}, dsInfo)
+		require.Error(t, err)
+		err = s.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodDelete, URL: "functions"}, &fakeSender{}, dsInfo)
+		require.Error(t, err)
+	})
+}
@@ -14,15 +14,21 @@ import (
 const maxPointsEnforceFactor float64 = 10
 
 // executeQuery runs a flux query using the queryModel to interpolate the query and the runner to execute it.
-// maxSeries somehow limits the response.
-func executeQuery(ctx context.Context, query queryModel, runner queryRunner, maxSeries int) (dr backend.DataResponse) {
+// The limits restrict the size of the response, results exceeding the row or byte limit are truncated.
+func executeQuery(ctx context.Context, query queryModel, runner queryRunner, limits resultLimits) (dr backend.DataResponse) {
 	dr = backend.DataResponse{}
 
 	flux := interpolate(query)
 
 	glog.Debug("Executing Flux query", "flux", flux)
 
-	tables, err := runner.runQuery(ctx, flux)
+	// The request is cancelled when the result is truncated, so that InfluxDB stops
+	// running the query and the rest of the response is not read.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	size := &responseSize{limit: limits.maxBytes}
+
+	tables, err := runner.runQuery(withResponseSize(ctx, size), flux)
 	if err != nil {
 		glog.Warn("Flux query failed", "err", err, "query", flux)
 		dr.Error = err
@@ -30,7 +36,7 @@ func executeQuery(ctx context.Context, query queryModel, runner queryRunner, max
 		// we only enforce a larger number than maxDataPoints
 		maxPointsEnforced := int(float64(query.MaxDataPoints) * maxPointsEnforceFactor)
 
-		dr = readDataFrames(tables, maxPointsEnforced, maxSeries)
+		dr = readDataFrames(tables, maxPointsEnforced, limits, cancel)
 
 		if dr.Error != nil {
 			// we check if a too-many-data-points error happened, and if it is so,
@@ -62,15 +68,29 @@ func executeQuery(ctx context.Context, query queryModel, runner queryRunner, max
 	return dr
 }
 
-func readDataFrames(result *api.QueryTableResult, maxPoints int, maxSeries int) (dr backend.DataResponse) {
-	glog.Debug("Reading data frames from query result", "maxPoints", maxPoints, "maxSeries", maxSeries)
+// readDataFrames reads the frames from the streamed query result. When the result is larger
+// than the row or byte limit, reading stops and the frames read so far are returned with a notice.
+func readDataFrames(result *api.QueryTableResult, maxPoints int, limits resultLimits, cancel context.CancelFunc) (dr backend.DataResponse) {
+	glog.Debug("Reading data frames from query result", "maxPoints", maxPoints, "maxSeries", limits.maxSeries,
+		"maxRows", limits.maxRows, "maxBytes", limits.maxBytes)
 	dr = backend.DataResponse{}
 
+	defer func() {
+		// closing the result reads the remaining response, which
+		// fails right away for the cancelled request
+		cancel()
+		if err := result.Close(); err != nil {
+			glog.Debug("Failed to close query result", "err", err)
+		}
+	}()
+
 	builder := &frameBuilder{
 		maxPoints: maxPoints,
-		maxSeries: maxSeries,
+		maxSeries: limits.maxSeries,
 	}
 
+	var notice *data.Notice
+	rows := 0
 	for result.Next() {
 		// Observe when there is new grouping key producing new table
 		if result.TableChanged() {
@@ -91,6 +111,15 @@ func readDataFrames(result *api.QueryTableResult, maxPoints int, maxSeries int)
 			return dr
 		}
 
+		if limits.maxRows > 0 && rows >= limits.maxRows {
+			notice = &data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("The query returned more than %d rows, the results have been truncated.", limits.maxRows),
+			}
+			break
+		}
+		rows++
+
 		err := builder.Append(result.Record())
 		if err != nil {
 			dr.Error = err
@@ -106,8 +135,23 @@ func readDataFrames(result *api.QueryTableResult, maxPoints int, maxSeries int)
 	}
 
 	// result.Err() is probably more important then the other errors
-	if result.Err() != nil {
-		dr.Error = result.Err()
+	if err := result.Err(); err != nil {
+		if errors.Is(err, errResponseTooLarge) {
+			notice = &data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("The query returned more than %d bytes, the results have been truncated.", limits.maxBytes),
+			}
+		} else {
+			dr.Error = err
+		}
+	}
+
+	if notice != nil {
+		glog.Debug("Query result truncated", "rows", rows, "notice", notice.Text)
+		if len(dr.Frames) == 0 {
+			dr.Frames = append(dr.Frames, data.NewFrame(""))
+		}
+		dr.Frames[0].AppendNotices(*notice)
 	}
 	return dr
 }
@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,29 +33,35 @@ import (
 // MockRunner reads local file path for testdata.
 type MockRunner struct {
 	testDataPath string
+	// body is used instead of the test data file when set
+	body []byte
 }
 
 func (r *MockRunner) runQuery(ctx context.Context, q string) (*api.QueryTableResult, error) {
-	bytes, err := os.ReadFile(filepath.Join("testdata", r.testDataPath))
-	if err != nil {
-		return nil, err
+	bytes := r.body
+	if bytes == nil {
+		var err error
+		bytes, err = os.ReadFile(filepath.Join("testdata", r.testDataPath))
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(100 * time.Millisecond)
 		if r.Method == http.MethodPost {
 			w.WriteHeader(http.StatusOK)
-			_, err := w.Write(bytes)
-			if err != nil {
-				panic(fmt.Sprintf("Failed to write response: %s", err))
-			}
+			// the client stops reading truncated results, so write errors are expected
+			_, _ = w.Write(bytes)
 		} else {
 			w.WriteHeader(http.StatusNotFound)
 		}
 	}))
 	defer server.Close()
 
-	client := influxdb2.NewClient(server.URL, "a")
+	opts := influxdb2.DefaultOptions()
+	opts.HTTPOptions().SetHTTPClient(&http.Client{Transport: newResponseSizeTransport(nil)})
+	client := influxdb2.NewClientWithOptions(server.URL, "a", opts)
 	return client.QueryAPI("x").Query(ctx, q)
 }
 
@@ -62,7 +70,7 @@ func executeMockedQuery(t *testing.T, name string, query queryModel) *backend.Da
 		testDataPath: name + ".csv",
 	}
 
-	dr := executeQuery(context.Background(), query, runner, 50)
+	dr := executeQuery(context.Background(), query, runner, resultLimits{maxSeries: 50})
 	return &dr
 }
 
@@ -229,7 +237,7 @@ func TestRealQuery(t *testing.T) {
 		dr := executeQuery(context.Background(), queryModel{
 			MaxDataPoints: 100,
 			RawQuery:      "buckets()",
-		}, runner, 50)
+		}, runner, resultLimits{maxSeries: 50})
 		experimental.CheckGoldenJSONResponse(t, "testdata", "buckets-real.golden", &dr, true)
 	})
 }
@@ -305,3 +313,97 @@ func TestTimestampFirst(t *testing.T) {
 	require.Equal(t, "Time", dr.Frames[0].Fields[0].Name)
 	require.Equal(t, "Value", dr.Frames[0].Fields[1].Name)
 }
+
+// largeResult creates a result with many tables and rows from the annotations
+// and the first row of a test data file.
+func largeResult(t *testing.T, name string, tables int, rowsPerTable int) []byte {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", name+".csv"))
+	require.NoError(t, err)
+
+	var header, row []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ",result,") {
+			header = append(header, line)
+		} else if line != "" {
+			row = strings.Split(line, ",")
+			break
+		}
+	}
+	require.NotEmpty(t, row)
+
+	var b strings.Builder
+	b.WriteString(strings.Join(header, "\n") + "\n")
+	for table := 0; table < tables; table++ {
+		// the table and the tag "a" of the group key are different for each table
+		row[2] = strconv.Itoa(table)
+		row[9] = strconv.Itoa(table)
+		line := strings.Join(row, ",") + "\n"
+		for i := 0; i < rowsPerTable; i++ {
+			b.WriteString(line)
+		}
+	}
+	return []byte(b.String())
+}
+
+func countRows(frames data.Frames) int {
+	rows := 0
+	for _, frame := range frames {
+		rows += frame.Rows()
+	}
+	return rows
+}
+
+func TestLargeResults(t *testing.T) {
+	body := largeResult(t, "simple", 3, 1000)
+	query := queryModel{MaxDataPoints: 1000}
+
+	t.Run("all rows are read without limits", func(t *testing.T) {
+		dr := executeQuery(context.Background(), query, &MockRunner{body: body}, resultLimits{maxSeries: 50})
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 3)
+		require.Equal(t, 3000, countRows(dr.Frames))
+		require.Empty(t, dr.Frames[0].Meta.Notices)
+	})
+
+	t.Run("results are truncated at the row limit", func(t *testing.T) {
+		dr := executeQuery(context.Background(), query, &MockRunner{body: body}, resultLimits{maxSeries: 50, maxRows: 1500})
+		require.NoError(t, dr.Error)
+		require.Len(t, dr.Frames, 2)
+		require.Equal(t, 1500, countRows(dr.Frames))
+		require.Equal(t, []data.Notice{{
+			Severity: data.NoticeSeverityWarning,
+			Text:     "The query returned more than 1500 rows, the results have been truncated.",
+		}}, dr.Frames[0].Meta.Notices)
+	})
+
+	t.Run("results are truncated at the byte limit", func(t *testing.T) {
+		dr := executeQuery(context.Background(), query, &MockRunner{body: body}, resultLimits{maxSeries: 50, maxBytes: 100000})
+		require.NoError(t, dr.Error)
+		rows := countRows(dr.Frames)
+		require.Greater(t, rows, 0)
+		require.Less(t, rows, 3000)
+		require.Len(t, dr.Frames[0].Meta.Notices, 1)
+		require.Equal(t, "The query returned more than 100000 bytes, the results have been truncated.", dr.Frames[0].Meta.Notices[0].Text)
+	})
+
+	t.Run("byte limit of the exact response size", func(t *testing.T) {
+		dr := executeQuery(context.Background(), query, &MockRunner{body: body}, resultLimits{maxSeries: 50, maxBytes: int64(len(body))})
+		require.NoError(t, dr.Error)
+		require.Equal(t, 3000, countRows(dr.Frames))
+		require.Empty(t, dr.Frames[0].Meta.Notices)
+	})
+
+	t.Run("series limit fails the query", func(t *testing.T) {
+		dr := executeQuery(context.Background(), query, &MockRunner{body: body}, resultLimits{maxSeries: 2})
+		require.EqualError(t, dr.Error, "results are truncated, max series reached (2)")
+	})
+
+	t.Run("query is cancelled with the request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		dr := executeQuery(ctx, query, &MockRunner{body: body}, resultLimits{maxSeries: 50})
+		require.Error(t, dr.Error)
+		require.Contains(t, dr.Error.Error(), context.Canceled.Error())
+	})
+}
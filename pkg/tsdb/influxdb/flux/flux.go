@@ -3,6 +3,7 @@ package flux
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -34,9 +35,13 @@ func Query(ctx context.Context, dsInfo *models.DatasourceInfo, tsdbQuery backend
 			continue
 		}
 
-		// If the default changes also update labels/placeholder in config page.
-		maxSeries := dsInfo.MaxSeries
-		res := executeQuery(ctx, *qm, r, maxSeries)
+		// If the defaults change also update labels/placeholders in config page.
+		limits := resultLimits{
+			maxSeries: dsInfo.MaxSeries,
+			maxRows:   dsInfo.MaxRows,
+			maxBytes:  dsInfo.MaxBytes,
+		}
+		res := executeQuery(ctx, *qm, r, limits)
 
 		tRes.Responses[query.RefID] = res
 	}
@@ -72,8 +77,15 @@ func runnerFromDataSource(dsInfo *models.DatasourceInfo) (*runner, error) {
 	if url == "" {
 		return nil, fmt.Errorf("missing URL from datasource configuration")
 	}
+	// the response size of queries is counted by the transport of the client
+	httpClient := &http.Client{}
+	if dsInfo.HTTPClient != nil {
+		*httpClient = *dsInfo.HTTPClient
+	}
+	httpClient.Transport = newResponseSizeTransport(httpClient.Transport)
+
 	opts := influxdb2.DefaultOptions()
-	opts.HTTPOptions().SetHTTPClient(dsInfo.HTTPClient)
+	opts.HTTPOptions().SetHTTPClient(httpClient)
 	return &runner{
 		client: influxdb2.NewClientWithOptions(url, dsInfo.Token, opts),
 		org:    org,
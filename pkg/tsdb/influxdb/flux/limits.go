@@ -0,0 +1,85 @@
+package flux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// resultLimits limit the size of the result of a query, zero disables a limit.
+// The series limit fails the query, results exceeding the row or byte limit are truncated.
+type resultLimits struct {
+	maxSeries int
+	maxRows   int
+	maxBytes  int64
+}
+
+var errResponseTooLarge = errors.New("response size limit exceeded")
+
+type responseSizeKey struct{}
+
+// responseSize counts the bytes of a query response.
+type responseSize struct {
+	limit int64
+	read  int64
+}
+
+// withResponseSize makes the size of the query response sent with the context counted in size.
+func withResponseSize(ctx context.Context, size *responseSize) context.Context {
+	return context.WithValue(ctx, responseSizeKey{}, size)
+}
+
+// responseSizeTransport counts the size of query responses, reading the response
+// fails once the limit is exceeded.
+type responseSizeTransport struct {
+	next http.RoundTripper
+}
+
+func newResponseSizeTransport(next http.RoundTripper) *responseSizeTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &responseSizeTransport{next: next}
+}
+
+func (t *responseSizeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	size, ok := req.Context().Value(responseSizeKey{}).(*responseSize)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	// The influxdb client asks for gzip compressed responses. Without the header the
+	// transport requests and decompresses them itself, so the uncompressed size is limited.
+	req = req.Clone(req.Context())
+	req.Header.Del("Accept-Encoding")
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = &sizeLimitedBody{ReadCloser: res.Body, size: size}
+	return res, nil
+}
+
+type sizeLimitedBody struct {
+	io.ReadCloser
+	size *responseSize
+}
+
+func (b *sizeLimitedBody) Read(p []byte) (int, error) {
+	if b.size.limit > 0 && b.size.read >= b.size.limit {
+		// the response may end exactly at the limit
+		var probe [1]byte
+		if n, err := b.ReadCloser.Read(probe[:]); n == 0 && errors.Is(err, io.EOF) {
+			return 0, io.EOF
+		}
+		return 0, errResponseTooLarge
+	}
+	if b.size.limit > 0 && int64(len(p)) > b.size.limit-b.size.read {
+		p = p[:b.size.limit-b.size.read]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.size.read += int64(n)
+	return n, err
+}
@@ -64,6 +64,14 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 		if maxSeries == 0 {
 			maxSeries = 1000
 		}
+		maxRows := jsonData.MaxRows
+		if maxRows == 0 {
+			maxRows = 1000000
+		}
+		maxBytes := jsonData.MaxBytes
+		if maxBytes == 0 {
+			maxBytes = 256 * 1024 * 1024
+		}
 		version := jsonData.Version
 		if version == "" {
 			version = influxVersionInfluxQL
@@ -78,6 +86,8 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			DefaultBucket: jsonData.DefaultBucket,
 			Organization:  jsonData.Organization,
 			MaxSeries:     maxSeries,
+			MaxRows:       maxRows,
+			MaxBytes:      maxBytes,
 			Token:         settings.DecryptedSecureJSONData["token"],
 		}
 		return model, nil
@@ -16,4 +16,6 @@ type DatasourceInfo struct {
 	DefaultBucket string `json:"defaultBucket"`
 	Organization  string `json:"organization"`
 	MaxSeries     int    `json:"maxSeries"`
+	MaxRows       int    `json:"maxRows"`
+	MaxBytes      int64  `json:"maxBytes"`
 }
@@ -138,7 +138,7 @@ func TestOauthForwardIdentity(t *testing.T) {
 
 	for _, test := range tt {
 		t.Run("CallResource: "+test.name, func(t *testing.T) {
-			response := []byte("mocked resource response")
+			response := []byte(`{"status":"success","data":["app","job"]}`)
 
 			clientUsed := false
 			dsInfo := makeMockedDsInfoForOauth(response, func(req *http.Request) {
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
-	"strings"
 	"sync"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -53,6 +52,9 @@ type datasourceInfo struct {
 	// open streams
 	streams   map[string]data.FrameJSONCache
 	streamsMu sync.RWMutex
+
+	// cached label and series responses
+	resources *resourceCache
 }
 
 type QueryJSONModel struct {
@@ -89,6 +91,7 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			HTTPClient: client,
 			URL:        settings.URL,
 			streams:    make(map[string]data.FrameJSONCache),
+			resources:  newResourceCache(),
 		}
 		return model, nil
 	}
@@ -133,36 +136,6 @@ func getAuthHeadersForCallResource(headers map[string][]string) map[string]strin
 	return data
 }
 
-func callResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo, plog log.Logger) error {
-	url := req.URL
-
-	// a very basic is-this-url-valid check
-	if req.Method != "GET" {
-		return fmt.Errorf("invalid resource method: %s", req.Method)
-	}
-	if (!strings.HasPrefix(url, "labels?")) &&
-		(!strings.HasPrefix(url, "label/")) && // the `/label/$label_name/values` form
-		(!strings.HasPrefix(url, "series?")) {
-		return fmt.Errorf("invalid resource URL: %s", url)
-	}
-	lokiURL := fmt.Sprintf("/loki/api/v1/%s", url)
-
-	api := newLokiAPI(dsInfo.HTTPClient, dsInfo.URL, plog, getAuthHeadersForCallResource(req.Headers))
-	bytes, err := api.RawQuery(ctx, lokiURL)
-
-	if err != nil {
-		return err
-	}
-
-	return sender.Send(&backend.CallResourceResponse{
-		Status: http.StatusOK,
-		Headers: map[string][]string{
-			"content-type": {"application/json"},
-		},
-		Body: bytes,
-	})
-}
-
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	dsInfo, err := s.getDSInfo(req.PluginContext)
 	if err != nil {
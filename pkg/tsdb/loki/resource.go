@@ -0,0 +1,288 @@
+package loki
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	gocache "github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// resourceCacheTTL is how long label and series responses are reused.
+	resourceCacheTTL = time.Minute
+	// resourceTimeGranularity is used to round the requested time range, so that
+	// requests of users opening the label browser at nearly the same time share
+	// the cached response.
+	resourceTimeGranularity = time.Minute
+	// resourceFetchTimeout limits a label or series request shared by concurrent callers.
+	resourceFetchTimeout = 30 * time.Second
+)
+
+var labelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// resourceCache caches shaped label and series responses of one data source instance.
+// Concurrent requests for the same resource are sent to Loki only once.
+type resourceCache struct {
+	cache *gocache.Cache
+	group singleflight.Group
+}
+
+func newResourceCache() *resourceCache {
+	return &resourceCache{
+		cache: gocache.New(resourceCacheTTL, 2*resourceCacheTTL),
+	}
+}
+
+// get returns the cached response for the key, or fetches it. The fetch is shared
+// by all concurrent callers, so it runs with its own timeout instead of the context
+// of the first caller, and each caller only stops waiting when its own context is done.
+func (c *resourceCache) get(ctx context.Context, key string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if c == nil {
+		return fetch(ctx)
+	}
+
+	if cached, ok := c.cache.Get(key); ok {
+		return cached.([]byte), nil
+	}
+
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.Background(), resourceFetchTimeout)
+		defer cancel()
+
+		body, err := fetch(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+		c.cache.SetDefault(key, body)
+		return body, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+// lokiResourceRequest is a validated label, label values or series request.
+type lokiResourceRequest struct {
+	path   string
+	params url.Values
+	shape  func(body []byte) ([]byte, error)
+}
+
+// parseResourceRequest validates the resource URL sent by the frontend, and keeps only
+// the parameters Loki understands for the resource.
+func parseResourceRequest(resourceURL string) (*lokiResourceRequest, error) {
+	u, err := url.Parse(resourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URL: %s", resourceURL)
+	}
+	query := u.Query()
+
+	req := &lokiResourceRequest{params: url.Values{}}
+	allowed := []string{"start", "end"}
+
+	switch {
+	case u.Path == "labels":
+		req.path = "labels"
+		req.shape = shapeStringList
+		allowed = append(allowed, "query")
+	case strings.HasPrefix(u.Path, "label/") && strings.HasSuffix(u.Path, "/values"):
+		name := strings.TrimSuffix(strings.TrimPrefix(u.Path, "label/"), "/values")
+		if !labelNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid label name: %s", name)
+		}
+		req.path = fmt.Sprintf("label/%s/values", name)
+		req.shape = shapeStringList
+		allowed = append(allowed, "query")
+	case u.Path == "series":
+		if len(query["match[]"]) == 0 {
+			return nil, fmt.Errorf("series request without stream selector")
+		}
+		req.path = "series"
+		req.shape = shapeSeries
+		allowed = append(allowed, "match[]")
+	default:
+		return nil, fmt.Errorf("invalid resource URL: %s", resourceURL)
+	}
+
+	for _, name := range allowed {
+		if values, ok := query[name]; ok {
+			req.params[name] = values
+		}
+	}
+
+	for _, name := range []string{"start", "end"} {
+		value := req.params.Get(name)
+		if value == "" {
+			continue
+		}
+		nanos, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", name, value)
+		}
+		rounded := roundResourceTime(time.Unix(0, nanos), name == "end")
+		req.params.Set(name, strconv.FormatInt(rounded.UnixNano(), 10))
+	}
+
+	return req, nil
+}
+
+// roundResourceTime rounds the start of a time range down and the end up,
+// so the rounded range always contains the requested one.
+func roundResourceTime(t time.Time, up bool) time.Time {
+	rounded := t.Truncate(resourceTimeGranularity)
+	if up && !rounded.Equal(t) {
+		rounded = rounded.Add(resourceTimeGranularity)
+	}
+	return rounded
+}
+
+// lokiURL is the path and query string of the request in the Loki API.
+func (r *lokiResourceRequest) lokiURL() string {
+	return fmt.Sprintf("/loki/api/v1/%s?%s", r.path, r.params.Encode())
+}
+
+// cacheKey identifies the response for the request and the forwarded identity,
+// users only share cached responses when they are sent with the same headers.
+func (r *lokiResourceRequest) cacheKey(headers map[string]string) string {
+	hash := sha256.New()
+	for _, name := range []string{"Authorization", "Cookie"} {
+		_, _ = hash.Write([]byte(name + "=" + headers[name] + "\n"))
+	}
+	return r.lokiURL() + "#" + hex.EncodeToString(hash.Sum(nil))
+}
+
+type lokiStringListResponse struct {
+	Status string   `json:"status"`
+	Data   []string `json:"data"`
+}
+
+type lokiSeriesResponse struct {
+	Status string              `json:"status"`
+	Data   []map[string]string `json:"data"`
+}
+
+// shapeStringList sorts and de-duplicates label names or values.
+func shapeStringList(body []byte) ([]byte, error) {
+	var res lokiStringListResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(res.Data))
+	values := make([]string, 0, len(res.Data))
+	for _, value := range res.Data {
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+
+	return json.Marshal(lokiStringListResponse{Status: "success", Data: values})
+}
+
+// shapeSeries de-duplicates series and sorts them by their labels.
+func shapeSeries(body []byte) ([]byte, error) {
+	var res lokiSeriesResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(res.Data))
+	series := make([]map[string]string, 0, len(res.Data))
+	sortKeys := make([]string, 0, len(res.Data))
+	for _, labels := range res.Data {
+		key := seriesKey(labels)
+		if keys[key] {
+			continue
+		}
+		keys[key] = true
+		series = append(series, labels)
+		sortKeys = append(sortKeys, key)
+	}
+	sort.Sort(seriesByKey{series: series, keys: sortKeys})
+
+	return json.Marshal(lokiSeriesResponse{Status: "success", Data: series})
+}
+
+func seriesKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(strconv.Quote(labels[name]))
+		b.WriteString(",")
+	}
+	return b.String()
+}
+
+type seriesByKey struct {
+	series []map[string]string
+	keys   []string
+}
+
+func (s seriesByKey) Len() int           { return len(s.series) }
+func (s seriesByKey) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s seriesByKey) Swap(i, j int) {
+	s.series[i], s.series[j] = s.series[j], s.series[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+func callResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo, plog log.Logger) error {
+	if req.Method != http.MethodGet {
+		return fmt.Errorf("invalid resource method: %s", req.Method)
+	}
+
+	resourceReq, err := parseResourceRequest(req.URL)
+	if err != nil {
+		return err
+	}
+
+	headers := getAuthHeadersForCallResource(req.Headers)
+	api := newLokiAPI(dsInfo.HTTPClient, dsInfo.URL, plog, headers)
+
+	body, err := dsInfo.resources.get(ctx, resourceReq.cacheKey(headers), func(ctx context.Context) ([]byte, error) {
+		body, err := api.RawQuery(ctx, resourceReq.lokiURL())
+		if err != nil {
+			return nil, err
+		}
+		return resourceReq.shape(body)
+	})
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(&backend.CallResourceResponse{
+		Status: http.StatusOK,
+		Headers: map[string][]string{
+			"content-type": {"application/json"},
+		},
+		Body: body,
+	})
+}
@@ -0,0 +1,160 @@
+package loki
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceRequest(t *testing.T) {
+	t.Run("labels with rounded time range", func(t *testing.T) {
+		req, err := parseResourceRequest("labels?start=1660000010000000000&end=1660000070000000000&limit=5")
+		require.NoError(t, err)
+		require.Equal(t, "/loki/api/v1/labels?end=1660000080000000000&start=1659999960000000000", req.lokiURL())
+	})
+
+	t.Run("label values", func(t *testing.T) {
+		req, err := parseResourceRequest("label/job/values?query=%7Bapp%3D%22grafana%22%7D")
+		require.NoError(t, err)
+		require.Equal(t, `/loki/api/v1/label/job/values?query=%7Bapp%3D%22grafana%22%7D`, req.lokiURL())
+	})
+
+	t.Run("series", func(t *testing.T) {
+		req, err := parseResourceRequest("series?match%5B%5D=%7Bapp%3D%22grafana%22%7D&start=1660000050000000000")
+		require.NoError(t, err)
+		require.Equal(t, `/loki/api/v1/series?match%5B%5D=%7Bapp%3D%22grafana%22%7D&start=1660000020000000000`, req.lokiURL())
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, url := range []string{
+			"query_range?query=x",
+			"label/../values",
+			"label/job/../../labels/values",
+			"series?start=1",
+			"labels?start=now-1h",
+		} {
+			_, err := parseResourceRequest(url)
+			require.Error(t, err, url)
+		}
+	})
+}
+
+func TestResourceShaping(t *testing.T) {
+	t.Run("string lists are sorted and unique", func(t *testing.T) {
+		body, err := shapeStringList([]byte(`{"status":"success","data":["job","app","job"]}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"status":"success","data":["app","job"]}`, string(body))
+
+		body, err = shapeStringList([]byte(`{"status":"success"}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"status":"success","data":[]}`, string(body))
+	})
+
+	t.Run("series are sorted and unique", func(t *testing.T) {
+		body, err := shapeSeries([]byte(`{"status":"success","data":[
+			{"job":"b","app":"x"},
+			{"app":"x","job":"a"},
+			{"job":"b","app":"x"}
+		]}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"status":"success","data":[{"app":"x","job":"a"},{"app":"x","job":"b"}]}`, string(body))
+	})
+}
+
+func TestCallResourceCache(t *testing.T) {
+	requests := 0
+	api := makeMockedAPI(http.StatusOK, "application/json", []byte(`{"status":"success","data":["job","app"]}`), func(req *http.Request) {
+		requests++
+	})
+	dsInfo := &datasourceInfo{HTTPClient: api.client, URL: api.url, resources: newResourceCache()}
+
+	call := func(url string, auth string) *backend.CallResourceResponse {
+		sender := &mockedCallResourceResponseSenderForOauth{}
+		err := callResource(context.Background(), &backend.CallResourceRequest{
+			Method:  http.MethodGet,
+			URL:     url,
+			Headers: map[string][]string{"Authorization": {auth}},
+		}, sender, dsInfo, log.New("test"))
+		require.NoError(t, err)
+		return sender.Response
+	}
+
+	res := call("labels?start=1660000025000000000", "user1")
+	require.JSONEq(t, `{"status":"success","data":["app","job"]}`, string(res.Body))
+	require.Equal(t, 1, requests)
+
+	// the same rounded time range is served from the cache
+	call("labels?start=1660000050000000000", "user1")
+	require.Equal(t, 1, requests)
+
+	// other users don't share the cached response
+	call("labels?start=1660000050000000000", "user2")
+	require.Equal(t, 2, requests)
+
+	call("label/job/values?start=1660000050000000000", "user1")
+	require.Equal(t, 3, requests)
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		api := makeMockedAPI(http.StatusBadRequest, "application/json", []byte(`{"message":"bad selector"}`), func(req *http.Request) {
+			requests++
+		})
+		dsInfo := &datasourceInfo{HTTPClient: api.client, URL: api.url, resources: newResourceCache()}
+		req := &backend.CallResourceRequest{Method: http.MethodGet, URL: "series?match[]=x"}
+
+		err := callResource(context.Background(), req, &mockedCallResourceResponseSenderForOauth{}, dsInfo, log.New("test"))
+		require.EqualError(t, err, "bad selector")
+		err = callResource(context.Background(), req, &mockedCallResourceResponseSenderForOauth{}, dsInfo, log.New("test"))
+		require.Error(t, err)
+		require.Equal(t, 5, requests)
+	})
+}
+
+func TestResourceCacheSharedFetch(t *testing.T) {
+	cache := newResourceCache()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) ([]byte, error) {
+		close(started)
+		<-release
+		// the shared fetch is not cancelled with the context of the first caller
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return []byte("labels"), nil
+	}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := cache.get(firstCtx, "key", fetch)
+		firstErr <- err
+	}()
+	<-started
+
+	secondRes := make(chan []byte)
+	go func() {
+		body, err := cache.get(context.Background(), "key", func(ctx context.Context) ([]byte, error) {
+			return nil, errors.New("the fetch should be shared")
+		})
+		require.NoError(t, err)
+		secondRes <- body
+	}()
+
+	// the first caller stops waiting when its context is cancelled
+	cancelFirst()
+	require.ErrorIs(t, <-firstErr, context.Canceled)
+
+	close(release)
+	require.Equal(t, []byte("labels"), <-secondRes)
+
+	body, err := cache.get(context.Background(), "key", func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("the response should be cached")
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte("labels"), body)
+}
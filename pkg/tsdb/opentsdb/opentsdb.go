@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -36,12 +37,26 @@ func ProvideService(httpClientProvider httpclient.Provider) *Service {
 }
 
 type datasourceInfo struct {
-	HTTPClient *http.Client
-	URL        string
+	HTTPClient     *http.Client
+	URL            string
+	TSDBVersion    int64
+	TSDBResolution int64
+	LookupLimit    int64
 }
 
 type DsAccess string
 
+const (
+	// OpenTSDB versions as configured in the data source settings
+	tsdbVersion23 = 3
+
+	tsdbResolutionMilliseconds = 2
+
+	defaultLookupLimit = 1000
+
+	annotationsQueryType = "annotations"
+)
+
 func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
 	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		opts, err := settings.HTTPClientOptions()
@@ -54,26 +69,86 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		jsonData, err := simplejson.NewJson(settings.JSONData)
+		if err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+
 		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
+			HTTPClient:     client,
+			URL:            settings.URL,
+			TSDBVersion:    jsonData.Get("tsdbVersion").MustInt64(1),
+			TSDBResolution: jsonData.Get("tsdbResolution").MustInt64(1),
+			LookupLimit:    jsonData.Get("lookupLimit").MustInt64(defaultLookupLimit),
 		}
 
 		return model, nil
 	}
 }
 
+// tsdbQueryRef links a sub query of the OpenTSDB request to the query of the data request.
+type tsdbQueryRef struct {
+	refID        string
+	metric       string
+	tags         map[string]string
+	hasFilters   bool
+	isAnnotation bool
+	isGlobal     bool
+}
+
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
 	var tsdbQuery OpenTsdbQuery
 
 	q := req.Queries[0]
 
 	tsdbQuery.Start = q.TimeRange.From.UnixNano() / int64(time.Millisecond)
 	tsdbQuery.End = q.TimeRange.To.UnixNano() / int64(time.Millisecond)
+	tsdbQuery.MsResolution = dsInfo.TSDBResolution == tsdbResolutionMilliseconds
+	tsdbQuery.ShowQuery = dsInfo.TSDBVersion >= tsdbVersion23
 
+	refs := make([]tsdbQueryRef, 0, len(req.Queries))
+	result := backend.NewQueryDataResponse()
 	for _, query := range req.Queries {
+		model, err := simplejson.NewJson(query.JSON)
+		if err != nil {
+			result.Responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+		if model.Get("hide").MustBool() || model.Get("metric").MustString() == "" {
+			result.Responses[query.RefID] = backend.DataResponse{}
+			continue
+		}
+
 		metric := s.buildMetric(query)
+		ref := tsdbQueryRef{
+			refID:        query.RefID,
+			metric:       model.Get("metric").MustString(),
+			tags:         map[string]string{},
+			hasFilters:   len(model.Get("filters").MustArray()) > 0,
+			isAnnotation: model.Get("queryType").MustString() == annotationsQueryType,
+			isGlobal:     model.Get("isGlobal").MustBool(),
+		}
+		for key, value := range model.Get("tags").MustMap() {
+			if value, ok := value.(string); ok {
+				ref.tags[key] = value
+			}
+		}
+		if ref.isAnnotation {
+			metric["aggregator"] = "sum"
+			tsdbQuery.GlobalAnnotations = tsdbQuery.GlobalAnnotations || ref.isGlobal
+		}
+
 		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
+		refs = append(refs, ref)
+	}
+
+	if len(refs) == 0 {
+		return result, nil
 	}
 
 	// TODO: Don't use global variable
@@ -81,11 +156,6 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		s.logger.Debug("OpenTsdb request", "params", tsdbQuery)
 	}
 
-	dsInfo, err := s.getDSInfo(req.PluginContext)
-	if err != nil {
-		return nil, err
-	}
-
 	request, err := s.createRequest(ctx, dsInfo, tsdbQuery)
 	if err != nil {
 		return &backend.QueryDataResponse{}, err
@@ -96,11 +166,15 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		return &backend.QueryDataResponse{}, err
 	}
 
-	result, err := s.parseResponse(res)
+	parsed, err := s.parseResponse(res, refs, tsdbQuery.MsResolution)
 	if err != nil {
 		return &backend.QueryDataResponse{}, err
 	}
 
+	for refID, response := range parsed.Responses {
+		result.Responses[refID] = response
+	}
+
 	return result, nil
 }
 
@@ -127,7 +201,7 @@ func (s *Service) createRequest(ctx context.Context, dsInfo *datasourceInfo, dat
 	return req, nil
 }
 
-func (s *Service) parseResponse(res *http.Response) (*backend.QueryDataResponse, error) {
+func (s *Service) parseResponse(res *http.Response, refs []tsdbQueryRef, msResolution bool) (*backend.QueryDataResponse, error) {
 	resp := backend.NewQueryDataResponse()
 
 	body, err := io.ReadAll(res.Body)
@@ -152,32 +226,142 @@ func (s *Service) parseResponse(res *http.Response) (*backend.QueryDataResponse,
 		return nil, err
 	}
 
-	frames := data.Frames{}
+	for _, ref := range refs {
+		resp.Responses[ref.refID] = backend.DataResponse{Frames: data.Frames{}}
+	}
+
+	annotationsAdded := map[string]bool{}
 	for _, val := range responseData {
-		timeVector := make([]time.Time, 0, len(val.DataPoints))
-		values := make([]float64, 0, len(val.DataPoints))
-		name := val.Metric
-		tags := val.Tags
-
-		for timeString, value := range val.DataPoints {
-			timestamp, err := strconv.ParseInt(timeString, 10, 64)
-			if err != nil {
-				s.logger.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
-				return nil, err
+		idx := findQueryIndex(val, refs)
+		if idx < 0 {
+			s.logger.Debug("Failed to find query for opentsdb result", "metric", val.Metric, "tags", val.Tags)
+			continue
+		}
+		ref := refs[idx]
+		result := resp.Responses[ref.refID]
+
+		if ref.isAnnotation {
+			// Annotations are the same for all series of a query.
+			if !annotationsAdded[ref.refID] {
+				annotations := val.Annotations
+				if ref.isGlobal {
+					annotations = val.GlobalAnnotations
+				}
+				result.Frames = append(result.Frames, annotationsToFrame(ref.refID, annotations))
+				annotationsAdded[ref.refID] = true
 			}
-			timeVector = append(timeVector, time.Unix(timestamp, 0).UTC())
-			values = append(values, value)
+			resp.Responses[ref.refID] = result
+			continue
 		}
-		frames = append(frames, data.NewFrame(name,
-			data.NewField("time", nil, timeVector),
-			data.NewField("value", tags, values)))
+
+		frame, err := seriesToFrame(val, msResolution)
+		if err != nil {
+			s.logger.Info("Failed to unmarshal opentsdb timestamp", "error", err)
+			return nil, err
+		}
+		frame.RefID = ref.refID
+		result.Frames = append(result.Frames, frame)
+		resp.Responses[ref.refID] = result
 	}
-	result := resp.Responses["A"]
-	result.Frames = frames
-	resp.Responses["A"] = result
+
 	return resp, nil
 }
 
+// findQueryIndex returns the index of the query a result belongs to. The index is part of the
+// result since OpenTSDB 2.3, for older versions the query is found by its metric and tags.
+func findQueryIndex(val OpenTsdbResponse, refs []tsdbQueryRef) int {
+	if val.Query != nil {
+		if val.Query.Index < 0 || val.Query.Index >= len(refs) {
+			return -1
+		}
+		return val.Query.Index
+	}
+
+	for i, ref := range refs {
+		if ref.metric != val.Metric {
+			continue
+		}
+		if ref.hasFilters || tagsMatch(ref.tags, val.Tags) {
+			return i
+		}
+	}
+	return -1
+}
+
+func tagsMatch(queryTags map[string]string, resultTags map[string]string) bool {
+	for key, value := range queryTags {
+		if value == "*" {
+			continue
+		}
+		matched := false
+		for _, v := range strings.Split(value, "|") {
+			if v == resultTags[key] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func seriesToFrame(val OpenTsdbResponse, msResolution bool) (*data.Frame, error) {
+	timestamps := make([]int64, 0, len(val.DataPoints))
+	for timeString := range val.DataPoints {
+		timestamp, err := strconv.ParseInt(timeString, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	timeVector := make([]time.Time, 0, len(timestamps))
+	values := make([]float64, 0, len(timestamps))
+	for _, timestamp := range timestamps {
+		value := val.DataPoints[strconv.FormatInt(timestamp, 10)]
+		if msResolution {
+			timeVector = append(timeVector, time.Unix(0, timestamp*int64(time.Millisecond)).UTC())
+		} else {
+			timeVector = append(timeVector, time.Unix(timestamp, 0).UTC())
+		}
+		values = append(values, value)
+	}
+
+	return data.NewFrame(val.Metric,
+		data.NewField("time", nil, timeVector),
+		data.NewField("value", val.Tags, values)), nil
+}
+
+func annotationsToFrame(refID string, annotations []OpenTsdbAnnotation) *data.Frame {
+	times := make([]time.Time, 0, len(annotations))
+	timeEnds := make([]*time.Time, 0, len(annotations))
+	texts := make([]string, 0, len(annotations))
+	tsuids := make([]string, 0, len(annotations))
+	for _, annotation := range annotations {
+		times = append(times, time.Unix(annotation.StartTime, 0).UTC())
+		if annotation.EndTime > 0 {
+			end := time.Unix(annotation.EndTime, 0).UTC()
+			timeEnds = append(timeEnds, &end)
+		} else {
+			timeEnds = append(timeEnds, nil)
+		}
+		texts = append(texts, annotation.Description)
+		tsuids = append(tsuids, annotation.TSUID)
+	}
+
+	frame := data.NewFrame("annotations",
+		data.NewField("time", nil, times),
+		data.NewField("timeEnd", nil, timeEnds),
+		data.NewField("text", nil, texts),
+		data.NewField("tsuid", nil, tsuids),
+	)
+	frame.RefID = refID
+	return frame
+}
+
 func (s *Service) buildMetric(query backend.DataQuery) map[string]interface{} {
 	metric := make(map[string]interface{})
 
@@ -211,38 +395,59 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]interface{} {
 		rateOptions := make(map[string]interface{})
 		rateOptions["counter"] = model.Get("isCounter").MustBool()
 
-		counterMax, counterMaxCheck := model.CheckGet("counterMax")
+		counterMax, counterMaxCheck := numberOption(model, "counterMax")
 		if counterMaxCheck {
-			rateOptions["counterMax"] = counterMax.MustFloat64()
+			rateOptions["counterMax"] = counterMax
 		}
 
-		resetValue, resetValueCheck := model.CheckGet("counterResetValue")
+		resetValue, resetValueCheck := numberOption(model, "counterResetValue")
 		if resetValueCheck {
-			rateOptions["resetValue"] = resetValue.MustFloat64()
+			rateOptions["resetValue"] = resetValue
 		}
 
-		if !counterMaxCheck && (!resetValueCheck || resetValue.MustFloat64() == 0) {
+		if !counterMaxCheck && (!resetValueCheck || resetValue == 0) {
 			rateOptions["dropResets"] = true
 		}
 
 		metric["rateOptions"] = rateOptions
 	}
 
-	// Setting tags
-	tags, tagsCheck := model.CheckGet("tags")
-	if tagsCheck && len(tags.MustMap()) > 0 {
-		metric["tags"] = tags.MustMap()
-	}
-
-	// Setting filters
+	// Setting filters, tags are only used without filters
 	filters, filtersCheck := model.CheckGet("filters")
 	if filtersCheck && len(filters.MustArray()) > 0 {
 		metric["filters"] = filters.MustArray()
+	} else if tags, tagsCheck := model.CheckGet("tags"); tagsCheck && len(tags.MustMap()) > 0 {
+		metric["tags"] = tags.MustMap()
+	}
+
+	// Only return series with exactly the given tags
+	if model.Get("explicitTags").MustBool() {
+		metric["explicitTags"] = true
 	}
 
 	return metric
 }
 
+// numberOption reads an optional number which the query editor stores as string.
+func numberOption(model *simplejson.Json, key string) (float64, bool) {
+	value, ok := model.CheckGet(key)
+	if !ok {
+		return 0, false
+	}
+	if number, err := value.Float64(); err == nil {
+		return number, true
+	}
+	str := strings.TrimSpace(value.MustString())
+	if str == "" {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
 func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*datasourceInfo, error) {
 	i, err := s.im.Get(pluginCtx)
 	if err != nil {
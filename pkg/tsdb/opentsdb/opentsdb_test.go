@@ -37,7 +37,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 	t.Run("Parse response should handle invalid JSON", func(t *testing.T) {
 		response := `{ invalid }`
 
-		result, err := service.parseResponse(&http.Response{Body: io.NopCloser(strings.NewReader(response))})
+		res := &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(response))}
+		result, err := service.parseResponse(res, []tsdbQueryRef{{refID: "A", metric: "test"}}, false)
 		require.Nil(t, result)
 		require.Error(t, err)
 	})
@@ -64,10 +65,11 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			data.NewField("value", map[string]string{"env": "prod", "app": "grafana"}, []float64{
 				50}),
 		)
+		testFrame.RefID = "A"
 
 		resp := http.Response{Body: io.NopCloser(strings.NewReader(response))}
 		resp.StatusCode = 200
-		result, err := service.parseResponse(&resp)
+		result, err := service.parseResponse(&resp, []tsdbQueryRef{{refID: "A", metric: "test", tags: map[string]string{}}}, false)
 		require.NoError(t, err)
 
 		frame := result.Responses["A"]
@@ -245,4 +247,134 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		require.Equal(t, float64(45), metricRateOptions["counterMax"])
 		require.Equal(t, float64(60), metricRateOptions["resetValue"])
 	})
+	t.Run("Parse response should map results to queries", func(t *testing.T) {
+		refs := []tsdbQueryRef{
+			{refID: "A", metric: "cpu", tags: map[string]string{"host": "a|b"}},
+			{refID: "B", metric: "cpu", tags: map[string]string{"host": "*"}},
+		}
+
+		t.Run("by query index", func(t *testing.T) {
+			response := `[
+				{"metric": "cpu", "tags": {"host": "a"}, "dps": {"2": 2, "1": 1}, "query": {"index": 1}},
+				{"metric": "cpu", "tags": {"host": "c"}, "dps": {"1": 3}, "query": {"index": 0}}
+			]`
+			res := &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(response))}
+			result, err := service.parseResponse(res, refs, false)
+			require.NoError(t, err)
+
+			require.Len(t, result.Responses["A"].Frames, 1)
+			require.Len(t, result.Responses["B"].Frames, 1)
+			frame := result.Responses["B"].Frames[0]
+			require.Equal(t, "B", frame.RefID)
+			require.Equal(t, time.Unix(1, 0).UTC(), frame.Fields[0].At(0))
+			require.Equal(t, []float64{1, 2}, []float64{frame.Fields[1].At(0).(float64), frame.Fields[1].At(1).(float64)})
+		})
+
+		t.Run("by metric and tags", func(t *testing.T) {
+			response := `[
+				{"metric": "cpu", "tags": {"host": "b"}, "dps": {"1": 1}},
+				{"metric": "cpu", "tags": {"host": "c"}, "dps": {"1": 2}},
+				{"metric": "mem", "tags": {"host": "a"}, "dps": {"1": 3}}
+			]`
+			res := &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(response))}
+			result, err := service.parseResponse(res, refs, false)
+			require.NoError(t, err)
+
+			require.Len(t, result.Responses["A"].Frames, 1)
+			require.Len(t, result.Responses["B"].Frames, 1)
+			require.Equal(t, map[string]string{"host": "c"}, map[string]string(result.Responses["B"].Frames[0].Fields[1].Labels))
+		})
+
+		t.Run("with millisecond resolution", func(t *testing.T) {
+			response := `[{"metric": "cpu", "tags": {"host": "a"}, "dps": {"1405544146500": 1}}]`
+			res := &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(response))}
+			result, err := service.parseResponse(res, refs, true)
+			require.NoError(t, err)
+			require.Equal(t, time.Date(2014, 7, 16, 20, 55, 46, 500000000, time.UTC), result.Responses["A"].Frames[0].Fields[0].At(0))
+		})
+
+		t.Run("with failed request", func(t *testing.T) {
+			res := &http.Response{StatusCode: 400, Status: "400 Bad Request", Body: io.NopCloser(strings.NewReader(`{"error": {}}`))}
+			_, err := service.parseResponse(res, refs, false)
+			require.Error(t, err)
+		})
+	})
+
+	t.Run("Parse response should handle annotations", func(t *testing.T) {
+		refs := []tsdbQueryRef{
+			{refID: "A", metric: "cpu", isAnnotation: true},
+			{refID: "B", metric: "cpu", isAnnotation: true, isGlobal: true},
+		}
+		response := `[
+			{"metric": "cpu", "dps": {}, "query": {"index": 0},
+				"annotations": [{"tsuid": "0001", "description": "deploy", "startTime": 1405544146, "endTime": 1405544246}]},
+			{"metric": "cpu", "dps": {}, "query": {"index": 0},
+				"annotations": [{"tsuid": "0001", "description": "deploy", "startTime": 1405544146, "endTime": 1405544246}]},
+			{"metric": "cpu", "dps": {}, "query": {"index": 1},
+				"globalAnnotations": [{"description": "outage", "startTime": 1405544146}]}
+		]`
+		res := &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(response))}
+		result, err := service.parseResponse(res, refs, false)
+		require.NoError(t, err)
+
+		end := time.Date(2014, 7, 16, 20, 57, 26, 0, time.UTC)
+		expected := data.NewFrame("annotations",
+			data.NewField("time", nil, []time.Time{time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC)}),
+			data.NewField("timeEnd", nil, []*time.Time{&end}),
+			data.NewField("text", nil, []string{"deploy"}),
+			data.NewField("tsuid", nil, []string{"0001"}),
+		)
+		expected.RefID = "A"
+		require.Len(t, result.Responses["A"].Frames, 1)
+		if diff := cmp.Diff(expected, result.Responses["A"].Frames[0], data.FrameTestCompareOptions()...); diff != "" {
+			t.Errorf("Result mismatch (-want +got):\n%s", diff)
+		}
+
+		require.Len(t, result.Responses["B"].Frames, 1)
+		global := result.Responses["B"].Frames[0]
+		require.Equal(t, "outage", global.Fields[2].At(0))
+		require.Nil(t, global.Fields[1].At(0))
+	})
+
+	t.Run("Build metric with filters and explicit tags", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu",
+						"aggregator": "sum",
+						"disableDownsampling": true,
+						"explicitTags": true,
+						"tags": {"env": "prod"},
+						"filters": [{"type": "wildcard", "tagk": "host", "filter": "web*", "groupBy": true}]
+					}`,
+			),
+		}
+
+		metric := service.buildMetric(query)
+
+		require.Nil(t, metric["tags"])
+		require.Len(t, metric["filters"], 1)
+		require.True(t, metric["explicitTags"].(bool))
+	})
+
+	t.Run("Build metric with counter options as strings", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu",
+						"aggregator": "sum",
+						"disableDownsampling": true,
+						"shouldComputeRate": true,
+						"isCounter": true,
+						"counterMax": "45",
+						"counterResetValue": ""
+					}`,
+			),
+		}
+
+		rateOptions := service.buildMetric(query)["rateOptions"].(map[string]interface{})
+		require.Equal(t, float64(45), rateOptions["counterMax"])
+		require.Nil(t, rateOptions["resetValue"])
+		require.Nil(t, rateOptions["dropResets"])
+	})
 }
@@ -0,0 +1,106 @@
+package opentsdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+var _ backend.CallResourceHandler = (*Service)(nil)
+
+// resources which can be requested through CallResource, with the parameters which are
+// passed to OpenTSDB and the parameter limiting the number of results.
+var tsdbResources = map[string]struct {
+	params     []string
+	limitParam string
+}{
+	"api/suggest":       {params: []string{"type", "q"}, limitParam: "max"},
+	"api/search/lookup": {params: []string{"m"}, limitParam: "limit"},
+}
+
+// CallResource provides metric, tag key and tag value suggestions and lookups.
+// The number of results is limited by the lookup limit of the data source.
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	return s.callResource(ctx, req, sender, dsInfo)
+}
+
+func (s *Service) callResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo) error {
+	if req.Method != http.MethodGet {
+		return fmt.Errorf("invalid resource method: %s", req.Method)
+	}
+
+	reqURL, err := url.Parse(req.URL)
+	if err != nil {
+		return fmt.Errorf("invalid resource URL: %s", req.URL)
+	}
+	resourcePath := strings.Trim(reqURL.Path, "/")
+	resource, ok := tsdbResources[resourcePath]
+	if !ok {
+		return fmt.Errorf("invalid resource URL: %s", req.URL)
+	}
+
+	params := url.Values{}
+	for _, name := range resource.params {
+		if value := reqURL.Query().Get(name); value != "" {
+			params.Set(name, value)
+		}
+	}
+	limit := dsInfo.LookupLimit
+	if requested, err := strconv.ParseInt(reqURL.Query().Get(resource.limitParam), 10, 64); err == nil && requested > 0 && requested < limit {
+		limit = requested
+	}
+	params.Set(resource.limitParam, strconv.FormatInt(limit, 10))
+
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, resourcePath)
+	u.RawQuery = params.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		s.logger.Info("Failed to create request", "error", err)
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if values := req.Headers[name]; len(values) > 0 && values[0] != "" {
+			request.Header.Set(name, values[0])
+		}
+	}
+
+	res, err := dsInfo.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(&backend.CallResourceResponse{
+		Status: res.StatusCode,
+		Headers: map[string][]string{
+			"content-type": {"application/json"},
+		},
+		Body: body,
+	})
+}
@@ -0,0 +1,82 @@
+package opentsdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSender struct {
+	response *backend.CallResourceResponse
+}
+
+func (s *fakeSender) Send(res *backend.CallResourceResponse) error {
+	s.response = res
+	return nil
+}
+
+func TestCallResource(t *testing.T) {
+	var lastRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = r
+		switch r.URL.Path {
+		case "/api/suggest":
+			_, _ = w.Write([]byte(`["cpu.user", "cpu.system"]`))
+		case "/api/search/lookup":
+			_, _ = w.Write([]byte(`{"type": "LOOKUP", "metric": "cpu", "results": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	s := &Service{logger: log.New("test")}
+	dsInfo := &datasourceInfo{HTTPClient: server.Client(), URL: server.URL, LookupLimit: 100}
+
+	call := func(t *testing.T, method, url string) *backend.CallResourceResponse {
+		t.Helper()
+		sender := &fakeSender{}
+		err := s.callResource(context.Background(), &backend.CallResourceRequest{
+			Method:  method,
+			URL:     url,
+			Headers: map[string][]string{"Authorization": {"Bearer token"}},
+		}, sender, dsInfo)
+		require.NoError(t, err)
+		return sender.response
+	}
+
+	t.Run("suggest uses the lookup limit", func(t *testing.T) {
+		res := call(t, http.MethodGet, "api/suggest?type=metrics&q=cpu&ignored=1")
+		require.Equal(t, http.StatusOK, res.Status)
+		require.JSONEq(t, `["cpu.user", "cpu.system"]`, string(res.Body))
+		require.Equal(t, "max=100&q=cpu&type=metrics", lastRequest.URL.RawQuery)
+		require.Equal(t, "Bearer token", lastRequest.Header.Get("Authorization"))
+	})
+
+	t.Run("suggest with smaller limit", func(t *testing.T) {
+		call(t, http.MethodGet, "api/suggest?type=tagk&max=10")
+		require.Equal(t, "10", lastRequest.URL.Query().Get("max"))
+
+		call(t, http.MethodGet, "api/suggest?type=tagk&max=1000")
+		require.Equal(t, "100", lastRequest.URL.Query().Get("max"))
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		res := call(t, http.MethodGet, "api/search/lookup?m=cpu%7Bhost%3D*%7D")
+		require.Equal(t, http.StatusOK, res.Status)
+		require.Equal(t, "cpu{host=*}", lastRequest.URL.Query().Get("m"))
+		require.Equal(t, "100", lastRequest.URL.Query().Get("limit"))
+	})
+
+	t.Run("unknown resources are rejected", func(t *testing.T) {
+		err := s.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodGet, URL: "api/query"}, &fakeSender{}, dsInfo)
+		require.Error(t, err)
+		err = s.callResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodPost, URL: "api/suggest"}, &fakeSender{}, dsInfo)
+		require.Error(t, err)
+	})
+}
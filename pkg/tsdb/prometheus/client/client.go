@@ -22,23 +22,33 @@ type doer interface {
 // objects, we have to go through them and then serialize again into DataFrame which isn't very efficient. Using custom
 // client we can parse response directly into DataFrame.
 type Client struct {
-	doer    doer
-	method  string
-	baseUrl string
+	doer       doer
+	method     string
+	baseUrl    string
+	queryStats bool
 }
 
 func NewClient(d doer, method, baseUrl string) *Client {
 	return &Client{doer: d, method: method, baseUrl: baseUrl}
 }
 
+// SetQueryStats makes range and instant queries request the query statistics from Prometheus.
+func (c *Client) SetQueryStats(enabled bool) {
+	c.queryStats = enabled
+}
+
 func (c *Client) QueryRange(ctx context.Context, q *models.Query, headers http.Header) (*http.Response, error) {
 	tr := q.TimeRange()
-	u, err := c.createUrl("api/v1/query_range", map[string]string{
+	qs := map[string]string{
 		"query": q.Expr,
 		"start": formatTime(tr.Start),
 		"end":   formatTime(tr.End),
 		"step":  strconv.FormatFloat(tr.Step.Seconds(), 'f', -1, 64),
-	})
+	}
+	if c.queryStats {
+		qs["stats"] = "true"
+	}
+	u, err := c.createUrl("api/v1/query_range", qs)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +66,9 @@ func (c *Client) QueryInstant(ctx context.Context, q *models.Query, headers http
 	if !tr.End.IsZero() {
 		qs["time"] = formatTime(tr.End)
 	}
+	if c.queryStats {
+		qs["stats"] = "true"
+	}
 
 	u, err := c.createUrl("api/v1/query", qs)
 	if err != nil {
@@ -5,9 +5,11 @@ import (
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/models"
 	"github.com/stretchr/testify/require"
 )
 
@@ -80,4 +82,32 @@ func TestClient(t *testing.T) {
 			require.Equal(t, "http://localhost:9090/api/v1/series?match%5B%5D=ALERTS&start=1655272558&end=1655294158", doer.Req.URL.String())
 		})
 	})
+	t.Run("QueryRange", func(t *testing.T) {
+		doer := &MockDoer{}
+		client := NewClient(doer, http.MethodGet, "http://localhost:9090")
+		q := &models.Query{
+			Expr:       "up",
+			Step:       time.Minute,
+			Start:      time.Unix(1655271360, 0),
+			End:        time.Unix(1655293020, 0),
+			RangeQuery: true,
+		}
+
+		t.Run("sends query without stats", func(t *testing.T) {
+			_, err := client.QueryRange(context.Background(), q, http.Header{})
+			require.NoError(t, err)
+			require.Equal(t, "http://localhost:9090/api/v1/query_range?end=1655293020&query=up&start=1655271360&step=60", doer.Req.URL.String())
+		})
+
+		t.Run("sends query with stats", func(t *testing.T) {
+			client.SetQueryStats(true)
+			_, err := client.QueryRange(context.Background(), q, http.Header{})
+			require.NoError(t, err)
+			require.Equal(t, "true", doer.Req.URL.Query().Get("stats"))
+
+			_, err = client.QueryInstant(context.Background(), q, http.Header{})
+			require.NoError(t, err)
+			require.Equal(t, "true", doer.Req.URL.Query().Get("stats"))
+		})
+	})
 }
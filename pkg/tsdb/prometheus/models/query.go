@@ -138,6 +138,39 @@ func (query *Query) TimeRange() TimeRange {
 	}
 }
 
+// SplitTimeRange splits a range query into queries for consecutive sub-ranges of at most
+// the given interval. The sub-ranges are aligned to multiples of the interval, so that
+// the same sub-range is requested by later queries for an overlapping time range.
+func (query *Query) SplitTimeRange(interval time.Duration) []*Query {
+	tr := query.TimeRange()
+	if !query.RangeQuery || tr.Step <= 0 || interval < tr.Step || tr.End.Sub(tr.Start) <= interval {
+		return []*Query{query}
+	}
+
+	// Every sub-range has to start at a multiple of the step, so that no sample
+	// is evaluated twice or skipped at the borders of the sub-ranges.
+	interval -= interval % tr.Step
+	if interval%time.Second != 0 {
+		return []*Query{query}
+	}
+
+	var queries []*Query
+	for start := tr.Start; !start.After(tr.End); {
+		end := alignTimeRange(start.Add(interval), interval, query.UtcOffsetSec).Add(-tr.Step)
+		if end.After(tr.End) {
+			end = tr.End
+		}
+
+		subQuery := *query
+		subQuery.Start = start
+		subQuery.End = end
+		queries = append(queries, &subQuery)
+
+		start = end.Add(tr.Step)
+	}
+	return queries
+}
+
 func calculatePrometheusInterval(model *QueryModel, timeInterval string, query backend.DataQuery, intervalCalculator intervalv2.Calculator) (time.Duration, error) {
 	queryInterval := model.Interval
 
@@ -449,3 +449,57 @@ func queryContext(json string, timeRange backend.TimeRange) backend.DataQuery {
 		RefID:     "A",
 	}
 }
+
+func TestQuery_SplitTimeRange(t *testing.T) {
+	start := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	query := &models.Query{
+		Expr:       "up",
+		Step:       time.Hour,
+		Start:      start,
+		End:        start.Add(71 * time.Hour),
+		RangeQuery: true,
+	}
+
+	t.Run("splits into sub-ranges aligned to the interval", func(t *testing.T) {
+		queries := query.SplitTimeRange(24 * time.Hour)
+		require.Len(t, queries, 4)
+
+		require.Equal(t, start.Unix(), queries[0].TimeRange().Start.Unix())
+		require.Equal(t, time.Date(2022, 8, 1, 23, 0, 0, 0, time.UTC).Unix(), queries[0].TimeRange().End.Unix())
+		require.Equal(t, time.Date(2022, 8, 2, 0, 0, 0, 0, time.UTC).Unix(), queries[1].TimeRange().Start.Unix())
+		require.Equal(t, time.Date(2022, 8, 2, 23, 0, 0, 0, time.UTC).Unix(), queries[1].TimeRange().End.Unix())
+		require.Equal(t, time.Date(2022, 8, 4, 0, 0, 0, 0, time.UTC).Unix(), queries[3].TimeRange().Start.Unix())
+		require.Equal(t, query.End.Unix(), queries[3].TimeRange().End.Unix())
+
+		for _, q := range queries {
+			require.Equal(t, "up", q.Expr)
+			require.Equal(t, time.Hour, q.Step)
+		}
+	})
+
+	t.Run("interval is rounded down to a multiple of the step", func(t *testing.T) {
+		queries := query.SplitTimeRange(36*time.Hour + 30*time.Minute)
+		require.Greater(t, len(queries), 1)
+		require.Equal(t, start.Unix(), queries[0].TimeRange().Start.Unix())
+		require.Equal(t, query.End.Unix(), queries[len(queries)-1].TimeRange().End.Unix())
+		for i, q := range queries {
+			tr := q.TimeRange()
+			require.LessOrEqual(t, tr.End.Sub(tr.Start), 35*time.Hour)
+			if i > 0 {
+				require.Equal(t, queries[i-1].TimeRange().End.Add(time.Hour).Unix(), tr.Start.Unix())
+				require.Zero(t, tr.Start.Unix()%int64((36*time.Hour).Seconds()))
+			}
+		}
+	})
+
+	t.Run("does not split short ranges and instant queries", func(t *testing.T) {
+		require.Len(t, query.SplitTimeRange(72*time.Hour), 1)
+		require.Len(t, query.SplitTimeRange(30*time.Minute), 1)
+		require.Len(t, query.SplitTimeRange(0), 1)
+
+		instant := *query
+		instant.RangeQuery = false
+		instant.InstantQuery = true
+		require.Len(t, instant.SplitTimeRange(24*time.Hour), 1)
+	})
+}
@@ -2,6 +2,7 @@ package querydata
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"regexp"
 	"time"
@@ -19,7 +20,11 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
-const legendFormatAuto = "__auto"
+const (
+	legendFormatAuto = "__auto"
+	// maxSplitConcurrency limits the sub-range queries of a split query running in parallel.
+	maxSplitConcurrency = 4
+)
 
 var legendFormatRegexp = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
 
@@ -40,6 +45,9 @@ type QueryData struct {
 	URL                string
 	TimeInterval       string
 	enableWideSeries   bool
+	// splitInterval is the maximum time range of a single range query, longer
+	// queries are split into sub-ranges. Zero disables query splitting.
+	splitInterval time.Duration
 }
 
 func New(
@@ -60,7 +68,25 @@ func New(
 		return nil, err
 	}
 
+	queryStats, err := maputil.GetBoolOptional(jsonData, "queryStats")
+	if err != nil {
+		return nil, err
+	}
+
+	splitIntervalSetting, err := maputil.GetStringOptional(jsonData, "querySplitInterval")
+	if err != nil {
+		return nil, err
+	}
+	var splitInterval time.Duration
+	if splitIntervalSetting != "" {
+		splitInterval, err = intervalv2.ParseIntervalStringToTimeDuration(splitIntervalSetting)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query split interval %q: %w", splitIntervalSetting, err)
+		}
+	}
+
 	promClient := client.NewClient(httpClient, httpMethod, settings.URL)
+	promClient.SetQueryStats(queryStats)
 
 	return &QueryData{
 		intervalCalculator: intervalv2.NewCalculator(),
@@ -71,6 +97,7 @@ func New(
 		ID:                 settings.ID,
 		URL:                settings.URL,
 		enableWideSeries:   features.IsEnabled(featuremgmt.FlagPrometheusWideSeries),
+		splitInterval:      splitInterval,
 	}, nil
 }
 
@@ -142,6 +169,10 @@ func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.
 }
 
 func (s *QueryData) rangeQuery(ctx context.Context, c *client.Client, q *models.Query, headers map[string]string) (*backend.DataResponse, error) {
+	if queries := q.SplitTimeRange(s.splitInterval); len(queries) > 1 {
+		return s.splitRangeQuery(ctx, c, q, queries, headers)
+	}
+
 	res, err := c.QueryRange(ctx, q, sdkHeaderToHttpHeader(headers))
 	if err != nil {
 		return nil, err
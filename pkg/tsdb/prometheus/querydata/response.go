@@ -32,6 +32,7 @@ func (s *QueryData) parseResponse(ctx context.Context, q *models.Query, res *htt
 
 	// The ExecutedQueryString can be viewed in QueryInspector in UI
 	for _, frame := range r.Frames {
+		addStatsToFrame(frame)
 		if s.enableWideSeries {
 			addMetadataToWideFrame(q, frame)
 		} else {
@@ -42,6 +43,52 @@ func (s *QueryData) parseResponse(ctx context.Context, q *models.Query, res *htt
 	return r, nil
 }
 
+const statPeakSamples = "Peak samples"
+
+// addStatsToFrame moves the query statistics returned by Prometheus to the frame stats,
+// which are shown in the query inspector.
+func addStatsToFrame(frame *data.Frame) {
+	if frame.Meta == nil {
+		return
+	}
+	custom, ok := frame.Meta.Custom.(map[string]interface{})
+	if !ok {
+		return
+	}
+	rawStats, ok := custom["stats"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	delete(custom, "stats")
+
+	if samples, ok := rawStats["samples"].(map[string]interface{}); ok {
+		frame.Meta.Stats = append(frame.Meta.Stats,
+			makeStat("Total queryable samples", samples["totalQueryableSamples"], ""),
+			makeStat(statPeakSamples, samples["peakSamples"], ""))
+	}
+
+	if timings, ok := rawStats["timings"].(map[string]interface{}); ok {
+		frame.Meta.Stats = append(frame.Meta.Stats,
+			makeStat("Exec total time", timings["execTotalTime"], "s"),
+			makeStat("Exec queue time", timings["execQueueTime"], "s"),
+			makeStat("Query preparation time", timings["queryPreparationTime"], "s"),
+			makeStat("Eval total time", timings["evalTotalTime"], "s"),
+			makeStat("Inner eval time", timings["innerEvalTime"], "s"),
+			makeStat("Result sort time", timings["resultSortTime"], "s"))
+	}
+}
+
+func makeStat(name string, value interface{}, unit string) data.QueryStat {
+	v, _ := value.(float64)
+	return data.QueryStat{
+		FieldConfig: data.FieldConfig{
+			DisplayName: name,
+			Unit:        unit,
+		},
+		Value: v,
+	}
+}
+
 func addMetadataToMultiFrame(q *models.Query, frame *data.Frame) {
 	if frame.Meta == nil {
 		frame.Meta = &data.FrameMeta{}
@@ -0,0 +1,212 @@
+package querydata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/client"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// splitRangeQuery runs the sub-range queries of a long range query in parallel, and merges
+// their results so that every series is returned as one frame for the whole range.
+func (s *QueryData) splitRangeQuery(ctx context.Context, c *client.Client, q *models.Query, queries []*models.Query, headers map[string]string) (*backend.DataResponse, error) {
+	s.log.Debug("Splitting range query", "query", q.Expr, "interval", s.splitInterval, "queries", len(queries))
+
+	responses := make([]*backend.DataResponse, len(queries))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxSplitConcurrency)
+	for i, subQuery := range queries {
+		i, subQuery := i, subQuery
+		g.Go(func() error {
+			res, err := c.QueryRange(gctx, subQuery, sdkHeaderToHttpHeader(headers))
+			if err != nil {
+				return err
+			}
+			r, err := s.parseResponse(gctx, subQuery, res)
+			if err != nil {
+				return err
+			}
+			responses[i] = r
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	merged := &backend.DataResponse{Frames: data.Frames{}}
+	var stats [][]data.QueryStat
+	for _, r := range responses {
+		if r.Error != nil {
+			return r, nil
+		}
+		for _, frame := range r.Frames {
+			if frame.Meta != nil && len(frame.Meta.Stats) > 0 {
+				stats = append(stats, frame.Meta.Stats)
+				frame.Meta.Stats = nil
+			}
+		}
+		merged.Frames = mergeFrames(merged.Frames, r.Frames)
+	}
+
+	for _, frame := range merged.Frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.ExecutedQueryString = executedQueryString(q) +
+			fmt.Sprintf("\nSplit into %d queries of %s", len(queries), s.splitInterval)
+	}
+	if len(stats) > 0 && len(merged.Frames) > 0 {
+		merged.Frames[0].Meta.Stats = mergeStats(stats)
+	}
+
+	return merged, nil
+}
+
+// mergeFrames appends the frames of the next sub-range to the frames of the previous sub-ranges.
+// Frames of the same series are joined, wide frames are joined on the union of their series.
+func mergeFrames(merged data.Frames, frames data.Frames) data.Frames {
+	for _, frame := range frames {
+		key := frameKey(frame)
+		joined := false
+		for i, existing := range merged {
+			if frameKey(existing) != key {
+				continue
+			}
+			switch {
+			case sameSchema(existing, frame):
+				appendRows(existing, frame)
+			case isWideFrame(existing) && isWideFrame(frame):
+				merged[i] = joinWideFrames(existing, frame)
+			default:
+				continue
+			}
+			joined = true
+			break
+		}
+		if !joined {
+			merged = append(merged, frame)
+		}
+	}
+	return merged
+}
+
+func isWideFrame(frame *data.Frame) bool {
+	return frame.Meta != nil && frame.Meta.Type == data.FrameTypeTimeSeriesWide &&
+		len(frame.Fields) > 0 && frame.Fields[0].Type() == data.FieldTypeTime
+}
+
+// frameKey identifies the series of a frame, wide frames contain all series so they only
+// differ by their type.
+func frameKey(frame *data.Frame) string {
+	var b strings.Builder
+	b.WriteString(frame.Name)
+	if frame.Meta != nil {
+		b.WriteString("|" + string(frame.Meta.Type))
+	}
+	if isWideFrame(frame) {
+		return b.String()
+	}
+	for _, field := range frame.Fields {
+		b.WriteString("|" + fieldKey(field))
+	}
+	return b.String()
+}
+
+func fieldKey(field *data.Field) string {
+	return field.Name + field.Labels.String() + field.Type().ItemTypeString()
+}
+
+func sameSchema(a, b *data.Frame) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if fieldKey(a.Fields[i]) != fieldKey(b.Fields[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func appendRows(frame *data.Frame, next *data.Frame) {
+	for i, field := range next.Fields {
+		for row := 0; row < field.Len(); row++ {
+			frame.Fields[i].Append(field.CopyAt(row))
+		}
+	}
+	if next.Meta != nil && frame.Meta != nil {
+		frame.Meta.Notices = append(frame.Meta.Notices, next.Meta.Notices...)
+	}
+}
+
+// joinWideFrames joins two wide frames with different series, series missing in a sub-range
+// have no values for the rows of that sub-range.
+func joinWideFrames(a, b *data.Frame) *data.Frame {
+	rows := a.Rows() + b.Rows()
+	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, 0)
+	timeField.Name = a.Fields[0].Name
+	timeField.Config = a.Fields[0].Config
+	for _, frame := range []*data.Frame{a, b} {
+		for row := 0; row < frame.Rows(); row++ {
+			timeField.Append(frame.Fields[0].CopyAt(row))
+		}
+	}
+
+	joined := data.NewFrame(a.Name, timeField)
+	joined.Meta = a.Meta
+	if b.Meta != nil {
+		joined.Meta.Notices = append(joined.Meta.Notices, b.Meta.Notices...)
+	}
+
+	fields := map[string]*data.Field{}
+	for offset, frame := range []*data.Frame{a, b} {
+		rowOffset := offset * a.Rows()
+		for _, field := range frame.Fields[1:] {
+			key := fieldKey(field)
+			target, ok := fields[key]
+			if !ok {
+				target = data.NewFieldFromFieldType(field.Type(), rows)
+				target.Name = field.Name
+				target.Labels = field.Labels
+				target.Config = field.Config
+				fields[key] = target
+				joined.Fields = append(joined.Fields, target)
+			}
+			for row := 0; row < field.Len(); row++ {
+				target.Set(rowOffset+row, field.CopyAt(row))
+			}
+		}
+	}
+	return joined
+}
+
+// mergeStats adds up the statistics of the sub-range queries, peak values are
+// the maximum of all sub-ranges.
+func mergeStats(stats [][]data.QueryStat) []data.QueryStat {
+	merged := make([]data.QueryStat, 0, len(stats[0]))
+	index := map[string]int{}
+	for _, queryStats := range stats {
+		for _, stat := range queryStats {
+			i, ok := index[stat.DisplayName]
+			if !ok {
+				index[stat.DisplayName] = len(merged)
+				merged = append(merged, stat)
+				continue
+			}
+			if stat.DisplayName == statPeakSamples {
+				if stat.Value > merged[i].Value {
+					merged[i].Value = stat.Value
+				}
+			} else {
+				merged[i].Value += stat.Value
+			}
+		}
+	}
+	return merged
+}
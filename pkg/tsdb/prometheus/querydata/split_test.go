@@ -0,0 +1,189 @@
+package querydata_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/querydata"
+	"github.com/stretchr/testify/require"
+)
+
+// matrixResponse keeps the order of the fields, the result type has to be sent before the result.
+type matrixResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string                 `json:"resultType"`
+		Result     []interface{}          `json:"result"`
+		Stats      map[string]interface{} `json:"stats"`
+	} `json:"data"`
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// rangeResponder returns a matrix response with one sample per step for the requested range,
+// series are only returned for sub-ranges starting after their first timestamp.
+func rangeResponder(t *testing.T, starts *[]int64, mu *sync.Mutex, series map[string]int64) roundTripperFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		query := req.URL.Query()
+		start, err := strconv.ParseInt(query.Get("start"), 10, 64)
+		require.NoError(t, err)
+		end, err := strconv.ParseInt(query.Get("end"), 10, 64)
+		require.NoError(t, err)
+		step, err := strconv.ParseInt(query.Get("step"), 10, 64)
+		require.NoError(t, err)
+
+		mu.Lock()
+		*starts = append(*starts, start)
+		mu.Unlock()
+
+		result := []interface{}{}
+		for name, from := range series {
+			if end < from {
+				continue
+			}
+			values := [][]interface{}{}
+			for ts := start; ts <= end; ts += step {
+				if ts >= from {
+					values = append(values, []interface{}{ts, strconv.FormatInt(ts, 10)})
+				}
+			}
+			result = append(result, map[string]interface{}{
+				"metric": map[string]string{"__name__": "up", "job": name},
+				"values": values,
+			})
+		}
+
+		res := matrixResponse{Status: "success"}
+		res.Data.ResultType = "matrix"
+		res.Data.Result = result
+		res.Data.Stats = map[string]interface{}{
+			"timings": map[string]interface{}{"execTotalTime": 0.5},
+			"samples": map[string]interface{}{"totalQueryableSamples": 10, "peakSamples": float64(start % 7)},
+		}
+		body, err := json.Marshal(res)
+		require.NoError(t, err)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+	}
+}
+
+func TestQueryData_SplitRangeQuery(t *testing.T) {
+	from := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	to := from.Add(71 * time.Hour)
+	query := backend.DataQuery{
+		RefID:     "A",
+		TimeRange: backend.TimeRange{From: from, To: to},
+		JSON:      []byte(`{"expr": "up", "range": true, "interval": "1h"}`),
+	}
+
+	setup := func(t *testing.T, wide bool, starts *[]int64, series map[string]int64) *querydata.QueryData {
+		t.Helper()
+		settings := backend.DataSourceInstanceSettings{
+			URL:      "http://localhost:9090",
+			JSONData: json.RawMessage(`{"querySplitInterval": "1d", "queryStats": true}`),
+		}
+		features := &fakeFeatureToggles{flags: map[string]bool{"prometheusWideSeries": wide}}
+		client := &http.Client{Transport: rangeResponder(t, starts, &sync.Mutex{}, series)}
+		qd, err := querydata.New(client, features, tracing.InitializeTracerForTest(), settings, &fakeLogger{})
+		require.NoError(t, err)
+		return qd
+	}
+
+	t.Run("sub-range results are merged into one frame per series", func(t *testing.T) {
+		var starts []int64
+		qd := setup(t, false, &starts, map[string]int64{"a": 0, "b": from.Add(30 * time.Hour).Unix()})
+		res, err := qd.Execute(context.Background(), &backend.QueryDataRequest{Queries: []backend.DataQuery{query}})
+		require.NoError(t, err)
+
+		sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+		require.Equal(t, []int64{
+			from.Unix(),
+			time.Date(2022, 8, 2, 0, 0, 0, 0, time.UTC).Unix(),
+			time.Date(2022, 8, 3, 0, 0, 0, 0, time.UTC).Unix(),
+			time.Date(2022, 8, 4, 0, 0, 0, 0, time.UTC).Unix(),
+		}, starts)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 2)
+		require.Equal(t, 72, frames[0].Rows())
+		require.Equal(t, 42, frames[1].Rows())
+		for _, frame := range frames {
+			times := frame.Fields[0]
+			for i := 1; i < times.Len(); i++ {
+				require.Equal(t, time.Hour, times.At(i).(time.Time).Sub(times.At(i-1).(time.Time)))
+			}
+			require.Contains(t, frame.Meta.ExecutedQueryString, "Split into 4 queries of 24h0m0s")
+		}
+
+		stats := map[string]float64{}
+		for _, stat := range frames[0].Meta.Stats {
+			stats[stat.DisplayName] = stat.Value
+		}
+		require.Equal(t, float64(40), stats["Total queryable samples"])
+		require.Equal(t, float64(2), stats["Exec total time"])
+		require.LessOrEqual(t, stats["Peak samples"], float64(6))
+		require.Nil(t, frames[1].Meta.Stats)
+		require.Equal(t, map[string]interface{}{"resultType": "matrix"}, frames[0].Meta.Custom)
+	})
+
+	t.Run("wide frames are joined on all series", func(t *testing.T) {
+		var starts []int64
+		qd := setup(t, true, &starts, map[string]int64{"a": 0, "b": from.Add(30 * time.Hour).Unix()})
+		res, err := qd.Execute(context.Background(), &backend.QueryDataRequest{Queries: []backend.DataQuery{query}})
+		require.NoError(t, err)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		frame := frames[0]
+		require.Equal(t, data.FrameType(data.FrameTypeTimeSeriesWide), frame.Meta.Type)
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, 72, frame.Rows())
+
+		var b *data.Field
+		for _, field := range frame.Fields[1:] {
+			if field.Labels["job"] == "b" {
+				b = field
+			}
+		}
+		require.NotNil(t, b)
+		require.Nil(t, b.At(0))
+		last, ok := b.ConcreteAt(71)
+		require.True(t, ok)
+		require.Equal(t, float64(to.Unix()), last)
+	})
+
+	t.Run("short ranges are not split", func(t *testing.T) {
+		var starts []int64
+		qd := setup(t, false, &starts, map[string]int64{"a": 0})
+		short := query
+		short.TimeRange.To = from.Add(10 * time.Hour)
+		res, err := qd.Execute(context.Background(), &backend.QueryDataRequest{Queries: []backend.DataQuery{short}})
+		require.NoError(t, err)
+		require.Len(t, starts, 1)
+		require.Len(t, res.Responses["A"].Frames, 1)
+		require.Len(t, res.Responses["A"].Frames[0].Meta.Stats, 8)
+	})
+
+	t.Run("invalid split interval", func(t *testing.T) {
+		_, err := querydata.New(&http.Client{}, &fakeFeatureToggles{}, tracing.InitializeTracerForTest(), backend.DataSourceInstanceSettings{
+			JSONData: json.RawMessage(`{"querySplitInterval": "one day"}`),
+		}, &fakeLogger{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), fmt.Sprintf("%q", "one day"))
+	})
+}
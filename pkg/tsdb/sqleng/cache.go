@@ -0,0 +1,91 @@
+package sqleng
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+)
+
+// resultCache keeps query results for a short time, so that dashboards
+// refreshed by many users at once don't hit the database for every viewer.
+type resultCache struct {
+	cache *localcache.CacheService
+	ttl   time.Duration
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{
+		cache: localcache.New(ttl, 2*ttl),
+		ttl:   ttl,
+	}
+}
+
+// Frames are stored serialized, so that responses never share frames which
+// may be modified further down the query pipeline.
+func (c *resultCache) get(key string) (data.Frames, bool) {
+	cached, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	frames, err := data.UnmarshalArrowFrames(cached.([][]byte))
+	if err != nil {
+		return nil, false
+	}
+	return frames, true
+}
+
+func (c *resultCache) set(key string, frames data.Frames) error {
+	encoded, err := frames.MarshalArrow()
+	if err != nil {
+		return err
+	}
+	c.cache.Set(key, encoded, c.ttl)
+	return nil
+}
+
+// resultCacheKey identifies a query result by the raw SQL, the aligned time
+// range and all settings which change how the rows are queried and converted
+// to frames. The raw SQL is used because the interpolated SQL contains the
+// exact, unaligned time range, so the interval and the max data points, which
+// the interval macros expand to, are part of the key as well.
+func resultCacheKey(query backend.DataQuery, queryJson QueryJson, timeRange backend.TimeRange) (string, error) {
+	b, err := json.Marshal(struct {
+		SQL           string    `json:"sql"`
+		Query         QueryJson `json:"query"`
+		Interval      int64     `json:"interval"`
+		MaxDataPoints int64     `json:"maxDataPoints"`
+		From          int64     `json:"from"`
+		To            int64     `json:"to"`
+	}{
+		SQL:           queryJson.RawSql,
+		Query:         queryJson,
+		Interval:      query.Interval.Nanoseconds(),
+		MaxDataPoints: query.MaxDataPoints,
+		From:          timeRange.From.UnixNano(),
+		To:            timeRange.To.UnixNano(),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// alignTimeRange widens the time range to interval boundaries, so that the
+// same query executed a bit later can still be served from the cache.
+func alignTimeRange(timeRange backend.TimeRange, interval time.Duration) backend.TimeRange {
+	if interval < time.Second {
+		interval = time.Second
+	}
+	from := timeRange.From.Truncate(interval)
+	to := timeRange.To.Truncate(interval)
+	if to.Before(timeRange.To) {
+		to = to.Add(interval)
+	}
+	return backend.TimeRange{From: from, To: to}
+}
@@ -0,0 +1,91 @@
+package sqleng
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignTimeRange(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2022, 1, 1, 10, 0, 12, 0, time.UTC),
+		To:   time.Date(2022, 1, 1, 11, 0, 12, 0, time.UTC),
+	}
+
+	aligned := alignTimeRange(timeRange, time.Minute)
+	require.Equal(t, time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC), aligned.From)
+	require.Equal(t, time.Date(2022, 1, 1, 11, 1, 0, 0, time.UTC), aligned.To)
+
+	// Queries a few seconds apart share the same aligned range.
+	later := backend.TimeRange{From: timeRange.From.Add(30 * time.Second), To: timeRange.To.Add(30 * time.Second)}
+	require.Equal(t, aligned, alignTimeRange(later, time.Minute))
+
+	// Aligned ranges stay the same.
+	require.Equal(t, aligned, alignTimeRange(aligned, time.Minute))
+}
+
+func TestResultCache(t *testing.T) {
+	cache := newResultCache(time.Minute)
+	timeRange := backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(60, 0)}
+
+	key, err := resultCacheKey(backend.DataQuery{}, QueryJson{RawSql: "SELECT 1", Format: "table"}, timeRange)
+	require.NoError(t, err)
+	otherFormatKey, err := resultCacheKey(backend.DataQuery{}, QueryJson{RawSql: "SELECT 1", Format: "time_series"}, timeRange)
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherFormatKey)
+
+	_, ok := cache.get(key)
+	require.False(t, ok)
+
+	frame := data.NewFrame("", data.NewField("value", nil, []int64{1}))
+	frame.SetMeta(&data.FrameMeta{ExecutedQueryString: "SELECT 1"})
+	require.NoError(t, cache.set(key, data.Frames{frame}))
+
+	cached, ok := cache.get(key)
+	require.True(t, ok)
+	require.Len(t, cached, 1)
+	require.Equal(t, int64(1), cached[0].Fields[0].At(0))
+	require.Equal(t, "SELECT 1", cached[0].Meta.ExecutedQueryString)
+
+	// Cached frames are copies.
+	cached[0].Fields[0].Set(0, int64(2))
+	cached, _ = cache.get(key)
+	require.Equal(t, int64(1), cached[0].Fields[0].At(0))
+}
+
+func TestResultCacheKey(t *testing.T) {
+	queryJson := QueryJson{RawSql: "SELECT $__timeGroup(time, $__interval) FROM t WHERE $__timeFilter(time)", Format: "time_series"}
+	query := backend.DataQuery{Interval: time.Minute, MaxDataPoints: 1000}
+	timeRange := backend.TimeRange{
+		From: time.Date(2022, 1, 1, 10, 0, 12, 0, time.UTC),
+		To:   time.Date(2022, 1, 1, 11, 0, 12, 0, time.UTC),
+	}
+	later := backend.TimeRange{From: timeRange.From.Add(30 * time.Second), To: timeRange.To.Add(30 * time.Second)}
+
+	key, err := resultCacheKey(query, queryJson, alignTimeRange(timeRange, time.Minute))
+	require.NoError(t, err)
+	laterKey, err := resultCacheKey(query, queryJson, alignTimeRange(later, time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, key, laterKey)
+
+	// Queries in the next interval do not share the cached result.
+	next := backend.TimeRange{From: timeRange.From.Add(time.Minute), To: timeRange.To.Add(time.Minute)}
+	nextKey, err := resultCacheKey(query, queryJson, alignTimeRange(next, time.Minute))
+	require.NoError(t, err)
+	require.NotEqual(t, key, nextKey)
+
+	// Queries with another interval are bucketed differently, even when the
+	// time range is aligned to the same value.
+	otherInterval := backend.DataQuery{Interval: 30 * time.Second, MaxDataPoints: 1000}
+	otherIntervalKey, err := resultCacheKey(otherInterval, queryJson, alignTimeRange(timeRange, time.Minute))
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherIntervalKey)
+
+	otherMaxDataPoints := backend.DataQuery{Interval: time.Minute, MaxDataPoints: 500}
+	otherMaxDataPointsKey, err := resultCacheKey(otherMaxDataPoints, queryJson, alignTimeRange(timeRange, time.Minute))
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherMaxDataPointsKey)
+}
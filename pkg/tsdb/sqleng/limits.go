@@ -0,0 +1,109 @@
+package sqleng
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+)
+
+// resultLimits restricts the size of the data frames built from query results.
+// A zero limit disables the corresponding check.
+type resultLimits struct {
+	rows  int64
+	bytes int64
+}
+
+// newResultLimits combines the global row limit with the data source limits,
+// the data source can only lower the global limit.
+func newResultLimits(globalRowLimit int64, jsonData JsonData) resultLimits {
+	limits := resultLimits{rows: globalRowLimit, bytes: jsonData.MaxBytes}
+	if jsonData.MaxRows > 0 && (limits.rows <= 0 || jsonData.MaxRows < limits.rows) {
+		limits.rows = jsonData.MaxRows
+	}
+	return limits
+}
+
+// frameFromRows converts rows to a data frame like sqlutil.FrameFromRows, it
+// additionally stops reading rows once the byte limit is reached. When a limit
+// is reached a notice is added to the frame and the name of the limit returned.
+func frameFromRows(rows *sql.Rows, limits resultLimits, converters ...sqlutil.Converter) (*data.Frame, string, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, "", err
+	}
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, "", err
+	}
+
+	scanner, converters, err := sqlutil.MakeScanRow(types, names, converters...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	frame := sqlutil.NewFrame(names, converters...)
+
+	var rowCount, byteCount int64
+	for rows.Next() {
+		if limits.rows > 0 && rowCount == limits.rows {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     fmt.Sprintf("Results have been limited to %v because the SQL row limit was reached", limits.rows),
+			})
+			return frame, limitTypeRows, rows.Err()
+		}
+
+		r := scanner.NewScannableRow()
+		if err := rows.Scan(r...); err != nil {
+			return nil, "", err
+		}
+
+		if err := sqlutil.Append(frame, r, converters...); err != nil {
+			return nil, "", err
+		}
+		rowCount++
+
+		if limits.bytes > 0 {
+			byteCount += frameRowSize(frame, int(rowCount-1))
+			if byteCount > limits.bytes {
+				frame.AppendNotices(data.Notice{
+					Severity: data.NoticeSeverityWarning,
+					Text:     fmt.Sprintf("Results have been limited to %v rows because the SQL byte limit of %v bytes was reached", rowCount, limits.bytes),
+				})
+				return frame, limitTypeBytes, rows.Err()
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return frame, "", err
+	}
+
+	return frame, "", nil
+}
+
+// frameRowSize estimates the memory used by the values of a frame row.
+func frameRowSize(frame *data.Frame, rowIdx int) int64 {
+	var size int64
+	for _, field := range frame.Fields {
+		switch v := field.At(rowIdx).(type) {
+		case string:
+			size += int64(len(v))
+		case *string:
+			if v != nil {
+				size += int64(len(*v))
+			}
+		case []byte:
+			size += int64(len(v))
+		case time.Time, *time.Time:
+			size += 24
+		default:
+			size += 8
+		}
+	}
+	return size
+}
@@ -0,0 +1,81 @@
+package sqleng
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResultLimits(t *testing.T) {
+	require.Equal(t, resultLimits{rows: 1000}, newResultLimits(1000, JsonData{}))
+	require.Equal(t, resultLimits{rows: 10, bytes: 100}, newResultLimits(1000, JsonData{MaxRows: 10, MaxBytes: 100}))
+	// The data source can't raise the global limit.
+	require.Equal(t, resultLimits{rows: 1000}, newResultLimits(1000, JsonData{MaxRows: 5000}))
+}
+
+func TestFrameFromRows(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE metrics (host TEXT, value INTEGER)`)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = db.Exec(`INSERT INTO metrics (host, value) VALUES ('server-0123456789', ?)`, i)
+		require.NoError(t, err)
+	}
+
+	query := func(t *testing.T, limits resultLimits) (*data.Frame, string) {
+		t.Helper()
+		rows, err := db.Query(`SELECT host, value FROM metrics`)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = rows.Close() })
+		// sqlite only knows the scan type of a column after reading a row.
+		replacer := &sqlutil.StringFieldReplacer{
+			OutputFieldType: data.FieldTypeNullableString,
+			ReplaceFunc:     func(in *string) (interface{}, error) { return in, nil },
+		}
+		converters := sqlutil.ToConverters(
+			sqlutil.StringConverter{InputScanKind: reflect.String, InputTypeName: "TEXT", Replacer: replacer},
+			sqlutil.StringConverter{InputScanKind: reflect.String, InputTypeName: "INTEGER", Replacer: replacer},
+		)
+		frame, reachedLimit, err := frameFromRows(rows, limits, converters...)
+		require.NoError(t, err)
+		return frame, reachedLimit
+	}
+
+	t.Run("without limits", func(t *testing.T) {
+		frame, reachedLimit := query(t, resultLimits{})
+		require.Equal(t, 5, frame.Rows())
+		require.Empty(t, reachedLimit)
+		require.Nil(t, frame.Meta)
+	})
+
+	t.Run("limit not reached", func(t *testing.T) {
+		frame, reachedLimit := query(t, resultLimits{rows: 5, bytes: 1000})
+		require.Equal(t, 5, frame.Rows())
+		require.Empty(t, reachedLimit)
+	})
+
+	t.Run("row limit", func(t *testing.T) {
+		frame, reachedLimit := query(t, resultLimits{rows: 2})
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, limitTypeRows, reachedLimit)
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityWarning, frame.Meta.Notices[0].Severity)
+	})
+
+	t.Run("byte limit", func(t *testing.T) {
+		// Every row is estimated at 17 bytes for the host and 1 byte for the value.
+		frame, reachedLimit := query(t, resultLimits{bytes: 40})
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, limitTypeBytes, reachedLimit)
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Contains(t, frame.Meta.Notices[0].Text, "byte limit of 40 bytes")
+	})
+}
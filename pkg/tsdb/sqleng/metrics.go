@@ -0,0 +1,51 @@
+package sqleng
+
+import (
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	limitTypeRows  = "rows"
+	limitTypeBytes = "bytes"
+)
+
+var (
+	queriesCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Name:      "sql_datasource_queries_total",
+			Help:      "A counter for queries executed against SQL data sources",
+		},
+		[]string{"driver", "status"},
+	)
+
+	queryDurationHistogram = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.ExporterName,
+			Name:      "sql_datasource_query_duration_seconds",
+			Help:      "Histogram of durations of queries executed against SQL data sources, including the conversion to data frames",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 25, 50, 100},
+		},
+		[]string{"driver"},
+	)
+
+	cacheRequestsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Name:      "sql_datasource_cache_requests_total",
+			Help:      "A counter for SQL data source query result cache lookups",
+		},
+		[]string{"driver", "hit"},
+	)
+
+	truncatedResultsCounter = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Name:      "sql_datasource_truncated_results_total",
+			Help:      "A counter for SQL data source query results truncated by a row or byte limit",
+		},
+		[]string{"driver", "limit"},
+	)
+)
@@ -40,6 +40,13 @@ type SqlQueryResultTransformer interface {
 	GetConverterList() []sqlutil.StringConverter
 }
 
+// SqlQueryResultConverterProvider can be implemented by a SqlQueryResultTransformer
+// which needs converters that aren't string converters, e.g. to match database
+// type names with a regular expression. They take precedence over the converter list.
+type SqlQueryResultConverterProvider interface {
+	GetConverters() []sqlutil.Converter
+}
+
 var sqlIntervalCalculator = intervalv2.NewCalculator()
 
 // NewXormEngine is an xorm.Engine factory, that can be stubbed by tests.
@@ -63,6 +70,9 @@ type JsonData struct {
 	Encrypt             string `json:"encrypt"`
 	Servername          string `json:"servername"`
 	TimeInterval        string `json:"timeInterval"`
+	MaxRows             int64  `json:"maxRows"`
+	MaxBytes            int64  `json:"maxBytes"`
+	QueryCacheTTL       string `json:"queryCacheTTL"`
 }
 
 type DataSourceInfo struct {
@@ -92,7 +102,9 @@ type DataSourceHandler struct {
 	metricColumnTypes      []string
 	log                    log.Logger
 	dsInfo                 DataSourceInfo
-	rowLimit               int64
+	driverName             string
+	limits                 resultLimits
+	resultCache            *resultCache
 }
 type QueryJson struct {
 	RawSql       string  `json:"rawSql"`
@@ -130,7 +142,18 @@ func NewQueryDataHandler(config DataPluginConfiguration, queryResultTransformer
 		timeColumnNames:        []string{"time"},
 		log:                    log,
 		dsInfo:                 config.DSInfo,
-		rowLimit:               config.RowLimit,
+		driverName:             config.DriverName,
+		limits:                 newResultLimits(config.RowLimit, config.DSInfo.JsonData),
+	}
+
+	if config.DSInfo.JsonData.QueryCacheTTL != "" {
+		ttl, err := time.ParseDuration(config.DSInfo.JsonData.QueryCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query cache TTL: %w", err)
+		}
+		if ttl > 0 {
+			queryDataHandler.resultCache = newResultCache(ttl)
+		}
 	}
 
 	if len(config.TimeColumnNames) > 0 {
@@ -255,6 +278,34 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		return
 	}
 
+	// The aligned time range is only used to look up the cached result, the
+	// query itself always runs with the requested time range.
+	var cacheKey string
+	if e.resultCache != nil {
+		cacheKey, err = resultCacheKey(query, queryJson, alignTimeRange(timeRange, query.Interval))
+		if err != nil {
+			errAppendDebug("failed to build cache key", err, interpolatedQuery)
+			return
+		}
+		if frames, ok := e.resultCache.get(cacheKey); ok {
+			cacheRequestsCounter.WithLabelValues(e.driverName, "true").Inc()
+			queryResult.dataResponse.Frames = frames
+			ch <- queryResult
+			return
+		}
+		cacheRequestsCounter.WithLabelValues(e.driverName, "false").Inc()
+	}
+
+	start := time.Now()
+	defer func() {
+		status := "ok"
+		if queryResult.dataResponse.Error != nil {
+			status = "error"
+		}
+		queriesCounter.WithLabelValues(e.driverName, status).Inc()
+		queryDurationHistogram.WithLabelValues(e.driverName).Observe(time.Since(start).Seconds())
+	}()
+
 	session := e.engine.NewSession()
 	defer session.Close()
 	db := session.DB()
@@ -278,11 +329,18 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 
 	// Convert row.Rows to dataframe
 	stringConverters := e.queryResultTransformer.GetConverterList()
-	frame, err := sqlutil.FrameFromRows(rows.Rows, e.rowLimit, sqlutil.ToConverters(stringConverters...)...)
+	converters := sqlutil.ToConverters(stringConverters...)
+	if provider, ok := e.queryResultTransformer.(SqlQueryResultConverterProvider); ok {
+		converters = append(provider.GetConverters(), converters...)
+	}
+	frame, reachedLimit, err := frameFromRows(rows.Rows, e.limits, converters...)
 	if err != nil {
 		errAppendDebug("convert frame from rows error", err, interpolatedQuery)
 		return
 	}
+	if reachedLimit != "" {
+		truncatedResultsCounter.WithLabelValues(e.driverName, reachedLimit).Inc()
+	}
 
 	if frame.Meta == nil {
 		frame.Meta = &data.FrameMeta{}
@@ -293,6 +351,7 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 	// If no rows were returned, no point checking anything else.
 	if frame.Rows() == 0 {
 		queryResult.dataResponse.Frames = data.Frames{frame}
+		e.cacheResult(cacheKey, queryResult.dataResponse.Frames)
 		ch <- queryResult
 		return
 	}
@@ -364,9 +423,19 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 	}
 
 	queryResult.dataResponse.Frames = data.Frames{frame}
+	e.cacheResult(cacheKey, queryResult.dataResponse.Frames)
 	ch <- queryResult
 }
 
+func (e *DataSourceHandler) cacheResult(cacheKey string, frames data.Frames) {
+	if e.resultCache == nil {
+		return
+	}
+	if err := e.resultCache.set(cacheKey, frames); err != nil {
+		e.log.Warn("Failed to cache query result", "err", err)
+	}
+}
+
 // Interpolate provides global macros/substitutions for all sql datasources.
 var Interpolate = func(query backend.DataQuery, timeRange backend.TimeRange, timeInterval string, sql string) (string, error) {
 	minInterval, err := intervalv2.GetIntervalFrom(timeInterval, query.Interval.String(), query.Interval.Milliseconds(), time.Second*60)
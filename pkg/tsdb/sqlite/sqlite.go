@@ -0,0 +1,244 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"github.com/mattn/go-sqlite3"
+)
+
+var logger = log.New("tsdb.sqlite")
+
+type Service struct {
+	im instancemgmt.InstanceManager
+}
+
+func ProvideService(cfg *setting.Cfg) *Service {
+	return &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(cfg)),
+	}
+}
+
+func newInstanceSettings(cfg *setting.Cfg) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		jsonData := sqleng.JsonData{
+			MaxOpenConns:    0,
+			MaxIdleConns:    2,
+			ConnMaxLifetime: 14400,
+		}
+
+		err := json.Unmarshal(settings.JSONData, &jsonData)
+		if err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+		dsInfo := sqleng.DataSourceInfo{
+			JsonData:                jsonData,
+			URL:                     settings.URL,
+			User:                    settings.User,
+			Database:                settings.Database,
+			ID:                      settings.ID,
+			Updated:                 settings.Updated,
+			UID:                     settings.UID,
+			DecryptedSecureJSONData: settings.DecryptedSecureJSONData,
+		}
+
+		cnnstr, err := generateConnectionString(cfg, dsInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Env == setting.Dev {
+			logger.Debug("getEngine", "connection", cnnstr)
+		}
+
+		config := sqleng.DataPluginConfiguration{
+			DriverName:        "sqlite3",
+			ConnectionString:  cnnstr,
+			DSInfo:            dsInfo,
+			TimeColumnNames:   []string{"time", "time_sec"},
+			MetricColumnTypes: []string{"TEXT", "VARCHAR", "CHAR", "CLOB", "text", "varchar", "char", "clob"},
+			RowLimit:          cfg.DataProxyRowLimit,
+		}
+
+		rowTransformer := sqliteQueryResultTransformer{}
+
+		return sqleng.NewQueryDataHandler(config, &rowTransformer, newSQLiteMacroEngine(logger), logger)
+	}
+}
+
+// generateConnectionString opens the database file in read-only mode. The database
+// path is the database setting of the data source and has to be in one of the
+// allowed paths of the sqlite configuration section.
+func generateConnectionString(cfg *setting.Cfg, dsInfo sqleng.DataSourceInfo) (string, error) {
+	if len(cfg.SQLite.AllowedPaths) == 0 {
+		return "", errors.New("the SQLite data source is disabled, configure allowed_paths in the sqlite section to enable it")
+	}
+	if dsInfo.Database == "" {
+		return "", errors.New("path to the database file is missing")
+	}
+
+	path, err := filepath.Abs(dsInfo.Database)
+	if err != nil {
+		return "", fmt.Errorf("invalid database path: %w", err)
+	}
+	if !isAllowedPath(cfg.SQLite.AllowedPaths, path) {
+		return "", errors.New("the database file is not in one of the allowed paths")
+	}
+
+	params := url.Values{}
+	params.Set("mode", "ro")
+	params.Set("_busy_timeout", "5000")
+	params.Set("_loc", "UTC")
+
+	return "file:" + path + "?" + params.Encode(), nil
+}
+
+func isAllowedPath(allowedPaths []string, path string) bool {
+	for _, dir := range allowedPaths {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) getDataSourceHandler(pluginCtx backend.PluginContext) (*sqleng.DataSourceHandler, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+	instance := i.(*sqleng.DataSourceHandler)
+	return instance, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsHandler, err := s.getDataSourceHandler(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	return dsHandler.QueryData(ctx, req)
+}
+
+type sqliteQueryResultTransformer struct{}
+
+func (t *sqliteQueryResultTransformer) TransformQueryError(err error) error {
+	return err
+}
+
+func (t *sqliteQueryResultTransformer) GetConverterList() []sqlutil.StringConverter {
+	return nil
+}
+
+// The SQLite driver only knows the scan type of a column after the first row has been read,
+// so the columns are scanned as strings and converted based on the declared type. The type
+// affinity rules of SQLite are used to map the declared type, see https://sqlite.org/datatype3.html
+var (
+	integerTypeRegexp  = regexp.MustCompile(`(?i)INT`)
+	textTypeRegexp     = regexp.MustCompile(`(?i)CHAR|CLOB|TEXT`)
+	blobTypeRegexp     = regexp.MustCompile(`(?i)BLOB`)
+	realTypeRegexp     = regexp.MustCompile(`(?i)REAL|FLOA|DOUB`)
+	timeTypeRegexp     = regexp.MustCompile(`(?i)^(DATE|DATETIME|TIMESTAMP)$`)
+	expressionRegexp   = regexp.MustCompile(`^$`)
+	numericTypeRegexp  = regexp.MustCompile(`.*`)
+	errExpressionValue = errors.New("expression columns are converted to numbers, select a table column to return text")
+)
+
+// GetConverters implements sqleng.SqlQueryResultConverterProvider.
+func (t *sqliteQueryResultTransformer) GetConverters() []sqlutil.Converter {
+	return []sqlutil.Converter{
+		// Expressions have no declared type, for time series they are mostly times and values.
+		// This converter has to be the first one, as converters without type name match them too.
+		regexpConverter("handle expression", expressionRegexp, data.FieldTypeNullableFloat64, func(in string) (interface{}, error) {
+			v, err := strconv.ParseFloat(in, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", errExpressionValue, in)
+			}
+			return &v, nil
+		}),
+		regexpConverter("handle INTEGER", integerTypeRegexp, data.FieldTypeNullableInt64, parseInteger),
+		regexpConverter("handle TEXT", textTypeRegexp, data.FieldTypeNullableString, func(in string) (interface{}, error) {
+			return &in, nil
+		}),
+		regexpConverter("handle BLOB", blobTypeRegexp, data.FieldTypeNullableString, func(in string) (interface{}, error) {
+			return &in, nil
+		}),
+		regexpConverter("handle REAL", realTypeRegexp, data.FieldTypeNullableFloat64, parseFloat),
+		regexpConverter("handle DATETIME", timeTypeRegexp, data.FieldTypeNullableTime, parseTime),
+		regexpConverter("handle NUMERIC", numericTypeRegexp, data.FieldTypeNullableFloat64, parseFloat),
+	}
+}
+
+func regexpConverter(name string, typeRegexp *regexp.Regexp, fieldType data.FieldType, parse func(in string) (interface{}, error)) sqlutil.Converter {
+	converter := sqlutil.StringConverter{
+		Name:           name,
+		ConversionFunc: func(in *string) (*string, error) { return in, nil },
+		Replacer: &sqlutil.StringFieldReplacer{
+			OutputFieldType: fieldType,
+			ReplaceFunc: func(in *string) (interface{}, error) {
+				if in == nil {
+					return nil, nil
+				}
+				return parse(*in)
+			},
+		},
+	}.ToConverter()
+	converter.InputTypeRegex = typeRegexp
+	return converter
+}
+
+func parseInteger(in string) (interface{}, error) {
+	v, err := strconv.ParseInt(in, 10, 64)
+	if err != nil {
+		// Columns with integer affinity can still store any value.
+		f, ferr := strconv.ParseFloat(in, 64)
+		if ferr != nil {
+			return nil, err
+		}
+		v = int64(f)
+	}
+	return &v, nil
+}
+
+func parseFloat(in string) (interface{}, error) {
+	v, err := strconv.ParseFloat(in, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// parseTime parses the time formats of the SQLite date and time functions
+// and unix timestamps in seconds. Values which the driver already parsed
+// are formatted as RFC3339 when they are scanned to strings.
+func parseTime(in string) (interface{}, error) {
+	for _, format := range append([]string{time.RFC3339Nano}, sqlite3.SQLiteTimestampFormats...) {
+		if v, err := time.ParseInLocation(format, in, time.UTC); err == nil {
+			return &v, nil
+		}
+	}
+	if sec, err := strconv.ParseInt(in, 10, 64); err == nil {
+		v := time.Unix(sec, 0).UTC()
+		return &v, nil
+	}
+	return nil, fmt.Errorf("unexpected time value %q", in)
+}
@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConnectionString(t *testing.T) {
+	cfg := &setting.Cfg{SQLite: setting.SQLiteSettings{AllowedPaths: []string{"/data", "/srv/metrics"}}}
+
+	t.Run("opens the database read-only", func(t *testing.T) {
+		cnnstr, err := generateConnectionString(cfg, sqleng.DataSourceInfo{Database: "/data/metrics.db"})
+		require.NoError(t, err)
+		require.Equal(t, "file:/data/metrics.db?_busy_timeout=5000&_loc=UTC&mode=ro", cnnstr)
+	})
+
+	t.Run("requires a database path", func(t *testing.T) {
+		_, err := generateConnectionString(cfg, sqleng.DataSourceInfo{})
+		require.Error(t, err)
+	})
+
+	t.Run("is disabled without allowed paths", func(t *testing.T) {
+		_, err := generateConnectionString(&setting.Cfg{}, sqleng.DataSourceInfo{Database: "/data/metrics.db"})
+		require.Error(t, err)
+	})
+
+	t.Run("only allows files in the allowed paths", func(t *testing.T) {
+		_, err := generateConnectionString(cfg, sqleng.DataSourceInfo{Database: "/srv/metrics/sub/metrics.db"})
+		require.NoError(t, err)
+
+		for _, path := range []string{
+			"/var/lib/grafana/grafana.db",
+			"/data",
+			"/data/../var/lib/grafana/grafana.db",
+			"/data-other/metrics.db",
+			"/srv/metrics.db",
+			"metrics.db",
+		} {
+			_, err := generateConnectionString(cfg, sqleng.DataSourceInfo{Database: path})
+			require.Error(t, err, path)
+		}
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE metrics (time DATETIME, host TEXT, value REAL, count INTEGER)`)
+	require.NoError(t, err)
+	from := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	for i, host := range []string{"a", "b", "a", "b"} {
+		ts := from.Add(time.Duration(i/2*2) * time.Minute)
+		_, err = db.Exec(`INSERT INTO metrics VALUES (?, ?, ?, ?)`, ts.Format("2006-01-02 15:04:05"), host, float64(i)+0.5, i)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	cfg := &setting.Cfg{SQLite: setting.SQLiteSettings{AllowedPaths: []string{filepath.Dir(path)}}}
+	handler, err := newInstanceSettings(cfg)(backend.DataSourceInstanceSettings{
+		Database: path,
+		JSONData: []byte(`{}`),
+	})
+	require.NoError(t, err)
+	t.Cleanup(handler.(*sqleng.DataSourceHandler).Dispose)
+
+	query := func(t *testing.T, rawSQL string, format string) *data.Frame {
+		t.Helper()
+		resp, err := handler.(*sqleng.DataSourceHandler).QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				JSON:      []byte(`{"rawSql": "` + rawSQL + `", "format": "` + format + `"}`),
+				TimeRange: backend.TimeRange{From: from, To: from.Add(5 * time.Minute)},
+				Interval:  time.Minute,
+			}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		return resp.Responses["A"].Frames[0]
+	}
+
+	t.Run("table query converts declared types", func(t *testing.T) {
+		frame := query(t, "SELECT time, host, value, count FROM metrics WHERE $__timeFilter(time) ORDER BY time, host", "table")
+		require.Equal(t, 4, frame.Rows())
+		require.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		require.Equal(t, data.FieldTypeNullableString, frame.Fields[1].Type())
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+		require.Equal(t, data.FieldTypeNullableInt64, frame.Fields[3].Type())
+		require.Equal(t, from, *frame.Fields[0].At(0).(*time.Time))
+	})
+
+	t.Run("time series query with fill", func(t *testing.T) {
+		frame := query(t, "SELECT $__timeGroupAlias(time, '1m', 0), host AS metric, sum(value) AS value FROM metrics GROUP BY 1, 2 ORDER BY 1", "time_series")
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, "a", frame.Fields[1].Name)
+		require.Equal(t, "b", frame.Fields[2].Name)
+		// Minutes 10:00 to 10:05, with the missing minutes filled with zero.
+		require.Equal(t, 6, frame.Rows())
+		require.True(t, from.Equal(frame.Fields[0].At(0).(time.Time)))
+		require.Equal(t, 0.5, *frame.Fields[1].At(0).(*float64))
+		require.Equal(t, float64(0), *frame.Fields[1].At(1).(*float64))
+		require.Equal(t, 3.5, *frame.Fields[2].At(2).(*float64))
+	})
+
+	t.Run("text expressions are not supported", func(t *testing.T) {
+		resp, err := handler.(*sqleng.DataSourceHandler).QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(`{"rawSql": "SELECT host || '-x' FROM metrics", "format": "table"}`)}},
+		})
+		require.NoError(t, err)
+		require.ErrorIs(t, resp.Responses["A"].Error, errExpressionValue)
+	})
+}
+
+func TestMacroEngine(t *testing.T) {
+	engine := newSQLiteMacroEngine(log.New("test"))
+	query := &backend.DataQuery{JSON: []byte(`{}`)}
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := backend.TimeRange{From: from, To: to}
+
+	tests := []struct {
+		sql      string
+		expected string
+	}{
+		{"select $__time(time_column)", "select CAST(strftime('%s', time_column) AS INTEGER) AS time_sec"},
+		{"WHERE $__timeFilter(time_column)", "WHERE CAST(strftime('%s', time_column) AS INTEGER) BETWEEN 1523556000 AND 1523556300"},
+		{"select $__timeFrom(), $__timeTo()", "select datetime(1523556000, 'unixepoch'), datetime(1523556300, 'unixepoch')"},
+		{"GROUP BY $__timeGroup(time_column, '5m')", "GROUP BY CAST(strftime('%s', time_column) AS INTEGER) / 300 * 300"},
+		{"select $__timeGroupAlias(time_column, '5m')", "select CAST(strftime('%s', time_column) AS INTEGER) / 300 * 300 AS \"time\""},
+		{"WHERE $__unixEpochFilter(time)", "WHERE time >= 1523556000 AND time <= 1523556300"},
+		{"select $__unixEpochGroup(time, '1h')", "select time / 3600 * 3600"},
+	}
+	for _, tt := range tests {
+		sql, err := engine.Interpolate(query, timeRange, tt.sql)
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, sql)
+	}
+
+	t.Run("statements accessing other databases are not allowed", func(t *testing.T) {
+		for _, sql := range []string{
+			"ATTACH DATABASE '/var/lib/grafana/grafana.db' AS g; SELECT * FROM g.user",
+			"select 1; pragma table_info(user)",
+			"VACUUM INTO '/tmp/copy.db'",
+		} {
+			_, err := engine.Interpolate(query, timeRange, sql)
+			require.Error(t, err, sql)
+		}
+		_, err := engine.Interpolate(query, timeRange, "select attachments from mail")
+		require.NoError(t, err)
+	})
+}
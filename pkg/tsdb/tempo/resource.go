@@ -0,0 +1,115 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+var _ backend.CallResourceHandler = (*Service)(nil)
+
+// CallResource proxies tag autocompletion requests to Tempo:
+// `tags` lists tag names and `tag/$tag_name/values` lists values of a tag.
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	return s.callResource(ctx, req, sender, dsInfo)
+}
+
+func (s *Service) callResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, dsInfo *datasourceInfo) error {
+	if req.Method != "GET" {
+		return fmt.Errorf("invalid resource method: %s", req.Method)
+	}
+	resourceURL, err := parseResourceURL(req.URL)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", dsInfo.URL+"/api/search/"+resourceURL, nil)
+	if err != nil {
+		return err
+	}
+	setResourceHeaders(request, req.Headers, dsInfo)
+
+	resp, err := dsInfo.HTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed get to tempo: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(&backend.CallResourceResponse{
+		Status: resp.StatusCode,
+		Headers: map[string][]string{
+			"content-type": {"application/json"},
+		},
+		Body: body,
+	})
+}
+
+// parseResourceURL only accepts `tags` and `tag/$tag_name/values`, so that
+// callers cannot reach other Tempo endpoints with the datasource credentials.
+func parseResourceURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", fmt.Errorf("invalid resource URL: %s", rawURL)
+	}
+
+	segments := strings.Split(u.EscapedPath(), "/")
+	valid := len(segments) == 1 && segments[0] == "tags"
+	if len(segments) == 3 && segments[0] == "tag" && segments[2] == "values" {
+		name, err := url.PathUnescape(segments[1])
+		valid = err == nil && name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+	}
+	if !valid {
+		return "", fmt.Errorf("invalid resource URL: %s", rawURL)
+	}
+
+	if u.RawQuery != "" {
+		return u.EscapedPath() + "?" + u.RawQuery, nil
+	}
+	return u.EscapedPath(), nil
+}
+
+// setResourceHeaders forwards the OAuth token and the cookies allowed by the
+// datasource settings. Other headers of the caller, such as its Grafana
+// credentials, must not reach Tempo.
+func setResourceHeaders(request *http.Request, headers map[string][]string, dsInfo *datasourceInfo) {
+	if dsInfo.OAuthPassThru {
+		// Grafana appends the OAuth token after the Authorization header of the caller
+		if values := headers["Authorization"]; len(values) > 0 && values[len(values)-1] != "" {
+			request.Header.Set("Authorization", values[len(values)-1])
+		}
+		if values := headers["X-Id-Token"]; len(values) > 0 && values[0] != "" {
+			request.Header.Set("X-ID-Token", values[0])
+		}
+	}
+
+	if len(dsInfo.KeepCookies) > 0 {
+		incoming := http.Request{Header: http.Header{"Cookie": headers["Cookie"]}}
+		for _, cookie := range incoming.Cookies() {
+			for _, name := range dsInfo.KeepCookies {
+				if cookie.Name == name {
+					request.AddCookie(cookie)
+				}
+			}
+		}
+	}
+}
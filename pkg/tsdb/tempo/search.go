@@ -0,0 +1,166 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const defaultSearchLimit = 20
+
+var traceIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{1,32}$`)
+
+// isTraceID reports whether query is a trace ID rather than a TraceQL expression.
+func isTraceID(query string) bool {
+	return traceIDRegexp.MatchString(strings.TrimSpace(query))
+}
+
+type searchResponse struct {
+	Traces []searchTrace `json:"traces"`
+}
+
+type searchTrace struct {
+	TraceID           string `json:"traceID"`
+	RootServiceName   string `json:"rootServiceName"`
+	RootTraceName     string `json:"rootTraceName"`
+	StartTimeUnixNano string `json:"startTimeUnixNano"`
+	DurationMs        int64  `json:"durationMs"`
+}
+
+// searchParamsFromModel builds tag based search parameters in the same way
+// the frontend does for native search queries.
+func searchParamsFromModel(model *QueryModel) url.Values {
+	tags := strings.TrimSpace(model.Search)
+	if model.ServiceName != "" {
+		tags += fmt.Sprintf(" service.name=%q", model.ServiceName)
+	}
+	if model.SpanName != "" {
+		tags += fmt.Sprintf(" name=%q", model.SpanName)
+	}
+
+	params := url.Values{}
+	if tags = strings.TrimSpace(tags); tags != "" {
+		params.Set("tags", tags)
+	}
+	if model.MinDuration != "" {
+		params.Set("minDuration", strings.ReplaceAll(model.MinDuration, " ", ""))
+	}
+	if model.MaxDuration != "" {
+		params.Set("maxDuration", strings.ReplaceAll(model.MaxDuration, " ", ""))
+	}
+	return params
+}
+
+func (s *Service) search(ctx context.Context, dsInfo *datasourceInfo, query backend.DataQuery, model *QueryModel, params url.Values) backend.DataResponse {
+	queryRes := backend.DataResponse{}
+
+	limit := model.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit < 0 {
+		queryRes.Error = fmt.Errorf("invalid limit: %d", limit)
+		return queryRes
+	}
+	params.Set("limit", strconv.FormatInt(limit, 10))
+	if !query.TimeRange.From.IsZero() && !query.TimeRange.To.IsZero() {
+		params.Set("start", strconv.FormatInt(query.TimeRange.From.Unix(), 10))
+		params.Set("end", strconv.FormatInt(query.TimeRange.To.Unix(), 10))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", dsInfo.URL+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+	request.Header.Set("Accept", "application/json")
+	s.tlog.Debug("Tempo search request", "url", request.URL.String())
+
+	resp, err := dsInfo.HTTPClient.Do(request)
+	if err != nil {
+		queryRes.Error = fmt.Errorf("failed get to tempo: %w", err)
+		return queryRes
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.tlog.Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		queryRes.Error = err
+		return queryRes
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		queryRes.Error = fmt.Errorf("failed to search traces Status: %s Body: %s", resp.Status, string(body))
+		return queryRes
+	}
+
+	var result searchResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		queryRes.Error = fmt.Errorf("failed to parse tempo search response: %w", err)
+		return queryRes
+	}
+
+	frame := searchResponseToFrame(result)
+	frame.RefID = query.RefID
+	queryRes.Frames = data.Frames{frame}
+	return queryRes
+}
+
+// searchResponseToFrame converts found traces to a table, most recent traces first.
+func searchResponseToFrame(result searchResponse) *data.Frame {
+	traces := result.Traces
+	startTimes := make([]int64, len(traces))
+	for i, trace := range traces {
+		// Traces with unparsable start time are sorted last.
+		startTimes[i], _ = strconv.ParseInt(trace.StartTimeUnixNano, 10, 64)
+	}
+	indexes := make([]int, len(traces))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return startTimes[indexes[i]] > startTimes[indexes[j]]
+	})
+
+	traceIDs := make([]string, 0, len(traces))
+	traceNames := make([]string, 0, len(traces))
+	serviceNames := make([]string, 0, len(traces))
+	starts := make([]time.Time, 0, len(traces))
+	durations := make([]int64, 0, len(traces))
+	for _, i := range indexes {
+		trace := traces[i]
+		traceIDs = append(traceIDs, trace.TraceID)
+		traceNames = append(traceNames, trace.RootTraceName)
+		serviceNames = append(serviceNames, trace.RootServiceName)
+		starts = append(starts, time.Unix(0, startTimes[i]).UTC())
+		durations = append(durations, trace.DurationMs)
+	}
+
+	frame := data.NewFrame("Traces",
+		data.NewField("traceID", nil, traceIDs).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Trace ID"}),
+		data.NewField("traceName", nil, traceNames).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Trace name"}),
+		data.NewField("serviceName", nil, serviceNames).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Service name"}),
+		data.NewField("startTime", nil, starts).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Start time"}),
+		data.NewField("duration", nil, durations).SetConfig(&data.FieldConfig{DisplayNameFromDS: "Duration", Unit: "ms"}),
+	)
+	frame.Meta = &data.FrameMeta{
+		PreferredVisualization: data.VisTypeTable,
+	}
+	return frame
+}
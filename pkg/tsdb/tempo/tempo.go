@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
@@ -29,12 +30,33 @@ func ProvideService(httpClientProvider httpclient.Provider) *Service {
 }
 
 type datasourceInfo struct {
-	HTTPClient *http.Client
-	URL        string
+	HTTPClient    *http.Client
+	URL           string
+	OAuthPassThru bool
+	KeepCookies   []string
 }
 
+type jsonData struct {
+	OAuthPassThru bool     `json:"oauthPassThru"`
+	KeepCookies   []string `json:"keepCookies"`
+}
+
+const (
+	queryTypeTraceID      = "traceId"
+	queryTypeSearch       = "search"
+	queryTypeNativeSearch = "nativeSearch"
+	queryTypeTraceQL      = "traceql"
+)
+
 type QueryModel struct {
-	TraceID string `json:"query"`
+	TraceID     string `json:"query"`
+	QueryType   string `json:"queryType"`
+	Search      string `json:"search"`
+	ServiceName string `json:"serviceName"`
+	SpanName    string `json:"spanName"`
+	MinDuration string `json:"minDuration"`
+	MaxDuration string `json:"maxDuration"`
+	Limit       int64  `json:"limit"`
 }
 
 func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
@@ -49,9 +71,18 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		var data jsonData
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &data); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
 		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
+			HTTPClient:    client,
+			URL:           settings.URL,
+			OAuthPassThru: data.OAuthPassThru,
+			KeepCookies:   data.KeepCookies,
 		}
 		return model, nil
 	}
@@ -59,28 +90,54 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 
 func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	result := backend.NewQueryDataResponse()
-	queryRes := backend.DataResponse{}
-	refID := req.Queries[0].RefID
-
-	model := &QueryModel{}
-	err := json.Unmarshal(req.Queries[0].JSON, model)
-	if err != nil {
-		return result, err
-	}
 
 	dsInfo, err := s.getDSInfo(req.PluginContext)
 	if err != nil {
 		return nil, err
 	}
 
-	request, err := s.createRequest(ctx, dsInfo, model.TraceID)
+	for _, query := range req.Queries {
+		model := &QueryModel{}
+		if err := json.Unmarshal(query.JSON, model); err != nil {
+			result.Responses[query.RefID] = backend.DataResponse{Error: fmt.Errorf("failed to unmarshal query model: %w", err)}
+			continue
+		}
+
+		var queryRes backend.DataResponse
+		switch model.QueryType {
+		case queryTypeSearch, queryTypeNativeSearch:
+			queryRes = s.search(ctx, dsInfo, query, model, searchParamsFromModel(model))
+		case queryTypeTraceQL:
+			queryRes = s.search(ctx, dsInfo, query, model, url.Values{"q": []string{model.TraceID}})
+		case "", queryTypeTraceID:
+			// TraceQL expressions may be typed directly into the trace ID field.
+			if isTraceID(model.TraceID) {
+				queryRes = s.getTrace(ctx, dsInfo, query.RefID, model.TraceID)
+			} else {
+				queryRes = s.search(ctx, dsInfo, query, model, url.Values{"q": []string{model.TraceID}})
+			}
+		default:
+			queryRes.Error = fmt.Errorf("unsupported query type: %s", model.QueryType)
+		}
+		result.Responses[query.RefID] = queryRes
+	}
+
+	return result, nil
+}
+
+func (s *Service) getTrace(ctx context.Context, dsInfo *datasourceInfo, refID string, traceID string) backend.DataResponse {
+	queryRes := backend.DataResponse{}
+
+	request, err := s.createRequest(ctx, dsInfo, traceID)
 	if err != nil {
-		return result, err
+		queryRes.Error = err
+		return queryRes
 	}
 
 	resp, err := dsInfo.HTTPClient.Do(request)
 	if err != nil {
-		return result, fmt.Errorf("failed get to tempo: %w", err)
+		queryRes.Error = fmt.Errorf("failed get to tempo: %w", err)
+		return queryRes
 	}
 
 	defer func() {
@@ -91,30 +148,29 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		queryRes.Error = err
+		return queryRes
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		queryRes.Error = fmt.Errorf("failed to get trace with id: %s Status: %s Body: %s", model.TraceID, resp.Status, string(body))
-		result.Responses[refID] = queryRes
-		return result, nil
+		queryRes.Error = fmt.Errorf("failed to get trace with id: %s Status: %s Body: %s", traceID, resp.Status, string(body))
+		return queryRes
 	}
 
 	otTrace, err := otlp.NewProtobufTracesUnmarshaler().UnmarshalTraces(body)
-
 	if err != nil {
-		return &backend.QueryDataResponse{}, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
+		queryRes.Error = fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
+		return queryRes
 	}
 
 	frame, err := TraceToFrame(otTrace)
 	if err != nil {
-		return &backend.QueryDataResponse{}, fmt.Errorf("failed to transform trace %v to data frame: %w", model.TraceID, err)
+		queryRes.Error = fmt.Errorf("failed to transform trace %v to data frame: %w", traceID, err)
+		return queryRes
 	}
 	frame.RefID = refID
-	frames := []*data.Frame{frame}
-	queryRes.Frames = frames
-	result.Responses[refID] = queryRes
-	return result, nil
+	queryRes.Frames = []*data.Frame{frame}
+	return queryRes
 }
 
 func (s *Service) createRequest(ctx context.Context, dsInfo *datasourceInfo, traceID string) (*http.Request, error) {
@@ -2,8 +2,13 @@ package tempo
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,3 +22,138 @@ func TestTempo(t *testing.T) {
 		assert.Equal(t, 1, len(req.Header))
 	})
 }
+
+type mockedCallResourceResponseSender struct {
+	Response *backend.CallResourceResponse
+}
+
+func (s *mockedCallResourceResponseSender) Send(resp *backend.CallResourceResponse) error {
+	s.Response = resp
+	return nil
+}
+
+func TestTempoSearch(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		switch r.URL.Path {
+		case "/api/search":
+			_, _ = w.Write([]byte(`{"traces": [
+				{"traceID": "1", "rootServiceName": "app", "rootTraceName": "GET /", "startTimeUnixNano": "1000000000", "durationMs": 10},
+				{"traceID": "2", "rootServiceName": "app", "rootTraceName": "POST /", "startTimeUnixNano": "2000000000", "durationMs": 20}
+			]}`))
+		case "/api/search/tags":
+			_, _ = w.Write([]byte(`{"tagNames": ["service.name"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	service := &Service{tlog: log.New("tempo-test")}
+	dsInfo := &datasourceInfo{HTTPClient: server.Client(), URL: server.URL}
+	timeRange := backend.TimeRange{From: time.Unix(100, 0), To: time.Unix(200, 0)}
+
+	t.Run("native search", func(t *testing.T) {
+		requests = nil
+		model := &QueryModel{QueryType: queryTypeNativeSearch, Search: `http.status_code=500`, ServiceName: "app", MinDuration: "1 ms"}
+		res := service.search(context.Background(), dsInfo, backend.DataQuery{RefID: "A", TimeRange: timeRange}, model, searchParamsFromModel(model))
+		require.NoError(t, res.Error)
+		require.Len(t, requests, 1)
+
+		query := requests[0].URL.Query()
+		assert.Equal(t, `http.status_code=500 service.name="app"`, query.Get("tags"))
+		assert.Equal(t, "1ms", query.Get("minDuration"))
+		assert.Equal(t, "20", query.Get("limit"))
+		assert.Equal(t, "100", query.Get("start"))
+		assert.Equal(t, "200", query.Get("end"))
+
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		assert.Equal(t, 2, frame.Rows())
+		// Most recent traces first.
+		assert.Equal(t, "2", frame.Fields[0].At(0))
+		assert.Equal(t, time.Unix(2, 0).UTC(), frame.Fields[3].At(0))
+		assert.Equal(t, int64(20), frame.Fields[4].At(0))
+	})
+
+	t.Run("traceql", func(t *testing.T) {
+		requests = nil
+		model := &QueryModel{TraceID: `{ .service.name = "app" }`, Limit: 5}
+		res := service.search(context.Background(), dsInfo, backend.DataQuery{RefID: "A"}, model, url.Values{"q": []string{model.TraceID}})
+		require.NoError(t, res.Error)
+		require.Len(t, requests, 1)
+		assert.Equal(t, model.TraceID, requests[0].URL.Query().Get("q"))
+		assert.Equal(t, "5", requests[0].URL.Query().Get("limit"))
+	})
+
+	t.Run("tag names resource", func(t *testing.T) {
+		sender := &mockedCallResourceResponseSender{}
+		req := &backend.CallResourceRequest{
+			Method: "GET",
+			URL:    "tags",
+			Headers: map[string][]string{
+				"Authorization": {"Basic grafana-credentials"},
+				"Cookie":        {"grafana_session=secret"},
+			},
+		}
+		require.NoError(t, service.callResource(context.Background(), req, sender, dsInfo))
+		assert.Equal(t, http.StatusOK, sender.Response.Status)
+		assert.JSONEq(t, `{"tagNames": ["service.name"]}`, string(sender.Response.Body))
+		assert.Empty(t, requests[len(requests)-1].Header.Get("Authorization"))
+		assert.Empty(t, requests[len(requests)-1].Header.Get("Cookie"))
+	})
+
+	t.Run("forwards the OAuth token and allowed cookies", func(t *testing.T) {
+		sender := &mockedCallResourceResponseSender{}
+		oauthDSInfo := &datasourceInfo{HTTPClient: server.Client(), URL: server.URL, OAuthPassThru: true, KeepCookies: []string{"tempo"}}
+		req := &backend.CallResourceRequest{
+			Method: "GET",
+			URL:    "tags",
+			Headers: map[string][]string{
+				"Authorization": {"Basic grafana-credentials", "Bearer oauth-token"},
+				"X-Id-Token":    {"id-token"},
+				"Cookie":        {"grafana_session=secret; tempo=value"},
+			},
+		}
+		require.NoError(t, service.callResource(context.Background(), req, sender, oauthDSInfo))
+		header := requests[len(requests)-1].Header
+		assert.Equal(t, "Bearer oauth-token", header.Get("Authorization"))
+		assert.Equal(t, "id-token", header.Get("X-ID-Token"))
+		assert.Equal(t, "tempo=value", header.Get("Cookie"))
+	})
+
+	t.Run("invalid resource", func(t *testing.T) {
+		sender := &mockedCallResourceResponseSender{}
+		err := service.callResource(context.Background(), &backend.CallResourceRequest{Method: "POST", URL: "tags"}, sender, dsInfo)
+		require.Error(t, err)
+
+		for _, resourceURL := range []string{
+			"traces/1",
+			"tags/../traces/1",
+			"tag/../../traces/1/values",
+			"tag/%2e%2e/values",
+			"tag/a%2F..%2F..%2Fecho/values",
+			"tag/service.name/values/extra",
+			"tag//values",
+			"http://attacker/tags",
+		} {
+			err := service.callResource(context.Background(), &backend.CallResourceRequest{Method: "GET", URL: resourceURL}, sender, dsInfo)
+			require.Error(t, err, resourceURL)
+		}
+	})
+
+	t.Run("tag values resource", func(t *testing.T) {
+		resourceURL, err := parseResourceURL("tag/service.name/values?q=1")
+		require.NoError(t, err)
+		assert.Equal(t, "tag/service.name/values?q=1", resourceURL)
+	})
+}
+
+func TestIsTraceID(t *testing.T) {
+	assert.True(t, isTraceID("abcdef0123456789"))
+	assert.True(t, isTraceID(" 1a "))
+	assert.False(t, isTraceID(`{ .service.name = "app" }`))
+	assert.False(t, isTraceID(""))
+}
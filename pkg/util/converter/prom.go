@@ -146,9 +146,15 @@ func readPrometheusData(iter *jsoniter.Iterator, opt Options) *backend.DataRespo
 					meta = &data.FrameMeta{}
 					rsp.Frames[0].Meta = meta
 				}
-				meta.Custom = map[string]interface{}{
-					"stats": v,
+				// keep the result type of the frame next to the stats
+				custom := map[string]interface{}{}
+				if existing, ok := meta.Custom.(map[string]string); ok {
+					for key, value := range existing {
+						custom[key] = value
+					}
 				}
+				custom["stats"] = v
+				meta.Custom = custom
 			}
 
 		default:
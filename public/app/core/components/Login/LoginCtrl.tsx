@@ -5,6 +5,8 @@ import { getBackendSrv } from '@grafana/runtime';
 import appEvents from 'app/core/app_events';
 import config from 'app/core/config';
 
+import { TwoFactorEnrollment } from './TwoFactorForm';
+
 const isOauthEnabled = () => {
   return !!config.oauth && Object.keys(config.oauth).length > 0;
 };
@@ -29,22 +31,35 @@ interface Props {
     isOauthEnabled: boolean;
     loginHint: string;
     passwordHint: string;
+    isTwoFactor: boolean;
+    isVerifying: boolean;
+    verifyTwoFactor: (code: string) => void;
+    afterTwoFactor: () => void;
+    twoFactorEnrollment?: TwoFactorEnrollment;
+    recoveryCodes?: string[];
   }) => JSX.Element;
 }
 
 interface State {
   isLoggingIn: boolean;
   isChangingPassword: boolean;
+  isTwoFactor: boolean;
+  isVerifying: boolean;
+  twoFactorEnrollment?: TwoFactorEnrollment;
+  recoveryCodes?: string[];
 }
 
 export class LoginCtrl extends PureComponent<Props, State> {
   result: any = {};
+  password = '';
 
   constructor(props: Props) {
     super(props);
     this.state = {
       isLoggingIn: false,
       isChangingPassword: false,
+      isTwoFactor: false,
+      isVerifying: false,
     };
 
     if (config.loginError) {
@@ -90,12 +105,12 @@ export class LoginCtrl extends PureComponent<Props, State> {
       .post('/login', formModel)
       .then((result: any) => {
         this.result = result;
-        if (formModel.password !== 'admin' || config.ldapEnabled || config.authProxyEnabled) {
-          this.toGrafana();
+        this.password = formModel.password;
+        if (result.twoFactorRequired) {
+          this.startTwoFactor(result.twoFactorEnrollment);
           return;
-        } else {
-          this.changeView();
         }
+        this.afterLogin();
       })
       .catch(() => {
         this.setState({
@@ -104,6 +119,53 @@ export class LoginCtrl extends PureComponent<Props, State> {
       });
   };
 
+  afterLogin = () => {
+    if (this.password !== 'admin' || config.ldapEnabled || config.authProxyEnabled) {
+      this.toGrafana();
+    } else {
+      this.changeView();
+    }
+  };
+
+  startTwoFactor = (enrollment: boolean) => {
+    if (!enrollment) {
+      this.setState({ isTwoFactor: true });
+      return;
+    }
+
+    getBackendSrv()
+      .post('/login/two-factor/enroll')
+      .then((twoFactorEnrollment: TwoFactorEnrollment) => {
+        this.setState({ isTwoFactor: true, twoFactorEnrollment });
+      })
+      .catch(() => {
+        this.setState({ isLoggingIn: false });
+      });
+  };
+
+  verifyTwoFactor = (code: string) => {
+    this.setState({ isVerifying: true });
+
+    getBackendSrv()
+      .post('/login/two-factor', { code })
+      .then((result: any) => {
+        this.result = result;
+        if (result.recoveryCodes) {
+          this.setState({ isVerifying: false, recoveryCodes: result.recoveryCodes });
+          return;
+        }
+        this.afterLogin();
+      })
+      .catch((err: any) => {
+        // the login has to start over once the challenge has expired or too many codes were invalid
+        if (err.status === 401 && err.data?.message !== 'Invalid two-factor authentication code') {
+          this.setState({ isTwoFactor: false, isLoggingIn: false, isVerifying: false, twoFactorEnrollment: undefined });
+          return;
+        }
+        this.setState({ isVerifying: false });
+      });
+  };
+
   changeView = () => {
     this.setState({
       isChangingPassword: true,
@@ -125,8 +187,9 @@ export class LoginCtrl extends PureComponent<Props, State> {
 
   render() {
     const { children } = this.props;
-    const { isLoggingIn, isChangingPassword } = this.state;
-    const { login, toGrafana, changePassword } = this;
+    const { isLoggingIn, isChangingPassword, isTwoFactor, isVerifying, twoFactorEnrollment, recoveryCodes } =
+      this.state;
+    const { login, toGrafana, changePassword, verifyTwoFactor, afterLogin } = this;
     const { loginHint, passwordHint, disableLoginForm, disableUserSignUp } = config;
 
     return (
@@ -142,6 +205,12 @@ export class LoginCtrl extends PureComponent<Props, State> {
           changePassword,
           skipPasswordChange: toGrafana,
           isChangingPassword,
+          isTwoFactor,
+          isVerifying,
+          verifyTwoFactor,
+          afterTwoFactor: afterLogin,
+          twoFactorEnrollment,
+          recoveryCodes,
         })}
       </>
     );
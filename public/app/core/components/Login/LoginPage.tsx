@@ -13,6 +13,7 @@ import LoginCtrl from './LoginCtrl';
 import { LoginForm } from './LoginForm';
 import { LoginLayout, InnerBox } from './LoginLayout';
 import { LoginServiceButtons } from './LoginServiceButtons';
+import { TwoFactorForm } from './TwoFactorForm';
 import { UserSignup } from './UserSignup';
 
 const forgottenPasswordStyles = css`
@@ -35,9 +36,15 @@ export const LoginPage: FC = () => {
           changePassword,
           skipPasswordChange,
           isChangingPassword,
+          isTwoFactor,
+          isVerifying,
+          verifyTwoFactor,
+          afterTwoFactor,
+          twoFactorEnrollment,
+          recoveryCodes,
         }) => (
           <>
-            {!isChangingPassword && (
+            {!isChangingPassword && !isTwoFactor && (
               <InnerBox>
                 {!disableLoginForm && (
                   <LoginForm
@@ -61,6 +68,17 @@ export const LoginPage: FC = () => {
                 {!disableUserSignUp && <UserSignup />}
               </InnerBox>
             )}
+            {isTwoFactor && !isChangingPassword && (
+              <InnerBox>
+                <TwoFactorForm
+                  onSubmit={verifyTwoFactor}
+                  onContinue={afterTwoFactor}
+                  isVerifying={isVerifying}
+                  enrollment={twoFactorEnrollment}
+                  recoveryCodes={recoveryCodes}
+                />
+              </InnerBox>
+            )}
             {isChangingPassword && (
               <InnerBox>
                 <ChangePassword onSubmit={changePassword} onSkip={() => skipPasswordChange()} />
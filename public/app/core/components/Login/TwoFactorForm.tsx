@@ -0,0 +1,99 @@
+import { css } from '@emotion/css';
+import React, { FC } from 'react';
+
+import { Alert, Button, ClipboardButton, Field, Form, Input, VerticalGroup } from '@grafana/ui';
+
+import { submitButton } from './LoginForm';
+
+export interface TwoFactorEnrollment {
+  secret: string;
+  uri: string;
+}
+
+interface Props {
+  onSubmit: (code: string) => void;
+  onContinue: () => void;
+  isVerifying: boolean;
+  enrollment?: TwoFactorEnrollment;
+  recoveryCodes?: string[];
+}
+
+interface CodeDTO {
+  code: string;
+}
+
+const wrapperStyles = css`
+  width: 100%;
+  padding-bottom: 16px;
+`;
+
+const codesStyles = css`
+  font-family: monospace;
+  column-count: 2;
+  margin-bottom: 16px;
+`;
+
+export const TwoFactorForm: FC<Props> = ({ onSubmit, onContinue, isVerifying, enrollment, recoveryCodes }) => {
+  if (recoveryCodes) {
+    return (
+      <div className={wrapperStyles}>
+        <Alert severity="info" title="Save your recovery codes">
+          Each code can be used once to log in if you lose access to your authenticator app. They will not be shown
+          again.
+        </Alert>
+        <div className={codesStyles}>
+          {recoveryCodes.map((code) => (
+            <div key={code}>{code}</div>
+          ))}
+        </div>
+        <VerticalGroup>
+          <ClipboardButton variant="secondary" getText={() => recoveryCodes.join('\n')}>
+            Copy recovery codes
+          </ClipboardButton>
+          <Button onClick={onContinue} className={submitButton}>
+            Continue
+          </Button>
+        </VerticalGroup>
+      </div>
+    );
+  }
+
+  return (
+    <div className={wrapperStyles}>
+      {enrollment && (
+        <>
+          <p>
+            Your organization requires two-factor authentication. Add your account to an authenticator app with{' '}
+            <a href={enrollment.uri}>this link</a> or by entering the key below, then enter the code it shows.
+          </p>
+          <Field label="Key">
+            <Input value={enrollment.secret} readOnly />
+          </Field>
+        </>
+      )}
+      <Form onSubmit={(data: CodeDTO) => onSubmit(data.code)}>
+        {({ register, errors }) => (
+          <>
+            <Field
+              label="Authentication code"
+              description={enrollment ? undefined : 'Enter the code of your authenticator app or a recovery code'}
+              invalid={!!errors.code}
+              error={errors.code?.message}
+            >
+              <Input
+                {...register('code', { required: 'Code is required' })}
+                id="two-factor-code"
+                autoFocus
+                autoComplete="one-time-code"
+                autoCapitalize="none"
+              />
+            </Field>
+            <Button type="submit" className={submitButton} disabled={isVerifying}>
+              {isVerifying ? 'Verifying...' : 'Verify'}
+            </Button>
+          </>
+        )}
+      </Form>
+    </div>
+  );
+};
@@ -46,6 +46,22 @@ export interface MultipleOutputterConfig {
   outputs: FrameOutputterConfig[];
 }
 export interface ManagedStreamOutputConfig {}
+export interface KafkaOutputConfig {
+  brokers: string[];
+  topic: string;
+  encoding?: string;
+}
+export interface NATSOutputConfig {
+  url: string;
+  subject: string;
+  encoding?: string;
+}
+export interface MQTTOutputConfig {
+  broker: string;
+  topic: string;
+  qos?: number;
+  encoding?: string;
+}
 export interface FrameOutputterConfig {
   type: Omit<keyof FrameOutputterConfig, 'type'>;
   managedStream?: ManagedStreamOutputConfig;
@@ -56,6 +72,9 @@ export interface FrameOutputterConfig {
   remoteWrite?: RemoteWriteOutputConfig;
   loki?: LokiOutputConfig;
   changeLog?: ChangeLogOutputConfig;
+  kafka?: KafkaOutputConfig;
+  nats?: NATSOutputConfig;
+  mqtt?: MQTTOutputConfig;
 }
 export interface MultipleFrameProcessorConfig {
   processors: FrameProcessorConfig[];
@@ -66,11 +85,27 @@ export interface KeepFieldsFrameProcessorConfig {
 export interface DropFieldsFrameProcessorConfig {
   fieldNames: string[];
 }
+export interface WindowAggregation {
+  fieldName: string;
+  function: string;
+  as?: string;
+}
+export interface WindowAggregateFrameProcessorConfig {
+  windowMilliseconds: number;
+  aggregations: WindowAggregation[];
+  groupBy?: string[];
+}
+export interface RateLimitFrameProcessorConfig {
+  maxFrames: number;
+  intervalMilliseconds: number;
+}
 export interface FrameProcessorConfig {
   type: Omit<keyof FrameProcessorConfig, 'type'>;
   dropFields?: DropFieldsFrameProcessorConfig;
   keepFields?: KeepFieldsFrameProcessorConfig;
   multiple?: MultipleFrameProcessorConfig;
+  windowAggregate?: WindowAggregateFrameProcessorConfig;
+  rateLimit?: RateLimitFrameProcessorConfig;
 }
 export interface JsonFrameConverterConfig {}
 export interface AutoInfluxConverterConfig {
@@ -114,9 +149,29 @@ export interface DataOutputterConfig {
 export interface MultipleSubscriberConfig {
   subscribers: SubscriberConfig[];
 }
+export interface KafkaSubscriberConfig {
+  brokers: string[];
+  topic: string;
+  groupId?: string;
+  converter?: ConverterConfig;
+}
+export interface NATSSubscriberConfig {
+  url: string;
+  subject: string;
+  converter?: ConverterConfig;
+}
+export interface MQTTSubscriberConfig {
+  broker: string;
+  topic: string;
+  qos?: number;
+  converter?: ConverterConfig;
+}
 export interface SubscriberConfig {
   type: Omit<keyof SubscriberConfig, 'type'>;
   multiple?: MultipleSubscriberConfig;
+  kafka?: KafkaSubscriberConfig;
+  nats?: NATSSubscriberConfig;
+  mqtt?: MQTTSubscriberConfig;
 }
 export interface ChannelRuleSettings {
   auth?: ChannelAuthConfig;
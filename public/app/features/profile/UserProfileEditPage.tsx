@@ -5,12 +5,14 @@ import { useMount } from 'react-use';
 import { VerticalGroup } from '@grafana/ui';
 import { Page } from 'app/core/components/Page/Page';
 import SharedPreferences from 'app/core/components/SharedPreferences/SharedPreferences';
+import config from 'app/core/config';
 import { StoreState } from 'app/types';
 
 import UserOrganizations from './UserOrganizations';
 import UserProfileEditForm from './UserProfileEditForm';
 import UserSessions from './UserSessions';
 import { UserTeams } from './UserTeams';
+import { UserTwoFactor } from './UserTwoFactor';
 import { changeUserOrg, initUserProfilePage, revokeUserSession, updateUserProfile } from './state/actions';
 
 export interface OwnProps {}
@@ -66,6 +68,7 @@ export function UserProfileEditPage({
           <UserTeams isLoading={teamsAreLoading} teams={teams} />
           <UserOrganizations isLoading={orgsAreLoading} setUserOrg={changeUserOrg} orgs={orgs} user={user} />
           <UserSessions isLoading={sessionsAreLoading} revokeUserSession={revokeUserSession} sessions={sessions} />
+          {config.twoFactorEnabled && !user?.isExternal && <UserTwoFactor />}
         </VerticalGroup>
       </Page.Contents>
     </Page>
@@ -0,0 +1,121 @@
+import React, { useState } from 'react';
+import { useAsyncFn, useMount } from 'react-use';
+
+import { getBackendSrv } from '@grafana/runtime';
+import { Button, ClipboardButton, Field, HorizontalGroup, Input, LoadingPlaceholder } from '@grafana/ui';
+import { TwoFactorEnrollment } from 'app/core/components/Login/TwoFactorForm';
+
+interface TwoFactorStatus {
+  enabled: boolean;
+  required: boolean;
+  recoveryCodesRemaining: number;
+}
+
+type Action = 'confirm' | 'recovery-codes' | 'disable';
+
+export const UserTwoFactor = () => {
+  const [enrollment, setEnrollment] = useState<TwoFactorEnrollment>();
+  const [action, setAction] = useState<Action>();
+  const [code, setCode] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>();
+
+  const [{ value: status, loading }, loadStatus] = useAsyncFn(
+    (): Promise<TwoFactorStatus> => getBackendSrv().get('/api/user/two-factor'),
+    []
+  );
+  useMount(() => loadStatus());
+
+  const startEnrollment = async () => {
+    setEnrollment(await getBackendSrv().post('/api/user/two-factor/enroll'));
+    setAction('confirm');
+  };
+
+  const submit = async () => {
+    const result = await getBackendSrv().post(`/api/user/two-factor/${action}`, { code });
+    setRecoveryCodes(result.recoveryCodes);
+    setAction(undefined);
+    setEnrollment(undefined);
+    setCode('');
+    loadStatus();
+  };
+
+  if (loading && !status) {
+    return <LoadingPlaceholder text="Loading two-factor authentication..." />;
+  }
+  if (!status) {
+    return null;
+  }
+
+  return (
+    <div>
+      <h3 className="page-sub-heading">Two-factor authentication</h3>
+      <div className="gf-form-group">
+        <p>
+          {status.enabled
+            ? `Enabled, ${status.recoveryCodesRemaining} recovery codes remaining.`
+            : 'Protect your account with a code of an authenticator app when logging in with your password.'}
+          {status.required && ' Required by your organization.'}
+        </p>
+
+        {recoveryCodes && (
+          <>
+            <p>Save your recovery codes, each one can be used once to log in. They will not be shown again.</p>
+            <pre>{recoveryCodes.join('\n')}</pre>
+            <ClipboardButton variant="secondary" getText={() => recoveryCodes.join('\n')}>
+              Copy recovery codes
+            </ClipboardButton>
+          </>
+        )}
+
+        {enrollment && (
+          <>
+            <p>
+              Add your account to an authenticator app with <a href={enrollment.uri}>this link</a> or by entering the
+              key below.
+            </p>
+            <Field label="Key">
+              <Input value={enrollment.secret} readOnly width={50} />
+            </Field>
+          </>
+        )}
+
+        {action ? (
+          <HorizontalGroup align="flex-end">
+            <Field label="Authentication code">
+              <Input
+                value={code}
+                onChange={(e) => setCode(e.currentTarget.value)}
+                autoComplete="one-time-code"
+                width={20}
+              />
+            </Field>
+            <Field label="">
+              <Button onClick={submit} disabled={!code}>
+                {action === 'disable' ? 'Disable' : 'Verify'}
+              </Button>
+            </Field>
+            <Field label="">
+              <Button variant="secondary" onClick={() => setAction(undefined)}>
+                Cancel
+              </Button>
+            </Field>
+          </HorizontalGroup>
+        ) : (
+          <HorizontalGroup>
+            {!status.enabled && <Button onClick={startEnrollment}>Enable</Button>}
+            {status.enabled && (
+              <Button variant="secondary" onClick={() => setAction('recovery-codes')}>
+                New recovery codes
+              </Button>
+            )}
+            {status.enabled && !status.required && (
+              <Button variant="destructive" onClick={() => setAction('disable')}>
+                Disable
+              </Button>
+            )}
+          </HorizontalGroup>
+        )}
+      </div>
+    </div>
+  );
+};
@@ -3,17 +3,15 @@ import React, { PureComponent } from 'react';
 import { connect, ConnectedProps } from 'react-redux';
 
 import { NavModelItem } from '@grafana/data';
-import { featureEnabled } from '@grafana/runtime';
 import { Themeable2, withTheme2 } from '@grafana/ui';
 import { Page } from 'app/core/components/Page/Page';
-import { UpgradeBox } from 'app/core/components/Upgrade/UpgradeBox';
 import config from 'app/core/config';
 import { GrafanaRouteComponentProps } from 'app/core/navigation/types';
 import { getNavModel } from 'app/core/selectors/navModel';
 import { contextSrv } from 'app/core/services/context_srv';
 import { AccessControlAction, StoreState } from 'app/types';
 
-import TeamGroupSync, { TeamSyncUpgradeContent } from './TeamGroupSync';
+import TeamGroupSync from './TeamGroupSync';
 import TeamMembers from './TeamMembers';
 import TeamPermissions from './TeamPermissions';
 import TeamSettings from './TeamSettings';
@@ -29,7 +27,6 @@ interface TeamPageRouteParams {
 export interface OwnProps extends GrafanaRouteComponentProps<TeamPageRouteParams>, Themeable2 {}
 
 interface State {
-  isSyncEnabled: boolean;
   isLoading: boolean;
 }
 
@@ -80,7 +77,6 @@ export class TeamPages extends PureComponent<Props, State> {
 
     this.state = {
       isLoading: false,
-      isSyncEnabled: featureEnabled('teamsync'),
     };
   }
 
@@ -135,7 +131,6 @@ export class TeamPages extends PureComponent<Props, State> {
   };
 
   renderPage(isSignedInUserTeamAdmin: boolean): React.ReactNode {
-    const { isSyncEnabled } = this.state;
     const { members, team } = this.props;
     const currentPage = this.getCurrentPage();
 
@@ -160,23 +155,12 @@ export class TeamPages extends PureComponent<Props, State> {
         if (contextSrv.accessControlEnabled()) {
           return <TeamPermissions team={team!} />;
         } else {
-          return <TeamMembers syncEnabled={isSyncEnabled} members={members} />;
+          return <TeamMembers syncEnabled={true} members={members} />;
         }
       case PageTypes.Settings:
         return canReadTeam && <TeamSettings team={team!} />;
       case PageTypes.GroupSync:
-        if (isSyncEnabled) {
-          if (canReadTeamPermissions) {
-            return <TeamGroupSync isReadOnly={!canWriteTeamPermissions} />;
-          }
-        } else if (config.featureToggles.featureHighlights) {
-          return (
-            <>
-              <UpgradeBox featureName={'team sync'} featureId={'team-sync'} />
-              <TeamSyncUpgradeContent />
-            </>
-          );
-        }
+        return canReadTeamPermissions && <TeamGroupSync isReadOnly={!canWriteTeamPermissions} />;
     }
 
     return null;
@@ -1,9 +1,5 @@
 import { NavModelItem, NavModel } from '@grafana/data';
-import { featureEnabled } from '@grafana/runtime';
-import { ProBadge } from 'app/core/components/Upgrade/ProBadge';
-import config from 'app/core/config';
 import { contextSrv } from 'app/core/services/context_srv';
-import { highlightTrial } from 'app/features/admin/utils';
 import { AccessControlAction, Team, TeamPermissionLevel } from 'app/types';
 
 const loadingTeam = {
@@ -62,23 +58,10 @@ export function buildNavModel(team: Team): NavModelItem {
 
   const isLoadingTeam = team === loadingTeam;
 
-  if (highlightTrial()) {
-    teamGroupSync.tabSuffix = () =>
-      ProBadge({ experimentId: isLoadingTeam ? '' : 'feature-highlights-team-sync-badge', eventVariant: 'trial' });
-  }
-
-  // With both Legacy and RBAC the tab is protected being featureEnabled
   // While team is loading we leave the teamsync tab
   // With RBAC the External Group Sync tab is available when user has ActionTeamsPermissionsRead for this team
-  if (featureEnabled('teamsync')) {
-    if (isLoadingTeam || contextSrv.hasPermissionInMetadata(AccessControlAction.ActionTeamsPermissionsRead, team)) {
-      navModel.children!.push(teamGroupSync);
-    }
-  } else if (config.featureToggles.featureHighlights) {
-    navModel.children!.push({
-      ...teamGroupSync,
-      tabSuffix: () => ProBadge({ experimentId: isLoadingTeam ? '' : 'feature-highlights-team-sync-badge' }),
-    });
+  if (isLoadingTeam || contextSrv.hasPermissionInMetadata(AccessControlAction.ActionTeamsPermissionsRead, team)) {
+    navModel.children!.push(teamGroupSync);
   }
 
   return navModel;
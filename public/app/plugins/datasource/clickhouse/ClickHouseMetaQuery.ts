@@ -0,0 +1,23 @@
+function quoteLiteral(value: string) {
+  return "'" + value.replace(/\\/g, '\\\\').replace(/'/g, "\\'") + "'";
+}
+
+function database(dataset?: string) {
+  return dataset ? quoteLiteral(dataset) : 'currentDatabase()';
+}
+
+export function showDatabases() {
+  return `SELECT name FROM system.databases
+    WHERE name NOT IN ('system', 'INFORMATION_SCHEMA', 'information_schema')
+    ORDER BY name`;
+}
+
+export function showTables(dataset?: string) {
+  return `SELECT name FROM system.tables WHERE database = ${database(dataset)} ORDER BY name`;
+}
+
+export function getSchema(dataset?: string, table?: string) {
+  return `SELECT name AS column, type FROM system.columns
+    WHERE database = ${database(dataset)} AND table = ${quoteLiteral(table ?? '')}
+    ORDER BY position`;
+}
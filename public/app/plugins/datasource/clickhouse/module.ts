@@ -0,0 +1,11 @@
+import { DataSourcePlugin } from '@grafana/data';
+import { SqlQueryEditor } from 'app/features/plugins/sql/components/QueryEditor';
+import { SQLQuery } from 'app/features/plugins/sql/types';
+
+import { ConfigurationEditor } from './configuration/ConfigurationEditor';
+import { ClickHouseDatasource } from './datasource';
+import { ClickHouseOptions } from './types';
+
+export const plugin = new DataSourcePlugin<ClickHouseDatasource, SQLQuery, ClickHouseOptions>(ClickHouseDatasource)
+  .setQueryEditor(SqlQueryEditor)
+  .setConfigEditor(ConfigurationEditor);
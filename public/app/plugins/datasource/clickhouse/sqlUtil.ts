@@ -0,0 +1,43 @@
+import { RAQBFieldTypes } from 'app/features/plugins/sql/types';
+
+// baseType strips the Nullable and LowCardinality wrappers and the type
+// parameters, e.g. Nullable(DateTime64(3)) is DateTime64.
+function baseType(type: string): string {
+  let t = type;
+  const wrapper = /^(?:Nullable|LowCardinality)\((.*)\)$/;
+  while (wrapper.test(t)) {
+    t = t.replace(wrapper, '$1');
+  }
+  return t.replace(/\(.*\)$/, '');
+}
+
+export function getIcon(type: string): string | undefined {
+  switch (getRAQBType(type)) {
+    case 'datetime':
+    case 'date':
+      return 'clock-nine';
+    case 'boolean':
+      return 'toggle-off';
+    case 'number':
+      return 'calculator-alt';
+    default:
+      return 'text';
+  }
+}
+
+export function getRAQBType(type: string): RAQBFieldTypes {
+  const t = baseType(type);
+  if (t.startsWith('DateTime')) {
+    return 'datetime';
+  }
+  if (t.startsWith('Date')) {
+    return 'date';
+  }
+  if (t === 'Bool') {
+    return 'boolean';
+  }
+  if (/^U?Int\d+$/.test(t) || /^Float\d+$/.test(t) || t.startsWith('Decimal')) {
+    return 'number';
+  }
+  return 'text';
+}
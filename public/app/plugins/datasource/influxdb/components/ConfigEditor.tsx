@@ -37,11 +37,15 @@ const versions = [
 export type Props = DataSourcePluginOptionsEditorProps<InfluxOptions>;
 type State = {
   maxSeries: string | undefined;
+  maxRows: string | undefined;
+  maxBytes: string | undefined;
 };
 
 export class ConfigEditor extends PureComponent<Props, State> {
   state = {
     maxSeries: '',
+    maxRows: '',
+    maxBytes: '',
   };
 
   htmlPrefix: string;
@@ -49,6 +53,8 @@ export class ConfigEditor extends PureComponent<Props, State> {
   constructor(props: Props) {
     super(props);
     this.state.maxSeries = props.options.jsonData.maxSeries?.toString() || '';
+    this.state.maxRows = props.options.jsonData.maxRows?.toString() || '';
+    this.state.maxBytes = props.options.jsonData.maxBytes?.toString() || '';
     this.htmlPrefix = uniqueId('influxdb-config');
   }
 
@@ -340,6 +346,56 @@ export class ConfigEditor extends PureComponent<Props, State> {
               />
             </InlineField>
           </div>
+          {options.jsonData.version === InfluxVersion.Flux && (
+            <>
+              <div className="gf-form-inline">
+                <InlineField
+                  labelWidth={20}
+                  label="Max rows"
+                  tooltip="Limit the number of rows that Grafana will read from a Flux query result. Results with more rows are truncated. Defaults to 1000000."
+                >
+                  <Input
+                    placeholder="1000000"
+                    type="number"
+                    className="width-10"
+                    value={this.state.maxRows}
+                    onChange={(event) => {
+                      this.setState({ maxRows: event.currentTarget.value });
+                      const val = parseInt(event.currentTarget.value, 10);
+                      updateDatasourcePluginJsonDataOption(
+                        this.props,
+                        'maxRows',
+                        Number.isFinite(val) ? val : undefined
+                      );
+                    }}
+                  />
+                </InlineField>
+              </div>
+              <div className="gf-form-inline">
+                <InlineField
+                  labelWidth={20}
+                  label="Max bytes"
+                  tooltip="Limit the size in bytes of a Flux query response that Grafana will read. Larger results are truncated. Defaults to 268435456 (256 MiB)."
+                >
+                  <Input
+                    placeholder="268435456"
+                    type="number"
+                    className="width-10"
+                    value={this.state.maxBytes}
+                    onChange={(event) => {
+                      this.setState({ maxBytes: event.currentTarget.value });
+                      const val = parseInt(event.currentTarget.value, 10);
+                      updateDatasourcePluginJsonDataOption(
+                        this.props,
+                        'maxBytes',
+                        Number.isFinite(val) ? val : undefined
+                      );
+                    }}
+                  />
+                </InlineField>
+              </div>
+            </>
+          )}
         </div>
       </>
     );
@@ -125,6 +125,37 @@ export const PromSettings = (props: Props) => {
             />
           </div>
         </div>
+        <div className="gf-form">
+          <InlineField
+            labelWidth={28}
+            label="Query statistics"
+            tooltip="Request statistics like the number of processed samples and the evaluation timings from Prometheus. They are shown in the query inspector."
+          >
+            <InlineSwitch
+              value={options.jsonData.queryStats ?? false}
+              onChange={onUpdateDatasourceJsonDataOptionChecked(props, 'queryStats')}
+            />
+          </InlineField>
+        </div>
+        <div className="gf-form-inline">
+          <div className="gf-form">
+            <FormField
+              label="Query split interval"
+              labelWidth={14}
+              tooltip="Range queries over a longer time range are split into queries of this interval, which are run in parallel. Leave empty to never split queries."
+              inputEl={
+                <Input
+                  className="width-6"
+                  value={options.jsonData.querySplitInterval}
+                  onChange={onChangeHandler('querySplitInterval', options, onOptionsChange)}
+                  spellCheck={false}
+                  placeholder="1d"
+                  validationEvents={promSettingsValidationEvents}
+                />
+              }
+            />
+          </div>
+        </div>
       </div>
       <ExemplarsSettings
         options={options.jsonData.exemplarTraceIdDestinations}
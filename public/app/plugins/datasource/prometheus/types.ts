@@ -29,6 +29,8 @@ export interface PromOptions extends DataSourceJsonData {
   directUrl?: string;
   customQueryParameters?: string;
   disableMetricsLookup?: boolean;
+  queryStats?: boolean;
+  querySplitInterval?: string;
   exemplarTraceIdDestinations?: ExemplarTraceIdDestination[];
 }
 
@@ -0,0 +1,13 @@
+// SQLite has a single schema for the opened database file.
+export const SCHEMA_NAME = 'main';
+
+export function showTables() {
+  return `SELECT name FROM sqlite_master
+    WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+    ORDER BY name`;
+}
+
+export function getSchema(table?: string) {
+  return `SELECT name AS "column", type AS "type"
+    FROM pragma_table_info('${(table ?? '').replace(/'/g, "''")}')`;
+}
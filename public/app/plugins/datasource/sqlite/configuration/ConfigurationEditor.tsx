@@ -0,0 +1,84 @@
+import React, { SyntheticEvent } from 'react';
+
+import {
+  DataSourcePluginOptionsEditorProps,
+  onUpdateDatasourceJsonDataOption,
+  updateDatasourcePluginJsonDataOption,
+} from '@grafana/data';
+import { Alert, FieldSet, InlineField, Input } from '@grafana/ui';
+import { ConnectionLimits } from 'app/features/plugins/sql/components/configuration/ConnectionLimits';
+
+import { SQLiteOptions } from '../types';
+
+export const ConfigurationEditor = (props: DataSourcePluginOptionsEditorProps<SQLiteOptions>) => {
+  const { options, onOptionsChange } = props;
+  const jsonData = options.jsonData;
+
+  const onDSOptionChanged = (property: keyof SQLiteOptions) => {
+    return (event: SyntheticEvent<HTMLInputElement>) => {
+      onOptionsChange({ ...options, ...{ [property]: event.currentTarget.value } });
+    };
+  };
+
+  const mediumWidth = 20;
+  const shortWidth = 15;
+  const longWidth = 40;
+
+  return (
+    <>
+      <FieldSet label="SQLite Connection" width={400}>
+        <InlineField
+          tooltip={
+            <span>
+              Path to the database file on the Grafana server. The file has to be in one of the directories listed in
+              the <code>allowed_paths</code> setting of the <code>[sqlite]</code> configuration section.
+            </span>
+          }
+          labelWidth={shortWidth}
+          label="Path"
+        >
+          <Input
+            width={longWidth}
+            name="database"
+            value={options.database || ''}
+            placeholder="/var/lib/data/metrics.db"
+            onChange={onDSOptionChanged('database')}
+          ></Input>
+        </InlineField>
+      </FieldSet>
+
+      <ConnectionLimits
+        labelWidth={shortWidth}
+        jsonData={jsonData}
+        onPropertyChanged={(property, value) => {
+          updateDatasourcePluginJsonDataOption(props, property, value);
+        }}
+      ></ConnectionLimits>
+
+      <FieldSet label="SQLite details">
+        <InlineField
+          tooltip={
+            <span>
+              A lower limit for the auto group by time interval. Recommended to be set to write frequency, for example
+              <code>1m</code> if your data is written every minute.
+            </span>
+          }
+          labelWidth={mediumWidth}
+          label="Min time interval"
+        >
+          <Input
+            placeholder="1m"
+            value={jsonData.timeInterval || ''}
+            onChange={onUpdateDatasourceJsonDataOption(props, 'timeInterval')}
+          ></Input>
+        </InlineField>
+      </FieldSet>
+
+      <Alert title="File access" severity="info">
+        Database files are opened read-only. The SQLite data source is disabled until a Grafana server administrator
+        configures the directories which contain the database files with the <code>allowed_paths</code> setting of the{' '}
+        <code>[sqlite]</code> configuration section.
+      </Alert>
+    </>
+  );
+};
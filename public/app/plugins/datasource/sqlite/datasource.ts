@@ -0,0 +1,85 @@
+import { DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
+import { TemplateSrv } from '@grafana/runtime';
+import { AGGREGATE_FNS } from 'app/features/plugins/sql/constants';
+import { SqlDatasource } from 'app/features/plugins/sql/datasource/SqlDatasource';
+import {
+  DB,
+  LanguageCompletionProvider,
+  ResponseParser,
+  SQLQuery,
+  SQLSelectableValue,
+} from 'app/features/plugins/sql/types';
+
+import { getSchema, SCHEMA_NAME, showTables } from './SQLiteMetaQuery';
+import { SQLiteQueryModel } from './SQLiteQueryModel';
+import { SQLiteResponseParser } from './response_parser';
+import { fetchColumns, fetchTables, getSqlCompletionProvider } from './sqlCompletionProvider';
+import { getIcon, getRAQBType } from './sqlUtil';
+import { SQLiteOptions } from './types';
+
+export class SQLiteDatasource extends SqlDatasource {
+  completionProvider: LanguageCompletionProvider | undefined = undefined;
+  constructor(instanceSettings: DataSourceInstanceSettings<SQLiteOptions>) {
+    super(instanceSettings);
+  }
+
+  getQueryModel(target?: SQLQuery, templateSrv?: TemplateSrv, scopedVars?: ScopedVars): SQLiteQueryModel {
+    return new SQLiteQueryModel(target, templateSrv, scopedVars);
+  }
+
+  getResponseParser(): ResponseParser {
+    return new SQLiteResponseParser();
+  }
+
+  async fetchTables(): Promise<string[]> {
+    const tables = await this.runSql<{ name: string[] }>(showTables(), { refId: 'tables' });
+    return tables.fields.name.values.toArray().flat();
+  }
+
+  async fetchFields(query: SQLQuery): Promise<SQLSelectableValue[]> {
+    const schema = await this.runSql<{ column: string; type: string }>(getSchema(query.table), { refId: 'columns' });
+    const result: SQLSelectableValue[] = [];
+    for (let i = 0; i < schema.length; i++) {
+      const column = schema.fields.column.values.get(i);
+      const type = schema.fields.type.values.get(i);
+      result.push({ label: column, value: column, type, icon: getIcon(type), raqbFieldType: getRAQBType(type) });
+    }
+    return result;
+  }
+
+  getSqlCompletionProvider(db: DB): LanguageCompletionProvider {
+    if (this.completionProvider !== undefined) {
+      return this.completionProvider;
+    }
+    const args = {
+      getColumns: { current: (query: SQLQuery) => fetchColumns(db, query) },
+      getTables: { current: (dataset?: string) => fetchTables(db, dataset) },
+    };
+    this.completionProvider = getSqlCompletionProvider(args);
+    return this.completionProvider;
+  }
+
+  getDB(): DB {
+    return {
+      init: () => Promise.resolve(true),
+      datasets: () => Promise.resolve([SCHEMA_NAME]),
+      tables: () => this.fetchTables(),
+      getSqlCompletionProvider: () => this.getSqlCompletionProvider(this.db),
+      fields: async (query: SQLQuery) => {
+        if (!query?.table) {
+          return [];
+        }
+        return this.fetchFields(query);
+      },
+      validateQuery: (query) =>
+        Promise.resolve({ isError: false, isValid: true, query, error: '', rawSql: query.rawSql }),
+      dsID: () => this.id,
+      dispose: (dsID?: string) => {},
+      lookup: async () => {
+        const tables = await this.fetchTables();
+        return tables.map((t) => ({ name: t, completion: t }));
+      },
+      functions: async () => AGGREGATE_FNS,
+    };
+  }
+}
@@ -0,0 +1,63 @@
+import { AGGREGATE_FNS, OPERATORS } from 'app/features/plugins/sql/constants';
+import {
+  ColumnDefinition,
+  DB,
+  LanguageCompletionProvider,
+  LinkedToken,
+  SQLQuery,
+  TableDefinition,
+  TokenType,
+} from 'app/features/plugins/sql/types';
+
+interface CompletionProviderGetterArgs {
+  getColumns: React.MutableRefObject<(t: SQLQuery) => Promise<ColumnDefinition[]>>;
+  getTables: React.MutableRefObject<(d?: string) => Promise<TableDefinition[]>>;
+}
+
+export const getSqlCompletionProvider: (args: CompletionProviderGetterArgs) => LanguageCompletionProvider =
+  ({ getColumns, getTables }) =>
+  () => ({
+    triggerCharacters: ['.', ' ', '$', ',', '(', "'"],
+    tables: {
+      resolve: async () => {
+        return await getTables.current();
+      },
+      parseName: (token: LinkedToken) => {
+        let processedToken = token;
+        let tablePath = processedToken.value;
+
+        while (processedToken.next && processedToken.next.type !== TokenType.Whitespace) {
+          tablePath += processedToken.next.value;
+          processedToken = processedToken.next;
+        }
+
+        const tableName = tablePath.split('.').pop();
+
+        return tableName || tablePath;
+      },
+    },
+
+    columns: {
+      resolve: async (t: string) => {
+        return await getColumns.current({ table: t, refId: 'A' });
+      },
+    },
+    supportedFunctions: () => AGGREGATE_FNS,
+    supportedOperators: () => OPERATORS,
+  });
+
+export async function fetchColumns(db: DB, q: SQLQuery) {
+  const cols = await db.fields(q);
+  if (cols.length > 0) {
+    return cols.map((c) => {
+      return { name: c.value, type: c.value, description: c.value };
+    });
+  } else {
+    return [];
+  }
+}
+
+export async function fetchTables(db: DB, dataset?: string) {
+  const tables = await db.lookup(dataset);
+  return tables;
+}
@@ -0,0 +1,35 @@
+import { RAQBFieldTypes } from 'app/features/plugins/sql/types';
+
+// SQLite columns have a type affinity derived from the declared type name,
+// see https://www.sqlite.org/datatype3.html#determination_of_column_affinity.
+export function getIcon(type: string): string | undefined {
+  switch (getRAQBType(type)) {
+    case 'datetime':
+      return 'clock-nine';
+    case 'number':
+      return 'calculator-alt';
+    default:
+      return 'text';
+  }
+}
+
+export function getRAQBType(type: string): RAQBFieldTypes {
+  const declared = type.toUpperCase();
+  if (declared.includes('DATE') || declared.includes('TIME')) {
+    return 'datetime';
+  }
+  if (declared.includes('CHAR') || declared.includes('CLOB') || declared.includes('TEXT')) {
+    return 'text';
+  }
+  if (
+    declared.includes('INT') ||
+    declared.includes('REAL') ||
+    declared.includes('FLOA') ||
+    declared.includes('DOUB') ||
+    declared.includes('NUMERIC') ||
+    declared.includes('DECIMAL')
+  ) {
+    return 'number';
+  }
+  return 'text';
+}